@@ -1,26 +1,86 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"syslog-analyzer/backup"
+	"syslog-analyzer/chargeback"
+	"syslog-analyzer/cmdb"
 	"syslog-analyzer/config"
+	"syslog-analyzer/counters"
+	"syslog-analyzer/destinations"
+	"syslog-analyzer/digest"
+	"syslog-analyzer/enrichment"
+	"syslog-analyzer/forecast"
+	"syslog-analyzer/exporter"
+	"syslog-analyzer/filtering"
+	"syslog-analyzer/fips"
+	"syslog-analyzer/gitops"
+	"syslog-analyzer/history"
+	"syslog-analyzer/ingest"
+	"syslog-analyzer/lifecycle"
+	"syslog-analyzer/logging"
 	"syslog-analyzer/models"
+	"syslog-analyzer/mtls"
+	"syslog-analyzer/notifications"
+	"syslog-analyzer/quota"
+	"syslog-analyzer/recovery"
+	"syslog-analyzer/snmp"
 	"syslog-analyzer/syslog"
+	"syslog-analyzer/tenancy"
+	"syslog-analyzer/tuning"
 	"syslog-analyzer/web"
 )
 
+// logBufferLines is how many operational log lines the log viewer can show
+const logBufferLines = 2000
+
+// maxSourceNameLength bounds source names, which end up in file names
+const maxSourceNameLength = 64
+
+// sourceNamePattern is the character set allowed in source names
+var sourceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
 // Application represents the main syslog analyzer application
 type Application struct {
+	ctx              context.Context // Parent of every source and listener; cancelled last on Stop
+	cancel           context.CancelFunc
 	configManager    *config.Manager
 	sources          map[string]*syslog.SyslogSource
 	sourceMutex      sync.RWMutex
+	resourceMutex    sync.Mutex // Serializes source and destination changes so preconditions hold until applied
 	webServer        *web.Server
 	sharedListeners  map[string]*syslog.SharedListener // map[port:protocol] -> SharedListener
 	listenerMutex    sync.RWMutex
 	globalSettings   models.GlobalSettings
+	remoteWriter     *exporter.RemoteWriter
+	snmpAgent        *snmp.Agent
+	syslogWriter     *logging.SyslogWriter
+	eventLogWriter   *logging.EventLogWriter
+	digestScheduler  *digest.Scheduler
+	alertEngine      *notifications.Engine
+	quotaManager     *quota.Manager
+	chargebackLedger *chargeback.Ledger
+	forecastHistory  *forecast.History
+	historyStore     *history.Store
+	lookupManager    *enrichment.Manager
+	counterStore     *counters.Store
+	gitopsSyncer     *gitops.Syncer
+	backupScheduler  *backup.Scheduler
+	cmdbSyncer       *cmdb.Syncer
+	ingestManager    *ingest.Manager
+	gitopsError      string // Why GitOps sync could not start
+	logBuffer        *logging.LogBuffer
 }
 
 // NewApplication creates a new application instance
@@ -30,17 +90,116 @@ func NewApplication(configFile string) *Application {
 		sources:         make(map[string]*syslog.SyslogSource),
 		webServer:       web.NewServer(),
 		sharedListeners: make(map[string]*syslog.SharedListener),
+		quotaManager:    quota.NewManager(),
+		lookupManager:   enrichment.NewManager(),
+		ingestManager:   ingest.NewManager(),
+		logBuffer:       logging.NewLogBuffer(logBufferLines),
 	}
+	app.ctx, app.cancel = context.WithCancel(context.Background())
+	
+	// Keep recent operational log lines for the dashboard's log viewer
+	app.setLogOutput()
 	
 	// Set up web server handlers
 	app.webServer.SetHandlers(
 		app.getMetrics,
 		app.getSources,
 		app.addSource,
-		app.updateSource,
+		app.putSource,
 		app.deleteSource,
 		app.validateSource,
 	)
+	app.webServer.SetResourceHandlers(app.getSource, app.putDestination, app.deleteDestination)
+	app.webServer.SetGitOpsHandlers(app.getGitOpsStatus, app.syncGitOps)
+	app.webServer.SetBackupHandlers(app.getBackupStatus, app.backupNow, app.restoreBackup)
+	app.webServer.SetExportHandlers(app.exportConfig, app.diffConfig)
+	app.webServer.SetConfigModeHandler(app.GetConfigMode)
+	app.webServer.SetIngestTokenHandlers(
+		app.getIngestTokens,
+		app.issueIngestToken,
+		app.rotateIngestToken,
+		app.revokeIngestToken,
+	)
+	app.webServer.SetCredentialHandlers(app.rotateCredentials, app.getCredentialAudit)
+	app.webServer.SetLogHandler(app.logBuffer.Subscribe)
+	app.webServer.SetSimulationHandler(app.setSimulationMode)
+	app.webServer.SetPauseHandler(app.setDeliveryPaused)
+	app.webServer.SetAlertHandlers(
+		app.getAlerts,
+		app.acknowledgeAlert,
+		app.addSilence,
+		app.removeSilence,
+	)
+	app.webServer.SetTenancyHandlers(
+		app.isMultiTenant,
+		app.resolveToken,
+		app.getTenants,
+		app.addTenant,
+		app.deleteTenant,
+	)
+	app.webServer.SetQuotaHandlers(
+		app.getQuotas,
+		app.updateQuotas,
+	)
+	app.webServer.SetChargebackHandlers(
+		app.getChargeback,
+		app.getChargebackMonths,
+	)
+	app.webServer.SetFilterImpactHandler(app.estimateFilterImpact)
+	app.webServer.SetForecastHandlers(
+		app.getForecast,
+		app.getComparison,
+	)
+	app.webServer.SetUnclaimedHandlers(
+		app.getUnclaimedSenders,
+		app.forgetUnclaimedSender,
+	)
+	app.webServer.SetListenerHandlers(
+		app.getListeners,
+		app.updateListener,
+		app.getConflicts,
+		app.getServicePorts,
+	)
+	app.webServer.SetLookupHandlers(
+		app.getLookups,
+		app.uploadLookup,
+		app.reloadLookup,
+		app.deleteLookup,
+	)
+	app.webServer.SetSettingsHandlers(
+		app.getSettings,
+		app.updateSettings,
+	)
+	app.webServer.SetFilterSetHandlers(
+		app.getFilterSets,
+		app.putFilterSet,
+		app.deleteFilterSet,
+	)
+	app.webServer.SetOnboardingHandlers(
+		app.getSourceTemplates,
+		app.putSourceTemplate,
+		app.deleteSourceTemplate,
+		app.onboard,
+	)
+	app.webServer.SetCMDBHandlers(app.getCMDBStatus, app.syncCMDB)
+	app.webServer.SetClassificationHandlers(
+		app.getClassificationRules,
+		app.updateClassificationRules,
+	)
+	app.webServer.SetReconciliationHandler(app.getReconciliation)
+	app.webServer.SetHistoryHandler(app.getHistory)
+	app.webServer.SetMetricsHistoryHandlers(
+		app.queryMetricsHistory,
+		app.getHistoryStorage,
+		app.exportMetricsHistory,
+		app.importMetricsHistory,
+		app.getEPSHeatmaps,
+	)
+	app.webServer.SetCounterHandlers(
+		app.resetCounters,
+		app.getCounterResets,
+	)
+	app.webServer.SetDiagnosticsHandler(app.getDiagnostics)
 	
 	return app
 }
@@ -53,9 +212,133 @@ func (app *Application) LoadConfig() error {
 	}
 	
 	app.globalSettings = config.GlobalSettings
+	
+	// Tune the runtime before sources and services start their goroutines
+	if err := tuning.Apply(config.GlobalSettings.Runtime); err != nil {
+		log.Printf("✗ Ignoring runtime settings: %v", err)
+	} else {
+		status := tuning.Status(config.GlobalSettings.Runtime)
+		log.Printf("✓ Runtime: GOMAXPROCS %d, GC percent %d, memory limit %s", status.MaxProcs, status.GCPercent, memoryLimitText(status.MemoryLimit))
+	}
+	if err := syslog.SetQueueType(config.GlobalSettings.QueueType); err != nil {
+		log.Printf("✗ Ignoring queue type: %v", err)
+	}
+	log.Printf("✓ Source queues: %s", syslog.GetQueueType())
+	if err := syslog.SetBatching(config.GlobalSettings.Batching, config.GlobalSettings.BatchSize); err != nil {
+		log.Printf("✗ Ignoring batching settings: %v", err)
+	}
+	if err := destinations.SetEncoder(config.GlobalSettings.JSONEncoder); err != nil {
+		log.Printf("✗ Ignoring JSON encoder: %v", err)
+	}
+	if err := syslog.SetPauseBuffer(config.GlobalSettings.PauseBuffer); err != nil {
+		log.Printf("✗ Ignoring pause buffer settings: %v", err)
+	}
+	if err := destinations.SetClassificationRules(config.ClassificationRules); err != nil {
+		log.Printf("✗ Ignoring classification rules: %v", err)
+	}
+	if err := destinations.SetStorageRoots(config.GlobalSettings.StorageRoots); err != nil {
+		log.Printf("✗ Ignoring %v", err)
+	}
+	if roots := destinations.GetStorageRoots(); len(roots) > 0 {
+		log.Printf("✓ Destination storage, window buffers and dedup ledgers restricted to: %s", strings.Join(roots, ", "))
+	}
+	if config.GlobalSettings.FIPSMode {
+		fips.Enable()
+	}
+	if fips.Enabled() {
+		log.Printf("✓ FIPS mode: TLS 1.2 with approved cipher suites only")
+	}
+	if batching := syslog.GetBatching(); batching.Mode == syslog.BatchingAdaptive {
+		log.Printf("✓ Adaptive batching: %d-%d events, %d-%d ms", batching.MinBatchSize, batching.MaxBatchSize, batching.MinFlushMs, batching.MaxFlushMs)
+	}
 	return nil
 }
 
+// memoryLimitText describes a soft memory limit for the log
+func memoryLimitText(limit int64) string {
+	if limit == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d MiB", limit>>20)
+}
+
+// getDiagnostics reports the runtime settings in effect
+func (app *Application) getDiagnostics() models.RuntimeStatus {
+	status := tuning.Status(app.globalSettings.Runtime)
+	status.QueueType = syslog.GetQueueType()
+	status.JSONEncoder = destinations.GetEncoder()
+	status.FIPSMode = fips.Enabled()
+	status.Lifecycle = app.getLifecycle()
+	return status
+}
+
+// getLifecycle accounts the pipeline goroutines to the running sources and
+// listeners and reports what is left of stopped ones as leaks
+func (app *Application) getLifecycle() models.LifecycleStatus {
+	var status models.LifecycleStatus
+	live := make(map[string]bool)
+	goroutines := lifecycle.Active()
+	
+	app.sourceMutex.RLock()
+	for name, source := range app.sources {
+		live[source.Owner()] = true
+		status.Sources = append(status.Sources, models.SourceLifecycle{
+			Name:       name,
+			Owner:      source.Owner(),
+			Running:    source.IsRunning(),
+			Listeners:  source.GetListenerCount(),
+			Goroutines: goroutines[source.Owner()],
+		})
+	}
+	app.sourceMutex.RUnlock()
+	
+	app.listenerMutex.RLock()
+	for _, sharedListener := range app.sharedListeners {
+		live[sharedListener.Owner()] = true
+		listenerStatus := models.ListenerLifecycle{
+			Protocol:   sharedListener.Protocol(),
+			Port:       sharedListener.Port(),
+			Owner:      sharedListener.Owner(),
+			Sources:    sharedListener.GetSourceCount(),
+			Goroutines: goroutines[sharedListener.Owner()],
+		}
+		status.Listeners = append(status.Listeners, listenerStatus)
+		if listenerStatus.Sources == 0 {
+			status.Leaks = append(status.Leaks, models.LifecycleLeak{
+				Owner:      listenerStatus.Owner,
+				Goroutines: listenerStatus.Goroutines,
+				Reason:     "listener has no sources",
+			})
+		}
+	}
+	app.listenerMutex.RUnlock()
+	
+	sort.Slice(status.Sources, func(i, j int) bool { return status.Sources[i].Name < status.Sources[j].Name })
+	sort.Slice(status.Listeners, func(i, j int) bool {
+		if status.Listeners[i].Port != status.Listeners[j].Port {
+			return status.Listeners[i].Port < status.Listeners[j].Port
+		}
+		return status.Listeners[i].Protocol < status.Listeners[j].Protocol
+	})
+	
+	// Goroutines of instances no longer registered outlived their Stop
+	var orphans []models.LifecycleLeak
+	for owner, count := range goroutines {
+		status.Goroutines += count
+		if !live[owner] && owner != lifecycle.Unowned {
+			orphans = append(orphans, models.LifecycleLeak{
+				Owner:      owner,
+				Goroutines: count,
+				Reason:     "goroutines of a stopped instance",
+			})
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].Owner < orphans[j].Owner })
+	status.Leaks = append(status.Leaks, orphans...)
+	status.GoroutinesStarted, status.GoroutinesExited = lifecycle.Totals()
+	return status
+}
+
 // SaveConfig saves current configuration to file
 func (app *Application) SaveConfig() error {
 	return app.configManager.SaveConfig()
@@ -76,9 +359,27 @@ func (app *Application) StartSources() error {
 		batchSize = 1000
 	}
 	
+	app.quotaManager.SetQuotas(config.Quotas)
+	app.lookupManager.SetTables(config.LookupTables)
+	app.ingestManager.SetTokens(config.IngestTokens)
+	
+	// Refuse sources conflicting with an earlier one upfront rather than
+	// letting them fail or steal each other's traffic half way through startup
+	reserved := reservedPorts(config.GlobalSettings)
+	var accepted []models.SourceConfig
 	for _, sourceConfig := range config.Sources {
-		source := syslog.NewSyslogSource(sourceConfig, batchSize)
-		if err := source.Start(app); err != nil {
+		if conflicts := syslog.FindConflicts(append(accepted, sourceConfig), reserved); len(conflicts) > 0 {
+			log.Printf("✗ Source '%s' not started: %s", sourceConfig.Name, conflicts[0].Reason)
+			continue
+		}
+		accepted = append(accepted, sourceConfig)
+		if sourceConfig.Disabled {
+			log.Printf("ℹ Source '%s' is disabled", sourceConfig.Name)
+			continue
+		}
+		
+		source := syslog.NewSyslogSource(runningSource(config, sourceConfig), batchSize)
+		if err := source.Start(app.ctx, app); err != nil {
 			log.Printf("✗ Failed to start source %s: %v", sourceConfig.Name, err)
 			continue
 		}
@@ -90,38 +391,171 @@ func (app *Application) StartSources() error {
 	return nil
 }
 
+// StartServices starts optional background services enabled in the global settings
+func (app *Application) StartServices() error {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
+	// Start self-logging first so the other services' startup is forwarded too
+	if config.GlobalSettings.SelfLogging.Enabled {
+		writer, err := logging.NewSyslogWriter(config.GlobalSettings.SelfLogging)
+		if err != nil {
+			log.Printf("✗ Failed to start self-logging: %v", err)
+		} else {
+			app.syslogWriter = writer
+			app.setLogOutput()
+			log.Printf("✓ Forwarding operational log to %s (%s)", config.GlobalSettings.SelfLogging.Address, config.GlobalSettings.SelfLogging.Protocol)
+		}
+	}
+	
+	// Critical operational messages also go to the Windows Event Log
+	if config.GlobalSettings.EventLog.Enabled {
+		writer, err := logging.NewEventLogWriter(config.GlobalSettings.EventLog)
+		if err != nil {
+			log.Printf("✗ Failed to start Windows Event Log output: %v", err)
+		} else {
+			app.eventLogWriter = writer
+			app.setLogOutput()
+			log.Printf("✓ Writing critical operational messages to the Windows Event Log as %s", config.GlobalSettings.EventLog.Source)
+		}
+	}
+	
+	// Start the counter store before anything samples the cumulative counters
+	store, err := counters.NewStore(config.GlobalSettings.CountersFile)
+	if err != nil {
+		log.Printf("✗ Failed to load cumulative counters: %v", err)
+	} else {
+		app.counterStore = store
+		store.Start()
+	}
+	
+	if config.GlobalSettings.RemoteWrite.Enabled {
+		app.remoteWriter = exporter.NewRemoteWriter(config.GlobalSettings.RemoteWrite, app.getMetrics)
+		if err := app.remoteWriter.Start(); err != nil {
+			log.Printf("✗ Failed to start remote-write exporter: %v", err)
+			app.remoteWriter = nil
+		}
+	}
+	
+	if config.GlobalSettings.SNMP.Enabled {
+		agent, err := snmp.NewAgent(config.GlobalSettings.SNMP, app.getMetrics)
+		if err == nil {
+			err = agent.Start()
+		}
+		if err != nil {
+			log.Printf("✗ Failed to start SNMP agent: %v", err)
+		} else {
+			app.snmpAgent = agent
+		}
+	}
+	
+	// Start the forecast history before the digest that reports it
+	forecastHistory, err := forecast.NewHistory(config.GlobalSettings.Forecast, app.getMetrics)
+	if err != nil {
+		log.Printf("✗ Failed to start forecast history: %v", err)
+	} else {
+		app.forecastHistory = forecastHistory
+		forecastHistory.Start()
+	}
+	
+	metricsStore, err := history.NewStore(config.GlobalSettings.History, app.getMetrics)
+	if err != nil {
+		log.Printf("✗ Failed to start metrics history: %v", err)
+	} else {
+		app.historyStore = metricsStore
+		metricsStore.Start()
+	}
+	
+	if config.GlobalSettings.Digest.Enabled {
+		scheduler, err := digest.NewScheduler(config.GlobalSettings.Digest, app.getMetrics)
+		if err != nil {
+			log.Printf("✗ Failed to start daily digest: %v", err)
+		} else {
+			if app.forecastHistory != nil {
+				scheduler.SetForecastFunc(func() (models.ForecastReport, error) {
+					return app.getForecast("")
+				})
+			}
+			app.digestScheduler = scheduler
+			scheduler.Start()
+		}
+	}
+	
+	// The chargeback ledger always records usage; Enabled only controls the e-mail
+	ledger, err := chargeback.NewLedger(config.GlobalSettings.Chargeback, app.getMetrics)
+	if err != nil {
+		log.Printf("✗ Failed to start chargeback ledger: %v", err)
+	} else {
+		app.chargebackLedger = ledger
+		ledger.Start()
+	}
+	
+	if config.GlobalSettings.Notifications.Enabled {
+		engine, err := notifications.NewEngine(config.GlobalSettings.Notifications, app.getMetrics)
+		if err != nil {
+			log.Printf("✗ Failed to start alert engine: %v", err)
+		} else {
+			app.alertEngine = engine
+			engine.Start()
+		}
+	}
+	
+	if config.GlobalSettings.Backup.Enabled {
+		scheduler, err := backup.NewScheduler(config.GlobalSettings.Backup, app.snapshotConfig, app.stateFiles())
+		if err != nil {
+			log.Printf("✗ Failed to start configuration backups: %v", err)
+		} else {
+			app.backupScheduler = scheduler
+			scheduler.Start()
+		}
+	}
+	
+	// The configuration stays read-only while GitOps is enabled, even if the
+	// syncer cannot start, so local edits never diverge from the repository
+	if config.GlobalSettings.GitOps.Enabled {
+		syncer, err := gitops.NewSyncer(config.GlobalSettings.GitOps, app.applyGitOps)
+		if err != nil {
+			log.Printf("✗ Failed to start GitOps sync: %v", err)
+			app.gitopsError = err.Error()
+		} else {
+			app.gitopsSyncer = syncer
+			syncer.Start()
+		}
+	}
+	
+	if config.GlobalSettings.CMDB.Enabled {
+		syncer, err := cmdb.NewSyncer(config.GlobalSettings.CMDB, app.reconcileInventory)
+		if err != nil {
+			log.Printf("✗ Failed to start CMDB sync: %v", err)
+		} else {
+			app.cmdbSyncer = syncer
+			syncer.Start()
+		}
+	}
+	
+	return nil
+}
+
 // GetGlobalMetrics calculates and returns global metrics
 func (app *Application) GetGlobalMetrics() models.GlobalMetrics {
 	app.sourceMutex.RLock()
 	defer app.sourceMutex.RUnlock()
 	
-	global := models.GlobalMetrics{
-		TotalSources: len(app.sources),
-	}
-	
+	var sourceMetrics []models.SourceMetrics
 	for _, source := range app.sources {
-		if source == nil {
-			continue
-		}
-		
-		metrics := source.GetMetrics()
-		
-		global.TotalRealTimeEPS += metrics.RealTimeEPS
-		global.TotalRealTimeGBps += metrics.RealTimeGBps
-		global.TotalLogsIngested += metrics.TotalLogsIngested
-		global.TotalHourlyAvgLogs += metrics.HourlyAvgLogs
-		global.TotalHourlyAvgGB += metrics.HourlyAvgGB
-		global.TotalDailyAvgLogs += metrics.DailyAvgLogs
-		global.TotalDailyAvgGB += metrics.DailyAvgGB
-		global.TotalQueueDepth += metrics.QueueDepth
-		global.TotalProcessedCount += metrics.ProcessedCount
-		global.TotalSentCount += metrics.SentCount
-		
-		if metrics.IsActive {
-			global.ActiveSources++
+		if source != nil {
+			sourceMetrics = append(sourceMetrics, source.GetMetrics())
 		}
 	}
 	
+	app.applyCounters(sourceMetrics)
+	app.applyQuotaUsage(sourceMetrics)
+	global := models.SummarizeMetrics(sourceMetrics)
+	global.Quotas = app.quotaManager.GetStatus()
+	global.TotalPanics = recovery.Total()
+	
 	return global
 }
 
@@ -132,6 +566,19 @@ func (app *Application) StartWebServer() error {
 		return fmt.Errorf("no configuration loaded")
 	}
 	
+	compression := config.GlobalSettings.Compression
+	if compression.Level < 0 || compression.Level > 9 {
+		log.Printf("✗ Ignoring compression level %d (expected 1-9)", compression.Level)
+		compression.Level = 0
+	}
+	app.webServer.SetCompression(compression)
+	app.webServer.SetSessions(config.GlobalSettings.Sessions)
+	if ms := config.GlobalSettings.SlowRequestMs; ms < 0 {
+		log.Printf("✗ Ignoring slow_request_ms %d (expected a positive number of milliseconds)", ms)
+	} else {
+		app.webServer.SetSlowRequestThreshold(time.Duration(ms) * time.Millisecond)
+	}
+	
 	return app.webServer.Start(config.GlobalSettings.WebPort)
 }
 
@@ -139,17 +586,89 @@ func (app *Application) StartWebServer() error {
 func (app *Application) Stop() {
 	log.Println("✓ Application shutting down...")
 	
-	// Stop all sources
+	// Stop syncing first so no sync restarts sources being stopped
+	if app.gitopsSyncer != nil {
+		app.gitopsSyncer.Stop()
+	}
+	if app.cmdbSyncer != nil {
+		app.cmdbSyncer.Stop()
+	}
+	
+	// Stop intake first so the queues only shrink while sources drain
+	app.listenerMutex.Lock()
+	for _, sharedListener := range app.sharedListeners {
+		sharedListener.Stop()
+	}
+	app.listenerMutex.Unlock()
+	
+	// Drain every source and close its destinations
 	app.sourceMutex.Lock()
 	for _, source := range app.sources {
 		source.Stop(app)
+		app.retireCounters(source)
 	}
 	app.sourceMutex.Unlock()
 	
+	// Stop background services
+	if app.remoteWriter != nil {
+		app.remoteWriter.Stop()
+	}
+	if app.snmpAgent != nil {
+		app.snmpAgent.Stop()
+	}
+	if app.digestScheduler != nil {
+		app.digestScheduler.Stop()
+	}
+	if app.backupScheduler != nil {
+		app.backupScheduler.Stop()
+	}
+	if app.alertEngine != nil {
+		app.alertEngine.Stop()
+	}
+	if app.chargebackLedger != nil {
+		app.chargebackLedger.Stop()
+	}
+	if app.forecastHistory != nil {
+		app.forecastHistory.Stop()
+	}
+	if app.historyStore != nil {
+		app.historyStore.Stop()
+	}
+	if app.counterStore != nil {
+		app.counterStore.Stop()
+	}
+	
 	// Stop web server
 	app.webServer.Stop()
 	
+	// Release anything still derived from the application context
+	app.cancel()
+	
 	log.Println("✓ Application stopped")
+	
+	// Stop self-logging last so shutdown messages are forwarded
+	syslogWriter, eventLogWriter := app.syslogWriter, app.eventLogWriter
+	app.syslogWriter, app.eventLogWriter = nil, nil
+	app.setLogOutput()
+	if syslogWriter != nil {
+		syslogWriter.Close()
+	}
+	if eventLogWriter != nil {
+		eventLogWriter.Close()
+	}
+}
+
+// setLogOutput sends the operational log to stderr, the dashboard's log
+// viewer and whichever forwarders are running
+func (app *Application) setLogOutput() {
+	writers := []io.Writer{os.Stderr, app.logBuffer}
+	if app.syslogWriter != nil {
+		writers = append(writers, app.syslogWriter)
+	}
+	if app.eventLogWriter != nil {
+		writers = append(writers, app.eventLogWriter)
+	}
+	log.SetOutput(io.MultiWriter(writers...))
 }
 
 // GetWebPort returns the web server port
@@ -161,6 +680,12 @@ func (app *Application) GetWebPort() int {
 	return config.GlobalSettings.WebPort
 }
 
+// GetConfigMode returns where the configuration comes from and whether it
+// is read-only
+func (app *Application) GetConfigMode() models.ConfigMode {
+	return app.configManager.GetMode()
+}
+
 // GetSourceCount returns the number of configured sources
 func (app *Application) GetSourceCount() int {
 	config := app.configManager.GetConfig()
@@ -170,148 +695,566 @@ func (app *Application) GetSourceCount() int {
 	return len(config.Sources)
 }
 
-// GetSharedListener gets or creates a shared listener for the given protocol and port
-func (app *Application) GetSharedListener(protocol string, port int) (*syslog.SharedListener, error) {
+// AcquireSharedListener registers a source with the shared listener for the
+// given protocol and port, creating the listener if needed. Registering under
+// listenerMutex keeps a concurrent release from stopping the listener between
+// lookup and registration.
+func (app *Application) AcquireSharedListener(protocol string, port int, source *syslog.SyslogSource) (*syslog.SharedListener, error) {
 	listenerKey := fmt.Sprintf("%d:%s", port, strings.ToUpper(protocol))
 	
 	app.listenerMutex.Lock()
 	defer app.listenerMutex.Unlock()
 	
 	if sharedListener, exists := app.sharedListeners[listenerKey]; exists {
+		sharedListener.AddSource(source)
 		return sharedListener, nil
 	}
 	
 	// Create new shared listener
 	sharedListener := syslog.NewSharedListener(strings.ToUpper(protocol), port)
+	if settings := app.findListenerConfig(protocol, port); settings != nil {
+		if err := sharedListener.SetConfig(*settings); err != nil {
+			log.Printf("⚠ Ignoring invalid settings for %s listener on port %d: %v", protocol, port, err)
+		}
+	}
 	
-	if err := sharedListener.Start(); err != nil {
+	if err := sharedListener.Start(app.ctx); err != nil {
 		return nil, fmt.Errorf("failed to start shared listener on %s port %d: %v", protocol, port, err)
 	}
 	
+	sharedListener.AddSource(source)
 	app.sharedListeners[listenerKey] = sharedListener
 	log.Printf("✓ Started %s listener on port %d", protocol, port)
 	
 	return sharedListener, nil
 }
 
-// RemoveSharedListener removes a shared listener
-func (app *Application) RemoveSharedListener(protocol string, port int) {
-	listenerKey := fmt.Sprintf("%d:%s", port, strings.ToUpper(protocol))
-	
+// ReleaseSharedListener unregisters a source from a shared listener and
+// stops the listener once no source uses it. Only this listener is removed,
+// never a newer one registered under the same key.
+func (app *Application) ReleaseSharedListener(sharedListener *syslog.SharedListener, source *syslog.SyslogSource) {
 	app.listenerMutex.Lock()
 	defer app.listenerMutex.Unlock()
 	
-	if sharedListener, exists := app.sharedListeners[listenerKey]; exists {
-		sharedListener.Stop()
-		delete(app.sharedListeners, listenerKey)
+	sharedListener.RemoveSource(source)
+	if sharedListener.GetSourceCount() > 0 {
+		return
+	}
+	
+	sharedListener.Stop()
+	for listenerKey, existing := range app.sharedListeners {
+		if existing == sharedListener {
+			delete(app.sharedListeners, listenerKey)
+			log.Printf("✓ Stopped %s listener on port %d", sharedListener.Protocol(), sharedListener.Port())
+		}
+	}
+}
+
+// findListenerConfig returns the configured settings for a listener, or nil
+func (app *Application) findListenerConfig(protocol string, port int) *models.ListenerConfig {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return nil
+	}
+	for i := range config.Listeners {
+		if strings.EqualFold(config.Listeners[i].Protocol, protocol) && config.Listeners[i].Port == port {
+			return &config.Listeners[i]
+		}
 	}
+	return nil
 }
 
 // Web server handler functions
 
-// getMetrics returns current metrics for the web server
-func (app *Application) getMetrics() ([]models.SourceMetrics, models.GlobalMetrics) {
-	app.sourceMutex.RLock()
-	defer app.sourceMutex.RUnlock()
+// getListeners returns the status of all running listeners
+func (app *Application) getListeners() []models.ListenerStatus {
+	app.listenerMutex.RLock()
+	defer app.listenerMutex.RUnlock()
 	
-	var sourceMetrics []models.SourceMetrics
-	for _, source := range app.sources {
-		if source != nil {
-			sourceMetrics = append(sourceMetrics, source.GetMetrics())
+	listeners := []models.ListenerStatus{}
+	for _, sharedListener := range app.sharedListeners {
+		listeners = append(listeners, sharedListener.GetStatus())
+	}
+	
+	sort.Slice(listeners, func(i, j int) bool {
+		if listeners[i].Port != listeners[j].Port {
+			return listeners[i].Port < listeners[j].Port
+		}
+		return listeners[i].Protocol < listeners[j].Protocol
+	})
+	return listeners
+}
+
+// getServicePorts lists every port the analyzer binds and the configured
+// ports it does not, so deployment tooling knows which ports to expose
+func (app *Application) getServicePorts() []models.ServicePort {
+	ports := []models.ServicePort{}
+	bound := make(map[string]bool)
+	
+	app.listenerMutex.RLock()
+	for listenerKey, sharedListener := range app.sharedListeners {
+		state := "up"
+		if sharedListener.IsDown() {
+			state = "down"
 		}
+		ports = append(ports, models.ServicePort{
+			Name:     servicePortName(sharedListener.Protocol(), sharedListener.Port()),
+			Port:     sharedListener.Port(),
+			Protocol: syslog.SocketTransport(sharedListener.Protocol()),
+			Service:  "syslog",
+			State:    state,
+			Sources:  sharedListener.GetSourceNames(),
+		})
+		bound[listenerKey] = true
 	}
+	app.listenerMutex.RUnlock()
 	
-	// Sort sources by name for consistent ordering
-	for i := 0; i < len(sourceMetrics)-1; i++ {
-		for j := i + 1; j < len(sourceMetrics); j++ {
-			if sourceMetrics[i].Name > sourceMetrics[j].Name {
-				sourceMetrics[i], sourceMetrics[j] = sourceMetrics[j], sourceMetrics[i]
+	// Configured syslog ports without a listener: conflicting sources or failed binds
+	config := app.configManager.GetConfig()
+	if config != nil {
+		conflicts := make(map[string]string)
+		for _, conflict := range syslog.FindConflicts(config.Sources, reservedPorts(config.GlobalSettings)) {
+			conflicts[conflict.Source] = conflict.Reason
+		}
+		
+		missing := make(map[string]*models.ServicePort)
+		var order []string
+		for _, source := range config.Sources {
+			for _, transport := range syslog.Transports(source.Protocol) {
+				listenerKey := fmt.Sprintf("%d:%s", source.Port, transport)
+				if bound[listenerKey] {
+					continue
+				}
+				port, exists := missing[listenerKey]
+				if !exists {
+					port = &models.ServicePort{
+						Name:     servicePortName(transport, source.Port),
+						Port:     source.Port,
+						Protocol: syslog.SocketTransport(transport),
+						Service:  "syslog",
+						State:    "not_bound",
+						Reason:   "listener is not running",
+					}
+					missing[listenerKey] = port
+					order = append(order, listenerKey)
+				}
+				port.Sources = append(port.Sources, source.Name)
+				if reason, conflicting := conflicts[source.Name]; conflicting {
+					port.Reason = reason
+				}
 			}
 		}
+		for _, listenerKey := range order {
+			ports = append(ports, *missing[listenerKey])
+		}
+	}
+	
+	// The analyzer's own ports; the web port only changes with a restart
+	ports = append(ports, models.ServicePort{Name: "web", Port: app.globalSettings.WebPort, Protocol: "TCP", Service: "web", State: "up"})
+	if config != nil && config.GlobalSettings.WebPort != app.globalSettings.WebPort {
+		ports = append(ports, models.ServicePort{
+			Name:     "web-pending",
+			Port:     config.GlobalSettings.WebPort,
+			Protocol: "TCP",
+			Service:  "web",
+			State:    "pending_restart",
+			Reason:   "the web port changes on the next restart",
+		})
+	}
+	if app.globalSettings.SNMP.Enabled {
+		port := models.ServicePort{Name: "snmp", Port: app.globalSettings.SNMP.Port, Protocol: "UDP", Service: "snmp", State: "up"}
+		if port.Port == 0 {
+			port.Port = snmp.DefaultPort
+		}
+		if app.snmpAgent == nil {
+			port.State = "not_bound"
+			port.Reason = "SNMP agent is not running"
+		}
+		ports = append(ports, port)
 	}
 	
-	return sourceMetrics, app.GetGlobalMetrics()
+	sort.SliceStable(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		return ports[i].Protocol < ports[j].Protocol
+	})
+	return ports
 }
 
-// getSources returns all configured sources
-func (app *Application) getSources() []models.SourceConfig {
-	config := app.configManager.GetConfig()
-	if config == nil {
-		return []models.SourceConfig{}
-	}
-	return config.Sources
+// servicePortName names a syslog port the way Kubernetes accepts: at most
+// 15 lowercase characters
+func servicePortName(protocol string, port int) string {
+	return fmt.Sprintf("%s-%d", strings.ToLower(protocol), port)
 }
 
-// addSource adds a new source
-func (app *Application) addSource(newSource models.SourceConfig) error {
+// updateListener stores listener settings and applies them to the running listener
+func (app *Application) updateListener(settings models.ListenerConfig) error {
 	config := app.configManager.GetConfig()
 	if config == nil {
 		return fmt.Errorf("no configuration loaded")
 	}
 	
-	// Add source to configuration
-	config.Sources = append(config.Sources, newSource)
-	app.configManager.UpdateConfig(config)
-	
-	// Start the source
-	batchSize := config.GlobalSettings.BatchSize
-	if batchSize == 0 {
-		batchSize = 1000
+	settings.Protocol = strings.ToUpper(settings.Protocol)
+	if settings.Protocol != "UDP" && settings.Protocol != "TCP" && settings.Protocol != models.ProtocolRELP {
+		return fmt.Errorf("protocol must be UDP, TCP or %s", models.ProtocolRELP)
 	}
-	
-	source := syslog.NewSyslogSource(newSource, batchSize)
-	if err := source.Start(app); err != nil {
+	if settings.Port < 1 || settings.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	if _, err := syslog.ParseIPList(settings.AllowList); err != nil {
+		return fmt.Errorf("allow list: %v", err)
+	}
+	if _, err := syslog.ParseIPList(settings.DenyList); err != nil {
+		return fmt.Errorf("deny list: %v", err)
+	}
+	if err := syslog.ValidateWorkerSettings(settings); err != nil {
 		return err
 	}
+	if settings.TLS != nil {
+		if settings.Protocol == "UDP" {
+			return fmt.Errorf("tls only applies to TCP and RELP listeners")
+		}
+		if _, err := mtls.Load(*settings.TLS); err != nil {
+			return err
+		}
+	}
 	
-	app.sourceMutex.Lock()
-	app.sources[newSource.Name] = source
-	app.sourceMutex.Unlock()
+	if existing := app.findListenerConfig(settings.Protocol, settings.Port); existing != nil {
+		*existing = settings
+	} else {
+		config.Listeners = append(config.Listeners, settings)
+	}
+	app.configManager.UpdateConfig(config)
+	
+	listenerKey := fmt.Sprintf("%d:%s", settings.Port, settings.Protocol)
+	app.listenerMutex.RLock()
+	if sharedListener, exists := app.sharedListeners[listenerKey]; exists {
+		sharedListener.SetConfig(settings)
+	}
+	app.listenerMutex.RUnlock()
 	
-	// Save configuration
 	if err := app.SaveConfig(); err != nil {
 		log.Printf("⚠ Warning: Failed to save config: %v", err)
 	}
 	
+	log.Printf("✓ Updated %s listener on port %d (strict: %v)", settings.Protocol, settings.Port, settings.StrictMode)
 	return nil
 }
 
-// updateSource updates an existing source
-func (app *Application) updateSource(oldName string, updatedSource models.SourceConfig) error {
-	config := app.configManager.GetConfig()
-	if config == nil {
-		return fmt.Errorf("no configuration loaded")
+// getUnclaimedSenders returns senders without a matching source across all listeners
+func (app *Application) getUnclaimedSenders() []models.UnclaimedSender {
+	app.listenerMutex.RLock()
+	defer app.listenerMutex.RUnlock()
+	
+	senders := []models.UnclaimedSender{}
+	for _, sharedListener := range app.sharedListeners {
+		senders = append(senders, sharedListener.GetUnclaimedSenders()...)
 	}
 	
-	// Stop existing source
-	app.sourceMutex.Lock()
-	if existingSource, exists := app.sources[oldName]; exists {
-		existingSource.Stop(app)
-		delete(app.sources, oldName)
+	sort.Slice(senders, func(i, j int) bool {
+		return senders[i].EPS > senders[j].EPS
+	})
+	return senders
+}
+
+// forgetUnclaimedSender dismisses a sender from a listener's unclaimed list
+func (app *Application) forgetUnclaimedSender(protocol string, port int, ip string) error {
+	listenerKey := fmt.Sprintf("%d:%s", port, strings.ToUpper(protocol))
+	
+	app.listenerMutex.RLock()
+	sharedListener, exists := app.sharedListeners[listenerKey]
+	app.listenerMutex.RUnlock()
+	
+	if !exists || !sharedListener.ForgetUnclaimedSender(ip) {
+		return fmt.Errorf("unclaimed sender %s not found on %s port %d", ip, protocol, port)
 	}
-	app.sourceMutex.Unlock()
+	return nil
+}
+
+// getReconciliation returns the delivery accounting of every source between from and to
+func (app *Application) getReconciliation(from, to time.Time) []models.SourceReconciliation {
+	app.sourceMutex.RLock()
+	defer app.sourceMutex.RUnlock()
 	
-	// Remove from configuration
-	var newSources []models.SourceConfig
-	for _, source := range config.Sources {
-		if source.Name != oldName {
-			newSources = append(newSources, source)
+	reconciliation := []models.SourceReconciliation{}
+	for _, source := range app.sources {
+		if source == nil {
+			continue
 		}
+		config := source.GetConfig()
+		reconciliation = append(reconciliation, models.SourceReconciliation{
+			Source:               config.Name,
+			Tenant:               config.Tenant,
+			ReconciliationCounts: source.GetReconciliation(from, to),
+		})
 	}
 	
-	// Add updated source to configuration
-	newSources = append(newSources, updatedSource)
+	sort.Slice(reconciliation, func(i, j int) bool {
+		return reconciliation[i].Source < reconciliation[j].Source
+	})
+	return reconciliation
+}
+
+// getHistory returns the delivery accounting of a source between from and to
+// in intervals of step
+func (app *Application) getHistory(name string, from, to time.Time, step time.Duration) ([]models.HistoryPoint, error) {
+	app.sourceMutex.RLock()
+	source, exists := app.sources[name]
+	app.sourceMutex.RUnlock()
+	
+	if !exists || source == nil {
+		return nil, fmt.Errorf("source '%s' not found", name)
+	}
+	return source.GetHistory(from, to, step), nil
+}
+
+// queryMetricsHistory returns the persisted metrics of the sources include
+// selects between from and to, and the resolution of the points
+func (app *Application) queryMetricsHistory(from, to time.Time, resolution string, include func(source string) bool) ([]models.MetricsPoint, string, error) {
+	if app.historyStore == nil {
+		return nil, "", fmt.Errorf("metrics history is not running")
+	}
+	return app.historyStore.Query(from, to, resolution, include)
+}
+
+// getHistoryStorage returns the disk usage of the metrics history
+func (app *Application) getHistoryStorage() (models.HistoryStorageStatus, error) {
+	if app.historyStore == nil {
+		return models.HistoryStorageStatus{}, fmt.Errorf("metrics history is not running")
+	}
+	return app.historyStore.Status()
+}
+
+// exportMetricsHistory copies the persisted metrics of the sources include
+// selects between from and to
+func (app *Application) exportMetricsHistory(from, to time.Time, include func(source string) bool) (models.MetricsHistoryExport, error) {
+	if app.historyStore == nil {
+		return models.MetricsHistoryExport{}, fmt.Errorf("metrics history is not running")
+	}
+	return app.historyStore.Export(from, to, include)
+}
+
+// importMetricsHistory adds an export from another instance to the metrics history
+func (app *Application) importMetricsHistory(export models.MetricsHistoryExport) (models.MetricsHistoryImport, error) {
+	if app.historyStore == nil {
+		return models.MetricsHistoryImport{}, fmt.Errorf("metrics history is not running")
+	}
+	return app.historyStore.Import(export)
+}
+
+// getEPSHeatmaps returns the hour-of-day by day-of-week average EPS of the
+// sources include selects between from and to
+func (app *Application) getEPSHeatmaps(from, to time.Time, location *time.Location, include func(source string) bool) ([]models.EPSHeatmap, error) {
+	if app.historyStore == nil {
+		return nil, fmt.Errorf("metrics history is not running")
+	}
+	return app.historyStore.Heatmap(from, to, location, include)
+}
+
+// getMetrics returns current metrics for the web server
+func (app *Application) getMetrics() ([]models.SourceMetrics, models.GlobalMetrics) {
+	app.sourceMutex.RLock()
+	defer app.sourceMutex.RUnlock()
+	
+	var sourceMetrics []models.SourceMetrics
+	for _, source := range app.sources {
+		if source != nil {
+			sourceMetrics = append(sourceMetrics, source.GetMetrics())
+		}
+	}
+	
+	// Sort sources by name for consistent ordering
+	for i := 0; i < len(sourceMetrics)-1; i++ {
+		for j := i + 1; j < len(sourceMetrics); j++ {
+			if sourceMetrics[i].Name > sourceMetrics[j].Name {
+				sourceMetrics[i], sourceMetrics[j] = sourceMetrics[j], sourceMetrics[i]
+			}
+		}
+	}
+	
+	app.applyCounters(sourceMetrics)
+	app.applyQuotaUsage(sourceMetrics)
+	global := models.SummarizeMetrics(sourceMetrics)
+	global.Quotas = app.quotaManager.GetStatus()
+	global.TotalPanics = recovery.Total()
+	
+	return sourceMetrics, global
+}
+
+// applyCounters replaces the per-process counters of each source with the
+// persisted cumulative totals
+func (app *Application) applyCounters(sourceMetrics []models.SourceMetrics) {
+	if app.counterStore != nil {
+		app.counterStore.Apply(sourceMetrics)
+	}
+}
+
+// retireCounters folds the final counters of a stopped source into its totals
+func (app *Application) retireCounters(source *syslog.SyslogSource) {
+	if app.counterStore != nil {
+		app.counterStore.Retire(source.GetMetrics())
+	}
+}
+
+// resetCounters starts the cumulative counters of a source from zero
+func (app *Application) resetCounters(name, by string) (models.CounterResetEvent, error) {
+	if app.counterStore == nil {
+		return models.CounterResetEvent{}, fmt.Errorf("counter store is not running")
+	}
+	
+	app.sourceMutex.RLock()
+	source, exists := app.sources[name]
+	app.sourceMutex.RUnlock()
+	if !exists {
+		return models.CounterResetEvent{}, fmt.Errorf("source '%s' not found", name)
+	}
+	
+	// Fold what the source counted so far so it is not carried past the reset
+	app.counterStore.Apply([]models.SourceMetrics{source.GetMetrics()})
+	return app.counterStore.Reset(name, by)
+}
+
+// getCounterResets returns the recorded counter reset events, newest first
+func (app *Application) getCounterResets() []models.CounterResetEvent {
+	if app.counterStore == nil {
+		return []models.CounterResetEvent{}
+	}
+	return app.counterStore.GetResets()
+}
+
+// applyQuotaUsage records the consumption of the quotas covering each source
+func (app *Application) applyQuotaUsage(sourceMetrics []models.SourceMetrics) {
+	for i := range sourceMetrics {
+		sourceMetrics[i].QuotaUsage = app.quotaManager.Usage(sourceMetrics[i].Tenant, sourceMetrics[i].Group)
+	}
+}
+
+// getSources returns all configured sources
+func (app *Application) getSources() []models.SourceConfig {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return []models.SourceConfig{}
+	}
+	return config.Sources
+}
+
+// addSource adds a new source
+func (app *Application) addSource(newSource models.SourceConfig) error {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	if newSource.ID == "" {
+		newSource.ID = config.NewID()
+	}
+	config.AssignDestinationIDs(newSource.Destinations, nil)
+	return app.startNewSource(newSource)
+}
+
+// startNewSource adds a validated source to the configuration and starts it
+// unless it is disabled
+func (app *Application) startNewSource(newSource models.SourceConfig) error {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
+	// Add source to configuration
+	config.Sources = append(config.Sources, newSource)
+	app.configManager.UpdateConfig(config)
+	
+	if newSource.Disabled {
+		if err := app.SaveConfig(); err != nil {
+			log.Printf("⚠ Warning: Failed to save config: %v", err)
+		}
+		return nil
+	}
+	
+	// Start the source
+	batchSize := config.GlobalSettings.BatchSize
+	if batchSize == 0 {
+		batchSize = 1000
+	}
+	
+	source := syslog.NewSyslogSource(runningSource(config, newSource), batchSize)
+	if err := source.Start(app.ctx, app); err != nil {
+		return err
+	}
+	
+	app.sourceMutex.Lock()
+	app.sources[newSource.Name] = source
+	app.sourceMutex.Unlock()
+	
+	// Save configuration
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	
+	return nil
+}
+
+// updateSource replaces an existing source with a validated configuration
+// and restarts it, or leaves it stopped when the update disables it
+func (app *Application) updateSource(oldName string, updatedSource models.SourceConfig) error {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
+	// Refuse the update while the existing source still runs
+	if err := checkConflicts(config, updatedSource, oldName); err != nil {
+		return err
+	}
+	
+	// Stop existing source, remembering whether its delivery was paused
+	paused := false
+	app.sourceMutex.Lock()
+	if existingSource, exists := app.sources[oldName]; exists {
+		paused = existingSource.DeliveryPaused()
+		existingSource.Stop(app)
+		app.retireCounters(existingSource)
+		delete(app.sources, oldName)
+	}
+	if app.counterStore != nil {
+		app.counterStore.Rename(oldName, updatedSource.Name)
+	}
+	app.sourceMutex.Unlock()
+	if err := syslog.RenamePauseBuffer(oldName, updatedSource.Name); err != nil {
+		log.Printf("⚠ Warning: Failed to move held back events of source '%s': %v", oldName, err)
+	}
+	
+	// Remove from configuration
+	var newSources []models.SourceConfig
+	for _, source := range config.Sources {
+		if source.Name != oldName {
+			newSources = append(newSources, source)
+		}
+	}
+	
+	// Add updated source to configuration
+	newSources = append(newSources, updatedSource)
 	config.Sources = newSources
 	app.configManager.UpdateConfig(config)
 	
+	if updatedSource.Disabled {
+		if err := app.SaveConfig(); err != nil {
+			log.Printf("⚠ Warning: Failed to save config: %v", err)
+		}
+		return nil
+	}
+	
 	// Start the updated source
 	batchSize := config.GlobalSettings.BatchSize
 	if batchSize == 0 {
 		batchSize = 1000
 	}
 	
-	source := syslog.NewSyslogSource(updatedSource, batchSize)
-	if err := source.Start(app); err != nil {
+	source := syslog.NewSyslogSource(runningSource(config, updatedSource), batchSize)
+	
+	// Stay paused through the edit, before the held back events reloaded from
+	// disk would be replayed
+	source.SetDeliveryPaused(paused)
+	if err := source.Start(app.ctx, app); err != nil {
 		return err
 	}
 	
@@ -327,30 +1270,117 @@ func (app *Application) updateSource(oldName string, updatedSource models.Source
 	return nil
 }
 
-// deleteSource deletes a source
-func (app *Application) deleteSource(name string) error {
+// setSimulationMode switches a running source between simulation and live
+// delivery in place, keeping its queue, and stores the new mode
+func (app *Application) setSimulationMode(name string, enabled bool) error {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
 	config := app.configManager.GetConfig()
 	if config == nil {
 		return fmt.Errorf("no configuration loaded")
 	}
 	
+	app.sourceMutex.RLock()
+	source, exists := app.sources[name]
+	app.sourceMutex.RUnlock()
+	if !exists {
+		return fmt.Errorf("source '%s' not found", name)
+	}
+	source.SetSimulationMode(enabled)
+	
+	for i := range config.Sources {
+		if config.Sources[i].Name == name {
+			config.Sources[i].SimulationMode = enabled
+		}
+	}
+	app.configManager.UpdateConfig(config)
+	
+	// Save configuration
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	
+	return nil
+}
+
+// setDeliveryPaused pauses or resumes delivery of a running source. Pausing
+// is an operational state for maintenance windows; it is kept while the
+// source is edited but not stored, so after a restart the events held back
+// on disk are delivered.
+func (app *Application) setDeliveryPaused(name string, paused bool) error {
+	app.sourceMutex.RLock()
+	source, exists := app.sources[name]
+	app.sourceMutex.RUnlock()
+	if !exists {
+		return fmt.Errorf("source '%s' not found", name)
+	}
+	
+	source.SetDeliveryPaused(paused)
+	return nil
+}
+
+// deleteSource deletes a source by name or ID if it still matches the precondition
+func (app *Application) deleteSource(ref string, precondition config.Precondition) error {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
+	existing, exists := findSourceConfig(cfg, ref)
+	if !exists {
+		return fmt.Errorf("%w: source '%s'", config.ErrNotFound, ref)
+	}
+	if err := precondition.Check(config.ETag(existing)); err != nil {
+		return err
+	}
+	return app.removeSource(existing.Name)
+}
+
+// removeSource stops a source and removes it from the configuration
+func (app *Application) removeSource(name string) error {
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
 	// Stop and remove source
 	app.sourceMutex.Lock()
 	if source, exists := app.sources[name]; exists {
 		source.Stop(app)
 		delete(app.sources, name)
 	}
+	if app.counterStore != nil {
+		app.counterStore.Delete(name)
+	}
 	app.sourceMutex.Unlock()
+	if err := syslog.RemovePauseBuffer(name); err != nil {
+		log.Printf("⚠ Warning: Failed to remove held back events of source '%s': %v", name, err)
+	}
 	
-	// Remove from configuration
+	// Remove from configuration, along with its ingest tokens
 	var newSources []models.SourceConfig
-	for _, source := range config.Sources {
+	var removedID string
+	for _, source := range cfg.Sources {
 		if source.Name != name {
 			newSources = append(newSources, source)
+		} else {
+			removedID = source.ID
 		}
 	}
-	config.Sources = newSources
-	app.configManager.UpdateConfig(config)
+	cfg.Sources = newSources
+	var tokens []models.IngestToken
+	for _, token := range cfg.IngestTokens {
+		if token.SourceID != removedID {
+			tokens = append(tokens, token)
+		}
+	}
+	cfg.IngestTokens = tokens
+	app.ingestManager.SetTokens(tokens)
+	app.configManager.UpdateConfig(cfg)
 	
 	// Save configuration
 	if err := app.SaveConfig(); err != nil {
@@ -360,11 +1390,60 @@ func (app *Application) deleteSource(name string) error {
 	return nil
 }
 
-// validateSource validates a source configuration
-func (app *Application) validateSource(source models.SourceConfig) error {
-	if source.Name == "" {
+// validateSourceName checks that a source name is safe to use in file
+// names, URLs and HEC metadata: letters, digits, dots, dashes and
+// underscores, starting with a letter or digit
+func validateSourceName(name string) error {
+	if name == "" {
 		return fmt.Errorf("source name is required")
 	}
+	if len(name) > maxSourceNameLength {
+		return fmt.Errorf("source name must be at most %d characters", maxSourceNameLength)
+	}
+	if !sourceNamePattern.MatchString(name) {
+		return fmt.Errorf("source name may only contain letters, digits, '.', '-' and '_', and must start with a letter or digit")
+	}
+	return nil
+}
+
+// validateSource validates a new source configuration
+func (app *Application) validateSource(source models.SourceConfig) error {
+	return app.checkSource(source, "")
+}
+
+// checkSource validates a source configuration against the other sources,
+// leaving out the one named exclude that it replaces
+func (app *Application) checkSource(source models.SourceConfig, exclude string) error {
+	if err := app.checkSourceFields(source); err != nil {
+		return err
+	}
+	
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
+	// Check for duplicate names and IDs
+	for _, existing := range config.Sources {
+		if existing.Name == exclude {
+			continue
+		}
+		if existing.Name == source.Name {
+			return fmt.Errorf("source name already exists")
+		}
+		if source.ID != "" && existing.ID == source.ID {
+			return fmt.Errorf("source ID already exists")
+		}
+	}
+	
+	return checkConflicts(config, source, exclude)
+}
+
+// checkSourceFields validates a source configuration on its own
+func (app *Application) checkSourceFields(source models.SourceConfig) error {
+	if err := validateSourceName(source.Name); err != nil {
+		return err
+	}
 	
 	if source.IP == "" {
 		return fmt.Errorf("source IP is required")
@@ -374,20 +1453,531 @@ func (app *Application) validateSource(source models.SourceConfig) error {
 		return fmt.Errorf("invalid port number")
 	}
 	
+	switch strings.ToUpper(source.Protocol) {
+	case "", "UDP", "TCP", models.ProtocolUDPTCP, models.ProtocolRELP:
+	default:
+		return fmt.Errorf("protocol must be UDP, TCP, %s or %s", models.ProtocolUDPTCP, models.ProtocolRELP)
+	}
+	
 	config := app.configManager.GetConfig()
 	if config == nil {
 		return fmt.Errorf("no configuration loaded")
 	}
 	
-	// Check for duplicate names or IPs
+	if err := filtering.ValidateRules(source.Filters); err != nil {
+		return err
+	}
+	if _, err := filtering.ExpandSets(config.FilterSets, source.FilterSets, source.Filters); err != nil {
+		return err
+	}
+	switch source.FilterMatcher {
+	case models.FilterMatcherRules, models.FilterMatcherMulti:
+	default:
+		return fmt.Errorf("unknown filter matcher: %s", source.FilterMatcher)
+	}
+	switch source.FilterPolicy {
+	case models.FilterPolicyAll, models.FilterPolicyFirst:
+	default:
+		return fmt.Errorf("unknown filter policy: %s", source.FilterPolicy)
+	}
+	
+	for _, rule := range source.Aggregations {
+		switch rule.Overflow {
+		case "", models.OverflowOther, models.OverflowDisable, models.OverflowAlert:
+		default:
+			return fmt.Errorf("unknown aggregation overflow strategy: %s", rule.Overflow)
+		}
+		if rule.MaxGroups < 0 {
+			return fmt.Errorf("aggregation max_groups cannot be negative")
+		}
+		switch rule.Mode {
+		case "", models.AggregationModeEvents:
+		case models.AggregationModeMetrics:
+			if rule.TimeWindow <= 0 {
+				return fmt.Errorf("aggregation rule '%s' needs a time window in metrics mode", rule.Name)
+			}
+		default:
+			return fmt.Errorf("unknown aggregation mode: %s", rule.Mode)
+		}
+		for _, metric := range rule.Metrics {
+			for _, op := range metric.Ops {
+				switch op {
+				case models.AggregateSum, models.AggregateAvg, models.AggregateMin, models.AggregateMax, models.AggregateRate:
+				default:
+					return fmt.Errorf("unknown aggregate %q for field %s", op, metric.Field)
+				}
+			}
+		}
+	}
+	
+	if err := destinations.ValidateClassification(source.Classification); err != nil {
+		return err
+	}
+	for _, dest := range source.Destinations {
+		configMap, _ := dest.Config.(map[string]interface{})
+		if path, _ := configMap["path"].(string); dest.Type == "storage" && (dest.Enabled || path != "") {
+			if err := destinations.ValidateStoragePath(path); err != nil {
+				return fmt.Errorf("destination '%s': %v", dest.Name, err)
+			}
+			format, _ := configMap["format"].(string)
+			if err := destinations.ValidateStorageFormat(format); err != nil {
+				return fmt.Errorf("destination '%s': %v", dest.Name, err)
+			}
+		}
+		if err := destinations.ValidateSchema(dest.Schema); err != nil {
+			return fmt.Errorf("destination '%s': %v", dest.Name, err)
+		}
+		if dest.Enabled {
+			if err := destinations.CheckRouting(source.Classification, dest); err != nil {
+				return err
+			}
+		}
+		if dest.Window != nil {
+			if _, _, err := destinations.ParseWindow(*dest.Window); err != nil {
+				return fmt.Errorf("destination '%s': %v", dest.Name, err)
+			}
+		}
+		if dest.Throttle != nil {
+			if err := destinations.ValidateThrottle(*dest.Throttle); err != nil {
+				return fmt.Errorf("destination '%s': %v", dest.Name, err)
+			}
+		}
+		if dest.Dedup != nil {
+			if err := destinations.ValidateDedup(*dest.Dedup); err != nil {
+				return fmt.Errorf("destination '%s': %v", dest.Name, err)
+			}
+		}
+		if dest.Heartbeat != nil {
+			if err := destinations.ValidateHeartbeat(*dest.Heartbeat); err != nil {
+				return fmt.Errorf("destination '%s': %v", dest.Name, err)
+			}
+		}
+		if err := destinations.ValidateCost(dest.CostPerGB); err != nil {
+			return fmt.Errorf("destination '%s': %v", dest.Name, err)
+		}
+	}
+	
+	if source.MinSeverity != "" {
+		if _, err := syslog.ParseSeverityLevel(source.MinSeverity); err != nil {
+			return fmt.Errorf("invalid min_severity: %v", err)
+		}
+	}
+	if source.Tracer != nil {
+		if err := syslog.ValidateTracer(*source.Tracer); err != nil {
+			return err
+		}
+	}
+	if err := syslog.ValidateTimestampSource(source.TimestampSource); err != nil {
+		return err
+	}
+	if err := syslog.ValidateParser(source.Parser); err != nil {
+		return err
+	}
+	if source.RawCapture != nil {
+		if err := syslog.ValidateRawCapture(*source.RawCapture); err != nil {
+			return err
+		}
+	}
+	if source.Probe != nil {
+		if err := syslog.ValidateProbe(*source.Probe, source); err != nil {
+			return err
+		}
+	}
+	
+	for _, rule := range source.Enrichments {
+		if rule.Field == "" {
+			return fmt.Errorf("enrichment field is required")
+		}
+		if app.findLookupTable(rule.Table) == nil {
+			return fmt.Errorf("unknown lookup table: %s", rule.Table)
+		}
+	}
+	
+	if source.Hostname != "" && strings.EqualFold(source.Protocol, "UDP") {
+		return fmt.Errorf("hostname attribution requires TCP")
+	}
+	
+	// Sources must belong to a known tenant when multi-tenancy is enabled
+	if config.GlobalSettings.MultiTenant && source.Tenant != "" && app.findTenant(source.Tenant) == nil {
+		return fmt.Errorf("unknown tenant: %s", source.Tenant)
+	}
+	
+	destinationIDs := make(map[string]bool)
+	for _, dest := range source.Destinations {
+		if dest.ID != "" && destinationIDs[dest.ID] {
+			return fmt.Errorf("duplicate destination ID: %s", dest.ID)
+		}
+		destinationIDs[dest.ID] = true
+	}
+	
+	return nil
+}
+
+// checkConflicts reports whether a source collides with the other configured
+// sources, leaving out the one named exclude, or with the analyzer's own ports
+func checkConflicts(config *models.Config, source models.SourceConfig, exclude string) error {
+	var others []models.SourceConfig
 	for _, existing := range config.Sources {
-		if existing.Name == source.Name {
-			return fmt.Errorf("source name already exists")
+		if existing.Name != exclude {
+			others = append(others, existing)
+		}
+	}
+	
+	if conflicts := syslog.FindConflicts(append(others, source), reservedPorts(config.GlobalSettings)); len(conflicts) > 0 {
+		return fmt.Errorf("%s", conflicts[len(conflicts)-1].Reason)
+	}
+	return nil
+}
+
+// reservedPorts lists the ports the analyzer itself listens on
+func reservedPorts(settings models.GlobalSettings) []syslog.ReservedPort {
+	reserved := []syslog.ReservedPort{{Transport: "TCP", Port: settings.WebPort, Owner: "the web interface"}}
+	if settings.SNMP.Enabled {
+		port := settings.SNMP.Port
+		if port == 0 {
+			port = snmp.DefaultPort
 		}
-		if existing.IP == source.IP && existing.Port == source.Port {
-			return fmt.Errorf("source IP and port combination already exists")
+		reserved = append(reserved, syslog.ReservedPort{Transport: "UDP", Port: port, Owner: "the SNMP agent"})
+	}
+	return reserved
+}
+
+// getConflicts lists the configured sources that conflict with an earlier
+// source or a reserved port and are therefore not running
+func (app *Application) getConflicts() []models.PortConflict {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return []models.PortConflict{}
+	}
+	
+	conflicts := syslog.FindConflicts(config.Sources, reservedPorts(config.GlobalSettings))
+	if conflicts == nil {
+		return []models.PortConflict{}
+	}
+	return conflicts
+}
+
+// getAlerts returns active alerts and silences
+func (app *Application) getAlerts() ([]notifications.Alert, []notifications.Silence, error) {
+	if app.alertEngine == nil {
+		return nil, nil, fmt.Errorf("alerting is not enabled")
+	}
+	return app.alertEngine.GetActiveAlerts(), app.alertEngine.GetSilences(), nil
+}
+
+// acknowledgeAlert acknowledges a firing alert
+func (app *Application) acknowledgeAlert(rule, source, by string) (notifications.Alert, error) {
+	if app.alertEngine == nil {
+		return notifications.Alert{}, fmt.Errorf("alerting is not enabled")
+	}
+	return app.alertEngine.Acknowledge(rule, source, by)
+}
+
+// addSilence registers an alert silence
+func (app *Application) addSilence(silence notifications.Silence) (notifications.Silence, error) {
+	if app.alertEngine == nil {
+		return notifications.Silence{}, fmt.Errorf("alerting is not enabled")
+	}
+	return app.alertEngine.AddSilence(silence)
+}
+
+// removeSilence deletes an alert silence
+func (app *Application) removeSilence(id string) error {
+	if app.alertEngine == nil {
+		return fmt.Errorf("alerting is not enabled")
+	}
+	return app.alertEngine.RemoveSilence(id)
+}
+
+// isMultiTenant reports whether tenant access control is enabled
+func (app *Application) isMultiTenant() bool {
+	config := app.configManager.GetConfig()
+	return config != nil && config.GlobalSettings.MultiTenant
+}
+
+// resolveToken maps an API token to a tenant scope
+func (app *Application) resolveToken(token string) (tenancy.Scope, bool) {
+	return tenancy.ResolveToken(app.configManager.GetConfig(), token)
+}
+
+// getTenants returns all configured tenants
+func (app *Application) getTenants() []models.TenantConfig {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return []models.TenantConfig{}
+	}
+	return config.Tenants
+}
+
+// findTenant returns the tenant with the given ID, or nil
+func (app *Application) findTenant(id string) *models.TenantConfig {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return nil
+	}
+	for i := range config.Tenants {
+		if config.Tenants[i].ID == id {
+			return &config.Tenants[i]
 		}
 	}
+	return nil
+}
+
+// addTenant adds a new tenant
+func (app *Application) addTenant(tenant models.TenantConfig) error {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
 	
+	if tenant.ID == "" {
+		return fmt.Errorf("tenant ID is required")
+	}
+	if app.findTenant(tenant.ID) != nil {
+		return fmt.Errorf("tenant ID already exists")
+	}
+	if tenant.Name == "" {
+		tenant.Name = tenant.ID
+	}
+	for _, token := range tenant.Tokens {
+		if _, exists := tenancy.ResolveToken(config, token); exists {
+			return fmt.Errorf("token is already in use")
+		}
+	}
+	
+	config.Tenants = append(config.Tenants, tenant)
+	app.configManager.UpdateConfig(config)
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	
+	log.Printf("✓ Added tenant '%s'", tenant.ID)
 	return nil
-} 
+}
+
+// deleteTenant removes a tenant that no longer owns any sources
+func (app *Application) deleteTenant(id string) error {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
+	if app.findTenant(id) == nil {
+		return fmt.Errorf("tenant '%s' not found", id)
+	}
+	for _, source := range config.Sources {
+		if source.Tenant == id {
+			return fmt.Errorf("tenant still owns source '%s'", source.Name)
+		}
+	}
+	
+	var tenants []models.TenantConfig
+	for _, tenant := range config.Tenants {
+		if tenant.ID != id {
+			tenants = append(tenants, tenant)
+		}
+	}
+	config.Tenants = tenants
+	app.configManager.UpdateConfig(config)
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	
+	log.Printf("✓ Deleted tenant '%s'", id)
+	return nil
+}
+
+// AdmitMessage applies tenant and group quotas to a received message
+func (app *Application) AdmitMessage(source models.SourceConfig, size int) bool {
+	if source.Tenant == "" && source.Group == "" {
+		return true
+	}
+	return app.quotaManager.Admit(source.Tenant, source.Group, size)
+}
+
+// getQuotas returns all configured quotas
+func (app *Application) getQuotas() []models.QuotaConfig {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return []models.QuotaConfig{}
+	}
+	return config.Quotas
+}
+
+// updateQuotas replaces the quota definitions
+func (app *Application) updateQuotas(quotas []models.QuotaConfig) error {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
+	names := make(map[string]bool)
+	for _, q := range quotas {
+		if err := quota.Validate(q); err != nil {
+			return err
+		}
+		if names[q.Name] {
+			return fmt.Errorf("duplicate quota name: %s", q.Name)
+		}
+		names[q.Name] = true
+	}
+	
+	config.Quotas = quotas
+	app.configManager.UpdateConfig(config)
+	app.quotaManager.SetQuotas(quotas)
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	
+	log.Printf("✓ Updated %d quotas", len(quotas))
+	return nil
+}
+
+// getChargeback returns the chargeback usage rows of a month
+func (app *Application) getChargeback(month string) ([]chargeback.Usage, error) {
+	if app.chargebackLedger == nil {
+		return nil, fmt.Errorf("chargeback ledger is not running")
+	}
+	return app.chargebackLedger.GetUsage(month), nil
+}
+
+// getChargebackMonths returns the months with recorded chargeback usage
+func (app *Application) getChargebackMonths() []string {
+	if app.chargebackLedger == nil {
+		return []string{}
+	}
+	return app.chargebackLedger.Months()
+}
+
+// getForecast projects the recorded daily ingest with a forecast model, or
+// the configured model when empty
+func (app *Application) getForecast(model string) (models.ForecastReport, error) {
+	if app.forecastHistory == nil {
+		return models.ForecastReport{}, fmt.Errorf("forecast history is not running")
+	}
+	return app.forecastHistory.Report(time.Now(), model, app.getQuotas())
+}
+
+// getComparison compares each source's recorded daily ingest between two periods
+func (app *Application) getComparison(current, baseline models.IngestPeriod) (models.IngestComparison, error) {
+	if app.forecastHistory == nil {
+		return models.IngestComparison{}, fmt.Errorf("forecast history is not running")
+	}
+	return app.forecastHistory.Compare(current, baseline), nil
+}
+
+// LookupValue returns the row of a lookup table for a key
+func (app *Application) LookupValue(table, key string) (map[string]string, bool) {
+	return app.lookupManager.Lookup(table, key)
+}
+
+// findLookupTable returns the lookup table with the given name, or nil
+func (app *Application) findLookupTable(name string) *models.LookupTableConfig {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return nil
+	}
+	for i := range config.LookupTables {
+		if config.LookupTables[i].Name == name {
+			return &config.LookupTables[i]
+		}
+	}
+	return nil
+}
+
+// getLookups returns the load state of all lookup tables
+func (app *Application) getLookups() []models.LookupTableStatus {
+	return app.lookupManager.GetStatus()
+}
+
+// uploadLookup stores a new version of a lookup table, creating its
+// definition if needed. Omitted settings keep their current values.
+func (app *Application) uploadLookup(table models.LookupTableConfig, data []byte) (models.LookupTableStatus, error) {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return models.LookupTableStatus{}, fmt.Errorf("no configuration loaded")
+	}
+	
+	existing := app.findLookupTable(table.Name)
+	if existing != nil {
+		if table.Format == "" {
+			table.Format = existing.Format
+		}
+		if table.Path == "" {
+			table.Path = existing.Path
+		}
+		if table.KeyField == "" {
+			table.KeyField = existing.KeyField
+		}
+	}
+	if table.Format == "" {
+		table.Format = enrichment.FormatCSV
+	}
+	if table.Path == "" {
+		table.Path = filepath.Join("lookups", table.Name+"."+table.Format)
+	}
+	if err := enrichment.Validate(table); err != nil {
+		return models.LookupTableStatus{}, err
+	}
+	
+	status, err := app.lookupManager.Upload(table, data)
+	if err != nil {
+		return models.LookupTableStatus{}, err
+	}
+	
+	if existing != nil {
+		*existing = table
+	} else {
+		config.LookupTables = append(config.LookupTables, table)
+	}
+	app.configManager.UpdateConfig(config)
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	return status, nil
+}
+
+// reloadLookup re-reads a lookup table from its file
+func (app *Application) reloadLookup(name string) (models.LookupTableStatus, error) {
+	return app.lookupManager.Reload(name)
+}
+
+// deleteLookup removes a lookup table no source enriches from
+func (app *Application) deleteLookup(name string) error {
+	config := app.configManager.GetConfig()
+	if config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
+	if app.findLookupTable(name) == nil {
+		return fmt.Errorf("lookup table '%s' not found", name)
+	}
+	for _, source := range config.Sources {
+		for _, rule := range source.Enrichments {
+			if rule.Table == name {
+				return fmt.Errorf("lookup table is used by source '%s'", source.Name)
+			}
+		}
+	}
+	
+	var tables []models.LookupTableConfig
+	for _, table := range config.LookupTables {
+		if table.Name != name {
+			tables = append(tables, table)
+		}
+	}
+	config.LookupTables = tables
+	app.configManager.UpdateConfig(config)
+	app.lookupManager.Remove(name)
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	
+	log.Printf("✓ Deleted lookup table '%s'", name)
+	return nil
+}
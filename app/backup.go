@@ -0,0 +1,68 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"syslog-analyzer/gitops"
+	"syslog-analyzer/models"
+)
+
+// snapshotConfig returns the configuration as it would be saved, for backups
+func (app *Application) snapshotConfig() ([]byte, error) {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return nil, fmt.Errorf("no configuration loaded")
+	}
+	return json.MarshalIndent(cfg, "", "  ")
+}
+
+// stateFiles returns the state files copied into backups
+func (app *Application) stateFiles() []string {
+	settings := app.globalSettings
+	files := []string{app.credentialAuditFile()}
+	for _, file := range []string{settings.CountersFile, settings.Forecast.DataFile, settings.Chargeback.DataFile} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// getBackupStatus returns the state of configuration backups
+func (app *Application) getBackupStatus() models.BackupStatus {
+	if app.backupScheduler != nil {
+		return app.backupScheduler.Status()
+	}
+	return models.BackupStatus{Enabled: app.globalSettings.Backup.Enabled, Backups: []models.BackupInfo{}}
+}
+
+// backupNow backs up immediately instead of waiting for the scheduled time
+func (app *Application) backupNow() (models.BackupInfo, error) {
+	if app.backupScheduler == nil {
+		return models.BackupInfo{}, fmt.Errorf("configuration backups are not running")
+	}
+	return app.backupScheduler.BackupNow()
+}
+
+// restoreBackup brings the sources in line with those of a backup, the same
+// way a GitOps revision is applied: the backup's sources are validated as a
+// whole, then added, updated or removed while unchanged ones keep running.
+// Global settings and state files stay as they are; they are in the backup
+// for restoring by hand on a stopped analyzer.
+func (app *Application) restoreBackup(name string) (models.BackupRestore, error) {
+	result := models.BackupRestore{Backup: name}
+	if app.backupScheduler == nil {
+		return result, fmt.Errorf("configuration backups are not running")
+	}
+	
+	cfg, err := app.backupScheduler.Load(name)
+	if err != nil {
+		return result, err
+	}
+	result.Changes, err = app.applyGitOps(gitops.Document{Sources: cfg.Sources})
+	return result, err
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"fmt"
+	"log"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/destinations"
+	"syslog-analyzer/models"
+)
+
+// getClassificationRules returns the data classification rules
+func (app *Application) getClassificationRules() []models.ClassificationRule {
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return []models.ClassificationRule{}
+	}
+	return append([]models.ClassificationRule{}, cfg.ClassificationRules...)
+}
+
+// updateClassificationRules replaces the classification rules. Rules that
+// would cut a source off from one of its enabled destinations are refused,
+// so the destination has to be changed first. Sources with destinations the
+// old rules held back are restarted to deliver to them.
+func (app *Application) updateClassificationRules(rules []models.ClassificationRule) error {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	if err := destinations.ValidateClassificationRules(rules); err != nil {
+		return fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	
+	var released []models.SourceConfig
+	for _, source := range cfg.Sources {
+		heldBack := false
+		for _, dest := range source.Destinations {
+			if !dest.Enabled {
+				continue
+			}
+			if err := destinations.CheckRoutingWith(rules, source.Classification, dest); err != nil {
+				return fmt.Errorf("%w: source '%s': %v", config.ErrInvalid, source.Name, err)
+			}
+			if destinations.CheckRoutingWith(cfg.ClassificationRules, source.Classification, dest) != nil {
+				heldBack = true
+			}
+		}
+		if heldBack {
+			released = append(released, source)
+		}
+	}
+	
+	cfg.ClassificationRules = rules
+	app.configManager.UpdateConfig(cfg)
+	if err := destinations.SetClassificationRules(rules); err != nil {
+		return err
+	}
+	
+	for _, source := range released {
+		if err := app.updateSource(source.Name, source); err != nil {
+			log.Printf("✗ Failed to restart source %s with new classification rules: %v", source.Name, err)
+		}
+	}
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	log.Printf("✓ Updated %d classification rules", len(rules))
+	return nil
+}
@@ -0,0 +1,185 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"syslog-analyzer/cmdb"
+	"syslog-analyzer/config"
+	"syslog-analyzer/models"
+)
+
+// cmdbManagedTag marks the sources the CMDB sync created. Only those are
+// updated and disabled by it; sources added by hand are left alone even
+// when a device in the inventory matches them.
+const cmdbManagedTag = "managed-by:cmdb"
+
+// reconcileInventory brings the sources in line with the devices of the
+// inventory: active devices get a source from the template for their
+// vendor, and managed sources whose device left the inventory or is no
+// longer active are disabled rather than removed, so their history and
+// port stay with them if the device returns. A dry run reports the same
+// changes without making them.
+func (app *Application) reconcileInventory(devices []cmdb.Device, dryRun bool) models.CMDBSyncReport {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	report := models.CMDBSyncReport{DryRun: dryRun, Changes: []models.CMDBChange{}}
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		report.Error = "no configuration loaded"
+		return report
+	}
+	if !dryRun && app.GetConfigMode().ReadOnly {
+		report.DryRun = true
+		report.Error = "configuration is read-only in environment-only mode; changes were not applied"
+	} else if !dryRun && cfg.GlobalSettings.GitOps.Enabled {
+		report.DryRun = true
+		report.Error = "configuration is managed by GitOps sync; changes were not applied"
+	}
+	dryRun = report.DryRun
+	
+	claimed := make(map[string]string) // Source name -> device it belongs to
+	inactive := make(map[string]bool)  // Sources of devices no longer active
+	for _, device := range devices {
+		label := device.Hostname
+		if label == "" {
+			label = device.IP
+		}
+		if device.IP == "" || net.ParseIP(device.IP) == nil {
+			if device.Active {
+				report.Changes = append(report.Changes, models.CMDBChange{Action: models.CMDBSkip, Device: label, Reason: "device has no IP address"})
+			}
+			continue
+		}
+	
+		cfg = app.configManager.GetConfig()
+		request := models.OnboardRequest{
+			Hostname: device.Hostname,
+			IP:       device.IP,
+			Vendor:   device.Vendor,
+			Tags:     append(append([]string{}, device.Tags...), cmdbManagedTag),
+		}
+		if !device.Active {
+			inactive[deviceSourceName(request)] = true
+			continue
+		}
+	
+		template, err := selectTemplate(cfg, request)
+		if err != nil {
+			report.Changes = append(report.Changes, models.CMDBChange{Action: models.CMDBSkip, Device: label, Reason: err.Error()})
+			continue
+		}
+		change := models.CMDBChange{Device: label, Template: template.Name}
+		source, err := instantiateTemplate(*template, request)
+		if err != nil {
+			change.Action, change.Error = models.CMDBSkip, err.Error()
+			report.Changes = append(report.Changes, change)
+			continue
+		}
+		change.Source = source.Name
+	
+		existing, exists := findDeviceSource(cfg, source)
+		if exists {
+			change.Source = existing.Name
+		}
+		if other, taken := claimed[change.Source]; taken {
+			change.Action, change.Reason = models.CMDBSkip, fmt.Sprintf("source is already claimed by device %s", other)
+			report.Changes = append(report.Changes, change)
+			continue
+		}
+		claimed[change.Source] = label
+	
+		if !exists {
+			change.Action = models.CMDBCreate
+			source.ID = config.NewID()
+			source.CreatedAt = time.Now()
+			config.AssignDestinationIDs(source.Destinations, nil)
+			if err := app.checkSource(source, ""); err != nil {
+				change.Error = err.Error()
+			} else if !dryRun {
+				if err := app.startNewSource(source); err != nil {
+					change.Error = err.Error()
+				} else {
+					log.Printf("✓ CMDB sync added source '%s' for %s (%s)", source.Name, label, source.IP)
+				}
+			}
+			report.Changes = append(report.Changes, change)
+			continue
+		}
+	
+		if !containsFold(existing.Tags, cmdbManagedTag) {
+			change.Action, change.Reason = models.CMDBSkip, fmt.Sprintf("source '%s' was not created by the CMDB sync", existing.Name)
+			report.Changes = append(report.Changes, change)
+			continue
+		}
+		source.ID = existing.ID
+		source.CreatedAt = existing.CreatedAt
+		config.AssignDestinationIDs(source.Destinations, existing.Destinations)
+		if config.ETag(source) == config.ETag(existing) {
+			report.Unchanged++
+			continue
+		}
+	
+		change.Action = models.CMDBUpdate
+		if existing.Disabled {
+			change.Reason = "device is active again"
+		}
+		if err := app.checkSource(source, existing.Name); err != nil {
+			change.Error = err.Error()
+		} else if !dryRun {
+			if err := app.updateSource(existing.Name, source); err != nil {
+				change.Error = err.Error()
+			} else {
+				log.Printf("✓ CMDB sync updated source '%s' for %s (%s)", source.Name, label, source.IP)
+			}
+		}
+		report.Changes = append(report.Changes, change)
+	}
+	
+	// Disable the managed sources no active device claimed
+	for _, existing := range app.configManager.GetConfig().Sources {
+		if existing.Disabled || !containsFold(existing.Tags, cmdbManagedTag) {
+			continue
+		}
+		if _, taken := claimed[existing.Name]; taken {
+			continue
+		}
+	
+		change := models.CMDBChange{Action: models.CMDBDisable, Device: existing.Name, Source: existing.Name, Reason: "device is no longer in the inventory"}
+		if inactive[existing.Name] {
+			change.Reason = "device is no longer active"
+		}
+		if !dryRun {
+			disabled := existing
+			disabled.Disabled = true
+			if err := app.updateSource(existing.Name, disabled); err != nil {
+				change.Error = err.Error()
+			} else {
+				log.Printf("✓ CMDB sync disabled source '%s': %s", existing.Name, change.Reason)
+			}
+		}
+		report.Changes = append(report.Changes, change)
+	}
+	
+	return report
+}
+
+// getCMDBStatus returns the state of the CMDB sync
+func (app *Application) getCMDBStatus() models.CMDBSyncStatus {
+	if app.cmdbSyncer != nil {
+		return app.cmdbSyncer.Status()
+	}
+	return models.CMDBSyncStatus{Enabled: false}
+}
+
+// syncCMDB syncs immediately instead of waiting for the next interval, or
+// only reports the changes a sync would make
+func (app *Application) syncCMDB(dryRun bool) (models.CMDBSyncReport, error) {
+	if app.cmdbSyncer == nil {
+		return models.CMDBSyncReport{}, fmt.Errorf("CMDB sync is not running")
+	}
+	return app.cmdbSyncer.SyncNow(dryRun), nil
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/destinations"
+	"syslog-analyzer/models"
+	"syslog-analyzer/tenancy"
+)
+
+// defaultCredentialAuditFile is where credential rotations are recorded
+// when the settings don't say
+const defaultCredentialAuditFile = "credential_audit.jsonl"
+
+// rotateCredentials switches the HEC destinations with the given name to a
+// new API key. Running destinations take the key in place, keeping their
+// queues, and every rotated destination is recorded in the audit trail.
+func (app *Application) rotateCredentials(scope tenancy.Scope, name string, rotation models.CredentialRotation) ([]models.CredentialAuditEntry, error) {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return nil, fmt.Errorf("no configuration loaded")
+	}
+	if err := validateAPIKey(rotation.APIKey); err != nil {
+		return nil, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	
+	allowed := make(map[string]bool)
+	for _, source := range tenancy.FilterSources(scope, cfg.Sources) {
+		allowed[source.Name] = true
+	}
+	wanted := make(map[string]bool, len(rotation.Sources))
+	for _, source := range rotation.Sources {
+		if !allowed[source] {
+			return nil, fmt.Errorf("%w: source '%s'", config.ErrNotFound, source)
+		}
+		wanted[source] = true
+	}
+	
+	// Check every matching destination before changing any
+	now := time.Now().UTC()
+	var entries []models.CredentialAuditEntry
+	for _, source := range cfg.Sources {
+		if !allowed[source.Name] || (len(wanted) > 0 && !wanted[source.Name]) {
+			continue
+		}
+		for _, dest := range source.Destinations {
+			if dest.Name != name {
+				continue
+			}
+			if _, err := destinations.WithAPIKey(dest, rotation.APIKey); err != nil {
+				return nil, fmt.Errorf("%w: destination '%s' of source '%s': %v", config.ErrInvalid, dest.Name, source.Name, err)
+			}
+			entries = append(entries, models.CredentialAuditEntry{
+				Time:           now,
+				Actor:          rotation.Actor,
+				Client:         rotation.Client,
+				Tenant:         source.Tenant,
+				Source:         source.Name,
+				Destination:    dest.Name,
+				DestinationID:  dest.ID,
+				Field:          "api_key",
+				OldFingerprint: credentialFingerprint(destinations.APIKey(dest)),
+				NewFingerprint: credentialFingerprint(rotation.APIKey),
+				Reason:         rotation.Reason,
+			})
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: destination '%s'", config.ErrNotFound, name)
+	}
+	
+	for i, entry := range entries {
+		for j := range cfg.Sources {
+			if cfg.Sources[j].Name == entry.Source {
+				cfg.Sources[j].Destinations = destinations.ReplaceAPIKey(cfg.Sources[j].Destinations, entry.DestinationID, rotation.APIKey)
+			}
+		}
+	
+		app.sourceMutex.RLock()
+		source, running := app.sources[entry.Source]
+		app.sourceMutex.RUnlock()
+		if running {
+			entries[i].Live = source.RotateAPIKey(entry.DestinationID, rotation.APIKey)
+		}
+		log.Printf("✓ Rotated API key of destination '%s' of source '%s' (%s → %s, live: %v)", entry.Destination, entry.Source, entry.OldFingerprint, entry.NewFingerprint, entries[i].Live)
+	}
+	app.configManager.UpdateConfig(cfg)
+	
+	// Save configuration
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	if err := app.appendCredentialAudit(entries); err != nil {
+		log.Printf("⚠ Warning: Failed to record credential rotation: %v", err)
+	}
+	return entries, nil
+}
+
+// validateAPIKey checks a new API key can be sent in a request header
+func validateAPIKey(apiKey string) error {
+	if apiKey == "" {
+		return fmt.Errorf("api_key is required")
+	}
+	for _, r := range apiKey {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("api_key must not contain whitespace or control characters")
+		}
+	}
+	return nil
+}
+
+// credentialFingerprint identifies a credential in the audit trail without
+// revealing it
+func credentialFingerprint(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// credentialAuditFile returns the path of the audit trail
+func (app *Application) credentialAuditFile() string {
+	if app.globalSettings.CredentialAuditFile != "" {
+		return app.globalSettings.CredentialAuditFile
+	}
+	return defaultCredentialAuditFile
+}
+
+// appendCredentialAudit appends entries to the audit trail as JSON lines
+func (app *Application) appendCredentialAudit(entries []models.CredentialAuditEntry) error {
+	file, err := os.OpenFile(app.credentialAuditFile(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return file.Sync()
+}
+
+// getCredentialAudit returns the newest rotations of the sources a scope may
+// see, newest first
+func (app *Application) getCredentialAudit(scope tenancy.Scope, limit int) ([]models.CredentialAuditEntry, error) {
+	entries := []models.CredentialAuditEntry{}
+	file, err := os.Open(app.credentialAuditFile())
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry models.CredentialAuditEntry
+		if err := json.Unmarshal([]byte(strings.TrimSpace(scanner.Text())), &entry); err != nil {
+			continue
+		}
+		if scope.Allows(entry.Tenant) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/filtering"
+	"syslog-analyzer/models"
+	"syslog-analyzer/syslog"
+)
+
+// impactHistoryDays is how many recent days of ingest the filter impact
+// estimates are scaled to
+const impactHistoryDays = 7
+
+// estimateFilterImpact evaluates a proposed filter rule set against the
+// recent events of sources, next to their current filters. The dropped
+// share of the sampled bytes is scaled to each source's average daily
+// ingest, or to its daily average metric before a day has been recorded.
+func (app *Application) estimateFilterImpact(request models.FilterImpactRequest) ([]models.FilterImpact, error) {
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return nil, fmt.Errorf("no configuration loaded")
+	}
+	
+	if err := filtering.ValidateRules(request.Filters); err != nil {
+		return nil, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	rules, err := filtering.ExpandSets(cfg.FilterSets, request.FilterSets, request.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	switch request.FilterMatcher {
+	case models.FilterMatcherRules, models.FilterMatcherMulti:
+	default:
+		return nil, fmt.Errorf("%w: unknown filter matcher: %s", config.ErrInvalid, request.FilterMatcher)
+	}
+	switch request.FilterPolicy {
+	case models.FilterPolicyAll, models.FilterPolicyFirst:
+	default:
+		return nil, fmt.Errorf("%w: unknown filter policy: %s", config.ErrInvalid, request.FilterPolicy)
+	}
+	proposed := syslog.NewFilterEngine(models.SourceConfig{
+		Filters:       rules,
+		FilterMatcher: request.FilterMatcher,
+		FilterPolicy:  request.FilterPolicy,
+	})
+	
+	var daily map[string]float64
+	if app.forecastHistory != nil {
+		daily = app.forecastHistory.DailyAverage(time.Now(), impactHistoryDays)
+	}
+	
+	app.sourceMutex.RLock()
+	defer app.sourceMutex.RUnlock()
+	
+	names := request.Sources
+	if len(names) == 0 {
+		for name := range app.sources {
+			names = append(names, name)
+		}
+	}
+	
+	impacts := make([]models.FilterImpact, 0, len(names))
+	for _, name := range names {
+		source, exists := app.sources[name]
+		if !exists {
+			return nil, fmt.Errorf("%w: source '%s'", config.ErrNotFound, name)
+		}
+		sourceConfig := source.GetConfig()
+		current := syslog.NewFilterEngine(runningSource(cfg, sourceConfig))
+		samples := source.GetSamples()
+		
+		impact := models.FilterImpact{
+			Source:  name,
+			Tenant:  sourceConfig.Tenant,
+			Samples: len(samples),
+		}
+		if gb, recorded := daily[name]; recorded {
+			impact.DailyGB = gb
+		} else {
+			impact.DailyGB = source.GetMetrics().DailyAvgGB
+		}
+		
+		impact.CurrentDroppedEvents, impact.CurrentDroppedBytes = droppedShare(current, samples)
+		impact.ProposedDroppedEvents, impact.ProposedDroppedBytes = droppedShare(proposed, samples)
+		impact.ProposedDroppedGBPerDay = impact.DailyGB * impact.ProposedDroppedBytes / 100
+		impact.SavingsGBPerDay = impact.DailyGB * (impact.ProposedDroppedBytes - impact.CurrentDroppedBytes) / 100
+		impacts = append(impacts, impact)
+	}
+	
+	sort.Slice(impacts, func(i, j int) bool {
+		return impacts[i].Source < impacts[j].Source
+	})
+	return impacts, nil
+}
+
+// droppedShare returns the percentages of events and bytes the filters drop
+func droppedShare(engine *filtering.Engine, events []models.LogEvent) (float64, float64) {
+	if len(events) == 0 {
+		return 0, 0
+	}
+	
+	var dropped, droppedBytes, totalBytes int64
+	for _, event := range events {
+		totalBytes += event.Size
+		if !engine.Includes(event) {
+			dropped++
+			droppedBytes += event.Size
+		}
+	}
+	
+	eventShare := float64(dropped) / float64(len(events)) * 100
+	if totalBytes == 0 {
+		return eventShare, 0
+	}
+	return eventShare, float64(droppedBytes) / float64(totalBytes) * 100
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"fmt"
+	"log"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/filtering"
+	"syslog-analyzer/models"
+)
+
+// runningSource returns a source's configuration as its pipeline runs it,
+// with the rules of its filter sets ahead of its own filters
+func runningSource(cfg *models.Config, source models.SourceConfig) models.SourceConfig {
+	rules, err := filtering.ExpandSets(cfg.FilterSets, source.FilterSets, source.Filters)
+	if err != nil {
+		log.Printf("⚠ Source '%s': %v; running its own filters only", source.Name, err)
+		return source
+	}
+	source.Filters = rules
+	return source
+}
+
+// getFilterSets returns the named filter sets
+func (app *Application) getFilterSets() []models.FilterSet {
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return []models.FilterSet{}
+	}
+	return append([]models.FilterSet{}, cfg.FilterSets...)
+}
+
+// putFilterSet creates or replaces a filter set if it matches the
+// precondition, restarting the sources it is attached to
+func (app *Application) putFilterSet(set models.FilterSet, precondition config.Precondition) (models.FilterSet, bool, error) {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return models.FilterSet{}, false, fmt.Errorf("no configuration loaded")
+	}
+	if set.Name == "" {
+		return models.FilterSet{}, false, fmt.Errorf("%w: filter set name is required", config.ErrInvalid)
+	}
+	if err := filtering.ValidateRules(set.Rules); err != nil {
+		return models.FilterSet{}, false, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	
+	existing := filtering.FindSet(cfg.FilterSets, set.Name)
+	currentTag := ""
+	if existing != nil {
+		currentTag = config.ETag(*existing)
+	}
+	if err := precondition.Check(currentTag); err != nil {
+		return models.FilterSet{}, false, err
+	}
+	if existing != nil && config.ETag(set) == currentTag {
+		return set, false, nil
+	}
+	
+	if existing != nil {
+		*existing = set
+	} else {
+		cfg.FilterSets = append(cfg.FilterSets, set)
+	}
+	app.configManager.UpdateConfig(cfg)
+	
+	// Restart the sources using the set so they pick up its new rules
+	for _, source := range filterSetUsers(cfg, set.Name) {
+		if err := app.updateSource(source.Name, source); err != nil {
+			log.Printf("✗ Failed to restart source %s with filter set '%s': %v", source.Name, set.Name, err)
+		}
+	}
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	log.Printf("✓ Stored filter set '%s' (%d rules)", set.Name, len(set.Rules))
+	return set, existing == nil, nil
+}
+
+// deleteFilterSet removes a filter set no source is attached to
+func (app *Application) deleteFilterSet(name string, precondition config.Precondition) error {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
+	existing := filtering.FindSet(cfg.FilterSets, name)
+	if existing == nil {
+		return fmt.Errorf("%w: filter set '%s'", config.ErrNotFound, name)
+	}
+	if err := precondition.Check(config.ETag(*existing)); err != nil {
+		return err
+	}
+	if users := filterSetUsers(cfg, name); len(users) > 0 {
+		return fmt.Errorf("%w: filter set is used by source '%s'", config.ErrInvalid, users[0].Name)
+	}
+	
+	var sets []models.FilterSet
+	for _, set := range cfg.FilterSets {
+		if set.Name != name {
+			sets = append(sets, set)
+		}
+	}
+	cfg.FilterSets = sets
+	app.configManager.UpdateConfig(cfg)
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	
+	log.Printf("✓ Deleted filter set '%s'", name)
+	return nil
+}
+
+// filterSetUsers returns the sources a filter set is attached to
+func filterSetUsers(cfg *models.Config, name string) []models.SourceConfig {
+	var users []models.SourceConfig
+	for _, source := range cfg.Sources {
+		for _, attached := range source.FilterSets {
+			if attached == name {
+				users = append(users, source)
+				break
+			}
+		}
+	}
+	return users
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/gitops"
+	"syslog-analyzer/models"
+	"syslog-analyzer/syslog"
+)
+
+// applyGitOps brings the configured sources in line with a GitOps revision.
+// The revision is validated as a whole first so an invalid one changes
+// nothing; sources it leaves unchanged keep running.
+func (app *Application) applyGitOps(doc gitops.Document) (models.GitOpsChanges, error) {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	changes := models.GitOpsChanges{Added: []string{}, Updated: []string{}, Removed: []string{}}
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return changes, fmt.Errorf("no configuration loaded")
+	}
+	
+	for _, source := range doc.Sources {
+		if err := app.checkSourceFields(source); err != nil {
+			return changes, fmt.Errorf("source '%s': %v", source.Name, err)
+		}
+	}
+	if conflicts := syslog.FindConflicts(doc.Sources, reservedPorts(cfg.GlobalSettings)); len(conflicts) > 0 {
+		return changes, fmt.Errorf("source '%s': %s", conflicts[0].Source, conflicts[0].Reason)
+	}
+	
+	desired := make(map[string]bool)
+	for _, source := range doc.Sources {
+		desired[source.Name] = true
+	}
+	
+	var failures []string
+	
+	// Remove sources first so the ports they free are available to the others
+	var removed []string
+	for _, existing := range cfg.Sources {
+		if !desired[existing.Name] {
+			removed = append(removed, existing.Name)
+		}
+	}
+	for _, name := range removed {
+		if err := app.removeSource(name); err != nil {
+			failures = append(failures, fmt.Sprintf("remove '%s': %v", name, err))
+			continue
+		}
+		changes.Removed = append(changes.Removed, name)
+	}
+	
+	for _, source := range doc.Sources {
+		existing, exists := findSourceConfig(app.configManager.GetConfig(), source.Name)
+		if !exists {
+			if source.ID == "" {
+				source.ID = config.NewID()
+			}
+			if source.CreatedAt.IsZero() {
+				source.CreatedAt = time.Now()
+			}
+			config.AssignDestinationIDs(source.Destinations, nil)
+			if err := app.startNewSource(source); err != nil {
+				failures = append(failures, fmt.Sprintf("add '%s': %v", source.Name, err))
+				continue
+			}
+			changes.Added = append(changes.Added, source.Name)
+			continue
+		}
+		
+		if source.ID == "" {
+			source.ID = existing.ID
+		}
+		if source.CreatedAt.IsZero() {
+			source.CreatedAt = existing.CreatedAt
+		}
+		config.AssignDestinationIDs(source.Destinations, existing.Destinations)
+		if config.ETag(source) == config.ETag(existing) {
+			continue
+		}
+		if err := app.updateSource(existing.Name, source); err != nil {
+			failures = append(failures, fmt.Sprintf("update '%s': %v", source.Name, err))
+			continue
+		}
+		changes.Updated = append(changes.Updated, source.Name)
+	}
+	
+	if len(failures) > 0 {
+		return changes, fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return changes, nil
+}
+
+// getGitOpsStatus returns the state of GitOps sync
+func (app *Application) getGitOpsStatus() models.GitOpsStatus {
+	if app.gitopsSyncer != nil {
+		return app.gitopsSyncer.Status()
+	}
+	return models.GitOpsStatus{
+		Enabled:   app.globalSettings.GitOps.Enabled,
+		LastError: app.gitopsError,
+	}
+}
+
+// syncGitOps syncs immediately instead of waiting for the next interval
+func (app *Application) syncGitOps() error {
+	if app.gitopsSyncer == nil {
+		return fmt.Errorf("GitOps sync is not running")
+	}
+	return app.gitopsSyncer.Sync()
+}
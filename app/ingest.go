@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/ingest"
+	"syslog-analyzer/models"
+)
+
+// getIngestTokens returns the ingest tokens of a source
+func (app *Application) getIngestTokens(sourceRef string) ([]models.IngestTokenStatus, error) {
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return nil, fmt.Errorf("no configuration loaded")
+	}
+	source, exists := findSourceConfig(cfg, sourceRef)
+	if !exists {
+		return nil, fmt.Errorf("%w: source '%s'", config.ErrNotFound, sourceRef)
+	}
+	return app.ingestManager.Status(source.ID), nil
+}
+
+// issueIngestToken issues a new token for a sender to a source
+func (app *Application) issueIngestToken(sourceRef, name string) (models.IssuedIngestToken, error) {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg, source, err := app.ingestSource(sourceRef)
+	if err != nil {
+		return models.IssuedIngestToken{}, err
+	}
+	if name == "" {
+		return models.IssuedIngestToken{}, fmt.Errorf("%w: token name is required", config.ErrInvalid)
+	}
+	
+	stored, token, err := ingest.NewToken(source.ID, name)
+	if err != nil {
+		return models.IssuedIngestToken{}, err
+	}
+	app.setIngestTokens(cfg, append(cfg.IngestTokens, stored))
+	
+	log.Printf("✓ Issued ingest token %s (%s) for source '%s'", stored.Prefix, name, source.Name)
+	return models.IssuedIngestToken{Token: token, IngestTokenStatus: app.ingestManager.TokenStatus(stored)}, nil
+}
+
+// rotateIngestToken replaces a token by a new one for the same sender. The
+// old token keeps working for the grace period so the sender can switch over.
+func (app *Application) rotateIngestToken(sourceRef, id string, grace time.Duration) (models.IssuedIngestToken, error) {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg, source, err := app.ingestSource(sourceRef)
+	if err != nil {
+		return models.IssuedIngestToken{}, err
+	}
+	if grace < 0 {
+		return models.IssuedIngestToken{}, fmt.Errorf("%w: grace period cannot be negative", config.ErrInvalid)
+	}
+	
+	tokens := append([]models.IngestToken(nil), cfg.IngestTokens...)
+	index := findIngestToken(tokens, source.ID, id)
+	if index < 0 {
+		return models.IssuedIngestToken{}, fmt.Errorf("%w: token '%s'", config.ErrNotFound, id)
+	}
+	now := time.Now()
+	if !ingest.Active(tokens[index], now) {
+		return models.IssuedIngestToken{}, fmt.Errorf("%w: token '%s' is no longer active", config.ErrInvalid, id)
+	}
+	
+	stored, token, err := ingest.NewToken(source.ID, tokens[index].Name)
+	if err != nil {
+		return models.IssuedIngestToken{}, err
+	}
+	if grace == 0 {
+		tokens[index].RevokedAt = now
+	} else {
+		tokens[index].ExpiresAt = now.Add(grace)
+	}
+	tokens[index].ReplacedBy = stored.ID
+	app.setIngestTokens(cfg, append(tokens, stored))
+	
+	log.Printf("✓ Rotated ingest token %s of source '%s' to %s (grace %v)", tokens[index].Prefix, source.Name, stored.Prefix, grace)
+	return models.IssuedIngestToken{Token: token, IngestTokenStatus: app.ingestManager.TokenStatus(stored)}, nil
+}
+
+// revokeIngestToken cuts a token off immediately
+func (app *Application) revokeIngestToken(sourceRef, id string) error {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg, source, err := app.ingestSource(sourceRef)
+	if err != nil {
+		return err
+	}
+	
+	tokens := append([]models.IngestToken(nil), cfg.IngestTokens...)
+	index := findIngestToken(tokens, source.ID, id)
+	if index < 0 {
+		return fmt.Errorf("%w: token '%s'", config.ErrNotFound, id)
+	}
+	if tokens[index].RevokedAt.IsZero() {
+		tokens[index].RevokedAt = time.Now()
+		app.setIngestTokens(cfg, tokens)
+		log.Printf("✓ Revoked ingest token %s of source '%s'", tokens[index].Prefix, source.Name)
+	}
+	return nil
+}
+
+// ingestSource returns the configuration and a source whose tokens change
+func (app *Application) ingestSource(sourceRef string) (*models.Config, models.SourceConfig, error) {
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return nil, models.SourceConfig{}, fmt.Errorf("no configuration loaded")
+	}
+	source, exists := findSourceConfig(cfg, sourceRef)
+	if !exists {
+		return nil, models.SourceConfig{}, fmt.Errorf("%w: source '%s'", config.ErrNotFound, sourceRef)
+	}
+	return cfg, source, nil
+}
+
+// findIngestToken returns the index of a source's token, or -1
+func findIngestToken(tokens []models.IngestToken, sourceID, id string) int {
+	for i, token := range tokens {
+		if token.ID == id && token.SourceID == sourceID {
+			return i
+		}
+	}
+	return -1
+}
+
+// setIngestTokens stores the ingest tokens and applies them immediately
+func (app *Application) setIngestTokens(cfg *models.Config, tokens []models.IngestToken) {
+	cfg.IngestTokens = tokens
+	app.configManager.UpdateConfig(cfg)
+	app.ingestManager.SetTokens(tokens)
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+}
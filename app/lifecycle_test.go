@@ -0,0 +1,152 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+	
+	"syslog-analyzer/config"
+	"syslog-analyzer/lifecycle"
+	"syslog-analyzer/models"
+)
+
+// newTestApplication loads a default configuration from a temporary directory
+func newTestApplication(t *testing.T) *Application {
+	t.Helper()
+	app := NewApplication(filepath.Join(t.TempDir(), "config.json"))
+	if err := app.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	t.Cleanup(app.cancel)
+	return app
+}
+
+// freePort returns a port that is free for both UDP and TCP right now
+func freePort(t *testing.T) int {
+	t.Helper()
+	for i := 0; i < 10; i++ {
+		tcp, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := tcp.Addr().(*net.TCPAddr).Port
+		udp, err := net.ListenPacket("udp", fmt.Sprintf("127.0.0.1:%d", port))
+		tcp.Close()
+		if err == nil {
+			udp.Close()
+			return port
+		}
+	}
+	t.Fatal("no free port")
+	return 0
+}
+
+func churnSource(name string, i, port int) models.SourceConfig {
+	return models.SourceConfig{
+		Name:     name,
+		IP:       fmt.Sprintf("127.0.0.%d", i+1),
+		Port:     port,
+		Protocol: models.ProtocolUDPTCP,
+		Destinations: []models.Destination{
+			{Name: "null", Type: "null", Enabled: true},
+		},
+	}
+}
+
+// waitForBaseline waits for the goroutines of stopped instances to exit and
+// returns the owners still running goroutines that were not running before
+func waitForBaseline(baseline map[string]int) map[string]int {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		extra := make(map[string]int)
+		for owner, count := range lifecycle.Active() {
+			if count > baseline[owner] {
+				extra[owner] = count - baseline[owner]
+			}
+		}
+		if len(extra) == 0 || time.Now().After(deadline) {
+			return extra
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// settledLeaks returns the leaks the diagnostics still report once the
+// goroutines of replaced instances had time to exit
+func settledLeaks(app *Application) []models.LifecycleLeak {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		leaks := app.getLifecycle().Leaks
+		if len(leaks) == 0 || time.Now().After(deadline) {
+			return leaks
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestSourceChurnReleasesResources adds, edits and removes sources sharing
+// listeners, moving them between ports on edit, and checks that every
+// listener and pipeline goroutine they started is gone afterwards
+func TestSourceChurnReleasesResources(t *testing.T) {
+	app := newTestApplication(t)
+	ports := []int{freePort(t), freePort(t)}
+	baseline := lifecycle.Active()
+	const sources, cycles = 3, 5
+	
+	for cycle := 0; cycle < cycles; cycle++ {
+		for i := 0; i < sources; i++ {
+			if err := app.addSource(churnSource(fmt.Sprintf("churn-%d", i), i, ports[0])); err != nil {
+				t.Fatalf("cycle %d: add: %v", cycle, err)
+			}
+		}
+		if got := len(app.getLifecycle().Listeners); got != 2 {
+			t.Fatalf("cycle %d: %d listeners after adding, want UDP and TCP", cycle, got)
+		}
+	
+		// Edits restart every source; moving one to the other port starts its listeners
+		for i := 0; i < sources; i++ {
+			name := fmt.Sprintf("churn-%d", i)
+			edited := churnSource(name, i, ports[i%2])
+			edited.SimulationMode = cycle%2 == 0
+			if _, _, err := app.putSource(name, edited, config.Precondition{}); err != nil {
+				t.Fatalf("cycle %d: edit %s: %v", cycle, name, err)
+			}
+		}
+		if got := len(app.getLifecycle().Listeners); got != 4 {
+			t.Fatalf("cycle %d: %d listeners after editing, want 4", cycle, got)
+		}
+		if leaks := settledLeaks(app); len(leaks) > 0 {
+			t.Fatalf("cycle %d: leaks while running: %+v", cycle, leaks)
+		}
+	
+		for i := 0; i < sources; i++ {
+			if err := app.deleteSource(fmt.Sprintf("churn-%d", i), config.Precondition{}); err != nil {
+				t.Fatalf("cycle %d: delete: %v", cycle, err)
+			}
+		}
+	}
+	
+	if extra := waitForBaseline(baseline); len(extra) > 0 {
+		t.Errorf("goroutines still running after churn: %v", extra)
+	}
+	status := app.getLifecycle()
+	if len(status.Sources) != 0 || len(status.Listeners) != 0 {
+		t.Errorf("%d sources and %d listeners still registered", len(status.Sources), len(status.Listeners))
+	}
+	if leaks := settledLeaks(app); len(leaks) != 0 {
+		t.Errorf("leaks: %+v", leaks)
+	}
+	if started, exited := lifecycle.Totals(); started == 0 || started-exited != int64(sumCounts(baseline)) {
+		t.Errorf("%d goroutines started, %d exited, %d running before", started, exited, sumCounts(baseline))
+	}
+}
+
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	return total
+}
@@ -0,0 +1,312 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/models"
+)
+
+// getSourceTemplates returns the templates onboarded devices get
+func (app *Application) getSourceTemplates() []models.SourceTemplate {
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return []models.SourceTemplate{}
+	}
+	return append([]models.SourceTemplate{}, cfg.SourceTemplates...)
+}
+
+// putSourceTemplate creates or replaces a source template if it matches the
+// precondition. Sources already onboarded keep their configuration; they
+// pick up a changed template when their device is onboarded again.
+func (app *Application) putSourceTemplate(template models.SourceTemplate, precondition config.Precondition) (models.SourceTemplate, bool, error) {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return models.SourceTemplate{}, false, fmt.Errorf("no configuration loaded")
+	}
+	if template.Name == "" {
+		return models.SourceTemplate{}, false, fmt.Errorf("%w: template name is required", config.ErrInvalid)
+	}
+	
+	// A template must make a valid source for any device
+	sample, err := instantiateTemplate(template, models.OnboardRequest{Hostname: "template-check", IP: "192.0.2.1"})
+	if err != nil {
+		return models.SourceTemplate{}, false, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	if err := app.checkSourceFields(sample); err != nil {
+		return models.SourceTemplate{}, false, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	for _, other := range cfg.SourceTemplates {
+		if other.Name == template.Name {
+			continue
+		}
+		for _, vendor := range template.Vendors {
+			if containsFold(other.Vendors, vendor) {
+				return models.SourceTemplate{}, false, fmt.Errorf("%w: vendor '%s' is already covered by template '%s'", config.ErrInvalid, vendor, other.Name)
+			}
+		}
+		if len(template.Vendors) == 0 && len(other.Vendors) == 0 {
+			return models.SourceTemplate{}, false, fmt.Errorf("%w: template '%s' already covers other vendors", config.ErrInvalid, other.Name)
+		}
+	}
+	
+	existing := findSourceTemplate(cfg, template.Name)
+	currentTag := ""
+	if existing != nil {
+		currentTag = config.ETag(*existing)
+	}
+	if err := precondition.Check(currentTag); err != nil {
+		return models.SourceTemplate{}, false, err
+	}
+	if existing != nil && config.ETag(template) == currentTag {
+		return template, false, nil
+	}
+	
+	if existing != nil {
+		*existing = template
+	} else {
+		cfg.SourceTemplates = append(cfg.SourceTemplates, template)
+	}
+	app.configManager.UpdateConfig(cfg)
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	log.Printf("✓ Stored source template '%s'", template.Name)
+	return template, existing == nil, nil
+}
+
+// deleteSourceTemplate removes a source template. Sources created from it
+// are copies and keep running.
+func (app *Application) deleteSourceTemplate(name string, precondition config.Precondition) error {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
+	existing := findSourceTemplate(cfg, name)
+	if existing == nil {
+		return fmt.Errorf("%w: source template '%s'", config.ErrNotFound, name)
+	}
+	if err := precondition.Check(config.ETag(*existing)); err != nil {
+		return err
+	}
+	
+	var templates []models.SourceTemplate
+	for _, template := range cfg.SourceTemplates {
+		if template.Name != name {
+			templates = append(templates, template)
+		}
+	}
+	cfg.SourceTemplates = templates
+	app.configManager.UpdateConfig(cfg)
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	
+	log.Printf("✓ Deleted source template '%s'", name)
+	return nil
+}
+
+// onboard creates the source of a device being deployed from the template
+// for its vendor, or brings the device's existing source in line with the
+// template, so provisioning can call it on every run. A device's source is
+// the one named after it, or failing that the one receiving its IP on the
+// template's port.
+func (app *Application) onboard(request models.OnboardRequest) (models.OnboardResult, error) {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return models.OnboardResult{}, fmt.Errorf("no configuration loaded")
+	}
+	if net.ParseIP(request.IP) == nil {
+		return models.OnboardResult{}, fmt.Errorf("%w: ip must be an IP address", config.ErrInvalid)
+	}
+	
+	template, err := selectTemplate(cfg, request)
+	if err != nil {
+		return models.OnboardResult{}, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	source, err := instantiateTemplate(*template, request)
+	if err != nil {
+		return models.OnboardResult{}, err
+	}
+	result := models.OnboardResult{Template: template.Name}
+	
+	existing, exists := findDeviceSource(cfg, source)
+	if !exists {
+		source.ID = config.NewID()
+		source.CreatedAt = time.Now()
+		config.AssignDestinationIDs(source.Destinations, nil)
+		if err := app.checkSource(source, ""); err != nil {
+			return result, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+		}
+		if err := app.startNewSource(source); err != nil {
+			return result, err
+		}
+		log.Printf("✓ Onboarded %s (%s) as source '%s' from template '%s'", request.Hostname, source.IP, source.Name, template.Name)
+		result.Source, result.Created = source, true
+		return result, nil
+	}
+	
+	source.ID = existing.ID
+	source.CreatedAt = existing.CreatedAt
+	config.AssignDestinationIDs(source.Destinations, existing.Destinations)
+	if config.ETag(source) == config.ETag(existing) {
+		result.Source = existing
+		return result, nil
+	}
+	if err := app.checkSource(source, existing.Name); err != nil {
+		return result, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	if err := app.updateSource(existing.Name, source); err != nil {
+		return result, err
+	}
+	log.Printf("✓ Re-onboarded %s (%s) as source '%s' from template '%s'", request.Hostname, source.IP, source.Name, template.Name)
+	result.Source = source
+	return result, nil
+}
+
+// selectTemplate returns the template a request names, or the one listing
+// its vendor, or the one listing no vendors
+func selectTemplate(cfg *models.Config, request models.OnboardRequest) (*models.SourceTemplate, error) {
+	if request.Template != "" {
+		if template := findSourceTemplate(cfg, request.Template); template != nil {
+			return template, nil
+		}
+		return nil, fmt.Errorf("source template '%s' not found", request.Template)
+	}
+	
+	var fallback *models.SourceTemplate
+	for i := range cfg.SourceTemplates {
+		template := &cfg.SourceTemplates[i]
+		if request.Vendor != "" && containsFold(template.Vendors, request.Vendor) {
+			return template, nil
+		}
+		if len(template.Vendors) == 0 {
+			fallback = template
+		}
+	}
+	if fallback == nil {
+		return nil, fmt.Errorf("no source template for vendor '%s'", request.Vendor)
+	}
+	return fallback, nil
+}
+
+// instantiateTemplate builds a device's source from a deep copy of the
+// template, named after the device and tagged with its vendor
+func instantiateTemplate(template models.SourceTemplate, request models.OnboardRequest) (models.SourceConfig, error) {
+	var source models.SourceConfig
+	data, err := json.Marshal(template.Source)
+	if err != nil {
+		return source, err
+	}
+	if err := json.Unmarshal(data, &source); err != nil {
+		return source, err
+	}
+	
+	source.Name = deviceSourceName(request)
+	source.IP = request.IP
+	source.ID = ""
+	source.Disabled = false
+	for i := range source.Destinations {
+		source.Destinations[i].ID = ""
+	}
+	if source.Destinations == nil {
+		source.Destinations = []models.Destination{}
+	}
+	if source.Filters == nil {
+		source.Filters = []models.FilterRule{}
+	}
+	if source.Aggregations == nil {
+		source.Aggregations = []models.AggregationRule{}
+	}
+	
+	tags := append(append([]string{}, source.Tags...), template.Tags...)
+	if request.Vendor != "" {
+		tags = append(tags, "vendor:"+strings.ToLower(request.Vendor))
+	}
+	source.Tags = mergeTags(append(tags, request.Tags...))
+	return source, nil
+}
+
+// findDeviceSource returns the source a device already has: the one named
+// like the source built for it, or the one receiving its IP on that port
+func findDeviceSource(cfg *models.Config, source models.SourceConfig) (models.SourceConfig, bool) {
+	if existing, exists := findSourceConfig(cfg, source.Name); exists {
+		return existing, true
+	}
+	for _, candidate := range cfg.Sources {
+		if candidate.IP == source.IP && candidate.Port == source.Port {
+			return candidate, true
+		}
+	}
+	return models.SourceConfig{}, false
+}
+
+// deviceSourceName makes a valid source name from a device's hostname, or
+// its IP when it has none
+func deviceSourceName(request models.OnboardRequest) string {
+	name := strings.TrimSpace(request.Hostname)
+	if name == "" {
+		name = request.IP
+	}
+	name = strings.Map(func(r rune) rune {
+		if r < 128 && (r == '.' || r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, name)
+	name = strings.TrimLeft(name, ".-_")
+	if len(name) > maxSourceNameLength {
+		name = name[:maxSourceNameLength]
+	}
+	return name
+}
+
+// mergeTags drops empty and repeated tags, keeping the first occurrence
+func mergeTags(tags []string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		merged = append(merged, tag)
+	}
+	return merged
+}
+
+func findSourceTemplate(cfg *models.Config, name string) *models.SourceTemplate {
+	for i := range cfg.SourceTemplates {
+		if cfg.SourceTemplates[i].Name == name {
+			return &cfg.SourceTemplates[i]
+		}
+	}
+	return nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/models"
+)
+
+// findSourceConfig finds a configured source by name or, failing that, by ID
+func findSourceConfig(cfg *models.Config, ref string) (models.SourceConfig, bool) {
+	for _, source := range cfg.Sources {
+		if source.Name == ref {
+			return source, true
+		}
+	}
+	for _, source := range cfg.Sources {
+		if source.ID != "" && source.ID == ref {
+			return source, true
+		}
+	}
+	return models.SourceConfig{}, false
+}
+
+// getSource returns a configured source by name or ID
+func (app *Application) getSource(ref string) (models.SourceConfig, bool) {
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return models.SourceConfig{}, false
+	}
+	return findSourceConfig(cfg, ref)
+}
+
+// putSource creates or replaces the source with the given name or ID if it
+// matches the precondition. Putting a source unchanged is a no-op that leaves
+// it running; IDs and the creation time cannot be changed.
+func (app *Application) putSource(ref string, source models.SourceConfig, precondition config.Precondition) (models.SourceConfig, bool, error) {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return models.SourceConfig{}, false, fmt.Errorf("no configuration loaded")
+	}
+	
+	existing, exists := findSourceConfig(cfg, ref)
+	currentTag := ""
+	if exists {
+		currentTag = config.ETag(existing)
+	}
+	if err := precondition.Check(currentTag); err != nil {
+		return models.SourceConfig{}, false, err
+	}
+	
+	if !exists {
+		if source.Name == "" {
+			source.Name = ref
+		}
+		if source.ID == "" {
+			source.ID = config.NewID()
+		}
+		if source.CreatedAt.IsZero() {
+			source.CreatedAt = time.Now()
+		}
+		config.AssignDestinationIDs(source.Destinations, nil)
+		if err := app.checkSource(source, ""); err != nil {
+			return models.SourceConfig{}, false, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+		}
+		if err := app.startNewSource(source); err != nil {
+			return models.SourceConfig{}, false, err
+		}
+		return source, true, nil
+	}
+	
+	if source.Name == "" {
+		source.Name = existing.Name
+	}
+	if source.ID == "" {
+		source.ID = existing.ID
+	} else if source.ID != existing.ID {
+		return models.SourceConfig{}, false, fmt.Errorf("%w: source ID cannot be changed", config.ErrInvalid)
+	}
+	source.CreatedAt = existing.CreatedAt
+	config.AssignDestinationIDs(source.Destinations, existing.Destinations)
+	
+	// Don't restart a source for a configuration it already runs
+	if config.ETag(source) == currentTag {
+		return existing, false, nil
+	}
+	
+	if err := app.checkSource(source, existing.Name); err != nil {
+		return models.SourceConfig{}, false, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	if err := app.updateSource(existing.Name, source); err != nil {
+		return models.SourceConfig{}, false, err
+	}
+	return source, false, nil
+}
+
+// putDestination creates or replaces the destination with the given ID of a
+// source if it matches the precondition, restarting the source only when
+// the destination changed
+func (app *Application) putDestination(sourceRef, id string, dest models.Destination, precondition config.Precondition) (models.Destination, bool, error) {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return models.Destination{}, false, fmt.Errorf("no configuration loaded")
+	}
+	
+	source, exists := findSourceConfig(cfg, sourceRef)
+	if !exists {
+		return models.Destination{}, false, fmt.Errorf("%w: source '%s'", config.ErrNotFound, sourceRef)
+	}
+	
+	index := -1
+	currentTag := ""
+	for i, existing := range source.Destinations {
+		if existing.ID == id {
+			index = i
+			currentTag = config.ETag(existing)
+			break
+		}
+	}
+	if err := precondition.Check(currentTag); err != nil {
+		return models.Destination{}, false, err
+	}
+	
+	if dest.ID != "" && dest.ID != id {
+		return models.Destination{}, false, fmt.Errorf("%w: destination ID cannot be changed", config.ErrInvalid)
+	}
+	dest.ID = id
+	if index >= 0 && config.ETag(dest) == currentTag {
+		return source.Destinations[index], false, nil
+	}
+	
+	updated := source
+	updated.Destinations = append([]models.Destination(nil), source.Destinations...)
+	if index >= 0 {
+		updated.Destinations[index] = dest
+	} else {
+		updated.Destinations = append(updated.Destinations, dest)
+	}
+	
+	if err := app.checkSource(updated, source.Name); err != nil {
+		return models.Destination{}, false, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	if err := app.updateSource(source.Name, updated); err != nil {
+		return models.Destination{}, false, err
+	}
+	return dest, index < 0, nil
+}
+
+// deleteDestination removes the destination with the given ID from a source
+// if it matches the precondition
+func (app *Application) deleteDestination(sourceRef, id string, precondition config.Precondition) error {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	
+	source, exists := findSourceConfig(cfg, sourceRef)
+	if !exists {
+		return fmt.Errorf("%w: source '%s'", config.ErrNotFound, sourceRef)
+	}
+	
+	updated := source
+	updated.Destinations = []models.Destination{}
+	for _, dest := range source.Destinations {
+		if dest.ID != id {
+			updated.Destinations = append(updated.Destinations, dest)
+			continue
+		}
+		if err := precondition.Check(config.ETag(dest)); err != nil {
+			return err
+		}
+	}
+	if len(updated.Destinations) == len(source.Destinations) {
+		return fmt.Errorf("%w: destination '%s'", config.ErrNotFound, id)
+	}
+	
+	return app.updateSource(source.Name, updated)
+}
+
+// exportConfig exports the configuration with secrets replaced by placeholders
+func (app *Application) exportConfig() (models.ConfigExport, error) {
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return models.ConfigExport{}, fmt.Errorf("no configuration loaded")
+	}
+	return config.Export(cfg)
+}
+
+// diffConfig reports what applying a proposed configuration would change
+func (app *Application) diffConfig(proposed *models.Config) (models.ConfigDiff, error) {
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return models.ConfigDiff{}, fmt.Errorf("no configuration loaded")
+	}
+	return config.Diff(cfg, proposed)
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"fmt"
+	"log"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/models"
+	"syslog-analyzer/syslog"
+	"syslog-analyzer/tuning"
+)
+
+// restartSettings are the settings read only at startup: the web server
+// binds its port once, and sources size their batches when they start
+var restartSettings = []string{"web_port", "batch_size"}
+
+// editableSettings extracts the dashboard-editable part of the global settings
+func editableSettings(settings models.GlobalSettings) models.Settings {
+	return models.Settings{
+		WebPort:               settings.WebPort,
+		MaxMemoryPerSource:    settings.MaxMemoryPerSource,
+		MetricsRetentionHours: settings.MetricsRetentionHours,
+		BatchSize:             settings.BatchSize,
+		MaxEPSPerSource:       settings.MaxEPSPerSource,
+	}
+}
+
+// getSettings returns the editable settings and the changes waiting for a restart
+func (app *Application) getSettings() models.SettingsStatus {
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return models.SettingsStatus{RestartRequired: restartSettings, PendingRestart: []string{}}
+	}
+	
+	saved := editableSettings(cfg.GlobalSettings)
+	running := editableSettings(app.globalSettings)
+	pending := []string{}
+	if saved.WebPort != running.WebPort {
+		pending = append(pending, "web_port")
+	}
+	if saved.BatchSize != running.BatchSize {
+		pending = append(pending, "batch_size")
+	}
+	
+	return models.SettingsStatus{
+		Settings:        saved,
+		RestartRequired: restartSettings,
+		PendingRestart:  pending,
+	}
+}
+
+// updateSettings validates and saves the editable settings
+func (app *Application) updateSettings(settings models.Settings) (models.SettingsStatus, error) {
+	app.resourceMutex.Lock()
+	defer app.resourceMutex.Unlock()
+	
+	cfg := app.configManager.GetConfig()
+	if cfg == nil {
+		return models.SettingsStatus{}, fmt.Errorf("no configuration loaded")
+	}
+	if err := validateSettings(cfg, settings); err != nil {
+		return models.SettingsStatus{}, fmt.Errorf("%w: %v", config.ErrInvalid, err)
+	}
+	
+	cfg.GlobalSettings.WebPort = settings.WebPort
+	cfg.GlobalSettings.MaxMemoryPerSource = settings.MaxMemoryPerSource
+	cfg.GlobalSettings.MetricsRetentionHours = settings.MetricsRetentionHours
+	cfg.GlobalSettings.BatchSize = settings.BatchSize
+	cfg.GlobalSettings.MaxEPSPerSource = settings.MaxEPSPerSource
+	app.configManager.UpdateConfig(cfg)
+	
+	if err := app.SaveConfig(); err != nil {
+		log.Printf("⚠ Warning: Failed to save config: %v", err)
+	}
+	
+	status := app.getSettings()
+	log.Printf("✓ Updated global settings (waiting for restart: %v)", status.PendingRestart)
+	return status, nil
+}
+
+// validateSettings checks editable settings against the rest of the configuration
+func validateSettings(cfg *models.Config, settings models.Settings) error {
+	if settings.WebPort < 1 || settings.WebPort > 65535 {
+		return fmt.Errorf("web_port must be between 1 and 65535")
+	}
+	if settings.MaxMemoryPerSource != "" {
+		if _, err := tuning.ParseSize(settings.MaxMemoryPerSource); err != nil {
+			return fmt.Errorf("max_memory_per_source: %v", err)
+		}
+	}
+	if settings.MetricsRetentionHours < 1 {
+		return fmt.Errorf("metrics_retention_hours must be at least 1")
+	}
+	if settings.BatchSize < 0 {
+		return fmt.Errorf("batch_size cannot be negative (0 uses the default of 1000)")
+	}
+	if settings.MaxEPSPerSource < 0 {
+		return fmt.Errorf("max_eps_per_source cannot be negative")
+	}
+	
+	// The web interface cannot move onto a port a source listens on
+	for _, source := range cfg.Sources {
+		if source.Port != settings.WebPort {
+			continue
+		}
+		for _, transport := range syslog.Transports(source.Protocol) {
+			if syslog.SocketTransport(transport) == "TCP" {
+				return fmt.Errorf("TCP port %d is used by source '%s'", settings.WebPort, source.Name)
+			}
+		}
+	}
+	return nil
+}
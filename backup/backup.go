@@ -0,0 +1,372 @@
+// Package backup copies the analyzer's configuration and state files to a
+// directory or an S3 bucket every night, keeping them for a retention period,
+// so a lost host can be rebuilt from the last backup.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Backup defaults
+const (
+	defaultTime          = "02:00"
+	defaultRetentionDays = 30
+	configEntry          = "syslog_analyzer.json" // Archive entry holding the configuration
+	stateDir             = "state/"               // Archive directory holding the state files
+	namePrefix           = "syslog_analyzer_"
+	nameSuffix           = ".tar.gz"
+	nameTime             = "20060102T150405Z"
+	maxArchiveEntry      = 256 << 20
+)
+
+// SnapshotFunc returns the configuration to back up, as JSON
+type SnapshotFunc func() ([]byte, error)
+
+// store keeps backups by name
+type store interface {
+	location() string
+	put(name string, data []byte) error
+	get(name string) ([]byte, error)
+	list() ([]models.BackupInfo, error)
+	remove(name string) error
+}
+
+// Scheduler backs up the configuration and state files once a day at the
+// configured local time, deleting backups past the retention period
+type Scheduler struct {
+	config     models.BackupConfig
+	store      store
+	snapshot   SnapshotFunc
+	stateFiles []string
+	status     models.BackupStatus
+	lastRun    string     // Date (YYYY-MM-DD) of the last scheduled backup
+	mutex      sync.Mutex // Guards status
+	runMutex   sync.Mutex // Serializes backups
+	stopChan   chan bool
+}
+
+// NewScheduler creates a backup scheduler for config, validating it.
+// stateFiles are copied into each backup when they exist.
+func NewScheduler(config models.BackupConfig, snapshot SnapshotFunc, stateFiles []string) (*Scheduler, error) {
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
+	if config.Time == "" {
+		config.Time = defaultTime
+	}
+	if config.RetentionDays == 0 {
+		config.RetentionDays = defaultRetentionDays
+	}
+	
+	var target store
+	if config.S3.Bucket != "" {
+		target = newS3Store(config.S3)
+	} else {
+		target = &dirStore{dir: config.Path}
+	}
+	
+	return &Scheduler{
+		config:     config,
+		store:      target,
+		snapshot:   snapshot,
+		stateFiles: stateFiles,
+		status:     models.BackupStatus{Enabled: true, Location: target.location(), Time: config.Time},
+		stopChan:   make(chan bool),
+	}, nil
+}
+
+// Validate checks a backup configuration
+func Validate(config models.BackupConfig) error {
+	if config.Time != "" {
+		if _, err := time.Parse("15:04", config.Time); err != nil {
+			return fmt.Errorf("invalid backup time %q (expected HH:MM)", config.Time)
+		}
+	}
+	if config.RetentionDays < 0 {
+		return fmt.Errorf("backup retention_days cannot be negative")
+	}
+	if (config.Path == "") == (config.S3.Bucket == "") {
+		return fmt.Errorf("exactly one of backup path and s3 bucket is required")
+	}
+	if config.S3.Bucket != "" && (config.S3.AccessKeyID == "" || config.S3.SecretAccessKey == "") {
+		return fmt.Errorf("backup s3 access_key_id and secret_access_key are required")
+	}
+	return nil
+}
+
+// Start begins the daily backups
+func (s *Scheduler) Start() {
+	go s.run()
+	log.Printf("✓ Daily backup to %s scheduled at %s, kept %d days", s.store.location(), s.config.Time, s.config.RetentionDays)
+}
+
+// Stop stops the scheduler
+func (s *Scheduler) Stop() {
+	close(s.stopChan)
+}
+
+// run checks once a minute and backs up when due
+func (s *Scheduler) run() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			today := now.Format("2006-01-02")
+			if now.Format("15:04") >= s.config.Time && s.lastRun != today {
+				s.lastRun = today
+				if _, err := s.BackupNow(); err != nil {
+					log.Printf("✗ Scheduled backup failed: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// BackupNow writes a backup immediately and applies the retention period
+func (s *Scheduler) BackupNow() (models.BackupInfo, error) {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+	
+	now := time.Now()
+	info, err := s.backup(now)
+	
+	s.mutex.Lock()
+	s.status.LastAttempt = now
+	if err != nil {
+		s.status.LastError = err.Error()
+	} else {
+		s.status.LastError = ""
+		s.status.LastSuccess = now
+		s.status.LastBackup = info.Name
+	}
+	s.mutex.Unlock()
+	
+	if err != nil {
+		return info, err
+	}
+	log.Printf("✓ Backed up configuration to %s (%d bytes)", info.Name, info.Size)
+	s.prune(now, info.Name)
+	return info, nil
+}
+
+// backup archives the configuration and state files and stores the archive
+func (s *Scheduler) backup(now time.Time) (models.BackupInfo, error) {
+	config, err := s.snapshot()
+	if err != nil {
+		return models.BackupInfo{}, fmt.Errorf("failed to snapshot configuration: %v", err)
+	}
+	
+	var buffer bytes.Buffer
+	gz := gzip.NewWriter(&buffer)
+	archive := tar.NewWriter(gz)
+	if err := addEntry(archive, configEntry, config, now); err != nil {
+		return models.BackupInfo{}, err
+	}
+	for _, file := range s.stateFiles {
+		data, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return models.BackupInfo{}, fmt.Errorf("failed to read state file: %v", err)
+		}
+		if err := addEntry(archive, stateDir+filepath.Base(file), data, now); err != nil {
+			return models.BackupInfo{}, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return models.BackupInfo{}, err
+	}
+	if err := gz.Close(); err != nil {
+		return models.BackupInfo{}, err
+	}
+	
+	info := models.BackupInfo{
+		Name:      namePrefix + now.UTC().Format(nameTime) + nameSuffix,
+		Size:      int64(buffer.Len()),
+		CreatedAt: now.UTC().Truncate(time.Second),
+	}
+	if err := s.store.put(info.Name, buffer.Bytes()); err != nil {
+		return info, fmt.Errorf("failed to store backup: %v", err)
+	}
+	return info, nil
+}
+
+// addEntry adds a file to the archive
+func addEntry(archive *tar.Writer, name string, data []byte, modified time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: modified}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := archive.Write(data)
+	return err
+}
+
+// prune deletes backups older than the retention period, never the one just
+// written
+func (s *Scheduler) prune(now time.Time, keep string) {
+	backups, err := s.store.list()
+	if err != nil {
+		log.Printf("⚠ Failed to list backups for retention: %v", err)
+		return
+	}
+	cutoff := now.AddDate(0, 0, -s.config.RetentionDays)
+	for _, backup := range backups {
+		if backup.Name == keep || !backup.CreatedAt.Before(cutoff) {
+			continue
+		}
+		if err := s.store.remove(backup.Name); err != nil {
+			log.Printf("⚠ Failed to delete expired backup %s: %v", backup.Name, err)
+			continue
+		}
+		log.Printf("✓ Deleted expired backup %s", backup.Name)
+	}
+}
+
+// Status returns the state of backups and the backups kept
+func (s *Scheduler) Status() models.BackupStatus {
+	s.mutex.Lock()
+	status := s.status
+	s.mutex.Unlock()
+	
+	backups, err := s.store.list()
+	if err != nil {
+		if status.LastError == "" {
+			status.LastError = fmt.Sprintf("failed to list backups: %v", err)
+		}
+		backups = []models.BackupInfo{}
+	}
+	status.Backups = backups
+	return status
+}
+
+// Load reads the configuration kept in a backup
+func (s *Scheduler) Load(name string) (*models.Config, error) {
+	if !validName(name) {
+		return nil, fmt.Errorf("invalid backup name %q", name)
+	}
+	data, err := s.store.get(name)
+	if err != nil {
+		return nil, err
+	}
+	
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("backup %s is not a gzip archive: %v", name, err)
+	}
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("backup %s holds no configuration", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("backup %s is damaged: %v", name, err)
+		}
+		if header.Name != configEntry {
+			continue
+		}
+		content, err := ioutil.ReadAll(io.LimitReader(archive, maxArchiveEntry))
+		if err != nil {
+			return nil, fmt.Errorf("backup %s is damaged: %v", name, err)
+		}
+		config := &models.Config{}
+		if err := json.Unmarshal(content, config); err != nil {
+			return nil, fmt.Errorf("backup %s holds an invalid configuration: %v", name, err)
+		}
+		return config, nil
+	}
+}
+
+// validName reports whether name is one this package writes, so restore
+// requests can't address other objects or paths
+func validName(name string) bool {
+	if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, nameSuffix) {
+		return false
+	}
+	_, err := time.Parse(nameTime, strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), nameSuffix))
+	return err == nil
+}
+
+// backupInfo describes a stored object, or reports false when it is not a
+// backup
+func backupInfo(name string, size int64) (models.BackupInfo, bool) {
+	if !validName(name) {
+		return models.BackupInfo{}, false
+	}
+	created, _ := time.Parse(nameTime, strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), nameSuffix))
+	return models.BackupInfo{Name: name, Size: size, CreatedAt: created}, true
+}
+
+// sortBackups orders backups oldest first
+func sortBackups(backups []models.BackupInfo) {
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.Before(backups[j].CreatedAt) })
+}
+
+// dirStore keeps backups in a directory, which may be a mounted network share
+type dirStore struct {
+	dir string
+}
+
+func (d *dirStore) location() string {
+	return d.dir
+}
+
+// put writes through a temporary file so a partly written backup never
+// carries a backup's name
+func (d *dirStore) put(name string, data []byte) error {
+	if err := os.MkdirAll(d.dir, 0700); err != nil {
+		return err
+	}
+	temp := filepath.Join(d.dir, name+".tmp")
+	if err := ioutil.WriteFile(temp, data, 0600); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	return os.Rename(temp, filepath.Join(d.dir, name))
+}
+
+func (d *dirStore) get(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(d.dir, name))
+}
+
+func (d *dirStore) list() ([]models.BackupInfo, error) {
+	entries, err := ioutil.ReadDir(d.dir)
+	if os.IsNotExist(err) {
+		return []models.BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	backups := []models.BackupInfo{}
+	for _, entry := range entries {
+		if info, ok := backupInfo(entry.Name(), entry.Size()); ok && !entry.IsDir() {
+			backups = append(backups, info)
+		}
+	}
+	sortBackups(backups)
+	return backups, nil
+}
+
+func (d *dirStore) remove(name string) error {
+	return os.Remove(filepath.Join(d.dir, name))
+}
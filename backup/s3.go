@@ -0,0 +1,210 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// s3Timeout bounds one S3 request
+const s3Timeout = 5 * time.Minute
+
+// s3Store keeps backups in an S3 bucket. Requests are signed with AWS
+// Signature Version 4 by hand, like the exporter encodes remote write by
+// hand, so no SDK is needed.
+type s3Store struct {
+	config models.S3Config
+	client *http.Client
+}
+
+func newS3Store(config models.S3Config) *s3Store {
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	return &s3Store{config: config, client: &http.Client{Timeout: s3Timeout}}
+}
+
+func (s *s3Store) location() string {
+	return "s3://" + s.config.Bucket + "/" + s.config.Prefix
+}
+
+func (s *s3Store) put(name string, data []byte) error {
+	_, err := s.do("PUT", s.config.Prefix+name, nil, data)
+	return err
+}
+
+func (s *s3Store) get(name string) ([]byte, error) {
+	return s.do("GET", s.config.Prefix+name, nil, nil)
+}
+
+func (s *s3Store) remove(name string) error {
+	_, err := s.do("DELETE", s.config.Prefix+name, nil, nil)
+	return err
+}
+
+// list pages through the objects under the prefix
+func (s *s3Store) list() ([]models.BackupInfo, error) {
+	backups := []models.BackupInfo{}
+	token := ""
+	for {
+		query := map[string]string{"list-type": "2", "prefix": s.config.Prefix}
+		if token != "" {
+			query["continuation-token"] = token
+		}
+		body, err := s.do("GET", "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+	
+		var result struct {
+			Contents []struct {
+				Key  string `xml:"Key"`
+				Size int64  `xml:"Size"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("invalid S3 listing: %v", err)
+		}
+		for _, object := range result.Contents {
+			if info, ok := backupInfo(strings.TrimPrefix(object.Key, s.config.Prefix), object.Size); ok {
+				backups = append(backups, info)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sortBackups(backups)
+	return backups, nil
+}
+
+// do sends a signed request for an object key, or for the bucket when key is
+// empty, and returns the response body
+func (s *s3Store) do(method, key string, query map[string]string, body []byte) ([]byte, error) {
+	path := "/" + uriEncode(s.config.Bucket, false)
+	if key != "" {
+		path += "/" + uriEncode(key, true)
+	}
+	canonicalQuery := canonicalQueryString(query)
+	url := s.config.Endpoint + path
+	if canonicalQuery != "" {
+		url += "?" + canonicalQuery
+	}
+	
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, path, canonicalQuery, body, time.Now().UTC())
+	
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxArchiveEntry))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var s3Error struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		if xml.Unmarshal(content, &s3Error) == nil && s3Error.Code != "" {
+			return nil, fmt.Errorf("S3 %s %s: %s: %s", method, path, s3Error.Code, s3Error.Message)
+		}
+		return nil, fmt.Errorf("S3 %s %s: HTTP %d", method, path, resp.StatusCode)
+	}
+	return content, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to a request
+func (s *s3Store) sign(req *http.Request, path, canonicalQuery string, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	req.Header.Set("x-amz-date", amzDate)
+	
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery,
+		"host:" + req.URL.Host + "\n" + "x-amz-content-sha256:" + payloadHash + "\n" + "x-amz-date:" + amzDate + "\n",
+		"host;x-amz-content-sha256;x-amz-date",
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=%s", s.config.AccessKeyID, scope, signature))
+}
+
+// canonicalQueryString encodes query parameters sorted by name, as signing
+// requires
+func canonicalQueryString(query map[string]string) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = uriEncode(name, false) + "=" + uriEncode(query[name], false)
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters, and
+// slashes when keepSlash is set
+func uriEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,68 @@
+package chargeback
+
+import (
+	"sort"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// daysPerMonth is the month costs are projected over
+const daysPerMonth = 30
+
+// bytesPerGB matches the GB the rest of the metrics are reported in
+const bytesPerGB = 1024 * 1024 * 1024
+
+// Costs projects the monthly cost of every metered destination from the
+// bytes it was delivered and the bytes its source ingested since metering
+// started. Destinations with no cost_per_gb are left out.
+func Costs(sources []models.SourceMetrics, now time.Time) models.CostReport {
+	report := models.CostReport{GeneratedAt: now, Destinations: []models.DestinationCost{}}
+	for _, source := range sources {
+		for _, dest := range source.Destinations {
+			if dest.MeteredSince.IsZero() {
+				continue
+			}
+			
+			cost := models.DestinationCost{
+				Source:          source.Name,
+				Destination:     dest.Name,
+				DestinationType: dest.Type,
+				CostPerGB:       dest.CostPerGB,
+				MeteredSince:    dest.MeteredSince,
+			}
+			if elapsed := now.Sub(dest.MeteredSince); elapsed > 0 {
+				scale := float64(daysPerMonth*24*time.Hour) / float64(elapsed) / bytesPerGB
+				cost.MonthlyGB = float64(dest.DeliveredBytes) * scale
+				cost.RawMonthlyGB = float64(dest.IngestedBytes) * scale
+			}
+			cost.MonthlyCost = cost.MonthlyGB * cost.CostPerGB
+			cost.RawMonthlyCost = cost.RawMonthlyGB * cost.CostPerGB
+			cost.MonthlySavings = cost.RawMonthlyCost - cost.MonthlyCost
+			cost.SavingsPercent = savingsPercent(cost.MonthlySavings, cost.RawMonthlyCost)
+			
+			report.Destinations = append(report.Destinations, cost)
+			report.MonthlyCost += cost.MonthlyCost
+			report.RawMonthlyCost += cost.RawMonthlyCost
+		}
+	}
+	report.MonthlySavings = report.RawMonthlyCost - report.MonthlyCost
+	report.SavingsPercent = savingsPercent(report.MonthlySavings, report.RawMonthlyCost)
+	
+	// Most expensive first
+	sort.Slice(report.Destinations, func(i, j int) bool {
+		if report.Destinations[i].MonthlyCost != report.Destinations[j].MonthlyCost {
+			return report.Destinations[i].MonthlyCost > report.Destinations[j].MonthlyCost
+		}
+		return report.Destinations[i].Source+report.Destinations[i].Destination < report.Destinations[j].Source+report.Destinations[j].Destination
+	})
+	return report
+}
+
+// savingsPercent returns savings as a percentage of raw, or 0 without raw cost
+func savingsPercent(savings, raw float64) float64 {
+	if raw <= 0 {
+		return 0
+	}
+	return savings / raw * 100
+}
@@ -0,0 +1,36 @@
+package chargeback
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+)
+
+// Filename returns the download name of a month's chargeback CSV
+func Filename(month string) string {
+	return fmt.Sprintf("chargeback-%s.csv", month)
+}
+
+// CSV renders usage rows as a chargeback CSV
+func CSV(month string, rows []Usage) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	
+	writer.Write([]string{"month", "kind", "name", "events", "ingested_gb", "peak_eps"})
+	for _, row := range rows {
+		writer.Write([]string{
+			month,
+			row.Kind,
+			row.Name,
+			fmt.Sprintf("%d", row.Events),
+			fmt.Sprintf("%.6f", float64(row.Bytes)/(1024*1024*1024)),
+			fmt.Sprintf("%.2f", row.PeakEPS),
+		})
+	}
+	
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return buf.Bytes(), nil
+}
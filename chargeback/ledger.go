@@ -0,0 +1,312 @@
+package chargeback
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"syslog-analyzer/digest"
+	"syslog-analyzer/models"
+)
+
+// Usage kinds a row is attributed to
+const (
+	KindTenant     = "tenant"
+	KindGroup      = "group"
+	KindUnassigned = "unassigned"
+)
+
+// monthsRetained bounds how much history the ledger keeps
+const monthsRetained = 13
+
+// Usage is the consumption of one tenant or group in one month
+type Usage struct {
+	Kind    string  `json:"kind"`
+	Name    string  `json:"name"`
+	Events  int64   `json:"events"`
+	Bytes   int64   `json:"bytes"`
+	PeakEPS float64 `json:"peak_eps"`
+}
+
+// counters remembers a source's cumulative counters at the last sample
+type counters struct {
+	logs  int64
+	bytes int64
+}
+
+// ledgerFile is the persisted form of the ledger
+type ledgerFile struct {
+	Months   map[string]map[string]*Usage `json:"months"`
+	LastSent string                       `json:"last_sent"` // Month (YYYY-MM) of the last e-mailed CSV
+}
+
+// Ledger accumulates monthly usage per tenant and group and optionally
+// e-mails the previous month's chargeback CSV
+type Ledger struct {
+	config      models.ChargebackConfig
+	mailer      *digest.Mailer
+	metricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)
+	data        ledgerFile
+	last        map[string]counters
+	mutex       sync.Mutex
+	stopChan    chan bool
+}
+
+// NewLedger creates a ledger and loads previously recorded usage
+func NewLedger(config models.ChargebackConfig, metricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)) (*Ledger, error) {
+	if config.SendDay < 1 || config.SendDay > 28 {
+		return nil, fmt.Errorf("invalid chargeback send day %d (expected 1-28)", config.SendDay)
+	}
+	if _, err := time.Parse("15:04", config.SendTime); err != nil {
+		return nil, fmt.Errorf("invalid chargeback send time %q (expected HH:MM)", config.SendTime)
+	}
+	if config.Enabled && len(config.Recipients) == 0 {
+		return nil, fmt.Errorf("no chargeback recipients configured")
+	}
+	
+	l := &Ledger{
+		config:      config,
+		mailer:      digest.NewMailer(config.SMTP),
+		metricsFunc: metricsFunc,
+		data:        ledgerFile{Months: make(map[string]map[string]*Usage)},
+		last:        make(map[string]counters),
+		stopChan:    make(chan bool),
+	}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	
+	return l, nil
+}
+
+// Start begins sampling metrics and sending scheduled exports
+func (l *Ledger) Start() {
+	go l.run()
+	if l.config.Enabled {
+		log.Printf("✓ Monthly chargeback export scheduled on day %d at %s for %d recipients", l.config.SendDay, l.config.SendTime, len(l.config.Recipients))
+	}
+}
+
+// Stop stops sampling and persists the ledger
+func (l *Ledger) Stop() {
+	close(l.stopChan)
+	
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if err := l.save(); err != nil {
+		log.Printf("✗ Failed to save chargeback ledger: %v", err)
+	}
+}
+
+// run samples once a minute and sends the export when due
+func (l *Ledger) run() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	
+	l.sample(time.Now())
+	
+	for {
+		select {
+		case <-l.stopChan:
+			return
+		case now := <-ticker.C:
+			l.sample(now)
+	
+			// Months recorded before the ledger existed have nothing to send
+			previous := now.AddDate(0, 0, -now.Day()).Format("2006-01")
+			if l.config.Enabled && now.Day() >= l.config.SendDay && now.Format("15:04") >= l.config.SendTime && l.lastSent() != previous && len(l.GetUsage(previous)) > 0 {
+				if err := l.SendMonth(previous); err != nil {
+					log.Printf("✗ Failed to send chargeback export: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// sample attributes the traffic since the previous sample to the current month
+func (l *Ledger) sample(now time.Time) {
+	if l.metricsFunc == nil {
+		return
+	}
+	sources, _ := l.metricsFunc()
+	
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	month := now.Format("2006-01")
+	usage, exists := l.data.Months[month]
+	if !exists {
+		usage = make(map[string]*Usage)
+		l.data.Months[month] = usage
+		l.prune()
+	}
+	
+	eps := make(map[string]float64)
+	for _, source := range sources {
+		// Counters only start from zero again when explicitly reset
+		previous := l.last[source.Name]
+		if source.TotalLogsIngested < previous.logs {
+			previous = counters{}
+		}
+		events := source.TotalLogsIngested - previous.logs
+		bytes := source.TotalEventBytes - previous.bytes
+		l.last[source.Name] = counters{logs: source.TotalLogsIngested, bytes: source.TotalEventBytes}
+	
+		for _, key := range attribution(source) {
+			entry, exists := usage[key.id()]
+			if !exists {
+				entry = &Usage{Kind: key.kind, Name: key.name}
+				usage[key.id()] = entry
+			}
+			entry.Events += events
+			entry.Bytes += bytes
+			eps[key.id()] += source.RealTimeEPS
+		}
+	}
+	
+	for id, total := range eps {
+		if total > usage[id].PeakEPS {
+			usage[id].PeakEPS = total
+		}
+	}
+	
+	if err := l.save(); err != nil {
+		log.Printf("✗ Failed to save chargeback ledger: %v", err)
+	}
+}
+
+// usageKey identifies a chargeback row
+type usageKey struct {
+	kind string
+	name string
+}
+
+func (k usageKey) id() string {
+	return k.kind + ":" + k.name
+}
+
+// attribution returns the rows a source's traffic is charged to. A source
+// with both a tenant and a group is counted in each, so totals are per kind.
+func attribution(source models.SourceMetrics) []usageKey {
+	var keys []usageKey
+	if source.Tenant != "" {
+		keys = append(keys, usageKey{KindTenant, source.Tenant})
+	}
+	if source.Group != "" {
+		keys = append(keys, usageKey{KindGroup, source.Group})
+	}
+	if len(keys) == 0 {
+		keys = append(keys, usageKey{KindUnassigned, ""})
+	}
+	return keys
+}
+
+// prune drops months beyond the retention window; callers hold the mutex
+func (l *Ledger) prune() {
+	months := l.monthsLocked()
+	for len(months) > monthsRetained {
+		delete(l.data.Months, months[0])
+		months = months[1:]
+	}
+}
+
+// Months returns the months with recorded usage, oldest first
+func (l *Ledger) Months() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.monthsLocked()
+}
+
+func (l *Ledger) monthsLocked() []string {
+	months := make([]string, 0, len(l.data.Months))
+	for month := range l.data.Months {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+	return months
+}
+
+// GetUsage returns the usage rows of a month ordered by kind and name
+func (l *Ledger) GetUsage(month string) []Usage {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	rows := []Usage{}
+	for _, usage := range l.data.Months[month] {
+		rows = append(rows, *usage)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Kind != rows[j].Kind {
+			return rows[i].Kind < rows[j].Kind
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// SendMonth e-mails the chargeback CSV of a month
+func (l *Ledger) SendMonth(month string) error {
+	csvData, err := CSV(month, l.GetUsage(month))
+	if err != nil {
+		return err
+	}
+	
+	subject := fmt.Sprintf("Syslog Analyzer chargeback - %s", month)
+	body := fmt.Sprintf("Attached is the ingest chargeback report for %s.\n", month)
+	if err := l.mailer.SendAttachment(l.config.Recipients, subject, body, Filename(month), "text/csv", csvData); err != nil {
+		return err
+	}
+	
+	l.mutex.Lock()
+	l.data.LastSent = month
+	if err := l.save(); err != nil {
+		log.Printf("✗ Failed to save chargeback ledger: %v", err)
+	}
+	l.mutex.Unlock()
+	
+	log.Printf("✓ Chargeback export for %s sent", month)
+	return nil
+}
+
+func (l *Ledger) lastSent() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.data.LastSent
+}
+
+// load reads the persisted ledger if present
+func (l *Ledger) load() error {
+	data, err := ioutil.ReadFile(l.config.DataFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read chargeback ledger: %v", err)
+	}
+	if err := json.Unmarshal(data, &l.data); err != nil {
+		return fmt.Errorf("failed to parse chargeback ledger: %v", err)
+	}
+	if l.data.Months == nil {
+		l.data.Months = make(map[string]map[string]*Usage)
+	}
+	return nil
+}
+
+// save writes the ledger to disk; callers hold the mutex
+func (l *Ledger) save() error {
+	data, err := json.MarshalIndent(l.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal chargeback ledger: %v", err)
+	}
+	
+	tmpFile := l.config.DataFile + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write chargeback ledger: %v", err)
+	}
+	return os.Rename(tmpFile, l.config.DataFile)
+}
@@ -0,0 +1,58 @@
+// Command collectorimport proposes analyzer sources for an existing rsyslog
+// or syslog-ng collector, from its configuration. The proposal is printed as
+// a GitOps document on stdout, ready to review and commit or to edit into the
+// analyzer's configuration; what could not be carried over is listed on
+// stderr.
+//
+//	go run ./cmd/collectorimport /etc/rsyslog.conf > sources.json
+//	go run ./cmd/collectorimport -format syslog-ng /etc/syslog-ng/syslog-ng.conf
+//
+// PATH may be a directory, whose *.conf files are read in name order. It
+// exits 0 when a proposal was printed and 2 on error.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"syslog-analyzer/gitops"
+	"syslog-analyzer/migrate"
+)
+
+func main() {
+	var (
+		format = flag.String("format", "", "Collector format, rsyslog or syslog-ng; detected when empty")
+		asJSON = flag.Bool("json", false, "Print the whole import, with files read and warnings, as JSON")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: collectorimport [flags] PATH\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	
+	result, err := migrate.Import(flag.Arg(0), *format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "collectorimport: %v\n", err)
+		os.Exit(2)
+	}
+	
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if *asJSON {
+		encoder.Encode(result)
+		return
+	}
+	encoder.Encode(gitops.Document{Sources: result.Sources})
+	
+	fmt.Fprintf(os.Stderr, "Read %d %s files, proposed %d sources\n", len(result.Files), result.Format, len(result.Sources))
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+}
@@ -0,0 +1,175 @@
+// Command configdiff reports what promoting a configuration to an analyzer
+// would change: the sources and destinations it would add, change or remove
+// and the settings it would change. It diffs against a running analyzer
+// through its API, or against a local configuration file, so a rollout from
+// lab to production can be reviewed before it is applied.
+//
+//	go run ./cmd/configdiff -server http://prod:8080 -token $ADMIN_TOKEN lab.json
+//	go run ./cmd/configdiff -running prod.json lab.json
+//
+// It exits 0 when the configurations are in sync, 1 when they differ and 2
+// on error.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/models"
+)
+
+func main() {
+	var (
+		server  = flag.String("server", "", "Base URL of the analyzer to diff against, e.g. http://localhost:8080")
+		token   = flag.String("token", "", "Super-admin API token of the analyzer")
+		running = flag.String("running", "", "Configuration file to diff against instead of an analyzer")
+		asJSON  = flag.Bool("json", false, "Print the diff as JSON")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: configdiff (-server URL | -running FILE) [flags] PROPOSED\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	
+	if flag.NArg() != 1 || (*server == "") == (*running == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+	
+	proposed, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	
+	var diff models.ConfigDiff
+	if *server != "" {
+		diff, err = remoteDiff(*server, *token, proposed)
+	} else {
+		diff, err = localDiff(*running, proposed)
+	}
+	if err != nil {
+		fail(err)
+	}
+	
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(diff)
+	} else {
+		printDiff(diff)
+	}
+	if !diff.InSync {
+		os.Exit(1)
+	}
+}
+
+// fail reports an error and exits
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "configdiff: %v\n", err)
+	os.Exit(2)
+}
+
+// remoteDiff has an analyzer diff its running configuration
+func remoteDiff(server, token string, proposed []byte) (models.ConfigDiff, error) {
+	var diff models.ConfigDiff
+	req, err := http.NewRequest("POST", strings.TrimRight(server, "/")+"/api/config/diff", bytes.NewReader(proposed))
+	if err != nil {
+		return diff, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return diff, err
+	}
+	defer resp.Body.Close()
+	
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return diff, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return diff, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	err = json.Unmarshal(body, &diff)
+	return diff, err
+}
+
+// localDiff diffs two configuration files
+func localDiff(runningFile string, proposed []byte) (models.ConfigDiff, error) {
+	data, err := ioutil.ReadFile(runningFile)
+	if err != nil {
+		return models.ConfigDiff{}, err
+	}
+	current, err := config.DecodeConfig(data)
+	if err != nil {
+		return models.ConfigDiff{}, fmt.Errorf("%s: %v", runningFile, err)
+	}
+	desired, err := config.DecodeConfig(proposed)
+	if err != nil {
+		return models.ConfigDiff{}, fmt.Errorf("%s: %v", flag.Arg(0), err)
+	}
+	return config.Diff(current, desired)
+}
+
+// changeMarks are the diff-style marks of each kind of change
+var changeMarks = map[string]string{
+	models.ChangeAdded:   "+",
+	models.ChangeChanged: "~",
+	models.ChangeRemoved: "-",
+}
+
+// printDiff prints a diff for review
+func printDiff(diff models.ConfigDiff) {
+	if diff.InSync {
+		fmt.Println("Configurations are in sync")
+		return
+	}
+	
+	for _, source := range diff.Sources {
+		fmt.Printf("%s source %s\n", changeMarks[source.Change], source.Name)
+		printFields("    ", source.Fields)
+		for _, dest := range source.Destinations {
+			fmt.Printf("    %s destination %s\n", changeMarks[dest.Change], dest.Name)
+			printFields("        ", dest.Fields)
+		}
+	}
+	if len(diff.Settings) > 0 {
+		fmt.Println("~ settings")
+		printFields("    ", diff.Settings)
+	}
+	
+	fmt.Printf("\n%d sources added, %d changed, %d removed; %d settings changed\n",
+		diff.Added, diff.Changed, diff.Removed, len(diff.Settings))
+}
+
+// printFields prints changed fields as "field: from -> to"
+func printFields(indent string, fields []models.FieldChange) {
+	for _, field := range fields {
+		fmt.Printf("%s%s: %s -> %s\n", indent, field.Field, formatValue(field.From), formatValue(field.To))
+	}
+}
+
+// formatValue formats a value as compact JSON
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "(unset)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
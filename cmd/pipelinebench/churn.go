@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"syslog-analyzer/lifecycle"
+	"syslog-analyzer/models"
+	"syslog-analyzer/syslog"
+)
+
+// settleTimeout bounds how long the churn run waits for stopped goroutines to exit
+const settleTimeout = 5 * time.Second
+
+// ChurnOptions configures the source churn run
+type ChurnOptions struct {
+	Port    int
+	Sources int // Sources added, edited and removed concurrently on the port
+	Cycles  int // Add/edit/remove cycles per source
+}
+
+// ChurnResult is the accounting left over after the churn run; anything
+// above zero is a leak
+type ChurnResult struct {
+	Sources          int      `json:"sources"`
+	Cycles           int      `json:"cycles"`
+	Seconds          float64  `json:"seconds"`
+	GoroutinesBefore int      `json:"goroutines_before"`
+	GoroutinesAfter  int      `json:"goroutines_after"`
+	Listeners        int      `json:"listeners"` // Listeners still registered
+	Leaks            []string `json:"leaks,omitempty"`
+}
+
+// runChurn adds, edits and removes sources sharing one UDP+TCP port from
+// several goroutines at once, with a TCP connection open to each, and checks
+// that every listener and pipeline goroutine they started is gone afterwards
+func runChurn(options ChurnOptions) (*ChurnResult, error) {
+	if options.Sources < 1 || options.Cycles < 1 {
+		return nil, fmt.Errorf("churn needs at least one source and one cycle")
+	}
+	
+	app := &benchApp{listeners: make(map[string]*syslog.SharedListener)}
+	result := &ChurnResult{Sources: options.Sources, Cycles: options.Cycles, GoroutinesBefore: runtime.NumGoroutine()}
+	start := time.Now()
+	
+	var wg sync.WaitGroup
+	errs := make(chan error, options.Sources)
+	for i := 0; i < options.Sources; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			config := churnSourceConfig(i, options.Port)
+			for cycle := 0; cycle < options.Cycles; cycle++ {
+				if err := churnCycle(app, config); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	result.Seconds = time.Since(start).Seconds()
+	
+	// Stopped goroutines exit right after their Stop returns
+	deadline := time.Now().Add(settleTimeout)
+	active := lifecycle.Active()
+	for len(active) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		active = lifecycle.Active()
+	}
+	for owner, count := range active {
+		result.Leaks = append(result.Leaks, fmt.Sprintf("%s: %d goroutines", owner, count))
+	}
+	sort.Strings(result.Leaks)
+	
+	app.mutex.Lock()
+	result.Listeners = len(app.listeners)
+	for key := range app.listeners {
+		result.Leaks = append(result.Leaks, fmt.Sprintf("listener %s still registered", key))
+	}
+	app.mutex.Unlock()
+	
+	result.GoroutinesAfter = runtime.NumGoroutine()
+	return result, nil
+}
+
+// churnCycle starts a source, connects to it, replaces it the way an edit
+// does and removes it
+func churnCycle(app *benchApp, config models.SourceConfig) error {
+	source := syslog.NewSyslogSource(config, 100)
+	if err := source.Start(context.Background(), app); err != nil {
+		return err
+	}
+	
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", config.Port))
+	if err != nil {
+		source.Stop(app)
+		return err
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "<134>%s churn: cycle of source %s\n", time.Now().Format(time.RFC3339), config.Name)
+	
+	// An edit stops the source and starts its replacement
+	source.Stop(app)
+	source = syslog.NewSyslogSource(config, 100)
+	if err := source.Start(context.Background(), app); err != nil {
+		return err
+	}
+	source.Stop(app)
+	return nil
+}
+
+func churnSourceConfig(i, port int) models.SourceConfig {
+	return models.SourceConfig{
+		Name:     fmt.Sprintf("churn-%d", i),
+		IP:       fmt.Sprintf("127.0.0.%d", i+1),
+		Port:     port,
+		Protocol: models.ProtocolUDPTCP,
+		Destinations: []models.Destination{
+			{ID: "null", Name: "null", Type: "null", Enabled: true},
+		},
+	}
+}
+
+func printChurn(r *ChurnResult) {
+	fmt.Printf("Source churn (%d sources, %d cycles each, %.1fs)\n", r.Sources, r.Cycles, r.Seconds)
+	fmt.Printf("  %d goroutines before, %d after, %d listeners registered\n", r.GoroutinesBefore, r.GoroutinesAfter, r.Listeners)
+	if len(r.Leaks) == 0 {
+		fmt.Println("  no leaks")
+		return
+	}
+	for _, leak := range r.Leaks {
+		fmt.Printf("  leak: %s\n", leak)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/models"
+	"syslog-analyzer/syslog"
+)
+
+// drainTimeout bounds how long the harness waits for queued events after the load stops
+const drainTimeout = 10 * time.Second
+
+// EndToEndOptions configures the end-to-end run
+type EndToEndOptions struct {
+	Protocol  string
+	Port      int
+	Senders   int
+	EPS       int // Offered load; 0 sends as fast as possible
+	Duration  time.Duration
+	Size      int
+	Aggregate bool
+}
+
+// EndToEndResult is the outcome of the end-to-end run. CPU and allocation
+// figures cover the whole process, load generator included.
+type EndToEndResult struct {
+	Protocol          string  `json:"protocol"`
+	Senders           int     `json:"senders"`
+	OfferedEPS        int     `json:"offered_eps"`
+	MessageSize       int     `json:"message_size"`
+	Aggregate         bool    `json:"aggregate"`
+	Seconds           float64 `json:"seconds"`
+	Sent              int64   `json:"sent"`
+	Received          int64   `json:"received"`
+	Delivered         int64   `json:"delivered"` // Output events; aggregation changes the count
+	Dropped           int64   `json:"dropped"`
+	LossPercent       float64 `json:"loss_percent"`
+	EPS               float64 `json:"eps"` // Events that made it through the pipeline per second
+	CPUCores          float64 `json:"cpu_cores"`
+	CPUMicrosPerEvent float64 `json:"cpu_us_per_event"`
+	AllocsPerEvent    float64 `json:"allocs_per_event"`
+	BytesPerEvent     float64 `json:"bytes_per_event"`
+}
+
+// sameLoad reports whether two runs offered the same load and can be compared
+func (r EndToEndResult) sameLoad(other EndToEndResult) bool {
+	return r.Protocol == other.Protocol && r.Senders == other.Senders && r.OfferedEPS == other.OfferedEPS &&
+		r.MessageSize == other.MessageSize && r.Aggregate == other.Aggregate
+}
+
+// benchApp provides the listeners a source needs, the way the application does
+type benchApp struct {
+	listeners map[string]*syslog.SharedListener
+	mutex     sync.Mutex
+}
+
+func (a *benchApp) AcquireSharedListener(protocol string, port int, source *syslog.SyslogSource) (*syslog.SharedListener, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	
+	key := fmt.Sprintf("%d:%s", port, protocol)
+	if listener, exists := a.listeners[key]; exists {
+		listener.AddSource(source)
+		return listener, nil
+	}
+	listener := syslog.NewSharedListener(protocol, port)
+	if err := listener.Start(context.Background()); err != nil {
+		return nil, err
+	}
+	listener.AddSource(source)
+	a.listeners[key] = listener
+	return listener, nil
+}
+
+func (a *benchApp) ReleaseSharedListener(listener *syslog.SharedListener, source *syslog.SyslogSource) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	
+	listener.RemoveSource(source)
+	if listener.GetSourceCount() > 0 {
+		return
+	}
+	listener.Stop()
+	key := fmt.Sprintf("%d:%s", listener.Port(), listener.Protocol())
+	if a.listeners[key] == listener {
+		delete(a.listeners, key)
+	}
+}
+
+func (a *benchApp) AdmitMessage(config models.SourceConfig, size int) bool {
+	return true
+}
+
+func (a *benchApp) LookupValue(table, key string) (map[string]string, bool) {
+	return nil, false
+}
+
+// runEndToEndBenchmark offers load to a real listener and measures what the
+// pipeline delivers to a null destination
+func runEndToEndBenchmark(options EndToEndOptions) (*EndToEndResult, error) {
+	if options.Protocol != "UDP" && options.Protocol != "TCP" {
+		return nil, fmt.Errorf("unsupported protocol %q (expected UDP or TCP)", options.Protocol)
+	}
+	if options.Senders < 1 {
+		return nil, fmt.Errorf("at least one sender is required")
+	}
+	
+	app := &benchApp{listeners: make(map[string]*syslog.SharedListener)}
+	source := syslog.NewSyslogSource(benchSourceConfig(options.Port, options.Protocol, options.Aggregate), 1000)
+	if err := source.Start(context.Background(), app); err != nil {
+		return nil, err
+	}
+	defer source.Stop(app)
+	
+	// Frame every message the way the transport expects
+	messages := sampleMessages(1000, options.Size)
+	for i := range messages {
+		if options.Protocol == "TCP" {
+			messages[i] = append(messages[i], '\n')
+		}
+	}
+	
+	runtime.GC()
+	var memBefore, memAfter runtime.MemStats
+	runtime.ReadMemStats(&memBefore)
+	cpuBefore := processCPUTime()
+	start := time.Now()
+	
+	sent, err := generateLoad(options, messages)
+	if err != nil {
+		return nil, err
+	}
+	waitForDrain(source, start)
+	
+	elapsed := time.Since(start)
+	cpu := processCPUTime() - cpuBefore
+	runtime.ReadMemStats(&memAfter)
+	
+	counts := source.GetReconciliation(start.Add(-time.Minute), time.Now())
+	processed := counts.Processed + counts.Filtered + counts.SeverityDropped
+	result := &EndToEndResult{
+		Protocol:    options.Protocol,
+		Senders:     options.Senders,
+		OfferedEPS:  options.EPS,
+		MessageSize: options.Size,
+		Aggregate:   options.Aggregate,
+		Seconds:     elapsed.Seconds(),
+		Sent:        sent,
+		Received:    counts.Received,
+		Delivered:   counts.Sent,
+		Dropped:     counts.Dropped + (sent - counts.Received),
+		EPS:         float64(processed) / elapsed.Seconds(),
+		CPUCores:    cpu.Seconds() / elapsed.Seconds(),
+	}
+	if sent > 0 {
+		result.LossPercent = 100 * float64(result.Dropped) / float64(sent)
+	}
+	if processed > 0 {
+		result.CPUMicrosPerEvent = float64(cpu.Microseconds()) / float64(processed)
+		result.AllocsPerEvent = float64(memAfter.Mallocs-memBefore.Mallocs) / float64(processed)
+		result.BytesPerEvent = float64(memAfter.TotalAlloc-memBefore.TotalAlloc) / float64(processed)
+	}
+	return result, nil
+}
+
+// generateLoad sends messages from the configured number of connections for
+// the run's duration and returns how many were written
+func generateLoad(options EndToEndOptions, messages [][]byte) (int64, error) {
+	network := strings.ToLower(options.Protocol)
+	address := fmt.Sprintf("127.0.0.1:%d", options.Port)
+	
+	conns := make([]net.Conn, options.Senders)
+	for i := range conns {
+		conn, err := net.Dial(network, address)
+		if err != nil {
+			for _, opened := range conns[:i] {
+				opened.Close()
+			}
+			return 0, fmt.Errorf("failed to connect load generator: %v", err)
+		}
+		conns[i] = conn
+	}
+	
+	var sent int64
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(options.Duration)
+	perSender := float64(options.EPS) / float64(options.Senders)
+	
+	for i, conn := range conns {
+		wg.Add(1)
+		go func(offset int, conn net.Conn) {
+			defer wg.Done()
+			defer conn.Close()
+			
+			start := time.Now()
+			var count int64
+			defer func() { atomic.AddInt64(&sent, count) }()
+			
+			for time.Now().Before(deadline) {
+				// Pace to the offered rate; unpaced senders write as fast as they can
+				if perSender > 0 && float64(count) >= perSender*time.Since(start).Seconds() {
+					time.Sleep(time.Millisecond)
+					continue
+				}
+				if _, err := conn.Write(messages[(offset+int(count))%len(messages)]); err != nil {
+					if network == "tcp" {
+						return
+					}
+					continue
+				}
+				count++
+			}
+		}(i*len(messages)/options.Senders, conn)
+	}
+	wg.Wait()
+	
+	return sent, nil
+}
+
+// waitForDrain waits until every received event is accounted for or the
+// pipeline stops making progress
+func waitForDrain(source *syslog.SyslogSource, start time.Time) {
+	deadline := time.Now().Add(drainTimeout)
+	var previous models.ReconciliationCounts
+	for time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		counts := source.GetReconciliation(start.Add(-time.Minute), time.Now())
+		if counts.Unaccounted == 0 || counts == previous {
+			return
+		}
+		previous = counts
+	}
+}
+
+// sampleMessages builds JSON messages of roughly size bytes with fields the
+// benchmark filters and aggregations use
+func sampleMessages(count, size int) [][]byte {
+	messages := make([][]byte, count)
+	for i := range messages {
+		message := fmt.Sprintf(`{"host":"host-%02d","action":"%s","user":"user-%d","bytes":%d,"message":"request handled","padding":""}`,
+			i%50, []string{"login", "logout", "read", "write"}[i%4], i%200, 100+i*7)
+		if pad := size - len(message); pad > 0 {
+			message = strings.Replace(message, `"padding":""`, `"padding":"`+strings.Repeat("x", pad)+`"`, 1)
+		}
+		messages[i] = []byte(message)
+	}
+	return messages
+}
+
+// benchFilters drops one action in four
+func benchFilters() []models.FilterRule {
+	return []models.FilterRule{
+		{Field: "action", Operator: "equals", Value: "logout", Action: "exclude"},
+	}
+}
+
+// benchAggregations sums bytes per host and action
+func benchAggregations() []models.AggregationRule {
+	return []models.AggregationRule{{
+		Name:       "bytes_by_host",
+		GroupBy:    []string{"host", "action"},
+		TimeWindow: time.Minute,
+		Metrics:    []models.AggregationMetric{{Field: "bytes", Ops: []string{models.AggregateSum, models.AggregateMax}}},
+	}}
+}
+
+// benchSourceConfig is the source every benchmark delivers through
+func benchSourceConfig(port int, protocol string, aggregate bool) models.SourceConfig {
+	config := models.SourceConfig{
+		Name:     "bench",
+		IP:       "127.0.0.1",
+		Port:     port,
+		Protocol: protocol,
+		Destinations: []models.Destination{
+			{ID: "null", Name: "null", Type: "null", Enabled: true},
+		},
+	}
+	if aggregate {
+		config.Filters = benchFilters()
+		config.Aggregations = benchAggregations()
+	}
+	return config
+}
@@ -0,0 +1,229 @@
+// Command pipelinebench measures the ingest pipeline end to end (load
+// generator -> listener -> pipeline -> null destination), prints EPS, CPU and
+// allocation figures and appends them, keyed by commit, to a results file.
+// Each run is compared with the previous one so pipeline redesigns can be
+// validated against the EPS target and regressions fail the run. With -churn
+// it also adds, edits and removes sources concurrently and fails the run if
+// any listener or pipeline goroutine outlives its source.
+//
+// The hot paths have go test benchmarks next to their code; compare two
+// commits with benchstat:
+//
+//	go test -run '^$' -bench . -count 10 ./... > new.txt && benchstat old.txt new.txt
+//	go run ./cmd/pipelinebench -duration 30s -senders 8 -out pipelinebench.jsonl
+//	go run ./cmd/pipelinebench -e2e=false -churn 50 -out ""
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"syslog-analyzer/syslog"
+)
+
+// Result is one run of the harness as stored in the results file
+type Result struct {
+	Commit     string          `json:"commit"`
+	Time       time.Time       `json:"time"`
+	GoVersion  string          `json:"go_version"`
+	OS         string          `json:"os"`
+	Arch       string          `json:"arch"`
+	CPUs       int             `json:"cpus"`
+	GOMAXPROCS int             `json:"gomaxprocs"`
+	Queue      string          `json:"queue,omitempty"` // Source queue implementation
+	EndToEnd   *EndToEndResult `json:"end_to_end,omitempty"`
+	Churn      *ChurnResult    `json:"churn,omitempty"`
+}
+
+func main() {
+	var (
+		runEndToEnd   = flag.Bool("e2e", true, "Run the end-to-end benchmark")
+		duration      = flag.Duration("duration", 10*time.Second, "Load duration of the end-to-end run")
+		eps           = flag.Int("eps", 0, "Offered events per second of the end-to-end run; 0 sends as fast as possible")
+		senders       = flag.Int("senders", 4, "Concurrent load generator connections")
+		protocol      = flag.String("protocol", "UDP", "Transport of the end-to-end run (UDP or TCP)")
+		port          = flag.Int("port", 15514, "Listener port of the end-to-end run")
+		size          = flag.Int("size", 256, "Approximate message size in bytes")
+		aggregate     = flag.Bool("aggregate", false, "Add a filter and an aggregation rule to the end-to-end pipeline")
+		target        = flag.Float64("target", 500000, "EPS target the end-to-end result is reported against")
+		out           = flag.String("out", "pipelinebench.jsonl", "Results file the run is appended to; empty disables")
+		commit        = flag.String("commit", "", "Commit the run is recorded for (default: git rev-parse --short HEAD)")
+		maxRegression = flag.Float64("max-regression", 10, "Percent EPS drop against the previous run that fails the run")
+		queue         = flag.String("queue", "channel", "Source queue implementation (channel or ring)")
+		churn         = flag.Int("churn", 0, "Add/edit/remove cycles per source of the source churn run; 0 disables")
+		churnSources  = flag.Int("churn-sources", 8, "Sources churned concurrently on the listener port")
+		verbose       = flag.Bool("v", false, "Keep the analyzer's operational log")
+	)
+	flag.Parse()
+	
+	if err := syslog.SetQueueType(*queue); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+	
+	result := Result{
+		Commit:     *commit,
+		Time:       time.Now().UTC(),
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		CPUs:       runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Queue:      syslog.GetQueueType(),
+	}
+	if result.Commit == "" {
+		result.Commit = gitCommit()
+	}
+	
+	fmt.Printf("Pipeline benchmark for %s (%s, %s/%s, %d CPUs, %s queue)\n\n", result.Commit, result.GoVersion, result.OS, result.Arch, result.CPUs, result.Queue)
+	
+	if *runEndToEnd {
+		e2e, err := runEndToEndBenchmark(EndToEndOptions{
+			Protocol:  strings.ToUpper(*protocol),
+			Port:      *port,
+			Senders:   *senders,
+			EPS:       *eps,
+			Duration:  *duration,
+			Size:      *size,
+			Aggregate: *aggregate,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "End-to-end benchmark failed: %v\n", err)
+			os.Exit(2)
+		}
+		result.EndToEnd = e2e
+		printEndToEnd(e2e, *target)
+	}
+	
+	if *churn > 0 {
+		churnResult, err := runChurn(ChurnOptions{Port: *port, Sources: *churnSources, Cycles: *churn})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Source churn run failed: %v\n", err)
+			os.Exit(2)
+		}
+		result.Churn = churnResult
+		fmt.Println()
+		printChurn(churnResult)
+		if len(churnResult.Leaks) > 0 {
+			os.Exit(1)
+		}
+	}
+	
+	if *out == "" {
+		return
+	}
+	
+	previous, err := lastResult(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read previous results: %v\n", err)
+	}
+	if err := appendResult(*out, result); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to record results: %v\n", err)
+		os.Exit(2)
+	}
+	fmt.Printf("\nRecorded in %s\n", *out)
+	
+	if previous != nil {
+		if regressions := compare(*previous, result, *maxRegression); len(regressions) > 0 {
+			fmt.Printf("\nRegressions against %s:\n", previous.Commit)
+			for _, regression := range regressions {
+				fmt.Printf("  %s\n", regression)
+			}
+			os.Exit(1)
+		}
+		fmt.Printf("No regressions against %s (threshold %.0f%%)\n", previous.Commit, *maxRegression)
+	}
+}
+
+// gitCommit returns the short hash of the checked out commit
+func gitCommit() string {
+	output, err := exec.Command("git", "rev-parse", "--short", "HEAD").Output()
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func printEndToEnd(r *EndToEndResult, target float64) {
+	fmt.Printf("End-to-end (%s, %d senders, %.0fs)\n", r.Protocol, r.Senders, r.Seconds)
+	fmt.Printf("  sent %d, received %d, dropped %d (%.2f%% loss), %d output events delivered\n", r.Sent, r.Received, r.Dropped, r.LossPercent, r.Delivered)
+	fmt.Printf("  %.0f EPS processed (%.1f%% of the %.0f EPS target)\n", r.EPS, 100*r.EPS/target, target)
+	fmt.Printf("  %.2f CPU cores, %.1f µs CPU/event, %.2f allocs/event, %.0f B/event\n", r.CPUCores, r.CPUMicrosPerEvent, r.AllocsPerEvent, r.BytesPerEvent)
+}
+
+// lastResult returns the last run recorded in the results file, if any
+func lastResult(path string) (*Result, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	
+	var last *Result
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var result Result
+		if err := json.Unmarshal(scanner.Bytes(), &result); err == nil {
+			last = &result
+		}
+	}
+	return last, scanner.Err()
+}
+
+// appendResult adds a run to the results file, one JSON document per line
+func appendResult(path string, result Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// compare lists the EPS figures of current that fell more than threshold
+// percent below previous. Runs with different queue implementations are not
+// compared.
+func compare(previous, current Result, threshold float64) []string {
+	var regressions []string
+	if previous.Queue != current.Queue {
+		return regressions
+	}
+	check := func(name string, before, after float64) {
+		if before <= 0 {
+			return
+		}
+		if drop := 100 * (before - after) / before; drop > threshold {
+			regressions = append(regressions, fmt.Sprintf("%s: %.0f -> %.0f EPS (-%.1f%%)", name, before, after, drop))
+		}
+	}
+	
+	// Only compare end-to-end runs made under the same load
+	if previous.EndToEnd != nil && current.EndToEnd != nil && previous.EndToEnd.sameLoad(*current.EndToEnd) {
+		check("end-to-end", previous.EndToEnd.EPS, current.EndToEnd.EPS)
+	}
+	return regressions
+}
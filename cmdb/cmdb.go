@@ -0,0 +1,193 @@
+// Package cmdb reads the device inventory from NetBox or a CMDB REST API on
+// an interval, so the analyzer's sources follow devices as they are
+// deployed and retired without anyone adding them by hand.
+package cmdb
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Sync defaults
+const (
+	defaultInterval = 60 // Minutes
+	fetchTimeout    = 2 * time.Minute
+	maxResponseSize = 64 << 20
+	maxPages        = 1000
+)
+
+// Device is an inventory entry
+type Device struct {
+	Hostname string
+	IP       string // "" when the inventory has no address for the device
+	Vendor   string
+	Tags     []string
+	Active   bool // False for planned, offline or decommissioned devices
+}
+
+// ApplyFunc brings the sources in line with the devices read or, in a dry
+// run, only reports what it would change
+type ApplyFunc func(devices []Device, dryRun bool) models.CMDBSyncReport
+
+// Syncer reads the inventory on an interval and applies it
+type Syncer struct {
+	config    models.CMDBConfig
+	apply     ApplyFunc
+	client    *http.Client
+	mutex     sync.Mutex // Guards last and next
+	syncMutex sync.Mutex // Serializes syncs
+	last      *models.CMDBSyncReport
+	next      time.Time
+	stopChan  chan bool
+	done      chan bool
+}
+
+// NewSyncer creates a syncer for config, validating it
+func NewSyncer(config models.CMDBConfig, apply ApplyFunc) (*Syncer, error) {
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
+	if config.IntervalMinutes == 0 {
+		config.IntervalMinutes = defaultInterval
+	}
+	
+	return &Syncer{
+		config: config,
+		apply:  apply,
+		client: &http.Client{
+			Timeout: fetchTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: !config.VerifySSL},
+			},
+		},
+		stopChan: make(chan bool),
+		done:     make(chan bool),
+	}, nil
+}
+
+// Validate checks a CMDB sync configuration
+func Validate(config models.CMDBConfig) error {
+	if config.Kind != models.CMDBNetBox && config.Kind != models.CMDBGeneric {
+		return fmt.Errorf("cmdb kind must be %s or %s", models.CMDBNetBox, models.CMDBGeneric)
+	}
+	parsed, err := url.Parse(config.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("cmdb url must be an http or https URL")
+	}
+	if _, err := url.ParseQuery(config.Filter); err != nil {
+		return fmt.Errorf("invalid cmdb filter: %v", err)
+	}
+	if config.IntervalMinutes < 0 {
+		return fmt.Errorf("cmdb interval_minutes cannot be negative")
+	}
+	return nil
+}
+
+// Start syncs immediately and then on the configured interval
+func (s *Syncer) Start() {
+	go s.run()
+	mode := ""
+	if s.config.DryRun {
+		mode = ", dry run"
+	}
+	log.Printf("✓ CMDB sync started (%s %s every %dm%s)", s.config.Kind, s.config.URL, s.config.IntervalMinutes, mode)
+}
+
+// Stop stops periodic syncing, waiting for a sync in progress to finish
+func (s *Syncer) Stop() {
+	close(s.stopChan)
+	<-s.done
+}
+
+// run syncs until stopped
+func (s *Syncer) run() {
+	defer close(s.done)
+	
+	interval := time.Duration(s.config.IntervalMinutes) * time.Minute
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	
+	for {
+		s.mutex.Lock()
+		s.next = time.Now().Add(interval)
+		s.mutex.Unlock()
+		s.SyncNow(false)
+	
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the sync settings and the report of the last sync
+func (s *Syncer) Status() models.CMDBSyncStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	status := models.CMDBSyncStatus{
+		Enabled:    true,
+		Kind:       s.config.Kind,
+		URL:        s.config.URL,
+		DryRun:     s.config.DryRun,
+		LastReport: s.last,
+	}
+	if !s.next.IsZero() {
+		next := s.next
+		status.NextSync = &next
+	}
+	return status
+}
+
+// SyncNow reads the inventory and applies it, or only reports the changes
+// when dryRun is set or the sync is configured as a dry run. An inventory
+// that cannot be read, or lists no devices, changes nothing: an empty list
+// is far more likely a broken filter than a retired network.
+func (s *Syncer) SyncNow(dryRun bool) models.CMDBSyncReport {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+	
+	dryRun = dryRun || s.config.DryRun
+	devices, err := s.fetch()
+	if err == nil && len(devices) == 0 {
+		err = fmt.Errorf("inventory returned no devices; nothing was changed")
+	}
+	
+	var report models.CMDBSyncReport
+	if err != nil {
+		report = models.CMDBSyncReport{DryRun: dryRun, Changes: []models.CMDBChange{}, Error: err.Error()}
+		log.Printf("✗ CMDB sync from %s failed: %v", s.config.URL, err)
+	} else {
+		report = s.apply(devices, dryRun)
+		report.Devices = len(devices)
+		if len(report.Changes) > 0 && !report.DryRun {
+			log.Printf("✓ CMDB sync applied %d changes for %d devices", len(report.Changes), len(devices))
+		}
+	}
+	report.Time = time.Now()
+	
+	// Reports requested as dry runs don't replace the last scheduled result
+	s.mutex.Lock()
+	if !dryRun || s.config.DryRun || s.last == nil {
+		s.last = &report
+	}
+	s.mutex.Unlock()
+	return report
+}
+
+// fetch reads every device of the inventory
+func (s *Syncer) fetch() ([]Device, error) {
+	if s.config.Kind == models.CMDBNetBox {
+		return s.fetchNetBox()
+	}
+	return s.fetchGeneric()
+}
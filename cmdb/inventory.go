@@ -0,0 +1,214 @@
+package cmdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// netBoxPage is one page of NetBox's /api/dcim/devices/ listing
+type netBoxPage struct {
+	Next    *string        `json:"next"`
+	Results []netBoxDevice `json:"results"`
+}
+
+// netBoxDevice holds the device fields the sync uses
+type netBoxDevice struct {
+	Name   *string `json:"name"`
+	Status struct {
+		Value string `json:"value"`
+	} `json:"status"`
+	PrimaryIP *struct {
+		Address string `json:"address"`
+	} `json:"primary_ip"`
+	DeviceType struct {
+		Manufacturer struct {
+			Name string `json:"name"`
+			Slug string `json:"slug"`
+		} `json:"manufacturer"`
+	} `json:"device_type"`
+	Tags []struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	} `json:"tags"`
+}
+
+// fetchNetBox reads every device NetBox lists for the filter, following
+// the pages it returns
+func (s *Syncer) fetchNetBox() ([]Device, error) {
+	query, _ := url.ParseQuery(s.config.Filter)
+	if query.Get("limit") == "" {
+		query.Set("limit", "500")
+	}
+	next := strings.TrimRight(s.config.URL, "/") + "/api/dcim/devices/?" + query.Encode()
+	
+	var devices []Device
+	for pages := 0; next != ""; pages++ {
+		if pages == maxPages {
+			return nil, fmt.Errorf("inventory has more than %d pages", maxPages)
+		}
+		var page netBoxPage
+		if err := s.getJSON(next, "Token", &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Results {
+			device := Device{Active: item.Status.Value == "" || item.Status.Value == "active"}
+			if item.Name != nil {
+				device.Hostname = *item.Name
+			}
+			if item.PrimaryIP != nil {
+				device.IP = stripPrefixLength(item.PrimaryIP.Address)
+			}
+			device.Vendor = item.DeviceType.Manufacturer.Slug
+			if device.Vendor == "" {
+				device.Vendor = item.DeviceType.Manufacturer.Name
+			}
+			for _, tag := range item.Tags {
+				device.Tags = append(device.Tags, firstNonEmpty(tag.Slug, tag.Name))
+			}
+			devices = append(devices, device)
+		}
+		next = ""
+		if page.Next != nil {
+			next = *page.Next
+		}
+	}
+	return devices, nil
+}
+
+// fetchGeneric reads the devices of a generic CMDB endpoint: the URL returns
+// a JSON array of devices, or an object holding one under items_field.
+// Fields are looked up by dotted paths, e.g. "network.mgmt_ip".
+func (s *Syncer) fetchGeneric() ([]Device, error) {
+	target := s.config.URL
+	if s.config.Filter != "" {
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		target += separator + s.config.Filter
+	}
+	
+	var body interface{}
+	if err := s.getJSON(target, "Bearer", &body); err != nil {
+		return nil, err
+	}
+	if s.config.ItemsField != "" {
+		body = lookupField(body, s.config.ItemsField)
+	}
+	items, ok := body.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("inventory response is not a list of devices")
+	}
+	
+	hostnameField := firstNonEmpty(s.config.HostnameField, "hostname")
+	ipField := firstNonEmpty(s.config.IPField, "ip")
+	vendorField := firstNonEmpty(s.config.VendorField, "vendor")
+	
+	var devices []Device
+	for _, item := range items {
+		device := Device{
+			Hostname: stringField(item, hostnameField),
+			IP:       stripPrefixLength(stringField(item, ipField)),
+			Vendor:   stringField(item, vendorField),
+			Active:   true,
+		}
+		if s.config.StatusField != "" {
+			switch status := lookupField(item, s.config.StatusField).(type) {
+			case nil:
+			case bool:
+				device.Active = status
+			case string:
+				device.Active = status == "" || strings.EqualFold(status, "active")
+			default:
+				device.Active = false
+			}
+		}
+		if s.config.TagsField != "" {
+			if tags, ok := lookupField(item, s.config.TagsField).([]interface{}); ok {
+				for _, tag := range tags {
+					switch tag := tag.(type) {
+					case string:
+						device.Tags = append(device.Tags, tag)
+					case map[string]interface{}:
+						device.Tags = append(device.Tags, firstNonEmpty(stringField(tag, "slug"), stringField(tag, "name")))
+					}
+				}
+			}
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// getJSON fetches target with the token in the given authorization scheme
+// and decodes the response into v
+func (s *Syncer) getJSON(target, scheme string, v interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if s.config.Token != "" {
+		request.Header.Set("Authorization", scheme+" "+s.config.Token)
+	}
+	
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	
+	if response.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("inventory returned %s: %s", response.Status, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxResponseSize)).Decode(v); err != nil {
+		return fmt.Errorf("invalid inventory response: %v", err)
+	}
+	return nil
+}
+
+// lookupField follows a dotted path through nested JSON objects
+func lookupField(value interface{}, path string) interface{} {
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// stringField returns the string or number at a dotted path, or ""
+func stringField(value interface{}, path string) string {
+	switch field := lookupField(value, path).(type) {
+	case string:
+		return strings.TrimSpace(field)
+	case float64:
+		return fmt.Sprint(field)
+	}
+	return ""
+}
+
+// stripPrefixLength turns an interface address such as "10.0.0.1/24" into
+// the host address
+func stripPrefixLength(address string) string {
+	if i := strings.IndexByte(address, '/'); i >= 0 {
+		return address[:i]
+	}
+	return address
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"syslog-analyzer/models"
+)
+
+// Fields a diff ignores: IDs, creation times and ingest tokens belong to each
+// analyzer, and test results are runtime state
+var (
+	sourceDiffIgnored      = map[string]bool{"id": true, "created_at": true, "destinations": true}
+	destinationDiffIgnored = map[string]bool{"id": true, "tested": true, "test_status": true, "test_message": true}
+	configDiffIgnored      = map[string]bool{"sources": true, "global_settings": true, "ingest_tokens": true}
+)
+
+// DecodeConfig decodes a configuration file or an export of one
+func DecodeConfig(data []byte) (*models.Config, error) {
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("invalid configuration file: %v", err)
+	}
+	if exported, ok := sections["config"]; ok {
+		data = exported
+	}
+	
+	var config models.Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid configuration file: %v", err)
+	}
+	return &config, nil
+}
+
+// Diff reports the sources, destinations and settings that applying proposed
+// would add, change or remove. Both sides are compared with their secrets
+// replaced by placeholders, so exports diff cleanly and the diff never
+// contains a secret; a secret whose value alone changed is not reported.
+func Diff(running, proposed *models.Config) (models.ConfigDiff, error) {
+	diff := models.ConfigDiff{Sources: []models.SourceDiff{}, Settings: []models.FieldChange{}}
+	
+	from, err := Export(running)
+	if err != nil {
+		return diff, err
+	}
+	to, err := Export(proposed)
+	if err != nil {
+		return diff, err
+	}
+	
+	current := make(map[string]models.SourceConfig)
+	for _, source := range from.Config.Sources {
+		current[source.Name] = source
+	}
+	desired := make(map[string]bool)
+	for _, source := range to.Config.Sources {
+		desired[source.Name] = true
+		existing, exists := current[source.Name]
+		if !exists {
+			diff.Sources = append(diff.Sources, models.SourceDiff{
+				Name:         source.Name,
+				Change:       models.ChangeAdded,
+				Destinations: diffDestinations(nil, source.Destinations),
+			})
+			diff.Added++
+			continue
+		}
+		
+		fields := diffFields("", existing, source, sourceDiffIgnored)
+		destinations := diffDestinations(existing.Destinations, source.Destinations)
+		if len(fields) > 0 || len(destinations) > 0 {
+			diff.Sources = append(diff.Sources, models.SourceDiff{
+				Name:         source.Name,
+				Change:       models.ChangeChanged,
+				Fields:       fields,
+				Destinations: destinations,
+			})
+			diff.Changed++
+		}
+	}
+	for _, source := range from.Config.Sources {
+		if !desired[source.Name] {
+			diff.Sources = append(diff.Sources, models.SourceDiff{Name: source.Name, Change: models.ChangeRemoved})
+			diff.Removed++
+		}
+	}
+	
+	diff.Settings = append(diff.Settings, diffFields("global_settings.", from.Config.GlobalSettings, to.Config.GlobalSettings, nil)...)
+	diff.Settings = append(diff.Settings, diffFields("", from.Config, to.Config, configDiffIgnored)...)
+	
+	diff.InSync = len(diff.Sources) == 0 && len(diff.Settings) == 0
+	return diff, nil
+}
+
+// diffDestinations matches destinations by name, since their IDs differ
+// between analyzers
+func diffDestinations(current, proposed []models.Destination) []models.DestinationDiff {
+	var diffs []models.DestinationDiff
+	existing := make(map[string]models.Destination)
+	for _, dest := range current {
+		existing[dest.Name] = dest
+	}
+	
+	desired := make(map[string]bool)
+	for _, dest := range proposed {
+		desired[dest.Name] = true
+		old, exists := existing[dest.Name]
+		if !exists {
+			diffs = append(diffs, models.DestinationDiff{Name: dest.Name, Change: models.ChangeAdded})
+			continue
+		}
+		if fields := diffFields("", old, dest, destinationDiffIgnored); len(fields) > 0 {
+			diffs = append(diffs, models.DestinationDiff{Name: dest.Name, Change: models.ChangeChanged, Fields: fields})
+		}
+	}
+	for _, dest := range current {
+		if !desired[dest.Name] {
+			diffs = append(diffs, models.DestinationDiff{Name: dest.Name, Change: models.ChangeRemoved})
+		}
+	}
+	return diffs
+}
+
+// diffFields compares the top-level JSON fields of two values, sorted by name
+func diffFields(prefix string, from, to interface{}, ignored map[string]bool) []models.FieldChange {
+	fromFields := jsonFields(from)
+	toFields := jsonFields(to)
+	
+	names := make(map[string]bool)
+	for name := range fromFields {
+		names[name] = true
+	}
+	for name := range toFields {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		if !ignored[name] {
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+	
+	var changes []models.FieldChange
+	for _, name := range sorted {
+		if !sameValue(fromFields[name], toFields[name]) {
+			changes = append(changes, models.FieldChange{Field: prefix + name, From: fromFields[name], To: toFields[name]})
+		}
+	}
+	return changes
+}
+
+// sameValue compares two decoded JSON values, treating a missing or null
+// list or object as empty
+func sameValue(a, b interface{}) bool {
+	if isEmpty(a) && isEmpty(b) {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// isEmpty reports whether a decoded JSON value is null or an empty list or object
+func isEmpty(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(value) == 0
+	case map[string]interface{}:
+		return len(value) == 0
+	}
+	return false
+}
+
+// jsonFields returns the fields of a value as it is stored
+func jsonFields(v interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	data, err := json.Marshal(v)
+	if err == nil {
+		json.Unmarshal(data, &fields)
+	}
+	return fields
+}
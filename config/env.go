@@ -0,0 +1,232 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"syslog-analyzer/models"
+)
+
+// Environment variables of the environment-only mode, for immutable
+// container deployments. Any of them switches the mode on: the configuration
+// is read once, from the environment or a mounted file such as a ConfigMap,
+// and never written back.
+const (
+	// EnvConfig holds the whole configuration as JSON
+	EnvConfig = "SYSLOG_ANALYZER_CONFIG"
+	// EnvConfigFile names a read-only configuration file, e.g. a mounted ConfigMap
+	EnvConfigFile = "SYSLOG_ANALYZER_CONFIG_FILE"
+	// EnvReadOnly set to true keeps the regular configuration file read-only
+	EnvReadOnly = "SYSLOG_ANALYZER_READ_ONLY"
+	
+	// envPrefix followed by a configuration section, e.g. SOURCES or
+	// GLOBAL_SETTINGS, replaces that section with the variable's JSON
+	envPrefix = "SYSLOG_ANALYZER_"
+	// envSettingPrefix followed by a global setting, e.g. WEB_PORT, replaces
+	// that setting. Values that are not JSON are taken as strings.
+	envSettingPrefix = "SYSLOG_ANALYZER_SETTING_"
+)
+
+// environmentMode reports whether the configuration comes from the environment
+func environmentMode() bool {
+	if os.Getenv(EnvConfig) != "" || os.Getenv(EnvConfigFile) != "" {
+		return true
+	}
+	if readOnly, err := strconv.ParseBool(os.Getenv(EnvReadOnly)); err == nil && readOnly {
+		return true
+	}
+	return len(environmentOverrides()) > 0
+}
+
+// environmentOverrides returns the set section and setting variables, sorted
+func environmentOverrides() []string {
+	var names []string
+	sections := sectionKeys()
+	for _, variable := range os.Environ() {
+		name := variable[:strings.Index(variable, "=")]
+		if strings.HasPrefix(name, envSettingPrefix) || sections[sectionOf(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sectionOf returns the configuration section a variable names, e.g.
+// "lookup_tables" for SYSLOG_ANALYZER_LOOKUP_TABLES
+func sectionOf(name string) string {
+	if !strings.HasPrefix(name, envPrefix) {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(name, envPrefix))
+}
+
+// sectionKeys returns the JSON keys of the configuration's sections
+func sectionKeys() map[string]bool {
+	keys := make(map[string]bool)
+	configType := reflect.TypeOf(models.Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		if key := strings.Split(configType.Field(i).Tag.Get("json"), ",")[0]; key != "" {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// loadFromEnvironment reads the configuration in environment-only mode: the
+// base configuration from EnvConfig, EnvConfigFile or the regular file, in
+// that order, over the defaults, with the section and setting variables
+// applied on top
+func (m *Manager) loadFromEnvironment() (*models.Config, error) {
+	data, origin, err := m.environmentBase()
+	if err != nil {
+		return nil, err
+	}
+	
+	defaults, err := json.Marshal(defaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	var sections, base map[string]json.RawMessage
+	if err := json.Unmarshal(defaults, &sections); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration from %s: %v", origin, err)
+	}
+	for key, value := range base {
+		if err := mergeSection(sections, key, value); err != nil {
+			return nil, fmt.Errorf("failed to parse configuration from %s: %v", origin, err)
+		}
+	}
+	
+	overrides := environmentOverrides()
+	if err := applyOverrides(sections, overrides); err != nil {
+		return nil, err
+	}
+	
+	merged, err := json.Marshal(sections)
+	if err != nil {
+		return nil, err
+	}
+	config := &models.Config{}
+	if err := json.Unmarshal(merged, config); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration from the environment: %v", err)
+	}
+	applyDefaults(config)
+	
+	// IDs assigned here change on every start; give resources IDs in the
+	// provided configuration where they need to stay stable
+	AssignIDs(config)
+	
+	m.config = config
+	m.readOnly = true
+	m.origin = origin
+	m.overrides = overrides
+	log.Printf("✓ Configuration loaded read-only from %s with %d environment overrides", origin, len(overrides))
+	return config, nil
+}
+
+// environmentBase returns the configuration the overrides apply to and where
+// it was read from
+func (m *Manager) environmentBase() ([]byte, string, error) {
+	if text := os.Getenv(EnvConfig); text != "" {
+		return []byte(text), EnvConfig, nil
+	}
+	
+	path := os.Getenv(EnvConfigFile)
+	if path == "" {
+		path = m.configFile
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return []byte("{}"), "defaults", nil
+		}
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config file: %v", err)
+	}
+	return data, path, nil
+}
+
+// applyOverrides applies the named section and setting variables. Whole
+// sections go first, so settings apply over a replaced global_settings.
+func applyOverrides(sections map[string]json.RawMessage, names []string) error {
+	for _, name := range names {
+		if strings.HasPrefix(name, envSettingPrefix) {
+			continue
+		}
+		if err := mergeSection(sections, sectionOf(name), json.RawMessage(os.Getenv(name))); err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+	}
+	
+	for _, name := range names {
+		if !strings.HasPrefix(name, envSettingPrefix) {
+			continue
+		}
+		value := json.RawMessage(os.Getenv(name))
+		if !json.Valid(value) {
+			value, _ = json.Marshal(os.Getenv(name))
+		}
+		key := strings.ToLower(strings.TrimPrefix(name, envSettingPrefix))
+		setting, _ := json.Marshal(map[string]json.RawMessage{key: value})
+		if err := mergeSection(sections, "global_settings", setting); err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// mergeSection sets a section of the configuration. Global settings are
+// merged setting by setting, so settings left out keep their defaults.
+func mergeSection(sections map[string]json.RawMessage, key string, value json.RawMessage) error {
+	if !json.Valid(value) {
+		return fmt.Errorf("not valid JSON")
+	}
+	if key != "global_settings" {
+		sections[key] = value
+		return nil
+	}
+	
+	var settings, given map[string]json.RawMessage
+	if err := json.Unmarshal(sections[key], &settings); err != nil || settings == nil {
+		settings = make(map[string]json.RawMessage)
+	}
+	if err := json.Unmarshal(value, &given); err != nil {
+		return err
+	}
+	for setting, settingValue := range given {
+		settings[setting] = settingValue
+	}
+	merged, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	sections[key] = merged
+	return nil
+}
+
+// ReadOnly reports whether the configuration is read-only because it comes
+// from the environment
+func (m *Manager) ReadOnly() bool {
+	return m.readOnly
+}
+
+// GetMode returns where the configuration comes from
+func (m *Manager) GetMode() models.ConfigMode {
+	mode := models.ConfigMode{ReadOnly: m.readOnly, Origin: m.configFile, Overrides: m.overrides}
+	if m.readOnly {
+		mode.Origin = m.origin
+	}
+	if mode.Overrides == nil {
+		mode.Overrides = []string{}
+	}
+	return mode
+}
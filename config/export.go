@@ -0,0 +1,133 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// exporter replaces secrets by placeholders while recording them
+type exporter struct {
+	secrets []models.SecretPlaceholder
+	names   map[string]bool
+}
+
+// Export returns a copy of config with every secret replaced by a named
+// "${NAME}" placeholder, and the manifest of the placeholders. Empty secrets
+// are left empty.
+func Export(config *models.Config) (models.ConfigExport, error) {
+	var copied models.Config
+	data, err := json.Marshal(config)
+	if err != nil {
+		return models.ConfigExport{}, err
+	}
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return models.ConfigExport{}, err
+	}
+	
+	e := &exporter{secrets: []models.SecretPlaceholder{}, names: make(map[string]bool)}
+	for i := range copied.Sources {
+		source := &copied.Sources[i]
+		for j := range source.Destinations {
+			dest := &source.Destinations[j]
+			configMap, ok := dest.Config.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if key, ok := configMap["api_key"].(string); ok {
+				configMap["api_key"] = e.replace(key, fmt.Sprintf("sources[%s].destinations[%s].config.api_key", source.Name, dest.Name), "SOURCE", source.Name, dest.Name, "API_KEY")
+			}
+		}
+	}
+	
+	for i := range copied.SourceTemplates {
+		template := &copied.SourceTemplates[i]
+		for j := range template.Source.Destinations {
+			dest := &template.Source.Destinations[j]
+			configMap, ok := dest.Config.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if key, ok := configMap["api_key"].(string); ok {
+				configMap["api_key"] = e.replace(key, fmt.Sprintf("source_templates[%s].destinations[%s].config.api_key", template.Name, dest.Name), "TEMPLATE", template.Name, dest.Name, "API_KEY")
+			}
+		}
+	}
+	
+	for i := range copied.Tenants {
+		tenant := &copied.Tenants[i]
+		for j := range tenant.Tokens {
+			tenant.Tokens[j] = e.replace(tenant.Tokens[j], fmt.Sprintf("tenants[%s].tokens[%d]", tenant.ID, j), "TENANT", tenant.ID, fmt.Sprintf("TOKEN_%d", j+1))
+		}
+	}
+	
+	settings := &copied.GlobalSettings
+	for i := range settings.AdminTokens {
+		settings.AdminTokens[i] = e.replace(settings.AdminTokens[i], fmt.Sprintf("global_settings.admin_tokens[%d]", i), fmt.Sprintf("ADMIN_TOKEN_%d", i+1))
+	}
+	settings.RemoteWrite.Password = e.replace(settings.RemoteWrite.Password, "global_settings.remote_write.password", "REMOTE_WRITE_PASSWORD")
+	settings.RemoteWrite.BearerToken = e.replace(settings.RemoteWrite.BearerToken, "global_settings.remote_write.bearer_token", "REMOTE_WRITE_BEARER_TOKEN")
+	settings.SNMP.Community = e.replace(settings.SNMP.Community, "global_settings.snmp.community", "SNMP_COMMUNITY")
+	settings.Digest.SMTP.Password = e.replace(settings.Digest.SMTP.Password, "global_settings.digest.smtp.password", "DIGEST_SMTP_PASSWORD")
+	settings.Chargeback.SMTP.Password = e.replace(settings.Chargeback.SMTP.Password, "global_settings.chargeback.smtp.password", "CHARGEBACK_SMTP_PASSWORD")
+	settings.GitOps.BearerToken = e.replace(settings.GitOps.BearerToken, "global_settings.gitops.bearer_token", "GITOPS_BEARER_TOKEN")
+	settings.Backup.S3.SecretAccessKey = e.replace(settings.Backup.S3.SecretAccessKey, "global_settings.backup.s3.secret_access_key", "BACKUP_S3_SECRET_ACCESS_KEY")
+	settings.CMDB.Token = e.replace(settings.CMDB.Token, "global_settings.cmdb.token", "CMDB_TOKEN")
+	for i := range settings.Notifications.Connectors {
+		connector := &settings.Notifications.Connectors[i]
+		path := fmt.Sprintf("global_settings.notifications.connectors[%s]", connector.Name)
+		// Slack and Teams webhook URLs embed their credential
+		connector.WebhookURL = e.replace(connector.WebhookURL, path+".webhook_url", "CONNECTOR", connector.Name, "WEBHOOK_URL")
+		connector.RoutingKey = e.replace(connector.RoutingKey, path+".routing_key", "CONNECTOR", connector.Name, "ROUTING_KEY")
+		connector.APIKey = e.replace(connector.APIKey, path+".api_key", "CONNECTOR", connector.Name, "API_KEY")
+	}
+	
+	return models.ConfigExport{
+		ExportedAt: time.Now().UTC(),
+		Config:     copied,
+		Secrets:    e.secrets,
+	}, nil
+}
+
+// replace records a secret under a name built from parts and returns its
+// placeholder, or "" for an empty secret
+func (e *exporter) replace(secret, path string, parts ...string) string {
+	if secret == "" {
+		return ""
+	}
+	
+	name := placeholderName(parts)
+	for n := 2; e.names[name]; n++ {
+		name = fmt.Sprintf("%s_%d", placeholderName(parts), n)
+	}
+	e.names[name] = true
+	
+	e.secrets = append(e.secrets, models.SecretPlaceholder{Name: name, Path: path})
+	return "${" + name + "}"
+}
+
+// placeholderName joins parts into an upper-case identifier such as
+// SOURCE_FIREWALL_SPLUNK_API_KEY
+func placeholderName(parts []string) string {
+	var name strings.Builder
+	underscore := false
+	for _, part := range parts {
+		for _, r := range strings.ToUpper(part) {
+			if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				name.WriteRune(r)
+				underscore = false
+			} else if !underscore && name.Len() > 0 {
+				name.WriteByte('_')
+				underscore = true
+			}
+		}
+		if !underscore && name.Len() > 0 {
+			name.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(name.String(), "_")
+}
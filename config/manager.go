@@ -14,6 +14,9 @@ import (
 type Manager struct {
 	configFile string
 	config     *models.Config
+	readOnly   bool     // Environment-only mode; SaveConfig never writes
+	origin     string   // Where the environment-only configuration was read from
+	overrides  []string // Environment variables applied over it
 }
 
 // NewManager creates a new configuration manager
@@ -23,20 +26,17 @@ func NewManager(configFile string) *Manager {
 	}
 }
 
-// LoadConfig loads configuration from file
+// LoadConfig loads configuration from file, or in environment-only mode
+// from the environment
 func (m *Manager) LoadConfig() (*models.Config, error) {
+	// Immutable deployments provide the configuration through the environment
+	if environmentMode() {
+		return m.loadFromEnvironment()
+	}
+	
 	if _, err := os.Stat(m.configFile); os.IsNotExist(err) {
 		// Create default configuration
-		m.config = &models.Config{
-			Sources: []models.SourceConfig{},
-			GlobalSettings: models.GlobalSettings{
-				WebPort:               8080,
-				MaxMemoryPerSource:    "100MB",
-				MetricsRetentionHours: 24,
-				BatchSize:             1000,
-				MaxEPSPerSource:       20000,
-			},
-		}
+		m.config = defaultConfig()
 		if err := m.SaveConfig(); err != nil {
 			return nil, err
 		}
@@ -53,22 +53,27 @@ func (m *Manager) LoadConfig() (*models.Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %v", err)
 	}
 	
-	// Set defaults if missing
-	if m.config.GlobalSettings.BatchSize == 0 {
-		m.config.GlobalSettings.BatchSize = 1000
-	}
-	if m.config.GlobalSettings.MaxEPSPerSource == 0 {
-		m.config.GlobalSettings.MaxEPSPerSource = 20000
+	applyDefaults(m.config)
+	
+	// Give resources created before IDs existed a stable ID
+	if AssignIDs(m.config) {
+		if err := m.SaveConfig(); err != nil {
+			log.Printf("⚠ Warning: Failed to save assigned resource IDs: %v", err)
+		}
 	}
 	
 	return m.config, nil
 }
 
-// SaveConfig saves current configuration to file
+// SaveConfig saves current configuration to file. In environment-only
+// mode nothing is written and ErrReadOnly is returned.
 func (m *Manager) SaveConfig() error {
 	if m.config == nil {
 		return fmt.Errorf("no configuration to save")
 	}
+	if m.readOnly {
+		return ErrReadOnly
+	}
 	
 	data, err := json.MarshalIndent(m.config, "", "  ")
 	if err != nil {
@@ -98,4 +103,129 @@ func (m *Manager) GetConfig() *models.Config {
 // UpdateConfig updates the current configuration
 func (m *Manager) UpdateConfig(config *models.Config) {
 	m.config = config
-} 
+}
+
+// defaultConfig returns the configuration written on first start
+func defaultConfig() *models.Config {
+	return &models.Config{
+		Sources: []models.SourceConfig{},
+		GlobalSettings: models.GlobalSettings{
+			WebPort:               8080,
+			MaxMemoryPerSource:    "100MB",
+			MetricsRetentionHours: 24,
+			BatchSize:             1000,
+			MaxEPSPerSource:       20000,
+			RemoteWrite: models.RemoteWriteConfig{
+				IntervalSeconds:   15,
+				MaxRetries:        3,
+				MaxBufferedPushes: 240,
+			},
+			SNMP: models.SNMPConfig{
+				Port:      1161,
+				Community: "public",
+				BaseOID:   "1.3.6.1.4.1.99999.1",
+			},
+			SelfLogging: models.SelfLoggingConfig{
+				Protocol:  "udp",
+				Facility:  16,
+				AppName:   "syslog-analyzer",
+				VerifySSL: true,
+			},
+			EventLog: models.EventLogConfig{
+				Source:       "syslog-analyzer",
+				MinLevel:     "warning",
+				MaxPerMinute: 60,
+			},
+			Digest: models.DigestConfig{
+				SendTime:   "08:00",
+				Recipients: []string{},
+				SMTP: models.SMTPConfig{
+					Port: 587,
+				},
+			},
+			Notifications: models.NotificationsConfig{
+				EvaluationIntervalSeconds: 30,
+				Rules:                     []models.AlertRule{},
+				Connectors:                []models.NotificationConnector{},
+			},
+			Chargeback: models.ChargebackConfig{
+				DataFile:   "chargeback.json",
+				SendDay:    1,
+				SendTime:   "08:00",
+				Recipients: []string{},
+				SMTP: models.SMTPConfig{
+					Port: 587,
+				},
+			},
+			Forecast: models.ForecastConfig{
+				DataFile:          "forecast.json",
+				HistoryDays:       90,
+				HorizonDays:       90,
+				Model:             models.ForecastLinear,
+				DiskRetentionDays: 30,
+			},
+			History: models.HistoryConfig{
+				Dir:          "history",
+				RawDays:      7,
+				HourlyMonths: 13,
+			},
+			CountersFile: "counters.json",
+			Compression: models.CompressionConfig{
+				HTTP:      true,
+				WebSocket: true,
+			},
+		},
+	}
+}
+
+// applyDefaults fills in settings missing from a loaded configuration
+func applyDefaults(config *models.Config) {
+	if config.GlobalSettings.BatchSize == 0 {
+		config.GlobalSettings.BatchSize = 1000
+	}
+	if config.GlobalSettings.MaxEPSPerSource == 0 {
+		config.GlobalSettings.MaxEPSPerSource = 20000
+	}
+	if config.GlobalSettings.RemoteWrite.IntervalSeconds == 0 {
+		config.GlobalSettings.RemoteWrite.IntervalSeconds = 15
+	}
+	if config.GlobalSettings.RemoteWrite.MaxBufferedPushes == 0 {
+		config.GlobalSettings.RemoteWrite.MaxBufferedPushes = 240
+	}
+	if config.GlobalSettings.Chargeback.DataFile == "" {
+		config.GlobalSettings.Chargeback.DataFile = "chargeback.json"
+	}
+	if config.GlobalSettings.Chargeback.SendDay == 0 {
+		config.GlobalSettings.Chargeback.SendDay = 1
+	}
+	if config.GlobalSettings.Chargeback.SendTime == "" {
+		config.GlobalSettings.Chargeback.SendTime = "08:00"
+	}
+	if config.GlobalSettings.Forecast.DataFile == "" {
+		config.GlobalSettings.Forecast.DataFile = "forecast.json"
+	}
+	if config.GlobalSettings.Forecast.HistoryDays == 0 {
+		config.GlobalSettings.Forecast.HistoryDays = 90
+	}
+	if config.GlobalSettings.Forecast.HorizonDays == 0 {
+		config.GlobalSettings.Forecast.HorizonDays = 90
+	}
+	if config.GlobalSettings.Forecast.Model == "" {
+		config.GlobalSettings.Forecast.Model = models.ForecastLinear
+	}
+	if config.GlobalSettings.Forecast.DiskRetentionDays == 0 {
+		config.GlobalSettings.Forecast.DiskRetentionDays = 30
+	}
+	if config.GlobalSettings.History.Dir == "" {
+		config.GlobalSettings.History.Dir = "history"
+	}
+	if config.GlobalSettings.History.RawDays == 0 {
+		config.GlobalSettings.History.RawDays = 7
+	}
+	if config.GlobalSettings.History.HourlyMonths == 0 {
+		config.GlobalSettings.History.HourlyMonths = 13
+	}
+	if config.GlobalSettings.CountersFile == "" {
+		config.GlobalSettings.CountersFile = "counters.json"
+	}
+}
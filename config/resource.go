@@ -0,0 +1,110 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"syslog-analyzer/models"
+)
+
+// Errors of conditional and idempotent resource updates
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrInvalid            = errors.New("invalid configuration")
+	ErrPreconditionFailed = errors.New("resource has been modified (ETag mismatch)")
+	ErrReadOnly           = errors.New("configuration is read-only in environment-only mode")
+)
+
+// NewID returns a random, stable resource ID
+func NewID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// ETag returns the entity tag of a resource's current representation.
+// Resources with identical configuration have identical tags.
+func ETag(resource interface{}) string {
+	data, _ := json.Marshal(resource)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Precondition holds the If-Match and If-None-Match headers of a request
+type Precondition struct {
+	IfMatch     string
+	IfNoneMatch string
+}
+
+// Check verifies the precondition against the ETag of the current resource,
+// "" when it does not exist
+func (p Precondition) Check(etag string) error {
+	if p.IfMatch != "" && !matchETag(p.IfMatch, etag) {
+		return ErrPreconditionFailed
+	}
+	if p.IfNoneMatch != "" && matchETag(p.IfNoneMatch, etag) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// matchETag reports whether a header's list of tags, or "*", matches etag
+func matchETag(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// AssignIDs gives every source and destination without an ID a new one and
+// reports whether any was assigned
+func AssignIDs(config *models.Config) bool {
+	assigned := false
+	for i := range config.Sources {
+		if config.Sources[i].ID == "" {
+			config.Sources[i].ID = NewID()
+			assigned = true
+		}
+		if AssignDestinationIDs(config.Sources[i].Destinations, nil) {
+			assigned = true
+		}
+	}
+	return assigned
+}
+
+// AssignDestinationIDs gives destinations without an ID the ID of the
+// previous destination of the same name, so resubmitting a source unchanged
+// keeps its IDs, or a new one. It reports whether any ID was assigned.
+func AssignDestinationIDs(destinations []models.Destination, previous []models.Destination) bool {
+	used := make(map[string]bool)
+	for _, dest := range destinations {
+		used[dest.ID] = true
+	}
+	
+	assigned := false
+	for i := range destinations {
+		if destinations[i].ID != "" {
+			continue
+		}
+		destinations[i].ID = NewID()
+		for _, old := range previous {
+			if old.Name == destinations[i].Name && old.ID != "" && !used[old.ID] {
+				destinations[i].ID = old.ID
+				break
+			}
+		}
+		used[destinations[i].ID] = true
+		assigned = true
+	}
+	return assigned
+}
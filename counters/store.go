@@ -0,0 +1,302 @@
+package counters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Reasons recorded with a counter reset event
+const (
+	ReasonManual  = "manual"  // Reset through the API
+	ReasonDeleted = "deleted" // Source was deleted
+)
+
+// maxResetEvents bounds the reset history kept
+const maxResetEvents = 500
+
+// saveInterval is how often the totals are persisted
+const saveInterval = 1 * time.Minute
+
+// values is one set of the counters kept per source
+type values struct {
+	Logs       int64 `json:"logs"`
+	EventBytes int64 `json:"event_bytes"`
+	WireBytes  int64 `json:"wire_bytes"`
+	Processed  int64 `json:"processed"`
+	Sent       int64 `json:"sent"`
+	Dropped    int64 `json:"dropped"`
+	DestErrors int64 `json:"destination_errors"`
+	Severity   int64 `json:"severity_dropped"`
+}
+
+// rawValues reads the process-lifetime counters of a source's metrics
+func rawValues(metrics models.SourceMetrics) values {
+	return values{
+		Logs:       metrics.TotalLogsIngested,
+		EventBytes: metrics.TotalEventBytes,
+		WireBytes:  metrics.TotalWireBytes,
+		Processed:  metrics.ProcessedCount,
+		Sent:       metrics.SentCount,
+		Dropped:    metrics.DroppedCount,
+		DestErrors: metrics.DestinationErrors,
+		Severity:   metrics.SeverityDropped,
+	}
+}
+
+// sourceCounters are the persisted monotonic totals of a source
+type sourceCounters struct {
+	Totals values    `json:"totals"`
+	Since  time.Time `json:"since"`  // When the totals last started from zero
+	Resets int64     `json:"resets"` // Reset events recorded for the source
+	raw    values    // Raw counters at the last observation; not persisted
+}
+
+// advance adds the growth of each counter since the last observation to the
+// totals. Snapshots taken concurrently may arrive out of order, so a counter
+// below its last observed value is ignored rather than treated as a restart.
+func (c *sourceCounters) advance(current values) {
+	grow := func(total, raw *int64, value int64) {
+		if value > *raw {
+			*total += value - *raw
+			*raw = value
+		}
+	}
+	grow(&c.Totals.Logs, &c.raw.Logs, current.Logs)
+	grow(&c.Totals.EventBytes, &c.raw.EventBytes, current.EventBytes)
+	grow(&c.Totals.WireBytes, &c.raw.WireBytes, current.WireBytes)
+	grow(&c.Totals.Processed, &c.raw.Processed, current.Processed)
+	grow(&c.Totals.Sent, &c.raw.Sent, current.Sent)
+	grow(&c.Totals.Dropped, &c.raw.Dropped, current.Dropped)
+	grow(&c.Totals.DestErrors, &c.raw.DestErrors, current.DestErrors)
+	grow(&c.Totals.Severity, &c.raw.Severity, current.Severity)
+}
+
+// storeFile is the persisted form of the store
+type storeFile struct {
+	Sources map[string]*sourceCounters `json:"sources"`
+	Resets  []models.CounterResetEvent `json:"resets"`
+}
+
+// Store turns the per-process counters of each source into totals that only
+// go up: they survive restarts of the analyzer and of individual sources and
+// only start from zero again through an explicit reset, which is recorded
+type Store struct {
+	dataFile string
+	data     storeFile
+	mutex    sync.Mutex
+	stopChan chan bool
+}
+
+// NewStore creates a store and loads previously persisted totals
+func NewStore(dataFile string) (*Store, error) {
+	s := &Store{
+		dataFile: dataFile,
+		data:     storeFile{Sources: make(map[string]*sourceCounters)},
+		stopChan: make(chan bool),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Start persists the totals periodically
+func (s *Store) Start() {
+	go s.run()
+}
+
+// Stop stops the periodic save and persists the totals
+func (s *Store) Stop() {
+	close(s.stopChan)
+	
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.save(); err != nil {
+		log.Printf("✗ Failed to save counters: %v", err)
+	}
+}
+
+func (s *Store) run() {
+	ticker := time.NewTicker(saveInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.mutex.Lock()
+			if err := s.save(); err != nil {
+				log.Printf("✗ Failed to save counters: %v", err)
+			}
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// Apply folds the raw counters of each source into its totals and replaces
+// the raw counters in the metrics with the totals
+func (s *Store) Apply(sources []models.SourceMetrics) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	now := time.Now()
+	for i := range sources {
+		counters := s.observe(sources[i], now)
+		sources[i].TotalLogsIngested = counters.Totals.Logs
+		sources[i].TotalEventBytes = counters.Totals.EventBytes
+		sources[i].TotalWireBytes = counters.Totals.WireBytes
+		sources[i].ProcessedCount = counters.Totals.Processed
+		sources[i].SentCount = counters.Totals.Sent
+		sources[i].DroppedCount = counters.Totals.Dropped
+		sources[i].DestinationErrors = counters.Totals.DestErrors
+		sources[i].SeverityDropped = counters.Totals.Severity
+		sources[i].CountersSince = counters.Since
+		sources[i].CounterResets = counters.Resets
+	}
+}
+
+// Retire folds the final counters of a stopped source; the source's next
+// instance starts its raw counters from zero and continues the same totals
+func (s *Store) Retire(metrics models.SourceMetrics) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	s.observe(metrics, time.Now()).raw = values{}
+}
+
+// Rename moves the totals of a source to its new name
+func (s *Store) Rename(oldName, newName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	if counters, exists := s.data.Sources[oldName]; exists && oldName != newName {
+		s.data.Sources[newName] = counters
+		delete(s.data.Sources, oldName)
+	}
+}
+
+// Delete drops the totals of a deleted source and records the reset
+func (s *Store) Delete(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	if counters, exists := s.data.Sources[name]; exists {
+		s.recordReset(name, ReasonDeleted, "", counters.Totals, time.Now())
+		delete(s.data.Sources, name)
+		s.saveOrLog()
+	}
+}
+
+// Reset starts the totals of a source from zero
+func (s *Store) Reset(name, by string) (models.CounterResetEvent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	counters, exists := s.data.Sources[name]
+	if !exists {
+		return models.CounterResetEvent{}, fmt.Errorf("no counters recorded for source '%s'", name)
+	}
+	
+	now := time.Now()
+	event := s.recordReset(name, ReasonManual, by, counters.Totals, now)
+	counters.Totals = values{}
+	counters.Since = now
+	counters.Resets++
+	s.saveOrLog()
+	return event, nil
+}
+
+// GetResets returns the recorded reset events, newest first
+func (s *Store) GetResets() []models.CounterResetEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	events := make([]models.CounterResetEvent, len(s.data.Resets))
+	copy(events, s.data.Resets)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.After(events[j].Time)
+	})
+	return events
+}
+
+// observe folds a source's raw counters into its totals; callers hold the mutex
+func (s *Store) observe(metrics models.SourceMetrics, now time.Time) *sourceCounters {
+	counters, exists := s.data.Sources[metrics.Name]
+	if !exists {
+		counters = &sourceCounters{Since: now}
+		s.data.Sources[metrics.Name] = counters
+	}
+	
+	counters.advance(rawValues(metrics))
+	return counters
+}
+
+// recordReset appends a reset event; callers hold the mutex
+func (s *Store) recordReset(name, reason, by string, totals values, now time.Time) models.CounterResetEvent {
+	event := models.CounterResetEvent{
+		Source:            name,
+		Time:              now,
+		Reason:            reason,
+		By:                by,
+		LogsIngested:      totals.Logs,
+		ProcessedCount:    totals.Processed,
+		SentCount:         totals.Sent,
+		DroppedCount:      totals.Dropped,
+		DestinationErrors: totals.DestErrors,
+	}
+	s.data.Resets = append(s.data.Resets, event)
+	if len(s.data.Resets) > maxResetEvents {
+		s.data.Resets = s.data.Resets[len(s.data.Resets)-maxResetEvents:]
+	}
+	log.Printf("⚠ Counter reset for source '%s' (%s)", name, reason)
+	return event
+}
+
+// saveOrLog persists the store and logs failures; callers hold the mutex
+func (s *Store) saveOrLog() {
+	if err := s.save(); err != nil {
+		log.Printf("✗ Failed to save counters: %v", err)
+	}
+}
+
+// load reads the persisted totals if present. The raw counters of the new
+// process start from zero, so nothing from before the restart is double counted.
+func (s *Store) load() error {
+	data, err := ioutil.ReadFile(s.dataFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read counters: %v", err)
+	}
+	if err := json.Unmarshal(data, &s.data); err != nil {
+		return fmt.Errorf("failed to parse counters: %v", err)
+	}
+	if s.data.Sources == nil {
+		s.data.Sources = make(map[string]*sourceCounters)
+	}
+	return nil
+}
+
+// save writes the totals to disk; callers hold the mutex
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal counters: %v", err)
+	}
+	
+	tmpFile := s.dataFile + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write counters: %v", err)
+	}
+	return os.Rename(tmpFile, s.dataFile)
+}
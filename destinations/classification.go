@@ -0,0 +1,119 @@
+package destinations
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"syslog-analyzer/models"
+)
+
+// maxClassificationLabel bounds the length of a classification label
+const maxClassificationLabel = 64
+
+var (
+	classificationMutex sync.RWMutex
+	classificationRules []models.ClassificationRule
+)
+
+// ValidateClassification checks the classification labels of a source
+func ValidateClassification(labels []string) error {
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		if label == "" || len(label) > maxClassificationLabel || strings.ContainsAny(label, " ,;\t") {
+			return fmt.Errorf("invalid classification label %q (expected a word such as pci or pii)", label)
+		}
+		if seen[strings.ToLower(label)] {
+			return fmt.Errorf("duplicate classification label %q", label)
+		}
+		seen[strings.ToLower(label)] = true
+	}
+	return nil
+}
+
+// ValidateClassificationRules checks classification rules
+func ValidateClassificationRules(rules []models.ClassificationRule) error {
+	for _, rule := range rules {
+		if err := ValidateClassification([]string{rule.Label}); err != nil {
+			return err
+		}
+		if !rule.RequireEncryption && len(rule.DestinationTypes) == 0 {
+			return fmt.Errorf("rule for %s needs require_encryption or destination_types", rule.Label)
+		}
+		for _, destType := range rule.DestinationTypes {
+			switch destType {
+			case "storage", "hec", "null", "relay":
+			default:
+				return fmt.Errorf("rule for %s: unknown destination type %s", rule.Label, destType)
+			}
+		}
+	}
+	return nil
+}
+
+// SetClassificationRules sets the rules destinations are checked against
+// when sources start
+func SetClassificationRules(rules []models.ClassificationRule) error {
+	if err := ValidateClassificationRules(rules); err != nil {
+		return err
+	}
+	
+	classificationMutex.Lock()
+	defer classificationMutex.Unlock()
+	classificationRules = append([]models.ClassificationRule(nil), rules...)
+	return nil
+}
+
+// CheckRouting returns why a destination may not receive the events of a
+// source with the given labels under the current rules, or nil
+func CheckRouting(labels []string, dest models.Destination) error {
+	classificationMutex.RLock()
+	defer classificationMutex.RUnlock()
+	return CheckRoutingWith(classificationRules, labels, dest)
+}
+
+// CheckRoutingWith checks a destination against the given rules. Labels
+// match rules regardless of case.
+func CheckRoutingWith(rules []models.ClassificationRule, labels []string, dest models.Destination) error {
+	for _, label := range labels {
+		for _, rule := range rules {
+			if !strings.EqualFold(rule.Label, label) {
+				continue
+			}
+			if rule.RequireEncryption && !Encrypted(dest) {
+				return fmt.Errorf("%s events may only go to encrypted destinations; %s destination '%s' does not encrypt in transit", label, dest.Type, dest.Name)
+			}
+			if len(rule.DestinationTypes) > 0 && !containsString(rule.DestinationTypes, dest.Type) {
+				return fmt.Errorf("%s events may only go to %s destinations, not %s destination '%s'", label, strings.Join(rule.DestinationTypes, ", "), dest.Type, dest.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Encrypted reports whether a destination encrypts events in transit: HEC
+// over HTTPS and relays over TLS. Null destinations send nothing and count
+// as encrypted; storage writes plain files.
+func Encrypted(dest models.Destination) bool {
+	switch dest.Type {
+	case "null":
+		return true
+	case "hec":
+		configMap, _ := dest.Config.(map[string]interface{})
+		url, _ := configMap["url"].(string)
+		return strings.HasPrefix(strings.ToLower(url), "https://")
+	case "relay":
+		config, err := parseRelayConfig(&dest)
+		return err == nil && config.TLS != nil
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
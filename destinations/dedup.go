@@ -0,0 +1,250 @@
+package destinations
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"syslog-analyzer/models"
+	"syslog-analyzer/spool"
+)
+
+// Dedup defaults
+const (
+	defaultDedupMaxIDs    = 250000
+	defaultDedupLedgerDir = "delivery_ledger"
+)
+
+// ValidateDedup checks a destination's dedup settings
+func ValidateDedup(dedup models.Dedup) error {
+	if dedup.MaxIDs < 0 {
+		return fmt.Errorf("dedup max_ids cannot be negative")
+	}
+	// An empty directory takes the default; one set by an API caller is
+	// held to the storage roots like a storage path
+	if dedup.LedgerDir != "" {
+		if err := validatePath("dedup ledger_dir", dedup.LedgerDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dedupLedger remembers the IDs of the last maxIDs events a destination
+// received, in memory and in an append-only file that is read back on start
+type dedupLedger struct {
+	path   string
+	maxIDs int
+	ids    map[string]struct{}
+	order  []string // Remembered IDs as a ring; once full, the oldest is at next
+	next   int
+	file   *os.File
+	lines  int // IDs in the file, including those no longer remembered
+}
+
+// openDedupLedger reads the ledger at path, if there is one, and opens it
+// for appending
+func openDedupLedger(path string, maxIDs int) (*dedupLedger, error) {
+	l := &dedupLedger{path: path, maxIDs: maxIDs, ids: make(map[string]struct{})}
+	
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, id := range strings.Split(string(data), "\n") {
+		if id != "" {
+			l.remember(id)
+			l.lines++
+		}
+	}
+	
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if l.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// remember adds an ID, forgetting the oldest one once maxIDs are remembered
+func (l *dedupLedger) remember(id string) {
+	if _, seen := l.ids[id]; seen {
+		return
+	}
+	if len(l.order) < l.maxIDs {
+		l.order = append(l.order, id)
+	} else {
+		delete(l.ids, l.order[l.next])
+		l.order[l.next] = id
+		l.next = (l.next + 1) % l.maxIDs
+	}
+	l.ids[id] = struct{}{}
+}
+
+// seen returns whether an ID is remembered
+func (l *dedupLedger) seen(id string) bool {
+	_, seen := l.ids[id]
+	return seen
+}
+
+// record remembers delivered IDs and appends them to the file, synced so
+// they survive a crash. The file is rewritten once it holds twice the IDs
+// remembered.
+func (l *dedupLedger) record(ids []string) error {
+	var buf []byte
+	for _, id := range ids {
+		l.remember(id)
+		buf = append(buf, id...)
+		buf = append(buf, '\n')
+	}
+	if _, err := l.file.Write(buf); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	l.lines += len(ids)
+	
+	if l.lines > 2*l.maxIDs {
+		return l.compact()
+	}
+	return nil
+}
+
+// compact atomically rewrites the file with only the remembered IDs
+func (l *dedupLedger) compact() error {
+	var buf []byte
+	for _, id := range append(append([]string(nil), l.order[l.next:]...), l.order[:l.next]...) {
+		buf = append(buf, id...)
+		buf = append(buf, '\n')
+	}
+	
+	temp := l.path + ".tmp"
+	if err := os.WriteFile(temp, buf, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(temp, l.path); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	
+	l.file.Close()
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	l.file = file
+	l.lines = len(l.order)
+	return nil
+}
+
+// close closes the file
+func (l *dedupLedger) close() error {
+	return l.file.Close()
+}
+
+// dedupProcessor skips events the wrapped destination already received and
+// records the IDs of those it accepts. Events are only recorded once the
+// destination accepted the whole batch, so a failed batch is retried in full.
+// Events without an ID are always delivered.
+type dedupProcessor struct {
+	source     string
+	next       DestinationProcessor
+	ledger     *dedupLedger
+	suppressed int64
+	mutex      sync.Mutex // Held from the check until the IDs are recorded
+}
+
+// newDedupProcessor wraps a destination in the ledger of what it received
+func newDedupProcessor(dedup models.Dedup, dest models.Destination, sourceName string, next DestinationProcessor) (*dedupProcessor, error) {
+	if err := ValidateDedup(dedup); err != nil {
+		return nil, err
+	}
+	if dedup.MaxIDs == 0 {
+		dedup.MaxIDs = defaultDedupMaxIDs
+	}
+	if dedup.LedgerDir == "" {
+		dedup.LedgerDir = defaultDedupLedgerDir
+	}
+	
+	name := spool.SafeName(sourceName+"_"+dest.ID) + ".ids"
+	ledger, err := openDedupLedger(filepath.Join(dedup.LedgerDir, name), dedup.MaxIDs)
+	if err != nil {
+		return nil, fmt.Errorf("dedup ledger: %v", err)
+	}
+	return &dedupProcessor{source: sourceName, next: next, ledger: ledger}, nil
+}
+
+// ProcessBatch forwards the events not delivered before
+func (p *dedupProcessor) ProcessBatch(batch *models.LogBatch, sourceName string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	
+	// Most batches hold no duplicates and are forwarded as they are
+	duplicates := 0
+	for _, event := range batch.Events {
+		if event.ID != "" && p.ledger.seen(event.ID) {
+			duplicates++
+		}
+	}
+	if duplicates > 0 {
+		fresh := *batch
+		fresh.Events = make([]models.LogEvent, 0, len(batch.Events)-duplicates)
+		for _, event := range batch.Events {
+			if event.ID == "" || !p.ledger.seen(event.ID) {
+				fresh.Events = append(fresh.Events, event)
+			}
+		}
+		atomic.AddInt64(&p.suppressed, int64(duplicates))
+		batch = &fresh
+		if len(batch.Events) == 0 {
+			return nil
+		}
+	}
+	
+	if err := p.next.ProcessBatch(batch, sourceName); err != nil {
+		return err
+	}
+	
+	ids := make([]string, 0, len(batch.Events))
+	for _, event := range batch.Events {
+		if event.ID != "" {
+			ids = append(ids, event.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	
+	// The events are delivered; failing the batch now would only resend them
+	if err := p.ledger.record(ids); err != nil {
+		log.Printf("⚠ Error recording delivered events of source '%s' in the dedup ledger: %v", p.source, err)
+	}
+	return nil
+}
+
+// Flush flushes the wrapped destination
+func (p *dedupProcessor) Flush() error {
+	return p.next.Flush()
+}
+
+// Close closes the ledger and the wrapped destination
+func (p *dedupProcessor) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	
+	if err := p.ledger.close(); err != nil {
+		log.Printf("⚠ Error closing the dedup ledger of source '%s': %v", p.source, err)
+	}
+	return p.next.Close()
+}
+
+// dedupStats reports the duplicates suppressed
+func (p *dedupProcessor) dedupStats(stat *models.DestinationStats) {
+	stat.DuplicatesSuppressed = atomic.LoadInt64(&p.suppressed)
+}
@@ -0,0 +1,321 @@
+package destinations
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"syslog-analyzer/models"
+)
+
+// JSON encoders selectable through GlobalSettings.JSONEncoder
+const (
+	EncoderStandard = "standard" // encoding/json
+	EncoderFast     = "fast"     // Reflection-free encoder for event payloads
+)
+
+// Encoder writes the newline-delimited JSON that storage files and HEC
+// requests are made of. Implementations must produce the same bytes as
+// encoding/json, so switching encoders never changes the output.
+type Encoder interface {
+	// AppendLine appends the JSON encoding of v and a newline to dst
+	AppendLine(dst []byte, v interface{}) ([]byte, error)
+}
+
+var (
+	encoders = map[string]Encoder{
+		EncoderStandard: standardEncoder{},
+		EncoderFast:     fastEncoder{},
+	}
+	encoderName  = EncoderStandard
+	encoderMutex sync.RWMutex
+)
+
+// RegisterEncoder makes an encoder selectable by name, e.g. one backed by a
+// third-party JSON library
+func RegisterEncoder(name string, encoder Encoder) {
+	encoderMutex.Lock()
+	defer encoderMutex.Unlock()
+	encoders[name] = encoder
+}
+
+// SetEncoder selects the encoder of destinations created after the call; an
+// empty name selects encoding/json
+func SetEncoder(name string) error {
+	if name == "" {
+		name = EncoderStandard
+	}
+	
+	encoderMutex.Lock()
+	defer encoderMutex.Unlock()
+	if _, exists := encoders[name]; !exists {
+		return fmt.Errorf("unknown JSON encoder %q", name)
+	}
+	encoderName = name
+	return nil
+}
+
+// GetEncoder returns the name of the encoder new destinations use
+func GetEncoder() string {
+	encoderMutex.RLock()
+	defer encoderMutex.RUnlock()
+	return encoderName
+}
+
+// LookupEncoder returns a registered encoder
+func LookupEncoder(name string) (Encoder, bool) {
+	encoderMutex.RLock()
+	defer encoderMutex.RUnlock()
+	encoder, exists := encoders[name]
+	return encoder, exists
+}
+
+// currentEncoder returns the selected encoder
+func currentEncoder() Encoder {
+	encoderMutex.RLock()
+	defer encoderMutex.RUnlock()
+	return encoders[encoderName]
+}
+
+// standardEncoder encodes with encoding/json
+type standardEncoder struct{}
+
+func (standardEncoder) AppendLine(dst []byte, v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return dst, err
+	}
+	return append(append(dst, data...), '\n'), nil
+}
+
+// fastEncoder appends the types event payloads are decoded into without
+// reflection, and hands anything else to encoding/json
+type fastEncoder struct{}
+
+func (fastEncoder) AppendLine(dst []byte, v interface{}) ([]byte, error) {
+	out, err := appendValue(dst, v)
+	if err != nil {
+		return dst, err
+	}
+	return append(out, '\n'), nil
+}
+
+// appendValue appends the JSON encoding of v
+func appendValue(dst []byte, v interface{}) ([]byte, error) {
+	switch value := v.(type) {
+	case nil:
+		return append(dst, "null"...), nil
+	case string:
+		return appendString(dst, value), nil
+	case bool:
+		return strconv.AppendBool(dst, value), nil
+	case float64:
+		return appendFloat(dst, value, 64)
+	case float32:
+		return appendFloat(dst, float64(value), 32)
+	case int:
+		return strconv.AppendInt(dst, int64(value), 10), nil
+	case int64:
+		return strconv.AppendInt(dst, value, 10), nil
+	case int32:
+		return strconv.AppendInt(dst, int64(value), 10), nil
+	case uint64:
+		return strconv.AppendUint(dst, value, 10), nil
+	case json.RawMessage:
+		return appendRaw(dst, value)
+	case time.Time:
+		// Years outside 0-9999 are rejected by encoding/json; let it report them
+		if value.Year() < 0 || value.Year() > 9999 {
+			return appendStandard(dst, v)
+		}
+		dst = append(dst, '"')
+		dst = value.AppendFormat(dst, time.RFC3339Nano)
+		return append(dst, '"'), nil
+	case map[string]interface{}:
+		return appendObject(dst, value)
+	case []interface{}:
+		if value == nil {
+			return append(dst, "null"...), nil
+		}
+		dst = append(dst, '[')
+		for i, element := range value {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			var err error
+			if dst, err = appendValue(dst, element); err != nil {
+				return dst, err
+			}
+		}
+		return append(dst, ']'), nil
+	case models.LogEvent:
+		// Field order and names of the LogEvent JSON tags
+		dst = append(dst, `{"time":`...)
+		var err error
+		if dst, err = appendValue(dst, value.Time); err != nil {
+			return dst, err
+		}
+		dst = append(dst, `,"event":`...)
+		if dst, err = appendValue(dst, value.Event); err != nil {
+			return dst, err
+		}
+		dst = append(dst, `,"source":`...)
+		dst = appendString(dst, value.Source)
+		if value.ID != "" {
+			dst = append(dst, `,"id":`...)
+			dst = appendString(dst, value.ID)
+		}
+		if len(value.Classification) > 0 {
+			dst = append(dst, `,"classification":[`...)
+			for i, label := range value.Classification {
+				if i > 0 {
+					dst = append(dst, ',')
+				}
+				dst = appendString(dst, label)
+			}
+			dst = append(dst, ']')
+		}
+		return append(dst, '}'), nil
+	}
+	return appendStandard(dst, v)
+}
+
+// appendStandard appends v as encoded by encoding/json
+func appendStandard(dst []byte, v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, data...), nil
+}
+
+// appendObject appends a map with its keys sorted, as encoding/json does
+func appendObject(dst []byte, object map[string]interface{}) ([]byte, error) {
+	if object == nil {
+		return append(dst, "null"...), nil
+	}
+	
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	
+	dst = append(dst, '{')
+	for i, key := range keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendString(dst, key)
+		dst = append(dst, ':')
+		var err error
+		if dst, err = appendValue(dst, object[key]); err != nil {
+			return dst, err
+		}
+	}
+	return append(dst, '}'), nil
+}
+
+// appendFloat formats a number the way encoding/json does
+func appendFloat(dst []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return dst, fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, bits)
+	if format == 'e' {
+		// Clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst, nil
+}
+
+// appendRaw appends raw JSON compacted and HTML-escaped like encoding/json,
+// copying it as is when there is nothing to change
+func appendRaw(dst []byte, raw json.RawMessage) ([]byte, error) {
+	if len(raw) == 0 {
+		return append(dst, "null"...), nil
+	}
+	if bytes.IndexAny(raw, " \t\r\n<>&\u2028\u2029") < 0 {
+		return append(dst, raw...), nil
+	}
+	
+	var compacted, escaped bytes.Buffer
+	if err := json.Compact(&compacted, raw); err != nil {
+		return dst, err
+	}
+	json.HTMLEscape(&escaped, compacted.Bytes())
+	return append(dst, escaped.Bytes()...), nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendString appends a quoted string escaped the way encoding/json escapes
+// it with HTML escaping on
+func appendString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if c == '\u2028' || c == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
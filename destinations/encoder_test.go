@@ -0,0 +1,109 @@
+package destinations
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+	
+	"syslog-analyzer/models"
+)
+
+// encoderEvents decodes JSON payloads the way the processor does
+func encoderEvents(count int) []models.LogEvent {
+	events := make([]models.LogEvent, count)
+	for i := range events {
+		payload := fmt.Sprintf(`{"host":"host-%02d","action":"login","user":"user-%d","bytes":%d,"ratio":%g,"ok":true,"tags":["a","b<c>"],"nested":{"path":"/api?x=1&y=2","code":null},"message":"request handled in %dms\twith \"quotes\""}`,
+			i%50, i%200, 100+i*7, float64(i)/3, i)
+		var event interface{}
+		json.Unmarshal([]byte(payload), &event)
+		events[i] = models.LogEvent{
+			Time:   time.Date(2024, 5, 1, 12, 0, i%60, i*1000, time.UTC),
+			Event:  event,
+			Source: "bench",
+		}
+	}
+	return events
+}
+
+func TestFastEncoderMatchesStandard(t *testing.T) {
+	values := []interface{}{
+		nil,
+		true,
+		"plain",
+		"escapes \" \\ \b \f \n \r \t \x01 \x1f <script>&</script>",
+		"unicode é ✓ \u2028 \u2029 and invalid \xff\xfe bytes",
+		0.0, math.Copysign(0, -1), 1.0, -1.5, 0.1, 1e20, 1e21, 1e-6, 1e-7, 123456789.123, math.MaxFloat64, math.SmallestNonzeroFloat64,
+		float32(0.1), float32(1e21), float32(1e-7),
+		42, int64(-1 << 62), int32(-7), uint64(1 << 63),
+		json.RawMessage(`{"a": [1, 2], "b": "<tag>"}`),
+		json.RawMessage(`"compact"`),
+		json.Number("1.50"),
+		time.Date(2024, 5, 1, 12, 30, 45, 123456000, time.UTC),
+		time.Date(2024, 5, 1, 12, 30, 45, 0, time.FixedZone("CEST", 2*3600)),
+		map[string]interface{}{"z": 1.0, "a": []interface{}{"x", nil, map[string]interface{}{}}, "m": map[string]interface{}(nil)},
+		[]interface{}(nil),
+		[]interface{}{},
+		[]string{"typed", "slice"},
+		models.LogEvent{Time: time.Unix(0, 0).UTC(), Event: "text", Source: "s"},
+		models.LogEvent{Time: time.Unix(1700000000, 5).UTC(), Event: map[string]interface{}{"k": "v"}, Source: "s", ID: "id-1", Classification: []string{"pci", "pii"}},
+	}
+	for _, event := range encoderEvents(10) {
+		values = append(values, event, event.Event)
+	}
+	
+	standard, _ := LookupEncoder(EncoderStandard)
+	fast, _ := LookupEncoder(EncoderFast)
+	for _, value := range values {
+		want, err := standard.AppendLine(nil, value)
+		if err != nil {
+			t.Fatalf("standard encoder: %v", err)
+		}
+		got, err := fast.AppendLine(nil, value)
+		if err != nil {
+			t.Errorf("fast encoder failed on %#v: %v", value, err)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("fast encoder output differs for %#v:\n got %s\nwant %s", value, got, want)
+		}
+	}
+}
+
+func TestFastEncoderRejectsWhatStandardRejects(t *testing.T) {
+	standard, _ := LookupEncoder(EncoderStandard)
+	fast, _ := LookupEncoder(EncoderFast)
+	for _, value := range []interface{}{math.NaN(), math.Inf(1), map[string]interface{}{"x": math.Inf(-1)}} {
+		if _, err := standard.AppendLine(nil, value); err == nil {
+			t.Fatalf("standard encoder accepted %v", value)
+		}
+		if _, err := fast.AppendLine(nil, value); err == nil {
+			t.Errorf("fast encoder accepted %v", value)
+		}
+	}
+}
+
+func benchmarkEncoder(b *testing.B, name string) {
+	encoder, _ := LookupEncoder(name)
+	events := encoderEvents(100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	
+	var line []byte
+	for i := 0; i < b.N; i++ {
+		for _, event := range events {
+			line, _ = encoder.AppendLine(line[:0], event)
+		}
+	}
+}
+
+// BenchmarkEncoderStd encodes a batch of 100 events with encoding/json
+func BenchmarkEncoderStd(b *testing.B) {
+	benchmarkEncoder(b, EncoderStandard)
+}
+
+// BenchmarkEncoderFast encodes the same batch with the fast encoder
+func BenchmarkEncoderFast(b *testing.B) {
+	benchmarkEncoder(b, EncoderFast)
+}
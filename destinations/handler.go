@@ -1,22 +1,32 @@
 package destinations
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"syslog-analyzer/models"
+	"syslog-analyzer/recovery"
 )
 
 // Handler manages destination processing for multiple destinations
 type Handler struct {
 	destinations map[string]DestinationProcessor
+	configs      map[string]models.Destination // Same keys as destinations
+	latencies    map[string]time.Duration      // Latency of the last tracer each destination accepted, same keys
+	panics       int64                         // Panics recovered in delivery
 	mutex        sync.RWMutex
+	latencyMutex sync.Mutex // Guards latencies, written while mutex is only read locked
 }
 
 // DestinationProcessor interface for different destination types
 type DestinationProcessor interface {
 	ProcessBatch(batch *models.LogBatch, sourceName string) error
+	Flush() error // Persist or send anything accumulated so far
 	Close() error
 }
 
@@ -24,11 +34,19 @@ type DestinationProcessor interface {
 func NewHandler() *Handler {
 	return &Handler{
 		destinations: make(map[string]DestinationProcessor),
+		configs:      make(map[string]models.Destination),
+		latencies:    make(map[string]time.Duration),
 	}
 }
 
-// AddDestination adds a new destination for processing
-func (h *Handler) AddDestination(dest models.Destination, sourceName string) error {
+// finalizedFilesReporter is implemented by destinations that finalize files
+type finalizedFilesReporter interface {
+	FinalizedFiles() int64
+}
+
+// AddDestination adds a new destination for processing. Background work of
+// the destination, such as draining a delivery window, ends with ctx.
+func (h *Handler) AddDestination(ctx context.Context, dest models.Destination, sourceName string) error {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 	
@@ -37,6 +55,11 @@ func (h *Handler) AddDestination(dest models.Destination, sourceName string) err
 		return nil
 	}
 	
+	// Reject an unknown schema before opening anything the chain would hold
+	if err := ValidateSchema(dest.Schema); err != nil {
+		return err
+	}
+	
 	var processor DestinationProcessor
 	var err error
 	
@@ -45,6 +68,10 @@ func (h *Handler) AddDestination(dest models.Destination, sourceName string) err
 		processor, err = h.createStorageProcessor(dest)
 	case "hec":
 		processor, err = h.createHECProcessor(dest)
+	case "null":
+		processor = NewNullHandler()
+	case "relay":
+		processor, err = h.createRelayProcessor(dest)
 	default:
 		return fmt.Errorf("unknown destination type: %s", dest.Type)
 	}
@@ -53,8 +80,63 @@ func (h *Handler) AddDestination(dest models.Destination, sourceName string) err
 		return fmt.Errorf("failed to create %s processor: %v", dest.Type, err)
 	}
 	
+	// Count what the destination itself accepts for the cost report
+	if err := ValidateCost(dest.CostPerGB); err != nil {
+		processor.Close()
+		return err
+	}
+	if dest.CostPerGB > 0 {
+		processor = newMeterProcessor(dest.CostPerGB, processor)
+	}
+	
+	// Pace delivery closest to the destination so spooled replays are paced too
+	if dest.Throttle != nil {
+		throttled, err := newThrottleProcessor(*dest.Throttle, processor)
+		if err != nil {
+			processor.Close()
+			return err
+		}
+		processor = throttled
+	}
+	
+	// Skip events the destination already received, before they are paced
+	if dest.Dedup != nil {
+		deduped, err := newDedupProcessor(*dest.Dedup, dest, sourceName, processor)
+		if err != nil {
+			processor.Close()
+			return err
+		}
+		processor = deduped
+	}
+	
+	// Convert events to the destination's output schema before delivery
+	if dest.Schema != "" && dest.Schema != SchemaRaw {
+		processor = &schemaProcessor{schema: dest.Schema, next: processor}
+	}
+	
+	// Hold events back outside the destination's delivery window
+	if dest.Window != nil {
+		windowed, err := newWindowProcessor(ctx, *dest.Window, dest, sourceName, processor, &h.panics)
+		if err != nil {
+			processor.Close()
+			return err
+		}
+		processor = windowed
+	}
+	
+	// Heartbeats take the same path as real events, window included
+	if dest.Heartbeat != nil {
+		beating, err := newHeartbeatProcessor(ctx, *dest.Heartbeat, dest, sourceName, processor, &h.panics)
+		if err != nil {
+			processor.Close()
+			return err
+		}
+		processor = beating
+	}
+	
 	key := fmt.Sprintf("%s_%s", sourceName, dest.ID)
 	h.destinations[key] = processor
+	h.configs[key] = dest
 	
 	log.Printf("✓ Added %s destination '%s' for source '%s'", dest.Type, dest.Name, sourceName)
 	return nil
@@ -72,6 +154,10 @@ func (h *Handler) RemoveDestination(destID, sourceName string) error {
 			log.Printf("⚠ Error closing destination processor: %v", err)
 		}
 		delete(h.destinations, key)
+		delete(h.configs, key)
+		h.latencyMutex.Lock()
+		delete(h.latencies, key)
+		h.latencyMutex.Unlock()
 		log.Printf("✓ Removed destination '%s' for source '%s'", destID, sourceName)
 	}
 	
@@ -92,9 +178,18 @@ func (h *Handler) ProcessBatch(batch *models.LogBatch, sourceName string) error
 			continue
 		}
 		
-		if err := processor.ProcessBatch(batch, sourceName); err != nil {
+		// A panicking destination fails like any other, without keeping the
+		// batch from the source's other destinations
+		err := recovery.Guard("destination "+key, &h.panics, func() error {
+			return processor.ProcessBatch(batch, sourceName)
+		})
+		if err != nil {
 			log.Printf("⚠ Error processing batch for destination %s: %v", key, err)
 			errors = append(errors, err)
+		} else if batch.Trace != nil {
+			h.latencyMutex.Lock()
+			h.latencies[key] = time.Since(batch.Trace.Injected)
+			h.latencyMutex.Unlock()
 		}
 	}
 	
@@ -106,6 +201,27 @@ func (h *Handler) ProcessBatch(batch *models.LogBatch, sourceName string) error
 	return nil
 }
 
+// Flush flushes all destination processors, returning the first error
+func (h *Handler) Flush() error {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	
+	var errors []error
+	
+	for key, processor := range h.destinations {
+		if err := processor.Flush(); err != nil {
+			log.Printf("⚠ Error flushing destination processor %s: %v", key, err)
+			errors = append(errors, err)
+		}
+	}
+	
+	if len(errors) > 0 {
+		return errors[0]
+	}
+	
+	return nil
+}
+
 // Close closes all destination processors
 func (h *Handler) Close() error {
 	h.mutex.Lock()
@@ -122,6 +238,10 @@ func (h *Handler) Close() error {
 	
 	// Clear all destinations
 	h.destinations = make(map[string]DestinationProcessor)
+	h.configs = make(map[string]models.Destination)
+	h.latencyMutex.Lock()
+	h.latencies = make(map[string]time.Duration)
+	h.latencyMutex.Unlock()
 	
 	// Return first error if any occurred
 	if len(errors) > 0 {
@@ -148,8 +268,8 @@ func (h *Handler) createStorageProcessor(dest models.Destination) (DestinationPr
 		return nil, fmt.Errorf("invalid storage path format")
 	}
 	
-	if path == "" {
-		return nil, fmt.Errorf("storage path is empty")
+	if err := ValidateStoragePath(path); err != nil {
+		return nil, err
 	}
 	
 	// Get max events per file (optional)
@@ -160,9 +280,15 @@ func (h *Handler) createStorageProcessor(dest models.Destination) (DestinationPr
 		}
 	}
 	
+	format, _ := configMap["format"].(string)
+	if err := ValidateStorageFormat(format); err != nil {
+		return nil, err
+	}
+	
 	config := models.StorageConfig{
 		Path:             path,
 		MaxEventsPerFile: maxEventsPerFile,
+		Format:           format,
 	}
 	
 	return NewStorageHandler(config), nil
@@ -215,9 +341,138 @@ func (h *Handler) createHECProcessor(dest models.Destination) (DestinationProces
 		URL:       url,
 		APIKey:    apiKey,
 		VerifySSL: verifySSL,
+		Fields:    make(map[string]string),
 	}
 	
-	return NewHECHandler(config), nil
+	// Splunk metadata (optional, may be templates over event fields)
+	if sourcetype, ok := configMap["sourcetype"].(string); ok {
+		config.Sourcetype = sourcetype
+	}
+	if index, ok := configMap["index"].(string); ok {
+		config.Index = index
+	}
+	if host, ok := configMap["host"].(string); ok {
+		config.Host = host
+	}
+	if fields, ok := configMap["fields"].(map[string]interface{}); ok {
+		for name, value := range fields {
+			if text, ok := value.(string); ok {
+				config.Fields[name] = text
+			}
+		}
+	}
+	
+	handler, err := NewHECHandler(config)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// createRelayProcessor creates a relay destination processor
+func (h *Handler) createRelayProcessor(dest models.Destination) (DestinationProcessor, error) {
+	config, err := parseRelayConfig(&dest)
+	if err != nil {
+		return nil, err
+	}
+	return NewRelayHandler(config)
+}
+
+// GetStats returns the counters of every destination, sorted by name
+func (h *Handler) GetStats() []models.DestinationStats {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	
+	stats := make([]models.DestinationStats, 0, len(h.destinations))
+	for key, processor := range h.destinations {
+		dest := h.configs[key]
+		stat := models.DestinationStats{
+			ID:   dest.ID,
+			Name: dest.Name,
+			Type: dest.Type,
+		}
+		if wrapper, ok := processor.(*heartbeatProcessor); ok {
+			wrapper.heartbeatStats(&stat)
+			processor = wrapper.next
+		}
+		if wrapper, ok := processor.(*windowProcessor); ok {
+			wrapper.windowStats(&stat)
+			processor = wrapper.next
+		}
+		if wrapper, ok := processor.(*schemaProcessor); ok {
+			processor = wrapper.next
+		}
+		if wrapper, ok := processor.(*dedupProcessor); ok {
+			wrapper.dedupStats(&stat)
+			processor = wrapper.next
+		}
+		if wrapper, ok := processor.(*throttleProcessor); ok {
+			wrapper.throttleStats(&stat)
+			processor = wrapper.next
+		}
+		if wrapper, ok := processor.(*meterProcessor); ok {
+			wrapper.meterStats(&stat)
+			processor = wrapper.next
+		}
+		if reporter, ok := processor.(finalizedFilesReporter); ok {
+			stat.FinalizedFiles = reporter.FinalizedFiles()
+		}
+		h.latencyMutex.Lock()
+		stat.LatencySeconds = h.latencies[key].Seconds()
+		h.latencyMutex.Unlock()
+		stats = append(stats, stat)
+	}
+	
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// SetAPIKey switches a running destination of a source to a new API key in
+// place, keeping anything its delivery window holds. It reports false when
+// the destination is not running or has no API key.
+func (h *Handler) SetAPIKey(sourceName, destID, apiKey string) bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	
+	processor, exists := h.destinations[fmt.Sprintf("%s_%s", sourceName, destID)]
+	if !exists {
+		return false
+	}
+	setter, ok := innermost(processor).(interface{ SetAPIKey(string) })
+	if !ok {
+		return false
+	}
+	setter.SetAPIKey(apiKey)
+	return true
+}
+
+// innermost returns the destination inside a processor's wrappers
+func innermost(processor DestinationProcessor) DestinationProcessor {
+	for {
+		switch wrapper := processor.(type) {
+		case *heartbeatProcessor:
+			processor = wrapper.next
+		case *windowProcessor:
+			processor = wrapper.next
+		case *schemaProcessor:
+			processor = wrapper.next
+		case *dedupProcessor:
+			processor = wrapper.next
+		case *throttleProcessor:
+			processor = wrapper.next
+		case *meterProcessor:
+			processor = wrapper.next
+		default:
+			return processor
+		}
+	}
+}
+
+// GetPanics returns the number of panics recovered in delivery
+func (h *Handler) GetPanics() int64 {
+	return atomic.LoadInt64(&h.panics)
 }
 
 // GetDestinationCount returns the number of active destinations
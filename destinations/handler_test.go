@@ -0,0 +1,36 @@
+package destinations
+
+import (
+	"context"
+	"os"
+	"testing"
+	
+	"syslog-analyzer/models"
+)
+
+func TestAddDestinationRejectsSchemaBeforeOpening(t *testing.T) {
+	ledgerDir := t.TempDir()
+	h := NewHandler()
+	dest := models.Destination{
+		ID:      "d1",
+		Name:    "null",
+		Type:    "null",
+		Enabled: true,
+		Schema:  "bogus",
+		Dedup:   &models.Dedup{LedgerDir: ledgerDir},
+	}
+	
+	if err := h.AddDestination(context.Background(), dest, "source"); err == nil {
+		t.Fatal("unknown schema was accepted")
+	}
+	entries, err := os.ReadDir(ledgerDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("rejected destination left %d files in its ledger directory", len(entries))
+	}
+	if len(h.destinations) != 0 {
+		t.Fatalf("rejected destination was registered")
+	}
+}
@@ -0,0 +1,174 @@
+package destinations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/lifecycle"
+	"syslog-analyzer/models"
+	"syslog-analyzer/recovery"
+)
+
+// defaultHeartbeatMinutes is the heartbeat interval when none is configured
+const defaultHeartbeatMinutes = 5
+
+// ValidateHeartbeat checks a destination heartbeat
+func ValidateHeartbeat(heartbeat models.Heartbeat) error {
+	if heartbeat.IntervalMinutes < 0 {
+		return fmt.Errorf("heartbeat interval_minutes cannot be negative")
+	}
+	return nil
+}
+
+// heartbeatProcessor passes batches through and sends the wrapped
+// destination a synthetic heartbeat event every interval, so the SIEM can
+// tell a broken pipeline from a quiet source. Heartbeats take the same path
+// as real events, so they are converted, paced and held back by a delivery
+// window like any other.
+type heartbeatProcessor struct {
+	interval time.Duration
+	destID   string
+	destName string
+	source   string
+	host     string
+	next     DestinationProcessor
+	panics   *int64     // Panics recovered in the heartbeat loop, counted by the handler
+	mutex    sync.Mutex // Serializes delivery by the source and by the heartbeat loop
+	sent     int64
+	failed   int64
+	sequence int64
+	last     atomic.Value // time.Time of the last accepted heartbeat
+	ctx      context.Context
+	cancel   context.CancelFunc // Stops the heartbeat loop
+	done     chan struct{}
+}
+
+// newHeartbeatProcessor wraps a destination and starts the loop sending its
+// heartbeats, until ctx is cancelled or the processor is closed
+func newHeartbeatProcessor(ctx context.Context, heartbeat models.Heartbeat, dest models.Destination, sourceName string, next DestinationProcessor, panics *int64) (*heartbeatProcessor, error) {
+	if err := ValidateHeartbeat(heartbeat); err != nil {
+		return nil, err
+	}
+	if heartbeat.IntervalMinutes == 0 {
+		heartbeat.IntervalMinutes = defaultHeartbeatMinutes
+	}
+	host, _ := os.Hostname()
+	
+	p := &heartbeatProcessor{
+		interval: time.Duration(heartbeat.IntervalMinutes) * time.Minute,
+		destID:   dest.ID,
+		destName: dest.Name,
+		source:   sourceName,
+		host:     host,
+		next:     next,
+		panics:   panics,
+		done:     make(chan struct{}),
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	lifecycle.Go(p.ctx, p.run)
+	return p, nil
+}
+
+// ProcessBatch passes the batch to the wrapped destination
+func (p *heartbeatProcessor) ProcessBatch(batch *models.LogBatch, sourceName string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.next.ProcessBatch(batch, sourceName)
+}
+
+// run sends heartbeats, restarting the loop if a delivery panics
+func (p *heartbeatProcessor) run() {
+	defer close(p.done)
+	recovery.Supervise(fmt.Sprintf("heartbeat of destination '%s' of source '%s'", p.destName, p.source), p.panics, p.heartbeatLoop)
+}
+
+// heartbeatLoop sends a heartbeat every interval until stopped
+func (p *heartbeatProcessor) heartbeatLoop() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.beat(); err != nil {
+				atomic.AddInt64(&p.failed, 1)
+				log.Printf("⚠ Error sending heartbeat to destination '%s' of source '%s': %v", p.destName, p.source, err)
+			}
+		}
+	}
+}
+
+// beat delivers one heartbeat event and flushes it, so it doesn't sit in a
+// destination's batch until real events arrive
+func (p *heartbeatProcessor) beat() error {
+	now := time.Now()
+	sequence := atomic.AddInt64(&p.sequence, 1)
+	payload := map[string]interface{}{
+		"message":          "Syslog Analyzer heartbeat",
+		"synthetic":        true,
+		"event_type":       "heartbeat",
+		"source":           p.source,
+		"destination":      p.destName,
+		"analyzer_host":    p.host,
+		"sequence":         sequence,
+		"interval_seconds": int64(p.interval / time.Second),
+		"timestamp":        now.UTC().Format(time.RFC3339),
+	}
+	encoded, _ := json.Marshal(payload)
+	event := models.LogEvent{
+		Time:     now,
+		Event:    payload,
+		Source:   p.source,
+		ID:       fmt.Sprintf("heartbeat-%s-%d", p.destID, now.UnixNano()),
+		Size:     int64(len(encoded)),
+		Severity: 6,
+	}
+	batch := &models.LogBatch{Events: []models.LogEvent{event}, Timestamp: now}
+	
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := p.next.ProcessBatch(batch, p.source); err != nil {
+		return err
+	}
+	if err := p.next.Flush(); err != nil {
+		return err
+	}
+	atomic.AddInt64(&p.sent, 1)
+	p.last.Store(now)
+	return nil
+}
+
+// Flush flushes the wrapped destination
+func (p *heartbeatProcessor) Flush() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.next.Flush()
+}
+
+// Close stops the heartbeat loop and closes the wrapped destination
+func (p *heartbeatProcessor) Close() error {
+	p.cancel()
+	<-p.done
+	
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.next.Close()
+}
+
+// heartbeatStats reports the heartbeats sent and when the last one was
+// accepted
+func (p *heartbeatProcessor) heartbeatStats(stat *models.DestinationStats) {
+	stat.HeartbeatsSent = atomic.LoadInt64(&p.sent)
+	stat.HeartbeatFailures = atomic.LoadInt64(&p.failed)
+	if last, ok := p.last.Load().(time.Time); ok {
+		stat.LastHeartbeat = last
+	}
+}
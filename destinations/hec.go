@@ -2,11 +2,13 @@ package destinations
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"syslog-analyzer/models"
@@ -14,17 +16,182 @@ import (
 
 // HECHandler handles HEC destination processing
 type HECHandler struct {
-	config models.HECConfig
-	client *http.Client
+	config     models.HECConfig
+	client     *http.Client
+	sourcetype *metaTemplate
+	index      *metaTemplate
+	host       *metaTemplate
+	fields     map[string]*metaTemplate
+	encoder    Encoder
+	keyMutex   sync.RWMutex // Guards config.APIKey, which may be rotated while delivering
+}
+
+// metaTemplate is a HEC metadata value; values containing "{{" are
+// rendered per event as a Go template over the event fields
+type metaTemplate struct {
+	text     string
+	template *template.Template
 }
 
 // NewHECHandler creates a new HEC handler
-func NewHECHandler(config models.HECConfig) *HECHandler {
-	return &HECHandler{
+func NewHECHandler(config models.HECConfig) (*HECHandler, error) {
+	handler := &HECHandler{
 		config: config,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		fields:  make(map[string]*metaTemplate, len(config.Fields)),
+		encoder: currentEncoder(),
+	}
+	
+	var err error
+	if handler.sourcetype, err = newMetaTemplate("sourcetype", config.Sourcetype); err != nil {
+		return nil, err
+	}
+	if handler.index, err = newMetaTemplate("index", config.Index); err != nil {
+		return nil, err
+	}
+	if handler.host, err = newMetaTemplate("host", config.Host); err != nil {
+		return nil, err
+	}
+	for name, value := range config.Fields {
+		if handler.fields[name], err = newMetaTemplate("field "+name, value); err != nil {
+			return nil, err
+		}
+	}
+	
+	return handler, nil
+}
+
+// SetAPIKey switches the handler to a new API key; batches sent from now on
+// authenticate with it
+func (h *HECHandler) SetAPIKey(apiKey string) {
+	h.keyMutex.Lock()
+	defer h.keyMutex.Unlock()
+	h.config.APIKey = apiKey
+}
+
+// APIKey returns the API key of a HEC destination's configuration
+func APIKey(dest models.Destination) string {
+	configMap, _ := dest.Config.(map[string]interface{})
+	apiKey, _ := configMap["api_key"].(string)
+	return apiKey
+}
+
+// WithAPIKey returns a copy of a HEC destination using a new API key. The
+// configuration map is copied, as running sources may share the original.
+func WithAPIKey(dest models.Destination, apiKey string) (models.Destination, error) {
+	if dest.Type != "hec" {
+		return dest, fmt.Errorf("%s destinations have no API key", dest.Type)
+	}
+	configMap, ok := dest.Config.(map[string]interface{})
+	if !ok {
+		return dest, fmt.Errorf("invalid HEC configuration type")
+	}
+	
+	copied := make(map[string]interface{}, len(configMap))
+	for key, value := range configMap {
+		copied[key] = value
+	}
+	copied["api_key"] = apiKey
+	dest.Config = copied
+	return dest, nil
+}
+
+// ReplaceAPIKey returns a copy of dests in which the HEC destination with
+// the given ID uses a new API key
+func ReplaceAPIKey(dests []models.Destination, destID, apiKey string) []models.Destination {
+	replaced := append([]models.Destination(nil), dests...)
+	for i := range replaced {
+		if replaced[i].ID != destID {
+			continue
+		}
+		if dest, err := WithAPIKey(replaced[i], apiKey); err == nil {
+			replaced[i] = dest
+		}
+	}
+	return replaced
+}
+
+// newMetaTemplate parses a metadata value
+func newMetaTemplate(name, text string) (*metaTemplate, error) {
+	meta := &metaTemplate{text: text}
+	if strings.Contains(text, "{{") {
+		tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s template: %v", name, err)
+		}
+		meta.template = tmpl
+	}
+	return meta, nil
+}
+
+// render returns the value for an event; templates that fail to render
+// yield an empty value so the event is still delivered
+func (m *metaTemplate) render(data map[string]interface{}) string {
+	if m.template == nil {
+		return m.text
+	}
+	var buf bytes.Buffer
+	if err := m.template.Execute(&buf, data); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(buf.String(), "<no value>", ""))
+}
+
+// templateData exposes an event to metadata templates: the event's own
+// fields plus message, source and source_ip
+func templateData(event models.LogEvent, sourceName string) map[string]interface{} {
+	event.Materialize()
+	data := map[string]interface{}{
+		"source":    sourceName,
+		"source_ip": event.SenderIP,
+	}
+	switch payload := event.Event.(type) {
+	case string:
+		data["message"] = payload
+	case map[string]interface{}:
+		for name, value := range payload {
+			data[name] = value
+		}
+	case models.MetricsEvent:
+		for name, value := range payload.Dimensions {
+			data[name] = value
+		}
+	}
+	return data
+}
+
+// applyMetadata adds the configured sourcetype, index, host and indexed
+// fields to a HEC event
+func (h *HECHandler) applyMetadata(hecEvent map[string]interface{}, event models.LogEvent, sourceName string) {
+	if h.sourcetype.text == "" && h.index.text == "" && h.host.text == "" && len(h.fields) == 0 {
+		return
+	}
+	
+	data := templateData(event, sourceName)
+	if value := h.sourcetype.render(data); value != "" {
+		hecEvent["sourcetype"] = value
+	}
+	if value := h.index.render(data); value != "" {
+		hecEvent["index"] = value
+	}
+	if value := h.host.render(data); value != "" {
+		hecEvent["host"] = value
+	}
+	if len(h.fields) == 0 {
+		return
+	}
+	
+	fields, ok := hecEvent["fields"].(map[string]interface{})
+	if !ok {
+		fields = make(map[string]interface{}, len(h.fields))
+		hecEvent["fields"] = fields
+	}
+	for name, meta := range h.fields {
+		if value := meta.render(data); value != "" {
+			fields[name] = value
+		}
 	}
 }
 
@@ -38,11 +205,29 @@ func (h *HECHandler) ProcessBatch(batch *models.LogBatch, sourceName string) err
 	hecEvents := make([]map[string]interface{}, 0, len(batch.Events))
 	
 	for _, event := range batch.Events {
-		hecEvent := map[string]interface{}{
-			"time":   event.Time.Unix(),
-			"event":  event.Event,
-			"source": sourceName,
+		var hecEvent map[string]interface{}
+		if metricsEvent, ok := event.Event.(models.MetricsEvent); ok {
+			hecEvent = hecMetricEvent(event, metricsEvent, sourceName)
+		} else {
+			hecEvent = map[string]interface{}{
+				"time":   event.Time.Unix(),
+				"event":  event.Payload(),
+				"source": sourceName,
+			}
+			// Lets searches spot events a replay delivered twice despite the ledger
+			if event.ID != "" {
+				hecEvent["fields"] = map[string]interface{}{"event_id": event.ID}
+			}
 		}
+		if len(event.Classification) > 0 {
+			fields, ok := hecEvent["fields"].(map[string]interface{})
+			if !ok {
+				fields = make(map[string]interface{}, 1)
+				hecEvent["fields"] = fields
+			}
+			fields["classification"] = event.Classification
+		}
+		h.applyMetadata(hecEvent, event, sourceName)
 		hecEvents = append(hecEvents, hecEvent)
 	}
 	
@@ -50,6 +235,32 @@ func (h *HECHandler) ProcessBatch(batch *models.LogBatch, sourceName string) err
 	return h.sendToHEC(hecEvents)
 }
 
+// hecMetricEvent converts a metrics event to the HEC multiple-metric format,
+// with the group-by fields and rule name as dimensions
+func hecMetricEvent(event models.LogEvent, metricsEvent models.MetricsEvent, sourceName string) map[string]interface{} {
+	fields := make(map[string]interface{}, len(metricsEvent.Dimensions)+len(metricsEvent.Values)+1)
+	for name, value := range metricsEvent.Dimensions {
+		fields[name] = value
+	}
+	fields["aggregation"] = metricsEvent.Rule
+	for name, value := range metricsEvent.Values {
+		fields["metric_name:"+name] = value
+	}
+	
+	return map[string]interface{}{
+		"time":   event.Time.Unix(),
+		"event":  "metric",
+		"source": sourceName,
+		"fields": fields,
+	}
+}
+
+// Flush implements DestinationProcessor. Batches are sent synchronously by
+// ProcessBatch, so nothing is left to send once it has returned.
+func (h *HECHandler) Flush() error {
+	return nil
+}
+
 // Close closes the HEC handler (implements DestinationProcessor interface)
 func (h *HECHandler) Close() error {
 	// For HTTP client, we don't need to do anything special to close
@@ -61,24 +272,25 @@ func (h *HECHandler) Close() error {
 // sendToHEC sends events to the HEC endpoint
 func (h *HECHandler) sendToHEC(events []map[string]interface{}) error {
 	// Convert to JSON
-	var payload bytes.Buffer
-	encoder := json.NewEncoder(&payload)
-	
+	var payload []byte
 	for _, event := range events {
-		if err := encoder.Encode(event); err != nil {
+		var err error
+		if payload, err = h.encoder.AppendLine(payload, event); err != nil {
 			return fmt.Errorf("failed to encode event: %v", err)
 		}
 	}
 	
 	// Create request
-	req, err := http.NewRequest("POST", h.config.URL, &payload)
+	req, err := http.NewRequest("POST", h.config.URL, bytes.NewReader(payload))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %v", err)
 	}
 	
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
+	h.keyMutex.RLock()
 	req.Header.Set("Authorization", "Splunk "+h.config.APIKey)
+	h.keyMutex.RUnlock()
 	
 	// Send request
 	resp, err := h.client.Do(req)
@@ -0,0 +1,73 @@
+package destinations
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// ValidateCost checks a destination's price per GB
+func ValidateCost(costPerGB float64) error {
+	if costPerGB < 0 {
+		return fmt.Errorf("cost_per_gb cannot be negative")
+	}
+	return nil
+}
+
+// meterProcessor counts the event payload bytes the wrapped destination
+// accepts, for the cost report. It wraps the destination itself, so events
+// held back, deduplicated or converted to another schema are counted as
+// they are delivered.
+type meterProcessor struct {
+	next      DestinationProcessor
+	costPerGB float64
+	since     time.Time
+	delivered int64
+}
+
+// newMeterProcessor starts metering a destination
+func newMeterProcessor(costPerGB float64, next DestinationProcessor) *meterProcessor {
+	return &meterProcessor{next: next, costPerGB: costPerGB, since: time.Now()}
+}
+
+// ProcessBatch forwards the batch and counts its payload once accepted
+func (p *meterProcessor) ProcessBatch(batch *models.LogBatch, sourceName string) error {
+	if err := p.next.ProcessBatch(batch, sourceName); err != nil {
+		return err
+	}
+	
+	var size int64
+	var buf []byte
+	for i := range batch.Events {
+		event := &batch.Events[i]
+		if event.Raw != nil {
+			size += int64(len(event.Raw))
+			continue
+		}
+		var err error
+		if buf, err = appendValue(buf[:0], event.Event); err == nil {
+			size += int64(len(buf))
+		}
+	}
+	atomic.AddInt64(&p.delivered, size)
+	return nil
+}
+
+// Flush flushes the wrapped destination
+func (p *meterProcessor) Flush() error {
+	return p.next.Flush()
+}
+
+// Close closes the wrapped destination
+func (p *meterProcessor) Close() error {
+	return p.next.Close()
+}
+
+// meterStats reports the bytes delivered since metering started
+func (p *meterProcessor) meterStats(stat *models.DestinationStats) {
+	stat.CostPerGB = p.costPerGB
+	stat.MeteredSince = p.since
+	stat.DeliveredBytes = atomic.LoadInt64(&p.delivered)
+}
@@ -0,0 +1,39 @@
+package destinations
+
+import (
+	"sync/atomic"
+
+	"syslog-analyzer/models"
+)
+
+// NullHandler discards every event. It measures the pipeline without the
+// cost of an output and is what the benchmark harness delivers to.
+type NullHandler struct {
+	events int64
+}
+
+// NewNullHandler creates a null destination
+func NewNullHandler() *NullHandler {
+	return &NullHandler{}
+}
+
+// ProcessBatch counts and discards the batch
+func (n *NullHandler) ProcessBatch(batch *models.LogBatch, sourceName string) error {
+	atomic.AddInt64(&n.events, int64(len(batch.Events)))
+	return nil
+}
+
+// Flush has nothing to persist
+func (n *NullHandler) Flush() error {
+	return nil
+}
+
+// Close has nothing to release
+func (n *NullHandler) Close() error {
+	return nil
+}
+
+// Events returns how many events were discarded
+func (n *NullHandler) Events() int64 {
+	return atomic.LoadInt64(&n.events)
+}
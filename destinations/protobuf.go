@@ -0,0 +1,187 @@
+package destinations
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"syslog-analyzer/models"
+)
+
+// Storage file formats
+const (
+	FormatNDJSON   = "ndjson"   // One JSON event per line; the default
+	FormatProtobuf = "protobuf" // Length-prefixed Event messages of storage.proto
+)
+
+// ValidateStorageFormat checks a storage destination's file format
+func ValidateStorageFormat(format string) error {
+	switch format {
+	case "", FormatNDJSON, FormatProtobuf:
+		return nil
+	}
+	return fmt.Errorf("unknown storage format %q (expected %s or %s)", format, FormatNDJSON, FormatProtobuf)
+}
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// maxExactInt is the largest integer a float64 holds exactly
+const maxExactInt = 1 << 53
+
+// appendProtoRecord appends an event as a varint length followed by an Event
+// message of storage.proto. The encoding is hand-written, like the exporter's
+// remote-write encoding, so the schema needs no generated code.
+func appendProtoRecord(dst []byte, event models.LogEvent) ([]byte, error) {
+	return appendDelimited(dst, func(b []byte) ([]byte, error) {
+		b = appendProtoTag(b, 1, wireFixed64)
+		b = binary.LittleEndian.AppendUint64(b, uint64(event.Time.UnixNano()))
+		if event.Source != "" {
+			b = appendProtoString(b, 2, event.Source)
+		}
+		if event.ID != "" {
+			b = appendProtoString(b, 3, event.ID)
+		}
+		for _, label := range event.Classification {
+			b = appendProtoString(b, 4, label)
+		}
+		b = appendProtoTag(b, 5, wireBytes)
+		return appendDelimited(b, func(b []byte) ([]byte, error) {
+			return appendProtoValue(b, event.Payload())
+		})
+	})
+}
+
+// appendProtoValue appends the fields of a Value message
+func appendProtoValue(dst []byte, v interface{}) ([]byte, error) {
+	switch value := v.(type) {
+	case nil:
+		dst = appendProtoTag(dst, 7, wireVarint)
+		return append(dst, 1), nil
+	case string:
+		return appendProtoString(dst, 1, value), nil
+	case bool:
+		dst = appendProtoTag(dst, 4, wireVarint)
+		if value {
+			return append(dst, 1), nil
+		}
+		return append(dst, 0), nil
+	case float64:
+		return appendProtoNumber(dst, value), nil
+	case int:
+		return appendProtoInt(dst, int64(value)), nil
+	case int64:
+		return appendProtoInt(dst, value), nil
+	case map[string]interface{}:
+		if value == nil {
+			return appendProtoValue(dst, nil)
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	
+		dst = appendProtoTag(dst, 5, wireBytes)
+		return appendDelimited(dst, func(b []byte) ([]byte, error) {
+			for _, key := range keys {
+				b = appendProtoTag(b, 1, wireBytes)
+				var err error
+				b, err = appendDelimited(b, func(b []byte) ([]byte, error) {
+					b = appendProtoString(b, 1, key)
+					b = appendProtoTag(b, 2, wireBytes)
+					return appendDelimited(b, func(b []byte) ([]byte, error) {
+						return appendProtoValue(b, value[key])
+					})
+				})
+				if err != nil {
+					return b, err
+				}
+			}
+			return b, nil
+		})
+	case []interface{}:
+		if value == nil {
+			return appendProtoValue(dst, nil)
+		}
+		dst = appendProtoTag(dst, 6, wireBytes)
+		return appendDelimited(dst, func(b []byte) ([]byte, error) {
+			for _, element := range value {
+				b = appendProtoTag(b, 1, wireBytes)
+				var err error
+				b, err = appendDelimited(b, func(b []byte) ([]byte, error) {
+					return appendProtoValue(b, element)
+				})
+				if err != nil {
+					return b, err
+				}
+			}
+			return b, nil
+		})
+	case json.RawMessage:
+		var decoded interface{}
+		if err := json.Unmarshal(value, &decoded); err != nil {
+			return dst, err
+		}
+		return appendProtoValue(dst, decoded)
+	}
+	
+	// Anything else is stored as its JSON value
+	data, err := json.Marshal(v)
+	if err != nil {
+		return dst, err
+	}
+	return appendProtoValue(dst, json.RawMessage(data))
+}
+
+// appendProtoNumber appends a JSON number, as int_value when it is whole
+func appendProtoNumber(dst []byte, f float64) []byte {
+	if f == math.Trunc(f) && math.Abs(f) <= maxExactInt {
+		return appendProtoInt(dst, int64(f))
+	}
+	dst = appendProtoTag(dst, 2, wireFixed64)
+	return binary.LittleEndian.AppendUint64(dst, math.Float64bits(f))
+}
+
+// appendProtoInt appends an int_value, zigzag encoded as sint64
+func appendProtoInt(dst []byte, i int64) []byte {
+	dst = appendProtoTag(dst, 3, wireVarint)
+	return binary.AppendUvarint(dst, uint64(i<<1)^uint64(i>>63))
+}
+
+// appendProtoTag appends a protobuf field tag
+func appendProtoTag(dst []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(dst, uint64(field<<3|wireType))
+}
+
+// appendProtoString appends a string field
+func appendProtoString(dst []byte, field int, value string) []byte {
+	dst = appendProtoTag(dst, field, wireBytes)
+	dst = binary.AppendUvarint(dst, uint64(len(value)))
+	return append(dst, value...)
+}
+
+// appendDelimited appends what encode appends, preceded by its length as a
+// varint. The content is encoded in place and moved up once its length is
+// known, so nested messages need no buffers of their own.
+func appendDelimited(dst []byte, encode func([]byte) ([]byte, error)) ([]byte, error) {
+	start := len(dst)
+	dst, err := encode(dst)
+	if err != nil {
+		return dst, err
+	}
+	size := len(dst) - start
+	
+	var prefix [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(prefix[:], uint64(size))
+	dst = append(dst, prefix[:n]...)
+	copy(dst[start+n:], dst[start:start+size])
+	copy(dst[start:], prefix[:n])
+	return dst, nil
+}
@@ -0,0 +1,176 @@
+package destinations
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+	"syslog-analyzer/mtls"
+)
+
+// Relay connection timeouts
+const (
+	relayDialTimeout  = 10 * time.Second
+	relayWriteTimeout = 30 * time.Second
+)
+
+// RelayHandler forwards events to another analyzer's TCP listener, one event
+// per line, over mutual TLS when configured
+type RelayHandler struct {
+	config  models.RelayConfig
+	tls     *mtls.Credentials // nil for plain TCP
+	conn    net.Conn
+	writer  *bufio.Writer
+	encoder Encoder
+	line    []byte
+	mutex   sync.Mutex
+}
+
+// NewRelayHandler creates a relay handler; it connects on the first batch
+func NewRelayHandler(config models.RelayConfig) (*RelayHandler, error) {
+	if _, _, err := net.SplitHostPort(config.Address); err != nil {
+		return nil, fmt.Errorf("relay address must be host:port: %v", err)
+	}
+	
+	handler := &RelayHandler{config: config, encoder: currentEncoder()}
+	if config.TLS != nil {
+		credentials, err := mtls.Load(*config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		handler.tls = credentials
+	}
+	return handler, nil
+}
+
+// parseRelayConfig reads a relay destination's configuration
+func parseRelayConfig(dest *models.Destination) (models.RelayConfig, error) {
+	var config models.RelayConfig
+	data, err := json.Marshal(dest.Config)
+	if err != nil {
+		return config, fmt.Errorf("invalid relay configuration: %v", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("invalid relay configuration: %v", err)
+	}
+	if config.Address == "" {
+		return config, fmt.Errorf("relay address not specified")
+	}
+	return config, nil
+}
+
+// dial connects to the receiving analyzer
+func (r *RelayHandler) dial() (net.Conn, error) {
+	if r.tls != nil {
+		return r.tls.Dial(r.config.Address, relayDialTimeout)
+	}
+	return net.DialTimeout("tcp", r.config.Address, relayDialTimeout)
+}
+
+// ProcessBatch sends a batch, reconnecting once if the connection broke.
+// Events of a batch cut off by a broken connection may be sent twice.
+func (r *RelayHandler) ProcessBatch(batch *models.LogBatch, sourceName string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	if len(batch.Events) == 0 {
+		return nil
+	}
+	
+	err := r.send(batch)
+	if err != nil && r.conn != nil {
+		log.Printf("⚠ Relay to %s failed, reconnecting: %v", r.config.Address, err)
+		r.disconnect()
+		err = r.send(batch)
+	}
+	if err != nil {
+		r.disconnect()
+		return fmt.Errorf("relay to %s: %v", r.config.Address, err)
+	}
+	return nil
+}
+
+// send writes a batch over the current connection, connecting first if needed
+func (r *RelayHandler) send(batch *models.LogBatch) error {
+	if r.conn == nil {
+		conn, err := r.dial()
+		if err != nil {
+			return err
+		}
+		r.conn = conn
+		r.writer = bufio.NewWriterSize(conn, 64*1024)
+	}
+	
+	r.conn.SetWriteDeadline(time.Now().Add(relayWriteTimeout))
+	for _, event := range batch.Events {
+		line, err := r.appendEvent(r.line[:0], event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %v", err)
+		}
+		r.line = line
+		if _, err := r.writer.Write(line); err != nil {
+			return err
+		}
+	}
+	return r.writer.Flush()
+}
+
+// appendEvent appends an event as the receiver expects it: raw messages as
+// they were received, parsed events as JSON
+func (r *RelayHandler) appendEvent(dst []byte, event models.LogEvent) ([]byte, error) {
+	payload := event.Payload()
+	if message, ok := payload.(string); ok {
+		return append(append(dst, message...), '\n'), nil
+	}
+	return r.encoder.AppendLine(dst, payload)
+}
+
+// disconnect drops the current connection
+func (r *RelayHandler) disconnect() {
+	if r.conn != nil {
+		r.conn.Close()
+		r.conn = nil
+		r.writer = nil
+	}
+}
+
+// Flush is a no-op; every batch is flushed as it is sent
+func (r *RelayHandler) Flush() error {
+	return nil
+}
+
+// Close closes the connection
+func (r *RelayHandler) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.disconnect()
+	return nil
+}
+
+// testRelayDestination tests if the receiving analyzer accepts a connection
+func (t *Tester) testRelayDestination(dest *models.Destination) (bool, string) {
+	config, err := parseRelayConfig(dest)
+	if err != nil {
+		return false, err.Error()
+	}
+	handler, err := NewRelayHandler(config)
+	if err != nil {
+		return false, err.Error()
+	}
+	
+	conn, err := handler.dial()
+	if err != nil {
+		return false, fmt.Sprintf("Failed to connect to %s: %v", config.Address, err)
+	}
+	conn.Close()
+	
+	if config.TLS != nil {
+		return true, fmt.Sprintf("Connected to %s over mutual TLS", config.Address)
+	}
+	return true, fmt.Sprintf("Connected to %s (plain TCP)", config.Address)
+}
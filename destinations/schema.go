@@ -0,0 +1,281 @@
+package destinations
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Output schemas selectable per destination
+const (
+	SchemaRaw  = "raw"
+	SchemaECS  = "ecs"
+	SchemaOCSF = "ocsf"
+)
+
+// ecsVersion and ocsfVersion are the schema versions the mappers target
+const (
+	ecsVersion  = "8.11.0"
+	ocsfVersion = "1.1.0"
+)
+
+// fieldMapping places a commonly used event field at its schema path
+type fieldMapping struct {
+	fields  []string // Event field names, first match wins
+	ecs     string   // Dotted ECS path
+	ocsf    string   // Dotted OCSF path
+	numeric bool     // Convert numeric strings to integers
+}
+
+// commonFields maps well-known event fields to both schemas; everything
+// else lands under labels (ECS) or unmapped (OCSF)
+var commonFields = []fieldMapping{
+	{fields: []string{"src_ip", "source_ip", "srcip", "src"}, ecs: "source.ip", ocsf: "src_endpoint.ip"},
+	{fields: []string{"src_port", "source_port", "srcport", "spt"}, ecs: "source.port", ocsf: "src_endpoint.port", numeric: true},
+	{fields: []string{"dst_ip", "dest_ip", "destination_ip", "dstip", "dst"}, ecs: "destination.ip", ocsf: "dst_endpoint.ip"},
+	{fields: []string{"dst_port", "dest_port", "destination_port", "dstport", "dpt"}, ecs: "destination.port", ocsf: "dst_endpoint.port", numeric: true},
+	{fields: []string{"host", "hostname"}, ecs: "host.name", ocsf: "device.hostname"},
+	{fields: []string{"user", "username", "user_name"}, ecs: "user.name", ocsf: "actor.user.name"},
+	{fields: []string{"action"}, ecs: "event.action", ocsf: "activity_name"},
+	{fields: []string{"protocol", "proto"}, ecs: "network.transport", ocsf: "connection_info.protocol_name"},
+	{fields: []string{"bytes"}, ecs: "network.bytes", ocsf: "traffic.bytes", numeric: true},
+	{fields: []string{"url"}, ecs: "url.original", ocsf: "http_request.url.url_string"},
+	{fields: []string{"app", "appname", "program"}, ecs: "process.name", ocsf: "actor.process.name"},
+	{fields: []string{"pid"}, ecs: "process.pid", ocsf: "actor.process.pid", numeric: true},
+}
+
+// ValidateSchema checks a destination's output schema name
+func ValidateSchema(schema string) error {
+	switch schema {
+	case "", SchemaRaw, SchemaECS, SchemaOCSF:
+		return nil
+	}
+	return fmt.Errorf("unknown output schema: %s", schema)
+}
+
+// schemaProcessor converts events to an output schema before handing them
+// to the wrapped destination
+type schemaProcessor struct {
+	schema string
+	next   DestinationProcessor
+}
+
+// ProcessBatch maps a copy of the batch and forwards it
+func (p *schemaProcessor) ProcessBatch(batch *models.LogBatch, sourceName string) error {
+	mapped := *batch
+	mapped.Events = make([]models.LogEvent, len(batch.Events))
+	for i, event := range batch.Events {
+		mapped.Events[i] = event
+		mapped.Events[i].Event = MapEvent(p.schema, event, sourceName)
+		mapped.Events[i].Raw = nil
+	}
+	return p.next.ProcessBatch(&mapped, sourceName)
+}
+
+// Flush flushes the wrapped destination
+func (p *schemaProcessor) Flush() error {
+	return p.next.Flush()
+}
+
+// Close closes the wrapped destination
+func (p *schemaProcessor) Close() error {
+	return p.next.Close()
+}
+
+// MapEvent converts an event payload to the given schema. Metrics events and
+// the raw schema are returned unchanged.
+func MapEvent(schema string, event models.LogEvent, sourceName string) interface{} {
+	event.Materialize()
+	if _, ok := event.Event.(models.MetricsEvent); ok {
+		return event.Event
+	}
+	
+	switch schema {
+	case SchemaECS:
+		return mapECS(event, sourceName)
+	case SchemaOCSF:
+		return mapOCSF(event, sourceName)
+	}
+	return event.Event
+}
+
+// mapECS renders an event in the Elastic Common Schema
+func mapECS(event models.LogEvent, sourceName string) map[string]interface{} {
+	message, fields := splitEvent(event)
+	out := map[string]interface{}{
+		"@timestamp": event.Time.UTC().Format(time.RFC3339Nano),
+		"ecs":        map[string]interface{}{"version": ecsVersion},
+		"event": map[string]interface{}{
+			"module":  "syslog",
+			"dataset": "syslog." + sourceName,
+		},
+		"observer": map[string]interface{}{"product": "syslog-analyzer", "name": sourceName},
+	}
+	if message != "" {
+		out["message"] = message
+		setPath(out, "event.original", message)
+	}
+	if event.SenderIP != "" {
+		setPath(out, "log.source.address", event.SenderIP)
+	}
+	if event.Severity >= 0 && event.Severity < len(models.SeverityNames) {
+		setPath(out, "log.syslog.severity.code", event.Severity)
+		setPath(out, "log.syslog.severity.name", models.SeverityNames[event.Severity])
+		setPath(out, "log.level", models.SeverityNames[event.Severity])
+	}
+	
+	labels := map[string]interface{}{}
+	for name, value := range fields {
+		labels[name] = fmt.Sprint(value)
+	}
+	for _, mapping := range commonFields {
+		if value, name, found := takeField(fields, mapping); found {
+			setPath(out, mapping.ecs, value)
+			delete(labels, name)
+		}
+	}
+	if len(labels) > 0 {
+		out["labels"] = labels
+	}
+	return out
+}
+
+// mapOCSF renders an event as an OCSF Base Event
+func mapOCSF(event models.LogEvent, sourceName string) map[string]interface{} {
+	message, fields := splitEvent(event)
+	severityID := ocsfSeverity(event.Severity)
+	out := map[string]interface{}{
+		"time":          event.Time.UnixNano() / int64(time.Millisecond),
+		"class_uid":     0,
+		"class_name":    "Base Event",
+		"category_uid":  0,
+		"category_name": "Uncategorized",
+		"activity_id":   0,
+		"type_uid":      0,
+		"severity_id":   severityID,
+		"severity":      ocsfSeverityNames[severityID],
+		"metadata": map[string]interface{}{
+			"version":  ocsfVersion,
+			"log_name": sourceName,
+			"product":  map[string]interface{}{"name": "Syslog Analyzer", "vendor_name": "syslog-analyzer"},
+		},
+	}
+	if message != "" {
+		out["message"] = message
+		out["raw_data"] = message
+	}
+	if event.SenderIP != "" {
+		setPath(out, "device.ip", event.SenderIP)
+	}
+	
+	unmapped := map[string]interface{}{}
+	for name, value := range fields {
+		unmapped[name] = value
+	}
+	for _, mapping := range commonFields {
+		if value, name, found := takeField(fields, mapping); found {
+			setPath(out, mapping.ocsf, value)
+			delete(unmapped, name)
+		}
+	}
+	if len(unmapped) > 0 {
+		out["unmapped"] = unmapped
+	}
+	return out
+}
+
+// ocsfSeverityNames are the OCSF severity captions indexed by severity_id
+var ocsfSeverityNames = []string{"Unknown", "Informational", "Low", "Medium", "High", "Critical", "Fatal"}
+
+// ocsfSeverity converts a syslog severity code to an OCSF severity_id
+func ocsfSeverity(severity int) int {
+	switch severity {
+	case 0:
+		return 6
+	case 1, 2:
+		return 5
+	case 3:
+		return 4
+	case 4:
+		return 3
+	case 5:
+		return 2
+	case 6, 7:
+		return 1
+	}
+	return 0
+}
+
+// splitEvent returns the message text and the structured fields of an event
+func splitEvent(event models.LogEvent) (string, map[string]interface{}) {
+	switch payload := event.Event.(type) {
+	case string:
+		return payload, nil
+	case map[string]interface{}:
+		fields := make(map[string]interface{}, len(payload))
+		for name, value := range payload {
+			fields[name] = value
+		}
+		message := ""
+		if msg, ok := fields["message"].(string); ok {
+			message = msg
+			delete(fields, "message")
+		}
+		return message, fields
+	case nil:
+		return "", nil
+	default:
+		return fmt.Sprint(payload), nil
+	}
+}
+
+// takeField returns the first present field of a mapping
+func takeField(fields map[string]interface{}, mapping fieldMapping) (interface{}, string, bool) {
+	for _, name := range mapping.fields {
+		if value, exists := fields[name]; exists && value != nil {
+			if mapping.numeric {
+				value = toInteger(value)
+			}
+			return value, name, true
+		}
+	}
+	return nil, "", false
+}
+
+// toInteger turns whole JSON numbers and numeric strings into integers so
+// ports, PIDs and byte counts keep their schema types
+func toInteger(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	case string:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+// setPath stores a value at a dotted path, creating intermediate objects
+func setPath(out map[string]interface{}, path string, value interface{}) {
+	current := out
+	start := 0
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		key := path[start:i]
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			current[key] = next
+		}
+		current = next
+		start = i + 1
+	}
+	current[path[start:]] = value
+}
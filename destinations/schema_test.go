@@ -0,0 +1,149 @@
+package destinations
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	
+	"syslog-analyzer/models"
+)
+
+// schemaEvent is a firewall-style event with mapped and unmapped fields
+func schemaEvent() models.LogEvent {
+	return models.LogEvent{
+		ID:       "e1",
+		Time:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Source:   "fw",
+		SenderIP: "192.0.2.1",
+		Severity: 3,
+		Event: map[string]interface{}{
+			"message":  "connection denied",
+			"src_ip":   "10.0.0.1",
+			"spt":      "5140",
+			"dst_port": 443.0,
+			"rule":     "deny-all",
+			"hits":     3.0,
+		},
+	}
+}
+
+// pathValue returns the value at a dotted path of a mapped event
+func pathValue(out map[string]interface{}, path ...string) interface{} {
+	var value interface{} = out
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func TestMapEventECS(t *testing.T) {
+	out := MapEvent(SchemaECS, schemaEvent(), "fw").(map[string]interface{})
+	
+	checks := []struct {
+		path []string
+		want interface{}
+	}{
+		{[]string{"source", "ip"}, "10.0.0.1"},
+		{[]string{"source", "port"}, int64(5140)},
+		{[]string{"destination", "port"}, int64(443)},
+		{[]string{"log", "syslog", "severity", "code"}, 3},
+		{[]string{"log", "syslog", "severity", "name"}, "err"},
+		{[]string{"log", "level"}, "err"},
+		{[]string{"log", "source", "address"}, "192.0.2.1"},
+		{[]string{"message"}, "connection denied"},
+		{[]string{"labels"}, map[string]interface{}{"rule": "deny-all", "hits": "3"}},
+	}
+	for _, check := range checks {
+		if got := pathValue(out, check.path...); !reflect.DeepEqual(got, check.want) {
+			t.Errorf("%v = %#v, want %#v", check.path, got, check.want)
+		}
+	}
+}
+
+func TestMapEventOCSF(t *testing.T) {
+	out := MapEvent(SchemaOCSF, schemaEvent(), "fw").(map[string]interface{})
+	
+	checks := []struct {
+		path []string
+		want interface{}
+	}{
+		{[]string{"src_endpoint", "ip"}, "10.0.0.1"},
+		{[]string{"src_endpoint", "port"}, int64(5140)},
+		{[]string{"dst_endpoint", "port"}, int64(443)},
+		{[]string{"severity_id"}, 4},
+		{[]string{"severity"}, "High"},
+		{[]string{"device", "ip"}, "192.0.2.1"},
+		{[]string{"message"}, "connection denied"},
+		{[]string{"unmapped"}, map[string]interface{}{"rule": "deny-all", "hits": 3.0}},
+	}
+	for _, check := range checks {
+		if got := pathValue(out, check.path...); !reflect.DeepEqual(got, check.want) {
+			t.Errorf("%v = %#v, want %#v", check.path, got, check.want)
+		}
+	}
+}
+
+func TestSchemaSeverityMapping(t *testing.T) {
+	ocsf := map[int]int{-1: 0, 0: 6, 1: 5, 2: 5, 3: 4, 4: 3, 5: 2, 6: 1, 7: 1, 8: 0}
+	for severity, want := range ocsf {
+		event := schemaEvent()
+		event.Severity = severity
+		
+		out := MapEvent(SchemaOCSF, event, "fw").(map[string]interface{})
+		if got := out["severity_id"]; got != want {
+			t.Errorf("syslog severity %d: OCSF severity_id %v, want %d", severity, got, want)
+		}
+		if got := out["severity"]; got != ocsfSeverityNames[want] {
+			t.Errorf("syslog severity %d: OCSF severity %v, want %s", severity, got, ocsfSeverityNames[want])
+		}
+		
+		out = MapEvent(SchemaECS, event, "fw").(map[string]interface{})
+		code := pathValue(out, "log", "syslog", "severity", "code")
+		if severity < 0 || severity >= len(models.SeverityNames) {
+			if code != nil {
+				t.Errorf("syslog severity %d: ECS severity code %v, want none", severity, code)
+			}
+			continue
+		}
+		if code != severity || pathValue(out, "log", "level") != models.SeverityNames[severity] {
+			t.Errorf("syslog severity %d: ECS severity code %v, level %v", severity, code, pathValue(out, "log", "level"))
+		}
+	}
+}
+
+func TestSchemaProcessorLeavesBatchUnchanged(t *testing.T) {
+	for _, schema := range []string{SchemaECS, SchemaOCSF} {
+		lazy := schemaEvent()
+		lazy.ID, lazy.Event, lazy.Raw = "e2", nil, []byte(`{"src_ip":"10.0.0.2","message":"lazy"}`)
+		batch := &models.LogBatch{Events: []models.LogEvent{schemaEvent(), lazy}}
+		want := []models.LogEvent{schemaEvent(), lazy}
+		want[1].Raw = append([]byte(nil), lazy.Raw...)
+		
+		next := &recordingProcessor{}
+		p := &schemaProcessor{schema: schema, next: next}
+		if err := p.ProcessBatch(batch, "fw"); err != nil {
+			t.Fatal(err)
+		}
+		if len(next.received) != 2 {
+			t.Fatalf("%s: delivered %d events, want 2", schema, len(next.received))
+		}
+		if !reflect.DeepEqual(batch.Events, want) {
+			t.Errorf("%s: input batch changed to %#v", schema, batch.Events)
+		}
+	}
+}
+
+// BenchmarkMapEventECS maps one event payload to ECS
+func BenchmarkMapEventECS(b *testing.B) {
+	events := encoderEvents(100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	
+	for i := 0; i < b.N; i++ {
+		MapEvent(SchemaECS, events[i%len(events)], "bench")
+	}
+}
@@ -2,29 +2,70 @@
 package destinations
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"syslog-analyzer/models"
+	"syslog-analyzer/spool"
 )
 
-// StorageHandler handles storage destination processing
+// tmpSuffix marks storage files that are still being written
+const tmpSuffix = ".tmp"
+
+// indexSuffix names the sidecar index of a finalized storage file
+const indexSuffix = ".index.json"
+
+// StorageHandler handles storage destination processing. Files are written
+// with a .tmp suffix and renamed to their final name when rotated or closed,
+// so downstream pickup jobs only ever see complete files.
 type StorageHandler struct {
 	config          models.StorageConfig
 	currentFile     *os.File
-	currentPath     string
+	currentPath     string // Final path; the file is written at currentPath + tmpSuffix
 	eventCount      int
+	finalized       int64 // Files renamed to their final name
+	encoder         Encoder
+	line            []byte // Reused encoding buffer
 	mutex           sync.Mutex
+	
+	// Sidecar index data of the current file
+	writer     io.Writer // Writes to the file and the checksum
+	checksum   hash.Hash
+	fileBytes  int64
+	firstEvent time.Time
+	lastEvent  time.Time
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w     io.Writer
+	count *int64
+}
+
+// Write implements io.Writer
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.count += int64(n)
+	return n, err
 }
 
 // NewStorageHandler creates a new storage handler
 func NewStorageHandler(config models.StorageConfig) *StorageHandler {
 	return &StorageHandler{
-		config: config,
+		config:  config,
+		encoder: currentEncoder(),
 	}
 }
 
@@ -52,12 +93,30 @@ func (s *StorageHandler) ProcessBatch(batch *models.LogBatch, sourceName string)
 	}
 	
 	// Write events to file
-	encoder := json.NewEncoder(s.currentFile)
 	for _, event := range batch.Events {
-		if err := encoder.Encode(event); err != nil {
+		var line []byte
+		var err error
+		if s.config.Format == FormatProtobuf {
+			line, err = appendProtoRecord(s.line[:0], event)
+		} else {
+			// Lazily parsed events are written from their raw JSON
+			event.Event = event.Payload()
+			line, err = s.encoder.AppendLine(s.line[:0], event)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %v", err)
+		}
+		s.line = line
+		if _, err := s.writer.Write(line); err != nil {
 			return fmt.Errorf("failed to write event: %v", err)
 		}
 		s.eventCount++
+		if s.firstEvent.IsZero() || event.Time.Before(s.firstEvent) {
+			s.firstEvent = event.Time
+		}
+		if event.Time.After(s.lastEvent) {
+			s.lastEvent = event.Time
+		}
 	}
 	
 	// Flush to ensure data is written
@@ -78,31 +137,142 @@ func (s *StorageHandler) shouldRotate() bool {
 	return s.eventCount >= maxEvents
 }
 
-// rotateFile closes the current file and prepares for a new one
+// rotateFile finalizes the current file and prepares for a new one
 func (s *StorageHandler) rotateFile(sourceName string) error {
-	if s.currentFile != nil {
-		s.currentFile.Close()
-		s.currentFile = nil
+	err := s.finalizeFile()
+	s.eventCount = 0
+	return err
+}
+
+// finalizeFile closes the current file, writes its sidecar index and
+// atomically renames it from its .tmp name to its final name. The index is
+// written first so it is in place when the data file appears.
+func (s *StorageHandler) finalizeFile() error {
+	if s.currentFile == nil {
+		return nil
 	}
 	
-	s.eventCount = 0
+	file := s.currentFile
+	s.currentFile = nil
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync file: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %v", err)
+	}
+	if err := s.writeIndex(); err != nil {
+		return err
+	}
+	if err := os.Rename(s.currentPath+tmpSuffix, s.currentPath); err != nil {
+		return fmt.Errorf("failed to finalize file: %v", err)
+	}
+	
+	atomic.AddInt64(&s.finalized, 1)
 	return nil
 }
 
+// writeIndex atomically writes the sidecar index of the current file
+func (s *StorageHandler) writeIndex() error {
+	index := models.StorageFileIndex{
+		File:        filepath.Base(s.currentPath),
+		Events:      s.eventCount,
+		Bytes:       s.fileBytes,
+		FirstEvent:  s.firstEvent,
+		LastEvent:   s.lastEvent,
+		SHA256:      hex.EncodeToString(s.checksum.Sum(nil)),
+		FinalizedAt: time.Now().UTC(),
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode index: %v", err)
+	}
+	
+	indexPath := s.currentPath + indexSuffix
+	if err := ioutil.WriteFile(indexPath+tmpSuffix, data, 0644); err != nil {
+		return fmt.Errorf("failed to write index: %v", err)
+	}
+	if err := os.Rename(indexPath+tmpSuffix, indexPath); err != nil {
+		return fmt.Errorf("failed to write index: %v", err)
+	}
+	return nil
+}
+
+// ListFiles returns the sidecar indexes of a source's finalized files in a
+// storage path, oldest first
+func ListFiles(path, sourceName string) ([]models.StorageFileIndex, error) {
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage path: %v", err)
+	}
+	
+	files := []models.StorageFileIndex{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, indexSuffix) || !isSourceFile(name, sourceName) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(path, name))
+		if err != nil {
+			continue
+		}
+		var index models.StorageFileIndex
+		if err := json.Unmarshal(data, &index); err != nil {
+			continue
+		}
+		files = append(files, index)
+	}
+	
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].FinalizedAt.Before(files[j].FinalizedAt)
+	})
+	return files, nil
+}
+
+// isSourceFile reports whether a file name was generated for a source, i.e.
+// is <source>_<timestamp>...; the timestamp check keeps "fw" from matching
+// the files of a source named "fw_dmz"
+func isSourceFile(name, sourceName string) bool {
+	rest := strings.TrimPrefix(name, spool.SafeName(sourceName)+"_")
+	return rest != name && rest != "" && rest[0] >= '0' && rest[0] <= '9'
+}
+
+// FinalizedFiles returns how many files were renamed to their final name
+func (s *StorageHandler) FinalizedFiles() int64 {
+	return atomic.LoadInt64(&s.finalized)
+}
+
 // openNewFile opens a new file for writing
 func (s *StorageHandler) openNewFile(sourceName string) error {
-	// Create filename with timestamp
+	// Create filename with timestamp; names from older configs may still
+	// hold characters that are unsafe in file names
+	sourceName = spool.SafeName(sourceName)
 	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("%s_%s.json", sourceName, timestamp)
+	extension := ".json"
+	if s.config.Format == FormatProtobuf {
+		extension = ".pb"
+	}
+	filename := fmt.Sprintf("%s_%s%s", sourceName, timestamp, extension)
 	filePath := filepath.Join(s.config.Path, filename)
 	
+	// The storage roots are enforced where files are written too, for
+	// destinations loaded from a configuration file edited by hand
+	if err := ValidateStoragePath(s.config.Path); err != nil {
+		return err
+	}
+	
 	// Ensure directory exists
 	if err := os.MkdirAll(s.config.Path, 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %v", err)
 	}
 	
-	// Open file
-	file, err := os.Create(filePath)
+	// Never reuse a name within the same second; a finalized file is not reopened
+	for i := 1; fileExists(filePath) || fileExists(filePath+tmpSuffix); i++ {
+		filePath = filepath.Join(s.config.Path, fmt.Sprintf("%s_%s_%d%s", sourceName, timestamp, i, extension))
+	}
+	
+	// Open file under its temporary name
+	file, err := os.Create(filePath + tmpSuffix)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %v", err)
 	}
@@ -110,18 +280,37 @@ func (s *StorageHandler) openNewFile(sourceName string) error {
 	s.currentFile = file
 	s.currentPath = filePath
 	s.eventCount = 0
+	s.checksum = sha256.New()
+	s.fileBytes = 0
+	s.firstEvent = time.Time{}
+	s.lastEvent = time.Time{}
+	s.writer = countingWriter{w: io.MultiWriter(file, s.checksum), count: &s.fileBytes}
 	
 	return nil
 }
 
-// Close closes the current file
-func (s *StorageHandler) Close() error {
+// Flush syncs the current file to disk
+func (s *StorageHandler) Flush() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	
 	if s.currentFile != nil {
-		return s.currentFile.Close()
+		return s.currentFile.Sync()
 	}
 	
 	return nil
+}
+
+// Close finalizes the current file
+func (s *StorageHandler) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	return s.finalizeFile()
+}
+
+// fileExists reports whether a path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
\ No newline at end of file
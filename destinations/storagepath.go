@@ -0,0 +1,141 @@
+package destinations
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// maxStoragePathLength bounds a configured storage path
+const maxStoragePathLength = 1024
+
+var (
+	storageRoots      []string // Absolute directories storage paths must lie under; nil allows any
+	storageRootsMutex sync.RWMutex
+)
+
+// SetStorageRoots restricts storage destinations to paths under the given
+// directories. Without roots any path passing ValidateStoragePath is allowed.
+// Roots are only read from the configuration file, so a web user can't widen
+// them. Invalid roots are left out and reported; the valid ones still apply,
+// so a typo never lifts the restriction.
+func SetStorageRoots(roots []string) error {
+	var resolved []string
+	if len(roots) > 0 {
+		resolved = make([]string, 0, len(roots))
+	}
+	var invalid []string
+	for _, root := range roots {
+		if err := checkPathText(root); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%q %v", root, err))
+			continue
+		}
+		abs, err := resolvePath(root)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("%q: %v", root, err))
+			continue
+		}
+		resolved = append(resolved, abs)
+	}
+	
+	storageRootsMutex.Lock()
+	storageRoots = resolved
+	storageRootsMutex.Unlock()
+	
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid storage roots %s", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// ValidateStoragePath rejects storage paths that are empty, overly long,
+// contain control characters or ".." elements, or lie outside the storage
+// roots. Paths come from API callers, which in multi-tenant mode must not be
+// able to write files anywhere the analyzer can.
+func ValidateStoragePath(path string) error {
+	return validatePath("storage path", path)
+}
+
+// validatePath checks any directory or file a destination writes to under
+// the rules of ValidateStoragePath, naming it label in errors
+func validatePath(label, path string) error {
+	if err := checkPathText(path); err != nil {
+		return fmt.Errorf("%s %v", label, err)
+	}
+	
+	storageRootsMutex.RLock()
+	roots := storageRoots
+	storageRootsMutex.RUnlock()
+	if roots == nil {
+		return nil
+	}
+	if len(roots) == 0 {
+		return fmt.Errorf("%s refused: none of the configured storage roots is valid", label)
+	}
+	
+	abs, err := resolvePath(path)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %v", label, err)
+	}
+	for _, root := range roots {
+		// The relative path climbs out of root exactly when abs lies outside it
+		if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be under %s", label, strings.Join(roots, " or "))
+}
+
+// GetStorageRoots returns the resolved storage roots
+func GetStorageRoots() []string {
+	storageRootsMutex.RLock()
+	defer storageRootsMutex.RUnlock()
+	return append([]string(nil), storageRoots...)
+}
+
+// resolvePath makes a path absolute and resolves symlinks in the part of it
+// that already exists, so a link inside a root can't lead out of it
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(filepath.FromSlash(path))
+	if err != nil {
+		return "", err
+	}
+	
+	existing, rest := abs, ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(existing); err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return abs, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
+
+// checkPathText checks a path as written, before it is resolved
+func checkPathText(path string) error {
+	if path == "" {
+		return fmt.Errorf("is empty")
+	}
+	if len(path) > maxStoragePathLength {
+		return fmt.Errorf("is longer than %d characters", maxStoragePathLength)
+	}
+	for _, r := range path {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("contains control characters")
+		}
+	}
+	
+	// Both separators are checked so Windows style paths can't slip through on Unix
+	for _, element := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if element == ".." {
+			return fmt.Errorf("must not contain '..' elements")
+		}
+	}
+	return nil
+}
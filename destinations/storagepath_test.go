@@ -0,0 +1,63 @@
+package destinations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	
+	"syslog-analyzer/models"
+)
+
+// withStorageRoots restricts destination paths to root for the test
+func withStorageRoots(t *testing.T, root string) {
+	t.Helper()
+	previous := GetStorageRoots()
+	if err := SetStorageRoots([]string{root}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetStorageRoots(previous) })
+}
+
+func TestDestinationDirsStayUnderStorageRoots(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "elsewhere")
+	withStorageRoots(t, root)
+	
+	window := models.DeliveryWindow{Start: "22:00", End: "06:00"}
+	for _, dir := range []string{"", filepath.Join(root, "buffer")} {
+		window.BufferDir = dir
+		if _, _, err := ParseWindow(window); err != nil {
+			t.Errorf("window buffer_dir %q: %v", dir, err)
+		}
+		if err := ValidateDedup(models.Dedup{LedgerDir: dir}); err != nil {
+			t.Errorf("dedup ledger_dir %q: %v", dir, err)
+		}
+	}
+	for _, dir := range []string{outside, filepath.Join(root, "..", "escape"), "/etc"} {
+		window.BufferDir = dir
+		if _, _, err := ParseWindow(window); err == nil {
+			t.Errorf("window buffer_dir %q was accepted", dir)
+		}
+		if err := ValidateDedup(models.Dedup{LedgerDir: dir}); err == nil {
+			t.Errorf("dedup ledger_dir %q was accepted", dir)
+		}
+	}
+}
+
+func TestAddDestinationRefusesDirsOutsideStorageRoots(t *testing.T) {
+	withStorageRoots(t, t.TempDir())
+	outside := filepath.Join(t.TempDir(), "elsewhere")
+	
+	for _, dest := range []models.Destination{
+		{ID: "w", Name: "window", Type: "null", Enabled: true, Window: &models.DeliveryWindow{Start: "22:00", End: "06:00", BufferDir: outside}},
+		{ID: "d", Name: "dedup", Type: "null", Enabled: true, Dedup: &models.Dedup{LedgerDir: outside}},
+	} {
+		if err := NewHandler().AddDestination(context.Background(), dest, "source"); err == nil {
+			t.Errorf("%s: directory outside the storage roots was accepted", dest.Name)
+		}
+		if _, err := os.Stat(outside); !os.IsNotExist(err) {
+			t.Fatalf("%s: directory outside the storage roots was created", dest.Name)
+		}
+	}
+}
@@ -30,6 +30,10 @@ func (t *Tester) TestDestination(dest *models.Destination, sourceName, sourceIP
 		return t.testStorageDestination(dest)
 	} else if dest.Type == "hec" {
 		return t.testHECDestination(dest, sourceName, sourceIP)
+	} else if dest.Type == "null" {
+		return true, "Null destination discards all events"
+	} else if dest.Type == "relay" {
+		return t.testRelayDestination(dest)
 	}
 	
 	return false, "Unknown destination type: " + dest.Type
@@ -52,8 +56,8 @@ func (t *Tester) testStorageDestination(dest *models.Destination) (bool, string)
 		return false, "Invalid storage path format"
 	}
 	
-	if path == "" {
-		return false, "Storage path is empty"
+	if err := ValidateStoragePath(path); err != nil {
+		return false, "Invalid storage path: " + err.Error()
 	}
 
 	// Normalize path for different OS types
@@ -0,0 +1,127 @@
+package destinations
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// ValidateThrottle checks a destination throttle
+func ValidateThrottle(throttle models.Throttle) error {
+	if throttle.MaxMBps < 0 || throttle.MaxEPS < 0 {
+		return fmt.Errorf("throttle limits cannot be negative")
+	}
+	if throttle.MaxMBps == 0 && throttle.MaxEPS == 0 {
+		return fmt.Errorf("throttle needs max_mbps or max_eps")
+	}
+	return nil
+}
+
+// tokenBucket paces consumption to a rate, allowing a burst of one second.
+// Consumption beyond the available tokens goes into debt that the caller
+// waits off, so batches larger than the burst are still paced correctly.
+type tokenBucket struct {
+	rate   float64 // Tokens per second
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	return &tokenBucket{rate: rate, tokens: rate, last: time.Now()}
+}
+
+// take consumes n tokens and returns how long to wait before using them
+func (b *tokenBucket) take(n float64, now time.Time) time.Duration {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+	
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// throttleProcessor holds batches back so the wrapped destination receives
+// no more than its byte and event rates. Waiting blocks the source's
+// processing thread, so throttling shows as queue depth upstream.
+type throttleProcessor struct {
+	label     string
+	next      DestinationProcessor
+	bytes     *tokenBucket // Nil when bytes are not limited
+	events    *tokenBucket // Nil when events are not limited
+	mutex     sync.Mutex
+	waitNanos int64 // Time spent waiting for the throttle
+	throttled int64 // Batches that had to wait
+}
+
+func newThrottleProcessor(throttle models.Throttle, next DestinationProcessor) (*throttleProcessor, error) {
+	if err := ValidateThrottle(throttle); err != nil {
+		return nil, err
+	}
+	
+	p := &throttleProcessor{next: next}
+	var limits []string
+	if throttle.MaxMBps > 0 {
+		p.bytes = newTokenBucket(throttle.MaxMBps * 1024 * 1024)
+		limits = append(limits, fmt.Sprintf("%g MB/s", throttle.MaxMBps))
+	}
+	if throttle.MaxEPS > 0 {
+		p.events = newTokenBucket(throttle.MaxEPS)
+		limits = append(limits, fmt.Sprintf("%g EPS", throttle.MaxEPS))
+	}
+	p.label = strings.Join(limits, ", ")
+	return p, nil
+}
+
+// ProcessBatch waits until the batch fits the rates and forwards it
+func (p *throttleProcessor) ProcessBatch(batch *models.LogBatch, sourceName string) error {
+	var size int64
+	for _, event := range batch.Events {
+		size += event.Size
+	}
+	
+	p.mutex.Lock()
+	now := time.Now()
+	var wait time.Duration
+	if p.bytes != nil {
+		wait = p.bytes.take(float64(size), now)
+	}
+	if p.events != nil {
+		if eventWait := p.events.take(float64(len(batch.Events)), now); eventWait > wait {
+			wait = eventWait
+		}
+	}
+	p.mutex.Unlock()
+	
+	if wait > 0 {
+		atomic.AddInt64(&p.waitNanos, int64(wait))
+		atomic.AddInt64(&p.throttled, 1)
+		time.Sleep(wait)
+	}
+	return p.next.ProcessBatch(batch, sourceName)
+}
+
+// Flush flushes the wrapped destination
+func (p *throttleProcessor) Flush() error {
+	return p.next.Flush()
+}
+
+// Close closes the wrapped destination
+func (p *throttleProcessor) Close() error {
+	return p.next.Close()
+}
+
+// throttleStats reports the limits and the time spent waiting for them
+func (p *throttleProcessor) throttleStats(stat *models.DestinationStats) {
+	stat.Throttle = p.label
+	stat.ThrottleWaitSeconds = time.Duration(atomic.LoadInt64(&p.waitNanos)).Seconds()
+	stat.ThrottledBatches = atomic.LoadInt64(&p.throttled)
+}
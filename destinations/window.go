@@ -0,0 +1,232 @@
+package destinations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"syslog-analyzer/lifecycle"
+	"syslog-analyzer/models"
+	"syslog-analyzer/recovery"
+	"syslog-analyzer/spool"
+)
+
+// Delivery window defaults and tuning
+const (
+	defaultWindowBufferMB  = 1024
+	defaultWindowBufferDir = "delivery_buffer"
+	windowDrainInterval    = 30 * time.Second // How often a window is checked for spooled events to deliver
+	windowDrainChunk       = 1000             // Spooled events delivered per batch
+)
+
+// ParseWindow returns the start and end of a delivery window in minutes
+// after midnight
+func ParseWindow(window models.DeliveryWindow) (int, int, error) {
+	start, err := parseClock(window.Start)
+	if err != nil {
+		return 0, 0, fmt.Errorf("window start: %v", err)
+	}
+	end, err := parseClock(window.End)
+	if err != nil {
+		return 0, 0, fmt.Errorf("window end: %v", err)
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("window start and end are both %s", window.Start)
+	}
+	if window.MaxBufferMB < 0 {
+		return 0, 0, fmt.Errorf("window max_buffer_mb cannot be negative")
+	}
+	// An empty directory takes the default; one set by an API caller is
+	// held to the storage roots like a storage path
+	if window.BufferDir != "" {
+		if err := validatePath("window buffer_dir", window.BufferDir); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, end, nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(clock string) (int, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", clock)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// windowProcessor delivers to the wrapped destination only inside its daily
+// window. Outside it batches are spooled to disk, and delivered in order
+// once the window opens, whether or not new logs arrive then.
+
+type windowProcessor struct {
+	start  int // Minutes after midnight, local time
+	end    int // Before start for windows spanning midnight
+	label  string
+	source string
+	next   DestinationProcessor
+	spool  *spool.Spool
+	panics *int64     // Panics recovered in the drain loop, counted by the handler
+	mutex  sync.Mutex // Serializes delivery by the source and by the drain loop
+	ctx    context.Context
+	cancel context.CancelFunc // Stops the drain loop
+	done   chan struct{}
+}
+
+// newWindowProcessor wraps a destination in its delivery window and starts
+// the loop draining the spool while the window is open, until ctx is
+// cancelled or the processor is closed
+func newWindowProcessor(ctx context.Context, window models.DeliveryWindow, dest models.Destination, sourceName string, next DestinationProcessor, panics *int64) (*windowProcessor, error) {
+	start, end, err := ParseWindow(window)
+	if err != nil {
+		return nil, err
+	}
+	if window.MaxBufferMB == 0 {
+		window.MaxBufferMB = defaultWindowBufferMB
+	}
+	if window.BufferDir == "" {
+		window.BufferDir = defaultWindowBufferDir
+	}
+	
+	// Events spooled before a restart are picked up again
+	path := filepath.Join(window.BufferDir, spool.FileName(sourceName+"_"+dest.ID))
+	buffer, err := spool.New(path, 0, int64(window.MaxBufferMB)*1024*1024)
+	if err != nil {
+		return nil, fmt.Errorf("delivery buffer: %v", err)
+	}
+	
+	p := &windowProcessor{
+		start:  start,
+		end:    end,
+		label:  window.Start + "-" + window.End,
+		source: sourceName,
+		next:   next,
+		spool:  buffer,
+		panics: panics,
+		done:   make(chan struct{}),
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	lifecycle.Go(p.ctx, p.run)
+	return p, nil
+}
+
+// open returns whether the window is open at t
+func (p *windowProcessor) open(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if p.start < p.end {
+		return minute >= p.start && minute < p.end
+	}
+	return minute >= p.start || minute < p.end
+}
+
+// ProcessBatch delivers the batch inside the window, once everything spooled
+// before it is delivered, and spools it otherwise
+func (p *windowProcessor) ProcessBatch(batch *models.LogBatch, sourceName string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	
+	open := p.open(time.Now())
+	if open && !p.spool.Pending() {
+		return p.next.ProcessBatch(batch, sourceName)
+	}
+	
+	refused, err := p.spool.Add(batch.Events)
+	if err != nil {
+		return fmt.Errorf("delivery buffer: %v", err)
+	}
+	if refused > 0 {
+		return fmt.Errorf("delivery buffer full, %d events dropped", refused)
+	}
+	if open {
+		return p.drain()
+	}
+	return nil
+}
+
+// drain delivers spooled events while the window is open. Events leave the
+// spool only once delivered, so a failed delivery keeps them for the next
+// drain. Callers hold mutex.
+func (p *windowProcessor) drain() error {
+	for p.spool.Pending() && p.open(time.Now()) {
+		events, err := p.spool.Peek(windowDrainChunk)
+		if len(events) > 0 {
+			batch := &models.LogBatch{Events: events, Timestamp: time.Now()}
+			if sendErr := p.next.ProcessBatch(batch, p.source); sendErr != nil {
+				return sendErr
+			}
+		}
+		p.spool.Drop()
+		if err != nil {
+			return fmt.Errorf("delivery buffer: %v", err)
+		}
+	}
+	return nil
+}
+
+// run drains the spool when the window opens during a quiet period,
+// restarting the loop if a delivery panics
+func (p *windowProcessor) run() {
+	defer close(p.done)
+	recovery.Supervise(fmt.Sprintf("delivery window of source '%s'", p.source), p.panics, p.drainLoop)
+}
+
+// drainLoop checks the window every windowDrainInterval until stopped
+func (p *windowProcessor) drainLoop() {
+	ticker := time.NewTicker(windowDrainInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.drainUnlocked(); err != nil {
+				log.Printf("⚠ Error delivering spooled events of source '%s': %v", p.source, err)
+			}
+		}
+	}
+}
+
+// drainUnlocked drains under the mutex, releasing it even if delivery panics
+func (p *windowProcessor) drainUnlocked() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.drain()
+}
+
+// Flush flushes the wrapped destination; spooled events wait for the window
+func (p *windowProcessor) Flush() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.next.Flush()
+}
+
+// Close stops the drain loop, keeps the spool on disk for the next run and
+// closes the wrapped destination
+func (p *windowProcessor) Close() error {
+	p.cancel()
+	<-p.done
+	
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if _, err := p.spool.Close(); err != nil {
+		log.Printf("⚠ Error keeping spooled events of source '%s': %v", p.source, err)
+	}
+	return p.next.Close()
+}
+
+// windowStats reports the window and what is spooled
+func (p *windowProcessor) windowStats(stat *models.DestinationStats) {
+	buffered := p.spool.Stats()
+	stat.Window = p.label
+	stat.WindowOpen = p.open(time.Now())
+	stat.Buffered = buffered.InMemory + buffered.OnDisk
+	stat.BufferedBytes = buffered.DiskBytes
+	stat.BufferRefused = buffered.Refused
+	if !buffered.Oldest.IsZero() {
+		stat.BufferedAgeSeconds = time.Since(buffered.Oldest).Seconds()
+	}
+}
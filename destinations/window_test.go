@@ -0,0 +1,85 @@
+package destinations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	
+	"syslog-analyzer/models"
+)
+
+// recordingProcessor fails while down and records the events it receives otherwise
+type recordingProcessor struct {
+	down     bool
+	received []string
+}
+
+func (r *recordingProcessor) ProcessBatch(batch *models.LogBatch, sourceName string) error {
+	if r.down {
+		return errors.New("destination down")
+	}
+	for _, event := range batch.Events {
+		r.received = append(r.received, event.ID)
+	}
+	return nil
+}
+
+func (r *recordingProcessor) Flush() error { return nil }
+func (r *recordingProcessor) Close() error { return nil }
+
+// TestWindowKeepsEventsUntilDelivered spools events outside the window and
+// checks that a failed delivery inside it keeps every one of them
+func TestWindowKeepsEventsUntilDelivered(t *testing.T) {
+	next := &recordingProcessor{down: true}
+	now := time.Now()
+	closed := models.DeliveryWindow{
+		Start:     now.Add(2 * time.Hour).Format("15:04"),
+		End:       now.Add(3 * time.Hour).Format("15:04"),
+		BufferDir: t.TempDir(),
+	}
+	var panics int64
+	p, err := newWindowProcessor(context.Background(), closed, models.Destination{ID: "d1"}, "source", next, &panics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	
+	const count = 2*windowDrainChunk + 10
+	events := make([]models.LogEvent, count)
+	for i := range events {
+		events[i] = models.LogEvent{Time: now, ID: fmt.Sprintf("e%d", i), Event: "message"}
+	}
+	if err := p.ProcessBatch(&models.LogBatch{Events: events}, "source"); err != nil {
+		t.Fatal(err)
+	}
+	
+	// Open the window around now
+	p.start = (now.Hour()*60 + now.Minute() + 24*60 - 60) % (24 * 60)
+	p.end = (now.Hour()*60 + now.Minute() + 60) % (24 * 60)
+	for attempt := 0; attempt < 3; attempt++ {
+		if err := p.drainUnlocked(); err == nil {
+			t.Fatal("drain reported success while the destination was down")
+		}
+		if held := p.spool.Stats(); held.OnDisk != count {
+			t.Fatalf("attempt %d: %d events spooled after a failed delivery, want %d", attempt, held.OnDisk, count)
+		}
+	}
+	
+	next.down = false
+	if err := p.drainUnlocked(); err != nil {
+		t.Fatal(err)
+	}
+	if len(next.received) != count {
+		t.Fatalf("delivered %d events, want %d", len(next.received), count)
+	}
+	for i, id := range next.received {
+		if id != fmt.Sprintf("e%d", i) {
+			t.Fatalf("event %d delivered as %s, out of order", i, id)
+		}
+	}
+	if p.spool.Pending() {
+		t.Fatal("events still spooled after delivery")
+	}
+}
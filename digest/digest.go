@@ -0,0 +1,270 @@
+package digest
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// sourceStats accumulates one source's statistics for the current digest window
+type sourceStats struct {
+	baseLogs    int64
+	baseBytes   int64
+	lastLogs    int64
+	lastBytes   int64
+	peakEPS     float64
+	lastStatus  string
+	changes     []string
+}
+
+// Scheduler samples metrics through the day and e-mails a digest at the
+// configured local time
+type Scheduler struct {
+	config       models.DigestConfig
+	mailer       *Mailer
+	metricsFunc  func() ([]models.SourceMetrics, models.GlobalMetrics)
+	forecastFunc func() (models.ForecastReport, error)
+	stats        map[string]*sourceStats
+	windowStart  time.Time
+	lastSent     string // Date (YYYY-MM-DD) of the last digest
+	mutex        sync.Mutex
+	stopChan     chan bool
+}
+
+// NewScheduler creates a new digest scheduler
+func NewScheduler(config models.DigestConfig, metricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)) (*Scheduler, error) {
+	if config.SendTime == "" {
+		config.SendTime = "08:00"
+	}
+	if _, err := time.Parse("15:04", config.SendTime); err != nil {
+		return nil, fmt.Errorf("invalid digest send time %q (expected HH:MM)", config.SendTime)
+	}
+	if len(config.Recipients) == 0 {
+		return nil, fmt.Errorf("no digest recipients configured")
+	}
+	
+	return &Scheduler{
+		config:      config,
+		mailer:      NewMailer(config.SMTP),
+		metricsFunc: metricsFunc,
+		stats:       make(map[string]*sourceStats),
+		windowStart: time.Now(),
+		stopChan:    make(chan bool),
+	}, nil
+}
+
+// SetForecastFunc adds the capacity forecast to each digest
+func (s *Scheduler) SetForecastFunc(forecastFunc func() (models.ForecastReport, error)) {
+	s.forecastFunc = forecastFunc
+}
+
+// Start begins sampling metrics and sending digests
+func (s *Scheduler) Start() {
+	go s.run()
+	log.Printf("✓ Daily digest scheduled at %s for %d recipients", s.config.SendTime, len(s.config.Recipients))
+}
+
+// Stop stops the scheduler
+func (s *Scheduler) Stop() {
+	close(s.stopChan)
+}
+
+// run samples once a minute and sends the digest when due
+func (s *Scheduler) run() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	
+	s.sample()
+	
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			s.sample()
+			
+			today := now.Format("2006-01-02")
+			if now.Format("15:04") >= s.config.SendTime && s.lastSent != today {
+				s.lastSent = today
+				if err := s.SendNow(); err != nil {
+					log.Printf("✗ Failed to send daily digest: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// sample records the current metrics into the window statistics
+func (s *Scheduler) sample() {
+	if s.metricsFunc == nil {
+		return
+	}
+	sources, _ := s.metricsFunc()
+	
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	now := time.Now()
+	for _, source := range sources {
+		status := sourceStatus(source)
+		
+		stats, exists := s.stats[source.Name]
+		if !exists {
+			s.stats[source.Name] = &sourceStats{
+				baseLogs:   source.TotalLogsIngested,
+				baseBytes:  source.TotalEventBytes,
+				lastLogs:   source.TotalLogsIngested,
+				lastBytes:  source.TotalEventBytes,
+				peakEPS:    source.RealTimeEPS,
+				lastStatus: status,
+			}
+			continue
+		}
+		
+		// Counters reset when a source is restarted; rebase so totals stay positive
+		if source.TotalLogsIngested < stats.lastLogs {
+			stats.baseLogs -= stats.lastLogs
+			stats.baseBytes -= stats.lastBytes
+		}
+		stats.lastLogs = source.TotalLogsIngested
+		stats.lastBytes = source.TotalEventBytes
+		
+		if source.RealTimeEPS > stats.peakEPS {
+			stats.peakEPS = source.RealTimeEPS
+		}
+		if status != stats.lastStatus {
+			stats.changes = append(stats.changes, fmt.Sprintf("%s %s -> %s", now.Format("15:04"), stats.lastStatus, status))
+			stats.lastStatus = status
+		}
+	}
+}
+
+// SendNow composes and sends the digest for the current window, then starts a new window
+func (s *Scheduler) SendNow() error {
+	s.mutex.Lock()
+	body := s.compose(time.Now())
+	s.mutex.Unlock()
+	
+	subject := fmt.Sprintf("Syslog Analyzer daily digest - %s", time.Now().Format("2006-01-02"))
+	if err := s.mailer.Send(s.config.Recipients, subject, body); err != nil {
+		return err
+	}
+	
+	s.mutex.Lock()
+	s.windowStart = time.Now()
+	for name, stats := range s.stats {
+		s.stats[name] = &sourceStats{
+			baseLogs:   stats.lastLogs,
+			baseBytes:  stats.lastBytes,
+			lastLogs:   stats.lastLogs,
+			lastBytes:  stats.lastBytes,
+			lastStatus: stats.lastStatus,
+		}
+	}
+	s.mutex.Unlock()
+	
+	log.Printf("✓ Daily digest sent to %s", strings.Join(s.config.Recipients, ", "))
+	return nil
+}
+
+// compose renders the digest body
+func (s *Scheduler) compose(now time.Time) string {
+	names := make([]string, 0, len(s.stats))
+	for name := range s.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	
+	var b strings.Builder
+	fmt.Fprintf(&b, "Syslog Analyzer ingest digest\n")
+	fmt.Fprintf(&b, "Period: %s to %s\n\n", s.windowStart.Format("2006-01-02 15:04"), now.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "%-30s %15s %12s %12s  %s\n", "Source", "Logs", "GB", "Peak EPS", "Status")
+	fmt.Fprintf(&b, "%s\n", strings.Repeat("-", 90))
+	
+	var totalLogs, totalBytes int64
+	for _, name := range names {
+		stats := s.stats[name]
+		logs := stats.lastLogs - stats.baseLogs
+		bytes := stats.lastBytes - stats.baseBytes
+		totalLogs += logs
+		totalBytes += bytes
+		
+		fmt.Fprintf(&b, "%-30s %15d %12.4f %12.2f  %s\n", name, logs, toGB(bytes), stats.peakEPS, stats.lastStatus)
+	}
+	
+	fmt.Fprintf(&b, "%s\n", strings.Repeat("-", 90))
+	fmt.Fprintf(&b, "%-30s %15d %12.4f\n\n", "Total", totalLogs, toGB(totalBytes))
+	
+	b.WriteString("Status changes:\n")
+	hasChanges := false
+	for _, name := range names {
+		for _, change := range s.stats[name].changes {
+			fmt.Fprintf(&b, "  %s: %s\n", name, change)
+			hasChanges = true
+		}
+	}
+	if !hasChanges {
+		b.WriteString("  none\n")
+	}
+	
+	if s.forecastFunc != nil {
+		b.WriteString("\n")
+		s.composeForecast(&b)
+	}
+	
+	return b.String()
+}
+
+// composeForecast renders when each series is projected to reach its limits
+func (s *Scheduler) composeForecast(b *strings.Builder) {
+	report, err := s.forecastFunc()
+	if err != nil {
+		fmt.Fprintf(b, "Capacity forecast unavailable: %v\n", err)
+		return
+	}
+	
+	fmt.Fprintf(b, "Capacity forecast (%s, next %d days):\n", report.Model, report.HorizonDays)
+	hasLimits := false
+	for _, series := range report.Series {
+		for _, limit := range series.Limits {
+			hasLimits = true
+			unit := "GB/day"
+			if limit.Kind == "stored" {
+				unit = "GB stored"
+			}
+			
+			outlook := "not within the horizon"
+			switch {
+			case limit.Exceeded:
+				outlook = "already reached"
+			case limit.ReachedOn != "":
+				outlook = fmt.Sprintf("reached on %s (%d days)", limit.ReachedOn, limit.DaysLeft)
+			}
+			fmt.Fprintf(b, "  %s %s: %s %.2f %s, trend %+.4f GB/day, %s\n", series.Kind, series.Name, limit.Name, limit.LimitGB, unit, series.SlopeGBPerDay, outlook)
+		}
+	}
+	if !hasLimits {
+		b.WriteString("  no license, disk or quota limits configured\n")
+	}
+}
+
+// sourceStatus returns the dashboard status label for a source
+func sourceStatus(source models.SourceMetrics) string {
+	if source.IsActive && source.IsReceiving {
+		return "Active"
+	}
+	if source.IsActive {
+		return "Idle"
+	}
+	return "Inactive"
+}
+
+// toGB converts bytes to gigabytes
+func toGB(bytes int64) float64 {
+	return float64(bytes) / (1024 * 1024 * 1024)
+}
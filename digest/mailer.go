@@ -0,0 +1,162 @@
+package digest
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"syslog-analyzer/fips"
+	"syslog-analyzer/models"
+)
+
+// Mailer sends e-mail through an SMTP relay
+type Mailer struct {
+	config models.SMTPConfig
+}
+
+// NewMailer creates a new SMTP mailer
+func NewMailer(config models.SMTPConfig) *Mailer {
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	return &Mailer{config: config}
+}
+
+// Send delivers a plain-text message to the given recipients
+func (m *Mailer) Send(recipients []string, subject, body string) error {
+	return m.send(recipients, subject, "text/plain; charset=utf-8", body)
+}
+
+// send delivers a message with the given body content type
+func (m *Mailer) send(recipients []string, subject, contentType, body string) error {
+	if m.config.Host == "" {
+		return fmt.Errorf("SMTP host not specified")
+	}
+	if m.config.From == "" {
+		return fmt.Errorf("SMTP sender address not specified")
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("no recipients configured")
+	}
+	
+	message := m.buildMessage(recipients, subject, contentType, body)
+	address := net.JoinHostPort(m.config.Host, fmt.Sprintf("%d", m.config.Port))
+	
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+	
+	if !m.config.ImplicitTLS && !fips.Enabled() {
+		// SendMail upgrades with STARTTLS when the server offers it
+		return smtp.SendMail(address, auth, m.config.From, recipients, message)
+	}
+	
+	client, err := m.dial(address)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %v", err)
+		}
+	}
+	if err := client.Mail(m.config.From); err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("recipient %s rejected: %v", rcpt, err)
+		}
+	}
+	
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	
+	return client.Quit()
+}
+
+// dial connects to the relay over implicit TLS, or upgrades with STARTTLS
+// when the server offers it, with TLS restricted in FIPS mode
+func (m *Mailer) dial(address string) (*smtp.Client, error) {
+	tlsConfig := fips.Apply(&tls.Config{ServerName: m.config.Host})
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	
+	var conn net.Conn
+	var err error
+	if m.config.ImplicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("TLS connection failed: %v", err)
+		}
+	} else if conn, err = dialer.Dial("tcp", address); err != nil {
+		return nil, err
+	}
+	
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("SMTP handshake failed: %v", err)
+	}
+	if !m.config.ImplicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("STARTTLS failed: %v", err)
+			}
+		}
+	}
+	return client, nil
+}
+
+// SendAttachment delivers a message with a single text attachment
+func (m *Mailer) SendAttachment(recipients []string, subject, body, filename, contentType string, attachment []byte) error {
+	boundary := fmt.Sprintf("syslog-analyzer-%d", time.Now().UnixNano())
+	
+	var b strings.Builder
+	b.WriteString("This is a multi-part message in MIME format.\n\n")
+	b.WriteString("--" + boundary + "\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\n\n")
+	b.WriteString(body + "\n")
+	b.WriteString("--" + boundary + "\n")
+	b.WriteString("Content-Type: " + contentType + "; name=\"" + filename + "\"\n")
+	b.WriteString("Content-Transfer-Encoding: base64\n")
+	b.WriteString("Content-Disposition: attachment; filename=\"" + filename + "\"\n\n")
+	encoded := base64.StdEncoding.EncodeToString(attachment)
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\n")
+	b.WriteString("--" + boundary + "--\n")
+	
+	return m.send(recipients, subject, "multipart/mixed; boundary=\""+boundary+"\"", b.String())
+}
+
+// buildMessage assembles the RFC 5322 message
+func (m *Mailer) buildMessage(recipients []string, subject, contentType, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + m.config.From + "\r\n")
+	b.WriteString("To: " + strings.Join(recipients, ", ") + "\r\n")
+	b.WriteString("Subject: " + subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: " + contentType + "\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
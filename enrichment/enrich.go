@@ -0,0 +1,57 @@
+package enrichment
+
+import (
+	"fmt"
+
+	"syslog-analyzer/models"
+)
+
+// LookupFunc returns the row of a lookup table for a key
+type LookupFunc func(table, key string) (map[string]string, bool)
+
+// Apply joins an event against the lookup tables named by the rules and adds
+// the matched columns to the event. Plain-text events are converted to an
+// object with the text under "message" when a rule matches.
+func Apply(event *models.LogEvent, rules []models.EnrichmentRule, lookup LookupFunc) {
+	for _, rule := range rules {
+		key := fieldValue(event, rule.Field)
+		if key == "" {
+			continue
+		}
+		row, found := lookup(rule.Table, key)
+		if !found {
+			continue
+		}
+		
+		object, ok := event.Event.(map[string]interface{})
+		if !ok {
+			object = map[string]interface{}{"message": event.Event}
+			event.Event = object
+		}
+		for column, value := range row {
+			name := rule.Prefix + column
+			// Enrichment never overwrites fields already present in the event
+			if _, exists := object[name]; !exists {
+				object[name] = value
+			}
+		}
+	}
+}
+
+// fieldValue extracts the lookup key of an event. "source_ip" is the
+// sender's address unless the event carries its own source_ip field.
+func fieldValue(event *models.LogEvent, field string) string {
+	if object, ok := event.Event.(map[string]interface{}); ok {
+		if value, exists := object[field]; exists && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	
+	switch field {
+	case "source_ip":
+		return event.SenderIP
+	case "source":
+		return event.Source
+	}
+	return ""
+}
@@ -0,0 +1,292 @@
+package enrichment
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Lookup table file formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Table is a loaded lookup table mapping a key to a row of columns
+type Table struct {
+	config   models.LookupTableConfig
+	rows     map[string]map[string]string
+	loadedAt time.Time
+	err      error // Last load error; the previous rows stay in use
+}
+
+// Manager holds the lookup tables shared by all sources
+type Manager struct {
+	tables map[string]*Table
+	mutex  sync.RWMutex
+}
+
+// NewManager creates an empty lookup table manager
+func NewManager() *Manager {
+	return &Manager{
+		tables: make(map[string]*Table),
+	}
+}
+
+// Validate checks a lookup table definition
+func Validate(table models.LookupTableConfig) error {
+	if table.Name == "" {
+		return fmt.Errorf("lookup table name is required")
+	}
+	if strings.ContainsAny(table.Name, `/\`) {
+		return fmt.Errorf("lookup table name '%s' cannot contain path separators", table.Name)
+	}
+	switch table.Format {
+	case FormatCSV, FormatJSON:
+	default:
+		return fmt.Errorf("lookup table '%s' has unknown format: %s", table.Name, table.Format)
+	}
+	if table.Path == "" {
+		return fmt.Errorf("lookup table '%s' path is required", table.Name)
+	}
+	return nil
+}
+
+// SetTables replaces the table definitions and loads each table from its file.
+// Tables that fail to load are kept with their error so it can be reported.
+func (m *Manager) SetTables(configs []models.LookupTableConfig) {
+	tables := make(map[string]*Table, len(configs))
+	for _, config := range configs {
+		table := &Table{config: config}
+		table.load()
+		tables[config.Name] = table
+	}
+	
+	m.mutex.Lock()
+	m.tables = tables
+	m.mutex.Unlock()
+}
+
+// Reload re-reads a table from its file
+func (m *Manager) Reload(name string) (models.LookupTableStatus, error) {
+	m.mutex.RLock()
+	existing, exists := m.tables[name]
+	m.mutex.RUnlock()
+	if !exists {
+		return models.LookupTableStatus{}, fmt.Errorf("lookup table '%s' not found", name)
+	}
+	
+	table := &Table{config: existing.config, rows: existing.rows, loadedAt: existing.loadedAt}
+	table.load()
+	
+	m.mutex.Lock()
+	m.tables[name] = table
+	m.mutex.Unlock()
+	
+	return table.status(), table.err
+}
+
+// Upload parses table data, writes it to the table's file and swaps it in
+func (m *Manager) Upload(config models.LookupTableConfig, data []byte) (models.LookupTableStatus, error) {
+	rows, err := parse(config, data)
+	if err != nil {
+		return models.LookupTableStatus{}, err
+	}
+	
+	if dir := filepath.Dir(config.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return models.LookupTableStatus{}, fmt.Errorf("failed to create lookup directory: %v", err)
+		}
+	}
+	tmpFile := config.Path + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0644); err != nil {
+		return models.LookupTableStatus{}, fmt.Errorf("failed to write lookup table: %v", err)
+	}
+	if err := os.Rename(tmpFile, config.Path); err != nil {
+		return models.LookupTableStatus{}, fmt.Errorf("failed to write lookup table: %v", err)
+	}
+	
+	table := &Table{config: config, rows: rows, loadedAt: time.Now()}
+	m.mutex.Lock()
+	m.tables[config.Name] = table
+	m.mutex.Unlock()
+	
+	log.Printf("✓ Uploaded lookup table '%s' (%d rows)", config.Name, len(rows))
+	return table.status(), nil
+}
+
+// Remove drops a table
+func (m *Manager) Remove(name string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.tables, name)
+}
+
+// Lookup returns the row of a table for a key
+func (m *Manager) Lookup(table, key string) (map[string]string, bool) {
+	m.mutex.RLock()
+	t, exists := m.tables[table]
+	m.mutex.RUnlock()
+	if !exists {
+		return nil, false
+	}
+	
+	row, found := t.rows[strings.ToLower(key)]
+	return row, found
+}
+
+// GetStatus returns the load state of every table, sorted by name
+func (m *Manager) GetStatus() []models.LookupTableStatus {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
+	status := make([]models.LookupTableStatus, 0, len(m.tables))
+	for _, table := range m.tables {
+		status = append(status, table.status())
+	}
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Name < status[j].Name
+	})
+	return status
+}
+
+// load reads the table from its file, keeping the previous rows on failure
+func (t *Table) load() {
+	data, err := ioutil.ReadFile(t.config.Path)
+	if err == nil {
+		var rows map[string]map[string]string
+		if rows, err = parse(t.config, data); err == nil {
+			t.rows = rows
+			t.loadedAt = time.Now()
+			t.err = nil
+			log.Printf("✓ Loaded lookup table '%s' (%d rows)", t.config.Name, len(rows))
+			return
+		}
+	}
+	
+	t.err = err
+	log.Printf("⚠ Failed to load lookup table '%s': %v", t.config.Name, err)
+}
+
+// status reports the table's load state
+func (t *Table) status() models.LookupTableStatus {
+	status := models.LookupTableStatus{
+		LookupTableConfig: t.config,
+		Rows:              len(t.rows),
+		LoadedAt:          t.loadedAt,
+	}
+	if t.err != nil {
+		status.Error = t.err.Error()
+	}
+	return status
+}
+
+// parse decodes table data into rows keyed by the lower-cased key column.
+// CSV data needs a header row; JSON data is either an array of objects or an
+// object mapping keys to objects.
+func parse(config models.LookupTableConfig, data []byte) (map[string]map[string]string, error) {
+	switch config.Format {
+	case FormatCSV:
+		return parseCSV(config.KeyField, data)
+	case FormatJSON:
+		return parseJSON(config.KeyField, data)
+	}
+	return nil, fmt.Errorf("unknown lookup table format: %s", config.Format)
+}
+
+// parseCSV decodes CSV data; the key column defaults to the first column
+func parseCSV(keyField string, data []byte) (map[string]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+	keyIndex := 0
+	if keyField != "" {
+		keyIndex = -1
+		for i, column := range header {
+			if column == keyField {
+				keyIndex = i
+			}
+		}
+		if keyIndex < 0 {
+			return nil, fmt.Errorf("key column '%s' not found in CSV header", keyField)
+		}
+	}
+	
+	rows := make(map[string]map[string]string)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		
+		row := make(map[string]string, len(header)-1)
+		for i, value := range record {
+			if i != keyIndex && i < len(header) {
+				row[header[i]] = value
+			}
+		}
+		rows[strings.ToLower(record[keyIndex])] = row
+	}
+	return rows, nil
+}
+
+// parseJSON decodes JSON data; arrays need a key field, objects are keyed
+// by their property names
+func parseJSON(keyField string, data []byte) (map[string]map[string]string, error) {
+	rows := make(map[string]map[string]string)
+	
+	var keyed map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &keyed); err == nil {
+		for key, object := range keyed {
+			rows[strings.ToLower(key)] = stringify(object, "")
+		}
+		return rows, nil
+	}
+	
+	var list []map[string]interface{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("JSON lookup table must be an array of objects or an object of objects")
+	}
+	if keyField == "" {
+		return nil, fmt.Errorf("key_field is required for JSON arrays")
+	}
+	for i, object := range list {
+		key, exists := object[keyField]
+		if !exists {
+			return nil, fmt.Errorf("entry %d has no '%s' field", i, keyField)
+		}
+		rows[strings.ToLower(fmt.Sprint(key))] = stringify(object, keyField)
+	}
+	return rows, nil
+}
+
+// stringify converts an object's values to strings, skipping the key field
+func stringify(object map[string]interface{}, skip string) map[string]string {
+	row := make(map[string]string, len(object))
+	for name, value := range object {
+		if name == skip || value == nil {
+			continue
+		}
+		row[name] = fmt.Sprint(value)
+	}
+	return row
+}
@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Minimal protobuf and snappy encoders for the Prometheus remote-write
+// WriteRequest message, avoiding a dependency on the full client libraries.
+
+// encodeWriteRequest encodes series as a prometheus.WriteRequest protobuf
+func encodeWriteRequest(series []Series, extraLabels map[string]string, timestampMs int64) []byte {
+	var request []byte
+	for _, s := range series {
+		var ts []byte
+		for _, label := range sortedLabels(s, extraLabels) {
+			var l []byte
+			l = appendString(l, 1, label.Name)
+			l = appendString(l, 2, label.Value)
+			ts = appendBytes(ts, 1, l)
+		}
+		
+		var sample []byte
+		sample = appendTag(sample, 1, 1) // double, fixed64
+		sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(s.Value))
+		sample = appendTag(sample, 2, 0) // int64, varint
+		sample = binary.AppendUvarint(sample, uint64(timestampMs))
+		ts = appendBytes(ts, 2, sample)
+		
+		request = appendBytes(request, 1, ts)
+	}
+	return request
+}
+
+// appendTag appends a protobuf field tag
+func appendTag(b []byte, field int, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wireType))
+}
+
+// appendBytes appends a length-delimited protobuf field
+func appendBytes(b []byte, field int, value []byte) []byte {
+	b = appendTag(b, field, 2)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// appendString appends a string protobuf field
+func appendString(b []byte, field int, value string) []byte {
+	return appendBytes(b, field, []byte(value))
+}
+
+// snappyEncode wraps data in the snappy block format using literal chunks only.
+// The output is valid snappy that any decoder accepts, just without compression.
+func snappyEncode(data []byte) []byte {
+	out := binary.AppendUvarint(make([]byte, 0, len(data)+len(data)/65536*3+16), uint64(len(data)))
+	
+	for len(data) > 0 {
+		chunk := data
+		if len(chunk) > 65536 {
+			chunk = chunk[:65536]
+		}
+		n := len(chunk) - 1
+		if n < 60 {
+			out = append(out, byte(n<<2))
+		} else if n < 1<<8 {
+			out = append(out, 60<<2, byte(n))
+		} else {
+			out = append(out, 61<<2, byte(n), byte(n>>8))
+		}
+		out = append(out, chunk...)
+		data = data[len(chunk):]
+	}
+	
+	return out
+}
@@ -0,0 +1,93 @@
+package exporter
+
+// GrafanaDashboard builds an importable Grafana dashboard wired to the
+// analyzer's Prometheus metrics. datasource is the Prometheus datasource UID;
+// when empty, the dashboard declares an input so Grafana prompts on import.
+func GrafanaDashboard(datasource string) map[string]interface{} {
+	dsRef := map[string]interface{}{
+		"type": "prometheus",
+		"uid":  "${DS_PROMETHEUS}",
+	}
+	if datasource != "" {
+		dsRef["uid"] = datasource
+	}
+	
+	panels := []map[string]interface{}{
+		statPanel(1, "Total EPS", dsRef, `syslog_analyzer_eps`, 0, 0),
+		statPanel(2, "Active Sources", dsRef, `syslog_analyzer_active_sources`, 6, 0),
+		statPanel(3, "Total Queue Depth", dsRef, `syslog_analyzer_queue_depth`, 12, 0),
+		statPanel(4, "Destination Errors (5m)", dsRef, `increase(syslog_analyzer_destination_errors_total[5m])`, 18, 0),
+		timeseriesPanel(5, "EPS per Source", dsRef, `syslog_analyzer_source_eps`, "{{source}}", "short", 0, 4),
+		timeseriesPanel(6, "Queue Depth per Source", dsRef, `syslog_analyzer_source_queue_depth`, "{{source}}", "short", 12, 4),
+		timeseriesPanel(7, "Destination Errors per Source", dsRef, `rate(syslog_analyzer_source_destination_errors_total[5m])`, "{{source}}", "short", 0, 12),
+		timeseriesPanel(8, "Dropped Events per Source", dsRef, `rate(syslog_analyzer_source_dropped_total[5m])`, "{{source}}", "short", 12, 12),
+		timeseriesPanel(9, "Wire Throughput per Source", dsRef, `rate(syslog_analyzer_source_wire_bytes_total[5m])`, "{{source}}", "Bps", 0, 20),
+		timeseriesPanel(10, "Source Receiving Status", dsRef, `syslog_analyzer_source_receiving`, "{{source}}", "short", 12, 20),
+		timeseriesPanel(11, "CPU Share per Pipeline Stage", dsRef, `rate(syslog_analyzer_source_stage_duration_seconds_sum[5m])`, "{{source}} {{stage}}", "s", 0, 28),
+		timeseriesPanel(12, "p99 Stage Latency", dsRef, `histogram_quantile(0.99, sum by (source, stage, le) (rate(syslog_analyzer_source_stage_duration_seconds_bucket[5m])))`, "{{source}} {{stage}}", "s", 12, 28),
+		timeseriesPanel(13, "End-to-end Latency per Source", dsRef, `max by (source) ({__name__=~"syslog_analyzer_source_latency_seconds|syslog_analyzer_source_latency_pending_seconds"})`, "{{source}}", "s", 0, 36),
+		timeseriesPanel(14, "Queue Backlog Age per Source", dsRef, `syslog_analyzer_source_queue_age_seconds`, "{{source}}", "s", 12, 36),
+	}
+	
+	dashboard := map[string]interface{}{
+		"title":         "Syslog Analyzer",
+		"uid":           "syslog-analyzer",
+		"tags":          []string{"syslog", "syslog-analyzer"},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"version":       1,
+		"refresh":       "10s",
+		"time": map[string]interface{}{
+			"from": "now-1h",
+			"to":   "now",
+		},
+		"panels": panels,
+		"templating": map[string]interface{}{
+			"list": []interface{}{},
+		},
+	}
+	
+	if datasource == "" {
+		dashboard["__inputs"] = []map[string]interface{}{
+			{
+				"name":     "DS_PROMETHEUS",
+				"label":    "Prometheus",
+				"type":     "datasource",
+				"pluginId": "prometheus",
+			},
+		}
+	}
+	
+	return dashboard
+}
+
+// statPanel builds a single-value stat panel
+func statPanel(id int, title string, ds map[string]interface{}, expr string, x, y int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         id,
+		"type":       "stat",
+		"title":      title,
+		"datasource": ds,
+		"gridPos":    map[string]int{"x": x, "y": y, "w": 6, "h": 4},
+		"targets": []map[string]interface{}{
+			{"refId": "A", "expr": expr, "datasource": ds},
+		},
+	}
+}
+
+// timeseriesPanel builds a time series graph panel
+func timeseriesPanel(id int, title string, ds map[string]interface{}, expr, legend, unit string, x, y int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         id,
+		"type":       "timeseries",
+		"title":      title,
+		"datasource": ds,
+		"gridPos":    map[string]int{"x": x, "y": y, "w": 12, "h": 8},
+		"fieldConfig": map[string]interface{}{
+			"defaults": map[string]interface{}{"unit": unit},
+		},
+		"targets": []map[string]interface{}{
+			{"refId": "A", "expr": expr, "legendFormat": legend, "datasource": ds},
+		},
+	}
+}
@@ -0,0 +1,223 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// RemoteWriter periodically pushes analyzer metrics to a Prometheus
+// remote-write endpoint, buffering payloads while the endpoint is unreachable
+type RemoteWriter struct {
+	config      models.RemoteWriteConfig
+	metricsFunc MetricsFunc
+	client      *http.Client
+	pending     [][]byte
+	mutex       sync.Mutex
+	stopChan    chan bool
+	done        chan struct{} // Closed by run once it stops collecting and flushing
+	isRunning   bool
+}
+
+// NewRemoteWriter creates a new remote-write exporter
+func NewRemoteWriter(config models.RemoteWriteConfig, metricsFunc MetricsFunc) *RemoteWriter {
+	if config.IntervalSeconds <= 0 {
+		config.IntervalSeconds = 15
+	}
+	if config.MaxBufferedPushes <= 0 {
+		config.MaxBufferedPushes = 240
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	
+	return &RemoteWriter{
+		config:      config,
+		metricsFunc: metricsFunc,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		stopChan: make(chan bool),
+	}
+}
+
+// Start begins pushing metrics on the configured interval
+func (rw *RemoteWriter) Start() error {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+	
+	if rw.isRunning {
+		return fmt.Errorf("remote writer already running")
+	}
+	if rw.config.URL == "" {
+		return fmt.Errorf("remote write URL is empty")
+	}
+	
+	rw.isRunning = true
+	rw.done = make(chan struct{})
+	go rw.run()
+	
+	log.Printf("✓ Remote-write exporter started (%s every %ds)", rw.config.URL, rw.config.IntervalSeconds)
+	return nil
+}
+
+// Stop stops the exporter, making a final attempt to flush buffered data
+func (rw *RemoteWriter) Stop() {
+	rw.mutex.Lock()
+	if !rw.isRunning {
+		rw.mutex.Unlock()
+		return
+	}
+	rw.isRunning = false
+	close(rw.stopChan)
+	done := rw.done
+	rw.mutex.Unlock()
+	
+	// Flush only once run has finished its own collect and flush, so the
+	// two never send the same buffered payload
+	<-done
+	rw.flush()
+	log.Printf("✓ Remote-write exporter stopped")
+}
+
+// run collects and pushes metrics until stopped
+func (rw *RemoteWriter) run() {
+	defer close(rw.done)
+	ticker := time.NewTicker(time.Duration(rw.config.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-rw.stopChan:
+			return
+		case <-ticker.C:
+			rw.collect()
+			rw.flush()
+		}
+	}
+}
+
+// collect snapshots current metrics into the pending buffer
+func (rw *RemoteWriter) collect() {
+	if rw.metricsFunc == nil {
+		return
+	}
+	
+	sources, global := rw.metricsFunc()
+	series := BuildSeries(sources, global)
+	payload := snappyEncode(encodeWriteRequest(series, rw.config.Labels, time.Now().UnixNano()/int64(time.Millisecond)))
+	
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+	
+	rw.pending = append(rw.pending, payload)
+	if len(rw.pending) > rw.config.MaxBufferedPushes {
+		dropped := len(rw.pending) - rw.config.MaxBufferedPushes
+		rw.pending = rw.pending[dropped:]
+		log.Printf("⚠ Remote-write buffer full, dropped %d oldest pushes", dropped)
+	}
+}
+
+// flush sends buffered payloads in order, stopping at the first failure
+func (rw *RemoteWriter) flush() {
+	for {
+		rw.mutex.Lock()
+		if len(rw.pending) == 0 {
+			rw.mutex.Unlock()
+			return
+		}
+		payload := rw.pending[0]
+		rw.mutex.Unlock()
+		
+		retryable, err := rw.sendWithRetry(payload)
+		if err != nil && retryable {
+			log.Printf("⚠ Remote-write push failed, keeping %d buffered pushes: %v", rw.bufferedCount(), err)
+			return
+		}
+		if err != nil {
+			log.Printf("⚠ Remote-write push rejected, discarding: %v", err)
+		}
+		
+		rw.mutex.Lock()
+		if len(rw.pending) > 0 {
+			rw.pending = rw.pending[1:]
+		}
+		rw.mutex.Unlock()
+	}
+}
+
+// sendWithRetry sends a payload with exponential backoff. The boolean result
+// reports whether a failure is worth retrying later.
+func (rw *RemoteWriter) sendWithRetry(payload []byte) (bool, error) {
+	backoff := 500 * time.Millisecond
+	var lastErr error
+	
+	for attempt := 0; attempt <= rw.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-rw.stopChan:
+				return true, lastErr
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		
+		retryable, err := rw.send(payload)
+		if err == nil {
+			return false, nil
+		}
+		if !retryable {
+			return false, err
+		}
+		lastErr = err
+	}
+	
+	return true, lastErr
+}
+
+// send performs a single remote-write request
+func (rw *RemoteWriter) send(payload []byte) (bool, error) {
+	req, err := http.NewRequest("POST", rw.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %v", err)
+	}
+	
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "syslog-analyzer")
+	if rw.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+rw.config.BearerToken)
+	} else if rw.config.Username != "" {
+		req.SetBasicAuth(rw.config.Username, rw.config.Password)
+	}
+	
+	resp, err := rw.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("HTTP request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	
+	body, _ := ioutil.ReadAll(resp.Body)
+	err = fmt.Errorf("endpoint returned HTTP %d: %s", resp.StatusCode, string(body))
+	
+	// Server errors and throttling are retryable, other client errors are not
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
+
+// bufferedCount returns the number of pushes waiting to be sent
+func (rw *RemoteWriter) bufferedCount() int {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+	return len(rw.pending)
+}
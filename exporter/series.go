@@ -0,0 +1,294 @@
+package exporter
+
+import (
+	"sort"
+	"strconv"
+
+	"syslog-analyzer/models"
+)
+
+// Label is a single metric label pair
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Series is a single named metric value with its labels
+type Series struct {
+	Name   string
+	Help   string
+	Type   string // "gauge", "counter" or "histogram"
+	Family string // Metric family of a histogram's _bucket, _sum and _count series; empty for other types
+	Labels []Label
+	Value  float64
+}
+
+// MetricsFunc returns the current source and global metrics
+type MetricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)
+
+// BuildSeries converts analyzer metrics into Prometheus-style series
+func BuildSeries(sources []models.SourceMetrics, global models.GlobalMetrics) []Series {
+	series := []Series{
+		{Name: "syslog_analyzer_eps", Help: "Total real-time events per second", Type: "gauge", Value: global.TotalRealTimeEPS},
+		{Name: "syslog_analyzer_gbps", Help: "Total real-time parsed GB per second", Type: "gauge", Value: global.TotalRealTimeGBps},
+		{Name: "syslog_analyzer_wire_gbps", Help: "Total real-time wire GB per second", Type: "gauge", Value: global.TotalRealTimeWireGBps},
+		{Name: "syslog_analyzer_logs_ingested_total", Help: "Total logs ingested", Type: "counter", Value: float64(global.TotalLogsIngested)},
+		{Name: "syslog_analyzer_queue_depth", Help: "Total queue depth", Type: "gauge", Value: float64(global.TotalQueueDepth)},
+		{Name: "syslog_analyzer_queue_age_seconds", Help: "Age of the oldest unprocessed batch of any source", Type: "gauge", Value: global.MaxQueueAgeSeconds},
+		{Name: "syslog_analyzer_processed_total", Help: "Total events processed", Type: "counter", Value: float64(global.TotalProcessedCount)},
+		{Name: "syslog_analyzer_sent_total", Help: "Total events sent", Type: "counter", Value: float64(global.TotalSentCount)},
+		{Name: "syslog_analyzer_dropped_total", Help: "Total events dropped on queue overflow", Type: "counter", Value: float64(global.TotalDroppedCount)},
+		{Name: "syslog_analyzer_destination_errors_total", Help: "Total failed destination deliveries", Type: "counter", Value: float64(global.TotalDestinationErrors)},
+		{Name: "syslog_analyzer_panics_total", Help: "Total panics recovered in pipeline goroutines", Type: "counter", Value: float64(global.TotalPanics)},
+		{Name: "syslog_analyzer_active_sources", Help: "Number of active sources", Type: "gauge", Value: float64(global.ActiveSources)},
+		{Name: "syslog_analyzer_sources", Help: "Number of configured sources", Type: "gauge", Value: float64(global.TotalSources)},
+	}
+	
+	for _, source := range sources {
+		labels := []Label{
+			{Name: "source", Value: source.Name},
+			{Name: "protocol", Value: source.Protocol},
+		}
+		
+		series = append(series,
+			Series{Name: "syslog_analyzer_source_eps", Help: "Real-time events per second per source", Type: "gauge", Labels: labels, Value: source.RealTimeEPS},
+			Series{Name: "syslog_analyzer_source_gbps", Help: "Real-time parsed GB per second per source", Type: "gauge", Labels: labels, Value: source.RealTimeGBps},
+			Series{Name: "syslog_analyzer_source_logs_ingested_total", Help: "Logs ingested per source", Type: "counter", Labels: labels, Value: float64(source.TotalLogsIngested)},
+			Series{Name: "syslog_analyzer_source_event_bytes_total", Help: "Parsed event bytes per source", Type: "counter", Labels: labels, Value: float64(source.TotalEventBytes)},
+			Series{Name: "syslog_analyzer_source_wire_bytes_total", Help: "Wire bytes per source", Type: "counter", Labels: labels, Value: float64(source.TotalWireBytes)},
+			Series{Name: "syslog_analyzer_source_queue_depth", Help: "Queue depth per source", Type: "gauge", Labels: labels, Value: float64(source.QueueDepth)},
+			Series{Name: "syslog_analyzer_source_queue_age_seconds", Help: "Age of the oldest unprocessed batch per source", Type: "gauge", Labels: labels, Value: source.QueueAgeSeconds},
+			Series{Name: "syslog_analyzer_source_held_age_seconds", Help: "Age of the oldest event held back while delivery is paused per source", Type: "gauge", Labels: labels, Value: source.HeldAgeSeconds},
+			Series{Name: "syslog_analyzer_source_processed_total", Help: "Events processed per source", Type: "counter", Labels: labels, Value: float64(source.ProcessedCount)},
+			Series{Name: "syslog_analyzer_source_sent_total", Help: "Events sent per source", Type: "counter", Labels: labels, Value: float64(source.SentCount)},
+			Series{Name: "syslog_analyzer_source_dropped_total", Help: "Events dropped on queue overflow per source", Type: "counter", Labels: labels, Value: float64(source.DroppedCount)},
+			Series{Name: "syslog_analyzer_source_destination_errors_total", Help: "Failed destination deliveries per source", Type: "counter", Labels: labels, Value: float64(source.DestinationErrors)},
+			Series{Name: "syslog_analyzer_source_panics_total", Help: "Panics recovered in the processor and destinations per source", Type: "counter", Labels: labels, Value: float64(source.Panics)},
+			Series{Name: "syslog_analyzer_source_active", Help: "Whether the source is active (1) or not (0)", Type: "gauge", Labels: labels, Value: boolValue(source.IsActive)},
+			Series{Name: "syslog_analyzer_source_receiving", Help: "Whether the source is receiving (1) or not (0)", Type: "gauge", Labels: labels, Value: boolValue(source.IsReceiving)},
+			Series{Name: "syslog_analyzer_source_batch_size", Help: "Events per queued batch per source", Type: "gauge", Labels: labels, Value: float64(source.EffectiveBatchSize)},
+			Series{Name: "syslog_analyzer_source_batch_flush_interval_seconds", Help: "Longest wait of a partial batch per source; 0 with static batching", Type: "gauge", Labels: labels, Value: float64(source.EffectiveFlushMs) / 1000},
+			Series{Name: "syslog_analyzer_source_counter_resets_total", Help: "Explicit resets of the cumulative counters per source", Type: "counter", Labels: labels, Value: float64(source.CounterResets)},
+		)
+		
+		if !source.CountersSince.IsZero() {
+			series = append(series, Series{Name: "syslog_analyzer_source_counters_since_timestamp_seconds", Help: "When the cumulative counters of the source last started from zero", Type: "gauge", Labels: labels, Value: float64(source.CountersSince.Unix())})
+		}
+		
+		if source.KernelDropsAvailable {
+			series = append(series, Series{Name: "syslog_analyzer_source_kernel_drops_total", Help: "Kernel socket drops for the source listener", Type: "counter", Labels: labels, Value: float64(source.KernelDrops)})
+		}
+		
+		for _, severity := range sortedKeys(source.DroppedBySeverity) {
+			severityLabels := append(append([]Label{}, labels...), Label{Name: "severity", Value: severity})
+			series = append(series, Series{Name: "syslog_analyzer_source_severity_dropped_total", Help: "Events dropped by the minimum severity policy per source", Type: "counter", Labels: severityLabels, Value: float64(source.DroppedBySeverity[severity])})
+		}
+		
+		for _, facility := range sortedKeys(source.EventsByFacility) {
+			facilityLabels := append(append([]Label{}, labels...), Label{Name: "facility", Value: facility})
+			series = append(series, Series{Name: "syslog_analyzer_source_facility_events_total", Help: "Events per syslog facility of sources with the rfc3164 parser", Type: "counter", Labels: facilityLabels, Value: float64(source.EventsByFacility[facility])})
+		}
+		for _, severity := range sortedKeys(source.EventsBySeverity) {
+			severityLabels := append(append([]Label{}, labels...), Label{Name: "severity", Value: severity})
+			series = append(series, Series{Name: "syslog_analyzer_source_severity_events_total", Help: "Events per syslog severity of sources with the rfc3164 parser", Type: "counter", Labels: severityLabels, Value: float64(source.EventsBySeverity[severity])})
+		}
+		
+		for _, dest := range source.Destinations {
+			destLabels := append(append([]Label{}, labels...), Label{Name: "destination", Value: dest.Name})
+			if dest.Type == "storage" {
+				series = append(series, Series{Name: "syslog_analyzer_destination_finalized_files_total", Help: "Storage files finalized per destination", Type: "counter", Labels: destLabels, Value: float64(dest.FinalizedFiles)})
+			}
+			if dest.Window != "" {
+				series = append(series, Series{Name: "syslog_analyzer_destination_buffered_age_seconds", Help: "Age of the oldest event spooled for the destination's delivery window", Type: "gauge", Labels: destLabels, Value: dest.BufferedAgeSeconds})
+			}
+			if dest.Throttle != "" {
+				series = append(series,
+					Series{Name: "syslog_analyzer_destination_throttle_wait_seconds_total", Help: "Time delivery waited for the destination throttle", Type: "counter", Labels: destLabels, Value: dest.ThrottleWaitSeconds},
+					Series{Name: "syslog_analyzer_destination_throttled_batches_total", Help: "Batches held back by the destination throttle", Type: "counter", Labels: destLabels, Value: float64(dest.ThrottledBatches)},
+				)
+			}
+		}
+		
+		if source.Latency != nil {
+			series = append(series,
+				Series{Name: "syslog_analyzer_source_latency_seconds", Help: "End-to-end latency of the last tracer event per source", Type: "gauge", Labels: labels, Value: source.Latency.LastSeconds},
+				Series{Name: "syslog_analyzer_source_latency_queue_seconds", Help: "Time the last tracer event waited in the source queue", Type: "gauge", Labels: labels, Value: source.Latency.QueueSeconds},
+				Series{Name: "syslog_analyzer_source_latency_pending_seconds", Help: "Age of the oldest tracer event still in flight per source; 0 when none is", Type: "gauge", Labels: labels, Value: source.Latency.PendingSeconds},
+				Series{Name: "syslog_analyzer_source_tracers_total", Help: "Tracer events injected per source", Type: "counter", Labels: labels, Value: float64(source.Latency.Injected)},
+				Series{Name: "syslog_analyzer_source_tracers_lost_total", Help: "Tracer events dropped because the source queue was full", Type: "counter", Labels: labels, Value: float64(source.Latency.Lost)},
+			)
+			for _, dest := range source.Destinations {
+				destLabels := append(append([]Label{}, labels...), Label{Name: "destination", Value: dest.Name})
+				series = append(series, Series{Name: "syslog_analyzer_destination_latency_seconds", Help: "Latency of the last tracer event until the destination accepted it", Type: "gauge", Labels: destLabels, Value: dest.LatencySeconds})
+			}
+		}
+		
+		series = append(series, stageSeries(source.Stages, labels)...)
+		series = append(series, logMetricSeries(source)...)
+	}
+	
+	return series
+}
+
+// stageSeries converts a source's pipeline stage timings into a duration
+// histogram and an event counter per stage
+func stageSeries(stages []models.StageMetrics, labels []Label) []Series {
+	const family = "syslog_analyzer_source_stage_duration_seconds"
+	const help = "Time spent per call of a pipeline stage per source"
+	
+	var series []Series
+	for _, stage := range stages {
+		stageLabels := append(append([]Label{}, labels...), Label{Name: "stage", Value: stage.Stage})
+		for _, bucket := range stage.Buckets {
+			bucketLabels := append(append([]Label{}, stageLabels...), Label{Name: "le", Value: strconv.FormatFloat(bucket.UpperBound, 'g', -1, 64)})
+			series = append(series, Series{Name: family + "_bucket", Help: help, Type: "histogram", Family: family, Labels: bucketLabels, Value: float64(bucket.Count)})
+		}
+		series = append(series,
+			Series{Name: family + "_bucket", Help: help, Type: "histogram", Family: family, Labels: append(append([]Label{}, stageLabels...), Label{Name: "le", Value: "+Inf"}), Value: float64(stage.Calls)},
+			Series{Name: family + "_sum", Help: help, Type: "histogram", Family: family, Labels: stageLabels, Value: stage.TotalSeconds},
+			Series{Name: family + "_count", Help: help, Type: "histogram", Family: family, Labels: stageLabels, Value: float64(stage.Calls)},
+		)
+	}
+	for _, stage := range stages {
+		stageLabels := append(append([]Label{}, labels...), Label{Name: "stage", Value: stage.Stage})
+		series = append(series, Series{Name: "syslog_analyzer_source_stage_events_total", Help: "Events handled by a pipeline stage per source", Type: "counter", Labels: stageLabels, Value: float64(stage.Events)})
+	}
+	return series
+}
+
+// APISeries converts the web endpoints' request metrics into request and
+// error counters, a duration histogram and recent latency percentiles per
+// endpoint
+func APISeries(endpoints []models.APIEndpointMetrics) []Series {
+	const family = "syslog_analyzer_api_request_duration_seconds"
+	const help = "Time taken to answer a web request per endpoint"
+	
+	var series []Series
+	for _, endpoint := range endpoints {
+		labels := []Label{{Name: "method", Value: endpoint.Method}, {Name: "route", Value: endpoint.Route}}
+		for _, bucket := range endpoint.Buckets {
+			bucketLabels := append(append([]Label{}, labels...), Label{Name: "le", Value: strconv.FormatFloat(bucket.UpperBound, 'g', -1, 64)})
+			series = append(series, Series{Name: family + "_bucket", Help: help, Type: "histogram", Family: family, Labels: bucketLabels, Value: float64(bucket.Count)})
+		}
+		series = append(series,
+			Series{Name: family + "_bucket", Help: help, Type: "histogram", Family: family, Labels: append(append([]Label{}, labels...), Label{Name: "le", Value: "+Inf"}), Value: float64(endpoint.Requests)},
+			Series{Name: family + "_sum", Help: help, Type: "histogram", Family: family, Labels: labels, Value: endpoint.TotalSeconds},
+			Series{Name: family + "_count", Help: help, Type: "histogram", Family: family, Labels: labels, Value: float64(endpoint.Requests)},
+		)
+	}
+	// Each counter family is written in one piece
+	counters := []struct {
+		name  string
+		help  string
+		value func(models.APIEndpointMetrics) int64
+	}{
+		{"syslog_analyzer_api_requests_total", "Web requests per endpoint", func(e models.APIEndpointMetrics) int64 { return e.Requests }},
+		{"syslog_analyzer_api_client_errors_total", "Web requests answered with a 4xx status per endpoint", func(e models.APIEndpointMetrics) int64 { return e.ClientErrors }},
+		{"syslog_analyzer_api_server_errors_total", "Web requests answered with a 5xx status per endpoint", func(e models.APIEndpointMetrics) int64 { return e.ServerErrors }},
+		{"syslog_analyzer_api_slow_requests_total", "Web requests over the slow request threshold per endpoint", func(e models.APIEndpointMetrics) int64 { return e.SlowRequests }},
+	}
+	for _, counter := range counters {
+		for _, endpoint := range endpoints {
+			labels := []Label{{Name: "method", Value: endpoint.Method}, {Name: "route", Value: endpoint.Route}}
+			series = append(series, Series{Name: counter.name, Help: counter.help, Type: "counter", Labels: labels, Value: float64(counter.value(endpoint))})
+		}
+	}
+	for _, endpoint := range endpoints {
+		for _, quantile := range []struct {
+			name  string
+			value float64
+		}{{"0.5", endpoint.P50Ms}, {"0.95", endpoint.P95Ms}, {"0.99", endpoint.P99Ms}} {
+			labels := []Label{{Name: "method", Value: endpoint.Method}, {Name: "route", Value: endpoint.Route}, {Name: "quantile", Value: quantile.name}}
+			series = append(series, Series{Name: "syslog_analyzer_api_request_latency_seconds", Help: "Latency percentiles of the most recent web requests per endpoint", Type: "gauge", Labels: labels, Value: quantile.value / 1000})
+		}
+	}
+	return series
+}
+
+// logMetricSeries converts the last closed window of a source's metrics-mode
+// aggregation into gauges named syslog_analyzer_log_<field>_<op>, labelled
+// with the source, the rule and the group-by values
+func logMetricSeries(source models.SourceMetrics) []Series {
+	series := make([]Series, 0, len(source.LogMetrics))
+	for _, sample := range source.LogMetrics {
+		labels := []Label{
+			{Name: "source", Value: source.Name},
+			{Name: "rule", Value: sample.Rule},
+		}
+		for name, value := range sample.Dimensions {
+			name = sanitizeName(name)
+			if name == "source" || name == "rule" {
+				name = "field_" + name
+			}
+			labels = append(labels, Label{Name: name, Value: value})
+		}
+		sort.Slice(labels[2:], func(i, j int) bool {
+			return labels[2+i].Name < labels[2+j].Name
+		})
+		
+		series = append(series, Series{
+			Name:   "syslog_analyzer_log_" + sanitizeName(sample.Name),
+			Help:   "Value extracted from logs by a metrics-mode aggregation rule",
+			Type:   "gauge",
+			Labels: labels,
+			Value:  sample.Value,
+		})
+	}
+	
+	// Keep series of the same name together for the text exposition format
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].Name < series[j].Name
+	})
+	return series
+}
+
+// sortedKeys returns the keys of a counter map in order
+func sortedKeys(counts map[string]int64) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sanitizeName replaces characters not allowed in Prometheus metric and
+// label names with underscores
+func sanitizeName(name string) string {
+	out := []byte(name)
+	for i, c := range out {
+		valid := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')
+		if !valid {
+			out[i] = '_'
+		}
+	}
+	if len(out) == 0 {
+		return "_"
+	}
+	return string(out)
+}
+
+// sortedLabels returns the series labels plus __name__ and any extra labels,
+// sorted by name as required by remote-write receivers
+func sortedLabels(s Series, extra map[string]string) []Label {
+	labels := make([]Label, 0, len(s.Labels)+len(extra)+1)
+	labels = append(labels, Label{Name: "__name__", Value: s.Name})
+	labels = append(labels, s.Labels...)
+	for name, value := range extra {
+		labels = append(labels, Label{Name: name, Value: value})
+	}
+	
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+	
+	return labels
+}
+
+// boolValue converts a boolean to a metric value
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
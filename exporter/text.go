@@ -0,0 +1,54 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteText writes series in the Prometheus text exposition format
+func WriteText(w io.Writer, series []Series) error {
+	written := make(map[string]bool)
+	
+	for _, s := range series {
+		// HELP and TYPE are emitted once per metric family
+		family := s.Family
+		if family == "" {
+			family = s.Name
+		}
+		if !written[family] {
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", family, s.Help, family, s.Type); err != nil {
+				return err
+			}
+			written[family] = true
+		}
+		
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", s.Name, formatLabels(s.Labels), strconv.FormatFloat(s.Value, 'g', -1, 64)); err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+// formatLabels renders labels as {name="value",...}
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	
+	parts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		parts = append(parts, fmt.Sprintf("%s=\"%s\"", label.Name, escapeLabelValue(label.Value)))
+	}
+	
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// escapeLabelValue escapes backslashes, quotes and newlines in label values
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
@@ -1,30 +1,53 @@
- 
 package filtering
 
 import (
-	"crypto/md5"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"log"
+	"math"
+	"strconv"
 	"sync"
 	"time"
 
 	"syslog-analyzer/models"
 )
 
+// otherBucket is the group-by value of the overflow bucket
+const otherBucket = "__other__"
+
 // Aggregator handles log aggregation operations
 type Aggregator struct {
 	rules       []models.AggregationRule
 	groups      map[string]*AggregationGroup
 	groupsMutex sync.RWMutex
+	disabled    bool  // Set when the "disable" overflow strategy triggered
+	overflowing bool  // Whether the group limit is currently reached
+	overflows   int64 // Events that arrived while the group limit was reached
+	nextClose   time.Time             // Earliest window end of a windowed rule
+	samples     []models.MetricSample // Values of the last closed metrics window
+	keyBuf      []byte                // Scratch space of the group key, reused under groupsMutex
 }
 
 // AggregationGroup represents a group of aggregated events
 type AggregationGroup struct {
-	Key       string
-	Events    []models.LogEvent
+	Key         string
+	Source      string
+	WindowStart time.Time // Aligned window start when the rule has a time window
+	Fields    map[string]interface{} // Group-by field values
 	FirstSeen time.Time
 	LastSeen  time.Time
 	Count     int
+	Bytes     int64
+	Metrics   map[string]*fieldStats // Per-field numeric accumulators
+}
+
+// fieldStats accumulates the numeric values of one field within a group
+type fieldStats struct {
+	count int64
+	sum   float64
+	min   float64
+	max   float64
 }
 
 // NewAggregator creates a new aggregation engine
@@ -37,7 +60,7 @@ func NewAggregator(rules []models.AggregationRule) *Aggregator {
 
 // ProcessBatch processes a batch of events through the aggregation engine
 func (a *Aggregator) ProcessBatch(events []models.LogEvent) []models.LogEvent {
-	if len(a.rules) == 0 {
+	if len(a.rules) == 0 || a.isDisabled() {
 		return events // No aggregation needed
 	}
 	
@@ -50,73 +73,419 @@ func (a *Aggregator) ProcessBatch(events []models.LogEvent) []models.LogEvent {
 	return events
 }
 
+// FlushClosedWindows returns the aggregated events of windows that closed
+// while no events arrived. It is a no-op unless the rule has a time window.
+func (a *Aggregator) FlushClosedWindows() []models.LogEvent {
+	if len(a.rules) == 0 || !isWindowed(a.rules[0]) {
+		return nil
+	}
+	
+	a.groupsMutex.Lock()
+	defer a.groupsMutex.Unlock()
+	if a.disabled {
+		return nil
+	}
+	return a.flushClosedWindows(a.rules[0], time.Now())
+}
+
 // aggregateByRule aggregates events according to a specific rule
 func (a *Aggregator) aggregateByRule(events []models.LogEvent, rule models.AggregationRule) []models.LogEvent {
 	a.groupsMutex.Lock()
 	defer a.groupsMutex.Unlock()
 	
-	// Clean up old groups based on time window
-	a.cleanupOldGroups(rule.TimeWindow)
+	// A rule with a time window emits each group once, when its window closes
+	windowed := isWindowed(rule)
+	var closed []models.LogEvent
+	if windowed {
+		closed = a.flushClosedWindows(rule, time.Now())
+	}
+	
+	maxGroups := rule.MaxGroups
+	if maxGroups <= 0 {
+		maxGroups = models.DefaultMaxGroups
+	}
+	if a.overflowing && len(a.groups) < maxGroups {
+		a.overflowing = false
+	}
 	
 	// Group events
-	for _, event := range events {
+	var passthrough []models.LogEvent
+	for i, event := range events {
 		groupKey := a.generateGroupKey(event, rule.GroupBy)
+		var windowStart time.Time
+		other := false
+		if windowed {
+			windowStart = event.Time.Truncate(rule.TimeWindow)
+			groupKey = windowKey(windowStart, groupKey)
+		}
 		
-		if group, exists := a.groups[groupKey]; exists {
-			// Add to existing group
-			group.Events = append(group.Events, event)
-			group.LastSeen = event.Time
-			group.Count++
-		} else {
+		group, exists := a.groups[groupKey]
+		if !exists && len(a.groups) >= maxGroups {
+			a.overflows++
+			if !a.overflowing {
+				a.overflowing = true
+				log.Printf("⚠ Aggregation rule '%s' on source '%s' reached %d groups (overflow: %s)", rule.Name, event.Source, maxGroups, overflowStrategy(rule))
+			}
+			
+			switch overflowStrategy(rule) {
+			case models.OverflowDisable:
+				// Flush what was aggregated and forward the rest of the batch as-is
+				a.disabled = true
+				aggregated := append(closed, a.flushGroups(rule)...)
+				a.groups = make(map[string]*AggregationGroup)
+				return append(append(aggregated, passthrough...), events[i:]...)
+			case models.OverflowAlert:
+				passthrough = append(passthrough, event)
+				continue
+			default:
+				groupKey, other = otherBucket, true
+				if windowed {
+					groupKey = windowKey(windowStart, otherBucket)
+				}
+				group, exists = a.groups[groupKey]
+			}
+		}
+		
+		if !exists {
 			// Create new group
-			a.groups[groupKey] = &AggregationGroup{
-				Key:       groupKey,
-				Events:    []models.LogEvent{event},
-				FirstSeen: event.Time,
-				LastSeen:  event.Time,
-				Count:     1,
+			group = &AggregationGroup{
+				Key:         groupKey,
+				Source:      event.Source,
+				WindowStart: windowStart,
+				Fields:      make(map[string]interface{}),
+				FirstSeen:   event.Time,
+				Metrics:     make(map[string]*fieldStats),
+			}
+			if windowed {
+				if windowEnd := windowStart.Add(rule.TimeWindow); a.nextClose.IsZero() || windowEnd.Before(a.nextClose) {
+					a.nextClose = windowEnd
+				}
 			}
+			for _, field := range rule.GroupBy {
+				if other {
+					group.Fields[field] = otherBucket
+				} else {
+					group.Fields[field] = a.extractFieldValue(event, field)
+				}
+			}
+			a.groups[groupKey] = group
 		}
+		
+		// Add to group
+		group.LastSeen = event.Time
+		group.Count++
+		group.Bytes += event.Size
+		for _, metric := range rule.Metrics {
+			value, ok := toFloat(a.extractFieldValue(event, metric.Field))
+			if !ok {
+				continue
+			}
+			stats, exists := group.Metrics[metric.Field]
+			if !exists {
+				stats = &fieldStats{min: value, max: value}
+				group.Metrics[metric.Field] = stats
+			}
+			stats.add(value)
+		}
+	}
+	
+	if windowed {
+		return append(closed, passthrough...)
 	}
 	
-	// Return aggregated events (one per group)
+	// Without a window each batch emits the groups it touched and starts
+	// over, so every emission counts only its own events
+	aggregated := a.flushGroups(rule)
+	a.groups = make(map[string]*AggregationGroup)
+	return append(aggregated, passthrough...)
+}
+
+// flushGroups returns one aggregated event per group; callers hold groupsMutex
+func (a *Aggregator) flushGroups(rule models.AggregationRule) []models.LogEvent {
 	var aggregated []models.LogEvent
 	for _, group := range a.groups {
 		if group.Count > 0 {
-			// Create aggregated event
-			aggregatedEvent := models.LogEvent{
-				Time:   group.LastSeen,
-				Source: group.Events[0].Source,
-				Event: map[string]interface{}{
-					"aggregated_message": fmt.Sprintf("Aggregated %d similar events", group.Count),
-					"first_seen":         group.FirstSeen,
-					"last_seen":          group.LastSeen,
-					"count":              group.Count,
-					"group_key":          group.Key,
-					"sample_event":       group.Events[0].Event,
-				},
-				Size: group.Events[0].Size,
-			}
-			aggregated = append(aggregated, aggregatedEvent)
+			aggregated = append(aggregated, group.toLogEvent(rule))
 		}
 	}
 	
 	return aggregated
 }
 
-// generateGroupKey generates a unique key for grouping events
-func (a *Aggregator) generateGroupKey(event models.LogEvent, groupByFields []string) string {
-	groupData := make(map[string]interface{})
+// flushClosedWindows emits and removes the groups whose window ended before
+// now, remembering their values as the latest samples; callers hold groupsMutex
+func (a *Aggregator) flushClosedWindows(rule models.AggregationRule, now time.Time) []models.LogEvent {
+	if a.nextClose.IsZero() || now.Before(a.nextClose) {
+		return nil
+	}
 	
-	for _, field := range groupByFields {
-		value := a.extractFieldValue(event, field)
-		groupData[field] = value
+	var closed []models.LogEvent
+	var samples []models.MetricSample
+	a.nextClose = time.Time{}
+	for key, group := range a.groups {
+		windowEnd := group.WindowStart.Add(rule.TimeWindow)
+		if now.Before(windowEnd) {
+			if a.nextClose.IsZero() || windowEnd.Before(a.nextClose) {
+				a.nextClose = windowEnd
+			}
+			continue
+		}
+		
+		event := group.toLogEvent(rule)
+		closed = append(closed, event)
+		if metrics, ok := event.Event.(models.MetricsEvent); ok {
+			samples = append(samples, metrics.Samples()...)
+		}
+		delete(a.groups, key)
+	}
+	
+	if len(samples) > 0 {
+		a.samples = samples
+	}
+	return closed
+}
+
+// Drain returns the aggregated events of all open windows and clears them. It
+// is called on shutdown so partially filled windows are not lost; without a
+// time window every batch already emits its groups and Drain returns nothing.
+func (a *Aggregator) Drain() []models.LogEvent {
+	if len(a.rules) == 0 || !isWindowed(a.rules[0]) {
+		return nil
 	}
 	
-	// Create hash of group data
-	dataBytes, _ := json.Marshal(groupData)
-	hash := md5.Sum(dataBytes)
-	return fmt.Sprintf("%x", hash)
+	a.groupsMutex.Lock()
+	defer a.groupsMutex.Unlock()
+	
+	drained := a.flushGroups(a.rules[0])
+	a.groups = make(map[string]*AggregationGroup)
+	a.nextClose = time.Time{}
+	return drained
+}
+
+// GetMetricSamples returns the values of the most recently closed metrics window
+func (a *Aggregator) GetMetricSamples() []models.MetricSample {
+	a.groupsMutex.RLock()
+	defer a.groupsMutex.RUnlock()
+	return append([]models.MetricSample(nil), a.samples...)
+}
+
+// GetOverflows returns how many events arrived while the group limit was reached
+func (a *Aggregator) GetOverflows() int64 {
+	a.groupsMutex.RLock()
+	defer a.groupsMutex.RUnlock()
+	return a.overflows
+}
+
+// isDisabled reports whether the "disable" overflow strategy switched aggregation off
+func (a *Aggregator) isDisabled() bool {
+	a.groupsMutex.RLock()
+	defer a.groupsMutex.RUnlock()
+	return a.disabled
+}
+
+// isMetricsMode reports whether a rule emits metrics events; a rule without
+// a time window cannot close windows and stays in events mode
+func isMetricsMode(rule models.AggregationRule) bool {
+	return rule.Mode == models.AggregationModeMetrics && rule.TimeWindow > 0
+}
+
+// isWindowed reports whether a rule accumulates groups per aligned time
+// window and emits them as the windows close
+func isWindowed(rule models.AggregationRule) bool {
+	return rule.TimeWindow > 0
+}
+
+// windowKey prefixes a group key with its window so consecutive windows of
+// the same group are accumulated separately
+func windowKey(windowStart time.Time, groupKey string) string {
+	return strconv.FormatInt(windowStart.UnixNano(), 10) + ":" + groupKey
+}
+
+// overflowStrategy returns the rule's overflow strategy, defaulting to "other"
+func overflowStrategy(rule models.AggregationRule) string {
+	switch rule.Overflow {
+	case models.OverflowDisable, models.OverflowAlert:
+		return rule.Overflow
+	}
+	return models.OverflowOther
+}
+
+// toLogEvent wraps a group in a LogEvent using the rule's output format
+func (g *AggregationGroup) toLogEvent(rule models.AggregationRule) models.LogEvent {
+	if isMetricsMode(rule) {
+		return models.LogEvent{
+			Time:     g.WindowStart.Add(rule.TimeWindow),
+			Source:   g.Source,
+			Event:    g.toMetricsEvent(rule),
+			Size:     g.Bytes,
+			Severity: -1,
+		}
+	}
+	eventTime := g.LastSeen
+	if isWindowed(rule) {
+		eventTime = g.WindowStart.Add(rule.TimeWindow)
+	}
+	return models.LogEvent{
+		Time:     eventTime,
+		Source:   g.Source,
+		Event:    g.toEvent(rule),
+		Size:     g.Bytes,
+		Severity: -1,
+	}
+}
+
+// toMetricsEvent renders a group's window as a metrics event: the group-by
+// fields as string dimensions, the event count and one <field>_<op> value
+// per selected metric
+func (g *AggregationGroup) toMetricsEvent(rule models.AggregationRule) models.MetricsEvent {
+	event := models.MetricsEvent{
+		Rule:        rule.Name,
+		Dimensions:  make(map[string]string, len(g.Fields)),
+		Values:      map[string]float64{"count": float64(g.Count)},
+		WindowStart: g.WindowStart,
+		WindowEnd:   g.WindowStart.Add(rule.TimeWindow),
+	}
+	for field, value := range g.Fields {
+		if value != nil {
+			event.Dimensions[field] = fmt.Sprint(value)
+		} else {
+			event.Dimensions[field] = ""
+		}
+	}
+	
+	for _, metric := range rule.Metrics {
+		stats, exists := g.Metrics[metric.Field]
+		if !exists {
+			continue
+		}
+		for _, op := range metric.Ops {
+			if value, ok := stats.value(op, rule.TimeWindow); ok {
+				event.Values[metric.Field+"_"+op] = value
+			}
+		}
+	}
+	return event
+}
+
+// toEvent renders a group in the aggregation output schema: the group-by
+// fields flattened at the top level, followed by the reserved fields
+// aggregation, count, bytes, first_seen and last_seen, window_start and
+// window_end when the rule has a time window, and one <field>_<op> entry
+// per selected metric. Count and bytes cover only the events of this
+// emission. Reserved fields win over group-by fields of the same name.
+func (g *AggregationGroup) toEvent(rule models.AggregationRule) map[string]interface{} {
+	event := make(map[string]interface{}, len(g.Fields)+7)
+	for field, value := range g.Fields {
+		event[field] = value
+	}
+	
+	for _, metric := range rule.Metrics {
+		stats, exists := g.Metrics[metric.Field]
+		if !exists {
+			continue
+		}
+		for _, op := range metric.Ops {
+			if value, ok := stats.value(op, rule.TimeWindow); ok {
+				event[metric.Field+"_"+op] = value
+			}
+		}
+	}
+	
+	event["aggregation"] = rule.Name
+	event["count"] = g.Count
+	event["bytes"] = g.Bytes
+	event["first_seen"] = g.FirstSeen
+	event["last_seen"] = g.LastSeen
+	if isWindowed(rule) {
+		event["window_start"] = g.WindowStart
+		event["window_end"] = g.WindowStart.Add(rule.TimeWindow)
+	}
+	return event
+}
+
+// add records a value
+func (s *fieldStats) add(value float64) {
+	s.count++
+	s.sum += value
+	s.min = math.Min(s.min, value)
+	s.max = math.Max(s.max, value)
+}
+
+// value returns the result of an aggregate operation over a time window
+func (s *fieldStats) value(op string, window time.Duration) (float64, bool) {
+	switch op {
+	case models.AggregateSum:
+		return s.sum, true
+	case models.AggregateAvg:
+		return s.sum / float64(s.count), true
+	case models.AggregateMin:
+		return s.min, true
+	case models.AggregateMax:
+		return s.max, true
+	case models.AggregateRate:
+		if window <= 0 {
+			return 0, false
+		}
+		return s.sum / window.Seconds(), true
+	}
+	return 0, false
+}
+
+// toFloat converts a JSON number or numeric string to a float
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+// generateGroupKey generates a unique key for grouping events: the encoded
+// group-by values themselves rather than a hash of them, so values crafted
+// by a sender can't collide and merge groups. The caller holds groupsMutex.
+func (a *Aggregator) generateGroupKey(event models.LogEvent, groupByFields []string) string {
+	a.keyBuf = a.keyBuf[:0]
+	for _, field := range groupByFields {
+		// Each value is followed by its length so adjacent values can't run together
+		start := len(a.keyBuf)
+		a.keyBuf = appendKeyValue(a.keyBuf, a.extractFieldValue(event, field))
+		a.keyBuf = binary.BigEndian.AppendUint32(a.keyBuf, uint32(len(a.keyBuf)-start))
+	}
+	return string(a.keyBuf)
+}
+
+// appendKeyValue appends a type tag and the encoding of a group-by value.
+// Numbers encode alike whatever their Go type, as they do in JSON.
+func appendKeyValue(dst []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(dst, 'n')
+	case string:
+		return append(append(dst, 's'), v...)
+	case bool:
+		return strconv.AppendBool(append(dst, 'b'), v)
+	case float64:
+		return strconv.AppendFloat(append(dst, 'f'), v, 'g', -1, 64)
+	case int:
+		return strconv.AppendFloat(append(dst, 'f'), float64(v), 'g', -1, 64)
+	case int64:
+		return strconv.AppendFloat(append(dst, 'f'), float64(v), 'g', -1, 64)
+	case json.Number:
+		if f, err := strconv.ParseFloat(string(v), 64); err == nil {
+			return strconv.AppendFloat(append(dst, 'f'), f, 'g', -1, 64)
+		}
+		return append(append(dst, 'j'), v...)
+	case time.Time:
+		return strconv.AppendInt(append(dst, 't'), v.UnixNano(), 10)
+	}
+	data, _ := json.Marshal(value)
+	return append(append(dst, 'j'), data...)
 }
 
 // extractFieldValue extracts a field value from a log event (similar to filtering engine)
@@ -139,14 +508,3 @@ func (a *Aggregator) extractFieldValue(event models.LogEvent, field string) inte
 	
 	return nil
 }
-
-// cleanupOldGroups removes groups that are older than the time window
-func (a *Aggregator) cleanupOldGroups(timeWindow time.Duration) {
-	cutoff := time.Now().Add(-timeWindow)
-	
-	for key, group := range a.groups {
-		if group.LastSeen.Before(cutoff) {
-			delete(a.groups, key)
-		}
-	}
-}
\ No newline at end of file
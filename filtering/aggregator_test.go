@@ -0,0 +1,188 @@
+package filtering
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+	
+	"syslog-analyzer/models"
+)
+
+// benchEvents decodes JSON messages with the fields the benchmark rules use,
+// the way the processor does
+func benchEvents(count int) []models.LogEvent {
+	events := make([]models.LogEvent, count)
+	for i := range events {
+		message := fmt.Sprintf(`{"host":"host-%02d","action":"%s","user":"user-%d","bytes":%d,"message":"request handled"}`,
+			i%50, []string{"login", "logout", "read", "write"}[i%4], i%200, 100+i*7)
+		var parsed interface{}
+		json.Unmarshal([]byte(message), &parsed)
+		events[i] = models.LogEvent{
+			Time:     time.Now().UTC(),
+			Source:   "bench",
+			Event:    parsed,
+			Size:     int64(len(message)),
+			SenderIP: "127.0.0.1",
+			Severity: -1,
+		}
+	}
+	return events
+}
+
+// benchAggregations sums bytes per group of the given fields
+func benchAggregations(groupBy ...string) []models.AggregationRule {
+	return []models.AggregationRule{{
+		Name:       "bytes_by_group",
+		GroupBy:    groupBy,
+		TimeWindow: time.Minute,
+		Metrics:    []models.AggregationMetric{{Field: "bytes", Ops: []string{models.AggregateSum, models.AggregateMax}}},
+	}}
+}
+
+func groupKey(fields map[string]interface{}, groupBy ...string) string {
+	return NewAggregator(nil).generateGroupKey(models.LogEvent{Event: fields}, groupBy)
+}
+
+func TestGroupKeyKeepsValuesApart(t *testing.T) {
+	pairs := []struct {
+		name string
+		a, b map[string]interface{}
+	}{
+		{"adjacent values", map[string]interface{}{"x": "ab", "y": "c"}, map[string]interface{}{"x": "a", "y": "bc"}},
+		{"string and number", map[string]interface{}{"x": "1", "y": ""}, map[string]interface{}{"x": 1.0, "y": ""}},
+		{"missing and empty", map[string]interface{}{"y": ""}, map[string]interface{}{"x": "", "y": ""}},
+		{"bool and string", map[string]interface{}{"x": true, "y": ""}, map[string]interface{}{"x": "true", "y": ""}},
+		{"embedded length", map[string]interface{}{"x": "a\x00\x00\x00\x02sb", "y": "c"}, map[string]interface{}{"x": "a", "y": "b\x00\x00\x00\x02sc"}},
+	}
+	for _, pair := range pairs {
+		if groupKey(pair.a, "x", "y") == groupKey(pair.b, "x", "y") {
+			t.Errorf("%s: %v and %v share a group key", pair.name, pair.a, pair.b)
+		}
+	}
+}
+
+func TestGroupKeyEncodesNumbersAlike(t *testing.T) {
+	want := groupKey(map[string]interface{}{"x": 1.0}, "x")
+	for _, value := range []interface{}{1, int64(1), json.Number("1"), json.Number("1.0"), json.Number("1e0")} {
+		if got := groupKey(map[string]interface{}{"x": value}, "x"); got != want {
+			t.Errorf("%T %v: got key %q, want %q", value, value, got, want)
+		}
+	}
+}
+
+// eventsAt returns benchEvents all stamped with the same time
+func eventsAt(count int, at time.Time) []models.LogEvent {
+	events := benchEvents(count)
+	for i := range events {
+		events[i].Time = at
+	}
+	return events
+}
+
+func TestAggregatorGroupsByKey(t *testing.T) {
+	aggregator := NewAggregator(benchAggregations("host", "action"))
+	aggregator.ProcessBatch(eventsAt(200, time.Now().Truncate(time.Minute)))
+	// Host i%50 and action i%4 repeat together every lcm(50, 4) = 100 events
+	if got := len(aggregator.groups); got != 100 {
+		t.Fatalf("got %d groups, want 100", got)
+	}
+}
+
+// TestAggregatorEmitsWindowOnce checks that events mode emits each group
+// once, when its window closes, and not again with later batches
+func TestAggregatorEmitsWindowOnce(t *testing.T) {
+	aggregator := NewAggregator(benchAggregations("host", "action"))
+	windowStart := time.Now().Add(-2 * time.Minute).Truncate(time.Minute)
+	if out := aggregator.ProcessBatch(eventsAt(200, windowStart.Add(time.Second))); len(out) != 0 {
+		t.Fatalf("emitted %d events before the window closed", len(out))
+	}
+	
+	closed := aggregator.FlushClosedWindows()
+	if len(closed) != 100 {
+		t.Fatalf("closed window emitted %d events, want 100", len(closed))
+	}
+	for _, event := range closed {
+		fields := event.Event.(map[string]interface{})
+		if fields["count"] != 2 {
+			t.Fatalf("group count %v, want 2", fields["count"])
+		}
+		if !fields["window_start"].(time.Time).Equal(windowStart) || !fields["window_end"].(time.Time).Equal(windowStart.Add(time.Minute)) {
+			t.Fatalf("window %v - %v, want the aligned minute from %v", fields["window_start"], fields["window_end"], windowStart)
+		}
+	}
+	
+	if out := aggregator.ProcessBatch(nil); len(out) != 0 {
+		t.Fatalf("a later batch emitted %d events of the closed window again", len(out))
+	}
+	if drained := aggregator.Drain(); len(drained) != 0 {
+		t.Fatalf("drain emitted %d events of the closed window again", len(drained))
+	}
+}
+
+// TestAggregatorCountsEachBatchWithoutWindow checks that without a time
+// window every batch emits only the counts of its own events
+func TestAggregatorCountsEachBatchWithoutWindow(t *testing.T) {
+	rules := benchAggregations("host", "action")
+	rules[0].TimeWindow = 0
+	aggregator := NewAggregator(rules)
+	
+	for batch := 0; batch < 3; batch++ {
+		out := aggregator.ProcessBatch(benchEvents(200))
+		if len(out) != 100 {
+			t.Fatalf("batch %d emitted %d events, want 100", batch, len(out))
+		}
+		var total int
+		for _, event := range out {
+			fields := event.Event.(map[string]interface{})
+			total += fields["count"].(int)
+			if _, ok := fields["window_start"]; ok {
+				t.Fatal("window_start set on a rule without a time window")
+			}
+			if _, ok := fields["first_seen"]; !ok {
+				t.Fatal("first_seen missing")
+			}
+		}
+		if total != 200 {
+			t.Fatalf("batch %d counted %d events, want 200", batch, total)
+		}
+	}
+}
+
+// BenchmarkGroupKey builds the key of a three-field group
+func BenchmarkGroupKey(b *testing.B) {
+	aggregator := NewAggregator(nil)
+	events := benchEvents(100)
+	groupBy := []string{"host", "action", "user"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	
+	for i := 0; i < b.N; i++ {
+		aggregator.generateGroupKey(events[i%len(events)], groupBy)
+	}
+}
+
+// BenchmarkAggregateBatch aggregates a batch of 100 events by host and action
+func BenchmarkAggregateBatch(b *testing.B) {
+	aggregator := NewAggregator(benchAggregations("host", "action"))
+	events := benchEvents(100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	
+	for i := 0; i < b.N; i++ {
+		aggregator.ProcessBatch(events)
+	}
+}
+
+// BenchmarkAggregateBatchWide groups by three fields, so building the group
+// key dominates
+func BenchmarkAggregateBatchWide(b *testing.B) {
+	aggregator := NewAggregator(benchAggregations("host", "action", "user"))
+	events := benchEvents(100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	
+	for i := 0; i < b.N; i++ {
+		aggregator.ProcessBatch(events)
+	}
+}
@@ -1,9 +1,10 @@
- 
 package filtering
 
 import (
+	"fmt"
 	"log"
 	"regexp"
+	"sort"
 	"strings"
 
 	"syslog-analyzer/models"
@@ -11,14 +12,117 @@ import (
 
 // Engine handles log filtering operations
 type Engine struct {
-	rules []models.FilterRule
+	rules      []compiledRule   // In evaluation order
+	matchers   []literalMatcher // Literal prefilters of the regex rules, see NewMultiPatternEngine
+	errors     []string         // Invalid rules, reported instead of logged per event
+	firstMatch bool             // models.FilterPolicyFirst
+	hasInclude bool
 }
 
-// NewEngine creates a new filtering engine
-func NewEngine(rules []models.FilterRule) *Engine {
-	return &Engine{
-		rules: rules,
+// compiledRule is a filter rule prepared once for every event it evaluates
+type compiledRule struct {
+	models.FilterRule
+	lowerValue string         // Value of "contains" rules, lower-cased
+	pattern    *regexp.Regexp // Compiled value of "regex" rules
+	invalid    bool           // Never matches
+	matcher    int            // 1-based index of the literal matcher covering the rule, 0 for none
+	when       *schedule      // Applies only to events in the schedule
+}
+
+// NewEngine creates a new filtering engine, compiling the rules' patterns and
+// ordering them by priority. Invalid rules are logged once and reported by
+// Errors; rules with an invalid pattern or operator never match.
+func NewEngine(rules []models.FilterRule, policy string) *Engine {
+	engine := &Engine{
+		rules:      make([]compiledRule, 0, len(rules)),
+		firstMatch: policy == models.FilterPolicyFirst,
+	}
+	
+	for i, rule := range rules {
+		compiled, err := compileRule(rule)
+		if err != nil {
+			message := fmt.Sprintf("filter %d: %v", i+1, err)
+			log.Printf("⚠ Invalid %s", message)
+			engine.errors = append(engine.errors, message)
+		}
+		engine.rules = append(engine.rules, compiled)
+		if rule.Action == "include" {
+			engine.hasInclude = true
+		}
+	}
+	sort.SliceStable(engine.rules, func(i, j int) bool {
+		return engine.rules[i].Priority < engine.rules[j].Priority
+	})
+	
+	return engine
+}
+
+// NewMultiPatternEngine creates a filtering engine for sources with many
+// regex filters: each field is scanned once for the literals its regex rules
+// require, and only the rules whose literals were found run their pattern
+func NewMultiPatternEngine(rules []models.FilterRule, policy string) *Engine {
+	engine := NewEngine(rules, policy)
+	engine.matchers = buildLiteralMatchers(engine.rules)
+	return engine
+}
+
+// ValidateRules checks filter rules, so invalid ones are refused when a
+// source is configured rather than failing on every event
+func ValidateRules(rules []models.FilterRule) error {
+	for i, rule := range rules {
+		if err := ValidateRule(rule); err != nil {
+			return fmt.Errorf("filter %d: %v", i+1, err)
+		}
 	}
+	return nil
+}
+
+// ValidateRule checks a single filter rule
+func ValidateRule(rule models.FilterRule) error {
+	_, err := compileRule(rule)
+	return err
+}
+
+// compileRule prepares a rule for evaluation
+func compileRule(rule models.FilterRule) (compiledRule, error) {
+	compiled := compiledRule{FilterRule: rule}
+	
+	switch rule.Operator {
+	case "contains":
+		compiled.lowerValue = strings.ToLower(rule.Value)
+	case "equals":
+	case "regex":
+		pattern, err := regexp.Compile(rule.Value)
+		if err != nil {
+			compiled.invalid = true
+			return compiled, fmt.Errorf("invalid regex pattern '%s': %v", rule.Value, err)
+		}
+		compiled.pattern = pattern
+	default:
+		compiled.invalid = true
+		return compiled, fmt.Errorf("unknown operator: %s", rule.Operator)
+	}
+	
+	switch rule.Action {
+	case "include", "exclude":
+	default:
+		return compiled, fmt.Errorf("unknown action: %s", rule.Action)
+	}
+	
+	if rule.When != nil {
+		when, err := parseSchedule(*rule.When)
+		if err != nil {
+			compiled.invalid = true
+			return compiled, err
+		}
+		compiled.when = when
+	}
+	return compiled, nil
+}
+
+// Errors describes the invalid rules
+func (e *Engine) Errors() []string {
+	return e.errors
 }
 
 // ProcessBatch processes a batch of events through the filter engine
@@ -38,45 +142,72 @@ func (e *Engine) ProcessBatch(events []models.LogEvent) []models.LogEvent {
 	return filtered
 }
 
-// shouldInclude determines if an event should be included based on filter rules
+// Includes reports whether the filters keep an event
+func (e *Engine) Includes(event models.LogEvent) bool {
+	return len(e.rules) == 0 || e.shouldInclude(event)
+}
+
+// shouldInclude determines if an event should be included based on filter
+// rules, evaluated in priority order under the engine's policy
 func (e *Engine) shouldInclude(event models.LogEvent) bool {
-	for _, rule := range e.rules {
-		match := e.evaluateRule(event, rule)
+	var scanned [maxInlineMatchers]bool
+	var candidates [maxInlineRules / 64]uint64
+	state := e.newScanState(&scanned, &candidates)
+	
+	for i := range e.rules {
+		rule := &e.rules[i]
+		if rule.when != nil && !rule.when.matches(event.Time) {
+			continue // Not in effect for this event
+		}
+		match := e.evaluateRule(event, rule, i, state)
 		
+		if e.firstMatch {
+			if match {
+				return rule.Action == "include"
+			}
+			continue
+		}
 		if rule.Action == "exclude" && match {
 			return false // Exclude this event
 		}
 		if rule.Action == "include" && !match {
 			return false // Only include if it matches
 		}
+		if rule.Stop && match {
+			return true // Later rules don't apply
+		}
 	}
 	
+	if e.firstMatch {
+		return !e.hasInclude // No rule matched
+	}
 	return true // Include by default
 }
 
-// evaluateRule evaluates a single filter rule against an event
-func (e *Engine) evaluateRule(event models.LogEvent, rule models.FilterRule) bool {
+// evaluateRule evaluates the filter rule at index against an event. state
+// holds the event's literal scans, made as the rules need them.
+func (e *Engine) evaluateRule(event models.LogEvent, rule *compiledRule, index int, state scanState) bool {
+	if rule.invalid {
+		return false
+	}
 	fieldValue := e.extractFieldValue(event, rule.Field)
 	if fieldValue == "" {
 		return false
 	}
 	
+	if !e.prefilter(rule, index, fieldValue, state) {
+		return false
+	}
+	
 	switch rule.Operator {
 	case "contains":
-		return strings.Contains(strings.ToLower(fieldValue), strings.ToLower(rule.Value))
+		return strings.Contains(strings.ToLower(fieldValue), rule.lowerValue)
 	case "equals":
 		return strings.EqualFold(fieldValue, rule.Value)
 	case "regex":
-		matched, err := regexp.MatchString(rule.Value, fieldValue)
-		if err != nil {
-			log.Printf("⚠ Invalid regex pattern '%s': %v", rule.Value, err)
-			return false
-		}
-		return matched
-	default:
-		log.Printf("⚠ Unknown filter operator: %s", rule.Operator)
-		return false
+		return rule.pattern.MatchString(fieldValue)
 	}
+	return false
 }
 
 // extractFieldValue extracts a field value from a log event
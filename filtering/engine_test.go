@@ -0,0 +1,101 @@
+package filtering
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	
+	"syslog-analyzer/models"
+)
+
+// benchRegexFilters is an exclude list of regex rules that match few events
+func benchRegexFilters() []models.FilterRule {
+	rules := make([]models.FilterRule, 0, 25)
+	for i := 0; i < 24; i++ {
+		rules = append(rules, models.FilterRule{
+			Field:    "message",
+			Operator: "regex",
+			Value:    fmt.Sprintf(`(?i)error code %d\d*|timeout on backend-%02d`, i, i),
+			Action:   "exclude",
+		})
+	}
+	rules = append(rules, models.FilterRule{Field: "user", Operator: "regex", Value: `^user-1\d$`, Action: "exclude"})
+	return rules
+}
+
+// TestMultiPatternEngineMatchesEngine checks that the literal prefilter of
+// NewMultiPatternEngine never changes a decision of NewEngine
+func TestMultiPatternEngineMatchesEngine(t *testing.T) {
+	cases := []struct {
+		name     string
+		pattern  string
+		messages []string
+	}{
+		{"case-insensitive", `(?i)login failed`, []string{"LOGIN FAILED for root", "Login Failed", "login ok", "loginfailed"}},
+		{"alternation", `denied|refused`, []string{"access denied", "connection refused", "accepted", "DENIED"}},
+		{"case-insensitive alternation", `(?i)(alpha|beta)-\d`, []string{"ALPHA-1", "Beta-7", "gamma-3", "beta-x"}},
+		{"empty alternative", `timeout|`, []string{"timeout", "anything at all"}},
+		{"optional group", `(error )?code 5\d\d`, []string{"error code 503", "code 500", "error code 404", "code5"}},
+		{"optional letter", `colou?r`, []string{"color", "colour", "colr", "COLOR"}},
+		{"optional only", `(debug)?`, []string{"debug", "info"}},
+		{"start anchor", `^fail`, []string{"failure", "a failure", "FAIL"}},
+		{"end anchor", `(?i)done$`, []string{"job DONE", "done later", "Done"}},
+		{"word boundary", `\bwarn\b`, []string{"warn: disk", "warning", "prewarn", "a warn b"}},
+		{"accented letters", `(?i)échec`, []string{"ÉCHEC total", "échec", "echec", "ÉCHEc"}},
+		{"sharp s", `(?i)straße`, []string{"STRAẞE", "strasse", "Straße"}},
+		{"kelvin sign", `(?i)kelvin`, []string{"\u212Aelvin", "KELVIN", "celsius"}},
+		{"final sigma", `(?i)λόγος`, []string{"ΛΌΓΟΣ", "λόγοσ", "λογος"}},
+		{"long s", `(?i)sys`, []string{"ſyſ", "SYS", "sis"}},
+		{"repetition", `(?i)(ab){2,}`, []string{"ABAB", "ab", "xxAbaBxx"}},
+	}
+	
+	for _, tc := range cases {
+		reference := regexp.MustCompile(tc.pattern)
+		for _, action := range []string{"exclude", "include"} {
+			// A second regex rule on the field gets the multi-pattern matcher built
+			rules := []models.FilterRule{
+				{Field: "message", Operator: "regex", Value: tc.pattern, Action: action},
+				{Field: "message", Operator: "regex", Value: `never-\d+-seen`, Action: "exclude"},
+			}
+			single := NewEngine(rules, models.FilterPolicyAll)
+			multi := NewMultiPatternEngine(rules, models.FilterPolicyAll)
+			
+			for _, message := range tc.messages {
+				event := models.LogEvent{Event: message}
+				want := reference.MatchString(message) == (action == "include")
+				if got := single.Includes(event); got != want {
+					t.Errorf("%s: NewEngine %s %q = %v, want %v", tc.name, action, message, got, want)
+				}
+				if got := multi.Includes(event); got != want {
+					t.Errorf("%s: NewMultiPatternEngine %s %q = %v, want %v", tc.name, action, message, got, want)
+				}
+			}
+		}
+	}
+}
+
+func benchmarkFilterBatch(b *testing.B, engine *Engine) {
+	events := benchEvents(100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	
+	for i := 0; i < b.N; i++ {
+		engine.ProcessBatch(events)
+	}
+}
+
+// BenchmarkFilterBatch drops one action in four from a batch of 100 events
+func BenchmarkFilterBatch(b *testing.B) {
+	rules := []models.FilterRule{{Field: "action", Operator: "equals", Value: "logout", Action: "exclude"}}
+	benchmarkFilterBatch(b, NewEngine(rules, models.FilterPolicyAll))
+}
+
+// BenchmarkFilterBatchRegex runs 25 regex rules one by one
+func BenchmarkFilterBatchRegex(b *testing.B) {
+	benchmarkFilterBatch(b, NewEngine(benchRegexFilters(), models.FilterPolicyAll))
+}
+
+// BenchmarkFilterBatchRegexMulti runs the same rules with the multi-pattern matcher
+func BenchmarkFilterBatchRegexMulti(b *testing.B) {
+	benchmarkFilterBatch(b, NewMultiPatternEngine(benchRegexFilters(), models.FilterPolicyAll))
+}
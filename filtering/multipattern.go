@@ -0,0 +1,278 @@
+package filtering
+
+import (
+	"regexp/syntax"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Inline capacity of an event's scan state; engines past it allocate per event
+const (
+	maxInlineMatchers = 8
+	maxInlineRules    = 256
+)
+
+// literalMatcher scans a field once for the literals required by all of its
+// regex rules. A regex rule can only match text containing one of its
+// literals, so a rule none of whose literals was found is skipped without
+// running its pattern; only the candidates are then evaluated one by one.
+// The literals are compared case-folded and searched with an Aho-Corasick
+// automaton, so the scan costs the same however many rules the field has.
+type literalMatcher struct {
+	field   string
+	classes [256]uint8 // Byte to alphabet class; 0 is every byte no literal uses
+	width   int        // Number of alphabet classes
+	next    []int32    // Transition table, state*width+class
+	outputs [][]int32  // Rule indexes whose literal ends in each state
+}
+
+// scanState holds the literal scan results of the event being evaluated
+type scanState struct {
+	scanned    []bool   // By matcher
+	candidates []uint64 // Bitset of rules with a literal in their field
+}
+
+// newScanState prepares the scan state of one event
+func (e *Engine) newScanState(inline *[maxInlineMatchers]bool, bits *[maxInlineRules / 64]uint64) scanState {
+	if len(e.matchers) == 0 {
+		return scanState{}
+	}
+	state := scanState{scanned: inline[:len(e.matchers)], candidates: bits[:]}
+	if len(e.matchers) > maxInlineMatchers {
+		state.scanned = make([]bool, len(e.matchers))
+	}
+	if len(e.rules) > maxInlineRules {
+		state.candidates = make([]uint64, (len(e.rules)+63)/64)
+	}
+	return state
+}
+
+// prefilter reports whether the rule may match value, scanning value the
+// first time a rule on its field asks
+func (e *Engine) prefilter(rule *compiledRule, index int, value string, state scanState) bool {
+	if rule.matcher == 0 {
+		return true
+	}
+	if !state.scanned[rule.matcher-1] {
+		state.scanned[rule.matcher-1] = true
+		e.matchers[rule.matcher-1].scan(value, state.candidates)
+	}
+	return state.candidates[index/64]&(1<<(uint(index)%64)) != 0
+}
+
+// buildLiteralMatchers assigns a matcher to each field with more than one
+// regex rule whose required literals could be extracted
+func buildLiteralMatchers(rules []compiledRule) []literalMatcher {
+	literals := make(map[int][]string)
+	byField := make(map[string][]int)
+	var fields []string
+	for i := range rules {
+		rule := &rules[i]
+		if rule.invalid || rule.pattern == nil {
+			continue
+		}
+		parsed, err := syntax.Parse(rule.Value, syntax.Perl)
+		if err != nil {
+			continue
+		}
+		found := requiredLiterals(parsed)
+		if len(found) == 0 {
+			continue // Always evaluated
+		}
+		
+		literals[i] = found
+		if _, exists := byField[rule.Field]; !exists {
+			fields = append(fields, rule.Field)
+		}
+		byField[rule.Field] = append(byField[rule.Field], i)
+	}
+	
+	var matchers []literalMatcher
+	for _, field := range fields {
+		indexes := byField[field]
+		if len(indexes) < 2 {
+			continue
+		}
+		matchers = append(matchers, newLiteralMatcher(field, indexes, literals))
+		for _, i := range indexes {
+			rules[i].matcher = len(matchers)
+		}
+	}
+	return matchers
+}
+
+// requiredLiterals returns case-folded literals one of which every match of
+// re contains, or nil when no such set is known
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if len(re.Rune) == 0 {
+			return nil
+		}
+		var literal []byte
+		for _, r := range re.Rune {
+			literal = utf8.AppendRune(literal, foldRune(r))
+		}
+		return []string{string(literal)}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		// Any one part will do; keep the most selective
+		var best []string
+		bestLen := 0
+		for _, sub := range re.Sub {
+			found := requiredLiterals(sub)
+			if length := shortest(found); length > bestLen {
+				best, bestLen = found, length
+			}
+		}
+		return best
+	case syntax.OpAlternate:
+		var union []string
+		for _, sub := range re.Sub {
+			found := requiredLiterals(sub)
+			if len(found) == 0 {
+				return nil // This branch can match without a literal
+			}
+			union = append(union, found...)
+		}
+		return union
+	}
+	return nil
+}
+
+// shortest is the length of the shortest literal, 0 for none
+func shortest(literals []string) int {
+	length := 0
+	for i, literal := range literals {
+		if i == 0 || len(literal) < length {
+			length = len(literal)
+		}
+	}
+	return length
+}
+
+// foldRune maps a rune to the smallest rune it matches case-insensitively, so
+// folded text contains a folded literal wherever the regex could match it
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'a' <= r && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}
+	folded := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < folded {
+			folded = f
+		}
+	}
+	return folded
+}
+
+// newLiteralMatcher builds the automaton over the literals of the rules
+func newLiteralMatcher(field string, indexes []int, literals map[int][]string) literalMatcher {
+	matcher := literalMatcher{field: field, width: 1}
+	for _, i := range indexes {
+		for _, literal := range literals[i] {
+			for n := 0; n < len(literal); n++ {
+				if matcher.classes[literal[n]] == 0 {
+					matcher.classes[literal[n]] = uint8(matcher.width)
+					matcher.width++
+				}
+			}
+		}
+	}
+	
+	// Trie of the literals; -1 is a missing edge
+	children := [][]int32{newEdges(matcher.width)}
+	matcher.outputs = [][]int32{nil}
+	for _, i := range indexes {
+		for _, literal := range literals[i] {
+			state := int32(0)
+			for n := 0; n < len(literal); n++ {
+				class := matcher.classes[literal[n]]
+				if children[state][class] < 0 {
+					children[state][class] = int32(len(children))
+					children = append(children, newEdges(matcher.width))
+					matcher.outputs = append(matcher.outputs, nil)
+				}
+				state = children[state][class]
+			}
+			matcher.outputs[state] = append(matcher.outputs[state], int32(i))
+		}
+	}
+	
+	// Breadth-first fill of the failure transitions turns the trie into a DFA
+	matcher.next = make([]int32, len(children)*matcher.width)
+	fail := make([]int32, len(children))
+	queue := make([]int32, 0, len(children))
+	for class := 0; class < matcher.width; class++ {
+		if child := children[0][class]; child > 0 {
+			matcher.next[class] = child
+			queue = append(queue, child)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		matcher.outputs[state] = append(matcher.outputs[state], matcher.outputs[fail[state]]...)
+		for class := 0; class < matcher.width; class++ {
+			fallback := matcher.next[int(fail[state])*matcher.width+class]
+			if child := children[state][class]; child >= 0 {
+				fail[child] = fallback
+				matcher.next[int(state)*matcher.width+class] = child
+				queue = append(queue, child)
+			} else {
+				matcher.next[int(state)*matcher.width+class] = fallback
+			}
+		}
+	}
+	return matcher
+}
+
+// newEdges returns a trie node without edges
+func newEdges(width int) []int32 {
+	edges := make([]int32, width)
+	for i := range edges {
+		edges[i] = -1
+	}
+	return edges
+}
+
+// scan marks in candidates the rules with a literal in value
+func (m *literalMatcher) scan(value string, candidates []uint64) {
+	state := int32(0)
+	var encoded [utf8.UTFMax]byte
+	for i := 0; i < len(value); {
+		b := value[i]
+		if b < utf8.RuneSelf {
+			if 'a' <= b && b <= 'z' {
+				b -= 'a' - 'A'
+			}
+			state = m.step(state, b, candidates)
+			i++
+			continue
+		}
+		
+		r, size := utf8.DecodeRuneInString(value[i:])
+		n := utf8.EncodeRune(encoded[:], foldRune(r))
+		for _, b := range encoded[:n] {
+			state = m.step(state, b, candidates)
+		}
+		i += size
+	}
+}
+
+// step advances the automaton by one byte and records the literals ending there
+func (m *literalMatcher) step(state int32, b byte, candidates []uint64) int32 {
+	state = m.next[int(state)*m.width+int(m.classes[b])]
+	for _, rule := range m.outputs[state] {
+		candidates[rule/64] |= 1 << (uint(rule) % 64)
+	}
+	return state
+}
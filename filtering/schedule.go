@@ -0,0 +1,160 @@
+package filtering
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// scheduleMacros are the named schedules accepted in place of a cron expression
+var scheduleMacros = map[string]string{
+	"@business_hours": "* 9-16 * * 1-5", // Monday to Friday, 09:00 to 17:00
+	"@weekdays":       "* * * * 1-5",
+	"@weekends":       "* * * * 0,6",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// schedule is a parsed time condition. Each field is a bitset of the
+// values the cron expression allows.
+type schedule struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	anyDay   bool // Day of month is "*"
+	anyWeek  bool // Day of week is "*"
+	location *time.Location
+	negate   bool
+}
+
+// parseSchedule parses a time condition: a five-field cron expression
+// (minute, hour, day of month, month, day of week) or one of the macros
+func parseSchedule(condition models.TimeCondition) (*schedule, error) {
+	expression := strings.TrimSpace(condition.Schedule)
+	if macro, ok := scheduleMacros[strings.ToLower(expression)]; ok {
+		expression = macro
+	} else if strings.HasPrefix(expression, "@") {
+		return nil, fmt.Errorf("unknown schedule %q", expression)
+	}
+	
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q needs 5 fields: minute hour day month weekday", condition.Schedule)
+	}
+	
+	s := &schedule{location: time.Local, negate: condition.Negate}
+	var err error
+	if s.minutes, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("schedule minute: %v", err)
+	}
+	if s.hours, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("schedule hour: %v", err)
+	}
+	if s.days, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("schedule day of month: %v", err)
+	}
+	if s.months, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("schedule month: %v", err)
+	}
+	if s.weekdays, err = parseCronField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, fmt.Errorf("schedule weekday: %v", err)
+	}
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays |= 1 // 7 is Sunday too
+	}
+	s.anyDay = fields[2] == "*"
+	s.anyWeek = fields[4] == "*"
+	
+	if condition.Timezone != "" {
+		location, err := time.LoadLocation(condition.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("schedule timezone: %v", err)
+		}
+		s.location = location
+	}
+	return s, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges and steps
+// ("*", "5", "1-5", "*/15", "mon-fri") into a bitset
+func parseCronField(field string, min, max int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[slash+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:slash]
+		}
+		
+		low, high := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if low, err = parseCronValue(bounds[0], min, max, names); err != nil {
+				return 0, err
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = parseCronValue(bounds[1], min, max, names); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				high = max // "5/15" runs from 5 to the end
+			}
+			if high < low {
+				return 0, fmt.Errorf("range %q is backwards", part)
+			}
+		}
+		
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue parses a number or a name in a cron field
+func parseCronValue(value string, min, max int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(value, name) {
+			return i + min, nil
+		}
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	if number < min || number > max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", number, min, max)
+	}
+	return number, nil
+}
+
+// matches reports whether t falls in the schedule. As in cron, a day
+// matches when either the day of month or the day of week does, unless one
+// of them is "*".
+func (s *schedule) matches(t time.Time) bool {
+	t = t.In(s.location)
+	inDay := s.days&(1<<uint(t.Day())) != 0
+	inWeek := s.weekdays&(1<<uint(t.Weekday())) != 0
+	dayMatches := inDay && inWeek
+	if !s.anyDay && !s.anyWeek {
+		dayMatches = inDay || inWeek
+	}
+	
+	in := dayMatches &&
+		s.minutes&(1<<uint(t.Minute())) != 0 &&
+		s.hours&(1<<uint(t.Hour())) != 0 &&
+		s.months&(1<<uint(t.Month())) != 0
+	return in != s.negate
+}
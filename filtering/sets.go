@@ -0,0 +1,35 @@
+package filtering
+
+import (
+	"fmt"
+
+	"syslog-analyzer/models"
+)
+
+// FindSet returns the filter set with the given name, or nil
+func FindSet(sets []models.FilterSet, name string) *models.FilterSet {
+	for i := range sets {
+		if sets[i].Name == name {
+			return &sets[i]
+		}
+	}
+	return nil
+}
+
+// ExpandSets returns the rules of the named filter sets, in the order they
+// are named, followed by rules
+func ExpandSets(sets []models.FilterSet, names []string, rules []models.FilterRule) ([]models.FilterRule, error) {
+	if len(names) == 0 {
+		return rules, nil
+	}
+	
+	var expanded []models.FilterRule
+	for _, name := range names {
+		set := FindSet(sets, name)
+		if set == nil {
+			return nil, fmt.Errorf("unknown filter set: %s", name)
+		}
+		expanded = append(expanded, set.Rules...)
+	}
+	return append(expanded, rules...), nil
+}
@@ -0,0 +1,6 @@
+//go:build !fips
+
+package fips
+
+// buildEnabled leaves FIPS mode to the configuration
+const buildEnabled = false
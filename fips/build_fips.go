@@ -0,0 +1,10 @@
+//go:build fips
+
+package fips
+
+// buildEnabled turns FIPS mode on regardless of the configuration
+const buildEnabled = true
+
+func init() {
+	Enable()
+}
@@ -0,0 +1,78 @@
+// Package fips restricts the analyzer's cryptography to FIPS 140-approved
+// algorithms for regulated deployments. The mode is enabled by the
+// fips_mode setting or, for builds that must not run without it, by the
+// "fips" build tag:
+//
+//	go build -tags fips
+//
+// TLS is then limited to TLS 1.2 with ECDHE and AES-GCM cipher suites over
+// the P-256 and P-384 curves, the suites Go's TLS 1.3 stack does not let a
+// program restrict. Pair the build with a FIPS-validated Go toolchain
+// (GOEXPERIMENT=boringcrypto) where the crypto module itself must be validated.
+package fips
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+)
+
+// cipherSuites are the approved TLS 1.2 cipher suites
+var cipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// curves are the approved key exchange curves
+var curves = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+
+var (
+	enabled bool
+	mutex   sync.RWMutex
+)
+
+// Enable restricts cryptography to approved algorithms from now on,
+// including HTTP clients using the default transport. It cannot be undone.
+func Enable() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	
+	enabled = true
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		restrict(transport.TLSClientConfig)
+	}
+}
+
+// Enabled reports whether FIPS mode is on
+func Enabled() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return enabled
+}
+
+// BuildEnforced reports whether the binary was built with the fips tag
+func BuildEnforced() bool {
+	return buildEnabled
+}
+
+// Apply restricts a TLS configuration to approved algorithms when FIPS
+// mode is on and returns it
+func Apply(config *tls.Config) *tls.Config {
+	if Enabled() {
+		restrict(config)
+	}
+	return config
+}
+
+// restrict limits a TLS configuration to approved algorithms
+func restrict(config *tls.Config) {
+	config.MinVersion = tls.VersionTLS12
+	config.MaxVersion = tls.VersionTLS12
+	config.CipherSuites = cipherSuites
+	config.CurvePreferences = curves
+}
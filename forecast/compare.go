@@ -0,0 +1,101 @@
+package forecast
+
+import (
+	"sort"
+
+	"syslog-analyzer/models"
+)
+
+// Compare totals each source's recorded ingest in two periods. Sources
+// recorded in either period are included, deleted ones too, so the totals
+// match the bill; the current period may include the running day.
+func (h *History) Compare(current, baseline models.IngestPeriod) models.IngestComparison {
+	tenants := make(map[string]string)
+	if h.metricsFunc != nil {
+		sources, _ := h.metricsFunc()
+		for _, source := range sources {
+			tenants[source.Name] = source.Tenant
+		}
+	}
+	
+	h.mutex.Lock()
+	dates := h.datesLocked()
+	currentBytes := h.totalsLocked(dates, current)
+	baselineBytes := h.totalsLocked(dates, baseline)
+	h.mutex.Unlock()
+	
+	comparison := models.IngestComparison{
+		Current:  current,
+		Baseline: baseline,
+		Sources:  []models.SourceComparison{},
+	}
+	if len(dates) > 0 {
+		comparison.RecordedFrom = dates[0]
+	}
+	
+	names := make(map[string]bool)
+	for name := range currentBytes {
+		names[name] = true
+	}
+	for name := range baselineBytes {
+		names[name] = true
+	}
+	for name := range names {
+		source := models.SourceComparison{
+			Name:       name,
+			Tenant:     tenants[name],
+			CurrentGB:  float64(currentBytes[name]) / bytesPerGB,
+			BaselineGB: float64(baselineBytes[name]) / bytesPerGB,
+		}
+		comparison.Sources = append(comparison.Sources, source)
+	}
+	
+	Summarize(&comparison)
+	return comparison
+}
+
+// Summarize derives the changes and totals of a comparison from its sources
+// and orders the sources by growth, largest first
+func Summarize(comparison *models.IngestComparison) {
+	comparison.CurrentGB = 0
+	comparison.BaselineGB = 0
+	for i := range comparison.Sources {
+		source := &comparison.Sources[i]
+		source.ChangeGB, source.ChangePercent, source.New = change(source.CurrentGB, source.BaselineGB)
+		comparison.CurrentGB += source.CurrentGB
+		comparison.BaselineGB += source.BaselineGB
+	}
+	comparison.ChangeGB, comparison.ChangePercent, _ = change(comparison.CurrentGB, comparison.BaselineGB)
+	
+	sort.Slice(comparison.Sources, func(i, j int) bool {
+		a, b := comparison.Sources[i], comparison.Sources[j]
+		if a.ChangeGB != b.ChangeGB {
+			return a.ChangeGB > b.ChangeGB
+		}
+		return a.Name < b.Name
+	})
+}
+
+// change returns the growth from baseline to current and whether there was
+// no baseline to grow from
+func change(current, baseline float64) (float64, float64, bool) {
+	if baseline <= 0 {
+		return current, 0, current > 0
+	}
+	return current - baseline, (current - baseline) / baseline * 100, false
+}
+
+// totalsLocked sums each source's bytes over the days of a period; callers
+// hold the mutex
+func (h *History) totalsLocked(dates []string, period models.IngestPeriod) map[string]int64 {
+	totals := make(map[string]int64)
+	for _, date := range dates {
+		if date < period.From || date > period.To {
+			continue
+		}
+		for name, bytes := range h.data.Days[date] {
+			totals[name] += bytes
+		}
+	}
+	return totals
+}
@@ -0,0 +1,245 @@
+package forecast
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// historyFile is the persisted form of the history
+type historyFile struct {
+	Days map[string]map[string]int64 `json:"days"` // Date (YYYY-MM-DD) -> source -> event bytes
+}
+
+// History records the event bytes each source ingests per local day, the
+// input of the capacity forecast
+type History struct {
+	config      models.ForecastConfig
+	metricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)
+	data        historyFile
+	last        map[string]int64 // Event byte counter of each source at the last sample
+	mutex       sync.Mutex
+	stopChan    chan bool
+}
+
+// NewHistory creates a history and loads previously recorded days
+func NewHistory(config models.ForecastConfig, metricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)) (*History, error) {
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
+	
+	h := &History{
+		config:      config,
+		metricsFunc: metricsFunc,
+		data:        historyFile{Days: make(map[string]map[string]int64)},
+		last:        make(map[string]int64),
+		stopChan:    make(chan bool),
+	}
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+	
+	return h, nil
+}
+
+// Validate checks a forecast configuration
+func Validate(config models.ForecastConfig) error {
+	if err := validateModel(config.Model); err != nil {
+		return err
+	}
+	if config.HistoryDays < 1 {
+		return fmt.Errorf("invalid forecast history days %d (expected at least 1)", config.HistoryDays)
+	}
+	if config.HorizonDays < 1 {
+		return fmt.Errorf("invalid forecast horizon days %d (expected at least 1)", config.HorizonDays)
+	}
+	if config.LicenseGBPerDay < 0 || config.DiskLimitGB < 0 {
+		return fmt.Errorf("forecast limits cannot be negative")
+	}
+	if config.DiskLimitGB > 0 && config.DiskRetentionDays < 1 {
+		return fmt.Errorf("invalid forecast disk retention days %d (expected at least 1)", config.DiskRetentionDays)
+	}
+	return nil
+}
+
+// validateModel checks a forecast model name; empty selects linear
+func validateModel(model string) error {
+	switch model {
+	case "", models.ForecastLinear, models.ForecastSeasonal:
+		return nil
+	}
+	return fmt.Errorf("unknown forecast model %q (expected %q or %q)", model, models.ForecastLinear, models.ForecastSeasonal)
+}
+
+// Start begins sampling metrics
+func (h *History) Start() {
+	go h.run()
+}
+
+// Stop stops sampling and persists the history
+func (h *History) Stop() {
+	close(h.stopChan)
+	
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if err := h.save(); err != nil {
+		log.Printf("✗ Failed to save forecast history: %v", err)
+	}
+}
+
+// run samples once a minute
+func (h *History) run() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	
+	h.sample(time.Now())
+	
+	for {
+		select {
+		case <-h.stopChan:
+			return
+		case now := <-ticker.C:
+			h.sample(now)
+		}
+	}
+}
+
+// sample attributes the traffic since the previous sample to the current day
+func (h *History) sample(now time.Time) {
+	if h.metricsFunc == nil {
+		return
+	}
+	sources, _ := h.metricsFunc()
+	
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	
+	date := now.Format("2006-01-02")
+	day, exists := h.data.Days[date]
+	if !exists {
+		day = make(map[string]int64)
+		h.data.Days[date] = day
+		h.prune()
+	}
+	
+	for _, source := range sources {
+		// A source's counters start from zero again when it is restarted
+		previous := h.last[source.Name]
+		if source.TotalEventBytes < previous {
+			previous = 0
+		}
+		day[source.Name] += source.TotalEventBytes - previous
+		h.last[source.Name] = source.TotalEventBytes
+	}
+	
+	if err := h.save(); err != nil {
+		log.Printf("✗ Failed to save forecast history: %v", err)
+	}
+}
+
+// prune drops days beyond the retention window, keeping the current day;
+// callers hold the mutex
+func (h *History) prune() {
+	dates := h.datesLocked()
+	for len(dates) > h.config.HistoryDays+1 {
+		delete(h.data.Days, dates[0])
+		dates = dates[1:]
+	}
+}
+
+func (h *History) datesLocked() []string {
+	dates := make([]string, 0, len(h.data.Days))
+	for date := range h.data.Days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+// DailyAverage returns each source's average GB over the recorded complete
+// days among the last days before now
+func (h *History) DailyAverage(now time.Time, days int) map[string]float64 {
+	today := now.Format("2006-01-02")
+	first := now.AddDate(0, 0, -days).Format("2006-01-02")
+	
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	
+	totals := make(map[string]int64)
+	counts := make(map[string]int)
+	for date, day := range h.data.Days {
+		if date < first || date >= today {
+			continue
+		}
+		for name, bytes := range day {
+			totals[name] += bytes
+			counts[name]++
+		}
+	}
+	
+	averages := make(map[string]float64, len(totals))
+	for name, bytes := range totals {
+		averages[name] = float64(bytes) / bytesPerGB / float64(counts[name])
+	}
+	return averages
+}
+
+// snapshot copies the recorded days before a given date, oldest first
+func (h *History) snapshot(before string) ([]string, map[string]map[string]int64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	
+	var dates []string
+	days := make(map[string]map[string]int64)
+	for _, date := range h.datesLocked() {
+		if date >= before {
+			break
+		}
+		day := make(map[string]int64, len(h.data.Days[date]))
+		for name, bytes := range h.data.Days[date] {
+			day[name] = bytes
+		}
+		dates = append(dates, date)
+		days[date] = day
+	}
+	return dates, days
+}
+
+// load reads the persisted history if present
+func (h *History) load() error {
+	data, err := ioutil.ReadFile(h.config.DataFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read forecast history: %v", err)
+	}
+	if err := json.Unmarshal(data, &h.data); err != nil {
+		return fmt.Errorf("failed to parse forecast history: %v", err)
+	}
+	if h.data.Days == nil {
+		h.data.Days = make(map[string]map[string]int64)
+	}
+	return nil
+}
+
+// save writes the history to disk; callers hold the mutex
+func (h *History) save() error {
+	data, err := json.MarshalIndent(h.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal forecast history: %v", err)
+	}
+	
+	tmpFile := h.config.DataFile + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write forecast history: %v", err)
+	}
+	return os.Rename(tmpFile, h.config.DataFile)
+}
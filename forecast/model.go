@@ -0,0 +1,288 @@
+package forecast
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Series kinds and limit kinds of a report
+const (
+	KindSource = "source"
+	KindQuota  = "quota"
+	KindGlobal = "global"
+	
+	LimitDaily  = "daily"
+	LimitStored = "stored"
+)
+
+// minSeasonalDays is the history needed to estimate day-of-week factors;
+// shorter histories use the linear trend alone
+const minSeasonalDays = 14
+
+// bytesPerGB matches the GB reported elsewhere in the dashboard
+const bytesPerGB = 1024 * 1024 * 1024
+
+// Report projects the daily ingest of each current source, each quota with a
+// daily limit and all sources together. Only complete days are used, so the
+// projection starts with today. Sources are compared against the quotas
+// covering them, which a source may reach on its own.
+func (h *History) Report(now time.Time, model string, quotas []models.QuotaConfig) (models.ForecastReport, error) {
+	if model == "" {
+		model = h.config.Model
+	}
+	if err := validateModel(model); err != nil {
+		return models.ForecastReport{}, err
+	}
+	if model == "" {
+		model = models.ForecastLinear
+	}
+	
+	today := now.Format("2006-01-02")
+	dates, days := h.snapshot(today)
+	report := models.ForecastReport{
+		GeneratedAt: now,
+		Model:       model,
+		HorizonDays: h.config.HorizonDays,
+		Series:      []models.ForecastSeries{},
+	}
+	
+	var sources []models.SourceMetrics
+	if h.metricsFunc != nil {
+		sources, _ = h.metricsFunc()
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Name < sources[j].Name
+	})
+	
+	for _, source := range sources {
+		name := source.Name
+		series := h.project(KindSource, name, source.Tenant, dates, days, today, model, func(source string) bool {
+			return source == name
+		})
+		if series == nil {
+			continue
+		}
+		for _, quota := range quotas {
+			if quota.MaxGBPerDay > 0 && quotaApplies(quota, source.Tenant, source.Group) {
+				series.Limits = append(series.Limits, dailyLimit(quota.Name, quota.MaxGBPerDay, series))
+			}
+		}
+		report.Series = append(report.Series, *series)
+	}
+	
+	for _, quota := range quotas {
+		if quota.MaxGBPerDay <= 0 {
+			continue
+		}
+		covered := make(map[string]bool)
+		for _, source := range sources {
+			if quotaApplies(quota, source.Tenant, source.Group) {
+				covered[source.Name] = true
+			}
+		}
+		series := h.project(KindQuota, quota.Name, quota.Tenant, dates, days, today, model, func(source string) bool {
+			return covered[source]
+		})
+		if series == nil {
+			continue
+		}
+		series.Limits = append(series.Limits, dailyLimit(quota.Name, quota.MaxGBPerDay, series))
+		report.Series = append(report.Series, *series)
+	}
+	
+	// All recorded sources count towards the license and disk, deleted ones included
+	global := h.project(KindGlobal, "All sources", "", dates, days, today, model, func(string) bool {
+		return true
+	})
+	if global != nil {
+		if h.config.LicenseGBPerDay > 0 {
+			global.Limits = append(global.Limits, dailyLimit("license", h.config.LicenseGBPerDay, global))
+		}
+		if h.config.DiskLimitGB > 0 {
+			global.Limits = append(global.Limits, storedLimit("disk", h.config.DiskLimitGB, h.config.DiskRetentionDays, global))
+		}
+		report.Series = append(report.Series, *global)
+	}
+	
+	return report, nil
+}
+
+// quotaApplies reports whether a quota covers a source, as the quota manager does
+func quotaApplies(quota models.QuotaConfig, tenant, group string) bool {
+	if quota.Tenant != "" {
+		return quota.Tenant == tenant
+	}
+	return quota.Group != "" && quota.Group == group
+}
+
+// project builds the daily history of the sources selected by include, from
+// the first day any of them was recorded, and projects it over the horizon.
+// It returns nil when none of them were recorded.
+func (h *History) project(kind, name, tenant string, dates []string, days map[string]map[string]int64, today, model string, include func(source string) bool) *models.ForecastSeries {
+	series := &models.ForecastSeries{
+		Kind:       kind,
+		Name:       name,
+		Tenant:     tenant,
+		History:    []models.ForecastPoint{},
+		Projection: []models.ForecastPoint{},
+		Limits:     []models.ForecastLimit{},
+	}
+	
+	// Days without a record after the first are zero rather than missing
+	var start time.Time
+	totals := make(map[string]int64)
+	for _, date := range dates {
+		for source, bytes := range days[date] {
+			if include(source) {
+				if start.IsZero() {
+					start, _ = time.ParseInLocation("2006-01-02", date, time.Local)
+				}
+				totals[date] += bytes
+			}
+		}
+	}
+	if start.IsZero() {
+		return nil
+	}
+	
+	var values []float64
+	var weekdays []time.Weekday
+	for day := start; day.Format("2006-01-02") < today; day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		gb := float64(totals[date]) / bytesPerGB
+		series.History = append(series.History, models.ForecastPoint{Date: date, GB: gb})
+		values = append(values, gb)
+		weekdays = append(weekdays, day.Weekday())
+	}
+	
+	trend := fit(values, weekdays, model)
+	series.SlopeGBPerDay = trend.slope
+	first, _ := time.ParseInLocation("2006-01-02", today, time.Local)
+	for i := 0; i < h.config.HorizonDays; i++ {
+		day := first.AddDate(0, 0, i)
+		gb := math.Max(0, trend.at(len(values)+i, day.Weekday()))
+		series.Projection = append(series.Projection, models.ForecastPoint{Date: day.Format("2006-01-02"), GB: gb})
+	}
+	
+	return series
+}
+
+// trend is a fitted daily ingest model
+type trend struct {
+	intercept float64
+	slope     float64
+	factors   [7]float64 // Day-of-week multipliers, all 1 for the linear model
+}
+
+// at evaluates the trend on the day with the given history index
+func (t trend) at(index int, weekday time.Weekday) float64 {
+	return (t.intercept + t.slope*float64(index)) * t.factors[weekday]
+}
+
+// fit fits a least-squares line through the daily values and, for the
+// seasonal model, the average ratio of each weekday's values to the line
+func fit(values []float64, weekdays []time.Weekday, model string) trend {
+	t := trend{factors: [7]float64{1, 1, 1, 1, 1, 1, 1}}
+	n := float64(len(values))
+	if len(values) == 0 {
+		return t
+	}
+	
+	var sumX, sumY, sumXY, sumXX float64
+	for i, value := range values {
+		x := float64(i)
+		sumX += x
+		sumY += value
+		sumXY += x * value
+		sumXX += x * x
+	}
+	if denominator := n*sumXX - sumX*sumX; denominator != 0 {
+		t.slope = (n*sumXY - sumX*sumY) / denominator
+	}
+	t.intercept = (sumY - t.slope*sumX) / n
+	
+	if model != models.ForecastSeasonal || len(values) < minSeasonalDays {
+		return t
+	}
+	
+	var ratios, counts [7]float64
+	for i, value := range values {
+		if base := t.intercept + t.slope*float64(i); base > 0 {
+			ratios[weekdays[i]] += value / base
+			counts[weekdays[i]]++
+		}
+	}
+	// Normalise so a week of factors averages one and the trend keeps its level
+	var sum, present float64
+	for day := range ratios {
+		if counts[day] > 0 {
+			ratios[day] /= counts[day]
+			sum += ratios[day]
+			present++
+		}
+	}
+	if sum <= 0 {
+		return t
+	}
+	for day := range ratios {
+		if counts[day] > 0 {
+			t.factors[day] = ratios[day] * present / sum
+		}
+	}
+	return t
+}
+
+// dailyLimit finds when a series' daily ingest reaches a limit
+func dailyLimit(name string, limitGB float64, series *models.ForecastSeries) models.ForecastLimit {
+	limit := models.ForecastLimit{Name: name, Kind: LimitDaily, LimitGB: limitGB, DaysLeft: -1}
+	if count := len(series.History); count > 0 {
+		limit.Exceeded = series.History[count-1].GB >= limitGB
+	}
+	for i, point := range series.Projection {
+		if point.GB >= limitGB {
+			limit.ReachedOn = point.Date
+			limit.DaysLeft = i
+			break
+		}
+	}
+	return limit
+}
+
+// storedLimit finds when the ingest held over the retention window, recorded
+// days followed by projected ones, reaches a limit
+func storedLimit(name string, limitGB float64, retentionDays int, series *models.ForecastSeries) models.ForecastLimit {
+	limit := models.ForecastLimit{Name: name, Kind: LimitStored, LimitGB: limitGB, DaysLeft: -1}
+	
+	values := make([]float64, 0, len(series.History)+len(series.Projection))
+	for _, point := range series.History {
+		values = append(values, point.GB)
+	}
+	stored := func(end int) float64 {
+		var sum float64
+		for i := end - retentionDays + 1; i <= end; i++ {
+			if i >= 0 {
+				sum += values[i]
+			}
+		}
+		return sum
+	}
+	
+	recorded := len(values)
+	if recorded > 0 {
+		limit.Exceeded = stored(recorded-1) >= limitGB
+	}
+	for _, point := range series.Projection {
+		values = append(values, point.GB)
+	}
+	for i := range series.Projection {
+		if stored(recorded+i) >= limitGB {
+			limit.ReachedOn = series.Projection[i].Date
+			limit.DaysLeft = i
+			break
+		}
+	}
+	return limit
+}
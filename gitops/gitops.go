@@ -0,0 +1,327 @@
+// Package gitops keeps the analyzer's sources in line with a configuration
+// file kept in a Git repository or served over HTTP. Each revision must carry
+// a detached Ed25519 signature so a compromised repository or server cannot
+// reconfigure the analyzer.
+package gitops
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Sync defaults
+const (
+	defaultBranch      = "main"
+	defaultPath        = "sources.json"
+	defaultCheckoutDir = "gitops_checkout"
+	defaultInterval    = 60
+	fetchTimeout       = 2 * time.Minute
+	maxDocumentSize    = 16 << 20
+)
+
+// Document is the desired configuration a revision declares
+type Document struct {
+	Sources []models.SourceConfig `json:"sources"`
+}
+
+// ApplyFunc brings the running configuration in line with a verified
+// document and reports what it changed
+type ApplyFunc func(doc Document) (models.GitOpsChanges, error)
+
+// Syncer periodically fetches, verifies and applies the configuration
+type Syncer struct {
+	config    models.GitOpsConfig
+	publicKey ed25519.PublicKey
+	apply     ApplyFunc
+	client    *http.Client
+	status    models.GitOpsStatus
+	mutex     sync.Mutex // Guards status and isRunning
+	syncMutex sync.Mutex // Serializes syncs
+	stopChan  chan bool
+	done      chan bool // Closed when the sync loop exits
+	isRunning bool
+}
+
+// NewSyncer creates a syncer for config, validating it
+func NewSyncer(config models.GitOpsConfig, apply ApplyFunc) (*Syncer, error) {
+	if (config.Repository == "") == (config.URL == "") {
+		return nil, fmt.Errorf("exactly one of repository and url is required")
+	}
+	if config.Branch == "" {
+		config.Branch = defaultBranch
+	}
+	if config.Path == "" {
+		config.Path = defaultPath
+	}
+	if config.CheckoutDir == "" {
+		config.CheckoutDir = defaultCheckoutDir
+	}
+	if config.IntervalSeconds <= 0 {
+		config.IntervalSeconds = defaultInterval
+	}
+	
+	var publicKey ed25519.PublicKey
+	if config.PublicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(config.PublicKey))
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("public_key must be a base64 Ed25519 public key")
+		}
+		publicKey = key
+	} else if !config.AllowUnsigned {
+		return nil, fmt.Errorf("public_key is required unless allow_unsigned is set")
+	}
+	
+	location := config.URL
+	if config.Repository != "" {
+		location = config.Repository + "#" + config.Branch + ":" + config.Path
+	}
+	
+	return &Syncer{
+		config:    config,
+		publicKey: publicKey,
+		apply:     apply,
+		client: &http.Client{
+			Timeout: fetchTimeout,
+		},
+		status:   models.GitOpsStatus{Enabled: true, Location: location},
+		stopChan: make(chan bool),
+		done:     make(chan bool),
+	}, nil
+}
+
+// Start syncs immediately and then on the configured interval
+func (s *Syncer) Start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	if s.isRunning {
+		return
+	}
+	s.isRunning = true
+	go s.run()
+	
+	log.Printf("✓ GitOps sync started (%s every %ds)", s.status.Location, s.config.IntervalSeconds)
+}
+
+// Stop stops periodic syncing, waiting for a sync in progress to finish
+func (s *Syncer) Stop() {
+	s.mutex.Lock()
+	if !s.isRunning {
+		s.mutex.Unlock()
+		return
+	}
+	s.isRunning = false
+	close(s.stopChan)
+	s.mutex.Unlock()
+	
+	<-s.done
+}
+
+// run syncs until stopped
+func (s *Syncer) run() {
+	defer close(s.done)
+	
+	s.Sync()
+	
+	ticker := time.NewTicker(time.Duration(s.config.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.Sync()
+		}
+	}
+}
+
+// Status returns the current sync status
+func (s *Syncer) Status() models.GitOpsStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.status
+}
+
+// Sync fetches the current revision, verifies its signature and applies it.
+// An unverifiable or invalid revision leaves the running configuration as is.
+func (s *Syncer) Sync() error {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+	
+	revision, changes, err := s.sync()
+	
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	s.status.LastAttempt = time.Now()
+	if err != nil {
+		s.status.InSync = false
+		s.status.LastError = err.Error()
+		log.Printf("✗ GitOps sync from %s failed: %v", s.status.Location, err)
+		return err
+	}
+	
+	s.status.InSync = true
+	s.status.LastError = ""
+	s.status.LastSuccess = s.status.LastAttempt
+	s.status.Revision = revision
+	s.status.Verified = s.publicKey != nil
+	if len(changes.Added)+len(changes.Updated)+len(changes.Removed) > 0 {
+		s.status.LastChanges = changes
+		log.Printf("✓ GitOps applied revision %s: %d added, %d updated, %d removed", revision, len(changes.Added), len(changes.Updated), len(changes.Removed))
+	}
+	return nil
+}
+
+// sync runs one fetch, verify and apply cycle
+func (s *Syncer) sync() (string, models.GitOpsChanges, error) {
+	var changes models.GitOpsChanges
+	
+	data, signature, revision, err := s.fetch()
+	if err != nil {
+		return "", changes, err
+	}
+	
+	if s.publicKey != nil {
+		if err := verify(s.publicKey, data, signature); err != nil {
+			return "", changes, err
+		}
+	}
+	
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", changes, fmt.Errorf("invalid configuration file: %v", err)
+	}
+	
+	changes, err = s.apply(doc)
+	return revision, changes, err
+}
+
+// fetch returns the configuration file, its signature, if any, and its revision
+func (s *Syncer) fetch() ([]byte, []byte, string, error) {
+	if s.config.URL != "" {
+		data, err := s.get(s.config.URL)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		var signature []byte
+		if s.publicKey != nil {
+			if signature, err = s.get(s.config.URL + ".sig"); err != nil {
+				return nil, nil, "", fmt.Errorf("signature: %v", err)
+			}
+		}
+		sum := sha256.Sum256(data)
+		return data, signature, "sha256:" + hex.EncodeToString(sum[:6]), nil
+	}
+	
+	revision, err := s.pull()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	file := filepath.Join(s.config.CheckoutDir, filepath.FromSlash(s.config.Path))
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("revision %s: %v", revision, err)
+	}
+	var signature []byte
+	if s.publicKey != nil {
+		if signature, err = ioutil.ReadFile(file + ".sig"); err != nil {
+			return nil, nil, "", fmt.Errorf("revision %s has no signature: %v", revision, err)
+		}
+	}
+	return data, signature, revision, nil
+}
+
+// get downloads a URL
+func (s *Syncer) get(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.BearerToken)
+	}
+	
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxDocumentSize+1))
+	if err == nil && len(data) > maxDocumentSize {
+		return nil, fmt.Errorf("GET %s: larger than %d bytes", url, maxDocumentSize)
+	}
+	return data, err
+}
+
+// pull brings the checkout to the head of the branch and returns its commit
+func (s *Syncer) pull() (string, error) {
+	dir := s.config.CheckoutDir
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Clean(dir)), 0755); err != nil {
+			return "", err
+		}
+		if _, err := git("", "clone", "--quiet", "--depth", "1", "--branch", s.config.Branch, s.config.Repository, dir); err != nil {
+			return "", err
+		}
+	} else {
+		if _, err := git(dir, "fetch", "--quiet", "--depth", "1", "origin", s.config.Branch); err != nil {
+			return "", err
+		}
+		if _, err := git(dir, "reset", "--quiet", "--hard", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+	}
+	
+	commit, err := git(dir, "rev-parse", "HEAD")
+	return strings.TrimSpace(commit), err
+}
+
+// git runs a git command in dir and returns its output
+func git(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// verify checks a base64 detached Ed25519 signature of data
+func verify(publicKey ed25519.PublicKey, data, signature []byte) error {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || len(decoded) != ed25519.SignatureSize {
+		return fmt.Errorf("signature is not a base64 Ed25519 signature")
+	}
+	if !ed25519.Verify(publicKey, data, decoded) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
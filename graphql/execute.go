@@ -0,0 +1,403 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Request is a GraphQL request as posted by clients
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is the result of a request. Data is absent when the query could
+// not be executed at all; fields that failed are null and listed in Errors.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// Error is an error of a request, with the path of the field that failed
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Resolver returns the value of a field given its arguments. Values are
+// projected onto the selected sub-fields through their JSON encoding, so
+// any JSON-encodable value works; Object and []Object add nested resolvers.
+type Resolver func(args Args) (interface{}, error)
+
+// Object is a value with fields of its own resolved on demand, for fields
+// too expensive to compute unless selected
+type Object struct {
+	Value  interface{}
+	Fields map[string]Resolver
+}
+
+// Schema is the set of root query fields
+type Schema struct {
+	Query map[string]Resolver
+}
+
+// Args are the arguments of a field
+type Args map[string]interface{}
+
+// String returns a string argument, or def when it is absent or null
+func (a Args) String(name, def string) (string, error) {
+	raw, ok := a[name]
+	if !ok || raw == nil {
+		return def, nil
+	}
+	s, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %s must be a string", name)
+	}
+	return s, nil
+}
+
+// Int returns an integer argument, or def when it is absent or null
+func (a Args) Int(name string, def int64) (int64, error) {
+	switch raw := a[name].(type) {
+	case nil:
+		return def, nil
+	case int64:
+		return raw, nil
+	case float64:
+		if raw == math.Trunc(raw) {
+			return int64(raw), nil
+		}
+	case json.Number:
+		if n, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("argument %s must be an integer", name)
+}
+
+// Execute runs the request's query against the schema
+func (s *Schema) Execute(req Request) Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	
+	e := &executor{doc: doc, op: op, variables: req.Variables}
+	fields, err := e.collect(op.selections, map[string]bool{})
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	
+	data := &orderedMap{values: make(map[string]interface{})}
+	for _, f := range fields {
+		path := []interface{}{f.alias}
+		resolve, ok := s.Query[f.name]
+		if !ok {
+			e.fail(path, fmt.Errorf("unknown field %q", f.name))
+			data.set(f.alias, nil)
+			continue
+		}
+		data.set(f.alias, e.resolve(resolve, f, path))
+	}
+	return Response{Data: data, Errors: e.errors}
+}
+
+// operation picks the operation to run
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("operationName is required for documents with several operations")
+		}
+		return &d.operations[0], nil
+	}
+	for i := range d.operations {
+		if d.operations[i].name == name {
+			return &d.operations[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// executor holds the state of one request's execution
+type executor struct {
+	doc       *document
+	op        *operation
+	variables map[string]interface{}
+	errors    []Error
+}
+
+// fail records a field error
+func (e *executor) fail(path []interface{}, err error) {
+	e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+}
+
+// collect flattens fragments and directives into the fields to resolve,
+// merging the sub-selections of fields selected more than once under the
+// same response name
+func (e *executor) collect(selections []selection, visiting map[string]bool) ([]*field, error) {
+	var fields []*field
+	byAlias := make(map[string]*field)
+	add := func(f *field) {
+		if existing, ok := byAlias[f.alias]; ok {
+			existing.selections = append(existing.selections, f.selections...)
+			return
+		}
+		merged := *f
+		merged.selections = append([]selection(nil), f.selections...)
+		byAlias[f.alias] = &merged
+		fields = append(fields, &merged)
+	}
+	
+	for _, sel := range selections {
+		included, err := e.included(sel.directives)
+		if err != nil {
+			return nil, err
+		}
+		if !included {
+			continue
+		}
+		
+		var nested []selection
+		switch {
+		case sel.field != nil:
+			add(sel.field)
+			continue
+		case sel.fragment != "":
+			fragment, ok := e.doc.fragments[sel.fragment]
+			if !ok {
+				return nil, fmt.Errorf("unknown fragment %q", sel.fragment)
+			}
+			if visiting[sel.fragment] {
+				return nil, fmt.Errorf("fragment %q spreads itself", sel.fragment)
+			}
+			nested = fragment
+		default:
+			nested = sel.inline
+		}
+		
+		visiting[sel.fragment] = true
+		spread, err := e.collect(nested, visiting)
+		delete(visiting, sel.fragment)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range spread {
+			add(f)
+		}
+	}
+	return fields, nil
+}
+
+// included evaluates @include(if:) and @skip(if:)
+func (e *executor) included(directives []directive) (bool, error) {
+	for _, d := range directives {
+		raw, err := e.value(d.args["if"])
+		if err != nil {
+			return false, err
+		}
+		condition, ok := raw.(bool)
+		if !ok {
+			return false, fmt.Errorf("@%s requires a boolean if argument", d.name)
+		}
+		if condition == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// value resolves variables in an argument value
+func (e *executor) value(v value) (interface{}, error) {
+	if v.variable != "" {
+		if raw, ok := e.variables[v.variable]; ok {
+			return raw, nil
+		}
+		def, declared := e.op.variables[v.variable]
+		if !declared {
+			return nil, fmt.Errorf("variable $%s is not declared", v.variable)
+		}
+		return e.value(def)
+	}
+	if list, ok := v.literal.([]value); ok {
+		values := make([]interface{}, len(list))
+		for i, element := range list {
+			resolved, err := e.value(element)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = resolved
+		}
+		return values, nil
+	}
+	return v.literal, nil
+}
+
+// resolve calls a field's resolver and projects the result onto the
+// field's selections
+func (e *executor) resolve(resolve Resolver, f *field, path []interface{}) interface{} {
+	args := make(Args, len(f.args))
+	for name, v := range f.args {
+		resolved, err := e.value(v)
+		if err != nil {
+			e.fail(path, err)
+			return nil
+		}
+		args[name] = resolved
+	}
+	
+	result, err := resolve(args)
+	if err != nil {
+		e.fail(path, err)
+		return nil
+	}
+	return e.project(result, f, path)
+}
+
+// project shapes a resolved value to the selections of f
+func (e *executor) project(result interface{}, f *field, path []interface{}) interface{} {
+	switch typed := result.(type) {
+	case Object:
+		return e.projectObject(typed, f, path)
+	case []Object:
+		list := make([]interface{}, len(typed))
+		for i, object := range typed {
+			list[i] = e.projectObject(object, f, appendPath(path, i))
+		}
+		return list
+	}
+	
+	normalized, err := normalize(result)
+	if err != nil {
+		e.fail(path, err)
+		return nil
+	}
+	return e.projectValue(normalized, f, path)
+}
+
+// projectObject projects an Object, resolving selected nested fields
+func (e *executor) projectObject(object Object, f *field, path []interface{}) interface{} {
+	if len(f.selections) == 0 {
+		e.fail(path, fmt.Errorf("field %q must have a selection of subfields", f.alias))
+		return nil
+	}
+	
+	normalized, err := normalize(object.Value)
+	if err != nil {
+		e.fail(path, err)
+		return nil
+	}
+	values, _ := normalized.(map[string]interface{})
+	
+	fields, err := e.collect(f.selections, map[string]bool{})
+	if err != nil {
+		e.fail(path, err)
+		return nil
+	}
+	projected := &orderedMap{values: make(map[string]interface{})}
+	for _, sub := range fields {
+		subPath := appendPath(path, sub.alias)
+		if resolve, ok := object.Fields[sub.name]; ok {
+			projected.set(sub.alias, e.resolve(resolve, sub, subPath))
+			continue
+		}
+		value, ok := values[sub.name]
+		if !ok {
+			e.fail(subPath, fmt.Errorf("unknown field %q", sub.name))
+			projected.set(sub.alias, nil)
+			continue
+		}
+		projected.set(sub.alias, e.projectValue(value, sub, subPath))
+	}
+	return projected
+}
+
+// projectValue projects a normalized JSON value
+func (e *executor) projectValue(value interface{}, f *field, path []interface{}) interface{} {
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		list := make([]interface{}, len(typed))
+		for i, element := range typed {
+			list[i] = e.projectValue(element, f, appendPath(path, i))
+		}
+		return list
+	case map[string]interface{}:
+		return e.projectObject(Object{Value: typed}, f, path)
+	}
+	
+	if len(f.selections) > 0 {
+		e.fail(path, fmt.Errorf("field %q is a scalar and has no subfields", f.alias))
+		return nil
+	}
+	return value
+}
+
+// normalize converts a value to its generic JSON form, keeping numbers exact
+func normalize(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var normalized interface{}
+	if err := decoder.Decode(&normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// appendPath returns path extended by element without sharing its backing array
+func appendPath(path []interface{}, element interface{}) []interface{} {
+	extended := make([]interface{}, len(path), len(path)+1)
+	copy(extended, path)
+	return append(extended, element)
+}
+
+// orderedMap is a JSON object that keeps fields in selection order
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// MarshalJSON encodes the map with its keys in insertion order
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, _ := json.Marshal(key)
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		encodedValue, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,121 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind classifies a token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenName
+	tokenString
+	tokenNumber
+	tokenPunct
+)
+
+// token is a lexical token of a query
+type token struct {
+	kind   tokenKind
+	text   string // Unquoted for strings
+	offset int
+}
+
+// lex splits a query into tokens. Commas, whitespace and comments are
+// insignificant in GraphQL and are dropped.
+func lex(query string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(query[i:], "..."):
+			tokens = append(tokens, token{kind: tokenPunct, text: "...", offset: i})
+			i += 3
+		case strings.IndexByte("{}()[]:$!=@", c) >= 0:
+			tokens = append(tokens, token{kind: tokenPunct, text: string(c), offset: i})
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(query) && (query[i] == '_' || isLetter(query[i]) || isDigit(query[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenName, text: query[start:i], offset: start})
+		case c == '-' || isDigit(c):
+			start := i
+			i++
+			for i < len(query) && (isDigit(query[i]) || strings.IndexByte(".eE+-", query[i]) >= 0) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: query[start:i], offset: start})
+		case c == '"':
+			text, end, err := lexString(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, offset: i})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, offset: len(query)}), nil
+}
+
+// lexString reads the quoted string starting at start and returns its
+// unescaped text and the offset after the closing quote
+func lexString(query string, start int) (string, int, error) {
+	var text strings.Builder
+	for i := start + 1; i < len(query); i++ {
+		switch c := query[i]; c {
+		case '"':
+			return text.String(), i + 1, nil
+		case '\n':
+			return "", 0, fmt.Errorf("unterminated string at offset %d", start)
+		case '\\':
+			i++
+			if i >= len(query) {
+				break
+			}
+			switch query[i] {
+			case 'n':
+				text.WriteByte('\n')
+			case 't':
+				text.WriteByte('\t')
+			case 'r':
+				text.WriteByte('\r')
+			case 'u':
+				var r rune
+				if i+4 >= len(query) {
+					return "", 0, fmt.Errorf("invalid escape at offset %d", i)
+				}
+				if _, err := fmt.Sscanf(query[i+1:i+5], "%04x", &r); err != nil {
+					return "", 0, fmt.Errorf("invalid escape at offset %d", i)
+				}
+				text.WriteRune(r)
+				i += 4
+			default:
+				text.WriteByte(query[i])
+			}
+		default:
+			text.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,380 @@
+// Package graphql executes read-only GraphQL queries over the analyzer's
+// data. It implements the query subset integrators need: fields, aliases,
+// arguments, variables, fragments and the @include/@skip directives.
+// Mutations, subscriptions and introspection are not supported.
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// field is a selected field
+type field struct {
+	alias      string
+	name       string
+	args       map[string]value
+	selections []selection
+}
+
+// selection is a field, a fragment spread or an inline fragment
+type selection struct {
+	field      *field
+	fragment   string      // Name of a spread fragment
+	inline     []selection // Selections of an inline fragment
+	directives []directive
+}
+
+// directive is an @include or @skip directive
+type directive struct {
+	name string
+	args map[string]value
+}
+
+// operation is a query of a document
+type operation struct {
+	name       string
+	variables  map[string]value // Default values of declared variables
+	selections []selection
+}
+
+// document is a parsed query document
+type document struct {
+	operations []operation
+	fragments  map[string][]selection
+}
+
+// value is an argument value; variables are resolved at execution
+type value struct {
+	variable string
+	literal  interface{} // string, int64, float64, bool, nil or []value
+}
+
+// parser is a recursive descent parser over a query's tokens
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse parses a query document
+func parse(query string) (*document, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	
+	p := &parser{tokens: tokens}
+	doc := &document{fragments: make(map[string][]selection)}
+	for !p.at(tokenEOF, "") {
+		switch {
+		case p.at(tokenPunct, "{"):
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, operation{selections: selections})
+		case p.at(tokenName, "query"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.at(tokenName, "fragment"):
+			if err := p.fragment(doc); err != nil {
+				return nil, err
+			}
+		case p.at(tokenName, "mutation"), p.at(tokenName, "subscription"):
+			return nil, fmt.Errorf("%s operations are not supported", p.peek().text)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document has no query")
+	}
+	return doc, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) at(kind tokenKind, text string) bool {
+	t := p.peek()
+	return t.kind == kind && (text == "" || t.text == text)
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of query")
+	}
+	return fmt.Errorf("unexpected %q at offset %d", t.text, t.offset)
+}
+
+// expect consumes a token of kind with text, if given, and returns it
+func (p *parser) expect(kind tokenKind, text string) (token, error) {
+	if !p.at(kind, text) {
+		return token{}, p.unexpected()
+	}
+	t := p.peek()
+	p.pos++
+	return t, nil
+}
+
+// operation parses "query Name($var: Type = default) { ... }"
+func (p *parser) operation() (operation, error) {
+	p.pos++ // query
+	op := operation{variables: make(map[string]value)}
+	if p.at(tokenName, "") {
+		op.name = p.peek().text
+		p.pos++
+	}
+	
+	if p.at(tokenPunct, "(") {
+		p.pos++
+		for !p.at(tokenPunct, ")") {
+			if _, err := p.expect(tokenPunct, "$"); err != nil {
+				return op, err
+			}
+			name, err := p.expect(tokenName, "")
+			if err != nil {
+				return op, err
+			}
+			if _, err := p.expect(tokenPunct, ":"); err != nil {
+				return op, err
+			}
+			if err := p.skipType(); err != nil {
+				return op, err
+			}
+			var defaultValue value
+			if p.at(tokenPunct, "=") {
+				p.pos++
+				if defaultValue, err = p.value(); err != nil {
+					return op, err
+				}
+			}
+			op.variables[name.text] = defaultValue
+		}
+		p.pos++
+	}
+	
+	selections, err := p.selectionSet()
+	op.selections = selections
+	return op, err
+}
+
+// skipType consumes a variable type such as [String!]!; values are checked
+// by the resolvers, not against declared types
+func (p *parser) skipType() error {
+	if p.at(tokenPunct, "[") {
+		p.pos++
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if _, err := p.expect(tokenPunct, "]"); err != nil {
+			return err
+		}
+	} else if _, err := p.expect(tokenName, ""); err != nil {
+		return err
+	}
+	if p.at(tokenPunct, "!") {
+		p.pos++
+	}
+	return nil
+}
+
+// fragment parses "fragment Name on Type { ... }"
+func (p *parser) fragment(doc *document) error {
+	p.pos++ // fragment
+	name, err := p.expect(tokenName, "")
+	if err != nil {
+		return err
+	}
+	if _, err := p.expect(tokenName, "on"); err != nil {
+		return err
+	}
+	if _, err := p.expect(tokenName, ""); err != nil {
+		return err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return err
+	}
+	doc.fragments[name.text] = selections
+	return nil
+}
+
+// selectionSet parses "{ selection ... }"
+func (p *parser) selectionSet() ([]selection, error) {
+	if _, err := p.expect(tokenPunct, "{"); err != nil {
+		return nil, err
+	}
+	
+	var selections []selection
+	for !p.at(tokenPunct, "}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	p.pos++
+	
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+	return selections, nil
+}
+
+// selection parses a field, "...Fragment" or "... on Type { ... }"
+func (p *parser) selection() (selection, error) {
+	var sel selection
+	if p.at(tokenPunct, "...") {
+		p.pos++
+		if p.at(tokenName, "on") || p.at(tokenPunct, "{") || p.at(tokenPunct, "@") {
+			if p.at(tokenName, "on") {
+				p.pos++
+				if _, err := p.expect(tokenName, ""); err != nil {
+					return sel, err
+				}
+			}
+			directives, err := p.directives()
+			if err != nil {
+				return sel, err
+			}
+			inline, err := p.selectionSet()
+			return selection{inline: inline, directives: directives}, err
+		}
+		name, err := p.expect(tokenName, "")
+		if err != nil {
+			return sel, err
+		}
+		directives, err := p.directives()
+		return selection{fragment: name.text, directives: directives}, err
+	}
+	
+	name, err := p.expect(tokenName, "")
+	if err != nil {
+		return sel, err
+	}
+	f := &field{alias: name.text, name: name.text}
+	if p.at(tokenPunct, ":") {
+		p.pos++
+		actual, err := p.expect(tokenName, "")
+		if err != nil {
+			return sel, err
+		}
+		f.name = actual.text
+	}
+	if p.at(tokenPunct, "(") {
+		if f.args, err = p.arguments(); err != nil {
+			return sel, err
+		}
+	}
+	if sel.directives, err = p.directives(); err != nil {
+		return sel, err
+	}
+	if p.at(tokenPunct, "{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return sel, err
+		}
+	}
+	sel.field = f
+	return sel, nil
+}
+
+// arguments parses "(name: value, ...)"
+func (p *parser) arguments() (map[string]value, error) {
+	p.pos++ // (
+	args := make(map[string]value)
+	for !p.at(tokenPunct, ")") {
+		name, err := p.expect(tokenName, "")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenPunct, ":"); err != nil {
+			return nil, err
+		}
+		if args[name.text], err = p.value(); err != nil {
+			return nil, err
+		}
+	}
+	p.pos++
+	return args, nil
+}
+
+// directives parses "@name(args) ..."
+func (p *parser) directives() ([]directive, error) {
+	var directives []directive
+	for p.at(tokenPunct, "@") {
+		p.pos++
+		name, err := p.expect(tokenName, "")
+		if err != nil {
+			return nil, err
+		}
+		if name.text != "include" && name.text != "skip" {
+			return nil, fmt.Errorf("unknown directive @%s", name.text)
+		}
+		d := directive{name: name.text}
+		if p.at(tokenPunct, "(") {
+			if d.args, err = p.arguments(); err != nil {
+				return nil, err
+			}
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+// value parses a literal, a list or a variable reference
+func (p *parser) value() (value, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenPunct && t.text == "$":
+		p.pos++
+		name, err := p.expect(tokenName, "")
+		return value{variable: name.text}, err
+	case t.kind == tokenPunct && t.text == "[":
+		p.pos++
+		var list []value
+		for !p.at(tokenPunct, "]") {
+			element, err := p.value()
+			if err != nil {
+				return value{}, err
+			}
+			list = append(list, element)
+		}
+		p.pos++
+		return value{literal: list}, nil
+	case t.kind == tokenString:
+		p.pos++
+		return value{literal: t.text}, nil
+	case t.kind == tokenNumber:
+		p.pos++
+		if !strings.ContainsAny(t.text, ".eE") {
+			if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+				return value{literal: n}, nil
+			}
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return value{}, fmt.Errorf("invalid number %q", t.text)
+		}
+		return value{literal: f}, nil
+	case t.kind == tokenName:
+		p.pos++
+		switch t.text {
+		case "true":
+			return value{literal: true}, nil
+		case "false":
+			return value{literal: false}, nil
+		case "null":
+			return value{}, nil
+		}
+		// Enum values are passed to resolvers as strings
+		return value{literal: t.text}, nil
+	}
+	return value{}, p.unexpected()
+}
@@ -0,0 +1,85 @@
+package history
+
+import (
+	"sort"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Heatmap returns the average EPS of the sources include selects by day of
+// week and hour of day in location, over the complete hours between from
+// and to. Each source is averaged from its first recorded hour on, so a new
+// source is not diluted by the weeks before it existed.
+func (s *Store) Heatmap(from, to time.Time, location *time.Location, include func(source string) bool) ([]models.EPSHeatmap, error) {
+	points, _, err := s.Query(from, to, models.HistoryHour, include)
+	if err != nil {
+		return nil, err
+	}
+	
+	type sourceLogs struct {
+		first time.Time
+		logs  [7][24]int64
+	}
+	sources := make(map[string]*sourceLogs)
+	for _, point := range points {
+		// The hour still being rolled up is incomplete
+		if point.Time.Add(time.Hour).After(to) {
+			continue
+		}
+		source, exists := sources[point.Source]
+		if !exists {
+			source = &sourceLogs{first: point.Time}
+			sources[point.Source] = source
+		}
+		if point.Time.Before(source.first) {
+			source.first = point.Time
+		}
+		day, hour := heatmapCell(point.Time, location)
+		source.logs[day][hour] += point.Logs
+	}
+	
+	heatmaps := make([]models.EPSHeatmap, 0, len(sources))
+	for name, source := range sources {
+		heatmap := models.EPSHeatmap{Source: name, TimeZone: location.String(), From: source.first, To: to, Cells: make([][]float64, 7)}
+		
+		// Count how often each cell's hour occurred since the source's first hour
+		var hours [7][24]int
+		for t := source.first; !t.Add(time.Hour).After(to); t = t.Add(time.Hour) {
+			day, hour := heatmapCell(t, location)
+			hours[day][hour]++
+			heatmap.Hours++
+		}
+		
+		var total int64
+		for day := range heatmap.Cells {
+			heatmap.Cells[day] = make([]float64, 24)
+			for hour := range heatmap.Cells[day] {
+				total += source.logs[day][hour]
+				if hours[day][hour] == 0 {
+					continue
+				}
+				eps := float64(source.logs[day][hour]) / (float64(hours[day][hour]) * 3600)
+				heatmap.Cells[day][hour] = eps
+				if eps > heatmap.PeakEPS {
+					heatmap.PeakEPS = eps
+				}
+			}
+		}
+		if heatmap.Hours > 0 {
+			heatmap.MeanEPS = float64(total) / (float64(heatmap.Hours) * 3600)
+		}
+		heatmaps = append(heatmaps, heatmap)
+	}
+	
+	sort.Slice(heatmaps, func(i, j int) bool {
+		return heatmaps[i].Source < heatmaps[j].Source
+	})
+	return heatmaps, nil
+}
+
+// heatmapCell returns the day of week, Monday first, and hour of day of t in location
+func heatmapCell(t time.Time, location *time.Location) (int, int) {
+	local := t.In(location)
+	return (int(local.Weekday()) + 6) % 7, local.Hour()
+}
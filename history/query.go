@@ -0,0 +1,122 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// autoMinuteRange is the longest range answered with per-minute points when
+// no resolution is requested
+const autoMinuteRange = 48 * time.Hour
+
+// Query returns the points of the sources include selects between from and
+// to. An empty resolution picks per-minute points for short ranges still in
+// the raw tier and hourly rollups otherwise. Points of the same source and
+// interval are added together.
+func (s *Store) Query(from, to time.Time, resolution string, include func(source string) bool) ([]models.MetricsPoint, string, error) {
+	now := time.Now()
+	if resolution == "" {
+		resolution = models.HistoryHour
+		if to.Sub(from) <= autoMinuteRange && from.UTC().Format(rawPeriod) >= s.rawCutoff(now) {
+			resolution = models.HistoryMinute
+		}
+	}
+	
+	var prefix, layout string
+	var step time.Duration
+	switch resolution {
+	case models.HistoryMinute:
+		prefix, layout, step = rawPrefix, rawPeriod, time.Minute
+	case models.HistoryHour:
+		prefix, layout, step = hourlyPrefix, hourlyPeriod, time.Hour
+	default:
+		return nil, "", fmt.Errorf("unknown history resolution %q (expected %q or %q)", resolution, models.HistoryMinute, models.HistoryHour)
+	}
+	
+	files, err := s.listTier(prefix, layout)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list metrics history: %v", err)
+	}
+	first := from.UTC().Truncate(step)
+	firstPeriod, lastPeriod := first.Format(layout), to.UTC().Format(layout)
+	
+	totals := make(map[pointKey]*models.MetricsPoint)
+	for _, file := range files {
+		if file.period < firstPeriod || file.period > lastPeriod {
+			continue
+		}
+		if err := s.readFile(file.name, func(point models.MetricsPoint) {
+			if point.Time.Before(first) || point.Time.After(to) || !include(point.Source) {
+				return
+			}
+			accumulate(totals, point)
+		}); err != nil {
+			return nil, "", err
+		}
+	}
+	
+	// The hour being rolled up is only on disk at minute resolution
+	if resolution == models.HistoryHour {
+		s.mutex.Lock()
+		for _, point := range s.rollup {
+			if !point.Time.Before(first) && !point.Time.After(to) && include(point.Source) {
+				accumulate(totals, *point)
+			}
+		}
+		s.mutex.Unlock()
+	}
+	
+	points := make([]models.MetricsPoint, 0, len(totals))
+	for _, point := range totals {
+		points = append(points, *point)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if !points[i].Time.Equal(points[j].Time) {
+			return points[i].Time.Before(points[j].Time)
+		}
+		return points[i].Source < points[j].Source
+	})
+	return points, resolution, nil
+}
+
+// pointKey identifies the point of a source in an interval
+type pointKey struct {
+	source string
+	time   int64
+}
+
+// accumulate adds a point to the total of its source and interval
+func accumulate(totals map[pointKey]*models.MetricsPoint, point models.MetricsPoint) {
+	key := pointKey{point.Source, point.Time.Unix()}
+	if total, exists := totals[key]; exists {
+		mergePoint(total, point)
+		return
+	}
+	totals[key] = &point
+}
+
+// readFile calls visit with each point of a tier file
+func (s *Store) readFile(name string, visit func(models.MetricsPoint)) error {
+	file, err := os.Open(filepath.Join(s.config.Dir, name))
+	if err != nil {
+		return fmt.Errorf("failed to read metrics history: %v", err)
+	}
+	defer file.Close()
+	
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var point models.MetricsPoint
+		if err := json.Unmarshal(scanner.Bytes(), &point); err != nil {
+			continue // A line cut short by a crash
+		}
+		visit(point)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,141 @@
+package history
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// tierFile is a file of one tier and the period it covers
+type tierFile struct {
+	name   string
+	period string
+	size   int64
+}
+
+// listTier returns the files of a tier, oldest first
+func (s *Store) listTier(prefix, layout string) ([]tierFile, error) {
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		return nil, err
+	}
+	
+	var files []tierFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		period := strings.TrimSuffix(strings.TrimPrefix(name, prefix), fileSuffix)
+		if _, err := time.Parse(layout, period); err != nil {
+			continue // Not written by the store
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, tierFile{name: name, period: period, size: info.Size()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].period < files[j].period
+	})
+	return files, nil
+}
+
+// rawCutoff and hourlyCutoff are the oldest periods each tier keeps
+func (s *Store) rawCutoff(now time.Time) string {
+	return now.UTC().AddDate(0, 0, -s.config.RawDays).Format(rawPeriod)
+}
+
+func (s *Store) hourlyCutoff(now time.Time) string {
+	return now.UTC().AddDate(0, -s.config.HourlyMonths, 0).Format(hourlyPeriod)
+}
+
+// prune removes the files of both tiers that are past their retention
+func (s *Store) prune(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pruneLocked(now)
+}
+
+// pruneLocked prunes; callers hold the mutex. Raw days are only removed
+// once rolled up, which happens as each hour completes, so the hourly tier
+// already holds their history.
+func (s *Store) pruneLocked(now time.Time) {
+	tiers := []struct {
+		prefix, layout, cutoff string
+	}{
+		{rawPrefix, rawPeriod, s.rawCutoff(now)},
+		{hourlyPrefix, hourlyPeriod, s.hourlyCutoff(now)},
+	}
+	
+	removed := 0
+	for _, tier := range tiers {
+		files, err := s.listTier(tier.prefix, tier.layout)
+		if err != nil {
+			log.Printf("✗ Failed to list metrics history: %v", err)
+			return
+		}
+		for _, file := range files {
+			if file.period >= tier.cutoff {
+				break
+			}
+			if err := os.Remove(filepath.Join(s.config.Dir, file.name)); err != nil {
+				log.Printf("✗ Failed to prune metrics history file %s: %v", file.name, err)
+				continue
+			}
+			removed++
+		}
+	}
+	if removed > 0 {
+		log.Printf("✓ Pruned %d metrics history files past retention", removed)
+	}
+	s.pruned += removed
+	s.lastPruned = now
+}
+
+// Status reports the files and disk usage of each tier
+func (s *Store) Status() (models.HistoryStorageStatus, error) {
+	s.mutex.Lock()
+	status := models.HistoryStorageStatus{
+		Dir:        s.config.Dir,
+		Tiers:      []models.HistoryTierStatus{},
+		LastPruned: s.lastPruned,
+		Pruned:     s.pruned,
+	}
+	s.mutex.Unlock()
+	
+	tiers := []struct {
+		resolution, prefix, layout, retention string
+	}{
+		{models.HistoryMinute, rawPrefix, rawPeriod, fmt.Sprintf("%d days", s.config.RawDays)},
+		{models.HistoryHour, hourlyPrefix, hourlyPeriod, fmt.Sprintf("%d months", s.config.HourlyMonths)},
+	}
+	for _, tier := range tiers {
+		files, err := s.listTier(tier.prefix, tier.layout)
+		if err != nil {
+			return status, fmt.Errorf("failed to list metrics history: %v", err)
+		}
+		tierStatus := models.HistoryTierStatus{
+			Resolution: tier.resolution,
+			Retention:  tier.retention,
+			Files:      len(files),
+		}
+		for _, file := range files {
+			tierStatus.Bytes += file.size
+		}
+		if len(files) > 0 {
+			tierStatus.Oldest = files[0].period
+			tierStatus.Newest = files[len(files)-1].period
+		}
+		status.TotalBytes += tierStatus.Bytes
+		status.Tiers = append(status.Tiers, tierStatus)
+	}
+	return status, nil
+}
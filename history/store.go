@@ -0,0 +1,228 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Tiers of the history. Each tier keeps one JSON-lines file per period:
+// per-minute points per UTC day, hourly rollups per UTC month.
+const (
+	rawPrefix    = "raw-"
+	hourlyPrefix = "hourly-"
+	rawPeriod    = "2006-01-02"
+	hourlyPeriod = "2006-01"
+	fileSuffix   = ".jsonl"
+)
+
+// counters remembers a source's cumulative counters at the last sample
+type counters struct {
+	logs       int64
+	eventBytes int64
+	wireBytes  int64
+	sent       int64
+	dropped    int64
+}
+
+// Store persists per-minute metrics of every source, rolls them up into
+// hourly points as each hour completes and prunes both tiers by age
+type Store struct {
+	config      models.HistoryConfig
+	metricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)
+	last        map[string]counters
+	hour        time.Time                       // Start of the hour being rolled up
+	rollup      map[string]*models.MetricsPoint // Per-source totals of that hour
+	lastPruned  time.Time
+	pruned      int
+	mutex       sync.Mutex
+	stopChan    chan bool
+}
+
+// NewStore creates a history store writing to the configured directory
+func NewStore(config models.HistoryConfig, metricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)) (*Store, error) {
+	if config.RawDays < 1 {
+		return nil, fmt.Errorf("invalid history raw days %d (expected at least 1)", config.RawDays)
+	}
+	if config.HourlyMonths < 1 {
+		return nil, fmt.Errorf("invalid history hourly months %d (expected at least 1)", config.HourlyMonths)
+	}
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %v", err)
+	}
+	
+	return &Store{
+		config:      config,
+		metricsFunc: metricsFunc,
+		last:        make(map[string]counters),
+		rollup:      make(map[string]*models.MetricsPoint),
+		stopChan:    make(chan bool),
+	}, nil
+}
+
+// Start begins sampling metrics
+func (s *Store) Start() {
+	go s.run()
+	log.Printf("✓ Metrics history kept in %s: per-minute for %d days, hourly for %d months", s.config.Dir, s.config.RawDays, s.config.HourlyMonths)
+}
+
+// Stop stops sampling and writes the rollup of the current hour so far
+func (s *Store) Stop() {
+	close(s.stopChan)
+	
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.flushRollup(); err != nil {
+		log.Printf("✗ Failed to save metrics history rollup: %v", err)
+	}
+}
+
+// run samples once a minute and prunes as each hour completes
+func (s *Store) run() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	
+	s.prune(time.Now())
+	s.sample(time.Now())
+	
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			s.sample(now)
+		}
+	}
+}
+
+// sample records the traffic since the previous sample as the points of
+// the minute before now
+func (s *Store) sample(now time.Time) {
+	if s.metricsFunc == nil {
+		return
+	}
+	sources, _ := s.metricsFunc()
+	minute := now.UTC().Truncate(time.Minute).Add(-time.Minute)
+	
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	var points []models.MetricsPoint
+	for _, source := range sources {
+		current := counters{
+			logs:       source.TotalLogsIngested,
+			eventBytes: source.TotalEventBytes,
+			wireBytes:  source.TotalWireBytes,
+			sent:       source.SentCount,
+			dropped:    source.DroppedCount,
+		}
+		// A source's counters start from zero again when it is restarted
+		previous := s.last[source.Name]
+		if current.logs < previous.logs {
+			previous = counters{}
+		}
+		s.last[source.Name] = current
+		
+		point := models.MetricsPoint{
+			Time:       minute,
+			Source:     source.Name,
+			Logs:       current.logs - previous.logs,
+			EventBytes: current.eventBytes - previous.eventBytes,
+			WireBytes:  current.wireBytes - previous.wireBytes,
+			Sent:       current.sent - previous.sent,
+			Dropped:    current.dropped - previous.dropped,
+			PeakEPS:    source.RealTimeEPS,
+		}
+		if point.Logs > 0 || point.WireBytes > 0 || point.Sent > 0 || point.Dropped > 0 {
+			points = append(points, point)
+		}
+	}
+	
+	if err := s.appendPoints(rawPrefix+minute.Format(rawPeriod), points); err != nil {
+		log.Printf("✗ Failed to save metrics history: %v", err)
+	}
+	
+	// Roll the completed hour up before counting the new one
+	hour := minute.Truncate(time.Hour)
+	if !hour.Equal(s.hour) {
+		if err := s.flushRollup(); err != nil {
+			log.Printf("✗ Failed to save metrics history rollup: %v", err)
+		}
+		if !s.hour.IsZero() {
+			s.pruneLocked(now)
+		}
+		s.hour = hour
+	}
+	for _, point := range points {
+		addPoint(s.rollup, point, hour)
+	}
+}
+
+// addPoint accumulates a point into the per-source totals of an interval
+func addPoint(totals map[string]*models.MetricsPoint, point models.MetricsPoint, interval time.Time) {
+	total, exists := totals[point.Source]
+	if !exists {
+		total = &models.MetricsPoint{Time: interval, Source: point.Source}
+		totals[point.Source] = total
+	}
+	mergePoint(total, point)
+}
+
+// mergePoint adds the counters of point to total
+func mergePoint(total *models.MetricsPoint, point models.MetricsPoint) {
+	total.Logs += point.Logs
+	total.EventBytes += point.EventBytes
+	total.WireBytes += point.WireBytes
+	total.Sent += point.Sent
+	total.Dropped += point.Dropped
+	if point.PeakEPS > total.PeakEPS {
+		total.PeakEPS = point.PeakEPS
+	}
+}
+
+// flushRollup appends the hourly points accumulated so far; callers hold
+// the mutex. A rollup written on stop is completed by a second point for
+// the same hour after a restart, which queries add together.
+func (s *Store) flushRollup() error {
+	if len(s.rollup) == 0 {
+		return nil
+	}
+	points := make([]models.MetricsPoint, 0, len(s.rollup))
+	for _, point := range s.rollup {
+		points = append(points, *point)
+	}
+	s.rollup = make(map[string]*models.MetricsPoint)
+	return s.appendPoints(hourlyPrefix+s.hour.Format(hourlyPeriod), points)
+}
+
+// appendPoints writes points as JSON lines to a tier file
+func (s *Store) appendPoints(name string, points []models.MetricsPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+	file, err := os.OpenFile(filepath.Join(s.config.Dir, name+fileSuffix), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, point := range points {
+		if err := encoder.Encode(point); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
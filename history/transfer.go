@@ -0,0 +1,245 @@
+package history
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Export formats; either is gzip-compressed
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// csvHeader is the header row of a CSV export
+var csvHeader = []string{"resolution", "time", "source", "logs", "event_bytes", "wire_bytes", "sent", "dropped", "peak_eps"}
+
+// ExportFilename returns the download name of an export; a zero from is an
+// export of everything recorded up to to
+func ExportFilename(from, to time.Time, format string) string {
+	if from.IsZero() {
+		return fmt.Sprintf("metrics-history-until-%s.%s.gz", to.UTC().Format("20060102T1504"), format)
+	}
+	return fmt.Sprintf("metrics-history-%s-%s.%s.gz", from.UTC().Format("20060102T1504"), to.UTC().Format("20060102T1504"), format)
+}
+
+// Export copies the points of both tiers of the sources include selects
+// between from and to
+func (s *Store) Export(from, to time.Time, include func(source string) bool) (models.MetricsHistoryExport, error) {
+	export := models.MetricsHistoryExport{
+		ExportedAt: time.Now().UTC(),
+		From:       from.UTC(),
+		To:         to.UTC(),
+	}
+	
+	var err error
+	if export.Minute, _, err = s.Query(from, to, models.HistoryMinute, include); err != nil {
+		return export, err
+	}
+	if export.Hourly, _, err = s.Query(from, to, models.HistoryHour, include); err != nil {
+		return export, err
+	}
+	return export, nil
+}
+
+// Encode renders an export as gzip-compressed JSON or CSV
+func Encode(export models.MetricsHistoryExport, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	
+	switch format {
+	case FormatJSON:
+		if err := json.NewEncoder(writer).Encode(export); err != nil {
+			return nil, fmt.Errorf("failed to write JSON: %v", err)
+		}
+	case FormatCSV:
+		csvWriter := csv.NewWriter(writer)
+		csvWriter.Write(csvHeader)
+		for _, tier := range []struct {
+			resolution string
+			points     []models.MetricsPoint
+		}{
+			{models.HistoryMinute, export.Minute},
+			{models.HistoryHour, export.Hourly},
+		} {
+			for _, point := range tier.points {
+				csvWriter.Write([]string{
+					tier.resolution,
+					point.Time.UTC().Format(time.RFC3339),
+					point.Source,
+					strconv.FormatInt(point.Logs, 10),
+					strconv.FormatInt(point.EventBytes, 10),
+					strconv.FormatInt(point.WireBytes, 10),
+					strconv.FormatInt(point.Sent, 10),
+					strconv.FormatInt(point.Dropped, 10),
+					strconv.FormatFloat(point.PeakEPS, 'f', 2, 64),
+				})
+			}
+		}
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return nil, fmt.Errorf("failed to write CSV: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown export format %q (expected %q or %q)", format, FormatJSON, FormatCSV)
+	}
+	
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress export: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode parses an export written by Encode. The data may also be
+// uncompressed, and an empty format is detected from the content.
+func Decode(data []byte, format string) (models.MetricsHistoryExport, error) {
+	var export models.MetricsHistoryExport
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return export, fmt.Errorf("invalid gzip data: %v", err)
+		}
+		if data, err = ioutil.ReadAll(reader); err != nil {
+			return export, fmt.Errorf("invalid gzip data: %v", err)
+		}
+	}
+	if format == "" {
+		format = FormatCSV
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+			format = FormatJSON
+		}
+	}
+	
+	switch format {
+	case FormatJSON:
+		if err := json.Unmarshal(data, &export); err != nil {
+			return export, fmt.Errorf("invalid JSON export: %v", err)
+		}
+	case FormatCSV:
+		rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			return export, fmt.Errorf("invalid CSV export: %v", err)
+		}
+		for i, row := range rows {
+			if i == 0 && len(row) > 0 && row[0] == csvHeader[0] {
+				continue
+			}
+			point, resolution, err := parseCSVRow(row)
+			if err != nil {
+				return export, fmt.Errorf("invalid CSV export line %d: %v", i+1, err)
+			}
+			if resolution == models.HistoryMinute {
+				export.Minute = append(export.Minute, point)
+			} else {
+				export.Hourly = append(export.Hourly, point)
+			}
+		}
+	default:
+		return export, fmt.Errorf("unknown export format %q (expected %q or %q)", format, FormatJSON, FormatCSV)
+	}
+	return export, nil
+}
+
+// parseCSVRow parses a CSV export row into a point and its resolution
+func parseCSVRow(row []string) (models.MetricsPoint, string, error) {
+	var point models.MetricsPoint
+	if len(row) != len(csvHeader) {
+		return point, "", fmt.Errorf("expected %d columns, got %d", len(csvHeader), len(row))
+	}
+	resolution := row[0]
+	if resolution != models.HistoryMinute && resolution != models.HistoryHour {
+		return point, "", fmt.Errorf("unknown resolution %q", resolution)
+	}
+	
+	var err error
+	if point.Time, err = time.Parse(time.RFC3339, row[1]); err != nil {
+		return point, "", fmt.Errorf("invalid time: %v", err)
+	}
+	point.Source = row[2]
+	for i, counter := range []*int64{&point.Logs, &point.EventBytes, &point.WireBytes, &point.Sent, &point.Dropped} {
+		if *counter, err = strconv.ParseInt(row[3+i], 10, 64); err != nil {
+			return point, "", fmt.Errorf("invalid %s: %v", csvHeader[3+i], err)
+		}
+	}
+	if point.PeakEPS, err = strconv.ParseFloat(row[8], 64); err != nil {
+		return point, "", fmt.Errorf("invalid peak_eps: %v", err)
+	}
+	return point, resolution, nil
+}
+
+// Import adds the points of an export to both tiers. Points already recorded
+// for the same source and interval are skipped, so importing an export twice
+// or merging overlapping exports does not count traffic twice. Points past
+// retention are skipped too.
+func (s *Store) Import(export models.MetricsHistoryExport) (models.MetricsHistoryImport, error) {
+	var result models.MetricsHistoryImport
+	now := time.Now()
+	
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	tiers := []struct {
+		points         []models.MetricsPoint
+		prefix, layout string
+		step           time.Duration
+		cutoff         string
+		added          *int
+	}{
+		{export.Minute, rawPrefix, rawPeriod, time.Minute, s.rawCutoff(now), &result.Minute},
+		{export.Hourly, hourlyPrefix, hourlyPeriod, time.Hour, s.hourlyCutoff(now), &result.Hourly},
+	}
+	for _, tier := range tiers {
+		byFile := make(map[string][]models.MetricsPoint)
+		for _, point := range tier.points {
+			point.Time = point.Time.UTC().Truncate(tier.step)
+			period := point.Time.Format(tier.layout)
+			if period < tier.cutoff {
+				result.Expired++
+				continue
+			}
+			byFile[tier.prefix+period] = append(byFile[tier.prefix+period], point)
+		}
+		
+		for name, points := range byFile {
+			recorded := make(map[pointKey]bool)
+			if _, err := os.Stat(filepath.Join(s.config.Dir, name+fileSuffix)); err == nil {
+				if err := s.readFile(name+fileSuffix, func(point models.MetricsPoint) {
+					recorded[pointKey{point.Source, point.Time.Unix()}] = true
+				}); err != nil {
+					return result, err
+				}
+			}
+			if tier.prefix == hourlyPrefix {
+				for _, point := range s.rollup {
+					recorded[pointKey{point.Source, point.Time.Unix()}] = true
+				}
+			}
+			
+			var added []models.MetricsPoint
+			for _, point := range points {
+				key := pointKey{point.Source, point.Time.Unix()}
+				if recorded[key] {
+					result.Existing++
+					continue
+				}
+				recorded[key] = true
+				added = append(added, point)
+			}
+			if err := s.appendPoints(name, added); err != nil {
+				return result, fmt.Errorf("failed to save metrics history: %v", err)
+			}
+			*tier.added += len(added)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,173 @@
+// Package ingest authenticates senders to the HTTP ingest receivers of
+// sources. Each sender gets its own token, so a misbehaving one can be
+// rotated out or revoked without affecting the others.
+//
+// The receivers are not built yet: tokens can be issued, rotated and revoked
+// so senders can be provisioned ahead of them, but no listener checks them.
+package ingest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/models"
+)
+
+// Token format
+const (
+	tokenPrefix  = "sai_"
+	tokenBytes   = 32
+	prefixLength = len(tokenPrefix) + 8 // Characters kept to recognize a token by
+)
+
+// usage counts the requests made with a token since startup
+type usage struct {
+	lastUsed time.Time
+	accepted int64
+	rejected int64
+}
+
+// Manager checks ingest tokens against the configured ones
+type Manager struct {
+	tokens map[string]models.IngestToken // By hash
+	usage  map[string]*usage             // By token ID
+	mutex  sync.Mutex
+}
+
+// NewManager creates a manager without tokens
+func NewManager() *Manager {
+	return &Manager{
+		tokens: make(map[string]models.IngestToken),
+		usage:  make(map[string]*usage),
+	}
+}
+
+// NewToken generates a token for a sender to a source. It returns the
+// token as stored, without the token itself, and the token.
+func NewToken(sourceID, name string) (models.IngestToken, string, error) {
+	secret := make([]byte, tokenBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return models.IngestToken{}, "", err
+	}
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	
+	return models.IngestToken{
+		ID:        config.NewID(),
+		SourceID:  sourceID,
+		Name:      name,
+		Prefix:    token[:prefixLength],
+		Hash:      hashToken(token),
+		CreatedAt: time.Now(),
+	}, token, nil
+}
+
+// hashToken returns the stored hash of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Active reports whether a token is neither revoked nor past its grace period
+func Active(token models.IngestToken, now time.Time) bool {
+	if !token.RevokedAt.IsZero() {
+		return false
+	}
+	return token.ExpiresAt.IsZero() || now.Before(token.ExpiresAt)
+}
+
+// SetTokens replaces the configured tokens, keeping the usage of tokens
+// that are still configured
+func (m *Manager) SetTokens(tokens []models.IngestToken) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	
+	byHash := make(map[string]models.IngestToken, len(tokens))
+	counts := make(map[string]*usage, len(tokens))
+	for _, token := range tokens {
+		byHash[token.Hash] = token
+		if u, exists := m.usage[token.ID]; exists {
+			counts[token.ID] = u
+		} else {
+			counts[token.ID] = &usage{}
+		}
+	}
+	m.tokens = byHash
+	m.usage = counts
+}
+
+// Authenticate reports whether token is an active token of the source with
+// the given ID
+func (m *Manager) Authenticate(sourceID, token string) bool {
+	if token == "" {
+		return false
+	}
+	hash := hashToken(token)
+	
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	
+	stored, exists := m.tokens[hash]
+	if !exists || stored.SourceID != sourceID {
+		return false
+	}
+	
+	now := time.Now()
+	u := m.usage[stored.ID]
+	if !Active(stored, now) {
+		u.rejected++
+		return false
+	}
+	u.accepted++
+	u.lastUsed = now
+	return true
+}
+
+// Status returns the tokens of a source, oldest first
+func (m *Manager) Status(sourceID string) []models.IngestTokenStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	
+	status := []models.IngestTokenStatus{}
+	for _, token := range m.tokens {
+		if token.SourceID == sourceID {
+			status = append(status, m.status(token))
+		}
+	}
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].CreatedAt.Before(status[j].CreatedAt)
+	})
+	return status
+}
+
+// TokenStatus returns the status of a single token
+func (m *Manager) TokenStatus(token models.IngestToken) models.IngestTokenStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.status(token)
+}
+
+// status builds the status of a token; the caller holds the mutex
+func (m *Manager) status(token models.IngestToken) models.IngestTokenStatus {
+	status := models.IngestTokenStatus{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		RevokedAt:  token.RevokedAt,
+		ReplacedBy: token.ReplacedBy,
+		Active:     Active(token, time.Now()),
+	}
+	if u, exists := m.usage[token.ID]; exists {
+		status.LastUsed = u.lastUsed
+		status.Accepted = u.accepted
+		status.Rejected = u.rejected
+	}
+	return status
+}
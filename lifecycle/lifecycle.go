@@ -0,0 +1,91 @@
+// Package lifecycle accounts for the goroutines the pipeline starts. Every
+// goroutine is attributed to the source or listener instance that started
+// it, so goroutines still running for an instance that was stopped, and
+// instances that were never stopped, show up as leaks in the diagnostics.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Unowned is the owner of goroutines started without an owner in their context
+const Unowned = "unowned"
+
+// ownerKey is the context key of the owner goroutines are accounted to
+type ownerKey struct{}
+
+var (
+	nextID  int64
+	mutex   sync.Mutex
+	active  = make(map[string]int) // map[owner] -> running goroutines
+	started int64
+	exited  int64
+)
+
+// NewOwner returns a name for one instance of a source or listener. Names
+// are unique, so a source recreated under the same name is told apart from
+// the instance it replaced.
+func NewOwner(kind, name string) string {
+	return fmt.Sprintf("%s %s #%d", kind, name, atomic.AddInt64(&nextID, 1))
+}
+
+// WithOwner returns a context whose goroutines are accounted to owner
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// Owner returns the owner goroutines started with ctx are accounted to
+func Owner(ctx context.Context) string {
+	if owner, ok := ctx.Value(ownerKey{}).(string); ok {
+		return owner
+	}
+	return Unowned
+}
+
+// Go runs fn in a new goroutine accounted to the owner of ctx until fn returns
+func Go(ctx context.Context, fn func()) {
+	owner := Owner(ctx)
+	enter(owner)
+	go func() {
+		defer exit(owner)
+		fn()
+	}()
+}
+
+func enter(owner string) {
+	mutex.Lock()
+	active[owner]++
+	started++
+	mutex.Unlock()
+}
+
+func exit(owner string) {
+	mutex.Lock()
+	if active[owner]--; active[owner] <= 0 {
+		delete(active, owner)
+	}
+	exited++
+	mutex.Unlock()
+}
+
+// Active returns the running goroutines per owner
+func Active() map[string]int {
+	mutex.Lock()
+	defer mutex.Unlock()
+	
+	counts := make(map[string]int, len(active))
+	for owner, count := range active {
+		counts[owner] = count
+	}
+	return counts
+}
+
+// Totals returns the number of goroutines started and exited since startup
+func Totals() (int64, int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return started, exited
+}
@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// subscriberBuffer is how many lines a slow log viewer may fall behind
+// before lines are dropped for it
+const subscriberBuffer = 256
+
+// LogBuffer keeps the most recent lines of the analyzer's own log in memory
+// and streams new ones to subscribers, so the dashboard can show them.
+// Writes never block the caller: lines a subscriber cannot take are dropped.
+type LogBuffer struct {
+	mutex       sync.Mutex
+	lines       []models.LogLine // Ring of the last len(lines) lines
+	next        int
+	count       int
+	seq         int64
+	subscribers map[chan models.LogLine]bool
+}
+
+// NewLogBuffer creates a buffer holding up to size lines
+func NewLogBuffer(size int) *LogBuffer {
+	return &LogBuffer{
+		lines:       make([]models.LogLine, size),
+		subscribers: make(map[chan models.LogLine]bool),
+	}
+}
+
+// Write records a line written by the log package
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	text := strings.TrimRight(string(p), "\n")
+	
+	// The log package prefixes a date and time; the line carries its own
+	if len(text) > 20 && text[4] == '/' && text[7] == '/' && text[10] == ' ' && text[13] == ':' {
+		text = text[20:]
+	}
+	
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	
+	b.seq++
+	line := models.LogLine{
+		Seq:     b.seq,
+		Time:    time.Now(),
+		Level:   levelName(severityOf(text)),
+		Message: text,
+	}
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % len(b.lines)
+	if b.count < len(b.lines) {
+		b.count++
+	}
+	
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- line:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Recent returns the buffered lines, oldest first
+func (b *LogBuffer) Recent() []models.LogLine {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.recent()
+}
+
+// recent copies the ring; the caller holds the mutex
+func (b *LogBuffer) recent() []models.LogLine {
+	lines := make([]models.LogLine, 0, b.count)
+	start := (b.next - b.count + len(b.lines)) % len(b.lines)
+	for i := 0; i < b.count; i++ {
+		lines = append(lines, b.lines[(start+i)%len(b.lines)])
+	}
+	return lines
+}
+
+// Subscribe returns the buffered lines and a channel receiving every line
+// written after them. cancel stops the subscription and closes the channel.
+func (b *LogBuffer) Subscribe() (recent []models.LogLine, lines <-chan models.LogLine, cancel func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	
+	recent = b.recent()
+	subscriber := make(chan models.LogLine, subscriberBuffer)
+	b.subscribers[subscriber] = true
+	var once sync.Once
+	return recent, subscriber, func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			delete(b.subscribers, subscriber)
+			b.mutex.Unlock()
+			close(subscriber)
+		})
+	}
+}
+
+// levelName names a syslog severity for the log viewer
+func levelName(severity int) string {
+	switch severity {
+	case severityError:
+		return "error"
+	case severityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,190 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Event Log defaults
+const (
+	defaultEventSource       = "syslog-analyzer"
+	defaultEventMaxPerMinute = 60
+)
+
+// eventSink writes entries to the host's event log
+type eventSink interface {
+	report(severity int, message string) error
+	close() error
+}
+
+// EventLogWriter writes the analyzer's critical operational messages, such
+// as failing destinations and full queues, to the Windows Event Log so that
+// existing monitoring of the host picks them up. Lines below the configured
+// level are skipped. At most MaxPerMinute entries are written per minute;
+// the rest is counted and reported in one summary entry. Writes never block
+// the caller.
+type EventLogWriter struct {
+	config      models.EventLogConfig
+	minSeverity int // Highest severity code written
+	sink        eventSink
+	messages    chan eventEntry
+	stopChan    chan bool
+	window      time.Time // Start of the current rate limit minute
+	written     int       // Entries written in the current minute
+	suppressed  int       // Entries over the limit in the current minute
+	dropped     int64
+	mutex       sync.Mutex
+	wg          sync.WaitGroup
+}
+
+// eventEntry is a queued Event Log entry
+type eventEntry struct {
+	severity int
+	message  string
+}
+
+// NewEventLogWriter registers the configured event source and starts writing
+func NewEventLogWriter(config models.EventLogConfig) (*EventLogWriter, error) {
+	if config.Source == "" {
+		config.Source = defaultEventSource
+	}
+	if config.MaxPerMinute <= 0 {
+		config.MaxPerMinute = defaultEventMaxPerMinute
+	}
+	
+	var minSeverity int
+	switch strings.ToLower(config.MinLevel) {
+	case "", "warning":
+		minSeverity = severityWarning
+	case "error":
+		minSeverity = severityError
+	default:
+		return nil, fmt.Errorf("invalid event log level: %s (expected warning or error)", config.MinLevel)
+	}
+	
+	sink, err := openEventSink(config.Source)
+	if err != nil {
+		return nil, err
+	}
+	
+	writer := &EventLogWriter{
+		config:      config,
+		minSeverity: minSeverity,
+		sink:        sink,
+		messages:    make(chan eventEntry, 1000),
+		stopChan:    make(chan bool),
+	}
+	
+	writer.wg.Add(1)
+	go writer.run()
+	
+	return writer, nil
+}
+
+// Write implements io.Writer. Each call is one log line from the log package.
+func (w *EventLogWriter) Write(p []byte) (int, error) {
+	line := strings.TrimRight(string(p), "\r\n")
+	
+	// The log package prefixes a date and time; the event carries its own
+	if len(line) > 20 && line[4] == '/' && line[7] == '/' && line[10] == ' ' && line[13] == ':' {
+		line = line[20:]
+	}
+	
+	// Firing alerts are operational alerts even though they are logged as info
+	severity := severityOf(line)
+	if strings.HasPrefix(line, "🔔 Alert firing") {
+		severity = severityWarning
+	}
+	if line == "" || severity > w.minSeverity {
+		return len(p), nil
+	}
+	
+	select {
+	case w.messages <- eventEntry{severity: severity, message: line}:
+	default:
+		w.mutex.Lock()
+		w.dropped++
+		w.mutex.Unlock()
+	}
+	
+	return len(p), nil
+}
+
+// Close writes queued entries and deregisters the event source
+func (w *EventLogWriter) Close() error {
+	close(w.stopChan)
+	w.wg.Wait()
+	return w.sink.close()
+}
+
+// run writes queued entries until closed
+func (w *EventLogWriter) run() {
+	defer w.wg.Done()
+	
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case entry := <-w.messages:
+			w.write(entry, time.Now())
+		case now := <-ticker.C:
+			w.rollWindow(now)
+		case <-w.stopChan:
+			for {
+				select {
+				case entry := <-w.messages:
+					w.write(entry, time.Now())
+				default:
+					w.rollWindow(time.Now().Add(time.Minute))
+					return
+				}
+			}
+		}
+	}
+}
+
+// write reports an entry unless the current minute's limit is reached
+func (w *EventLogWriter) write(entry eventEntry, now time.Time) {
+	w.rollWindow(now)
+	if w.written >= w.config.MaxPerMinute {
+		w.suppressed++
+		return
+	}
+	w.written++
+	w.report(entry.severity, entry.message)
+}
+
+// rollWindow starts a new rate limit minute once the current one is over,
+// summarizing what the last one suppressed
+func (w *EventLogWriter) rollWindow(now time.Time) {
+	if now.Sub(w.window) < time.Minute {
+		return
+	}
+	if w.suppressed > 0 {
+		w.report(severityWarning, fmt.Sprintf("⚠ %d further operational messages suppressed after %d in one minute; see the analyzer's log", w.suppressed, w.config.MaxPerMinute))
+	}
+	w.window = now
+	w.written = 0
+	w.suppressed = 0
+}
+
+// report writes one entry to the sink
+func (w *EventLogWriter) report(severity int, message string) {
+	if err := w.sink.report(severity, message); err != nil {
+		// Cannot report through the log package without recursing
+		fmt.Fprintf(os.Stderr, "event log: cannot write to source %s: %v\n", w.config.Source, err)
+	}
+}
+
+// Dropped returns the number of log lines dropped because the queue was full
+func (w *EventLogWriter) Dropped() int64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.dropped
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package logging
+
+import (
+	"fmt"
+)
+
+// openEventSink is not supported on this platform
+func openEventSink(source string) (eventSink, error) {
+	return nil, fmt.Errorf("the Windows Event Log is only available on Windows")
+}
@@ -0,0 +1,76 @@
+//go:build windows
+
+package logging
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// Event types and the event IDs the analyzer reports them under
+const (
+	eventlogErrorType       = 0x0001
+	eventlogWarningType     = 0x0002
+	eventlogInformationType = 0x0004
+	
+	eventIDError   = 1
+	eventIDWarning = 2
+	eventIDInfo    = 3
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSourceW  = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEventW          = advapi32.NewProc("ReportEventW")
+)
+
+// windowsEventSink writes to the Application log under a registered event
+// source. The viewer shows the message text either way; registering the
+// source once (New-EventLog -LogName Application -Source <source>) drops its
+// note about a missing event description.
+type windowsEventSink struct {
+	handle uintptr
+}
+
+// openEventSink registers the event source with the local Event Log
+func openEventSink(source string) (eventSink, error) {
+	name, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, err
+	}
+	handle, _, err := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(name)))
+	if handle == 0 {
+		return nil, fmt.Errorf("failed to register event source %s: %v", source, err)
+	}
+	return &windowsEventSink{handle: handle}, nil
+}
+
+func (s *windowsEventSink) report(severity int, message string) error {
+	eventType, eventID := uintptr(eventlogInformationType), uintptr(eventIDInfo)
+	switch {
+	case severity <= severityError:
+		eventType, eventID = eventlogErrorType, eventIDError
+	case severity == severityWarning:
+		eventType, eventID = eventlogWarningType, eventIDWarning
+	}
+	
+	text, err := syscall.UTF16PtrFromString(message)
+	if err != nil {
+		return err
+	}
+	strings := []*uint16{text}
+	ok, _, err := procReportEventW.Call(s.handle, eventType, 0, eventID, 0, uintptr(len(strings)), 0, uintptr(unsafe.Pointer(&strings[0])), 0)
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
+
+func (s *windowsEventSink) close() error {
+	if ok, _, err := procDeregisterEventSource.Call(s.handle); ok == 0 {
+		return err
+	}
+	return nil
+}
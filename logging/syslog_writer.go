@@ -0,0 +1,253 @@
+package logging
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/fips"
+	"syslog-analyzer/models"
+)
+
+// Syslog severities used for operational messages
+const (
+	severityError   = 3
+	severityWarning = 4
+	severityInfo    = 6
+)
+
+// SyslogWriter forwards the analyzer's own log output as RFC 5424 messages
+// to an external collector over UDP, TCP or TLS. Writes never block the
+// caller: messages are queued and dropped if the collector cannot keep up.
+type SyslogWriter struct {
+	config    models.SelfLoggingConfig
+	hostname  string
+	tlsConfig *tls.Config
+	conn      net.Conn
+	messages  chan []byte
+	stopChan  chan bool
+	dropped   int64 // Accessed atomically; Write never waits on the connection
+	down      bool  // Collector unreachable; only used by the run goroutine
+	mutex     sync.Mutex
+	wg        sync.WaitGroup
+}
+
+// NewSyslogWriter creates a writer for the configured collector
+func NewSyslogWriter(config models.SelfLoggingConfig) (*SyslogWriter, error) {
+	config.Protocol = strings.ToLower(config.Protocol)
+	if config.Protocol == "" {
+		config.Protocol = "udp"
+	}
+	if config.Protocol != "udp" && config.Protocol != "tcp" && config.Protocol != "tls" {
+		return nil, fmt.Errorf("unsupported self-logging protocol: %s", config.Protocol)
+	}
+	if config.Address == "" {
+		return nil, fmt.Errorf("self-logging collector address is empty")
+	}
+	if config.Facility < 0 || config.Facility > 23 {
+		return nil, fmt.Errorf("invalid syslog facility: %d", config.Facility)
+	}
+	if config.AppName == "" {
+		config.AppName = "syslog-analyzer"
+	}
+	
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	
+	writer := &SyslogWriter{
+		config:   config,
+		hostname: hostname,
+		messages: make(chan []byte, 1000),
+		stopChan: make(chan bool),
+	}
+	
+	if config.Protocol == "tls" {
+		host, _, err := net.SplitHostPort(config.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid collector address: %v", err)
+		}
+		writer.tlsConfig = fips.Apply(&tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: !config.VerifySSL,
+		})
+		if config.CACertFile != "" {
+			pem, err := ioutil.ReadFile(config.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA certificate: %v", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", config.CACertFile)
+			}
+			writer.tlsConfig.RootCAs = pool
+		}
+	}
+	
+	writer.wg.Add(1)
+	go writer.run()
+	
+	return writer, nil
+}
+
+// Write implements io.Writer. Each call is one log line from the log package.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	line := strings.TrimRight(string(p), "\r\n")
+	if line == "" {
+		return len(p), nil
+	}
+	
+	select {
+	case w.messages <- w.format(line, time.Now()):
+	default:
+		atomic.AddInt64(&w.dropped, 1)
+	}
+	
+	return len(p), nil
+}
+
+// Close flushes queued messages and closes the collector connection
+func (w *SyslogWriter) Close() error {
+	close(w.stopChan)
+	w.wg.Wait()
+	
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.conn != nil {
+		return w.conn.Close()
+	}
+	return nil
+}
+
+// run delivers queued messages until closed
+func (w *SyslogWriter) run() {
+	defer w.wg.Done()
+	
+	for {
+		select {
+		case msg := <-w.messages:
+			w.send(msg)
+		case <-w.stopChan:
+			// Drain whatever is left without blocking shutdown for long
+			deadline := time.Now().Add(2 * time.Second)
+			for time.Now().Before(deadline) {
+				select {
+				case msg := <-w.messages:
+					w.send(msg)
+				default:
+					return
+				}
+			}
+			return
+		}
+	}
+}
+
+// send writes a message, reconnecting once on failure
+func (w *SyslogWriter) send(msg []byte) {
+	frame := msg
+	if w.config.Protocol != "udp" {
+		// Octet-counting framing (RFC 6587 / RFC 5425)
+		frame = append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+	}
+	
+	for attempt := 0; attempt < 2; attempt++ {
+		conn, err := w.connection()
+		if err != nil {
+			// Cannot report through the log package without recursing, and
+			// once per outage rather than once per queued line
+			if !w.down {
+				w.down = true
+				fmt.Fprintf(os.Stderr, "self-logging: cannot connect to %s, dropping log lines until it is reachable: %v\n", w.config.Address, err)
+			}
+			return
+		}
+		
+		conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if _, err := conn.Write(frame); err == nil {
+			if w.down {
+				w.down = false
+				fmt.Fprintf(os.Stderr, "self-logging: reconnected to %s\n", w.config.Address)
+			}
+			return
+		}
+		
+		w.mutex.Lock()
+		w.conn.Close()
+		w.conn = nil
+		w.mutex.Unlock()
+	}
+}
+
+// connection returns the current connection, dialing if needed. Only the run
+// goroutine dials, so the mutex is not held while it waits on the collector.
+func (w *SyslogWriter) connection() (net.Conn, error) {
+	w.mutex.Lock()
+	current := w.conn
+	w.mutex.Unlock()
+	if current != nil {
+		return current, nil
+	}
+	
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	
+	switch w.config.Protocol {
+	case "tls":
+		conn, err = tls.DialWithDialer(dialer, "tcp", w.config.Address, w.tlsConfig)
+	default:
+		conn, err = dialer.Dial(w.config.Protocol, w.config.Address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	w.mutex.Lock()
+	w.conn = conn
+	w.mutex.Unlock()
+	return conn, nil
+}
+
+// format renders a log line as an RFC 5424 message
+func (w *SyslogWriter) format(line string, now time.Time) []byte {
+	// The log package prefixes a date and time; the syslog header carries its own
+	if len(line) > 20 && line[4] == '/' && line[7] == '/' && line[10] == ' ' && line[13] == ':' {
+		line = line[20:]
+	}
+	
+	pri := w.config.Facility*8 + severityOf(line)
+	return []byte(fmt.Sprintf("<%d>1 %s %s %s %d - - %s",
+		pri,
+		now.Format(time.RFC3339Nano),
+		w.hostname,
+		w.config.AppName,
+		os.Getpid(),
+		line,
+	))
+}
+
+// Dropped returns the number of log lines dropped because the queue was full
+func (w *SyslogWriter) Dropped() int64 {
+	return atomic.LoadInt64(&w.dropped)
+}
+
+// severityOf infers a severity from the status markers used in log lines
+func severityOf(line string) int {
+	switch {
+	case strings.HasPrefix(line, "✗"), strings.HasPrefix(line, "❌"):
+		return severityError
+	case strings.HasPrefix(line, "⚠"), strings.HasPrefix(line, "Warning"):
+		return severityWarning
+	default:
+		return severityInfo
+	}
+}
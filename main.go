@@ -4,6 +4,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -33,14 +34,16 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 	
-	// Set up signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// Set up signal handling for graceful shutdown. Stopping closes the web
+	// server, so main waits for the rest of the shutdown before returning.
+	ctx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	stopped := make(chan struct{})
 	
 	go func() {
-		<-sigChan
+		<-ctx.Done()
 		application.Stop()
-		os.Exit(0)
+		close(stopped)
 	}()
 	
 	// Start syslog sources
@@ -48,6 +51,11 @@ func main() {
 		log.Fatalf("Failed to start syslog sources: %v", err)
 	}
 	
+	// Start optional background services (exporters, notifications)
+	if err := application.StartServices(); err != nil {
+		log.Fatalf("Failed to start background services: %v", err)
+	}
+	
 	// Display startup information
 	fmt.Printf("\n" + strings.Repeat("=", 60) + "\n")
 	fmt.Printf("🚀 Professional Syslog Analyzer Started Successfully!\n")
@@ -56,7 +64,11 @@ func main() {
 	fmt.Printf("🌐 Web Interface: http://localhost:%d\n", application.GetWebPort())
 	fmt.Printf("📡 Sources Loaded: %d\n", application.GetSourceCount())
 	fmt.Printf("⚡ Status: Ready to ingest data\n")
-	fmt.Printf("🔧 Config File: %s\n", configFile)
+	if mode := application.GetConfigMode(); mode.ReadOnly {
+		fmt.Printf("🔧 Config: %s (read-only, %d environment overrides)\n", mode.Origin, len(mode.Overrides))
+	} else {
+		fmt.Printf("🔧 Config File: %s\n", configFile)
+	}
 	fmt.Printf(strings.Repeat("=", 60) + "\n")
 	fmt.Printf("💡 Access the dashboard at: http://localhost:%d\n", application.GetWebPort())
 	fmt.Printf("🛑 Press Ctrl+C to stop the service\n")
@@ -68,4 +80,5 @@ func main() {
 			log.Fatalf("Failed to start web server: %v", err)
 		}
 	}
+	<-stopped
 }
\ No newline at end of file
@@ -0,0 +1,444 @@
+// Package migrate proposes analyzer sources for an existing rsyslog or
+// syslog-ng collector, from its configuration files. Network inputs become
+// sources listening on the same ports, sender filters become per-sender
+// sources and forwarding and file actions become relay and storage
+// destinations. What has no equivalent is reported as a warning, so the
+// proposal is reviewed before it is applied.
+package migrate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"syslog-analyzer/models"
+)
+
+// Collector formats
+const (
+	FormatRsyslog  = "rsyslog"
+	FormatSyslogNG = "syslog-ng"
+)
+
+// maxIncludeDepth bounds nested includes, which may also loop
+const maxIncludeDepth = 8
+
+// severityCodes maps the severity keywords both collectors accept to codes
+var severityCodes = map[string]int{
+	"emerg": 0, "panic": 0, "alert": 1, "crit": 2, "err": 3, "error": 3,
+	"warning": 4, "warn": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// listener is a network input of the collector
+type listener struct {
+	protocol string // "UDP", "TCP" or "RELP"
+	port     int
+	flow     string // Ruleset or source statement whose rules receive the input's messages
+}
+
+// rule sends the messages of a flow, optionally only those of one sender, to
+// a destination
+type rule struct {
+	flow        string
+	ip          string // Sender address; "" for any
+	hostname    string // Sender HOSTNAME; "" for any
+	minSeverity int    // Least severe code passed; 7 passes all
+	dest        models.Destination
+}
+
+// parser accumulates the listeners, rules and warnings of a configuration
+type parser struct {
+	listeners []listener
+	rules     []rule
+	warnings  []string
+	files     []string
+	visited   map[string]bool
+	root      string // Directory of the main file, for includes copied with it
+	syslogNG  *syslogNGConfig
+}
+
+func (p *parser) warn(format string, args ...interface{}) {
+	p.warnings = append(p.warnings, fmt.Sprintf(format, args...))
+}
+
+// Import reads the collector configuration at path, a file or a directory
+// of *.conf files, and proposes sources for it. format is FormatRsyslog,
+// FormatSyslogNG or "" to detect it from the configuration.
+func Import(path, format string) (models.CollectorImport, error) {
+	files, err := configFiles(path)
+	if err != nil {
+		return models.CollectorImport{}, err
+	}
+	if format == "" {
+		format = detectFormat(files[0])
+	}
+	
+	root := path
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		root = filepath.Dir(path)
+	}
+	p := &parser{visited: make(map[string]bool), root: root}
+	for _, file := range files {
+		switch format {
+		case FormatRsyslog:
+			err = p.parseRsyslogFile(file, 0)
+		case FormatSyslogNG:
+			err = p.parseSyslogNGFile(file, 0)
+		default:
+			return models.CollectorImport{}, fmt.Errorf("unknown collector format %q (expected %s or %s)", format, FormatRsyslog, FormatSyslogNG)
+		}
+		if err != nil {
+			return models.CollectorImport{}, err
+		}
+	}
+	p.resolveSyslogNG()
+	
+	result := models.CollectorImport{
+		Format:   format,
+		Files:    p.files,
+		Sources:  p.propose(format),
+		Warnings: p.warnings,
+	}
+	if result.Warnings == nil {
+		result.Warnings = []string{}
+	}
+	if len(p.listeners) == 0 {
+		result.Warnings = append(result.Warnings, "no network inputs found; the collector only reads local logs")
+	}
+	return result, nil
+}
+
+// configFiles returns path, or the *.conf files of a directory in name order
+func configFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(path, "*.conf"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .conf files in %s", path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// detectFormat tells syslog-ng configurations, which declare a version and
+// named source blocks, from rsyslog ones
+func detectFormat(file string) string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return FormatRsyslog
+	}
+	text := string(data)
+	if strings.Contains(filepath.Base(file), "syslog-ng") || strings.Contains(text, "@version") {
+		return FormatSyslogNG
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "source ") && strings.Contains(line, "{") {
+			return FormatSyslogNG
+		}
+	}
+	return FormatRsyslog
+}
+
+// readConfig reads a configuration file once, returning false for files
+// already read
+func (p *parser) readConfig(file string, depth int) (string, bool, error) {
+	if depth > maxIncludeDepth {
+		p.warn("includes nested deeper than %d levels at %s were not read", maxIncludeDepth, file)
+		return "", false, nil
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", false, err
+	}
+	if p.visited[abs] {
+		return "", false, nil
+	}
+	p.visited[abs] = true
+	
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if depth == 0 {
+			return "", false, err
+		}
+		p.warn("included file %s could not be read: %v", file, err)
+		return "", false, nil
+	}
+	p.files = append(p.files, file)
+	return string(data), true, nil
+}
+
+// resolveInclude expands an include pattern. Patterns that match nothing as
+// written, typically absolute paths of the collector host, are tried again
+// under the directory of the main file with their last two elements, so a
+// copied /etc/rsyslog.conf and /etc/rsyslog.d/ are found side by side.
+func (p *parser) resolveInclude(pattern, from string) []string {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(from), pattern)
+	}
+	if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+		return matches
+	}
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		matches, _ := filepath.Glob(filepath.Join(pattern, "*.conf"))
+		return matches
+	}
+	
+	parent := filepath.Base(filepath.Dir(pattern))
+	local := filepath.Join(p.root, parent, filepath.Base(pattern))
+	if matches, _ := filepath.Glob(local); len(matches) > 0 {
+		return matches
+	}
+	if info, err := os.Stat(filepath.Join(p.root, filepath.Base(pattern))); err == nil && info.IsDir() {
+		matches, _ := filepath.Glob(filepath.Join(p.root, filepath.Base(pattern), "*.conf"))
+		return matches
+	}
+	p.warn("include %s matched no files", pattern)
+	return nil
+}
+
+// parseSeverity reads a severity keyword or code
+func parseSeverity(text string) (int, bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if code, ok := severityCodes[text]; ok {
+		return code, true
+	}
+	if len(text) == 1 && text[0] >= '0' && text[0] <= '7' {
+		return int(text[0] - '0'), true
+	}
+	return 0, false
+}
+
+// portGroup is the listeners and rules that end up on one analyzer port
+type portGroup struct {
+	port      int
+	protocols map[string]bool
+	flows     []string
+}
+
+// propose turns the listeners and rules into sources: one per port for
+// messages from any sender, and one per sender the rules single out, which
+// also gets the rules for any sender since the analyzer routes a sender to
+// one source only
+func (p *parser) propose(format string) []models.SourceConfig {
+	groups := make(map[int]*portGroup)
+	var ports []int
+	for _, l := range p.listeners {
+		group, exists := groups[l.port]
+		if !exists {
+			group = &portGroup{port: l.port, protocols: make(map[string]bool)}
+			groups[l.port] = group
+			ports = append(ports, l.port)
+		}
+		group.protocols[l.protocol] = true
+		if !containsString(group.flows, l.flow) {
+			group.flows = append(group.flows, l.flow)
+		}
+	}
+	sort.Ints(ports)
+	
+	sources := []models.SourceConfig{}
+	names := make(map[string]bool)
+	for _, port := range ports {
+		group := groups[port]
+		protocol := "UDP"
+		switch {
+		case group.protocols[models.ProtocolRELP]:
+			// A port takes plain syslog or RELP on TCP, not both
+			protocol = models.ProtocolRELP
+			if len(group.protocols) > 1 {
+				p.warn("port %d also takes plain syslog; only RELP is proposed", port)
+			}
+		case group.protocols["UDP"] && group.protocols["TCP"]:
+			protocol = "UDP+TCP"
+		case group.protocols["TCP"]:
+			protocol = "TCP"
+		}
+	
+		var anySender []rule
+		senders := make(map[string][]rule)
+		var senderKeys []string
+		for _, r := range p.rules {
+			if !containsString(group.flows, r.flow) {
+				continue
+			}
+			if r.ip == "" && r.hostname == "" {
+				anySender = append(anySender, r)
+				continue
+			}
+			key := r.ip + "/" + r.hostname
+			if _, exists := senders[key]; !exists {
+				senderKeys = append(senderKeys, key)
+			}
+			senders[key] = append(senders[key], r)
+		}
+	
+		base := sourceName(format, group.flows[0], port)
+		if len(anySender) > 0 || len(senderKeys) == 0 {
+			sources = append(sources, p.newSource(uniqueName(names, base), "0.0.0.0", "", port, protocol, anySender))
+		}
+		for _, key := range senderKeys {
+			rules := append(append([]rule{}, senders[key]...), anySender...)
+			ip, hostname := rules[0].ip, rules[0].hostname
+			if ip == "" {
+				ip = "0.0.0.0"
+			}
+			if hostname != "" && protocol == "UDP" {
+				p.warn("sender %s on UDP port %d: the analyzer only attributes TCP connections by hostname", hostname, port)
+			}
+			suffix := ip
+			if hostname != "" {
+				suffix = hostname
+			}
+			sources = append(sources, p.newSource(uniqueName(names, base+"-"+suffix), ip, hostname, port, protocol, rules))
+		}
+	}
+	return sources
+}
+
+// newSource builds a source from the rules delivering its messages
+func (p *parser) newSource(name, ip, hostname string, port int, protocol string, rules []rule) models.SourceConfig {
+	source := models.SourceConfig{
+		Name:         name,
+		IP:           ip,
+		Port:         port,
+		Protocol:     protocol,
+		Hostname:     hostname,
+		Destinations: []models.Destination{},
+		Filters:      []models.FilterRule{},
+		Aggregations: []models.AggregationRule{},
+	}
+	if len(rules) == 0 {
+		p.warn("source %s: no actions found for its messages; add destinations", name)
+	}
+	
+	// The analyzer has one severity threshold per source; the least strict
+	// rule wins so no destination loses events
+	minSeverity := -1
+	seen := make(map[string]bool)
+	destNames := make(map[string]bool)
+	for _, r := range rules {
+		if r.minSeverity > minSeverity {
+			minSeverity = r.minSeverity
+		}
+		key := destinationKey(r.dest)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		dest := r.dest
+		dest.Name = uniqueName(destNames, dest.Name)
+		source.Destinations = append(source.Destinations, dest)
+	}
+	for _, r := range rules {
+		if r.minSeverity != minSeverity {
+			p.warn("source %s: actions filter on different severities; min_severity keeps the least strict", name)
+			break
+		}
+	}
+	if minSeverity >= 0 && minSeverity < 7 {
+		source.MinSeverity = models.SeverityNames[minSeverity]
+	}
+	return source
+}
+
+// destinationKey identifies a destination by what it delivers to
+func destinationKey(dest models.Destination) string {
+	config, _ := dest.Config.(map[string]interface{})
+	return fmt.Sprintf("%s %v %v %v", dest.Type, config["address"], config["path"], config["url"])
+}
+
+// relayDestination forwards to another collector. The relay sends JSON
+// lines over TCP, so UDP forwarding and plain syslog framing are noted.
+func (p *parser) relayDestination(host string, port int, protocol, context string) models.Destination {
+	if protocol == "UDP" {
+		p.warn("%s: UDP forwarding to %s is proposed as a TCP relay", context, host)
+	}
+	p.warn("%s: the relay to %s:%d sends one JSON event per line; check the receiver accepts it", context, host, port)
+	return models.Destination{
+		Type:    "relay",
+		Name:    "relay-" + host,
+		Enabled: true,
+		Config:  map[string]interface{}{"address": joinHostPort(host, port)},
+	}
+}
+
+// storageDestination writes to the directory of a collector's log file
+func storageDestination(file string) models.Destination {
+	dir := filepath.ToSlash(filepath.Dir(file))
+	return models.Destination{
+		Type:    "storage",
+		Name:    "storage-" + filepath.Base(dir),
+		Enabled: true,
+		Config:  map[string]interface{}{"path": dir, "max_events_per_file": 100000},
+	}
+}
+
+// hecDestination posts to an HTTP collector, which needs its token added
+func (p *parser) hecDestination(url, context string) models.Destination {
+	p.warn("%s: HTTP output %s is proposed as a HEC destination; set its api_key", context, url)
+	return models.Destination{
+		Type:    "hec",
+		Name:    "hec",
+		Enabled: true,
+		Config:  map[string]interface{}{"url": url, "api_key": "", "verify_ssl": true},
+	}
+}
+
+func joinHostPort(host string, port int) string {
+	if strings.Contains(host, ":") {
+		return fmt.Sprintf("[%s]:%d", host, port)
+	}
+	return fmt.Sprintf("%s:%d", host, port)
+}
+
+// sourceName derives a source name from the collector's name for the flow
+func sourceName(format, flow string, port int) string {
+	if flow == "" {
+		flow = format
+	}
+	return fmt.Sprintf("%s-%d", flow, port)
+}
+
+// uniqueName makes a name valid for the analyzer, and unique among names
+func uniqueName(names map[string]bool, name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	name = strings.TrimLeft(b.String(), ".-_")
+	if name == "" {
+		name = "imported"
+	}
+	unique := name
+	for i := 2; names[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	names[unique] = true
+	return unique
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
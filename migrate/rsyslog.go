@@ -0,0 +1,617 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"syslog-analyzer/models"
+)
+
+// rsyslogSenderCondition matches the sender comparisons of an if statement
+var rsyslogSenderCondition = regexp.MustCompile(`\$(fromhost-ip|fromhost|hostname)\s*==\s*["']([^"']+)["']`)
+
+// rsyslogScope is what the statements of a block apply to
+type rsyslogScope struct {
+	ruleset  string
+	ip       string
+	hostname string
+	skip     bool // Template and other blocks holding no actions
+}
+
+// rsyslogParser walks one file's statements
+type rsyslogParser struct {
+	*parser
+	file       string
+	depth      int
+	scopes     []rsyslogScope
+	pending    *rsyslogScope // Scope of the block the next "{" opens
+	udpRuleset string        // Set by $InputUDPServerBindRuleset
+	tcpRuleset string        // Set by $InputTCPServerBindRuleset
+	lastFilter rsyslogFilter // Filter of the last selector line, for "&" lines
+}
+
+// rsyslogFilter is the sender and severity a selector or filter passes
+type rsyslogFilter struct {
+	ip          string
+	hostname    string
+	minSeverity int
+}
+
+// call is a RainerScript statement such as action(type="omfwd" ...)
+type call struct {
+	name   string
+	params map[string]string
+}
+
+// parseRsyslogFile reads an rsyslog configuration and the files it includes
+func (p *parser) parseRsyslogFile(file string, depth int) error {
+	text, ok, err := p.readConfig(file, depth)
+	if err != nil || !ok {
+		return err
+	}
+	r := &rsyslogParser{parser: p, file: file, depth: depth, scopes: []rsyslogScope{{}}}
+	for _, statement := range splitRsyslog(text) {
+		if err := r.statement(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitRsyslog splits a configuration into statements: lines, with
+// parentheses spanning lines, and braces as statements of their own.
+// Comments are dropped.
+func splitRsyslog(text string) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			statements = append(statements, s)
+		}
+		current.Reset()
+	}
+	
+	var quote byte
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			current.WriteByte(c)
+			if c == '\\' && i+1 < len(text) {
+				i++
+				current.WriteByte(text[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			current.WriteByte(c)
+		case c == '#':
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			if depth == 0 {
+				flush()
+			}
+		case c == '(':
+			depth++
+			current.WriteByte(c)
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+			current.WriteByte(c)
+		case depth == 0 && c == '\n':
+			flush()
+		case depth == 0 && (c == '{' || c == '}'):
+			flush()
+			statements = append(statements, string(c))
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+func (r *rsyslogParser) scope() rsyslogScope {
+	return r.scopes[len(r.scopes)-1]
+}
+
+func (r *rsyslogParser) context() string {
+	return r.file
+}
+
+// statement handles one statement under the current scope
+func (r *rsyslogParser) statement(s string) error {
+	scope := r.scope()
+	switch {
+	case s == "{":
+		next := scope
+		if r.pending != nil {
+			next = *r.pending
+			r.pending = nil
+		}
+		r.scopes = append(r.scopes, next)
+		return nil
+	case s == "}":
+		if len(r.scopes) > 1 {
+			r.scopes = r.scopes[:len(r.scopes)-1]
+		}
+		return nil
+	case scope.skip:
+		return nil
+	case strings.HasPrefix(s, "$"):
+		return r.directive(s)
+	case strings.HasPrefix(s, "if ") || strings.HasPrefix(s, "if("):
+		return r.ifStatement(s)
+	case s == "else" || strings.HasPrefix(s, "else "):
+		r.warn("%s: else branches are not translated; their actions get the messages of the enclosing block", r.context())
+		r.pending = &scope
+		if rest := strings.TrimSpace(strings.TrimPrefix(s, "else")); rest != "" && !strings.HasPrefix(rest, "if") {
+			return r.actionText(rest, rsyslogFilter{ip: scope.ip, hostname: scope.hostname, minSeverity: 7})
+		}
+		return nil
+	case strings.HasPrefix(s, ":"):
+		return r.propertyFilter(s)
+	case strings.HasPrefix(s, "&"):
+		return r.actionText(strings.TrimSpace(s[1:]), r.lastFilter)
+	case s == "stop" || s == "~":
+		return nil
+	case isCall(s):
+		return r.calls(s, rsyslogFilter{ip: scope.ip, hostname: scope.hostname, minSeverity: 7})
+	case strings.HasPrefix(s, "call ") || strings.HasPrefix(s, "set ") || strings.HasPrefix(s, "unset "):
+		r.warn("%s: %q is not translated", r.context(), s)
+		return nil
+	}
+	
+	// Legacy "selector action" line
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		r.warn("%s: %q is not understood", r.context(), s)
+		return nil
+	}
+	filter := rsyslogFilter{ip: scope.ip, hostname: scope.hostname, minSeverity: r.selector(fields[0])}
+	r.lastFilter = filter
+	return r.actionText(strings.TrimSpace(strings.TrimPrefix(s, fields[0])), filter)
+}
+
+// directive handles a legacy $ directive
+func (r *rsyslogParser) directive(s string) error {
+	fields := strings.Fields(s)
+	name := strings.ToLower(fields[0])
+	arg := ""
+	if len(fields) > 1 {
+		arg = fields[1]
+	}
+	
+	switch name {
+	case "$udpserverrun":
+		r.addListener("UDP", arg, r.udpRuleset)
+	case "$inputtcpserverrun", "$inputptcpserverrun":
+		r.addListener("TCP", arg, r.tcpRuleset)
+	case "$inputrelpserverrun":
+		r.addListener(models.ProtocolRELP, arg, "")
+	case "$inputudpserverbindruleset":
+		r.udpRuleset = rulesetName(arg)
+	case "$inputtcpserverbindruleset", "$inputptcpserverbindruleset":
+		r.tcpRuleset = rulesetName(arg)
+	case "$ruleset":
+		r.scopes[len(r.scopes)-1].ruleset = rulesetName(arg)
+	case "$includeconfig":
+		return r.include(arg)
+	}
+	return nil
+}
+
+// rulesetName maps the default ruleset's name to the unnamed flow
+func rulesetName(name string) string {
+	name = strings.Trim(name, `"'`)
+	if name == "RSYSLOG_DefaultRuleset" {
+		return ""
+	}
+	return name
+}
+
+// addListener records an input; ports may be lists such as ["514","1514"]
+func (r *rsyslogParser) addListener(protocol, ports, ruleset string) {
+	numbers := regexp.MustCompile(`\d+`).FindAllString(ports, -1)
+	if len(numbers) == 0 {
+		numbers = []string{"514"}
+	}
+	for _, number := range numbers {
+		port, err := strconv.Atoi(number)
+		if err != nil || port < 1 || port > 65535 {
+			r.warn("%s: invalid port %s", r.context(), number)
+			continue
+		}
+		r.listeners = append(r.listeners, listener{protocol: protocol, port: port, flow: ruleset})
+	}
+}
+
+// include reads the files an include pattern names
+func (r *rsyslogParser) include(pattern string) error {
+	pattern = strings.Trim(pattern, `"'`)
+	for _, file := range r.resolveInclude(pattern, r.file) {
+		if err := r.parseRsyslogFile(file, r.depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ifStatement handles "if <condition> then ...". Sender comparisons become
+// per-sender sources; other conditions are dropped with a warning.
+func (r *rsyslogParser) ifStatement(s string) error {
+	scope := r.scope()
+	index := strings.Index(s, " then")
+	if index < 0 {
+		r.warn("%s: %q is not understood", r.context(), s)
+		return nil
+	}
+	condition := strings.TrimSpace(s[2:index])
+	rest := strings.TrimSpace(s[index+len(" then"):])
+	
+	next := scope
+	matches := rsyslogSenderCondition.FindAllStringSubmatch(condition, -1)
+	if len(matches) == 1 && !strings.Contains(condition, " or ") {
+		if matches[0][1] == "fromhost-ip" {
+			next.ip = matches[0][2]
+		} else {
+			next.hostname = strings.ToLower(matches[0][2])
+		}
+		if strings.Contains(condition, " and ") {
+			r.warn("%s: only the sender of condition %q is translated", r.context(), condition)
+		}
+	} else {
+		r.warn("%s: condition %q is not translated; its actions get all messages", r.context(), condition)
+	}
+	
+	if rest == "" {
+		r.pending = &next
+		return nil
+	}
+	return r.actionText(rest, rsyslogFilter{ip: next.ip, hostname: next.hostname, minSeverity: 7})
+}
+
+// propertyFilter handles ":property, operation, "value" action"
+func (r *rsyslogParser) propertyFilter(s string) error {
+	scope := r.scope()
+	parts := strings.SplitN(s[1:], ",", 3)
+	if len(parts) < 3 {
+		r.warn("%s: %q is not understood", r.context(), s)
+		return nil
+	}
+	property := strings.ToLower(strings.TrimSpace(parts[0]))
+	operation := strings.ToLower(strings.TrimSpace(parts[1]))
+	rest := strings.TrimSpace(parts[2])
+	
+	value, action := rest, ""
+	if len(rest) > 0 && rest[0] == '"' {
+		if end := strings.Index(rest[1:], `"`); end >= 0 {
+			value, action = rest[1:end+1], strings.TrimSpace(rest[end+2:])
+		}
+	}
+	
+	filter := rsyslogFilter{ip: scope.ip, hostname: scope.hostname, minSeverity: 7}
+	switch {
+	case operation == "isequal" && property == "fromhost-ip":
+		filter.ip = value
+	case operation == "isequal" && (property == "fromhost" || property == "hostname"):
+		filter.hostname = strings.ToLower(value)
+	default:
+		r.warn("%s: filter :%s, %s, %q is not translated; its action gets all messages", r.context(), property, operation, value)
+	}
+	r.lastFilter = filter
+	return r.actionText(action, filter)
+}
+
+// selector returns the least severe code a selector such as
+// "*.info;mail.none" passes
+func (r *rsyslogParser) selector(selector string) int {
+	least := -1
+	for _, part := range strings.Split(selector, ";") {
+		dot := strings.LastIndex(part, ".")
+		if dot < 0 {
+			r.warn("%s: selector %q is not understood", r.context(), selector)
+			return 7
+		}
+		facility, priority := part[:dot], strings.ToLower(part[dot+1:])
+		if priority == "none" {
+			continue
+		}
+		if facility != "*" {
+			r.warn("%s: selector %q filters on facility; the proposal keeps every facility", r.context(), selector)
+		}
+		if strings.HasPrefix(priority, "=") || strings.HasPrefix(priority, "!") {
+			r.warn("%s: selector %q uses an exact or negated severity; it is approximated by a minimum", r.context(), selector)
+			priority = strings.TrimLeft(priority, "=!")
+		}
+		code := 7
+		if priority != "*" {
+			parsed, ok := parseSeverity(priority)
+			if !ok {
+				r.warn("%s: unknown severity %q in selector %q", r.context(), priority, selector)
+			} else {
+				code = parsed
+			}
+		}
+		if code > least {
+			least = code
+		}
+	}
+	if least < 0 {
+		return 7
+	}
+	return least
+}
+
+// actionText handles the action part of a line: a legacy action or
+// RainerScript calls
+func (r *rsyslogParser) actionText(action string, filter rsyslogFilter) error {
+	if action == "" {
+		return nil
+	}
+	if isCall(action) {
+		return r.calls(action, filter)
+	}
+	
+	// Drop the ";Template" suffix of legacy actions
+	target := action
+	if i := strings.Index(target, ";"); i >= 0 {
+		target = target[:i]
+	}
+	target = strings.TrimSpace(target)
+	
+	switch {
+	case target == "~" || target == "stop":
+	case strings.HasPrefix(target, "@"):
+		protocol := "UDP"
+		target = target[1:]
+		if strings.HasPrefix(target, "@") {
+			protocol = "TCP"
+			target = target[1:]
+		}
+		if strings.HasPrefix(target, "(") {
+			if end := strings.Index(target, ")"); end >= 0 {
+				target = target[end+1:]
+			}
+		}
+		host, port := splitTarget(target, 514)
+		r.addRule(filter, r.relayDestination(host, port, protocol, r.context()))
+	case strings.HasPrefix(target, "/") || strings.HasPrefix(target, "-/"):
+		r.addRule(filter, storageDestination(strings.TrimPrefix(target, "-")))
+	case strings.HasPrefix(target, "?") || strings.HasPrefix(target, "-?"):
+		r.warn("%s: dynamic file %s is proposed as nothing; add a storage destination", r.context(), target)
+	case strings.HasPrefix(target, ":omusrmsg:") || target == "*" || !strings.ContainsAny(target, "/:|"):
+		// Messages to logged-in users
+	default:
+		r.warn("%s: action %q is not carried over", r.context(), target)
+	}
+	return nil
+}
+
+// calls handles RainerScript statements; several may share a line
+func (r *rsyslogParser) calls(s string, filter rsyslogFilter) error {
+	calls, rest := parseCalls(s)
+	for _, c := range calls {
+		switch strings.ToLower(c.name) {
+		case "input":
+			r.input(c)
+		case "action":
+			r.action(c, filter)
+		case "ruleset":
+			r.pending = &rsyslogScope{ruleset: rulesetName(c.params["name"])}
+		case "include":
+			if file := c.params["file"]; file != "" {
+				if err := r.include(file); err != nil {
+					return err
+				}
+			} else {
+				r.warn("%s: include(text=...) is not read", r.context())
+			}
+		case "template":
+			r.pending = &rsyslogScope{skip: true}
+		}
+	}
+	if rest != "" && rest != "stop" {
+		r.warn("%s: %q is not understood", r.context(), rest)
+	}
+	return nil
+}
+
+// input handles input(type=... port=... ruleset=...)
+func (r *rsyslogParser) input(c call) {
+	ruleset := rulesetName(c.params["ruleset"])
+	switch strings.ToLower(c.params["type"]) {
+	case "imudp":
+		r.addListener("UDP", c.params["port"], ruleset)
+	case "imtcp", "imptcp":
+		r.addListener("TCP", c.params["port"], ruleset)
+	case "imrelp":
+		r.addListener(models.ProtocolRELP, c.params["port"], ruleset)
+	}
+}
+
+// action handles action(type=...)
+func (r *rsyslogParser) action(c call, filter rsyslogFilter) {
+	context := r.context()
+	switch kind := strings.ToLower(c.params["type"]); kind {
+	case "omfwd", "omrelp":
+		port := 514
+		if value, err := strconv.Atoi(c.params["port"]); err == nil {
+			port = value
+		}
+		protocol := "UDP"
+		if kind == "omrelp" || strings.EqualFold(c.params["protocol"], "tcp") {
+			protocol = "TCP"
+		}
+		if c.params["target"] == "" {
+			r.warn("%s: %s action without a target is not carried over", context, kind)
+			return
+		}
+		r.addRule(filter, r.relayDestination(c.params["target"], port, protocol, context))
+	case "omfile":
+		if file := c.params["file"]; file != "" {
+			r.addRule(filter, storageDestination(file))
+		} else {
+			r.warn("%s: omfile with dynaFile %q is not carried over; add a storage destination", context, c.params["dynafile"])
+		}
+	case "omhttp":
+		scheme := "http"
+		if c.params["usehttps"] == "on" || c.params["usehttps"] == "1" {
+			scheme = "https"
+		}
+		server := c.params["server"]
+		if server == "" {
+			server = "localhost"
+		}
+		port := c.params["serverport"]
+		if port == "" {
+			port = "443"
+		}
+		url := fmt.Sprintf("%s://%s:%s/%s", scheme, server, port, strings.TrimPrefix(c.params["restpath"], "/"))
+		r.addRule(filter, r.hecDestination(url, context))
+	default:
+		r.warn("%s: %s action is not carried over", context, kind)
+	}
+}
+
+// addRule records a destination of the current ruleset
+func (r *rsyslogParser) addRule(filter rsyslogFilter, dest models.Destination) {
+	r.rules = append(r.rules, rule{
+		flow:        r.scope().ruleset,
+		ip:          filter.ip,
+		hostname:    filter.hostname,
+		minSeverity: filter.minSeverity,
+		dest:        dest,
+	})
+}
+
+// isCall reports whether s starts with a RainerScript call such as "action("
+func isCall(s string) bool {
+	i := 0
+	for i < len(s) && (isWordByte(s[i]) || s[i] == '_') {
+		i++
+	}
+	rest := strings.TrimLeft(s[i:], " \t")
+	return i > 0 && strings.HasPrefix(rest, "(")
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-'
+}
+
+// parseCalls reads consecutive name(param="value" ...) calls, returning
+// what follows them. Parameter names are lower-cased, as rsyslog ignores
+// their case.
+func parseCalls(s string) ([]call, string) {
+	var calls []call
+	for {
+		s = strings.TrimSpace(s)
+		if !isCall(s) {
+			return calls, s
+		}
+		open := strings.IndexByte(s, '(')
+		name := strings.TrimSpace(s[:open])
+		end := matchingParen(s, open)
+		if end < 0 {
+			return calls, s
+		}
+		calls = append(calls, call{name: name, params: parseParams(s[open+1 : end])})
+		s = s[end+1:]
+	}
+}
+
+// matchingParen returns the index of the parenthesis closing the one at
+// open, skipping quoted text
+func matchingParen(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseParams reads key="value" pairs; list values keep their brackets
+func parseParams(s string) map[string]string {
+	params := make(map[string]string)
+	for {
+		s = strings.TrimSpace(s)
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return params
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimSpace(s[eq+1:])
+	
+		var value string
+		switch {
+		case s == "":
+		case s[0] == '"' || s[0] == '\'':
+			end := 1
+			for end < len(s) && s[end] != s[0] {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			value = s[1:min(end, len(s))]
+			s = s[min(end+1, len(s)):]
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				end = len(s) - 1
+			}
+			value = s[:end+1]
+			s = s[end+1:]
+		default:
+			end := strings.IndexAny(s, " \t\n")
+			if end < 0 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+		}
+		params[key] = value
+	}
+}
+
+// splitTarget splits "host:port" or "[v6]:port" with a default port
+func splitTarget(target string, defaultPort int) (string, int) {
+	host, portText := target, ""
+	if strings.HasPrefix(target, "[") {
+		if end := strings.Index(target, "]"); end >= 0 {
+			host = target[1:end]
+			portText = strings.TrimPrefix(target[end+1:], ":")
+		}
+	} else if i := strings.LastIndex(target, ":"); i >= 0 && strings.Count(target, ":") == 1 {
+		host, portText = target[:i], target[i+1:]
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		port = defaultPort
+	}
+	return host, port
+}
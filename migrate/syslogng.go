@@ -0,0 +1,466 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"syslog-analyzer/models"
+)
+
+// syslogNGNode is a syslog-ng option such as port(514) or a bare value
+type syslogNGNode struct {
+	name  string // Option name; "" for a bare value
+	value string // Bare word or string
+	args  []syslogNGNode
+}
+
+// syslogNGConfig holds the named blocks of a configuration. Log paths are
+// resolved once every file has been read, since blocks may be defined in a
+// later include.
+type syslogNGConfig struct {
+	sources      map[string][]syslogNGNode
+	destinations map[string][]syslogNGNode
+	filters      map[string][]syslogNGNode
+	logs         []syslogNGLog
+	resolved     map[string][]models.Destination
+}
+
+// syslogNGLog is a log path and the file it was read from
+type syslogNGLog struct {
+	file string
+	body []syslogNGNode
+}
+
+// parseSyslogNGFile reads a syslog-ng configuration and the files it includes
+func (p *parser) parseSyslogNGFile(file string, depth int) error {
+	text, ok, err := p.readConfig(file, depth)
+	if err != nil || !ok {
+		return err
+	}
+	if p.syslogNG == nil {
+		p.syslogNG = &syslogNGConfig{
+			sources:      make(map[string][]syslogNGNode),
+			destinations: make(map[string][]syslogNGNode),
+			filters:      make(map[string][]syslogNGNode),
+			resolved:     make(map[string][]models.Destination),
+		}
+	}
+	
+	tokens, includes := tokenizeSyslogNG(text)
+	for _, include := range includes {
+		// scl.conf is syslog-ng's own library of source and destination
+		// definitions, installed with it
+		if include == "scl.conf" || strings.HasPrefix(include, "scl/") {
+			continue
+		}
+		for _, included := range p.resolveInclude(include, file) {
+			if err := p.parseSyslogNGFile(included, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	
+	for i := 0; i < len(tokens); {
+		kind := tokens[i]
+		i++
+		name := ""
+		if i < len(tokens) && tokens[i] != "{" && tokens[i] != ";" {
+			name = unquote(tokens[i])
+			i++
+		}
+		if i >= len(tokens) || tokens[i] != "{" {
+			// Statements without a block end at the next ";"
+			for i < len(tokens) && tokens[i] != ";" {
+				i++
+			}
+			i++
+			continue
+		}
+		i++
+		body := parseSyslogNGNodes(tokens, &i, "}")
+		if i < len(tokens) && tokens[i] == ";" {
+			i++
+		}
+	
+		switch kind {
+		case "source":
+			p.syslogNG.sources[name] = body
+		case "destination":
+			p.syslogNG.destinations[name] = body
+		case "filter":
+			p.syslogNG.filters[name] = body
+		case "log":
+			p.syslogNG.logs = append(p.syslogNG.logs, syslogNGLog{file: file, body: body})
+		}
+	}
+	return nil
+}
+
+// tokenizeSyslogNG splits a configuration into words, strings and
+// punctuation, dropping comments and returning @include targets separately
+func tokenizeSyslogNG(text string) ([]string, []string) {
+	var tokens, includes []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "@") {
+			fields := strings.Fields(trimmed)
+			if fields[0] == "@include" && len(fields) > 1 {
+				includes = append(includes, unquote(fields[1]))
+			}
+			continue
+		}
+	
+		for i := 0; i < len(line); {
+			c := line[i]
+			switch {
+			case c == '#':
+				i = len(line)
+			case c == ' ' || c == '\t' || c == '\r':
+				i++
+			case c == '"' || c == '\'':
+				end := i + 1
+				for end < len(line) && line[end] != c {
+					if line[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				end = min(end+1, len(line))
+				tokens = append(tokens, line[i:end])
+				i = end
+			case strings.IndexByte("(){};,", c) >= 0:
+				tokens = append(tokens, string(c))
+				i++
+			default:
+				end := i
+				for end < len(line) && strings.IndexByte("(){};,\"' \t\r#", line[end]) < 0 {
+					end++
+				}
+				tokens = append(tokens, line[i:end])
+				i = end
+			}
+		}
+	}
+	return tokens, includes
+}
+
+// parseSyslogNGNodes reads options up to the closing token
+func parseSyslogNGNodes(tokens []string, i *int, closing string) []syslogNGNode {
+	var nodes []syslogNGNode
+	for *i < len(tokens) {
+		token := tokens[*i]
+		*i++
+		switch {
+		case token == closing:
+			return nodes
+		case token == ";" || token == ",":
+		case token == "(":
+			// Grouping in filter expressions
+			nodes = append(nodes, syslogNGNode{name: "(", args: parseSyslogNGNodes(tokens, i, ")")})
+		case token == "{":
+			// Inline blocks are kept as a node of their own
+			nodes = append(nodes, syslogNGNode{name: "{", args: parseSyslogNGNodes(tokens, i, "}")})
+		case *i < len(tokens) && tokens[*i] == "(":
+			*i++
+			nodes = append(nodes, syslogNGNode{name: token, args: parseSyslogNGNodes(tokens, i, ")")})
+		default:
+			nodes = append(nodes, syslogNGNode{value: unquote(token)})
+		}
+	}
+	return nodes
+}
+
+func unquote(token string) string {
+	if len(token) >= 2 && (token[0] == '"' || token[0] == '\'') && token[len(token)-1] == token[0] {
+		return strings.ReplaceAll(token[1:len(token)-1], `\"`, `"`)
+	}
+	return token
+}
+
+// option returns the bare value of a named option, such as port(514)
+func option(nodes []syslogNGNode, name string) string {
+	for _, node := range nodes {
+		if node.name == name && len(node.args) > 0 {
+			return node.args[0].value
+		}
+	}
+	return ""
+}
+
+// firstValue returns the first bare value, such as the host of tcp("host")
+func firstValue(nodes []syslogNGNode) string {
+	for _, node := range nodes {
+		if node.name == "" {
+			return node.value
+		}
+	}
+	return ""
+}
+
+// resolveSyslogNG turns the log paths into listeners and rules. A source
+// statement's listeners form a flow; each log path adds rules to the flows
+// of the sources it names.
+func (p *parser) resolveSyslogNG() {
+	config := p.syslogNG
+	if config == nil {
+		return
+	}
+	used := make(map[string]bool)
+	for _, log := range config.logs {
+		var sources []string
+		var filters []syslogNGNode
+		var destinations []models.Destination
+		for _, node := range log.body {
+			name := firstValue(node.args)
+			switch node.name {
+			case "source":
+				if _, exists := config.sources[name]; !exists {
+					p.warn("%s: log path uses undefined source %q", log.file, name)
+					continue
+				}
+				sources = append(sources, name)
+			case "filter":
+				body, exists := config.filters[name]
+				if !exists {
+					p.warn("%s: log path uses undefined filter %q", log.file, name)
+					continue
+				}
+				filters = append(filters, body...)
+			case "destination":
+				if _, exists := config.destinations[name]; !exists {
+					p.warn("%s: log path uses undefined destination %q", log.file, name)
+					continue
+				}
+				destinations = append(destinations, p.syslogNGDestinations(name, log.file)...)
+			case "flags":
+				for _, flag := range node.args {
+					if flag.value == "final" {
+						p.warn("%s: flags(final) is not translated; later log paths may add destinations for its messages", log.file)
+					}
+				}
+			case "":
+				if node.value != "" {
+					p.warn("%s: %q in a log path is not understood", log.file, node.value)
+				}
+			default:
+				p.warn("%s: %s() in a log path is not translated", log.file, node.name)
+			}
+		}
+	
+		filter := p.syslogNGFilter(filters, log.file)
+		for _, source := range sources {
+			if !used[source] {
+				used[source] = true
+				p.syslogNGListeners(source, config.sources[source], log.file)
+			}
+			for _, dest := range destinations {
+				p.rules = append(p.rules, rule{
+					flow:        source,
+					ip:          filter.ip,
+					hostname:    filter.hostname,
+					minSeverity: filter.minSeverity,
+					dest:        dest,
+				})
+			}
+		}
+	}
+}
+
+// syslogNGListeners records the network drivers of a source statement
+func (p *parser) syslogNGListeners(name string, drivers []syslogNGNode, file string) {
+	for _, driver := range drivers {
+		transport := strings.ToLower(option(driver.args, "transport"))
+		port := 514
+		protocol := "TCP"
+		switch driver.name {
+		case "udp", "udp6":
+			protocol = "UDP"
+		case "tcp", "tcp6":
+		case "network":
+			if transport == "udp" {
+				protocol = "UDP"
+			}
+		case "syslog":
+			port = 601
+			if transport == "udp" {
+				protocol = "UDP"
+				port = 514
+			}
+		case "system", "internal", "file", "wildcard-file", "unix-stream", "unix-dgram", "pipe", "systemd-journal":
+			continue
+		default:
+			p.warn("%s: source driver %s() of %s is not translated", file, driver.name, name)
+			continue
+		}
+		if transport == "tls" {
+			p.warn("%s: TLS input of %s is proposed as a plain TCP listener", file, name)
+		}
+	
+		if value := option(driver.args, "port"); value != "" {
+			number, err := strconv.Atoi(value)
+			if err != nil || number < 1 || number > 65535 {
+				p.warn("%s: invalid port %s in %s", file, value, name)
+				continue
+			}
+			port = number
+		}
+		p.listeners = append(p.listeners, listener{protocol: protocol, port: port, flow: name})
+	}
+}
+
+// syslogNGDestinations translates a destination statement once, however many
+// log paths use it
+func (p *parser) syslogNGDestinations(name, file string) []models.Destination {
+	if dests, exists := p.syslogNG.resolved[name]; exists {
+		return dests
+	}
+	dests := []models.Destination{}
+	context := fmt.Sprintf("%s: destination %s", file, name)
+	for _, driver := range p.syslogNG.destinations[name] {
+		host := firstValue(driver.args)
+		port := 514
+		if value, err := strconv.Atoi(option(driver.args, "port")); err == nil {
+			port = value
+		}
+		transport := strings.ToLower(option(driver.args, "transport"))
+	
+		switch driver.name {
+		case "file":
+			if strings.Contains(host, "$") {
+				p.warn("%s: file %s uses macros; its directory is proposed as storage", context, host)
+			}
+			dests = append(dests, storageDestination(macroDir(host)))
+		case "udp", "udp6":
+			dests = append(dests, p.relayDestination(host, port, "UDP", context))
+		case "tcp", "tcp6":
+			dests = append(dests, p.relayDestination(host, port, "TCP", context))
+		case "network":
+			protocol := "TCP"
+			if transport == "udp" {
+				protocol = "UDP"
+			}
+			dests = append(dests, p.relayDestination(host, port, protocol, context))
+		case "syslog":
+			protocol := "TCP"
+			if transport == "udp" {
+				protocol = "UDP"
+			} else if option(driver.args, "port") == "" {
+				port = 601
+			}
+			dests = append(dests, p.relayDestination(host, port, protocol, context))
+		case "http":
+			url := option(driver.args, "url")
+			if url == "" {
+				url = host
+			}
+			dests = append(dests, p.hecDestination(url, context))
+		case "usertty":
+		default:
+			p.warn("%s: %s() is not carried over", context, driver.name)
+		}
+	}
+	p.syslogNG.resolved[name] = dests
+	return dests
+}
+
+// macroDir cuts a file path after the directory holding its first macro,
+// so /var/log/$HOST/messages proposes /var/log
+func macroDir(file string) string {
+	if i := strings.Index(file, "$"); i >= 0 {
+		if slash := strings.LastIndex(file[:i], "/"); slash >= 0 {
+			return file[:slash+1]
+		}
+	}
+	return file
+}
+
+// syslogNGSenderFilter is what the filters of a log path select
+type syslogNGSenderFilter struct {
+	ip          string
+	hostname    string
+	minSeverity int
+}
+
+// syslogNGLiteralHost matches host() patterns naming one host
+var syslogNGLiteralHost = regexp.MustCompile(`^\^?([A-Za-z0-9.\-_]+)\$?$`)
+
+// syslogNGFilter translates the conjunction of a log path's filters. Sender
+// and severity tests carry over; anything else is dropped with a warning.
+func (p *parser) syslogNGFilter(nodes []syslogNGNode, file string) syslogNGSenderFilter {
+	filter := syslogNGSenderFilter{minSeverity: 7}
+	for _, node := range nodes {
+		switch node.name {
+		case "host":
+			pattern := firstValue(node.args)
+			match := syslogNGLiteralHost.FindStringSubmatch(pattern)
+			if match == nil {
+				p.warn("%s: host(%q) is a pattern; only single hosts become per-sender sources", file, pattern)
+				continue
+			}
+			filter.hostname = strings.ToLower(strings.ReplaceAll(match[1], `\.`, "."))
+		case "netmask", "netmask6":
+			value := firstValue(node.args)
+			ip, mask, _ := strings.Cut(value, "/")
+			if mask != "" && mask != "32" && mask != "128" && mask != "255.255.255.255" {
+				p.warn("%s: netmask(%s) covers several senders and is not translated", file, value)
+				continue
+			}
+			filter.ip = ip
+		case "level", "priority":
+			if code, ok := p.syslogNGLevel(node.args, file); ok {
+				filter.minSeverity = code
+			}
+		case "filter":
+			nested := p.syslogNGFilter(p.syslogNG.filters[firstValue(node.args)], file)
+			if nested.ip != "" {
+				filter.ip = nested.ip
+			}
+			if nested.hostname != "" {
+				filter.hostname = nested.hostname
+			}
+			if nested.minSeverity < filter.minSeverity {
+				filter.minSeverity = nested.minSeverity
+			}
+		case "":
+			if node.value == "or" || node.value == "not" {
+				p.warn("%s: filters using %q are not translated exactly; the proposal may pass more messages", file, node.value)
+			}
+		case "(":
+			nested := p.syslogNGFilter(node.args, file)
+			if nested.minSeverity < filter.minSeverity {
+				filter.minSeverity = nested.minSeverity
+			}
+		default:
+			p.warn("%s: filter %s() is not translated", file, node.name)
+		}
+	}
+	return filter
+}
+
+// syslogNGLevel returns the least severe code of level(info..emerg) or
+// level(err, warning)
+func (p *parser) syslogNGLevel(args []syslogNGNode, file string) (int, bool) {
+	least := -1
+	single := len(args) == 1 && !strings.Contains(args[0].value, "..")
+	for _, arg := range args {
+		for _, part := range strings.Split(arg.value, "..") {
+			code, ok := parseSeverity(part)
+			if !ok {
+				p.warn("%s: unknown level %q", file, part)
+				continue
+			}
+			if code > least {
+				least = code
+			}
+		}
+	}
+	if least < 0 {
+		return 0, false
+	}
+	if single {
+		p.warn("%s: level(%s) passes one severity; it is approximated by a minimum", file, args[0].value)
+	}
+	return least, true
+}
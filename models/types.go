@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"sync"
 	"time"
 )
@@ -8,55 +9,402 @@ import (
 // Destination represents a single destination configuration
 type Destination struct {
 	ID          string      `json:"id"`
-	Type        string      `json:"type"` // "storage" or "hec"
+	Type        string      `json:"type"` // "storage", "hec", "null" or "relay"
 	Name        string      `json:"name"`
 	Config      interface{} `json:"config"` // StorageConfig or HECConfig
 	Enabled     bool        `json:"enabled"`
 	Tested      bool        `json:"tested"`
 	TestStatus  string      `json:"test_status"`  // "idle", "testing", "success", "failed"
 	TestMessage string      `json:"test_message"` // Error message or success message
+	Schema      string      `json:"schema,omitempty"` // Output schema: "raw" (default), "ecs" or "ocsf"
+	Window      *DeliveryWindow `json:"window,omitempty"` // Daily hours the destination delivers in; unset delivers always
+	Throttle    *Throttle       `json:"throttle,omitempty"` // Delivery rate limits; unset delivers as fast as the destination takes
+	Dedup       *Dedup          `json:"dedup,omitempty"`    // Suppress events the destination already received; unset delivers replays again
+	CostPerGB   float64         `json:"cost_per_gb,omitempty"` // Price per GB delivered, for the cost report; 0 leaves the destination unmetered
+	Heartbeat   *Heartbeat      `json:"heartbeat,omitempty"`   // Synthetic liveness events; unset sends none
+}
+
+// Heartbeat makes a destination receive a small synthetic event at a fixed
+// interval, whether or not the source has logs, so SIEM dashboards can alert
+// when the pipeline to the SIEM breaks rather than when a source goes quiet.
+// Heartbeat events carry "synthetic": true and "event_type": "heartbeat".
+type Heartbeat struct {
+	IntervalMinutes int `json:"interval_minutes"` // Default 5
+}
+
+// Dedup makes a destination skip events it already received, by event ID,
+// so events replayed from a delivery window or pause buffer after a crash
+// are not counted twice in the SIEM. The IDs of the most recently delivered
+// events are kept in a ledger file that survives the crash.
+type Dedup struct {
+	MaxIDs    int    `json:"max_ids"`    // Delivered event IDs remembered; default 250000
+	LedgerDir string `json:"ledger_dir"` // Default "delivery_ledger"
+}
+
+// Throttle caps the rate a destination is sent events at, e.g. to keep a
+// thin WAN link to a remote SIEM from saturating. Either limit may be 0.
+type Throttle struct {
+	MaxMBps float64 `json:"max_mbps"` // Event payload megabytes per second
+	MaxEPS  float64 `json:"max_eps"`  // Events per second
+}
+
+// DeliveryWindow limits a destination to delivering during certain hours,
+// e.g. off-peak bulk uploads. Outside the window events are spooled to disk.
+type DeliveryWindow struct {
+	Start       string `json:"start"`         // "HH:MM", local time
+	End         string `json:"end"`           // "HH:MM"; before start for windows spanning midnight
+	MaxBufferMB int    `json:"max_buffer_mb"` // Spool size limit; default 1024
+	BufferDir   string `json:"buffer_dir"`    // Default "delivery_buffer"
 }
 
 // StorageConfig represents storage destination configuration
 type StorageConfig struct {
 	Path              string `json:"path"`
 	MaxEventsPerFile  int    `json:"max_events_per_file"`
+	Format            string `json:"format,omitempty"` // "ndjson" (default) or "protobuf", see destinations/storage.proto
 }
 
-// HECConfig represents HEC destination configuration
+// HECConfig represents HEC destination configuration. Sourcetype, Index,
+// Host and Fields values may be Go templates over the event fields.
 type HECConfig struct {
-	URL       string `json:"url"`
-	APIKey    string `json:"api_key"`
-	VerifySSL bool   `json:"verify_ssl"`
+	URL        string            `json:"url"`
+	APIKey     string            `json:"api_key"`
+	VerifySSL  bool              `json:"verify_ssl"`
+	Sourcetype string            `json:"sourcetype,omitempty"`
+	Index      string            `json:"index,omitempty"`
+	Host       string            `json:"host,omitempty"`
+	Fields     map[string]string `json:"fields,omitempty"` // Indexed fields added to every event
+}
+
+// RelayConfig represents a destination forwarding events to the TCP
+// listener of another analyzer
+type RelayConfig struct {
+	Address string           `json:"address"` // host:port of the receiving analyzer
+	TLS     *MutualTLSConfig `json:"tls,omitempty"`
 }
 
-// FilterRule represents a filtering rule
+// SeverityNames are the RFC 5424 severity keywords indexed by severity code
+var SeverityNames = [8]string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// FacilityNames are the syslog facility keywords indexed by facility code
+var FacilityNames = [24]string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// ParserRFC3164 splits text messages into RFC 3164 (BSD syslog) fields
+const ParserRFC3164 = "rfc3164"
+
+// FilterRule represents a filtering rule. Rules are evaluated by ascending
+// Priority, rules of equal priority in the order they are configured.
 type FilterRule struct {
-	Field    string `json:"field"`
-	Operator string `json:"operator"` // "contains", "equals", "regex"
-	Value    string `json:"value"`
-	Action   string `json:"action"` // "include", "exclude"
+	Field    string         `json:"field"`
+	Operator string         `json:"operator"` // "contains", "equals", "regex"
+	Value    string         `json:"value"`
+	Action   string         `json:"action"`             // "include", "exclude"
+	Priority int            `json:"priority,omitempty"` // Lower runs first
+	Stop     bool           `json:"stop,omitempty"`     // Under the "all" policy, a matching rule decides the event and later rules are skipped
+	When     *TimeCondition `json:"when,omitempty"`     // Apply the rule only to events received in a schedule
+}
+
+// TimeCondition limits a filter rule to events whose time falls in a
+// schedule; outside it the rule is skipped
+type TimeCondition struct {
+	Schedule string `json:"schedule"`           // Cron expression "minute hour day month weekday", or "@business_hours", "@weekdays", "@weekends"
+	Timezone string `json:"timezone,omitempty"` // IANA zone; default local time
+	Negate   bool   `json:"negate,omitempty"`   // Apply the rule outside the schedule instead
+}
+
+// FilterSet is a named list of filter rules sources attach by reference
+type FilterSet struct {
+	Name  string       `json:"name"`
+	Rules []FilterRule `json:"rules"`
+}
+
+// SourceTemplate is the source configuration onboarded devices of some
+// vendors get. Onboarding fills in the device's name, address and tags.
+type SourceTemplate struct {
+	Name    string       `json:"name"`
+	Vendors []string     `json:"vendors,omitempty"` // Matched case-insensitively; empty makes it the template for vendors no other template lists
+	Tags    []string     `json:"tags,omitempty"`    // Given to every source created from the template
+	Source  SourceConfig `json:"source"`            // Port, protocol, destinations, filters and other settings; name and IP are ignored
+}
+
+// OnboardRequest describes a device being deployed, as provisioning such as
+// NetBox or Ansible sends it
+type OnboardRequest struct {
+	Hostname string   `json:"hostname"` // Becomes the source name; the IP is used when empty
+	IP       string   `json:"ip"`
+	Vendor   string   `json:"vendor"`
+	Template string   `json:"template,omitempty"` // Template to use instead of the one matching the vendor
+	Tags     []string `json:"tags,omitempty"`     // Added to the template's tags, e.g. "site:ams1"
+}
+
+// OnboardResult is the source an onboarded device got
+type OnboardResult struct {
+	Source   SourceConfig `json:"source"`
+	Template string       `json:"template"`
+	Created  bool         `json:"created"` // False when the device already had a source, which was updated
+}
+
+// Filter policies deciding how a source's rules combine
+const (
+	FilterPolicyAll   = ""      // Every rule must pass: exclude rules drop matches, include rules drop misses
+	FilterPolicyFirst = "first" // The first matching rule decides; unmatched events are dropped when there are include rules
+)
+
+// Event timestamp sources selectable per source
+const (
+	TimestampReceive        = "receive"           // When the analyzer received the message; also the default
+	TimestampDevice         = "device"            // The message's own timestamp, or the receive time when it has none
+	TimestampDeviceFallback = "device_or_receive" // As device, but future timestamps fall back to the receive time too
+)
+
+// Filter matchers selectable per source
+const (
+	FilterMatcherRules = ""      // Evaluate each rule on its own
+	FilterMatcherMulti = "multi" // Scan a field once for all of its regex rules
+)
+
+// Aggregate operations available for numeric fields
+const (
+	AggregateSum = "sum"
+	AggregateAvg = "avg"
+	AggregateMin = "min"
+	AggregateMax = "max"
+	AggregateRate = "rate" // Sum per second over the time window
+)
+
+// Aggregation modes
+const (
+	AggregationModeEvents  = "events"  // Emit a summary event per group when its window closes, or per batch without a window
+	AggregationModeMetrics = "metrics" // Emit a metrics event per group when its window closes
+)
+
+// AggregationMetric selects the aggregates computed for a numeric field
+type AggregationMetric struct {
+	Field string   `json:"field"`
+	Ops   []string `json:"ops"` // "sum", "avg", "min", "max", "rate"
+}
+
+// MetricsEvent is the event payload emitted by aggregation rules in metrics
+// mode: the numeric values of one group over one closed time window
+type MetricsEvent struct {
+	Rule        string             `json:"rule"`
+	Dimensions  map[string]string  `json:"dimensions"` // Group-by field values
+	Values      map[string]float64 `json:"values"`     // <field>_<op> plus "count"
+	WindowStart time.Time          `json:"window_start"`
+	WindowEnd   time.Time          `json:"window_end"`
+}
+
+// MetricSample is a single value of the most recent metrics window, exposed
+// to the Prometheus exporters
+type MetricSample struct {
+	Rule       string            `json:"rule"`
+	Name       string            `json:"name"`
+	Dimensions map[string]string `json:"dimensions"`
+	Value      float64           `json:"value"`
 }
 
+// Samples flattens a metrics event into one sample per value
+func (e MetricsEvent) Samples() []MetricSample {
+	samples := make([]MetricSample, 0, len(e.Values))
+	for name, value := range e.Values {
+		samples = append(samples, MetricSample{
+			Rule:       e.Rule,
+			Name:       name,
+			Dimensions: e.Dimensions,
+			Value:      value,
+		})
+	}
+	return samples
+}
+
+// Overflow strategies applied when an aggregation rule reaches MaxGroups
+const (
+	OverflowOther   = "other"   // Fold new groups into a single "other" bucket
+	OverflowDisable = "disable" // Stop aggregating and pass events through
+	OverflowAlert   = "alert"   // Log and pass events of new groups through
+)
+
+// DefaultMaxGroups bounds aggregation memory when a rule sets no limit
+const DefaultMaxGroups = 10000
+
 // AggregationRule represents an aggregation rule
 type AggregationRule struct {
-	GroupBy    []string      `json:"group_by"`
-	TimeWindow time.Duration `json:"time_window"`
+	Name       string              `json:"name"`
+	GroupBy    []string            `json:"group_by"`
+	TimeWindow time.Duration       `json:"time_window"`
+	Metrics    []AggregationMetric `json:"metrics,omitempty"`
+	MaxGroups  int                 `json:"max_groups"` // 0 = DefaultMaxGroups
+	Overflow   string              `json:"overflow"`   // "other" (default), "disable" or "alert"
+	Mode       string              `json:"mode"`       // "events" (default) or "metrics"
+}
+
+// EnrichmentRule joins an event field against a lookup table
+type EnrichmentRule struct {
+	Field  string `json:"field"`  // Event field holding the key; "source_ip" falls back to the sender address
+	Table  string `json:"table"`  // Lookup table name
+	Prefix string `json:"prefix"` // Prepended to the added column names
+}
+
+// LookupTableConfig describes a CSV or JSON lookup table used for enrichment
+type LookupTableConfig struct {
+	Name     string `json:"name"`
+	Format   string `json:"format"`    // "csv" or "json"
+	Path     string `json:"path"`      // File the table is loaded from and uploads are written to
+	KeyField string `json:"key_field"` // Key column; defaults to the first CSV column
 }
 
+// LookupTableStatus reports the load state of a lookup table
+type LookupTableStatus struct {
+	LookupTableConfig
+	Rows     int       `json:"rows"`
+	LoadedAt time.Time `json:"loaded_at"`
+	Error    string    `json:"error,omitempty"` // Last load error; the previous rows stay in use
+}
+
+// ProtocolUDPTCP configures a source to listen on UDP and TCP on the same port
+const ProtocolUDPTCP = "UDP+TCP"
+
+// ProtocolRELP configures a source to receive RELP, rsyslog's acknowledged
+// transport, on a TCP port
+const ProtocolRELP = "RELP"
+
 // SourceConfig represents a syslog source configuration
 type SourceConfig struct {
+	ID              string            `json:"id"` // Stable across renames; assigned when the source is created
 	Name            string            `json:"name"`
 	IP              string            `json:"ip"`
 	Port            int               `json:"port"`
-	Protocol        string            `json:"protocol"`
+	Protocol        string            `json:"protocol"` // "UDP", "TCP", "UDP+TCP" or "RELP"
 	Destinations    []Destination     `json:"destinations"`
 	SimulationMode  bool              `json:"simulation_mode"`
 	Filters         []FilterRule      `json:"filters"`
+	FilterSets      []string          `json:"filter_sets,omitempty"` // Named filter sets evaluated ahead of Filters
 	Aggregations    []AggregationRule `json:"aggregations"`
+	Enrichments     []EnrichmentRule  `json:"enrichments,omitempty"`
+	Tenant          string            `json:"tenant,omitempty"` // Owning tenant when multi-tenancy is enabled
+	Group           string            `json:"group,omitempty"`  // Optional group used for quotas
+	Hostname        string            `json:"hostname,omitempty"` // Attribute TCP connections from a shared IP by syslog HOSTNAME
+	MinSeverity     string            `json:"min_severity,omitempty"` // Drop events less severe than this keyword or code (e.g. "warning")
+	FilterMatcher   string            `json:"filter_matcher,omitempty"` // "" or "multi"
+	FilterPolicy    string            `json:"filter_policy,omitempty"`  // "" (all) or "first"
+	Classification  []string          `json:"classification,omitempty"` // Data classification labels, e.g. "pci", "pii" or "public"
+	Tracer          *Tracer           `json:"tracer,omitempty"`         // End-to-end latency measurement; unset injects no tracers
+	TimestampSource string            `json:"timestamp_source,omitempty"` // Where event times come from: "receive" (default), "device" or "device_or_receive"
+	RawCapture      *RawCapture       `json:"raw_capture,omitempty"`      // Forensic copy of the messages as received; unset captures nothing
+	Parser          string            `json:"parser,omitempty"`           // "" keeps text messages as strings, "rfc3164" splits them into fields
+	Tags            []string          `json:"tags,omitempty"`             // Free-form labels such as "vendor:cisco" or "site:ams1"; set on onboarding
+	Disabled        bool              `json:"disabled,omitempty"`         // Kept in the configuration, with its port claimed, but not started
+	Probe           *Probe            `json:"probe,omitempty"`            // Checks the device once the source goes silent; unset never probes
 	CreatedAt       time.Time         `json:"created_at"`
 }
 
+// RawCapture writes every message a source receives, unmodified and with
+// its receive time and sender, to rotating files under Path. Capture happens
+// before quotas, parsing and filters, so it also holds what is dropped.
+type RawCapture struct {
+	Enabled   bool   `json:"enabled"`
+	Path      string `json:"path"`
+	MaxFileMB int    `json:"max_file_mb"` // Size at which a file is rotated; default 100
+	MaxFiles  int    `json:"max_files"`   // Files kept per source; default 10
+}
+
+// RawCaptureMetrics reports a source's raw capture
+type RawCaptureMetrics struct {
+	Messages int64  `json:"messages"`
+	Bytes    int64  `json:"bytes"`
+	Errors   int64  `json:"errors"`
+	File     string `json:"file,omitempty"` // File being written
+}
+
+// Tracer makes a source inject a synthetic tracer event into its own queue
+// at a fixed interval. Tracers wait behind the source's events, skip the
+// filters and are delivered to every destination, so the time they take
+// shows how far behind delivery is. Tracer events carry "synthetic": true
+// and "event_type": "tracer".
+type Tracer struct {
+	IntervalSeconds int `json:"interval_seconds"` // Default 60
+}
+
+// Probe methods
+const (
+	ProbeICMP = "icmp"
+	ProbeTCP  = "tcp"
+)
+
+// Probe makes a source check its device once no message has arrived for
+// SilentSeconds, so a device that is down can be told from one that stopped
+// logging. ICMP probes need a raw socket (root or CAP_NET_RAW); TCP probes
+// connect to a port the device listens on, such as SSH, and need no
+// privileges. Probes stop as soon as the source receives again.
+type Probe struct {
+	Method          string `json:"method"`           // "icmp" or "tcp"
+	Host            string `json:"host,omitempty"`   // Device address; default the source IP, required for sources matching any sender
+	Port            int    `json:"port,omitempty"`   // TCP port; default the source port for TCP and RELP sources, 22 otherwise
+	SilentSeconds   int    `json:"silent_seconds"`   // Silence before probing starts; default 300
+	IntervalSeconds int    `json:"interval_seconds"` // Between probes of a silent source; default 60
+	TimeoutSeconds  int    `json:"timeout_seconds"`  // Default 5
+}
+
+// Probe states
+const (
+	ProbeReceiving   = "receiving"   // The source is not silent, so nothing is probed
+	ProbeReachable   = "reachable"   // The device answers: it is up but not logging
+	ProbeUnreachable = "unreachable" // The device does not answer: it is probably down
+)
+
+// ProbeMetrics reports the probing of a source's device
+type ProbeMetrics struct {
+	Method    string    `json:"method"`
+	Target    string    `json:"target"` // Address probed, with the port for TCP probes
+	State     string    `json:"state"`  // "receiving", "reachable" or "unreachable"
+	Since     time.Time `json:"since,omitempty"` // When the state last changed
+	LastProbe time.Time `json:"last_probe,omitempty"`
+	RTTMs     float64   `json:"rtt_ms,omitempty"` // Round trip of the last answered probe
+	Error     string    `json:"error,omitempty"`  // Why the last probe failed
+	Probes    int64     `json:"probes"`
+	Failures  int64     `json:"failures"`
+}
+
+// ClassificationRule restricts the destinations that may receive the events
+// of sources carrying a classification label, e.g. PCI data only to
+// destinations that encrypt in transit
+type ClassificationRule struct {
+	Label             string   `json:"label"`
+	RequireEncryption bool     `json:"require_encryption,omitempty"` // Only HTTPS HEC, TLS relay and null destinations
+	DestinationTypes  []string `json:"destination_types,omitempty"`  // Allowed destination types; empty allows every type
+}
+
+// ClassificationSummary reports the sources carrying a classification label
+// and their combined ingest
+type ClassificationSummary struct {
+	Label           string               `json:"label"`
+	Sources         []string             `json:"sources"`
+	RealTimeEPS     float64              `json:"realtime_eps"`
+	TotalLogs       int64                `json:"total_logs_ingested"`
+	TotalEventBytes int64                `json:"total_event_bytes"`
+	Rules           []ClassificationRule `json:"rules,omitempty"` // Rules restricting the label's destinations
+}
+
+// Settings are the global settings editable from the dashboard
+type Settings struct {
+	WebPort               int    `json:"web_port"`
+	MaxMemoryPerSource    string `json:"max_memory_per_source"`
+	MetricsRetentionHours int    `json:"metrics_retention_hours"`
+	BatchSize             int    `json:"batch_size"`
+	MaxEPSPerSource       int    `json:"max_eps_per_source"`
+}
+
+// SettingsStatus reports the editable settings and which of them wait for a restart
+type SettingsStatus struct {
+	Settings
+	RestartRequired []string `json:"restart_required"` // Settings only applied at startup
+	PendingRestart  []string `json:"pending_restart"`  // Settings saved since startup that are not in effect yet
+}
+
 // GlobalSettings contains application-wide configuration
 type GlobalSettings struct {
 	WebPort               int    `json:"web_port"`
@@ -64,59 +412,1257 @@ type GlobalSettings struct {
 	MetricsRetentionHours int    `json:"metrics_retention_hours"`
 	BatchSize             int    `json:"batch_size"`
 	MaxEPSPerSource       int    `json:"max_eps_per_source"`
+	MultiTenant           bool     `json:"multi_tenant"`
+	AdminTokens           []string `json:"admin_tokens,omitempty"` // Super-admin API tokens
+	RemoteWrite           RemoteWriteConfig `json:"remote_write"`
+	SNMP                  SNMPConfig        `json:"snmp"`
+	SelfLogging           SelfLoggingConfig `json:"self_logging"`
+	EventLog              EventLogConfig    `json:"event_log"`
+	Digest                DigestConfig      `json:"digest"`
+	Notifications         NotificationsConfig `json:"notifications"`
+	Chargeback            ChargebackConfig    `json:"chargeback"`
+	Forecast              ForecastConfig      `json:"forecast"`
+	History               HistoryConfig       `json:"history"`
+	CountersFile          string              `json:"counters_file"` // Where cumulative source counters are persisted
+	Runtime               RuntimeConfig       `json:"runtime"`
+	QueueType             string              `json:"queue_type"` // Batch queue of each source: "channel" (default) or "ring"
+	Batching              BatchingConfig      `json:"batching"`
+	JSONEncoder           string              `json:"json_encoder"` // Destination JSON encoder: "standard" (default) or "fast"
+	PauseBuffer           PauseBufferConfig   `json:"pause_buffer"`
+	Compression           CompressionConfig   `json:"compression"`
+	GitOps                GitOpsConfig        `json:"gitops"`
+	FIPSMode              bool                `json:"fips_mode"` // Restrict TLS and hashing to FIPS-approved algorithms
+	SlowRequestMs         int                 `json:"slow_request_ms"` // Log web requests taking longer; 0 uses the default of 2000
+	Sessions              SessionConfig       `json:"sessions"`
+	StorageRoots          []string            `json:"storage_roots,omitempty"` // Directories storage destinations, window buffers and dedup ledgers must write under; empty allows any
+	CredentialAuditFile   string              `json:"credential_audit_file"` // Where destination credential rotations are recorded; default credential_audit.jsonl
+	Backup                BackupConfig        `json:"backup"`
+	CMDB                  CMDBConfig          `json:"cmdb"`
+}
+
+// SessionConfig limits the dashboard sessions signed in with an API token
+// when multi-tenancy is enabled. A session ends after IdleMinutes without a
+// request or MaxHours after sign-in; remembered sessions survive closing the
+// browser and last RememberDays regardless of activity.
+type SessionConfig struct {
+	IdleMinutes  int `json:"idle_minutes"`  // Default 30
+	MaxHours     int `json:"max_hours"`     // Default 12
+	RememberDays int `json:"remember_days"` // Default 30; negative disables remember-me
+}
+
+// SessionInfo describes the caller's dashboard session
+type SessionInfo struct {
+	AuthRequired bool       `json:"auth_required"` // Whether API access needs a token or session
+	Tenant       string     `json:"tenant,omitempty"`
+	Admin        bool       `json:"admin"`
+	CSRFToken    string     `json:"csrf_token,omitempty"` // Send as X-CSRF-Token on state-changing requests
+	Remember     bool       `json:"remember,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// CompressionConfig compresses the web interface's traffic, which for
+// hundreds of sources is hundreds of KB of metrics JSON every few seconds
+type CompressionConfig struct {
+	HTTP      bool `json:"http"`      // gzip API and page responses for clients that accept it
+	WebSocket bool `json:"websocket"` // Negotiate permessage-deflate for the live metrics feed
+	Level     int  `json:"level"`     // 1 (fastest) to 9 (smallest); 0 uses the default level
+}
+
+// PauseBufferConfig limits the events a source holds back while its delivery
+// is paused. Events beyond the memory limit spill to a file per source until
+// the disk limit; events beyond both are dropped.
+type PauseBufferConfig struct {
+	MemoryEvents int    `json:"memory_events"` // Default 100000
+	MaxDiskMB    int    `json:"max_disk_mb"`   // 0 keeps held events in memory only
+	Dir          string `json:"dir"`           // Default "pause_buffer"
+}
+
+// BatchingConfig selects how parsed events are grouped into queued batches.
+// Adaptive batches grow between the minimum and maximum size and flush
+// interval while the queue backs up and shrink again when it empties.
+type BatchingConfig struct {
+	Mode         string `json:"mode"`           // "static" (default) or "adaptive"
+	MinBatchSize int    `json:"min_batch_size"` // Default 10
+	MaxBatchSize int    `json:"max_batch_size"` // Default batch_size
+	MinFlushMs   int    `json:"min_flush_ms"`   // Default 10
+	MaxFlushMs   int    `json:"max_flush_ms"`   // Default 500
+}
+
+// RuntimeConfig tunes the Go runtime for shared hosts and containers. Zero
+// values keep the defaults, which honour the GOMAXPROCS, GOGC and GOMEMLIMIT
+// environment variables.
+type RuntimeConfig struct {
+	MaxProcs    int    `json:"max_procs"`    // OS threads running Go code at once
+	GCPercent   int    `json:"gc_percent"`   // Heap growth that triggers a collection; -1 disables the GC
+	MemoryLimit string `json:"memory_limit"` // Soft memory limit, e.g. "2GiB"
+}
+
+// RuntimeStatus reports the runtime settings in effect, for diagnostics
+type RuntimeStatus struct {
+	GoVersion     string          `json:"go_version"`
+	OS            string          `json:"os"`
+	Arch          string          `json:"arch"`
+	NumCPU        int             `json:"num_cpu"`
+	MaxProcs      int             `json:"max_procs"`
+	GCPercent     int             `json:"gc_percent"`                   // -1 when the GC is disabled
+	MemoryLimit   int64           `json:"memory_limit_bytes,omitempty"` // Omitted when no limit is set
+	Configured    RuntimeConfig   `json:"configured"`
+	QueueType     string          `json:"queue_type"`   // Batch queue implementation of new sources
+	JSONEncoder   string          `json:"json_encoder"` // JSON encoder of new destinations
+	FIPSMode      bool            `json:"fips_mode"`
+	Goroutines    int             `json:"goroutines"`
+	HeapAlloc     uint64          `json:"heap_alloc_bytes"`
+	HeapSys       uint64          `json:"heap_sys_bytes"`
+	Sys           uint64          `json:"sys_bytes"`
+	NumGC         uint32          `json:"num_gc"`
+	LastGC        time.Time       `json:"last_gc"`
+	StartedAt     time.Time       `json:"started_at"`
+	UptimeSeconds int64           `json:"uptime_seconds"`
+	Lifecycle     LifecycleStatus `json:"lifecycle"`
+}
+
+// LifecycleStatus accounts for the sources, listeners and pipeline goroutines
+// that are running. Goroutines of a stopped source or listener instance, and
+// listeners no source uses, are reported as leaks.
+type LifecycleStatus struct {
+	Sources           []SourceLifecycle   `json:"sources"`
+	Listeners         []ListenerLifecycle `json:"listeners"`
+	Goroutines        int                 `json:"goroutines"` // Pipeline goroutines running
+	GoroutinesStarted int64               `json:"goroutines_started"`
+	GoroutinesExited  int64               `json:"goroutines_exited"`
+	Leaks             []LifecycleLeak     `json:"leaks"`
+}
+
+// SourceLifecycle is the accounting of one running source instance
+type SourceLifecycle struct {
+	Name       string `json:"name"`
+	Owner      string `json:"owner"` // Instance the goroutines are accounted to
+	Running    bool   `json:"running"`
+	Listeners  int    `json:"listeners"`
+	Goroutines int    `json:"goroutines"`
+}
+
+// ListenerLifecycle is the accounting of one registered shared listener
+type ListenerLifecycle struct {
+	Protocol   string `json:"protocol"`
+	Port       int    `json:"port"`
+	Owner      string `json:"owner"` // Instance the goroutines are accounted to
+	Sources    int    `json:"sources"`
+	Goroutines int    `json:"goroutines"`
+}
+
+// LifecycleLeak is a goroutine owner or listener that outlived its use
+type LifecycleLeak struct {
+	Owner      string `json:"owner"`
+	Goroutines int    `json:"goroutines"`
+	Reason     string `json:"reason"`
+}
+
+// RemoteWriteConfig configures pushing metrics to a Prometheus remote-write endpoint
+type RemoteWriteConfig struct {
+	Enabled           bool              `json:"enabled"`
+	URL               string            `json:"url"`
+	IntervalSeconds   int               `json:"interval_seconds"`
+	Username          string            `json:"username,omitempty"`
+	Password          string            `json:"password,omitempty"`
+	BearerToken       string            `json:"bearer_token,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"` // Extra labels added to every series
+	MaxRetries        int               `json:"max_retries"`
+	MaxBufferedPushes int               `json:"max_buffered_pushes"` // Pushes kept while the endpoint is down
+}
+
+// GitOpsConfig configures pulling the source and destination configuration
+// from a Git repository or an HTTP URL. While enabled, the configuration can
+// only be changed there; the API and UI are read-only.
+type GitOpsConfig struct {
+	Enabled         bool   `json:"enabled"`
+	Repository      string `json:"repository,omitempty"` // Git URL to clone
+	Branch          string `json:"branch,omitempty"`     // Default "main"
+	Path            string `json:"path,omitempty"`       // Config file within the repository, default "sources.json"
+	CheckoutDir     string `json:"checkout_dir,omitempty"` // Default "gitops_checkout"
+	URL             string `json:"url,omitempty"`          // HTTP(S) URL to fetch instead of a repository
+	BearerToken     string `json:"bearer_token,omitempty"`
+	PublicKey       string `json:"public_key,omitempty"` // Base64 Ed25519 key verifying the detached "<file>.sig" signature
+	AllowUnsigned   bool   `json:"allow_unsigned"`       // Skip signature verification (testing only)
+	IntervalSeconds int    `json:"interval_seconds"`     // Default 60
+}
+
+// BackupConfig schedules a nightly backup of the configuration and the state
+// files to a directory, such as a mounted SMB share or a UNC path, or to an
+// S3 bucket. Backups hold the configuration with its secrets, so the target
+// must be protected like the configuration file.
+type BackupConfig struct {
+	Enabled       bool     `json:"enabled"`
+	Time          string   `json:"time"`           // Local time of day, "HH:MM"; default "02:00"
+	Path          string   `json:"path,omitempty"` // Directory to write backups to
+	S3            S3Config `json:"s3"`             // Used instead of Path when Bucket is set
+	RetentionDays int      `json:"retention_days"` // Older backups are deleted; default 30
+}
+
+// S3Config addresses an S3 or S3-compatible bucket. Requests use path-style
+// URLs signed with AWS Signature Version 4.
+type S3Config struct {
+	Endpoint        string `json:"endpoint,omitempty"` // Default https://s3.<region>.amazonaws.com
+	Region          string `json:"region,omitempty"`   // Default us-east-1
+	Bucket          string `json:"bucket,omitempty"`
+	Prefix          string `json:"prefix,omitempty"` // Key prefix, e.g. "analyzer01/"
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+}
+
+// BackupInfo describes one stored backup
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupStatus reports scheduled backups and the backups kept
+type BackupStatus struct {
+	Enabled     bool         `json:"enabled"`
+	Location    string       `json:"location,omitempty"` // Directory or s3:// URL backed up to
+	Time        string       `json:"time,omitempty"`
+	LastAttempt time.Time    `json:"last_attempt"`
+	LastSuccess time.Time    `json:"last_success"`
+	LastBackup  string       `json:"last_backup,omitempty"`
+	LastError   string       `json:"last_error,omitempty"`
+	Backups     []BackupInfo `json:"backups"` // Oldest first
+}
+
+// BackupRestore reports what restoring a backup changed
+type BackupRestore struct {
+	Backup  string        `json:"backup"`
+	Changes GitOpsChanges `json:"changes"`
+}
+
+// CMDB inventory kinds
+const (
+	CMDBNetBox  = "netbox"
+	CMDBGeneric = "generic"
+)
+
+// CMDBConfig syncs the sources of devices with an inventory: NetBox, or a
+// CMDB REST API returning a JSON list of devices. Active devices get a source
+// from the template for their vendor, as onboarding gives them; sources the
+// sync created are disabled once their device leaves the inventory or is no
+// longer active.
+type CMDBConfig struct {
+	Enabled         bool   `json:"enabled"`
+	Kind            string `json:"kind"`  // "netbox" or "generic"
+	URL             string `json:"url"`   // NetBox base URL, or the device list endpoint of a generic CMDB
+	Token           string `json:"token"` // NetBox API token, or bearer token of a generic CMDB
+	Filter          string `json:"filter,omitempty"` // Query parameters selecting devices, e.g. "site=ams1&role=switch"
+	IntervalMinutes int    `json:"interval_minutes"` // Default 60
+	DryRun          bool   `json:"dry_run"`          // Only report the changes syncs would make
+	VerifySSL       bool   `json:"verify_ssl"`
+	
+	// Fields of a generic CMDB's devices, as dotted paths such as
+	// "primary_ip.address"; addresses may carry a prefix length
+	ItemsField    string `json:"items_field,omitempty"`    // Key of the device list; "" for a top-level array
+	HostnameField string `json:"hostname_field,omitempty"` // Default "hostname"
+	IPField       string `json:"ip_field,omitempty"`       // Default "ip"
+	VendorField   string `json:"vendor_field,omitempty"`   // Default "vendor"
+	StatusField   string `json:"status_field,omitempty"`   // Devices are active when it is unset, "active" or true
+	TagsField     string `json:"tags_field,omitempty"`     // List of strings, or of objects with a "slug" or "name"
+}
+
+// CMDB sync actions
+const (
+	CMDBCreate  = "create"
+	CMDBUpdate  = "update"
+	CMDBDisable = "disable"
+	CMDBSkip    = "skip"
+)
+
+// CMDBChange is a change a sync made or, in a dry run, would make
+type CMDBChange struct {
+	Action   string `json:"action"` // "create", "update", "disable" or "skip"
+	Device   string `json:"device"`
+	Source   string `json:"source,omitempty"`
+	Template string `json:"template,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	Error    string `json:"error,omitempty"` // Why the change failed; the source is left as it was
+}
+
+// CMDBSyncReport is the outcome of one sync
+type CMDBSyncReport struct {
+	Time      time.Time    `json:"time"`
+	DryRun    bool         `json:"dry_run"`
+	Devices   int          `json:"devices"`   // Devices read from the inventory
+	Unchanged int          `json:"unchanged"` // Sources already matching their device
+	Changes   []CMDBChange `json:"changes"`
+	Error     string       `json:"error,omitempty"` // Why the sync changed nothing, e.g. the inventory could not be read
+}
+
+// CMDBSyncStatus reports the inventory sync
+type CMDBSyncStatus struct {
+	Enabled    bool            `json:"enabled"`
+	Kind       string          `json:"kind,omitempty"`
+	URL        string          `json:"url,omitempty"`
+	DryRun     bool            `json:"dry_run"`
+	NextSync   *time.Time      `json:"next_sync,omitempty"`
+	LastReport *CMDBSyncReport `json:"last_report,omitempty"`
+}
+
+// CollectorImport is the set of sources proposed for the inputs and actions
+// of an rsyslog or syslog-ng configuration, with what couldn't be carried over
+type CollectorImport struct {
+	Format   string         `json:"format"` // "rsyslog" or "syslog-ng"
+	Files    []string       `json:"files"`  // Configuration files read, includes last
+	Sources  []SourceConfig `json:"sources"`
+	Warnings []string       `json:"warnings"`
+}
+
+// GitOpsChanges lists the sources a sync added, updated and removed
+type GitOpsChanges struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"`
+	Removed []string `json:"removed"`
+}
+
+// GitOpsStatus reports the state of GitOps config sync
+type GitOpsStatus struct {
+	Enabled     bool          `json:"enabled"`
+	Location    string        `json:"location,omitempty"` // Repository or URL synced from
+	Revision    string        `json:"revision,omitempty"` // Commit, or content hash for URLs, last applied
+	Verified    bool          `json:"verified"`           // Whether the applied revision's signature was verified
+	InSync      bool          `json:"in_sync"`            // Whether the last sync succeeded
+	LastAttempt time.Time     `json:"last_attempt"`
+	LastSuccess time.Time     `json:"last_success"`
+	LastError   string        `json:"last_error,omitempty"`
+	LastChanges GitOpsChanges `json:"last_changes"` // Changes of the last sync that changed anything
+}
+
+// ConfigMode reports where the configuration comes from. In environment-only
+// mode it is read from the environment or a mounted file and never saved.
+type ConfigMode struct {
+	ReadOnly  bool     `json:"read_only"`
+	Origin    string   `json:"origin,omitempty"` // Variable, file or "defaults" the configuration was read from
+	Overrides []string `json:"overrides"`        // Environment variables applied over it
+}
+
+// SNMPConfig configures the embedded read-only SNMP agent
+type SNMPConfig struct {
+	Enabled   bool   `json:"enabled"`
+	Port      int    `json:"port"`
+	Community string `json:"community"`
+	BaseOID   string `json:"base_oid"` // Root of the private MIB
+}
+
+// SelfLoggingConfig configures forwarding the analyzer's own log as syslog
+type SelfLoggingConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Protocol   string `json:"protocol"` // "udp", "tcp" or "tls"
+	Address    string `json:"address"`  // host:port of the collector
+	Facility   int    `json:"facility"` // Syslog facility code (16 = local0)
+	AppName    string `json:"app_name"`
+	VerifySSL  bool   `json:"verify_ssl"`
+	CACertFile string `json:"ca_cert_file,omitempty"`
+}
+
+// EventLogConfig configures writing the analyzer's critical operational
+// messages to the Windows Event Log
+type EventLogConfig struct {
+	Enabled      bool   `json:"enabled"`
+	Source       string `json:"source"`         // Event source name (default "syslog-analyzer")
+	MinLevel     string `json:"min_level"`      // "warning" (default) or "error"
+	MaxPerMinute int    `json:"max_per_minute"` // Entries per minute before the rest is summarized (default 60)
+}
+
+// LogLine is one line of the analyzer's own operational log
+type LogLine struct {
+	Seq     int64     `json:"seq"`
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"` // "error", "warning" or "info"
+	Message string    `json:"message"`
+}
+
+// SMTPConfig configures an outgoing mail relay
+type SMTPConfig struct {
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	From        string `json:"from"`
+	ImplicitTLS bool   `json:"implicit_tls"` // TLS from connect (port 465) instead of STARTTLS
+}
+
+// DigestConfig configures the daily ingest statistics e-mail
+type DigestConfig struct {
+	Enabled    bool       `json:"enabled"`
+	SendTime   string     `json:"send_time"` // Local time of day, "HH:MM"
+	Recipients []string   `json:"recipients"`
+	SMTP       SMTPConfig `json:"smtp"`
+}
+
+// ChargebackConfig configures the monthly per-tenant/group usage ledger
+type ChargebackConfig struct {
+	DataFile   string     `json:"data_file"` // Where monthly usage is persisted
+	Enabled    bool       `json:"enabled"`   // E-mail the previous month's CSV
+	SendDay    int        `json:"send_day"`  // Day of month, 1-28
+	SendTime   string     `json:"send_time"` // Local time of day, "HH:MM"
+	Recipients []string   `json:"recipients"`
+	SMTP       SMTPConfig `json:"smtp"`
+}
+
+// DestinationCost projects the monthly cost of one source's delivery to a
+// destination from the delivery rate measured since MeteredSince. RawGB is
+// what the source's unfiltered ingest would cost there; the difference is
+// what filters, severity policy and aggregation save.
+type DestinationCost struct {
+	Source          string    `json:"source"`
+	Destination     string    `json:"destination"`
+	DestinationType string    `json:"destination_type"`
+	CostPerGB       float64   `json:"cost_per_gb"`
+	MeteredSince    time.Time `json:"metered_since"`
+	MonthlyGB       float64   `json:"monthly_gb"`     // Projected delivery
+	RawMonthlyGB    float64   `json:"raw_monthly_gb"` // Projected ingest
+	MonthlyCost     float64   `json:"monthly_cost"`
+	RawMonthlyCost  float64   `json:"raw_monthly_cost"`
+	MonthlySavings  float64   `json:"monthly_savings"`
+	SavingsPercent  float64   `json:"savings_percent"`
+}
+
+// CostReport is the projected monthly cost of every metered destination
+type CostReport struct {
+	GeneratedAt    time.Time         `json:"generated_at"`
+	Destinations   []DestinationCost `json:"destinations"`
+	MonthlyCost    float64           `json:"monthly_cost"`
+	RawMonthlyCost float64           `json:"raw_monthly_cost"`
+	MonthlySavings float64           `json:"monthly_savings"`
+	SavingsPercent float64           `json:"savings_percent"`
+}
+
+// Forecast models
+const (
+	ForecastLinear   = "linear"   // Least-squares trend over the daily history
+	ForecastSeasonal = "seasonal" // Linear trend scaled by a day-of-week factor
+)
+
+// ForecastConfig configures the daily ingest history and the capacity
+// forecast projected from it. Quotas' max_gb_per_day are the per-tenant and
+// per-group limits; the license and disk limits apply to all sources.
+type ForecastConfig struct {
+	DataFile          string  `json:"data_file"`           // Where daily ingest is persisted
+	HistoryDays       int     `json:"history_days"`        // Days of history kept, default 90
+	HorizonDays       int     `json:"horizon_days"`        // Days projected ahead, default 90
+	Model             string  `json:"model"`               // "linear" (default) or "seasonal"
+	LicenseGBPerDay   float64 `json:"license_gb_per_day"`  // 0 = no license limit
+	DiskLimitGB       float64 `json:"disk_limit_gb"`       // 0 = no disk limit
+	DiskRetentionDays int     `json:"disk_retention_days"` // Days of ingest held on disk, default 30
+}
+
+// History resolutions
+const (
+	HistoryMinute = "minute"
+	HistoryHour   = "hour"
+)
+
+// HistoryConfig configures the persisted metrics history. Per-minute points
+// are kept for RawDays; older history is only kept as hourly rollups, for
+// HourlyMonths.
+type HistoryConfig struct {
+	Dir          string `json:"dir"`           // Default "history"
+	RawDays      int    `json:"raw_days"`      // Default 7
+	HourlyMonths int    `json:"hourly_months"` // Default 13
+}
+
+// AlertRule defines a threshold on a per-source metric
+type AlertRule struct {
+	Name      string   `json:"name"`
+	Metric    string   `json:"metric"`   // "eps", "gbps", "queue_depth", "dropped", "destination_errors", "kernel_drops", "silent_seconds", "quota_usage", "aggregation_overflows", "panics", "queue_age_seconds", "destination_age_seconds", "probe_unreachable"
+	Operator  string   `json:"operator"` // ">", ">=", "<", "<=", "=="
+	Threshold float64  `json:"threshold"`
+	Sources   []string `json:"sources,omitempty"` // Empty applies to all sources
+	Severity  string   `json:"severity"`          // "critical", "warning", "info"
+}
+
+// NotificationConnector configures a single alert destination
+type NotificationConnector struct {
+	Name       string `json:"name"`
+	Type       string   `json:"type"` // "slack", "teams", "pagerduty" or "opsgenie"
+	Enabled    bool     `json:"enabled"`
+	WebhookURL string   `json:"webhook_url,omitempty"` // Slack and Teams
+	Template   string   `json:"template,omitempty"`    // Go text/template; fields of notifications.Alert
+	RoutingKey string   `json:"routing_key,omitempty"` // PagerDuty Events API v2 integration key
+	APIKey     string   `json:"api_key,omitempty"`     // Opsgenie API key
+	APIURL     string   `json:"api_url,omitempty"`     // Override for regional API endpoints
+	Severities []string `json:"severities,omitempty"`  // Only forward these severities; empty forwards all
+}
+
+// NotificationsConfig configures alert rules and notification connectors
+type NotificationsConfig struct {
+	Enabled                   bool                    `json:"enabled"`
+	DashboardURL              string                  `json:"dashboard_url"` // Base URL used for deep links
+	EvaluationIntervalSeconds int                     `json:"evaluation_interval_seconds"`
+	Rules                     []AlertRule             `json:"rules"`
+	Connectors                []NotificationConnector `json:"connectors"`
+}
+
+// TenantConfig represents a tenant (workspace) and its API tokens
+type TenantConfig struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Tokens []string `json:"tokens"`
+}
+
+// Quota enforcement actions
+const (
+	QuotaActionAlert  = "alert"  // Log and report only
+	QuotaActionSample = "sample" // Keep one in SampleRate messages while over quota
+	QuotaActionDrop   = "drop"   // Drop messages while over quota
+)
+
+// QuotaConfig caps the ingest of all sources belonging to a tenant or group
+type QuotaConfig struct {
+	Name        string  `json:"name"`
+	Tenant      string  `json:"tenant,omitempty"`
+	Group       string  `json:"group,omitempty"`
+	MaxEPS      float64 `json:"max_eps"`        // 0 = unlimited
+	MaxGBPerDay float64 `json:"max_gb_per_day"` // 0 = unlimited, resets at local midnight
+	Action      string  `json:"action"`         // "alert", "sample" or "drop"
+	SampleRate  int     `json:"sample_rate"`    // Keep 1 in N messages when sampling
+}
+
+// ListenerConfig holds settings for the shared listener on a protocol and port
+type ListenerConfig struct {
+	Protocol   string `json:"protocol"`
+	Port       int    `json:"port"`
+	StrictMode bool   `json:"strict_mode"` // Drop traffic from senders no source is configured for
+	RejectLog  bool   `json:"reject_log"`  // Log firewall-style lines for rejected senders
+	AllowList  []string `json:"allow_list,omitempty"` // IPs/CIDRs permitted to send; empty allows all
+	DenyList   []string `json:"deny_list,omitempty"`  // IPs/CIDRs always blocked
+	TCPKeepAlivePeriodSeconds int `json:"tcp_keepalive_period_seconds"` // 0 = system default, negative disables keep-alives
+	UDPWorkers  int      `json:"udp_workers"`            // Goroutines reading the UDP socket; 0 = 1. Applied when the listener starts
+	CPUAffinity []string `json:"cpu_affinity,omitempty"` // Linux CPU lists (e.g. "0-1") the UDP workers are pinned to, round-robin
+	TLS         *MutualTLSConfig `json:"tls,omitempty"` // Require mutual TLS on a TCP listener, e.g. for relaying analyzers
+}
+
+// MutualTLSConfig configures mutual TLS between analyzer instances. The
+// files are re-read when they change, so certificates can be rotated
+// without a restart.
+type MutualTLSConfig struct {
+	CertFile     string   `json:"cert_file"`
+	KeyFile      string   `json:"key_file"`
+	CAFile       string   `json:"ca_file"`                 // CA bundle the peer's certificate must chain to
+	ServerName   string   `json:"server_name,omitempty"`   // Name the receiving analyzer's certificate must carry; defaults to the address' host
+	AllowedNames []string `json:"allowed_names,omitempty"` // Common or DNS names a listener accepts client certificates for; empty accepts any the CA signed
+}
+
+// MutualTLSStatus reports the certificate an analyzer presents to its peers
+type MutualTLSStatus struct {
+	Subject    string    `json:"subject"`
+	NotAfter   time.Time `json:"not_after"`
+	LoadedAt   time.Time `json:"loaded_at"`
+	Handshakes int64     `json:"handshakes"`
+	Failures   int64     `json:"failures"`             // Failed handshakes, e.g. peers without a valid certificate
+	LastError  string    `json:"last_error,omitempty"` // Latest handshake or reload failure
+}
+
+// ListenerWorkerStatus reports the traffic read by one UDP listener worker.
+// Share and the top sender cover the period since the previous status read,
+// so a worker carrying more than its part, or one sender dominating a
+// worker, shows up while it happens.
+type ListenerWorkerStatus struct {
+	ID             int     `json:"id"`
+	CPUs           string  `json:"cpus,omitempty"` // CPU list the worker is pinned to
+	Pinned         bool    `json:"pinned"`
+	PinError       string  `json:"pin_error,omitempty"`
+	Packets        int64   `json:"packets"`
+	Bytes          int64   `json:"bytes"`
+	Dropped        int64   `json:"dropped"` // Datagrams read but not accepted: denied, rejected, unclaimed or over quota
+	PPS            float64 `json:"pps"`     // Packets per second since the previous status read
+	Share          float64 `json:"share"`   // Percent of the listener's packets this worker read
+	TopSender      string  `json:"top_sender,omitempty"`
+	TopSenderShare float64 `json:"top_sender_share,omitempty"` // Percent of this worker's packets from the top sender
+}
+
+// TCPConnectionStatus reports activity on an open TCP connection
+type TCPConnectionStatus struct {
+	RemoteAddr   string    `json:"remote_addr"`
+	SourceIP     string    `json:"source_ip"`
+	ConnectedAt  time.Time `json:"connected_at"`
+	LastActivity time.Time `json:"last_activity"`
+	IdleSeconds  float64   `json:"idle_seconds"`
+	Messages     int64     `json:"messages"`
+	Bytes        int64     `json:"bytes"`
+}
+
+// ListenerStatus reports a running listener's settings and counters
+type ListenerStatus struct {
+	ListenerConfig
+	SourceCount          int   `json:"source_count"`
+	Rejected             int64 `json:"rejected"` // Messages dropped by strict mode
+	Denied               int64 `json:"denied"`   // Messages dropped by the allow/deny lists
+	KernelDrops          int64 `json:"kernel_drops"`
+	KernelDropsAvailable bool  `json:"kernel_drops_available"`
+	Connections          []TCPConnectionStatus `json:"connections,omitempty"` // Open TCP connections
+	Workers              []ListenerWorkerStatus `json:"workers,omitempty"`    // UDP reader goroutines
+	WorkerImbalance      float64    `json:"worker_imbalance,omitempty"` // Busiest worker's packets over the mean; 1 is even
+	State                string     `json:"state"`                // "up", or "down" while the socket is being re-bound
+	DownSince            *time.Time `json:"down_since,omitempty"`
+	LastError            string     `json:"last_error,omitempty"` // Socket error that last took the listener down
+	Restarts             int64      `json:"restarts"`             // Successful re-binds
+	Panics               int64      `json:"panics"`               // Panics recovered in the listener's readers and connections
+	TLSStatus            *MutualTLSStatus `json:"tls_status,omitempty"`
+}
+
+// ServicePort is a port the analyzer listens on or is configured to listen
+// on, for deployment tooling that exposes ports (Kubernetes Services,
+// firewall automation)
+type ServicePort struct {
+	Name     string   `json:"name"` // Valid Kubernetes port name, e.g. "udp-514"
+	Port     int      `json:"port"`
+	Protocol string   `json:"protocol"` // "TCP" or "UDP"; RELP ports are TCP
+	Service  string   `json:"service"`  // "syslog", "web" or "snmp"
+	State    string   `json:"state"`    // "up", "down" while re-binding, "not_bound" or "pending_restart"
+	Sources  []string `json:"sources,omitempty"` // Sources received on a syslog port
+	Reason   string   `json:"reason,omitempty"`  // Why a configured port is not bound
+}
+
+// PortConflict describes a source that cannot run alongside an earlier
+// source or a port the analyzer itself listens on
+type PortConflict struct {
+	Source        string `json:"source"`                   // Source that is not started
+	ConflictsWith string `json:"conflicts_with,omitempty"` // Earlier source it collides with
+	Protocol      string `json:"protocol"`
+	Port          int    `json:"port"`
+	Reason        string `json:"reason"`
+}
+
+// UnclaimedSender describes traffic received from an IP no source is configured for
+type UnclaimedSender struct {
+	IP            string    `json:"ip"`
+	Port          int       `json:"port"`
+	Protocol      string    `json:"protocol"`
+	EPS           float64   `json:"eps"`
+	MessageCount  int64     `json:"message_count"`
+	SampleMessage string    `json:"sample_message"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// CounterResetEvent records cumulative source counters starting from zero again
+type CounterResetEvent struct {
+	Source            string    `json:"source"`
+	Time              time.Time `json:"time"`
+	Reason            string    `json:"reason"` // "manual" or "deleted"
+	By                string    `json:"by,omitempty"`
+	LogsIngested      int64     `json:"logs_ingested"` // Totals before the reset
+	ProcessedCount    int64     `json:"processed_count"`
+	SentCount         int64     `json:"sent_count"`
+	DroppedCount      int64     `json:"dropped_count"`
+	DestinationErrors int64     `json:"destination_errors"`
+}
+
+// ReconciliationCounts accounts for the messages a source received over a time
+// range. Every received message ends up in exactly one of Dropped, Discarded,
+// SeverityDropped, Filtered or Processed; what is left is Unaccounted (still
+// queued at the end of the range, or lost). Sent and Failed count the events
+// handed to destinations after aggregation.
+type ReconciliationCounts struct {
+	Received        int64 `json:"received"`
+	Dropped         int64 `json:"dropped"`          // Quota and queue-full drops
+	Discarded       int64 `json:"discarded"`        // Empty messages
+	SeverityDropped int64 `json:"severity_dropped"`
+	Filtered        int64 `json:"filtered"`
+	Processed       int64 `json:"processed"`        // Events that passed filtering
+	Sent            int64 `json:"sent"`
+	Failed          int64 `json:"failed"`
+	Unaccounted     int64 `json:"unaccounted"`
+}
+
+// Add accumulates other into c and recomputes Unaccounted
+func (c *ReconciliationCounts) Add(other ReconciliationCounts) {
+	c.Received += other.Received
+	c.Dropped += other.Dropped
+	c.Discarded += other.Discarded
+	c.SeverityDropped += other.SeverityDropped
+	c.Filtered += other.Filtered
+	c.Processed += other.Processed
+	c.Sent += other.Sent
+	c.Failed += other.Failed
+	c.Unaccounted = c.Received - c.Dropped - c.Discarded - c.SeverityDropped - c.Filtered - c.Processed
+}
+
+// SourceReconciliation is the reconciliation of a single source
+type SourceReconciliation struct {
+	Source string `json:"source"`
+	Tenant string `json:"tenant,omitempty"`
+	ReconciliationCounts
+}
+
+// ReconciliationReport reconciles received against delivered events per source
+type ReconciliationReport struct {
+	From    time.Time              `json:"from"`
+	To      time.Time              `json:"to"`
+	Sources []SourceReconciliation `json:"sources"`
+	Total   ReconciliationCounts   `json:"total"`
+}
+
+// HistoryPoint is the delivery accounting of a source over one interval
+type HistoryPoint struct {
+	Time time.Time `json:"time"` // Start of the interval
+	ReconciliationCounts
+}
+
+// QuotaStatus reports the current consumption of a quota
+type QuotaStatus struct {
+	Name        string  `json:"name"`
+	Tenant      string  `json:"tenant,omitempty"`
+	Group       string  `json:"group,omitempty"`
+	Action      string  `json:"action"`
+	MaxEPS      float64 `json:"max_eps"`
+	MaxGBPerDay float64 `json:"max_gb_per_day"`
+	CurrentEPS  float64 `json:"current_eps"`
+	GBToday     float64 `json:"gb_today"`
+	EPSUsage    float64 `json:"eps_usage"`   // Percent of MaxEPS
+	DailyUsage  float64 `json:"daily_usage"` // Percent of MaxGBPerDay
+	Exceeded    bool    `json:"exceeded"`
+	Sampled     int64   `json:"sampled"` // Messages discarded by sampling
+	Dropped     int64   `json:"dropped"` // Messages discarded by drop enforcement
+}
+
+// ForecastPoint is the ingest of one day, recorded or projected
+type ForecastPoint struct {
+	Date string  `json:"date"` // YYYY-MM-DD
+	GB   float64 `json:"gb"`
+}
+
+// ForecastLimit is when a series is projected to reach a limit. Daily limits
+// compare each day's ingest; stored limits compare the ingest held over the
+// disk retention window.
+type ForecastLimit struct {
+	Name      string  `json:"name"`       // "license", "disk" or the quota name
+	Kind      string  `json:"kind"`       // "daily" or "stored"
+	LimitGB   float64 `json:"limit_gb"`
+	Exceeded  bool    `json:"exceeded"`   // Already reached on the last recorded day
+	ReachedOn string  `json:"reached_on"` // First projected day at the limit; empty beyond the horizon
+	DaysLeft  int     `json:"days_left"`  // Days until ReachedOn, -1 beyond the horizon
+}
+
+// ForecastSeries is the recorded and projected daily ingest of a source, a
+// quota or all sources
+type ForecastSeries struct {
+	Kind          string          `json:"kind"` // "source", "quota" or "global"
+	Name          string          `json:"name"`
+	Tenant        string          `json:"tenant,omitempty"`
+	SlopeGBPerDay float64         `json:"slope_gb_per_day"` // Daily growth of the trend
+	History       []ForecastPoint `json:"history"`
+	Projection    []ForecastPoint `json:"projection"`
+	Limits        []ForecastLimit `json:"limits"`
+}
+
+// ForecastReport is the capacity forecast of every series
+type ForecastReport struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Model       string           `json:"model"`
+	HorizonDays int              `json:"horizon_days"`
+	Series      []ForecastSeries `json:"series"`
+}
+
+// IngestPeriod is an inclusive range of local days
+type IngestPeriod struct {
+	From string `json:"from"` // YYYY-MM-DD
+	To   string `json:"to"`
+}
+
+// SourceComparison is the ingest of one source in two periods
+type SourceComparison struct {
+	Name          string  `json:"name"`
+	Tenant        string  `json:"tenant,omitempty"`
+	CurrentGB     float64 `json:"current_gb"`
+	BaselineGB    float64 `json:"baseline_gb"`
+	ChangeGB      float64 `json:"change_gb"`
+	ChangePercent float64 `json:"change_percent"` // 0 when New
+	New           bool    `json:"new"`            // No ingest in the baseline period
+}
+
+// IngestComparison compares the ingest of each source between a current and
+// a baseline period, largest growth first
+type IngestComparison struct {
+	Current       IngestPeriod       `json:"current"`
+	Baseline      IngestPeriod       `json:"baseline"`
+	RecordedFrom  string             `json:"recorded_from"` // First day of recorded history, empty when none
+	CurrentGB     float64            `json:"current_gb"`
+	BaselineGB    float64            `json:"baseline_gb"`
+	ChangeGB      float64            `json:"change_gb"`
+	ChangePercent float64            `json:"change_percent"`
+	Sources       []SourceComparison `json:"sources"`
+}
+
+// FilterImpactRequest is a proposed filter rule set to evaluate against the
+// recent events of sources before it is applied
+type FilterImpactRequest struct {
+	Sources       []string     `json:"sources,omitempty"` // Empty evaluates every source
+	Filters       []FilterRule `json:"filters"`           // Replace each source's own filters
+	FilterSets    []string     `json:"filter_sets,omitempty"`
+	FilterMatcher string       `json:"filter_matcher,omitempty"`
+	FilterPolicy  string       `json:"filter_policy,omitempty"`
+}
+
+// FilterImpact estimates what a source's current and proposed filters drop,
+// from its sampled events scaled to its daily ingest
+
+type FilterImpact struct {
+	Source                  string  `json:"source"`
+	Tenant                  string  `json:"tenant,omitempty"`
+	Samples                 int     `json:"samples"`                // Recent events evaluated
+	DailyGB                 float64 `json:"daily_gb"`               // Ingest the estimates are scaled to
+	CurrentDroppedEvents    float64 `json:"current_dropped_events"` // Percent of sampled events
+	CurrentDroppedBytes     float64 `json:"current_dropped_bytes"`  // Percent of sampled bytes
+	ProposedDroppedEvents   float64 `json:"proposed_dropped_events"`
+	ProposedDroppedBytes    float64 `json:"proposed_dropped_bytes"`
+	ProposedDroppedGBPerDay float64 `json:"proposed_dropped_gb_per_day"`
+	SavingsGBPerDay         float64 `json:"savings_gb_per_day"` // Dropped beyond the current filters; negative when the proposal keeps more
+}
+
+// MetricsPoint is the ingest of one source over an interval of the metrics history
+type MetricsPoint struct {
+	Time       time.Time `json:"time"` // Start of the interval
+	Source     string    `json:"source"`
+	Logs       int64     `json:"logs"`
+	EventBytes int64     `json:"event_bytes"`
+	WireBytes  int64     `json:"wire_bytes"`
+	Sent       int64     `json:"sent"`
+	Dropped    int64     `json:"dropped"`
+	PeakEPS    float64   `json:"peak_eps"`
+}
+
+// EPSHeatmap is a source's average EPS by day of week and hour of day, from
+// the hourly metrics history. Cells are indexed [day][hour] with Monday
+// first, in the heatmap's time zone; hours before the source's history
+// begins do not count towards the averages.
+type EPSHeatmap struct {
+	Source   string      `json:"source"`
+	TimeZone string      `json:"time_zone"`
+	From     time.Time   `json:"from"`
+	To       time.Time   `json:"to"`
+	Hours    int         `json:"hours"`    // Complete hours of history averaged
+	Cells    [][]float64 `json:"cells"`    // 7 days of 24 hourly average EPS
+	PeakEPS  float64     `json:"peak_eps"` // Highest cell
+	MeanEPS  float64     `json:"mean_eps"` // Average over all hours
+}
+
+// HistoryTierStatus reports the disk usage of one tier of the metrics history
+type HistoryTierStatus struct {
+	Resolution string `json:"resolution"` // "minute" or "hour"
+	Retention  string `json:"retention"`  // e.g. "7 days"
+	Files      int    `json:"files"`
+	Bytes      int64  `json:"bytes"`
+	Oldest     string `json:"oldest,omitempty"` // Period of the oldest file
+	Newest     string `json:"newest,omitempty"`
+}
+
+// HistoryStorageStatus reports the disk usage of the metrics history
+type HistoryStorageStatus struct {
+	Dir        string              `json:"dir"`
+	TotalBytes int64               `json:"total_bytes"`
+	Tiers      []HistoryTierStatus `json:"tiers"`
+	LastPruned time.Time           `json:"last_pruned"`
+	Pruned     int                 `json:"pruned"` // Files removed since startup
+}
+
+// MetricsHistoryExport is a portable copy of the metrics history of a range,
+// with the points of both tiers
+type MetricsHistoryExport struct {
+	ExportedAt time.Time      `json:"exported_at"`
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Minute     []MetricsPoint `json:"minute"`
+	Hourly     []MetricsPoint `json:"hourly"`
+}
+
+// MetricsHistoryImport reports what importing a metrics history export did
+type MetricsHistoryImport struct {
+	Minute   int `json:"minute"`   // Per-minute points added
+	Hourly   int `json:"hourly"`   // Hourly points added
+	Existing int `json:"existing"` // Points skipped as already recorded
+	Expired  int `json:"expired"`  // Points skipped as past retention
 }
 
 // Config represents the complete application configuration
 type Config struct {
 	Sources        []SourceConfig `json:"sources"`
+	Tenants        []TenantConfig `json:"tenants,omitempty"`
+	Quotas         []QuotaConfig  `json:"quotas,omitempty"`
+	Listeners      []ListenerConfig `json:"listeners,omitempty"`
+	LookupTables   []LookupTableConfig `json:"lookup_tables,omitempty"`
+	FilterSets     []FilterSet         `json:"filter_sets,omitempty"`
+	SourceTemplates []SourceTemplate   `json:"source_templates,omitempty"`
+	IngestTokens   []IngestToken  `json:"ingest_tokens,omitempty"`
+	ClassificationRules []ClassificationRule `json:"classification_rules,omitempty"`
 	GlobalSettings GlobalSettings `json:"global_settings"`
 }
 
+// IngestToken authenticates a sender to the HTTP ingest receiver of a
+// source. Only a hash of the token is stored.
+type IngestToken struct {
+	ID         string    `json:"id"`
+	SourceID   string    `json:"source_id"`
+	Name       string    `json:"name"`   // Sender the token was issued to
+	Prefix     string    `json:"prefix"` // Leading characters, to recognize the token by
+	Hash       string    `json:"hash"`   // Hex SHA-256 of the token
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"` // End of the grace period of a rotated token
+	RevokedAt  time.Time `json:"revoked_at"`
+	ReplacedBy string    `json:"replaced_by,omitempty"` // Token that rotated this one out
+}
+
+// IngestTokenStatus is an ingest token, without its hash, and its usage
+type IngestTokenStatus struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	RevokedAt  time.Time `json:"revoked_at"`
+	ReplacedBy string    `json:"replaced_by,omitempty"`
+	Active     bool      `json:"active"`
+	LastUsed   time.Time `json:"last_used"`
+	Accepted   int64     `json:"accepted"` // Requests authenticated since startup
+	Rejected   int64     `json:"rejected"` // Requests refused as revoked or expired since startup
+}
+
+// IssuedIngestToken is a newly issued ingest token. The token itself is
+// returned only once.
+type IssuedIngestToken struct {
+	Token string `json:"token"`
+	IngestTokenStatus
+}
+
+// CredentialRotation replaces the API key of the HEC destinations with a
+// given name, e.g. one Splunk token shared by many sources
+type CredentialRotation struct {
+	APIKey  string   `json:"api_key"`
+	Sources []string `json:"sources,omitempty"` // Only these sources' destinations; empty rotates all the caller may manage
+	Reason  string   `json:"reason,omitempty"`  // Recorded in the audit trail
+	Actor   string   `json:"-"`                 // Who asked, e.g. "admin" or "tenant:acme"
+	Client  string   `json:"-"`                 // Address the request came from
+}
+
+// CredentialAuditEntry records the rotation of one destination's
+// credential. Credentials are only identified by a fingerprint.
+type CredentialAuditEntry struct {
+	Time           time.Time `json:"time"`
+	Actor          string    `json:"actor"`
+	Client         string    `json:"client,omitempty"`
+	Tenant         string    `json:"tenant,omitempty"` // Owning tenant of the source
+	Source         string    `json:"source"`
+	Destination    string    `json:"destination"`
+	DestinationID  string    `json:"destination_id"`
+	Field          string    `json:"field"` // Configuration key replaced, e.g. "api_key"
+	OldFingerprint string    `json:"old_fingerprint,omitempty"`
+	NewFingerprint string    `json:"new_fingerprint"`
+	Live           bool      `json:"live"` // The running destination switched without a restart; false when it was not delivering
+	Reason         string    `json:"reason,omitempty"`
+}
+
+// SecretPlaceholder names a secret an export replaced by "${Name}"
+type SecretPlaceholder struct {
+	Name string `json:"name"`
+	Path string `json:"path"` // Where the secret is used, e.g. "sources[fw].destinations[splunk].config.api_key"
+}
+
+// ConfigExport is the configuration with its secrets replaced by
+// placeholders, and the manifest of the secrets to supply
+type ConfigExport struct {
+	ExportedAt time.Time           `json:"exported_at"`
+	Config     Config              `json:"config"`
+	Secrets    []SecretPlaceholder `json:"secrets"`
+}
+
+// Kinds of change a config diff reports
+const (
+	ChangeAdded   = "added"
+	ChangeChanged = "changed"
+	ChangeRemoved = "removed"
+)
+
+// FieldChange is a setting whose value differs between two configurations
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from,omitempty"`
+	To    interface{} `json:"to,omitempty"`
+}
+
+// DestinationDiff is an added, changed or removed destination of a source
+type DestinationDiff struct {
+	Name   string        `json:"name"`
+	Change string        `json:"change"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// SourceDiff is an added, changed or removed source
+type SourceDiff struct {
+	Name         string            `json:"name"`
+	Change       string            `json:"change"`
+	Fields       []FieldChange     `json:"fields,omitempty"`
+	Destinations []DestinationDiff `json:"destinations,omitempty"`
+}
+
+// ConfigDiff lists what applying a proposed configuration would change
+type ConfigDiff struct {
+	InSync   bool          `json:"in_sync"`
+	Added    int           `json:"added"`
+	Changed  int           `json:"changed"`
+	Removed  int           `json:"removed"`
+	Sources  []SourceDiff  `json:"sources"`
+	Settings []FieldChange `json:"settings"` // Global settings and other sections
+}
+
 // LogEvent represents a processed log event
 type LogEvent struct {
 	Time   time.Time   `json:"time"`
 	Event  interface{} `json:"event"`
 	Source string      `json:"source"`
+	ID     string      `json:"id,omitempty"` // Unique event ID, set for sources delivering to a dedup destination
+	Classification []string `json:"classification,omitempty"` // Classification labels of the source
 	Size   int64       `json:"-"` // Internal use for metrics
+	SenderIP string    `json:"-"` // Address the message was received from
+	Severity int       `json:"-"` // Syslog severity from the PRI header, -1 if absent
+	Raw      []byte    `json:"-"` // Undecoded JSON payload of a lazily parsed event; Event is nil while set
+}
+
+// Materialize decodes a lazily parsed event's raw payload into Event
+func (e *LogEvent) Materialize() {
+	if e.Raw == nil {
+		return
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(e.Raw, &decoded); err == nil {
+		e.Event = decoded
+	} else {
+		e.Event = string(e.Raw)
+	}
+	e.Raw = nil
+}
+
+// Payload returns the event payload for serialization: the raw JSON of a
+// lazily parsed event, which is written out as is, or the decoded Event
+func (e LogEvent) Payload() interface{} {
+	if e.Raw != nil {
+		return json.RawMessage(e.Raw)
+	}
+	return e.Event
 }
 
 // SourceMetrics holds real-time metrics for a syslog source
 type SourceMetrics struct {
 	Name              string    `json:"name"`
+	Tenant            string    `json:"tenant,omitempty"`
+	Group             string    `json:"group,omitempty"`
+	Classification    []string  `json:"classification,omitempty"`
+	Tags              []string  `json:"tags,omitempty"`
 	SourceIP          string    `json:"source_ip"`
 	Port              int       `json:"port"`
 	Protocol          string    `json:"protocol"`
 	SimulationMode    bool      `json:"simulation_mode"`
 	RealTimeEPS       float64   `json:"realtime_eps"`
 	RealTimeGBps      float64   `json:"realtime_gbps"`
+	RealTimeWireGBps  float64   `json:"realtime_wire_gbps"`
 	TotalLogsIngested int64     `json:"total_logs_ingested"`
+	TotalEventBytes   int64     `json:"total_event_bytes"` // Parsed message payload bytes
+	TotalWireBytes    int64     `json:"total_wire_bytes"`  // Bytes received including framing
 	HourlyAvgLogs     int64     `json:"hourly_avg_logs"`
 	HourlyAvgGB       float64   `json:"hourly_avg_gb"`
 	DailyAvgLogs      int64     `json:"daily_avg_logs"`
 	DailyAvgGB        float64   `json:"daily_avg_gb"`
 	QueueDepth        int64     `json:"queue_depth"`
+	QueueAgeSeconds   float64   `json:"queue_age_seconds"` // Age of the oldest batch not yet processed, the one in progress included
 	ProcessedCount    int64     `json:"processed_count"`
 	SentCount         int64     `json:"sent_count"`
+	DroppedCount      int64     `json:"dropped_count"` // Application-level drops (queue overflow or quota)
+	KernelDrops       int64     `json:"kernel_drops"`  // OS-level socket drops for the listener
+	KernelDropsAvailable bool   `json:"kernel_drops_available"`
+	DestinationErrors int64     `json:"destination_errors"` // Failed destination deliveries
+	OpenConnections   int       `json:"open_connections"`   // Open TCP connections from this source
+	ListenerDown      bool      `json:"listener_down"`      // A listener of the source lost its socket and is re-binding
+	ListenerError     string    `json:"listener_error,omitempty"`
+	DeliveryPaused    bool      `json:"delivery_paused"`
+	HeldEvents        int64     `json:"held_events"`         // Events held back while delivery is paused, memory and disk
+	HeldBytesOnDisk   int64     `json:"held_bytes_on_disk"`  // Size of the pause spill file
+	HeldRefused       int64     `json:"held_refused"`        // Events dropped because the pause buffer was full
+	HeldAgeSeconds    float64   `json:"held_age_seconds"`    // Age of the oldest held event
+	AggregationOverflows int64  `json:"aggregation_overflows"` // Events that arrived after an aggregation group limit was reached
+	Panics            int64     `json:"panics"`           // Panics recovered in the source's processor and destinations
+	FilterErrors      []string  `json:"filter_errors,omitempty"` // Invalid filter rules of the running configuration
+	SeverityDropped   int64     `json:"severity_dropped"` // Events dropped by the minimum severity policy
+	TimestampFallbacks int64    `json:"timestamp_fallbacks,omitempty"` // Events timed on receipt because their device timestamp was missing or in the future
+	ParseFailures     int64     `json:"parse_failures,omitempty"` // Text messages the source's parser couldn't split, kept as strings
+	EventsByFacility  map[string]int64 `json:"events_by_facility,omitempty"` // Facility keyword -> parsed events
+	EventsBySeverity  map[string]int64 `json:"events_by_severity,omitempty"` // Severity keyword -> parsed events
+	DroppedBySeverity map[string]int64 `json:"dropped_by_severity,omitempty"` // Severity keyword -> dropped events
+	Destinations      []DestinationStats `json:"destinations,omitempty"`
+	LogMetrics        []MetricSample `json:"log_metrics,omitempty"` // Last closed window of metrics-mode aggregation
+	Stages            []StageMetrics `json:"stages,omitempty"`      // Time spent per pipeline stage
+	Latency           *LatencyMetrics `json:"latency,omitempty"`    // Measured by tracer events; nil without a tracer
+	Probe             *ProbeMetrics   `json:"probe,omitempty"`      // Device checks while silent; nil without a probe
+	RawCapture        *RawCaptureMetrics `json:"raw_capture,omitempty"` // nil without raw capture
+	BatchingMode       string        `json:"batching_mode"`        // static or adaptive
+	EffectiveBatchSize int           `json:"effective_batch_size"` // Events per queued batch right now
+	EffectiveFlushMs   int64         `json:"effective_flush_ms"`   // Longest wait of a partial batch right now; 0 in static mode
+	QuotaUsage        float64   `json:"quota_usage"` // Highest consumption percent of any applicable quota
+	CountersSince     time.Time `json:"counters_since"` // When the cumulative counters last started from zero
+	CounterResets     int64     `json:"counter_resets"` // Times the cumulative counters were reset
 	LastUpdated       time.Time `json:"last_updated"`
 	IsActive          bool      `json:"is_active"`
 	IsReceiving       bool      `json:"is_receiving"`
 	LastMessageAt     time.Time `json:"last_message_at"`
 }
 
+// LatencyMetrics reports the end-to-end latency measured by a source's
+// tracer events. A tracer's latency runs from when it was queued until the
+// last destination accepted it.
+type LatencyMetrics struct {
+	IntervalSeconds int       `json:"interval_seconds"`
+	Injected        int64     `json:"injected"`
+	Lost            int64     `json:"lost"` // Tracers dropped because the queue was full
+	Completed       int64     `json:"completed"`
+	LastSeconds     float64   `json:"last_seconds"`    // End-to-end latency of the last completed tracer
+	QueueSeconds    float64   `json:"queue_seconds"`   // Of which it waited in the source queue
+	DeliverSeconds  float64   `json:"deliver_seconds"` // Of which the destinations took to accept it
+	MaxSeconds      float64   `json:"max_seconds"`     // Highest end-to-end latency since the source started
+	PendingSeconds  float64   `json:"pending_seconds"` // Age of the oldest tracer still in flight, a lower bound on the current lag
+	LastMeasured    time.Time `json:"last_measured,omitempty"`
+}
+
+// Trace carries the times a tracer event passed the pipeline stages
+type Trace struct {
+	Injected time.Time // Queued behind the source's events
+	Dequeued time.Time // Taken off the queue for delivery
+}
+
+// StageMetrics reports the calls, events and time spent in one pipeline
+// stage of a source since it started
+type StageMetrics struct {
+	Stage             string            `json:"stage"` // parse, enrich, filter, aggregate or deliver
+	Calls             int64             `json:"calls"` // Messages parsed, or batches for the other stages
+	Events            int64             `json:"events"`
+	TotalSeconds      float64           `json:"total_seconds"`
+	AvgMicrosPerEvent float64           `json:"avg_us_per_event"`
+	Buckets           []HistogramBucket `json:"buckets"` // Cumulative call durations; calls is the +Inf bucket
+}
+
+// APIEndpointMetrics counts the requests of one web endpoint, identified by
+// method and route template, since startup
+type APIEndpointMetrics struct {
+	Method       string            `json:"method"`
+	Route        string            `json:"route"` // e.g. /api/sources/{name}
+	Requests     int64             `json:"requests"`
+	ClientErrors int64             `json:"client_errors"` // 4xx responses
+	ServerErrors int64             `json:"server_errors"` // 5xx responses
+	ErrorRate    float64           `json:"error_rate"`    // Percent of requests answered 4xx or 5xx
+	SlowRequests int64             `json:"slow_requests"` // Requests over the slow request threshold
+	TotalSeconds float64           `json:"total_seconds"`
+	P50Ms        float64           `json:"p50_ms"` // Percentiles of the most recent requests
+	P95Ms        float64           `json:"p95_ms"`
+	P99Ms        float64           `json:"p99_ms"`
+	MaxMs        float64           `json:"max_ms"`
+	Buckets      []HistogramBucket `json:"buckets"` // Cumulative request durations; requests is the +Inf bucket
+}
+
+// HistogramBucket is one cumulative bucket of a duration histogram
+type HistogramBucket struct {
+	UpperBound float64 `json:"le"` // Seconds
+	Count      int64   `json:"count"`
+}
+
+// DestinationStats reports per-destination delivery counters
+type DestinationStats struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	FinalizedFiles int64  `json:"finalized_files,omitempty"` // Storage files closed and renamed to their final name
+	Window         string `json:"window,omitempty"`          // Delivery window, "HH:MM-HH:MM"
+	WindowOpen     bool   `json:"window_open,omitempty"`
+	Buffered       int64  `json:"buffered,omitempty"`        // Events spooled until the window opens
+	BufferedBytes  int64  `json:"buffered_bytes,omitempty"`
+	BufferRefused  int64  `json:"buffer_refused,omitempty"`  // Events dropped because the spool was full
+	BufferedAgeSeconds float64 `json:"buffered_age_seconds,omitempty"` // Age of the oldest spooled event
+	Throttle             string  `json:"throttle,omitempty"`              // Rate limits, e.g. "2 MB/s, 500 EPS"
+	ThrottleWaitSeconds  float64 `json:"throttle_wait_seconds,omitempty"` // Time delivery waited for the throttle
+	ThrottledBatches     int64   `json:"throttled_batches,omitempty"`     // Batches that had to wait
+	DuplicatesSuppressed int64   `json:"duplicates_suppressed,omitempty"` // Replayed events skipped because the destination already received them
+	CostPerGB      float64   `json:"cost_per_gb,omitempty"`
+	MeteredSince   time.Time `json:"metered_since,omitempty"`   // Start of the delivery counted below; zero when unmetered
+	DeliveredBytes int64     `json:"delivered_bytes,omitempty"` // Event payload bytes the destination accepted
+	IngestedBytes  int64     `json:"ingested_bytes,omitempty"`  // Event payload bytes the source received over the same time
+	HeartbeatsSent    int64     `json:"heartbeats_sent,omitempty"`
+	HeartbeatFailures int64     `json:"heartbeat_failures,omitempty"` // Heartbeats the destination did not accept
+	LastHeartbeat     time.Time `json:"last_heartbeat,omitempty"`     // When the destination last accepted a heartbeat; zero before the first
+	LatencySeconds    float64   `json:"latency_seconds,omitempty"`    // Queueing of the source's last tracer until the destination accepted it
+}
+
+// StorageFileIndex is the sidecar index written next to each finalized storage file
+type StorageFileIndex struct {
+	File        string    `json:"file"`   // Data file name, relative to the storage path
+	Events      int       `json:"events"`
+	Bytes       int64     `json:"bytes"`
+	FirstEvent  time.Time `json:"first_event"`
+	LastEvent   time.Time `json:"last_event"`
+	SHA256      string    `json:"sha256"`
+	FinalizedAt time.Time `json:"finalized_at"`
+}
+
 // GlobalMetrics represents aggregated metrics across all sources
 type GlobalMetrics struct {
 	TotalRealTimeEPS      float64 `json:"total_realtime_eps"`
 	TotalRealTimeGBps     float64 `json:"total_realtime_gbps"`
+	TotalRealTimeWireGBps float64 `json:"total_realtime_wire_gbps"`
 	TotalLogsIngested     int64   `json:"total_logs_ingested"`
+	TotalEventBytes       int64   `json:"total_event_bytes"`
+	TotalWireBytes        int64   `json:"total_wire_bytes"`
 	TotalHourlyAvgLogs    int64   `json:"total_hourly_avg_logs"`
 	TotalHourlyAvgGB      float64 `json:"total_hourly_avg_gb"`
 	TotalDailyAvgLogs     int64   `json:"total_daily_avg_logs"`
 	TotalDailyAvgGB       float64 `json:"total_daily_avg_gb"`
 	TotalQueueDepth       int64   `json:"total_queue_depth"`
+	MaxQueueAgeSeconds    float64 `json:"max_queue_age_seconds"` // Oldest unprocessed batch of any source
 	TotalProcessedCount   int64   `json:"total_processed_count"`
 	TotalSentCount        int64   `json:"total_sent_count"`
+	TotalDroppedCount     int64   `json:"total_dropped_count"`
+	TotalDestinationErrors int64  `json:"total_destination_errors"`
+	TotalSeverityDropped  int64   `json:"total_severity_dropped"`
+	TotalPanics           int64   `json:"total_panics"` // Panics recovered anywhere since startup
 	ActiveSources         int     `json:"active_sources"`
 	TotalSources          int     `json:"total_sources"`
+	Quotas                []QuotaStatus `json:"quotas,omitempty"`
+}
+
+// SummarizeMetrics aggregates per-source metrics into global metrics
+func SummarizeMetrics(sources []SourceMetrics) GlobalMetrics {
+	global := GlobalMetrics{
+		TotalSources: len(sources),
+	}
+	
+	for _, metrics := range sources {
+		global.TotalRealTimeEPS += metrics.RealTimeEPS
+		global.TotalRealTimeGBps += metrics.RealTimeGBps
+		global.TotalRealTimeWireGBps += metrics.RealTimeWireGBps
+		global.TotalLogsIngested += metrics.TotalLogsIngested
+		global.TotalEventBytes += metrics.TotalEventBytes
+		global.TotalWireBytes += metrics.TotalWireBytes
+		global.TotalHourlyAvgLogs += metrics.HourlyAvgLogs
+		global.TotalHourlyAvgGB += metrics.HourlyAvgGB
+		global.TotalDailyAvgLogs += metrics.DailyAvgLogs
+		global.TotalDailyAvgGB += metrics.DailyAvgGB
+		global.TotalQueueDepth += metrics.QueueDepth
+		if metrics.QueueAgeSeconds > global.MaxQueueAgeSeconds {
+			global.MaxQueueAgeSeconds = metrics.QueueAgeSeconds
+		}
+		global.TotalProcessedCount += metrics.ProcessedCount
+		global.TotalSentCount += metrics.SentCount
+		global.TotalDroppedCount += metrics.DroppedCount
+		global.TotalDestinationErrors += metrics.DestinationErrors
+		global.TotalSeverityDropped += metrics.SeverityDropped
+		
+		if metrics.IsActive {
+			global.ActiveSources++
+		}
+	}
+	
+	return global
 }
 
 // MetricDataPoint represents a single metric measurement
@@ -124,6 +1670,7 @@ type MetricDataPoint struct {
 	Timestamp   time.Time
 	LogCount    int64
 	DataSize    int64 // in bytes
+	WireSize    int64 // in bytes, including framing
 	Processed   int64
 	Sent        int64
 }
@@ -193,11 +1740,36 @@ func (cb *CircularBuffer) GetAverage(duration time.Duration) (int64, float64, in
 	return avgLogs, avgGB, avgProcessed, avgSent
 }
 
+// GetWireAverage calculates the average wire bytes in GB for the specified duration
+func (cb *CircularBuffer) GetWireAverage(duration time.Duration) float64 {
+	cb.Mutex.RLock()
+	defer cb.Mutex.RUnlock()
+	
+	cutoff := time.Now().Add(-duration)
+	var totalBytes int64
+	var validPoints int
+	
+	for i := 0; i < cb.Count; i++ {
+		idx := (cb.Head - 1 - i + cb.Size) % cb.Size
+		if cb.Buffer[idx].Timestamp.After(cutoff) {
+			totalBytes += cb.Buffer[idx].WireSize
+			validPoints++
+		}
+	}
+	
+	if validPoints == 0 {
+		return 0
+	}
+	
+	return float64(totalBytes) / (1024 * 1024 * 1024) / float64(validPoints)
+}
+
 // LogBatch represents a batch of log events for processing
 type LogBatch struct {
 	Events    []LogEvent
 	SourceIP  string
 	Timestamp time.Time
+	Trace     *Trace // Set on the batch of a tracer event
 }
 
 // QueueStats represents queue statistics
@@ -205,6 +1777,8 @@ type QueueStats struct {
 	Depth      int64
 	Processed  int64
 	Sent       int64
+	Dropped    int64
+	DestErrors int64
 	LastUpdate time.Time
 }
 
@@ -219,4 +1793,4 @@ type TestDestinationRequest struct {
 type TestDestinationResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,221 @@
+// Package mtls authenticates and encrypts traffic between analyzer
+// instances, such as an analyzer relaying events to another, with mutual
+// TLS. Certificates are re-read when their files change so they can be
+// rotated on either side without a restart.
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/fips"
+	"syslog-analyzer/models"
+)
+
+// Timing of reloads and handshakes
+const (
+	reloadCheckInterval = 10 * time.Second
+	handshakeTimeout    = 10 * time.Second
+)
+
+// Credentials are an analyzer's certificate and the CA its peers' must chain to
+type Credentials struct {
+	config     models.MutualTLSConfig
+	mutex      sync.Mutex
+	cert       tls.Certificate
+	leaf       *x509.Certificate
+	pool       *x509.CertPool
+	modTimes   [3]time.Time // Of the certificate, key and CA files when loaded
+	checked    time.Time
+	loadedAt   time.Time
+	lastError  string
+	handshakes int64 // Atomic
+	failures   int64 // Atomic
+}
+
+// Load reads the credentials a configuration names
+func Load(config models.MutualTLSConfig) (*Credentials, error) {
+	if config.CertFile == "" || config.KeyFile == "" || config.CAFile == "" {
+		return nil, fmt.Errorf("tls requires cert_file, key_file and ca_file")
+	}
+	c := &Credentials{config: config}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load reads the files; the caller holds the mutex unless c is not shared yet
+func (c *Credentials) load() error {
+	modTimes := c.fileModTimes()
+	
+	cert, err := tls.LoadX509KeyPair(c.config.CertFile, c.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tls certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("tls certificate: %v", err)
+	}
+	
+	caData, err := ioutil.ReadFile(c.config.CAFile)
+	if err != nil {
+		return fmt.Errorf("tls CA: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return fmt.Errorf("tls CA: no certificates in %s", c.config.CAFile)
+	}
+	
+	c.cert = cert
+	c.leaf = leaf
+	c.pool = pool
+	c.modTimes = modTimes
+	c.loadedAt = time.Now()
+	c.checked = c.loadedAt
+	return nil
+}
+
+// fileModTimes returns the modification times of the certificate, key and CA files
+func (c *Credentials) fileModTimes() [3]time.Time {
+	var modTimes [3]time.Time
+	for i, file := range []string{c.config.CertFile, c.config.KeyFile, c.config.CAFile} {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[i] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// current returns the certificate and CA pool, reloading them first when
+// the files changed. A failed reload keeps the previous ones.
+func (c *Credentials) current() (tls.Certificate, *x509.CertPool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	if time.Since(c.checked) >= reloadCheckInterval {
+		c.checked = time.Now()
+		if c.fileModTimes() != c.modTimes {
+			if err := c.load(); err != nil {
+				c.lastError = fmt.Sprintf("reload: %v", err)
+				log.Printf("⚠ Keeping previous TLS certificate %s: %v", c.config.CertFile, err)
+			} else {
+				c.lastError = ""
+				log.Printf("✓ Reloaded TLS certificate %s (%s, expires %s)", c.config.CertFile, c.leaf.Subject.CommonName, c.leaf.NotAfter.Format("2006-01-02"))
+			}
+		}
+	}
+	return c.cert, c.pool
+}
+
+// ServerConfig returns the TLS configuration of a listener requiring
+// client certificates
+func (c *Credentials) ServerConfig() *tls.Config {
+	cert, pool := c.current()
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if len(c.config.AllowedNames) > 0 {
+		config.VerifyPeerCertificate = verifyAllowedNames(c.config.AllowedNames)
+	}
+	return fips.Apply(config)
+}
+
+// ClientConfig returns the TLS configuration of a connection to the
+// analyzer at host
+func (c *Credentials) ClientConfig(host string) *tls.Config {
+	cert, pool := c.current()
+	serverName := c.config.ServerName
+	if serverName == "" {
+		serverName = host
+	}
+	return fips.Apply(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	})
+}
+
+// Server completes the server side of the handshake on an accepted connection
+func (c *Credentials) Server(conn net.Conn) (*tls.Conn, error) {
+	tlsConn := tls.Server(conn, c.ServerConfig())
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	err := tlsConn.Handshake()
+	conn.SetDeadline(time.Time{})
+	c.record(err)
+	return tlsConn, err
+}
+
+// Dial connects to the analyzer at address
+func (c *Credentials) Dial(address string, timeout time.Duration) (*tls.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, c.ClientConfig(host))
+	c.record(err)
+	return conn, err
+}
+
+// record counts a handshake
+func (c *Credentials) record(err error) {
+	atomic.AddInt64(&c.handshakes, 1)
+	if err == nil {
+		return
+	}
+	atomic.AddInt64(&c.failures, 1)
+	c.mutex.Lock()
+	c.lastError = err.Error()
+	c.mutex.Unlock()
+}
+
+// Status returns the certificate presented to peers and the handshake counters
+func (c *Credentials) Status() models.MutualTLSStatus {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	return models.MutualTLSStatus{
+		Subject:    c.leaf.Subject.String(),
+		NotAfter:   c.leaf.NotAfter,
+		LoadedAt:   c.loadedAt,
+		Handshakes: atomic.LoadInt64(&c.handshakes),
+		Failures:   atomic.LoadInt64(&c.failures),
+		LastError:  c.lastError,
+	}
+}
+
+// verifyAllowedNames accepts only client certificates whose common name or
+// a DNS name is allowed
+func verifyAllowedNames(allowed []string) func([][]byte, [][]*x509.Certificate) error {
+	names := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		names[name] = true
+	}
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		for _, chain := range verifiedChains {
+			peer := chain[0]
+			if names[peer.Subject.CommonName] {
+				return nil
+			}
+			for _, name := range peer.DNSNames {
+				if names[name] {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("client certificate name is not allowed")
+	}
+}
@@ -0,0 +1,193 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// defaultTemplate is used when a connector does not define its own
+const defaultTemplate = `{{if eq .State "firing"}}🔴 ALERT{{else}}✅ RESOLVED{{end}}: {{.Rule}}
+Source: {{.Source}}
+Metric: {{.Metric}} = {{printf "%.2f" .Value}} (threshold {{.Operator}} {{printf "%.2f" .Threshold}})
+{{if .DashboardURL}}Dashboard: {{.DashboardURL}}{{end}}`
+
+// Notifier delivers alerts to an external system
+type Notifier interface {
+	Name() string
+	Notify(alert Alert) error
+}
+
+// NewNotifier creates a notifier for the given connector configuration
+func NewNotifier(connector models.NotificationConnector) (Notifier, error) {
+	switch connector.Type {
+	case "slack":
+		return newSlackNotifier(connector)
+	case "teams":
+		return newTeamsNotifier(connector)
+	case "pagerduty":
+		return newPagerDutyNotifier(connector)
+	case "opsgenie":
+		return newOpsgenieNotifier(connector)
+	default:
+		return nil, fmt.Errorf("unknown connector type: %s", connector.Type)
+	}
+}
+
+// webhookNotifier holds the pieces shared by webhook-based connectors
+type webhookNotifier struct {
+	name     string
+	url      string
+	template *template.Template
+	client   *http.Client
+}
+
+// newWebhookNotifier validates the webhook URL and parses the message template
+func newWebhookNotifier(connector models.NotificationConnector) (*webhookNotifier, error) {
+	if connector.WebhookURL == "" {
+		return nil, fmt.Errorf("webhook URL is empty")
+	}
+	
+	text := connector.Template
+	if text == "" {
+		text = defaultTemplate
+	}
+	tmpl, err := template.New(connector.Name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	
+	return &webhookNotifier{
+		name:     connector.Name,
+		url:      connector.WebhookURL,
+		template: tmpl,
+		client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}, nil
+}
+
+// render executes the message template for an alert
+func (w *webhookNotifier) render(alert Alert) (string, error) {
+	var buf bytes.Buffer
+	if err := w.template.Execute(&buf, alert); err != nil {
+		return "", fmt.Errorf("failed to render template: %v", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// post sends a JSON payload to the webhook
+func (w *webhookNotifier) post(payload interface{}) error {
+	return postJSON(w.client, w.url, nil, payload)
+}
+
+// SlackNotifier posts alerts to a Slack incoming webhook
+type SlackNotifier struct {
+	*webhookNotifier
+}
+
+// newSlackNotifier creates a Slack connector
+func newSlackNotifier(connector models.NotificationConnector) (*SlackNotifier, error) {
+	base, err := newWebhookNotifier(connector)
+	if err != nil {
+		return nil, err
+	}
+	return &SlackNotifier{base}, nil
+}
+
+// Name returns the connector name
+func (s *SlackNotifier) Name() string {
+	return "slack:" + s.name
+}
+
+// Notify sends the alert to Slack
+func (s *SlackNotifier) Notify(alert Alert) error {
+	text, err := s.render(alert)
+	if err != nil {
+		return err
+	}
+	
+	payload := map[string]interface{}{
+		"text": text,
+	}
+	if alert.DashboardURL != "" {
+		payload["blocks"] = []map[string]interface{}{
+			{
+				"type": "section",
+				"text": map[string]string{"type": "mrkdwn", "text": text},
+			},
+			{
+				"type": "actions",
+				"elements": []map[string]interface{}{
+					{
+						"type": "button",
+						"text": map[string]string{"type": "plain_text", "text": "Open Dashboard"},
+						"url":  alert.DashboardURL,
+					},
+				},
+			},
+		}
+	}
+	
+	return s.post(payload)
+}
+
+// TeamsNotifier posts alerts to a Microsoft Teams incoming webhook
+type TeamsNotifier struct {
+	*webhookNotifier
+}
+
+// newTeamsNotifier creates a Teams connector
+func newTeamsNotifier(connector models.NotificationConnector) (*TeamsNotifier, error) {
+	base, err := newWebhookNotifier(connector)
+	if err != nil {
+		return nil, err
+	}
+	return &TeamsNotifier{base}, nil
+}
+
+// Name returns the connector name
+func (t *TeamsNotifier) Name() string {
+	return "teams:" + t.name
+}
+
+// Notify sends the alert to Teams as a MessageCard
+func (t *TeamsNotifier) Notify(alert Alert) error {
+	text, err := t.render(alert)
+	if err != nil {
+		return err
+	}
+	
+	color := "D9534F"
+	if alert.State == StateResolved {
+		color = "5CB85C"
+	}
+	
+	card := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    fmt.Sprintf("%s: %s on %s", strings.ToUpper(alert.State), alert.Rule, alert.Source),
+		"themeColor": color,
+		"title":      fmt.Sprintf("Syslog Analyzer: %s", alert.Rule),
+		// Teams renders markdown; two trailing spaces force line breaks
+		"text": strings.ReplaceAll(text, "\n", "  \n"),
+	}
+	if alert.DashboardURL != "" {
+		card["potentialAction"] = []map[string]interface{}{
+			{
+				"@type": "OpenUri",
+				"name":  "Open Dashboard",
+				"targets": []map[string]string{
+					{"os": "default", "uri": alert.DashboardURL},
+				},
+			},
+		}
+	}
+	
+	return t.post(card)
+}
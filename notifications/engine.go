@@ -0,0 +1,496 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// knownMetrics lists the source metrics that rules can reference
+var knownMetrics = map[string]bool{
+	"eps":                     true,
+	"gbps":                    true,
+	"queue_depth":             true,
+	"dropped":                 true,
+	"destination_errors":      true,
+	"kernel_drops":            true,
+	"silent_seconds":          true,
+	"quota_usage":             true,
+	"aggregation_overflows":   true,
+	"panics":                  true,
+	"queue_age_seconds":       true,
+	"destination_age_seconds": true,
+	"probe_unreachable":       true,
+}
+
+// Alert states
+const (
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// Alert describes a rule that started or stopped matching for a source
+type Alert struct {
+	Rule         string    `json:"rule"`
+	Source       string    `json:"source"`
+	Metric       string    `json:"metric"`
+	Operator     string    `json:"operator"`
+	Value        float64   `json:"value"`
+	Threshold    float64   `json:"threshold"`
+	Severity     string    `json:"severity"`
+	State        string    `json:"state"`
+	StartedAt    time.Time `json:"started_at"`
+	Timestamp    time.Time `json:"timestamp"`
+	DashboardURL string    `json:"dashboard_url"`
+	
+	Acknowledged   bool      `json:"acknowledged"`
+	AcknowledgedBy string    `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt time.Time `json:"acknowledged_at,omitempty"`
+	Silenced       bool      `json:"silenced"`
+}
+
+// Silence suppresses notifications for matching alerts until it expires
+type Silence struct {
+	ID        string    `json:"id"`
+	Rule      string    `json:"rule"`   // Empty matches any rule
+	Source    string    `json:"source"` // Empty matches any source
+	Until     time.Time `json:"until"`
+	Comment   string    `json:"comment"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Matches reports whether the silence applies to an alert at the given time
+func (s Silence) Matches(alert Alert, now time.Time) bool {
+	if now.After(s.Until) {
+		return false
+	}
+	return (s.Rule == "" || s.Rule == alert.Rule) && (s.Source == "" || s.Source == alert.Source)
+}
+
+// Acknowledger is implemented by notifiers that can forward acknowledgements,
+// such as incident management systems
+type Acknowledger interface {
+	Acknowledge(alert Alert) error
+}
+
+// Key returns the identity of the alert, stable across firing and resolution
+func (a Alert) Key() string {
+	return a.Rule + "|" + a.Source
+}
+
+// Engine evaluates alert rules against source metrics and dispatches
+// notifications to the configured connectors on state changes
+type Engine struct {
+	config      models.NotificationsConfig
+	notifiers   []Notifier
+	severities  [][]string // Per-notifier severity filter, parallel to notifiers
+	metricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)
+	active      map[string]*Alert
+	silences    map[string]Silence
+	nextSilence int
+	mutex       sync.RWMutex
+	stopChan    chan bool
+}
+
+// NewEngine creates a new alert engine
+func NewEngine(config models.NotificationsConfig, metricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)) (*Engine, error) {
+	if config.EvaluationIntervalSeconds <= 0 {
+		config.EvaluationIntervalSeconds = 30
+	}
+	
+	for _, rule := range config.Rules {
+		if err := validateRule(rule); err != nil {
+			return nil, fmt.Errorf("invalid rule '%s': %v", rule.Name, err)
+		}
+	}
+	
+	var notifiers []Notifier
+	var severities [][]string
+	for _, connector := range config.Connectors {
+		if !connector.Enabled {
+			continue
+		}
+		notifier, err := NewNotifier(connector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid connector '%s': %v", connector.Name, err)
+		}
+		notifiers = append(notifiers, notifier)
+		severities = append(severities, connector.Severities)
+	}
+	
+	return &Engine{
+		config:      config,
+		notifiers:   notifiers,
+		severities:  severities,
+		metricsFunc: metricsFunc,
+		active:      make(map[string]*Alert),
+		silences:    make(map[string]Silence),
+		stopChan:    make(chan bool),
+	}, nil
+}
+
+// Start begins periodic rule evaluation
+func (e *Engine) Start() {
+	go e.run()
+	log.Printf("✓ Alert engine started (%d rules, %d connectors)", len(e.config.Rules), len(e.notifiers))
+}
+
+// Stop stops rule evaluation
+func (e *Engine) Stop() {
+	close(e.stopChan)
+}
+
+// run evaluates rules on the configured interval
+func (e *Engine) run() {
+	ticker := time.NewTicker(time.Duration(e.config.EvaluationIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-e.stopChan:
+			return
+		case <-ticker.C:
+			e.Evaluate()
+		}
+	}
+}
+
+// Evaluate checks all rules against current metrics once
+func (e *Engine) Evaluate() {
+	if e.metricsFunc == nil {
+		return
+	}
+	sources, _ := e.metricsFunc()
+	now := time.Now()
+	
+	var changed []Alert
+	seen := make(map[string]bool)
+	
+	e.mutex.Lock()
+	for _, rule := range e.config.Rules {
+		for _, source := range sources {
+			if !ruleAppliesTo(rule, source.Name) {
+				continue
+			}
+			
+			value, ok := metricValue(source, rule.Metric, now)
+			if !ok {
+				continue
+			}
+			
+			key := rule.Name + "|" + source.Name
+			seen[key] = true
+			existing, firing := e.active[key]
+			
+			if compare(value, rule.Operator, rule.Threshold) {
+				if firing {
+					existing.Value = value
+					continue
+				}
+				alert := &Alert{
+					Rule:         rule.Name,
+					Source:       source.Name,
+					Metric:       rule.Metric,
+					Operator:     rule.Operator,
+					Value:        value,
+					Threshold:    rule.Threshold,
+					Severity:     rule.Severity,
+					State:        StateFiring,
+					StartedAt:    now,
+					Timestamp:    now,
+					DashboardURL: e.dashboardLink(source.Name),
+				}
+				e.active[key] = alert
+				changed = append(changed, *alert)
+			} else if firing {
+				changed = append(changed, e.resolve(key, existing, value, now))
+			}
+		}
+	}
+	
+	// Alerts for sources or rules that no longer exist are resolved
+	for key, existing := range e.active {
+		if !seen[key] {
+			changed = append(changed, e.resolve(key, existing, existing.Value, now))
+		}
+	}
+	e.mutex.Unlock()
+	
+	for _, alert := range changed {
+		e.dispatch(alert)
+	}
+}
+
+// resolve removes an active alert and returns its resolved form. Caller holds the mutex.
+func (e *Engine) resolve(key string, alert *Alert, value float64, now time.Time) Alert {
+	delete(e.active, key)
+	resolved := *alert
+	resolved.Value = value
+	resolved.State = StateResolved
+	resolved.Timestamp = now
+	return resolved
+}
+
+// dispatch sends an alert to every connector unless it is silenced
+func (e *Engine) dispatch(alert Alert) {
+	if e.isSilenced(alert) {
+		log.Printf("🔕 Alert %s silenced: %s on '%s'", alert.State, alert.Rule, alert.Source)
+		return
+	}
+	
+	log.Printf("🔔 Alert %s: %s on '%s' (%s %s %.2f, value %.2f)", alert.State, alert.Rule, alert.Source, alert.Metric, alert.Operator, alert.Threshold, alert.Value)
+	
+	for i, notifier := range e.notifiers {
+		if !severityAllowed(e.severities[i], alert.Severity) {
+			continue
+		}
+		if err := notifier.Notify(alert); err != nil {
+			log.Printf("⚠ Failed to send alert via %s: %v", notifier.Name(), err)
+		}
+	}
+}
+
+// GetActiveAlerts returns all currently firing alerts
+func (e *Engine) GetActiveAlerts() []Alert {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	
+	now := time.Now()
+	alerts := make([]Alert, 0, len(e.active))
+	for _, alert := range e.active {
+		a := *alert
+		a.Silenced = e.silencedLocked(a, now)
+		alerts = append(alerts, a)
+	}
+	
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Key() < alerts[j].Key()
+	})
+	return alerts
+}
+
+// Acknowledge marks a firing alert as acknowledged and forwards the
+// acknowledgement to connectors that support it
+func (e *Engine) Acknowledge(rule, source, by string) (Alert, error) {
+	e.mutex.Lock()
+	alert, exists := e.active[rule+"|"+source]
+	if !exists {
+		e.mutex.Unlock()
+		return Alert{}, fmt.Errorf("no active alert for rule '%s' on source '%s'", rule, source)
+	}
+	alert.Acknowledged = true
+	alert.AcknowledgedBy = by
+	alert.AcknowledgedAt = time.Now()
+	acked := *alert
+	e.mutex.Unlock()
+	
+	log.Printf("✓ Alert acknowledged by %s: %s on '%s'", by, rule, source)
+	for i, notifier := range e.notifiers {
+		acknowledger, ok := notifier.(Acknowledger)
+		if !ok || !severityAllowed(e.severities[i], acked.Severity) {
+			continue
+		}
+		if err := acknowledger.Acknowledge(acked); err != nil {
+			log.Printf("⚠ Failed to forward acknowledgement via %s: %v", notifier.Name(), err)
+		}
+	}
+	
+	return acked, nil
+}
+
+// AddSilence registers a silence and returns it with its assigned ID
+func (e *Engine) AddSilence(silence Silence) (Silence, error) {
+	if silence.Until.Before(time.Now()) {
+		return Silence{}, fmt.Errorf("silence end time is in the past")
+	}
+	
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	
+	e.nextSilence++
+	silence.ID = fmt.Sprintf("s%d", e.nextSilence)
+	silence.CreatedAt = time.Now()
+	e.silences[silence.ID] = silence
+	
+	log.Printf("🔕 Silence %s added by %s until %s (rule '%s', source '%s')", silence.ID, silence.CreatedBy, silence.Until.Format(time.RFC3339), silence.Rule, silence.Source)
+	return silence, nil
+}
+
+// RemoveSilence deletes a silence by ID
+func (e *Engine) RemoveSilence(id string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	
+	if _, exists := e.silences[id]; !exists {
+		return fmt.Errorf("silence '%s' not found", id)
+	}
+	delete(e.silences, id)
+	return nil
+}
+
+// GetSilences returns all unexpired silences, dropping expired ones
+func (e *Engine) GetSilences() []Silence {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	
+	now := time.Now()
+	silences := make([]Silence, 0, len(e.silences))
+	for id, silence := range e.silences {
+		if now.After(silence.Until) {
+			delete(e.silences, id)
+			continue
+		}
+		silences = append(silences, silence)
+	}
+	
+	sort.Slice(silences, func(i, j int) bool {
+		return silences[i].Until.Before(silences[j].Until)
+	})
+	return silences
+}
+
+// isSilenced reports whether any active silence matches the alert
+func (e *Engine) isSilenced(alert Alert) bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.silencedLocked(alert, time.Now())
+}
+
+// silencedLocked is isSilenced for callers already holding the mutex
+func (e *Engine) silencedLocked(alert Alert, now time.Time) bool {
+	for _, silence := range e.silences {
+		if silence.Matches(alert, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// dashboardLink builds a deep link to the source on the dashboard
+func (e *Engine) dashboardLink(sourceName string) string {
+	base := strings.TrimRight(e.config.DashboardURL, "/")
+	if base == "" {
+		return ""
+	}
+	return base + "/dashboard#source=" + url.QueryEscape(sourceName)
+}
+
+// validateRule checks that a rule references a known metric and operator
+func validateRule(rule models.AlertRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if !knownMetrics[rule.Metric] {
+		return fmt.Errorf("unknown metric: %s", rule.Metric)
+	}
+	switch rule.Operator {
+	case ">", ">=", "<", "<=", "==":
+	default:
+		return fmt.Errorf("unknown operator: %s", rule.Operator)
+	}
+	return nil
+}
+
+// severityAllowed reports whether a connector accepts alerts of the given severity
+func severityAllowed(allowed []string, severity string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, s := range allowed {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleAppliesTo reports whether a rule is scoped to the given source
+func ruleAppliesTo(rule models.AlertRule, sourceName string) bool {
+	if len(rule.Sources) == 0 {
+		return true
+	}
+	for _, name := range rule.Sources {
+		if name == sourceName {
+			return true
+		}
+	}
+	return false
+}
+
+// metricValue extracts the named metric from source metrics
+func metricValue(source models.SourceMetrics, metric string, now time.Time) (float64, bool) {
+	switch metric {
+	case "eps":
+		return source.RealTimeEPS, true
+	case "gbps":
+		return source.RealTimeGBps, true
+	case "queue_depth":
+		return float64(source.QueueDepth), true
+	case "dropped":
+		return float64(source.DroppedCount), true
+	case "destination_errors":
+		return float64(source.DestinationErrors), true
+	case "kernel_drops":
+		return float64(source.KernelDrops), source.KernelDropsAvailable
+	case "aggregation_overflows":
+		return float64(source.AggregationOverflows), true
+	case "panics":
+		return float64(source.Panics), true
+	case "quota_usage":
+		return source.QuotaUsage, source.Tenant != "" || source.Group != ""
+	case "queue_age_seconds":
+		return source.QueueAgeSeconds, source.IsActive
+	case "destination_age_seconds":
+		// Oldest event any destination of the source holds back
+		var oldest float64
+		for _, dest := range source.Destinations {
+			if dest.BufferedAgeSeconds > oldest {
+				oldest = dest.BufferedAgeSeconds
+			}
+		}
+		return oldest, source.IsActive
+	case "silent_seconds":
+		// Seconds since the last message; sources that never received count from creation
+		if !source.IsActive {
+			return 0, false
+		}
+		if source.LastMessageAt.IsZero() {
+			return 0, true
+		}
+		return now.Sub(source.LastMessageAt).Seconds(), true
+	case "probe_unreachable":
+		// 1 while a silent source's device does not answer its probes
+		if source.Probe == nil || source.Probe.State == models.ProbeReceiving || source.Probe.LastProbe.IsZero() {
+			return 0, false
+		}
+		if source.Probe.State == models.ProbeUnreachable {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// compare applies a rule operator
+func compare(value float64, operator string, threshold float64) bool {
+	switch operator {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	}
+	return false
+}
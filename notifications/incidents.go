@@ -0,0 +1,232 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Default incident management API endpoints
+const (
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieAlertsURL  = "https://api.opsgenie.com/v2/alerts"
+)
+
+// DedupKey returns the incident deduplication key for an alert. The same key
+// is used to open and resolve the incident, one per source and rule.
+func DedupKey(alert Alert) string {
+	return "syslog-analyzer:" + alert.Rule + ":" + alert.Source
+}
+
+// PagerDutyNotifier opens and resolves incidents through the PagerDuty Events API v2
+type PagerDutyNotifier struct {
+	name       string
+	routingKey string
+	url        string
+	client     *http.Client
+}
+
+// newPagerDutyNotifier creates a PagerDuty connector
+func newPagerDutyNotifier(connector models.NotificationConnector) (*PagerDutyNotifier, error) {
+	if connector.RoutingKey == "" {
+		return nil, fmt.Errorf("PagerDuty routing key is empty")
+	}
+	
+	apiURL := connector.APIURL
+	if apiURL == "" {
+		apiURL = pagerDutyEventsURL
+	}
+	
+	return &PagerDutyNotifier{
+		name:       connector.Name,
+		routingKey: connector.RoutingKey,
+		url:        apiURL,
+		client:     &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Name returns the connector name
+func (p *PagerDutyNotifier) Name() string {
+	return "pagerduty:" + p.name
+}
+
+// Notify triggers or resolves the incident for the alert
+func (p *PagerDutyNotifier) Notify(alert Alert) error {
+	event := map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"dedup_key":    DedupKey(alert),
+		"event_action": "trigger",
+	}
+	
+	if alert.State == StateResolved {
+		event["event_action"] = "resolve"
+	} else {
+		event["payload"] = map[string]interface{}{
+			"summary":   fmt.Sprintf("%s on %s: %s %s %.2f (value %.2f)", alert.Rule, alert.Source, alert.Metric, alert.Operator, alert.Threshold, alert.Value),
+			"source":    alert.Source,
+			"severity":  pagerDutySeverity(alert.Severity),
+			"timestamp": alert.StartedAt.Format(time.RFC3339),
+			"component": "syslog-analyzer",
+			"custom_details": map[string]interface{}{
+				"rule":      alert.Rule,
+				"metric":    alert.Metric,
+				"value":     alert.Value,
+				"threshold": alert.Threshold,
+			},
+		}
+		if alert.DashboardURL != "" {
+			event["links"] = []map[string]string{
+				{"href": alert.DashboardURL, "text": "Syslog Analyzer Dashboard"},
+			}
+		}
+	}
+	
+	return postJSON(p.client, p.url, nil, event)
+}
+
+// Acknowledge acknowledges the PagerDuty incident for the alert
+func (p *PagerDutyNotifier) Acknowledge(alert Alert) error {
+	return postJSON(p.client, p.url, nil, map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"dedup_key":    DedupKey(alert),
+		"event_action": "acknowledge",
+	})
+}
+
+// pagerDutySeverity maps rule severities onto PagerDuty's fixed set
+func pagerDutySeverity(severity string) string {
+	switch severity {
+	case "critical", "error", "warning", "info":
+		return severity
+	}
+	return "error"
+}
+
+// OpsgenieNotifier opens and closes alerts through the Opsgenie Alert API
+type OpsgenieNotifier struct {
+	name   string
+	apiKey string
+	url    string
+	client *http.Client
+}
+
+// newOpsgenieNotifier creates an Opsgenie connector
+func newOpsgenieNotifier(connector models.NotificationConnector) (*OpsgenieNotifier, error) {
+	if connector.APIKey == "" {
+		return nil, fmt.Errorf("Opsgenie API key is empty")
+	}
+	
+	apiURL := connector.APIURL
+	if apiURL == "" {
+		apiURL = opsgenieAlertsURL
+	}
+	
+	return &OpsgenieNotifier{
+		name:   connector.Name,
+		apiKey: connector.APIKey,
+		url:    strings.TrimRight(apiURL, "/"),
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Name returns the connector name
+func (o *OpsgenieNotifier) Name() string {
+	return "opsgenie:" + o.name
+}
+
+// Notify creates or closes the Opsgenie alert identified by the dedup key
+func (o *OpsgenieNotifier) Notify(alert Alert) error {
+	headers := map[string]string{"Authorization": "GenieKey " + o.apiKey}
+	alias := DedupKey(alert)
+	
+	if alert.State == StateResolved {
+		closeURL := fmt.Sprintf("%s/%s/close?identifierType=alias", o.url, url.PathEscape(alias))
+		return postJSON(o.client, closeURL, headers, map[string]string{
+			"source": "syslog-analyzer",
+			"note":   fmt.Sprintf("Resolved: %s is %.2f", alert.Metric, alert.Value),
+		})
+	}
+	
+	description := fmt.Sprintf("Metric %s = %.2f (threshold %s %.2f)", alert.Metric, alert.Value, alert.Operator, alert.Threshold)
+	if alert.DashboardURL != "" {
+		description += "\nDashboard: " + alert.DashboardURL
+	}
+	
+	return postJSON(o.client, o.url, headers, map[string]interface{}{
+		"message":     fmt.Sprintf("%s on %s", alert.Rule, alert.Source),
+		"alias":       alias,
+		"description": description,
+		"source":      "syslog-analyzer",
+		"entity":      alert.Source,
+		"priority":    opsgeniePriority(alert.Severity),
+		"tags":        []string{"syslog-analyzer", alert.Metric},
+		"details": map[string]string{
+			"rule":      alert.Rule,
+			"source":    alert.Source,
+			"metric":    alert.Metric,
+			"value":     fmt.Sprintf("%.2f", alert.Value),
+			"threshold": fmt.Sprintf("%.2f", alert.Threshold),
+		},
+	})
+}
+
+// Acknowledge acknowledges the Opsgenie alert identified by the dedup key
+func (o *OpsgenieNotifier) Acknowledge(alert Alert) error {
+	ackURL := fmt.Sprintf("%s/%s/acknowledge?identifierType=alias", o.url, url.PathEscape(DedupKey(alert)))
+	return postJSON(o.client, ackURL, map[string]string{"Authorization": "GenieKey " + o.apiKey}, map[string]string{
+		"user":   alert.AcknowledgedBy,
+		"source": "syslog-analyzer",
+	})
+}
+
+// opsgeniePriority maps rule severities onto Opsgenie priorities
+func opsgeniePriority(severity string) string {
+	switch severity {
+	case "critical":
+		return "P1"
+	case "error":
+		return "P2"
+	case "warning":
+		return "P3"
+	case "info":
+		return "P5"
+	}
+	return "P3"
+}
+
+// postJSON posts a JSON payload and treats any non-2xx response as an error
+func postJSON(client *http.Client, target string, headers map[string]string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %v", err)
+	}
+	
+	req, err := http.NewRequest("POST", target, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("API returned HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	
+	return nil
+}
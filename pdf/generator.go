@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/jung-kurt/gofpdf"
 
+	"syslog-analyzer/chargeback"
 	"syslog-analyzer/models"
 )
 
@@ -21,8 +23,9 @@ func NewGenerator() *Generator {
 	return &Generator{}
 }
 
-// GenerateReport generates a comprehensive PDF report
-func (g *Generator) GenerateReport(sources []models.SourceMetrics, global models.GlobalMetrics) ([]byte, error) {
+// GenerateReport generates a comprehensive PDF report. heatmaps may be nil
+// when the metrics history is not running.
+func (g *Generator) GenerateReport(sources []models.SourceMetrics, global models.GlobalMetrics, heatmaps []models.EPSHeatmap) ([]byte, error) {
 	// Initialize PDF
 	g.pdf = gofpdf.New("P", "mm", "A4", "")
 	g.pdf.SetMargins(20, 20, 20)
@@ -34,8 +37,11 @@ func (g *Generator) GenerateReport(sources []models.SourceMetrics, global models
 	// Generate report content
 	g.addHeader()
 	g.addGlobalSummary(global)
+	g.addQuotaSummary(global.Quotas)
+	g.addCostSummary(chargeback.Costs(sources, time.Now()))
 	g.addSourcesOverview(sources)
 	g.addDetailedSourceMetrics(sources)
+	g.addHeatmaps(heatmaps)
 	g.addFooter()
 	
 	// Check for errors
@@ -98,6 +104,7 @@ func (g *Generator) addGlobalSummary(global models.GlobalMetrics) {
 	}{
 		{"Total Real-time EPS", fmt.Sprintf("%.2f", global.TotalRealTimeEPS)},
 		{"Total Real-time GB/s", fmt.Sprintf("%.6f", global.TotalRealTimeGBps)},
+		{"Total Real-time Wire GB/s", fmt.Sprintf("%.6f", global.TotalRealTimeWireGBps)},
 		{"Total Logs Ingested", formatNumber(global.TotalLogsIngested)},
 		{"Hourly Average Logs", formatNumber(global.TotalHourlyAvgLogs)},
 		{"Daily Average Logs", formatNumber(global.TotalDailyAvgLogs)},
@@ -254,6 +261,9 @@ func (g *Generator) addSourceDetails(source models.SourceMetrics) {
 	
 	g.pdf.CellFormat(0, 6, fmt.Sprintf("Address: %s:%d (%s) | Simulation Mode: %s", 
 		source.SourceIP, source.Port, protocol, simulationMode), "", 1, "L", false, 0, "")
+	if len(source.Classification) > 0 {
+		g.pdf.CellFormat(0, 6, "Classification: "+strings.ToUpper(strings.Join(source.Classification, ", ")), "", 1, "L", false, 0, "")
+	}
 	g.pdf.Ln(3)
 	
 	// Metrics table
@@ -275,7 +285,10 @@ func (g *Generator) addSourceDetails(source models.SourceMetrics) {
 	}{
 		{"Real-time EPS", fmt.Sprintf("%.2f", source.RealTimeEPS)},
 		{"Real-time GB/s", fmt.Sprintf("%.6f", source.RealTimeGBps)},
+		{"Real-time Wire GB/s", fmt.Sprintf("%.6f", source.RealTimeWireGBps)},
 		{"Total Logs Ingested", formatNumber(source.TotalLogsIngested)},
+		{"Total Event Bytes", formatNumber(source.TotalEventBytes)},
+		{"Total Wire Bytes", formatNumber(source.TotalWireBytes)},
 		{"Hourly Avg Logs", formatNumber(source.HourlyAvgLogs)},
 		{"Hourly Avg GB", fmt.Sprintf("%.4f", source.HourlyAvgGB)},
 		{"Daily Avg Logs", formatNumber(source.DailyAvgLogs)},
@@ -283,6 +296,8 @@ func (g *Generator) addSourceDetails(source models.SourceMetrics) {
 		{"Queue Depth", formatNumber(source.QueueDepth)},
 		{"Processed Count", formatNumber(source.ProcessedCount)},
 		{"Sent Count", formatNumber(source.SentCount)},
+		{"Dropped (Queue)", formatNumber(source.DroppedCount)},
+		{"Dropped (Kernel)", formatKernelDrops(source)},
 	}
 	
 	for i, metric := range metrics {
@@ -314,6 +329,193 @@ func (g *Generator) addSourceDetails(source models.SourceMetrics) {
 	g.pdf.Ln(8)
 }
 
+// heatmapDays labels the rows of a heatmap, Monday first
+var heatmapDays = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// addHeatmaps adds each source's average EPS by day of week and hour of day,
+// shaded relative to the source's busiest hour
+func (g *Generator) addHeatmaps(heatmaps []models.EPSHeatmap) {
+	if len(heatmaps) == 0 {
+		return
+	}
+	
+	g.pdf.AddPage()
+	
+	// Section header
+	g.pdf.SetFont("Arial", "B", 16)
+	g.pdf.SetTextColor(52, 73, 94)
+	g.pdf.CellFormat(0, 10, "Office Hours EPS Heatmaps", "", 1, "L", false, 0, "")
+	g.pdf.SetFont("Arial", "", 9)
+	g.pdf.SetTextColor(127, 140, 141)
+	g.pdf.CellFormat(0, 6, fmt.Sprintf("Average EPS by hour of day (%s)", heatmaps[0].TimeZone), "", 1, "L", false, 0, "")
+	g.pdf.Ln(3)
+	
+	const labelWidth, cellWidth, cellHeight = 12.0, 6.5, 6.0
+	for _, heatmap := range heatmaps {
+		// A heatmap takes about 65mm
+		if g.pdf.GetY() > 200 {
+			g.pdf.AddPage()
+		}
+		
+		g.pdf.SetFont("Arial", "B", 11)
+		g.pdf.SetTextColor(0, 0, 0)
+		g.pdf.CellFormat(0, 7, heatmap.Source, "", 1, "L", false, 0, "")
+		g.pdf.SetFont("Arial", "", 8)
+		g.pdf.SetTextColor(127, 140, 141)
+		g.pdf.CellFormat(0, 5, fmt.Sprintf("%s to %s  |  Mean %.2f EPS  |  Peak hour %.2f EPS",
+			heatmap.From.In(time.Local).Format("2006-01-02"), heatmap.To.In(time.Local).Format("2006-01-02"),
+			heatmap.MeanEPS, heatmap.PeakEPS), "", 1, "L", false, 0, "")
+		
+		// Hour header
+		g.pdf.SetFont("Arial", "B", 6)
+		g.pdf.SetTextColor(0, 0, 0)
+		g.pdf.CellFormat(labelWidth, cellHeight, "", "", 0, "C", false, 0, "")
+		for hour := 0; hour < 24; hour++ {
+			g.pdf.CellFormat(cellWidth, cellHeight, fmt.Sprintf("%02d", hour), "", 0, "C", false, 0, "")
+		}
+		g.pdf.Ln(-1)
+		
+		for day, cells := range heatmap.Cells {
+			g.pdf.SetFont("Arial", "B", 7)
+			g.pdf.SetTextColor(0, 0, 0)
+			g.pdf.CellFormat(labelWidth, cellHeight, heatmapDays[day], "", 0, "L", false, 0, "")
+			g.pdf.SetFont("Arial", "", 5)
+			for _, eps := range cells {
+				// White to dark blue as the hour approaches the peak
+				intensity := 0.0
+				if heatmap.PeakEPS > 0 {
+					intensity = eps / heatmap.PeakEPS
+				}
+				g.pdf.SetFillColor(255-int(intensity*214), 255-int(intensity*157), 255-int(intensity*70))
+				if intensity > 0.6 {
+					g.pdf.SetTextColor(255, 255, 255)
+				} else {
+					g.pdf.SetTextColor(0, 0, 0)
+				}
+				g.pdf.CellFormat(cellWidth, cellHeight, formatHeatmapEPS(eps), "1", 0, "C", true, 0, "")
+			}
+			g.pdf.Ln(-1)
+		}
+		g.pdf.Ln(6)
+	}
+}
+
+// addQuotaSummary adds the consumption of tenant and group quotas
+func (g *Generator) addQuotaSummary(quotas []models.QuotaStatus) {
+	if len(quotas) == 0 {
+		return
+	}
+	
+	// Section header
+	g.pdf.SetFont("Arial", "B", 16)
+	g.pdf.SetTextColor(52, 73, 94)
+	g.pdf.CellFormat(0, 10, "Quota Consumption", "", 1, "L", false, 0, "")
+	g.pdf.Ln(5)
+	
+	// Table headers
+	g.pdf.SetFillColor(231, 243, 250)
+	g.pdf.SetFont("Arial", "B", 9)
+	g.pdf.SetTextColor(0, 0, 0)
+	headers := []string{"Quota", "Applies To", "Action", "EPS Usage", "Daily Usage", "Status"}
+	widths := []float64{35, 35, 20, 25, 25, 30}
+	for i, header := range headers {
+		g.pdf.CellFormat(widths[i], 8, header, "1", 0, "C", true, 0, "")
+	}
+	g.pdf.Ln(-1)
+	
+	// Table data
+	g.pdf.SetFont("Arial", "", 8)
+	for i, quota := range quotas {
+		fillColor := i%2 == 0
+		if fillColor {
+			g.pdf.SetFillColor(248, 249, 250)
+		} else {
+			g.pdf.SetFillColor(255, 255, 255)
+		}
+		
+		target := "Group: " + quota.Group
+		if quota.Tenant != "" {
+			target = "Tenant: " + quota.Tenant
+		}
+		status := "Within Limits"
+		if quota.Exceeded {
+			status = "Exceeded"
+		}
+		
+		g.pdf.CellFormat(widths[0], 6, truncateString(quota.Name, 20), "1", 0, "L", fillColor, 0, "")
+		g.pdf.CellFormat(widths[1], 6, truncateString(target, 20), "1", 0, "L", fillColor, 0, "")
+		g.pdf.CellFormat(widths[2], 6, quota.Action, "1", 0, "C", fillColor, 0, "")
+		g.pdf.CellFormat(widths[3], 6, formatUsage(quota.EPSUsage, quota.MaxEPS), "1", 0, "R", fillColor, 0, "")
+		g.pdf.CellFormat(widths[4], 6, formatUsage(quota.DailyUsage, quota.MaxGBPerDay), "1", 0, "R", fillColor, 0, "")
+		g.pdf.CellFormat(widths[5], 6, status, "1", 1, "C", fillColor, 0, "")
+	}
+	
+	g.pdf.Ln(10)
+}
+
+// addCostSummary adds the projected monthly cost of metered destinations and
+// the savings compared with delivering the raw ingest
+func (g *Generator) addCostSummary(report models.CostReport) {
+	if len(report.Destinations) == 0 {
+		return
+	}
+	if g.pdf.GetY() > 200 {
+		g.pdf.AddPage()
+	}
+	
+	// Section header
+	g.pdf.SetFont("Arial", "B", 16)
+	g.pdf.SetTextColor(52, 73, 94)
+	g.pdf.CellFormat(0, 10, "Destination Costs", "", 1, "L", false, 0, "")
+	g.pdf.SetFont("Arial", "", 9)
+	g.pdf.SetTextColor(127, 140, 141)
+	g.pdf.CellFormat(0, 6, "Projected over 30 days from the delivery rate since each destination started; raw is the unfiltered ingest", "", 1, "L", false, 0, "")
+	g.pdf.Ln(3)
+	
+	// Table headers
+	g.pdf.SetFillColor(231, 243, 250)
+	g.pdf.SetFont("Arial", "B", 9)
+	g.pdf.SetTextColor(0, 0, 0)
+	headers := []string{"Source", "Destination", "$/GB", "GB/Month", "Cost/Month", "Raw Cost", "Savings"}
+	widths := []float64{30, 30, 16, 22, 24, 24, 24}
+	for i, header := range headers {
+		g.pdf.CellFormat(widths[i], 8, header, "1", 0, "C", true, 0, "")
+	}
+	g.pdf.Ln(-1)
+	
+	// Table data
+	g.pdf.SetFont("Arial", "", 8)
+	for i, cost := range report.Destinations {
+		if g.pdf.GetY() > 265 {
+			g.pdf.AddPage()
+		}
+		fillColor := i%2 == 0
+		if fillColor {
+			g.pdf.SetFillColor(248, 249, 250)
+		} else {
+			g.pdf.SetFillColor(255, 255, 255)
+		}
+		
+		g.pdf.CellFormat(widths[0], 6, truncateString(cost.Source, 18), "1", 0, "L", fillColor, 0, "")
+		g.pdf.CellFormat(widths[1], 6, truncateString(cost.Destination, 18), "1", 0, "L", fillColor, 0, "")
+		g.pdf.CellFormat(widths[2], 6, fmt.Sprintf("%.3f", cost.CostPerGB), "1", 0, "R", fillColor, 0, "")
+		g.pdf.CellFormat(widths[3], 6, fmt.Sprintf("%.2f", cost.MonthlyGB), "1", 0, "R", fillColor, 0, "")
+		g.pdf.CellFormat(widths[4], 6, formatCost(cost.MonthlyCost), "1", 0, "R", fillColor, 0, "")
+		g.pdf.CellFormat(widths[5], 6, formatCost(cost.RawMonthlyCost), "1", 0, "R", fillColor, 0, "")
+		g.pdf.CellFormat(widths[6], 6, fmt.Sprintf("%s (%.0f%%)", formatCost(cost.MonthlySavings), cost.SavingsPercent), "1", 1, "R", fillColor, 0, "")
+	}
+	
+	// Totals
+	g.pdf.SetFont("Arial", "B", 8)
+	g.pdf.SetFillColor(231, 243, 250)
+	g.pdf.CellFormat(widths[0]+widths[1]+widths[2]+widths[3], 6, "Total", "1", 0, "L", true, 0, "")
+	g.pdf.CellFormat(widths[4], 6, formatCost(report.MonthlyCost), "1", 0, "R", true, 0, "")
+	g.pdf.CellFormat(widths[5], 6, formatCost(report.RawMonthlyCost), "1", 0, "R", true, 0, "")
+	g.pdf.CellFormat(widths[6], 6, fmt.Sprintf("%s (%.0f%%)", formatCost(report.MonthlySavings), report.SavingsPercent), "1", 1, "R", true, 0, "")
+	
+	g.pdf.Ln(10)
+}
+
 // addFooter adds the report footer
 func (g *Generator) addFooter() {
 	g.pdf.SetY(-15)
@@ -347,10 +549,49 @@ func formatNumber(n int64) string {
 	return result
 }
 
+// formatKernelDrops formats the kernel drop counter, or N/A when unavailable
+func formatKernelDrops(source models.SourceMetrics) string {
+	if !source.KernelDropsAvailable {
+		return "N/A"
+	}
+	return formatNumber(source.KernelDrops)
+}
+
+// formatUsage formats a quota consumption percent, or "-" when no limit is set
+func formatUsage(percent, limit float64) string {
+	if limit == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", percent)
+}
+
+// formatHeatmapEPS formats an EPS value to fit a heatmap cell
+func formatHeatmapEPS(eps float64) string {
+	switch {
+	case eps == 0:
+		return ""
+	case eps >= 10000:
+		return fmt.Sprintf("%.0fk", eps/1000)
+	case eps >= 1000:
+		return fmt.Sprintf("%.1fk", eps/1000)
+	case eps >= 10:
+		return fmt.Sprintf("%.0f", eps)
+	}
+	return fmt.Sprintf("%.1f", eps)
+}
+
+// formatCost formats an amount of money
+func formatCost(amount float64) string {
+	if amount < 0 {
+		return fmt.Sprintf("-$%.2f", -amount)
+	}
+	return fmt.Sprintf("$%.2f", amount)
+}
+
 // truncateString truncates a string to the specified length
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
 	}
 	return s[:maxLen-3] + "..."
-}
\ No newline at end of file
+}
\ No newline at end of file
@@ -0,0 +1,256 @@
+package quota
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+const bytesPerGB = 1024 * 1024 * 1024
+
+// Manager enforces EPS and daily volume quotas for tenants and source groups
+type Manager struct {
+	trackers []*tracker
+	mutex    sync.RWMutex
+}
+
+// tracker holds the usage counters of a single quota
+type tracker struct {
+	config models.QuotaConfig
+	mutex  sync.Mutex
+	
+	second      int64 // Unix second of the current EPS window
+	secondCount int64
+	lastEPS     float64 // Message count of the last complete second
+	
+	day        string // Local date the daily counter belongs to
+	bytesToday int64
+	
+	sampleCounter int64
+	exceeded      bool
+	sampled       int64
+	dropped       int64
+}
+
+// NewManager creates an empty quota manager
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Validate checks a quota definition
+func Validate(quota models.QuotaConfig) error {
+	if quota.Name == "" {
+		return fmt.Errorf("quota name is required")
+	}
+	if (quota.Tenant == "") == (quota.Group == "") {
+		return fmt.Errorf("quota '%s' must target exactly one tenant or group", quota.Name)
+	}
+	if quota.MaxEPS < 0 || quota.MaxGBPerDay < 0 {
+		return fmt.Errorf("quota '%s' limits cannot be negative", quota.Name)
+	}
+	if quota.MaxEPS == 0 && quota.MaxGBPerDay == 0 {
+		return fmt.Errorf("quota '%s' must set max_eps or max_gb_per_day", quota.Name)
+	}
+	switch quota.Action {
+	case models.QuotaActionAlert, models.QuotaActionSample, models.QuotaActionDrop:
+	default:
+		return fmt.Errorf("quota '%s' has unknown action: %s", quota.Name, quota.Action)
+	}
+	if quota.Action == models.QuotaActionSample && quota.SampleRate < 1 {
+		return fmt.Errorf("quota '%s' sample_rate must be at least 1", quota.Name)
+	}
+	return nil
+}
+
+// SetQuotas replaces the quota definitions, keeping the counters of
+// quotas whose name is unchanged
+func (m *Manager) SetQuotas(quotas []models.QuotaConfig) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	
+	existing := make(map[string]*tracker)
+	for _, t := range m.trackers {
+		existing[t.config.Name] = t
+	}
+	
+	trackers := make([]*tracker, 0, len(quotas))
+	for _, quota := range quotas {
+		if err := Validate(quota); err != nil {
+			log.Printf("⚠ Ignoring quota: %v", err)
+			continue
+		}
+		if t, ok := existing[quota.Name]; ok {
+			t.mutex.Lock()
+			t.config = quota
+			t.mutex.Unlock()
+			trackers = append(trackers, t)
+			continue
+		}
+		trackers = append(trackers, &tracker{config: quota})
+	}
+	m.trackers = trackers
+}
+
+// Admit accounts a message of the given size against every quota that
+// applies to the tenant and group, and reports whether it should be kept
+func (m *Manager) Admit(tenant, group string, size int) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
+	now := time.Now()
+	admit := true
+	for _, t := range m.trackers {
+		if t.applies(tenant, group) && !t.admit(now, size) {
+			admit = false
+		}
+	}
+	return admit
+}
+
+// Usage returns the highest consumption percent of any quota that applies
+// to the tenant and group
+func (m *Manager) Usage(tenant, group string) float64 {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
+	now := time.Now()
+	usage := 0.0
+	for _, t := range m.trackers {
+		if !t.applies(tenant, group) {
+			continue
+		}
+		status := t.status(now)
+		if status.EPSUsage > usage {
+			usage = status.EPSUsage
+		}
+		if status.DailyUsage > usage {
+			usage = status.DailyUsage
+		}
+	}
+	return usage
+}
+
+// GetStatus returns the consumption of all quotas
+func (m *Manager) GetStatus() []models.QuotaStatus {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
+	now := time.Now()
+	statuses := make([]models.QuotaStatus, 0, len(m.trackers))
+	for _, t := range m.trackers {
+		statuses = append(statuses, t.status(now))
+	}
+	return statuses
+}
+
+// applies reports whether the quota covers a source
+func (t *tracker) applies(tenant, group string) bool {
+	if t.config.Tenant != "" {
+		return t.config.Tenant == tenant
+	}
+	return t.config.Group != "" && t.config.Group == group
+}
+
+// admit records a message and applies the enforcement action
+func (t *tracker) admit(now time.Time, size int) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	
+	t.roll(now)
+	t.secondCount++
+	t.bytesToday += int64(size)
+	
+	over := (t.config.MaxEPS > 0 && float64(t.secondCount) > t.config.MaxEPS) ||
+		(t.config.MaxGBPerDay > 0 && float64(t.bytesToday) > t.config.MaxGBPerDay*bytesPerGB)
+	if over && !t.exceeded {
+		log.Printf("⚠ Quota '%s' exceeded (action: %s)", t.config.Name, t.config.Action)
+	}
+	if over {
+		t.exceeded = true
+	}
+	
+	if !over {
+		return true
+	}
+	
+	switch t.config.Action {
+	case models.QuotaActionSample:
+		t.sampleCounter++
+		if t.sampleCounter%int64(t.config.SampleRate) == 0 {
+			return true
+		}
+		t.sampled++
+		return false
+	case models.QuotaActionDrop:
+		t.dropped++
+		return false
+	default:
+		return true
+	}
+}
+
+// roll advances the EPS window and the daily counter; callers hold the mutex
+func (t *tracker) roll(now time.Time) {
+	second := now.Unix()
+	if second != t.second {
+		if second == t.second+1 {
+			t.lastEPS = float64(t.secondCount)
+		} else {
+			t.lastEPS = 0
+		}
+		t.second = second
+		t.secondCount = 0
+	}
+	
+	day := now.Format("2006-01-02")
+	if day != t.day {
+		t.day = day
+		t.bytesToday = 0
+	}
+	
+	// Clear the exceeded flag once both limits are back in range
+	if t.exceeded && !t.overEPS() && !t.overDaily() {
+		log.Printf("✓ Quota '%s' back within limits", t.config.Name)
+		t.exceeded = false
+	}
+}
+
+func (t *tracker) overEPS() bool {
+	return t.config.MaxEPS > 0 && (t.lastEPS > t.config.MaxEPS || float64(t.secondCount) > t.config.MaxEPS)
+}
+
+func (t *tracker) overDaily() bool {
+	return t.config.MaxGBPerDay > 0 && float64(t.bytesToday) > t.config.MaxGBPerDay*bytesPerGB
+}
+
+// status snapshots the quota consumption
+func (t *tracker) status(now time.Time) models.QuotaStatus {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	
+	t.roll(now)
+	
+	status := models.QuotaStatus{
+		Name:        t.config.Name,
+		Tenant:      t.config.Tenant,
+		Group:       t.config.Group,
+		Action:      t.config.Action,
+		MaxEPS:      t.config.MaxEPS,
+		MaxGBPerDay: t.config.MaxGBPerDay,
+		CurrentEPS:  t.lastEPS,
+		GBToday:     float64(t.bytesToday) / bytesPerGB,
+		Exceeded:    t.exceeded,
+		Sampled:     t.sampled,
+		Dropped:     t.dropped,
+	}
+	if t.config.MaxEPS > 0 {
+		status.EPSUsage = status.CurrentEPS / t.config.MaxEPS * 100
+	}
+	if t.config.MaxGBPerDay > 0 {
+		status.DailyUsage = status.GBToday / t.config.MaxGBPerDay * 100
+	}
+	return status
+}
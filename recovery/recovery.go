@@ -0,0 +1,79 @@
+// Package recovery keeps a panic in one pipeline goroutine from taking down
+// the process: it recovers the panic, logs it with its stack, counts it and,
+// for long-running loops, restarts the loop after a back-off.
+package recovery
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// Restart back-off of a supervised loop. The back-off starts over once a
+// loop ran for stableRun without panicking.
+const (
+	minRestartBackoff = 100 * time.Millisecond
+	maxRestartBackoff = 5 * time.Second
+	stableRun         = time.Minute
+)
+
+// total counts every panic recovered since startup
+var total int64
+
+// Total returns the number of panics recovered since startup
+func Total() int64 {
+	return atomic.LoadInt64(&total)
+}
+
+// record logs and counts a recovered panic; counter may be nil
+func record(component string, counter *int64, value interface{}) {
+	atomic.AddInt64(&total, 1)
+	if counter != nil {
+		atomic.AddInt64(counter, 1)
+	}
+	log.Printf("✗ Recovered panic in %s: %v\n%s", component, value, debug.Stack())
+}
+
+// Recover, when deferred, recovers a panic of the calling goroutine, which
+// then ends as if it had returned
+func Recover(component string, counter *int64) {
+	if value := recover(); value != nil {
+		record(component, counter, value)
+	}
+}
+
+// Guard calls fn and returns a panic in it as an error
+func Guard(component string, counter *int64, fn func() error) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			record(component, counter, value)
+			err = fmt.Errorf("panic in %s: %v", component, value)
+		}
+	}()
+	return fn()
+}
+
+// Supervise calls fn until it returns without panicking, restarting it after
+// a back-off each time it panics. fn is expected to check for a stop itself,
+// so a loop stopped while backing off returns right after its restart.
+func Supervise(component string, counter *int64, fn func()) {
+	backoff := minRestartBackoff
+	for {
+		started := time.Now()
+		if Guard(component, counter, func() error { fn(); return nil }) == nil {
+			return
+		}
+		if time.Since(started) >= stableRun {
+			backoff = minRestartBackoff
+		}
+		
+		log.Printf("↻ Restarting %s in %v", component, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+	}
+}
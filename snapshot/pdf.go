@@ -0,0 +1,145 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Layout of the PDF snapshot, in millimetres on a portrait A4 page
+const (
+	pdfMargin    = 20.0
+	pdfWidth     = 170.0 // Between the margins
+	pdfBarHeight = 6.0
+	pdfChartH    = 70.0
+)
+
+// renderPDF draws the same page as renderPNG with vector graphics
+func renderPDF(data Data) ([]byte, error) {
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	doc.SetAutoPageBreak(false, 0)
+	doc.AddPage()
+	
+	setFill := func(c [3]uint8) { doc.SetFillColor(int(c[0]), int(c[1]), int(c[2])) }
+	setText := func(c [3]uint8) { doc.SetTextColor(int(c[0]), int(c[1]), int(c[2])) }
+	setDraw := func(c [3]uint8) { doc.SetDrawColor(int(c[0]), int(c[1]), int(c[2])) }
+	text := func(x, y, w float64, s, align string) {
+		doc.SetXY(x, y)
+		doc.CellFormat(w, 5, s, "", 0, align, false, 0, "")
+	}
+	
+	// Header
+	setFill(headerRGB)
+	doc.Rect(0, 0, 210, 28, "F")
+	setText([3]uint8{255, 255, 255})
+	doc.SetFont("Arial", "B", 18)
+	text(pdfMargin, 8, pdfWidth, "Syslog Analyzer Snapshot", "L")
+	doc.SetFont("Arial", "", 10)
+	text(pdfMargin, 17, pdfWidth, data.GeneratedAt.Format("2006-01-02 15:04:05 MST"), "R")
+	
+	// Summary cards, four to a row
+	y := 36.0
+	cardWidth := (pdfWidth - 3*4) / 4
+	for i, item := range data.cards() {
+		x := pdfMargin + float64(i%4)*(cardWidth+4)
+		top := y + float64(i/4)*24
+		setFill(cardRGB)
+		doc.Rect(x, top, cardWidth, 20, "F")
+		setText(mutedRGB)
+		doc.SetFont("Arial", "", 8)
+		text(x+3, top+3, cardWidth-6, item.label, "L")
+		setText(headerRGB)
+		doc.SetFont("Arial", "B", 12)
+		text(x+3, top+11, cardWidth-6, item.value, "L")
+	}
+	y += 2*24 + 6
+	
+	// Busiest sources by EPS
+	sources := data.topSources()
+	setText(headerRGB)
+	doc.SetFont("Arial", "B", 12)
+	text(pdfMargin, y, pdfWidth, "Top sources by EPS", "L")
+	y += 9
+	maxEPS := 0.0
+	for _, source := range sources {
+		if source.RealTimeEPS > maxEPS {
+			maxEPS = source.RealTimeEPS
+		}
+	}
+	labelWidth, valueWidth := 50.0, 22.0
+	barSpace := pdfWidth - labelWidth - valueWidth
+	doc.SetFont("Arial", "", 9)
+	if len(sources) == 0 {
+		setText(mutedRGB)
+		text(pdfMargin, y, pdfWidth, "No sources configured", "L")
+	}
+	for i, source := range sources {
+		top := y + float64(i)*pdfBarHeight
+		setText(headerRGB)
+		text(pdfMargin, top, labelWidth, truncate(source.Name, 28), "L")
+		width := 0.0
+		if maxEPS > 0 {
+			width = source.RealTimeEPS / maxEPS * barSpace
+		}
+		setFill(barRGB)
+		doc.Rect(pdfMargin+labelWidth, top+0.8, width, pdfBarHeight-1.6, "F")
+		setText(mutedRGB)
+		text(pdfMargin+labelWidth+width+1, top, valueWidth, fmt.Sprintf("%.2f", source.RealTimeEPS), "L")
+	}
+	y += float64(len(sources))*pdfBarHeight + 8
+	
+	// Delivery history
+	setText(headerRGB)
+	doc.SetFont("Arial", "B", 12)
+	text(pdfMargin, y, pdfWidth, "Events per minute", "L")
+	doc.SetFont("Arial", "", 9)
+	legendX := pdfMargin + pdfWidth
+	for i := len(historySeries) - 1; i >= 0; i-- {
+		series := historySeries[i]
+		legendX -= doc.GetStringWidth(series.label) + 9
+		setFill(series.rgb)
+		doc.Rect(legendX, y+1.5, 3, 3, "F")
+		setText(mutedRGB)
+		text(legendX+4, y, doc.GetStringWidth(series.label)+1, series.label, "L")
+	}
+	y += 9
+	left, right, bottom := pdfMargin+18, pdfMargin+pdfWidth, y+pdfChartH
+	max := data.historyMax()
+	setDraw(axisRGB)
+	doc.SetLineWidth(0.2)
+	doc.Line(left, y, left, bottom)
+	doc.Line(left, bottom, right, bottom)
+	text(pdfMargin, y, 17, formatCount(max), "L")
+	text(pdfMargin, bottom-5, 17, "0", "L")
+	if count := len(data.History); count > 0 {
+		text(left, bottom+1, 20, data.History[0].Time.Format("15:04"), "L")
+		text(right-20, bottom+1, 20, data.History[count-1].Time.Format("15:04"), "R")
+	}
+	point := func(i int, value int64) (float64, float64) {
+		x := left
+		if len(data.History) > 1 {
+			x += float64(i) * (right - left) / float64(len(data.History)-1)
+		}
+		return x, bottom - float64(value)/float64(max)*pdfChartH
+	}
+	doc.SetLineWidth(0.5)
+	for _, series := range historySeries {
+		setDraw(series.rgb)
+		for i := 1; i < len(data.History); i++ {
+			x0, y0 := point(i-1, series.value(data.History[i-1].ReconciliationCounts))
+			x1, y1 := point(i, series.value(data.History[i].ReconciliationCounts))
+			doc.Line(x0, y0, x1, y1)
+		}
+	}
+	
+	if err := doc.Error(); err != nil {
+		return nil, fmt.Errorf("PDF generation error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := doc.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to output PDF: %v", err)
+	}
+	return buf.Bytes(), nil
+}
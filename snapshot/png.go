@@ -0,0 +1,199 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"unicode"
+)
+
+// Layout of the PNG snapshot, in pixels
+const (
+	pngWidth     = 1200
+	pngMargin    = 30
+	pngBarHeight = 28
+	pngChartH    = 260
+	textScale    = 2 // Font pixels per glyph pixel of body text
+)
+
+// canvas draws the snapshot onto an RGBA image
+type canvas struct {
+	img *image.RGBA
+}
+
+func rgb(c [3]uint8) color.RGBA {
+	return color.RGBA{c[0], c[1], c[2], 255}
+}
+
+// fill paints the rectangle from (x0, y0) up to (x1, y1)
+func (c *canvas) fill(x0, y0, x1, y1 int, col color.RGBA) {
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			c.img.SetRGBA(x, y, col)
+		}
+	}
+}
+
+// line draws a line two pixels thick
+func (c *canvas) line(x0, y0, x1, y1 int, col color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	err := dx + dy
+	for {
+		c.fill(x0, y0, x0+2, y0+2, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// text draws a string with its top-left corner at (x, y) and returns its width
+func (c *canvas) text(x, y int, s string, scale int, col color.RGBA) int {
+	start := x
+	for _, r := range s {
+		glyph, exists := glyphs[unicode.ToUpper(r)]
+		if !exists {
+			glyph = glyphs['?']
+		}
+		for row := 0; row < glyphHeight; row++ {
+			for column := 0; column < glyphWidth; column++ {
+				if glyph[row]&(1<<(glyphWidth-1-column)) != 0 {
+					c.fill(x+column*scale, y+row*scale, x+(column+1)*scale, y+(row+1)*scale, col)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+	return x - start
+}
+
+// textWidth returns the width text draws a string at
+func textWidth(s string, scale int) int {
+	return len([]rune(s)) * (glyphWidth + 1) * scale
+}
+
+// renderPNG draws the header, the summary cards, the busiest sources and
+// the recent delivery history
+func renderPNG(data Data) ([]byte, error) {
+	sources := data.topSources()
+	height := 70 + 2*100 + 20 + 40 + len(sources)*pngBarHeight + 20 + 40 + pngChartH + 60
+	c := &canvas{img: image.NewRGBA(image.Rect(0, 0, pngWidth, height))}
+	white := color.RGBA{255, 255, 255, 255}
+	dark := rgb(headerRGB)
+	muted := rgb(mutedRGB)
+	c.fill(0, 0, pngWidth, height, white)
+	
+	// Header
+	c.fill(0, 0, pngWidth, 70, dark)
+	c.text(pngMargin, 14, "Syslog Analyzer snapshot", 3, white)
+	stamp := data.GeneratedAt.Format("2006-01-02 15:04:05 MST")
+	c.text(pngWidth-pngMargin-textWidth(stamp, textScale), 46, stamp, textScale, white)
+	
+	// Summary cards, four to a row
+	y := 90
+	cardWidth := (pngWidth - 2*pngMargin - 3*20) / 4
+	for i, item := range data.cards() {
+		x := pngMargin + (i%4)*(cardWidth+20)
+		top := y + (i/4)*100
+		c.fill(x, top, x+cardWidth, top+80, rgb(cardRGB))
+		c.text(x+12, top+12, item.label, textScale, muted)
+		c.text(x+12, top+40, item.value, 3, dark)
+	}
+	y += 2*100 + 20
+	
+	// Busiest sources by EPS
+	c.text(pngMargin, y, "Top sources by EPS", textScale, dark)
+	y += 40
+	maxEPS := 0.0
+	for _, source := range sources {
+		if source.RealTimeEPS > maxEPS {
+			maxEPS = source.RealTimeEPS
+		}
+	}
+	labelWidth := 24*(glyphWidth+1)*textScale + 10
+	barSpace := pngWidth - 2*pngMargin - labelWidth - 150
+	if len(sources) == 0 {
+		c.text(pngMargin, y, "No sources configured", textScale, muted)
+	}
+	for i, source := range sources {
+		top := y + i*pngBarHeight
+		c.text(pngMargin, top+6, truncate(source.Name, 24), textScale, dark)
+		width := 0
+		if maxEPS > 0 {
+			width = int(source.RealTimeEPS / maxEPS * float64(barSpace))
+		}
+		c.fill(pngMargin+labelWidth, top+2, pngMargin+labelWidth+width, top+pngBarHeight-4, rgb(barRGB))
+		c.text(pngMargin+labelWidth+width+8, top+6, fmt.Sprintf("%.2f", source.RealTimeEPS), textScale, muted)
+	}
+	y += len(sources)*pngBarHeight + 20
+	
+	// Delivery history
+	c.text(pngMargin, y, "Events per minute", textScale, dark)
+	legendX := pngWidth - pngMargin
+	for i := len(historySeries) - 1; i >= 0; i-- {
+		series := historySeries[i]
+		legendX -= textWidth(series.label, textScale) + 30
+		c.fill(legendX, y+4, legendX+14, y+14, rgb(series.rgb))
+		c.text(legendX+20, y, series.label, textScale, muted)
+	}
+	y += 40
+	left, right, bottom := pngMargin+80, pngWidth-pngMargin, y+pngChartH
+	max := data.historyMax()
+	c.fill(left, y, left+1, bottom, rgb(axisRGB))
+	c.fill(left, bottom, right, bottom+1, rgb(axisRGB))
+	c.text(pngMargin, y, formatCount(max), textScale, muted)
+	c.text(pngMargin, bottom-14, "0", textScale, muted)
+	if count := len(data.History); count > 0 {
+		c.text(left, bottom+10, data.History[0].Time.Format("15:04"), textScale, muted)
+		end := data.History[count-1].Time.Format("15:04")
+		c.text(right-textWidth(end, textScale), bottom+10, end, textScale, muted)
+	}
+	point := func(i int, value int64) (int, int) {
+		x := left
+		if len(data.History) > 1 {
+			x += i * (right - left - 2) / (len(data.History) - 1)
+		}
+		return x, bottom - 2 - int(float64(value)/float64(max)*float64(pngChartH-4))
+	}
+	for _, series := range historySeries {
+		for i := 1; i < len(data.History); i++ {
+			x0, y0 := point(i-1, series.value(data.History[i-1].ReconciliationCounts))
+			x1, y1 := point(i, series.value(data.History[i].ReconciliationCounts))
+			c.line(x0, y0, x1, y1, rgb(series.rgb))
+		}
+	}
+	
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
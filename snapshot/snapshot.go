@@ -0,0 +1,133 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Formats a snapshot can be rendered in
+const (
+	FormatPNG = "png"
+	FormatPDF = "pdf"
+)
+
+// topSourceCount bounds the sources charted by EPS
+const topSourceCount = 10
+
+// Data is the dashboard state a snapshot renders
+type Data struct {
+	GeneratedAt time.Time
+	Sources     []models.SourceMetrics
+	Global      models.GlobalMetrics
+	History     []models.HistoryPoint // Delivery accounting of all sources per interval, oldest first
+}
+
+// Render renders a snapshot of the global summary and charts as a PNG image
+// or a one-page PDF, for pasting into incident reports
+func Render(data Data, format string) ([]byte, error) {
+	switch format {
+	case FormatPNG:
+		return renderPNG(data)
+	case FormatPDF:
+		return renderPDF(data)
+	}
+	return nil, fmt.Errorf("unknown snapshot format %q (expected %q or %q)", format, FormatPNG, FormatPDF)
+}
+
+// Filename returns the download name of a snapshot
+func Filename(generatedAt time.Time, format string) string {
+	return fmt.Sprintf("syslog_analyzer_snapshot_%s.%s", generatedAt.Format("20060102_150405"), format)
+}
+
+// card is a labelled value of the summary
+type card struct {
+	label string
+	value string
+}
+
+// cards returns the summary values, as the dashboard's header shows them
+func (d Data) cards() []card {
+	global := d.Global
+	return []card{
+		{"Real-time EPS", fmt.Sprintf("%.2f", global.TotalRealTimeEPS)},
+		{"Real-time GB/s", fmt.Sprintf("%.6f", global.TotalRealTimeGBps)},
+		{"Logs ingested", formatCount(global.TotalLogsIngested)},
+		{"Daily avg GB", fmt.Sprintf("%.3f", global.TotalDailyAvgGB)},
+		{"Active sources", fmt.Sprintf("%d / %d", global.ActiveSources, global.TotalSources)},
+		{"Queue depth", formatCount(global.TotalQueueDepth)},
+		{"Dropped", formatCount(global.TotalDroppedCount)},
+		{"Destination errors", formatCount(global.TotalDestinationErrors)},
+	}
+}
+
+// topSources returns the busiest sources by real-time EPS
+func (d Data) topSources() []models.SourceMetrics {
+	sources := append([]models.SourceMetrics(nil), d.Sources...)
+	sort.SliceStable(sources, func(i, j int) bool {
+		if sources[i].RealTimeEPS != sources[j].RealTimeEPS {
+			return sources[i].RealTimeEPS > sources[j].RealTimeEPS
+		}
+		return sources[i].Name < sources[j].Name
+	})
+	if len(sources) > topSourceCount {
+		sources = sources[:topSourceCount]
+	}
+	return sources
+}
+
+// historySeries are the lines of the delivery chart
+var historySeries = []struct {
+	label string
+	value func(models.ReconciliationCounts) int64
+	rgb   [3]uint8
+}{
+	{"Received", func(c models.ReconciliationCounts) int64 { return c.Received }, [3]uint8{52, 152, 219}},
+	{"Sent", func(c models.ReconciliationCounts) int64 { return c.Sent }, [3]uint8{39, 174, 96}},
+	{"Dropped", func(c models.ReconciliationCounts) int64 { return c.Dropped + c.Failed }, [3]uint8{231, 76, 60}},
+}
+
+// historyMax returns the largest value any delivery line reaches, at least 1
+func (d Data) historyMax() int64 {
+	max := int64(1)
+	for _, point := range d.History {
+		for _, series := range historySeries {
+			if value := series.value(point.ReconciliationCounts); value > max {
+				max = value
+			}
+		}
+	}
+	return max
+}
+
+// Theme colours shared by both renderers
+var (
+	headerRGB = [3]uint8{44, 62, 80}
+	cardRGB   = [3]uint8{236, 240, 241}
+	mutedRGB  = [3]uint8{127, 140, 141}
+	barRGB    = [3]uint8{52, 152, 219}
+	axisRGB   = [3]uint8{189, 195, 199}
+)
+
+// formatCount formats a count with thousands separators
+func formatCount(n int64) string {
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	str := fmt.Sprintf("%d", n)
+	for i := len(str) - 3; i > 0; i -= 3 {
+		str = str[:i] + "," + str[i:]
+	}
+	return str
+}
+
+// truncate shortens a label to at most max characters
+func truncate(label string, max int) string {
+	runes := []rune(label)
+	if len(runes) <= max {
+		return label
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,439 @@
+package snmp
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"syslog-analyzer/models"
+)
+
+// DefaultPort is the UDP port the agent listens on when none is configured
+const DefaultPort = 1161
+
+// SNMP versions as encoded on the wire
+const (
+	versionV1  = 0
+	versionV2c = 1
+)
+
+// Error statuses
+const (
+	errNoError    = 0
+	errTooBig     = 1
+	errNoSuchName = 2
+	errReadOnly   = 4
+	errGenErr     = 5
+)
+
+// Source status values exposed in the source table
+const (
+	statusActive   = 1
+	statusIdle     = 2
+	statusInactive = 3
+)
+
+// variable is a single MIB object instance and its encoded value
+type variable struct {
+	oid   OID
+	value []byte
+}
+
+// Agent is a minimal read-only SNMP v1/v2c agent exposing analyzer metrics
+// under a private MIB
+//
+// MIB layout relative to the base OID:
+//
+//	.1.1.0  total real-time EPS (Gauge32)
+//	.1.2.0  total queue depth (Gauge32)
+//	.1.3.0  total logs ingested (Counter64)
+//	.1.4.0  active sources (INTEGER)
+//	.1.5.0  total sources (INTEGER)
+//	.1.6.0  total dropped events (Counter64)
+//	.2.1.<column>.<index>  source table, indexed 1..N by source name
+//	    column 1 name (OCTET STRING), 2 EPS (Gauge32),
+//	    3 status (INTEGER: 1 active, 2 idle, 3 inactive),
+//	    4 queue depth (Gauge32), 5 logs ingested (Counter64),
+//	    6 dropped events (Counter64)
+type Agent struct {
+	config      models.SNMPConfig
+	baseOID     OID
+	metricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)
+	conn        *net.UDPConn
+	isRunning   bool
+	mutex       sync.Mutex
+}
+
+// NewAgent creates a new SNMP agent
+func NewAgent(config models.SNMPConfig, metricsFunc func() ([]models.SourceMetrics, models.GlobalMetrics)) (*Agent, error) {
+	if config.Port == 0 {
+		config.Port = DefaultPort
+	}
+	if config.Community == "" {
+		config.Community = "public"
+	}
+	if config.BaseOID == "" {
+		config.BaseOID = "1.3.6.1.4.1.99999.1"
+	}
+	
+	baseOID, err := ParseOID(config.BaseOID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base OID: %v", err)
+	}
+	
+	return &Agent{
+		config:      config,
+		baseOID:     baseOID,
+		metricsFunc: metricsFunc,
+	}, nil
+}
+
+// Start starts listening for SNMP requests
+func (a *Agent) Start() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	
+	if a.isRunning {
+		return fmt.Errorf("SNMP agent already running")
+	}
+	
+	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf(":%d", a.config.Port))
+	if err != nil {
+		return err
+	}
+	
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+	
+	a.conn = conn
+	a.isRunning = true
+	go a.serve()
+	
+	log.Printf("✓ SNMP agent started on UDP port %d (base OID %s)", a.config.Port, a.baseOID)
+	return nil
+}
+
+// Stop stops the SNMP agent
+func (a *Agent) Stop() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	
+	if !a.isRunning {
+		return
+	}
+	
+	a.isRunning = false
+	a.conn.Close()
+	log.Printf("✓ SNMP agent stopped")
+}
+
+// serve reads and answers requests until the socket is closed
+func (a *Agent) serve() {
+	buffer := make([]byte, 65536)
+	
+	for {
+		n, addr, err := a.conn.ReadFromUDP(buffer)
+		if err != nil {
+			if strings.Contains(err.Error(), "use of closed network connection") {
+				return
+			}
+			continue
+		}
+		
+		response, err := a.handlePacket(buffer[:n])
+		if err != nil {
+			log.Printf("⚠ Ignoring SNMP packet from %s: %v", addr, err)
+			continue
+		}
+		if response != nil {
+			a.conn.WriteToUDP(response, addr)
+		}
+	}
+}
+
+// handlePacket decodes a request and builds the response packet
+func (a *Agent) handlePacket(packet []byte) ([]byte, error) {
+	msg, _, err := readElement(packet)
+	if err != nil || msg.tag != tagSequence {
+		return nil, fmt.Errorf("malformed message")
+	}
+	
+	versionEl, rest, err := readElement(msg.value)
+	if err != nil || versionEl.tag != tagInteger {
+		return nil, fmt.Errorf("malformed version")
+	}
+	version := decodeInteger(versionEl.value)
+	if version != versionV1 && version != versionV2c {
+		return nil, fmt.Errorf("unsupported SNMP version %d", version)
+	}
+	
+	communityEl, rest, err := readElement(rest)
+	if err != nil || communityEl.tag != tagOctetString {
+		return nil, fmt.Errorf("malformed community")
+	}
+	if string(communityEl.value) != a.config.Community {
+		// Silently drop requests with a wrong community, like most agents
+		return nil, nil
+	}
+	
+	pdu, _, err := readElement(rest)
+	if err != nil {
+		return nil, fmt.Errorf("malformed PDU")
+	}
+	
+	requestIDEl, rest, err := readElement(pdu.value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed request ID")
+	}
+	field2, rest, err := readElement(rest)
+	if err != nil {
+		return nil, fmt.Errorf("malformed PDU header")
+	}
+	field3, rest, err := readElement(rest)
+	if err != nil {
+		return nil, fmt.Errorf("malformed PDU header")
+	}
+	varbindList, _, err := readElement(rest)
+	if err != nil || varbindList.tag != tagSequence {
+		return nil, fmt.Errorf("malformed varbind list")
+	}
+	
+	var oids []OID
+	for data := varbindList.value; len(data) > 0; {
+		var vb element
+		vb, data, err = readElement(data)
+		if err != nil {
+			return nil, fmt.Errorf("malformed varbind")
+		}
+		oidEl, _, err := readElement(vb.value)
+		if err != nil || oidEl.tag != tagOID {
+			return nil, fmt.Errorf("malformed varbind OID")
+		}
+		oid, err := decodeOID(oidEl.value)
+		if err != nil {
+			return nil, err
+		}
+		oids = append(oids, oid)
+	}
+	
+	mib := a.buildMIB()
+	if version == versionV1 {
+		// Counter64 cannot be represented in SNMPv1
+		mib = filterV1(mib)
+	}
+	var results []variable
+	errorStatus, errorIndex := errNoError, 0
+	
+	switch pdu.tag {
+	case pduGetRequest:
+		results, errorStatus, errorIndex = a.get(mib, oids, version)
+	case pduGetNextRequest:
+		results, errorStatus, errorIndex = a.getNext(mib, oids, version)
+	case pduGetBulkRequest:
+		if version == versionV1 {
+			return nil, fmt.Errorf("GetBulk is not valid in SNMPv1")
+		}
+		results = a.getBulk(mib, oids, int(decodeInteger(field2.value)), int(decodeInteger(field3.value)))
+	case pduSetRequest:
+		errorStatus, errorIndex = errReadOnly, 1
+		if version == versionV1 {
+			errorStatus = errNoSuchName
+		}
+		for _, oid := range oids {
+			results = append(results, variable{oid: oid, value: encodeTLV(tagNull, nil)})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PDU type 0x%X", pdu.tag)
+	}
+	
+	// Echo the request varbinds unchanged when reporting an error
+	if errorStatus != errNoError {
+		results = results[:0]
+		for _, oid := range oids {
+			results = append(results, variable{oid: oid, value: encodeTLV(tagNull, nil)})
+		}
+	}
+	
+	varbinds := make([][]byte, 0, len(results))
+	for _, v := range results {
+		varbinds = append(varbinds, encodeSequence(tagSequence, encodeOID(v.oid), v.value))
+	}
+	
+	response := encodeSequence(tagSequence,
+		encodeInteger(tagInteger, version),
+		encodeTLV(tagOctetString, communityEl.value),
+		encodeSequence(pduGetResponse,
+			encodeTLV(tagInteger, requestIDEl.value),
+			encodeInteger(tagInteger, int64(errorStatus)),
+			encodeInteger(tagInteger, int64(errorIndex)),
+			encodeSequence(tagSequence, varbinds...),
+		),
+	)
+	
+	if len(response) > 65507 {
+		return nil, fmt.Errorf("response too big (%d bytes)", len(response))
+	}
+	
+	return response, nil
+}
+
+// get answers a GetRequest
+func (a *Agent) get(mib []variable, oids []OID, version int64) ([]variable, int, int) {
+	results := make([]variable, 0, len(oids))
+	for i, oid := range oids {
+		idx := sort.Search(len(mib), func(j int) bool { return mib[j].oid.Compare(oid) >= 0 })
+		if idx < len(mib) && mib[idx].oid.Compare(oid) == 0 {
+			results = append(results, mib[idx])
+			continue
+		}
+		if version == versionV1 {
+			return nil, errNoSuchName, i + 1
+		}
+		results = append(results, variable{oid: oid, value: encodeTLV(tagNoSuchObject, nil)})
+	}
+	return results, errNoError, 0
+}
+
+// getNext answers a GetNextRequest
+func (a *Agent) getNext(mib []variable, oids []OID, version int64) ([]variable, int, int) {
+	results := make([]variable, 0, len(oids))
+	for i, oid := range oids {
+		if next, ok := nextVariable(mib, oid); ok {
+			results = append(results, next)
+			continue
+		}
+		if version == versionV1 {
+			return nil, errNoSuchName, i + 1
+		}
+		results = append(results, variable{oid: oid, value: encodeTLV(tagEndOfMibView, nil)})
+	}
+	return results, errNoError, 0
+}
+
+// getBulk answers a GetBulkRequest
+func (a *Agent) getBulk(mib []variable, oids []OID, nonRepeaters, maxRepetitions int) []variable {
+	if nonRepeaters < 0 {
+		nonRepeaters = 0
+	}
+	if nonRepeaters > len(oids) {
+		nonRepeaters = len(oids)
+	}
+	if maxRepetitions <= 0 {
+		maxRepetitions = 1
+	}
+	if maxRepetitions > 100 {
+		maxRepetitions = 100
+	}
+	
+	var results []variable
+	for _, oid := range oids[:nonRepeaters] {
+		if next, ok := nextVariable(mib, oid); ok {
+			results = append(results, next)
+		} else {
+			results = append(results, variable{oid: oid, value: encodeTLV(tagEndOfMibView, nil)})
+		}
+	}
+	
+	current := append([]OID{}, oids[nonRepeaters:]...)
+	for r := 0; r < maxRepetitions && len(current) > 0; r++ {
+		for i, oid := range current {
+			if next, ok := nextVariable(mib, oid); ok {
+				results = append(results, next)
+				current[i] = next.oid
+			} else {
+				results = append(results, variable{oid: oid, value: encodeTLV(tagEndOfMibView, nil)})
+			}
+		}
+	}
+	
+	return results
+}
+
+// nextVariable returns the first variable lexicographically after oid
+func nextVariable(mib []variable, oid OID) (variable, bool) {
+	idx := sort.Search(len(mib), func(j int) bool { return mib[j].oid.Compare(oid) > 0 })
+	if idx < len(mib) {
+		return mib[idx], true
+	}
+	return variable{}, false
+}
+
+// buildMIB snapshots current metrics into a sorted list of variables
+func (a *Agent) buildMIB() []variable {
+	var sources []models.SourceMetrics
+	var global models.GlobalMetrics
+	if a.metricsFunc != nil {
+		sources, global = a.metricsFunc()
+	}
+	
+	scalars := a.baseOID.Append(1)
+	mib := []variable{
+		{scalars.Append(1, 0), encodeUnsigned(tagGauge32, gauge(global.TotalRealTimeEPS))},
+		{scalars.Append(2, 0), encodeUnsigned(tagGauge32, gauge(float64(global.TotalQueueDepth)))},
+		{scalars.Append(3, 0), encodeUnsigned(tagCounter64, uint64(global.TotalLogsIngested))},
+		{scalars.Append(4, 0), encodeInteger(tagInteger, int64(global.ActiveSources))},
+		{scalars.Append(5, 0), encodeInteger(tagInteger, int64(global.TotalSources))},
+		{scalars.Append(6, 0), encodeUnsigned(tagCounter64, uint64(global.TotalDroppedCount))},
+	}
+	
+	sorted := make([]models.SourceMetrics, len(sources))
+	copy(sorted, sources)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	
+	entry := a.baseOID.Append(2, 1)
+	for i, source := range sorted {
+		index := uint32(i + 1)
+		
+		status := statusInactive
+		if source.IsActive && source.IsReceiving {
+			status = statusActive
+		} else if source.IsActive {
+			status = statusIdle
+		}
+		
+		mib = append(mib,
+			variable{entry.Append(1, index), encodeTLV(tagOctetString, []byte(source.Name))},
+			variable{entry.Append(2, index), encodeUnsigned(tagGauge32, gauge(source.RealTimeEPS))},
+			variable{entry.Append(3, index), encodeInteger(tagInteger, int64(status))},
+			variable{entry.Append(4, index), encodeUnsigned(tagGauge32, gauge(float64(source.QueueDepth)))},
+			variable{entry.Append(5, index), encodeUnsigned(tagCounter64, uint64(source.TotalLogsIngested))},
+			variable{entry.Append(6, index), encodeUnsigned(tagCounter64, uint64(source.DroppedCount))},
+		)
+	}
+	
+	sort.Slice(mib, func(i, j int) bool {
+		return mib[i].oid.Compare(mib[j].oid) < 0
+	})
+	
+	return mib
+}
+
+// filterV1 removes variables whose types are not valid in SNMPv1
+func filterV1(mib []variable) []variable {
+	filtered := make([]variable, 0, len(mib))
+	for _, v := range mib {
+		if v.value[0] != tagCounter64 {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
+
+// gauge converts a float to a Gauge32 value, clamping to the valid range
+func gauge(v float64) uint64 {
+	if v <= 0 || math.IsNaN(v) {
+		return 0
+	}
+	if v > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint64(math.Round(v))
+}
@@ -0,0 +1,234 @@
+package snmp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BER tags used by SNMP
+const (
+	tagInteger        = 0x02
+	tagOctetString    = 0x04
+	tagNull           = 0x05
+	tagOID            = 0x06
+	tagSequence       = 0x30
+	tagCounter32      = 0x41
+	tagGauge32        = 0x42
+	tagTimeTicks      = 0x43
+	tagCounter64      = 0x46
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMibView   = 0x82
+	
+	pduGetRequest     = 0xA0
+	pduGetNextRequest = 0xA1
+	pduGetResponse    = 0xA2
+	pduSetRequest     = 0xA3
+	pduGetBulkRequest = 0xA5
+)
+
+// OID is an SNMP object identifier
+type OID []uint32
+
+// ParseOID parses a dotted OID string such as "1.3.6.1.4.1"
+func ParseOID(s string) (OID, error) {
+	s = strings.Trim(s, ".")
+	if s == "" {
+		return nil, fmt.Errorf("empty OID")
+	}
+	
+	parts := strings.Split(s, ".")
+	oid := make(OID, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OID component %q", part)
+		}
+		oid = append(oid, uint32(n))
+	}
+	
+	return oid, nil
+}
+
+// String returns the dotted representation of the OID
+func (o OID) String() string {
+	parts := make([]string, len(o))
+	for i, n := range o {
+		parts[i] = strconv.FormatUint(uint64(n), 10)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Append returns a new OID with the given components appended
+func (o OID) Append(components ...uint32) OID {
+	result := make(OID, 0, len(o)+len(components))
+	result = append(result, o...)
+	return append(result, components...)
+}
+
+// Compare returns -1, 0 or 1 comparing two OIDs lexicographically
+func (o OID) Compare(other OID) int {
+	for i := 0; i < len(o) && i < len(other); i++ {
+		if o[i] < other[i] {
+			return -1
+		}
+		if o[i] > other[i] {
+			return 1
+		}
+	}
+	switch {
+	case len(o) < len(other):
+		return -1
+	case len(o) > len(other):
+		return 1
+	}
+	return 0
+}
+
+// element is a decoded BER TLV
+type element struct {
+	tag   byte
+	value []byte
+}
+
+// readElement decodes one TLV from data, returning the element and the remainder
+func readElement(data []byte) (element, []byte, error) {
+	if len(data) < 2 {
+		return element{}, nil, fmt.Errorf("truncated BER element")
+	}
+	
+	tag := data[0]
+	length := int(data[1])
+	offset := 2
+	
+	if length&0x80 != 0 {
+		numBytes := length & 0x7F
+		if numBytes == 0 || numBytes > 4 || len(data) < 2+numBytes {
+			return element{}, nil, fmt.Errorf("invalid BER length")
+		}
+		length = 0
+		for i := 0; i < numBytes; i++ {
+			length = length<<8 | int(data[2+i])
+		}
+		offset += numBytes
+	}
+	
+	if length < 0 || len(data) < offset+length {
+		return element{}, nil, fmt.Errorf("truncated BER value")
+	}
+	
+	return element{tag: tag, value: data[offset : offset+length]}, data[offset+length:], nil
+}
+
+// decodeInteger decodes a BER signed integer
+func decodeInteger(b []byte) int64 {
+	var n int64
+	for i, c := range b {
+		if i == 0 && c&0x80 != 0 {
+			n = -1
+		}
+		n = n<<8 | int64(c)
+	}
+	return n
+}
+
+// decodeOID decodes a BER object identifier
+func decodeOID(b []byte) (OID, error) {
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty OID value")
+	}
+	
+	oid := OID{uint32(b[0]) / 40, uint32(b[0]) % 40}
+	var n uint32
+	for _, c := range b[1:] {
+		n = n<<7 | uint32(c&0x7F)
+		if c&0x80 == 0 {
+			oid = append(oid, n)
+			n = 0
+		}
+	}
+	
+	return oid, nil
+}
+
+// encodeLength encodes a BER length
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	
+	var b []byte
+	for n > 0 {
+		b = append([]byte{byte(n)}, b...)
+		n >>= 8
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// encodeTLV encodes a tag, length and value
+func encodeTLV(tag byte, value []byte) []byte {
+	out := []byte{tag}
+	out = append(out, encodeLength(len(value))...)
+	return append(out, value...)
+}
+
+// encodeInteger encodes a signed integer with the given tag
+func encodeInteger(tag byte, n int64) []byte {
+	var b []byte
+	for {
+		b = append([]byte{byte(n)}, b...)
+		n >>= 8
+		if (n == 0 && b[0]&0x80 == 0) || (n == -1 && b[0]&0x80 != 0) {
+			break
+		}
+	}
+	return encodeTLV(tag, b)
+}
+
+// encodeUnsigned encodes an unsigned application type (Counter, Gauge, TimeTicks)
+func encodeUnsigned(tag byte, n uint64) []byte {
+	var b []byte
+	for {
+		b = append([]byte{byte(n)}, b...)
+		n >>= 8
+		if n == 0 {
+			break
+		}
+	}
+	// Prevent the value from being read as negative
+	if b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return encodeTLV(tag, b)
+}
+
+// encodeOID encodes an object identifier
+func encodeOID(oid OID) []byte {
+	if len(oid) < 2 {
+		return encodeTLV(tagOID, []byte{0})
+	}
+	
+	b := []byte{byte(oid[0]*40 + oid[1])}
+	for _, n := range oid[2:] {
+		var chunk []byte
+		chunk = append(chunk, byte(n&0x7F))
+		n >>= 7
+		for n > 0 {
+			chunk = append([]byte{byte(n&0x7F) | 0x80}, chunk...)
+			n >>= 7
+		}
+		b = append(b, chunk...)
+	}
+	
+	return encodeTLV(tagOID, b)
+}
+
+// encodeSequence wraps the concatenated items in a sequence with the given tag
+func encodeSequence(tag byte, items ...[]byte) []byte {
+	var body []byte
+	for _, item := range items {
+		body = append(body, item...)
+	}
+	return encodeTLV(tag, body)
+}
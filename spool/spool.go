@@ -0,0 +1,332 @@
+// Package spool holds back events that cannot be delivered yet, in memory up
+// to a number of events and then in a file up to a size, and hands them out
+// again in the order they came in.
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// unsafeFileChars are replaced when a name becomes a file name
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// SafeName replaces the characters of a source or destination name that
+// are unsafe in file names
+func SafeName(name string) string {
+	return unsafeFileChars.ReplaceAllString(name, "_")
+}
+
+// FileName turns a source or destination name into a spool file name
+func FileName(name string) string {
+	return SafeName(name) + ".jsonl"
+}
+
+// Stats reports what a spool holds
+type Stats struct {
+	InMemory  int64     // Events held in memory
+	OnDisk    int64     // Events held in the file
+	DiskBytes int64     // Size of the file
+	Refused   int64     // Events dropped because both limits were reached
+	Oldest    time.Time // Receive time of the oldest held event; zero when empty
+}
+
+// Spool holds events back. A file left by an earlier run is picked up, so
+// events spooled to disk survive a restart.
+type Spool struct {
+	path         string
+	memoryEvents int
+	maxDiskBytes int64
+	mutex        sync.Mutex
+	memory       []models.LogEvent
+	file         *os.File      // Spill file, appended to; nil while events fit in memory
+	writer       *bufio.Writer // Buffers appends to file
+	consumed     int64         // Bytes of file already handed out
+	onDisk       int64         // Events in file not yet handed out
+	diskSize     int64         // Bytes written to file
+	diskOldest   time.Time     // Time of the oldest event in file, or of the last handed out until the next is read
+	refused      int64
+	peeked       peek // What the last Peek returned, forgotten by Drop
+}
+
+// peek records what Peek returned, so Drop can forget exactly that
+type peek struct {
+	memory int       // Events from memory
+	lines  int64     // Lines of file, including any that failed to decode
+	end    int64     // Offset in file after those lines
+	last   time.Time // Time of the last event from file
+}
+
+// New creates a spool holding up to memoryEvents in memory and then up to
+// maxDiskBytes in the file at path; a zero maxDiskBytes keeps events in
+// memory only
+func New(path string, memoryEvents int, maxDiskBytes int64) (*Spool, error) {
+	s := &Spool{path: path, memoryEvents: memoryEvents, maxDiskBytes: maxDiskBytes}
+	if maxDiskBytes == 0 {
+		return s, nil
+	}
+	
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := s.openFile(os.O_APPEND); err != nil {
+		return s, err
+	}
+	s.onDisk = int64(bytes.Count(data, []byte{'\n'}))
+	s.diskSize = int64(len(data))
+	var first models.LogEvent
+	if line, _, _ := bytes.Cut(data, []byte{'\n'}); json.Unmarshal(line, &first) == nil {
+		s.diskOldest = first.Time
+	}
+	return s, nil
+}
+
+// Add holds events back, returning how many did not fit
+func (s *Spool) Add(events []models.LogEvent) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	// Once events spill to disk, later ones follow them there to keep the order
+	i := 0
+	if s.file == nil {
+		room := s.memoryEvents - len(s.memory)
+		if room > len(events) {
+			room = len(events)
+		}
+		if room > 0 {
+			s.memory = append(s.memory, events[:room]...)
+			i = room
+		}
+	}
+	if i == len(events) {
+		return 0, nil
+	}
+	
+	if s.maxDiskBytes == 0 {
+		return s.refuse(len(events) - i), nil
+	}
+	if s.file == nil {
+		if err := s.openFile(os.O_TRUNC); err != nil {
+			return s.refuse(len(events) - i), err
+		}
+	}
+	
+	for ; i < len(events); i++ {
+		event := events[i]
+		event.Event = event.Payload()
+		line, err := json.Marshal(event)
+		if err != nil {
+			return s.refuse(len(events) - i), err
+		}
+		if s.diskSize+int64(len(line))+1 > s.maxDiskBytes {
+			return s.refuse(len(events) - i), s.writer.Flush()
+		}
+		s.writer.Write(line)
+		s.writer.WriteByte('\n')
+		s.diskSize += int64(len(line)) + 1
+		if s.onDisk == 0 {
+			s.diskOldest = event.Time
+		}
+		s.onDisk++
+	}
+	return 0, s.writer.Flush()
+}
+
+// refuse counts events that did not fit
+func (s *Spool) refuse(count int) int {
+	s.refused += int64(count)
+	return count
+}
+
+// openFile opens the spill file for appending and reading from the start
+func (s *Spool) openFile(mode int) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_RDWR|mode, 0644)
+	if err != nil {
+		return err
+	}
+	
+	s.file = file
+	s.writer = bufio.NewWriter(file)
+	s.consumed = 0
+	s.onDisk = 0
+	s.diskSize = 0
+	return nil
+}
+
+// Next returns up to max of the oldest held events and forgets them
+func (s *Spool) Next(max int) ([]models.LogEvent, error) {
+	events, err := s.Peek(max)
+	s.Drop()
+	return events, err
+}
+
+// Peek returns up to max of the oldest held events without forgetting them.
+// Callers delivering them call Drop once delivered; until then the next Peek
+// returns the same events again.
+func (s *Spool) Peek(max int) ([]models.LogEvent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	s.peeked = peek{}
+	if len(s.memory) > 0 {
+		n := max
+		if n > len(s.memory) {
+			n = len(s.memory)
+		}
+		s.peeked.memory = n
+		return append([]models.LogEvent(nil), s.memory[:n]...), nil
+	}
+	if s.onDisk == 0 {
+		return nil, nil
+	}
+	
+	reader := bufio.NewReader(io.NewSectionReader(s.file, s.consumed, s.diskSize-s.consumed))
+	s.peeked.end = s.consumed
+	var events []models.LogEvent
+	for len(events) < max && s.peeked.lines < s.onDisk {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			// The rest of the file is unreadable; Drop forgets all of it
+			s.peeked.lines = s.onDisk
+			return events, err
+		}
+		s.peeked.end += int64(len(line))
+		s.peeked.lines++
+		
+		var event models.LogEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			continue
+		}
+		event.Size = int64(len(line) - 1)
+		s.peeked.last = event.Time
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Drop forgets the events the last Peek returned
+func (s *Spool) Drop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	peeked := s.peeked
+	s.peeked = peek{}
+	if peeked.memory > 0 {
+		s.memory = s.memory[peeked.memory:]
+		if len(s.memory) == 0 {
+			s.memory = nil
+		}
+		return
+	}
+	if peeked.lines == 0 || s.file == nil {
+		return
+	}
+	
+	s.consumed = peeked.end
+	s.onDisk -= peeked.lines
+	if !peeked.last.IsZero() {
+		s.diskOldest = peeked.last
+	}
+	if s.onDisk == 0 {
+		s.removeFile()
+	}
+}
+
+// removeFile deletes the spill file
+func (s *Spool) removeFile() {
+	s.file.Close()
+	os.Remove(s.path)
+	s.file, s.writer = nil, nil
+	s.consumed, s.onDisk, s.diskSize = 0, 0, 0
+	s.diskOldest = time.Time{}
+	s.peeked = peek{}
+}
+
+// Close keeps the events on disk for the next run, dropping those already
+// handed out from the file, and returns how many events held in memory were
+// lost
+func (s *Spool) Close() (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	lost := int64(len(s.memory))
+	s.memory = nil
+	s.peeked = peek{}
+	if s.file == nil {
+		return lost, nil
+	}
+	if s.onDisk == 0 {
+		s.removeFile()
+		return lost, nil
+	}
+	
+	err := s.writer.Flush()
+	if err == nil && s.consumed > 0 {
+		err = s.compact()
+	}
+	s.file.Close()
+	s.file, s.writer = nil, nil
+	return lost, err
+}
+
+// compact rewrites the file without the events already handed out
+func (s *Spool) compact() error {
+	temp := s.path + ".tmp"
+	out, err := os.Create(temp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, io.NewSectionReader(s.file, s.consumed, s.diskSize-s.consumed)); err != nil {
+		out.Close()
+		os.Remove(temp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	return os.Rename(temp, s.path)
+}
+
+// Stats returns what the spool holds. Events spill to disk only after those
+// in memory, so the oldest is the first in memory or else the next in file;
+// the latter is approximated by the last event read from the file.
+func (s *Spool) Stats() Stats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stats := Stats{
+		InMemory:  int64(len(s.memory)),
+		OnDisk:    s.onDisk,
+		DiskBytes: s.diskSize,
+		Refused:   s.refused,
+	}
+	if len(s.memory) > 0 {
+		stats.Oldest = s.memory[0].Time
+	} else if s.onDisk > 0 {
+		stats.Oldest = s.diskOldest
+	}
+	return stats
+}
+
+// Pending returns whether any events are held
+func (s *Spool) Pending() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.memory) > 0 || s.onDisk > 0
+}
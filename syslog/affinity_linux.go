@@ -0,0 +1,27 @@
+//go:build linux
+
+package syslog
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// maxCPUs is the highest CPU count an affinity mask can address
+const maxCPUs = 1024
+
+// pinThread binds the calling OS thread to the given CPUs
+func pinThread(cpus []int) error {
+	var mask [maxCPUs / 64]uint64
+	for _, cpu := range cpus {
+		mask[cpu/64] |= 1 << uint(cpu%64)
+	}
+	
+	// A pid of 0 means the calling thread
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0, uintptr(len(mask)*8), uintptr(unsafe.Pointer(&mask[0])))
+	if errno != 0 {
+		return fmt.Errorf("sched_setaffinity: %v", errno)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package syslog
+
+import (
+	"fmt"
+)
+
+// maxCPUs is the highest CPU count a CPU list may address
+const maxCPUs = 1024
+
+// pinThread is not supported on this platform
+func pinThread(cpus []int) error {
+	return fmt.Errorf("CPU pinning is only supported on Linux")
+}
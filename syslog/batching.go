@@ -0,0 +1,182 @@
+package syslog
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Batching modes selectable through GlobalSettings.Batching
+const (
+	BatchingStatic   = "static"   // Every event is queued as soon as it is parsed
+	BatchingAdaptive = "adaptive" // Events accumulate into batches sized by the queue depth
+)
+
+// Adaptive batching defaults and tuning
+const (
+	defaultMinBatchSize = 10
+	defaultMinFlushMs   = 10
+	defaultMaxFlushMs   = 500
+
+	batchFlushTick  = 5 * time.Millisecond   // How often pending batches are checked for their flush interval
+	batchAdaptEvery = 100 * time.Millisecond // How often the batch size follows the queue depth
+	batchGrowFill   = 0.5                    // Queue fill above which batches grow
+	batchShrinkFill = 0.1                    // Queue fill below which batches shrink
+)
+
+// batching is the resolved configuration of processors created from now on
+var batching atomic.Value
+
+func init() {
+	batching.Store(models.BatchingConfig{Mode: BatchingStatic})
+}
+
+// SetBatching selects the batching mode of sources started after the call.
+// In adaptive mode an unset maximum batch size defaults to batchSize.
+func SetBatching(config models.BatchingConfig, batchSize int) error {
+	resolved, err := ResolveBatching(config, batchSize)
+	if err != nil {
+		return err
+	}
+	batching.Store(resolved)
+	return nil
+}
+
+// GetBatching returns the batching configuration new sources use
+func GetBatching() models.BatchingConfig {
+	return batching.Load().(models.BatchingConfig)
+}
+
+// ResolveBatching validates a batching configuration and fills in defaults
+func ResolveBatching(config models.BatchingConfig, batchSize int) (models.BatchingConfig, error) {
+	if config.Mode == "" {
+		config.Mode = BatchingStatic
+	}
+	if config.Mode == BatchingStatic {
+		return models.BatchingConfig{Mode: BatchingStatic}, nil
+	}
+	if config.Mode != BatchingAdaptive {
+		return config, fmt.Errorf("unknown batching mode %q (expected %s or %s)", config.Mode, BatchingStatic, BatchingAdaptive)
+	}
+	
+	if config.MinBatchSize == 0 {
+		config.MinBatchSize = defaultMinBatchSize
+	}
+	if config.MaxBatchSize == 0 {
+		config.MaxBatchSize = batchSize
+	}
+	if config.MinFlushMs == 0 {
+		config.MinFlushMs = defaultMinFlushMs
+	}
+	if config.MaxFlushMs == 0 {
+		config.MaxFlushMs = defaultMaxFlushMs
+	}
+	if config.MinBatchSize < 1 || config.MaxBatchSize < config.MinBatchSize {
+		return config, fmt.Errorf("invalid batch sizes %d-%d", config.MinBatchSize, config.MaxBatchSize)
+	}
+	if config.MinFlushMs < 1 || config.MaxFlushMs < config.MinFlushMs {
+		return config, fmt.Errorf("invalid flush intervals %d-%d ms", config.MinFlushMs, config.MaxFlushMs)
+	}
+	return config, nil
+}
+
+// batcher accumulates parsed events into batches whose size and flush
+// interval grow while the queue backs up and shrink again when it empties
+type batcher struct {
+	config       models.BatchingConfig
+	mutex        sync.Mutex
+	pending      *models.LogBatch
+	pendingSince time.Time
+	size         int64 // Effective batch size
+	flushMs      int64 // Effective flush interval
+}
+
+// newBatcher creates a batcher starting at the smallest, lowest latency settings
+func newBatcher(config models.BatchingConfig) *batcher {
+	return &batcher{
+		config:  config,
+		size:    int64(config.MinBatchSize),
+		flushMs: int64(config.MinFlushMs),
+	}
+}
+
+// add appends an event to the pending batch and returns the batch once it
+// reached the effective size
+func (b *batcher) add(queue *LogQueue, event *models.LogEvent, sourceIP string) *models.LogBatch {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	
+	if b.pending == nil {
+		b.pending = queue.GetBatch()
+		b.pending.SourceIP = sourceIP
+		b.pending.Timestamp = time.Now()
+		b.pendingSince = b.pending.Timestamp
+	}
+	b.pending.Events = append(b.pending.Events, *event)
+	
+	if int64(len(b.pending.Events)) < atomic.LoadInt64(&b.size) {
+		return nil
+	}
+	return b.takeLocked()
+}
+
+// due returns the pending batch if it has waited for the flush interval
+func (b *batcher) due(now time.Time) *models.LogBatch {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	
+	if b.pending == nil || now.Sub(b.pendingSince) < time.Duration(atomic.LoadInt64(&b.flushMs))*time.Millisecond {
+		return nil
+	}
+	return b.takeLocked()
+}
+
+// take returns the pending batch, if any, regardless of its size and age
+func (b *batcher) take() *models.LogBatch {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.takeLocked()
+}
+
+func (b *batcher) takeLocked() *models.LogBatch {
+	batch := b.pending
+	b.pending = nil
+	return batch
+}
+
+// adapt doubles the batch size and flush interval while the queue is more
+// than half full and halves them while it is nearly empty
+func (b *batcher) adapt(fill float64) {
+	size := atomic.LoadInt64(&b.size)
+	flushMs := atomic.LoadInt64(&b.flushMs)
+	
+	switch {
+	case fill > batchGrowFill:
+		size, flushMs = size*2, flushMs*2
+	case fill < batchShrinkFill:
+		size, flushMs = size/2, flushMs/2
+	default:
+		return
+	}
+	
+	atomic.StoreInt64(&b.size, clamp(size, int64(b.config.MinBatchSize), int64(b.config.MaxBatchSize)))
+	atomic.StoreInt64(&b.flushMs, clamp(flushMs, int64(b.config.MinFlushMs), int64(b.config.MaxFlushMs)))
+}
+
+// effective returns the current batch size and flush interval
+func (b *batcher) effective() (int, int64) {
+	return int(atomic.LoadInt64(&b.size)), atomic.LoadInt64(&b.flushMs)
+}
+
+func clamp(value, low, high int64) int64 {
+	if value < low {
+		return low
+	}
+	if value > high {
+		return high
+	}
+	return value
+}
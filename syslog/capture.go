@@ -0,0 +1,221 @@
+package syslog
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/destinations"
+	"syslog-analyzer/models"
+	"syslog-analyzer/spool"
+)
+
+// Raw capture defaults
+const (
+	defaultCaptureFileMB = 100
+	defaultCaptureFiles  = 10
+	captureFlushDelay    = time.Second // Captured bytes reach the file at most this late
+)
+
+// ValidateRawCapture checks a source's raw capture. The path is held to the
+// storage roots like a storage destination's.
+func ValidateRawCapture(capture models.RawCapture) error {
+	if capture.MaxFileMB < 0 {
+		return fmt.Errorf("raw capture max_file_mb cannot be negative")
+	}
+	if capture.MaxFiles < 0 {
+		return fmt.Errorf("raw capture max_files cannot be negative")
+	}
+	if !capture.Enabled {
+		return nil
+	}
+	if err := destinations.ValidateStoragePath(capture.Path); err != nil {
+		return fmt.Errorf("raw capture: %v", err)
+	}
+	return nil
+}
+
+// rawCapture writes the messages of a source exactly as received, before
+// quotas, parsing and filters, for forensic use. Each record is a header line
+// "<RFC 3339 receive time> <sender IP> <length>" followed by that many bytes
+// of message and a newline, so messages with embedded newlines or binary
+// content are kept intact. A UDP record is a whole datagram, a TCP record one
+// framed message without its line terminator. Files are named
+// <source>_raw_<timestamp>.cap and rotate by size; the oldest beyond
+// max_files are deleted.
+type rawCapture struct {
+	dir      string
+	prefix   string // File name prefix; rotation only deletes files carrying it
+	maxBytes int64
+	maxFiles int
+	mutex    sync.Mutex
+	file     *os.File
+	writer   *bufio.Writer
+	size     int64
+	flushing bool // Whether a delayed flush is scheduled
+	header   []byte
+	messages int64
+	bytes    int64
+	errors   int64
+	current  string // Name of the file being written
+}
+
+// newRawCapture returns the raw capture of a source, or nil when disabled
+func newRawCapture(config models.SourceConfig) *rawCapture {
+	if config.RawCapture == nil || !config.RawCapture.Enabled {
+		return nil
+	}
+	if err := ValidateRawCapture(*config.RawCapture); err != nil {
+		log.Printf("⚠ Ignoring raw capture for source '%s': %v", config.Name, err)
+		return nil
+	}
+	maxFileMB := config.RawCapture.MaxFileMB
+	if maxFileMB == 0 {
+		maxFileMB = defaultCaptureFileMB
+	}
+	maxFiles := config.RawCapture.MaxFiles
+	if maxFiles == 0 {
+		maxFiles = defaultCaptureFiles
+	}
+	return &rawCapture{
+		dir:      filepath.FromSlash(config.RawCapture.Path),
+		prefix:   spool.SafeName(config.Name) + "_raw_",
+		maxBytes: int64(maxFileMB) << 20,
+		maxFiles: maxFiles,
+	}
+}
+
+// write captures one message. Errors are counted, and the first is logged,
+// rather than failing the message, which is still processed.
+func (c *rawCapture) write(data []byte, sourceIP string, received time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	if c.file == nil || c.size >= c.maxBytes {
+		if err := c.rotate(received); err != nil {
+			if atomic.AddInt64(&c.errors, 1) == 1 {
+				log.Printf("✗ Raw capture to %s failed: %v", c.dir, err)
+			}
+			return
+		}
+	}
+	
+	c.header = received.UTC().AppendFormat(c.header[:0], time.RFC3339Nano)
+	c.header = append(c.header, ' ')
+	c.header = append(c.header, sourceIP...)
+	c.header = append(c.header, ' ')
+	c.header = strconv.AppendInt(c.header, int64(len(data)), 10)
+	c.header = append(c.header, '\n')
+	c.writer.Write(c.header)
+	c.writer.Write(data)
+	if err := c.writer.WriteByte('\n'); err != nil {
+		atomic.AddInt64(&c.errors, 1)
+		return
+	}
+	c.size += int64(len(c.header) + len(data) + 1)
+	atomic.AddInt64(&c.messages, 1)
+	atomic.AddInt64(&c.bytes, int64(len(data)))
+	
+	if !c.flushing {
+		c.flushing = true
+		time.AfterFunc(captureFlushDelay, c.flush)
+	}
+}
+
+// rotate closes the current file, opens a new one and deletes the oldest
+// files beyond maxFiles
+func (c *rawCapture) rotate(now time.Time) error {
+	c.closeFile()
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	
+	name := c.prefix + now.Format("20060102_150405.000000000") + ".cap"
+	file, err := os.OpenFile(filepath.Join(c.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	c.file = file
+	c.writer = bufio.NewWriterSize(file, 64*1024)
+	c.size = 0
+	c.current = name
+	
+	c.prune()
+	return nil
+}
+
+// prune deletes the oldest capture files of the source beyond maxFiles. The
+// timestamp in the name sorts them by age.
+func (c *rawCapture) prune() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), c.prefix) && strings.HasSuffix(entry.Name(), ".cap") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > c.maxFiles {
+		if err := os.Remove(filepath.Join(c.dir, names[0])); err != nil {
+			log.Printf("⚠ Failed to delete raw capture file %s: %v", names[0], err)
+		}
+		names = names[1:]
+	}
+}
+
+// flush writes buffered records to the file
+func (c *rawCapture) flush() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.flushing = false
+	if c.writer != nil {
+		if err := c.writer.Flush(); err != nil {
+			atomic.AddInt64(&c.errors, 1)
+		}
+	}
+}
+
+// Close flushes and closes the current file. A later write opens a new one.
+func (c *rawCapture) Close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.closeFile()
+}
+
+func (c *rawCapture) closeFile() {
+	if c.file == nil {
+		return
+	}
+	if err := c.writer.Flush(); err != nil {
+		atomic.AddInt64(&c.errors, 1)
+	}
+	if err := c.file.Close(); err != nil {
+		atomic.AddInt64(&c.errors, 1)
+	}
+	c.file = nil
+	c.writer = nil
+}
+
+// snapshot reports what was captured so far
+func (c *rawCapture) snapshot() *models.RawCaptureMetrics {
+	c.mutex.Lock()
+	current := c.current
+	c.mutex.Unlock()
+	return &models.RawCaptureMetrics{
+		Messages: atomic.LoadInt64(&c.messages),
+		Bytes:    atomic.LoadInt64(&c.bytes),
+		Errors:   atomic.LoadInt64(&c.errors),
+		File:     current,
+	}
+}
@@ -0,0 +1,130 @@
+package syslog
+
+import (
+	"fmt"
+	"strings"
+
+	"syslog-analyzer/models"
+)
+
+// ReservedPort is a port the analyzer itself listens on, such as the web
+// interface, which sources cannot share
+type ReservedPort struct {
+	Transport string // "UDP" or "TCP"
+	Port      int
+	Owner     string // What holds the port, e.g. "the web interface"
+}
+
+// FindConflicts checks sources, in configuration order, for settings that
+// cannot be served together: duplicate names, invalid ports, ports held by
+// the analyzer itself, TCP and RELP sources sharing a TCP port, and two
+// sources claiming the same sender on the same transport and port. Each conflict names the later source, which is the
+// one that loses; earlier sources are unaffected.
+func FindConflicts(sources []models.SourceConfig, reserved []ReservedPort) []models.PortConflict {
+	var conflicts []models.PortConflict
+	names := make(map[string]bool)
+	claimed := make(map[string]string)      // transport/port/routing key -> source name
+	framing := make(map[int]string)         // TCP port -> first source using it
+	framingProtocol := make(map[int]string) // TCP port -> TCP or RELP
+	
+	for _, source := range sources {
+		conflict := models.PortConflict{Source: source.Name, Port: source.Port, Protocol: source.Protocol}
+		
+		if names[source.Name] {
+			conflict.ConflictsWith = source.Name
+			conflict.Reason = fmt.Sprintf("source name '%s' is used more than once", source.Name)
+			conflicts = append(conflicts, conflict)
+			continue
+		}
+		names[source.Name] = true
+		
+		if source.Port <= 0 || source.Port > 65535 {
+			conflict.Reason = fmt.Sprintf("port %d is outside 1-65535", source.Port)
+			conflicts = append(conflicts, conflict)
+			continue
+		}
+		
+		if reason := reservedConflict(source, reserved); reason != "" {
+			conflict.Reason = reason
+			conflicts = append(conflicts, conflict)
+			continue
+		}
+		
+		// A TCP socket reads either newline-framed syslog or RELP, not both
+		if owner, protocol := framingConflict(framing, framingProtocol, source); owner != "" {
+			conflict.ConflictsWith = owner
+			conflict.Reason = fmt.Sprintf("TCP port %d is used for %s by source '%s'", source.Port, protocol, owner)
+			conflicts = append(conflicts, conflict)
+			continue
+		}
+		
+		key := routingKey(source.IP, source.Hostname)
+		if owner, transport := claimedBy(claimed, source, key); owner != "" {
+			conflict.ConflictsWith = owner
+			conflict.Reason = fmt.Sprintf("sources '%s' and '%s' both claim sender %s on %s port %d", owner, source.Name, describeSender(source), transport, source.Port)
+			conflicts = append(conflicts, conflict)
+			continue
+		}
+		for _, transport := range Transports(source.Protocol) {
+			claimed[claimKey(transport, source.Port, key)] = source.Name
+			if SocketTransport(transport) == "TCP" && framing[source.Port] == "" {
+				framing[source.Port] = source.Name
+				framingProtocol[source.Port] = transport
+			}
+		}
+	}
+	return conflicts
+}
+
+// reservedConflict describes the reserved port a source would collide with
+func reservedConflict(source models.SourceConfig, reserved []ReservedPort) string {
+	for _, transport := range Transports(source.Protocol) {
+		for _, port := range reserved {
+			if port.Port == source.Port && strings.EqualFold(port.Transport, SocketTransport(transport)) {
+				return fmt.Sprintf("%s port %d is used by %s", SocketTransport(transport), source.Port, port.Owner)
+			}
+		}
+	}
+	return ""
+}
+
+// framingConflict returns the source whose TCP or RELP listener holds the
+// TCP port a source needs for the other framing, and that framing
+func framingConflict(framing, framingProtocol map[int]string, source models.SourceConfig) (string, string) {
+	for _, transport := range Transports(source.Protocol) {
+		if SocketTransport(transport) != "TCP" || framing[source.Port] == "" {
+			continue
+		}
+		if protocol := framingProtocol[source.Port]; protocol != transport {
+			return framing[source.Port], protocol
+		}
+	}
+	return "", ""
+}
+
+// claimedBy returns the source already receiving a sender on one of the
+// source's transports, and that transport
+func claimedBy(claimed map[string]string, source models.SourceConfig, key string) (string, string) {
+	for _, transport := range Transports(source.Protocol) {
+		if owner, exists := claimed[claimKey(transport, source.Port, key)]; exists {
+			return owner, transport
+		}
+	}
+	return "", ""
+}
+
+func claimKey(transport string, port int, key string) string {
+	return fmt.Sprintf("%s/%d/%s", transport, port, key)
+}
+
+// describeSender names the sender a source claims for conflict messages
+func describeSender(source models.SourceConfig) string {
+	ip := source.IP
+	if ip == "" || ip == "0.0.0.0" {
+		ip = "0.0.0.0 (any sender)"
+	}
+	if source.Hostname != "" {
+		return fmt.Sprintf("%s with hostname %s", ip, source.Hostname)
+	}
+	return ip
+}
@@ -0,0 +1,126 @@
+package syslog
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// tcpConnection tracks activity on one accepted TCP connection
+type tcpConnection struct {
+	remoteAddr   string
+	sourceIP     string
+	connectedAt  time.Time
+	lastActivity int64 // Unix nanoseconds, updated atomically
+	messages     int64
+	bytes        int64
+}
+
+// touch records a received message
+func (c *tcpConnection) touch(wireSize int) {
+	atomic.StoreInt64(&c.lastActivity, time.Now().UnixNano())
+	atomic.AddInt64(&c.messages, 1)
+	atomic.AddInt64(&c.bytes, int64(wireSize))
+}
+
+// idle returns how long the connection has been silent
+func (c *tcpConnection) idle() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&c.lastActivity)))
+}
+
+// connectionTracker holds the open TCP connections of a listener
+type connectionTracker struct {
+	connections map[*tcpConnection]bool
+	mutex       sync.Mutex
+}
+
+func newConnectionTracker() *connectionTracker {
+	return &connectionTracker{
+		connections: make(map[*tcpConnection]bool),
+	}
+}
+
+// open registers a new connection
+func (ct *connectionTracker) open(conn net.Conn, sourceIP string) *tcpConnection {
+	now := time.Now()
+	c := &tcpConnection{
+		remoteAddr:   conn.RemoteAddr().String(),
+		sourceIP:     sourceIP,
+		connectedAt:  now,
+		lastActivity: now.UnixNano(),
+	}
+	
+	ct.mutex.Lock()
+	ct.connections[c] = true
+	ct.mutex.Unlock()
+	return c
+}
+
+// close unregisters a connection
+func (ct *connectionTracker) close(c *tcpConnection) {
+	ct.mutex.Lock()
+	delete(ct.connections, c)
+	ct.mutex.Unlock()
+}
+
+// count returns the number of open connections from an IP; the wildcard
+// address counts every connection
+func (ct *connectionTracker) count(sourceIP string) int {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+	
+	if sourceIP == "" || sourceIP == "0.0.0.0" {
+		return len(ct.connections)
+	}
+	count := 0
+	for c := range ct.connections {
+		if c.sourceIP == sourceIP {
+			count++
+		}
+	}
+	return count
+}
+
+// snapshot returns the open connections, longest idle first
+func (ct *connectionTracker) snapshot() []models.TCPConnectionStatus {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+	
+	statuses := make([]models.TCPConnectionStatus, 0, len(ct.connections))
+	for c := range ct.connections {
+		idle := c.idle()
+		statuses = append(statuses, models.TCPConnectionStatus{
+			RemoteAddr:   c.remoteAddr,
+			SourceIP:     c.sourceIP,
+			ConnectedAt:  c.connectedAt,
+			LastActivity: time.Now().Add(-idle),
+			IdleSeconds:  idle.Seconds(),
+			Messages:     atomic.LoadInt64(&c.messages),
+			Bytes:        atomic.LoadInt64(&c.bytes),
+		})
+	}
+	
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].IdleSeconds > statuses[j].IdleSeconds
+	})
+	return statuses
+}
+
+// configureKeepAlive applies the listener's keep-alive setting to a connection.
+// A zero period keeps the system default; a negative period disables keep-alives.
+func configureKeepAlive(conn net.Conn, periodSeconds int) {
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok || periodSeconds == 0 {
+		return
+	}
+	if periodSeconds < 0 {
+		tcpConn.SetKeepAlive(false)
+		return
+	}
+	tcpConn.SetKeepAlive(true)
+	tcpConn.SetKeepAlivePeriod(time.Duration(periodSeconds) * time.Second)
+}
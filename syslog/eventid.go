@@ -0,0 +1,47 @@
+package syslog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// eventIDs stamps events with IDs that are unique across sources and
+// restarts: a random prefix per processor followed by a sequence number
+type eventIDs struct {
+	prefix string
+	next   uint64
+}
+
+// newEventIDs returns a generator with a fresh random prefix
+func newEventIDs() *eventIDs {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return &eventIDs{prefix: strconv.FormatInt(time.Now().UnixNano(), 36) + "-"}
+	}
+	return &eventIDs{prefix: hex.EncodeToString(random) + "-"}
+}
+
+// stamp gives the events without an ID the next IDs
+func (g *eventIDs) stamp(events []models.LogEvent) {
+	for i := range events {
+		if events[i].ID == "" {
+			events[i].ID = g.prefix + strconv.FormatUint(atomic.AddUint64(&g.next, 1), 36)
+		}
+	}
+}
+
+// needsEventIDs reports whether any destination of a source suppresses
+// duplicates by event ID
+func needsEventIDs(config models.SourceConfig) bool {
+	for _, dest := range config.Destinations {
+		if dest.Enabled && dest.Dedup != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package syslog
+
+import (
+	"bytes"
+	"strings"
+)
+
+// ParseHostname extracts the HOSTNAME field of an RFC 5424 or RFC 3164
+// message. It returns "" when the message carries no usable hostname.
+func ParseHostname(data []byte) string {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '<' {
+		return ""
+	}
+	end := bytes.IndexByte(data, '>')
+	if end < 2 || end > 4 {
+		return ""
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	
+	var hostname string
+	switch {
+	case len(fields) >= 3 && isDigits(fields[0]):
+		// RFC 5424: VERSION TIMESTAMP HOSTNAME ...
+		hostname = fields[2]
+	case len(fields) >= 2 && strings.Contains(fields[0], "T") && strings.Contains(fields[0], "-"):
+		// RFC 3164 with an ISO 8601 timestamp: TIMESTAMP HOSTNAME ...
+		hostname = fields[1]
+	case len(fields) >= 4 && len(fields[0]) == 3 && strings.Contains(fields[2], ":"):
+		// RFC 3164: Mmm dd hh:mm:ss HOSTNAME ...
+		hostname = fields[3]
+	}
+	
+	// Tags directly after the timestamp mean the hostname was omitted
+	if hostname == "-" || strings.HasSuffix(hostname, ":") || strings.Contains(hostname, "[") {
+		return ""
+	}
+	return strings.ToLower(hostname)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// routingKey returns the key a source is registered under on a listener: its
+// IP, qualified by hostname for sources attributed by the HOSTNAME field
+func routingKey(ip, hostname string) string {
+	if ip == "" {
+		ip = "0.0.0.0"
+	}
+	if hostname == "" {
+		return ip
+	}
+	return ip + "/" + strings.ToLower(hostname)
+}
@@ -0,0 +1,56 @@
+package syslog
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ParseIPList parses addresses and CIDR ranges; bare addresses match exactly
+func ParseIPList(entries []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+	
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %v", entry, err)
+			}
+			networks = append(networks, network)
+			continue
+		}
+	
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", entry)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 32
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return networks, nil
+}
+
+// ipListContains reports whether any network contains the address
+func ipListContains(networks []*net.IPNet, sourceIP string) bool {
+	if len(networks) == 0 {
+		return false
+	}
+	ip := net.ParseIP(sourceIP)
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
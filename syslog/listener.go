@@ -3,45 +3,117 @@ package syslog
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/lifecycle"
+	"syslog-analyzer/models"
+	"syslog-analyzer/mtls"
+	"syslog-analyzer/recovery"
 )
 
 // SharedListener manages a single listener for multiple sources
 type SharedListener struct {
-	tcpListener net.Listener
-	udpConn     *net.UDPConn
+	tcpListener net.Listener // Guarded by socketMutex
+	udpConn     *net.UDPConn // Guarded by socketMutex
+	udpInode    atomic.Value // Inode of the current UDP socket as a string; "" when unknown
+	socketMutex sync.Mutex
+	readers     sync.WaitGroup // Goroutines reading the current socket
+	handlers    sync.WaitGroup // The supervisor and TCP connection goroutines
+	failed      chan error     // Readers report a dead socket to the supervisor
 	protocol    string
 	port        int
+	owner       string // Goroutines are accounted to this instance
 	sources     map[string]*SyslogSource // map[sourceIP] -> source
 	sourceMutex sync.RWMutex
-	stopChan    chan bool
-	isRunning   bool
+	ctx         context.Context // Cancelled when the listener stops
+	cancel      context.CancelFunc
+	unclaimed   *UnclaimedTracker
+	settings    models.ListenerConfig // Guarded by sourceMutex
+	rejected    int64                 // Messages dropped by strict mode
+	denied      int64                 // Messages dropped by the allow/deny lists
+	panics      int64                 // Panics recovered in readers and connections
+	allowList   []*net.IPNet          // Guarded by sourceMutex
+	denyList    []*net.IPNet          // Guarded by sourceMutex
+	tls         *mtls.Credentials     // Guarded by sourceMutex; nil for plain TCP
+	rejectLog   *rejectLogger
+	connections *connectionTracker
+	workers     []*udpWorker // UDP readers; fixed once started
+	
+	// Cached kernel-level drop counter for UDP sockets
+	kernelDrops     int64
+	kernelDropsOK   bool
+	kernelDropsRead time.Time
+	kernelMutex     sync.Mutex
+	
+	// Socket health, maintained by the supervisor
+	healthMutex sync.Mutex
+	down        bool
+	downSince   time.Time
+	lastError   string
+	restarts    int64
 }
 
+// errListenerStopped is returned by bind when the listener stopped meanwhile
+var errListenerStopped = errors.New("listener stopped")
+
 // NewSharedListener creates a new shared listener
 func NewSharedListener(protocol string, port int) *SharedListener {
 	return &SharedListener{
-		protocol:  protocol,
-		port:      port,
-		sources:   make(map[string]*SyslogSource),
-		stopChan:  make(chan bool),
+		protocol:    protocol,
+		port:        port,
+		owner:       lifecycle.NewOwner("listener", fmt.Sprintf("%s/%d", protocol, port)),
+		sources:     make(map[string]*SyslogSource),
+		failed:      make(chan error, 1),
+		unclaimed:   NewUnclaimedTracker(),
+		settings:    models.ListenerConfig{Protocol: protocol, Port: port},
+		rejectLog:   newRejectLogger(),
+		connections: newConnectionTracker(),
 	}
 }
 
-// Start starts the shared listener
-func (sl *SharedListener) Start() error {
+// Start starts the shared listener and the supervisor that re-binds its
+// socket if it fails. The listener stops when ctx is cancelled or on Stop.
+func (sl *SharedListener) Start(ctx context.Context) error {
+	sl.ctx, sl.cancel = context.WithCancel(lifecycle.WithOwner(ctx, sl.owner))
+	if err := sl.bind(); err != nil {
+		sl.cancel()
+		return err
+	}
+	
+	sl.handlers.Add(1)
+	lifecycle.Go(sl.ctx, sl.supervise)
+	return nil
+}
+
+// bind opens the listener's socket and starts its readers
+func (sl *SharedListener) bind() error {
 	address := fmt.Sprintf(":%d", sl.port)
 	
-	if sl.protocol == "TCP" {
+	sl.socketMutex.Lock()
+	defer sl.socketMutex.Unlock()
+	
+	// Stop closes the sockets under socketMutex; don't open one after it
+	if sl.ctx.Err() != nil {
+		return errListenerStopped
+	}
+	
+	if sl.protocol != "UDP" {
 		listener, err := net.Listen("tcp", address)
 		if err != nil {
 			return err
 		}
 		sl.tcpListener = listener
-		go sl.handleTCPConnections()
+		sl.readers.Add(1)
+		lifecycle.Go(sl.ctx, func() { sl.handleTCPConnections(listener) })
 	} else {
 		udpAddr, err := net.ResolveUDPAddr("udp", address)
 		if err != nil {
@@ -53,21 +125,31 @@ func (sl *SharedListener) Start() error {
 			return err
 		}
 		sl.udpConn = udpConn
-		go sl.handleUDPConnections()
+		
+		// Kernel drops are looked up by inode, so reading them never touches
+		// a socket the supervisor may be closing
+		inode, _ := socketInode(udpConn)
+		sl.udpInode.Store(inode)
+		
+		// Workers and their counters survive re-binds
+		if sl.workers == nil {
+			sl.sourceMutex.RLock()
+			sl.workers = newUDPWorkers(sl.settings)
+			sl.sourceMutex.RUnlock()
+		}
+		for _, worker := range sl.workers {
+			sl.readers.Add(1)
+			worker := worker
+			lifecycle.Go(sl.ctx, func() { sl.handleUDPConnections(udpConn, worker) })
+		}
 	}
-	
-	sl.isRunning = true
 	return nil
 }
 
-// Stop stops the shared listener
-func (sl *SharedListener) Stop() {
-	if !sl.isRunning {
-		return
-	}
-	
-	sl.isRunning = false
-	close(sl.stopChan)
+// closeSocket closes the current socket, making its readers exit
+func (sl *SharedListener) closeSocket() {
+	sl.socketMutex.Lock()
+	defer sl.socketMutex.Unlock()
 	
 	if sl.tcpListener != nil {
 		sl.tcpListener.Close()
@@ -77,17 +159,32 @@ func (sl *SharedListener) Stop() {
 	}
 }
 
+// Stop stops the shared listener and returns once its socket is closed and
+// every goroutine it started has exited, open TCP connections included.
+// Stopping again is a no-op.
+func (sl *SharedListener) Stop() {
+	if sl.cancel == nil {
+		return
+	}
+	
+	sl.cancel()
+	sl.closeSocket()
+	sl.readers.Wait()
+	sl.handlers.Wait()
+}
+
 // AddSource adds a source to this shared listener
 func (sl *SharedListener) AddSource(source *SyslogSource) {
 	sl.sourceMutex.Lock()
 	defer sl.sourceMutex.Unlock()
 	
-	sourceKey := source.config.IP
-	if sourceKey == "" {
-		sourceKey = "0.0.0.0"
-	}
-	
+	sourceKey := routingKey(source.config.IP, source.config.Hostname)
 	sl.sources[sourceKey] = source
+	
+	// Other devices may still share a NAT address with a hostname-attributed source
+	if source.config.Hostname == "" {
+		sl.unclaimed.Claim(sourceKey)
+	}
 }
 
 // RemoveSource removes a source from this shared listener
@@ -95,12 +192,20 @@ func (sl *SharedListener) RemoveSource(source *SyslogSource) {
 	sl.sourceMutex.Lock()
 	defer sl.sourceMutex.Unlock()
 	
-	sourceKey := source.config.IP
-	if sourceKey == "" {
-		sourceKey = "0.0.0.0"
-	}
+	delete(sl.sources, routingKey(source.config.IP, source.config.Hostname))
+}
+
+// GetSourceNames returns the names of the sources using this listener, sorted
+func (sl *SharedListener) GetSourceNames() []string {
+	sl.sourceMutex.RLock()
+	defer sl.sourceMutex.RUnlock()
 	
-	delete(sl.sources, sourceKey)
+	names := make([]string, 0, len(sl.sources))
+	for _, source := range sl.sources {
+		names = append(names, source.config.Name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // GetSourceCount returns the number of sources using this listener
@@ -110,84 +215,345 @@ func (sl *SharedListener) GetSourceCount() int {
 	return len(sl.sources)
 }
 
-// handleUDPConnections processes UDP messages for all sources
-func (sl *SharedListener) handleUDPConnections() {
+// Protocol returns the listener's transport, UDP, TCP or RELP
+func (sl *SharedListener) Protocol() string {
+	return sl.protocol
+}
+
+// Port returns the port the listener binds
+func (sl *SharedListener) Port() int {
+	return sl.port
+}
+
+// Owner returns the name the listener's goroutines are accounted to
+func (sl *SharedListener) Owner() string {
+	return sl.owner
+}
+
+// SetConfig applies listener-level settings
+func (sl *SharedListener) SetConfig(settings models.ListenerConfig) error {
+	allowList, err := ParseIPList(settings.AllowList)
+	if err != nil {
+		return fmt.Errorf("allow list: %v", err)
+	}
+	denyList, err := ParseIPList(settings.DenyList)
+	if err != nil {
+		return fmt.Errorf("deny list: %v", err)
+	}
+	settings.Protocol = sl.protocol
+	if err := ValidateWorkerSettings(settings); err != nil {
+		return err
+	}
+	var credentials *mtls.Credentials
+	if settings.TLS != nil {
+		if sl.protocol == "UDP" {
+			return fmt.Errorf("tls only applies to TCP and RELP listeners")
+		}
+		if credentials, err = mtls.Load(*settings.TLS); err != nil {
+			return err
+		}
+	}
+	
+	sl.sourceMutex.Lock()
+	defer sl.sourceMutex.Unlock()
+	
+	settings.Protocol = sl.protocol
+	settings.Port = sl.port
+	sl.settings = settings
+	sl.allowList = allowList
+	sl.denyList = denyList
+	sl.tls = credentials
+	return nil
+}
+
+// GetStatus returns the listener's settings and counters
+func (sl *SharedListener) GetStatus() models.ListenerStatus {
+	sl.sourceMutex.RLock()
+	settings := sl.settings
+	sourceCount := len(sl.sources)
+	credentials := sl.tls
+	sl.sourceMutex.RUnlock()
+	
+	status := models.ListenerStatus{
+		ListenerConfig: settings,
+		SourceCount:    sourceCount,
+		Rejected:       atomic.LoadInt64(&sl.rejected),
+		Denied:         atomic.LoadInt64(&sl.denied),
+		Panics:         atomic.LoadInt64(&sl.panics),
+	}
+	status.KernelDrops, status.KernelDropsAvailable = sl.GetKernelDrops()
+	down, downSince, lastError, restarts := sl.health()
+	status.State = "up"
+	if down {
+		status.State = "down"
+		status.DownSince = &downSince
+	}
+	status.LastError = lastError
+	status.Restarts = restarts
+	if sl.protocol != "UDP" {
+		status.Connections = sl.connections.snapshot()
+	}
+	if credentials != nil {
+		tlsStatus := credentials.Status()
+		status.TLSStatus = &tlsStatus
+	}
+	status.Workers, status.WorkerImbalance = workerStatuses(sl.workers)
+	return status
+}
+
+// GetUnclaimedSenders returns senders that reached this listener without a matching source
+func (sl *SharedListener) GetUnclaimedSenders() []models.UnclaimedSender {
+	return sl.unclaimed.Snapshot(sl.protocol, sl.port)
+}
+
+// ForgetUnclaimedSender removes a sender from the unclaimed list
+func (sl *SharedListener) ForgetUnclaimedSender(sourceIP string) bool {
+	return sl.unclaimed.Forget(sourceIP)
+}
+
+// GetKernelDrops returns the number of datagrams the kernel dropped for this
+// listener's socket. The second value is false when the statistic is not
+// available (TCP and RELP listeners or unsupported platforms).
+func (sl *SharedListener) GetKernelDrops() (int64, bool) {
+	if sl.protocol != "UDP" {
+		return 0, false
+	}
+	inode, _ := sl.udpInode.Load().(string)
+	if inode == "" {
+		return 0, false
+	}
+	
+	sl.kernelMutex.Lock()
+	defer sl.kernelMutex.Unlock()
+	
+	// Avoid re-reading /proc for every source sharing this listener
+	if time.Since(sl.kernelDropsRead) < time.Second {
+		return sl.kernelDrops, sl.kernelDropsOK
+	}
+	
+	drops, err := readUDPKernelDrops(inode)
+	sl.kernelDrops = drops
+	sl.kernelDropsOK = err == nil
+	sl.kernelDropsRead = time.Now()
+	
+	return sl.kernelDrops, sl.kernelDropsOK
+}
+
+// handleUDPConnections reads UDP messages for all sources; each worker runs
+// its own copy on the shared socket, restarted if it panics
+func (sl *SharedListener) handleUDPConnections(udpConn *net.UDPConn, worker *udpWorker) {
+	defer sl.readers.Done()
+	
+	if err := worker.pin(); err != nil {
+		log.Printf("⚠ Could not pin UDP worker %d on port %d to CPUs %s: %v", worker.id, sl.port, FormatCPUList(worker.cpus), err)
+	}
+	component := fmt.Sprintf("UDP worker %d on port %d", worker.id, sl.port)
+	recovery.Supervise(component, &sl.panics, func() { sl.readUDP(udpConn, worker) })
+}
+
+// readUDP reads datagrams until the listener stops or the socket fails
+func (sl *SharedListener) readUDP(udpConn *net.UDPConn, worker *udpWorker) {
 	buffer := make([]byte, 65536)
+	failures := 0
 	
 	for {
 		select {
-		case <-sl.stopChan:
+		case <-sl.ctx.Done():
 			return
 		default:
-			if sl.udpConn == nil {
-				continue
-			}
-			
-			n, addr, err := sl.udpConn.ReadFromUDP(buffer)
+			n, addr, err := udpConn.ReadFromUDP(buffer)
 			if err != nil {
-				if strings.Contains(err.Error(), "use of closed network connection") {
+				if sl.readFailed(err, &failures) {
 					return
 				}
 				continue
 			}
+			failures = 0
 			
-			// Route message to appropriate sources
-			sl.routeMessage(buffer[:n], addr.IP.String())
+			// Route message to appropriate sources (a datagram is its own frame)
+			sourceIP := addr.IP.String()
+			worker.record(n, sourceIP, sl.routeMessage(buffer[:n], sourceIP, n, nil))
 		}
 	}
 }
 
-// handleTCPConnections processes TCP connections for all sources  
-func (sl *SharedListener) handleTCPConnections() {
+// handleTCPConnections processes TCP connections for all sources, restarting
+// the accept loop if it panics
+func (sl *SharedListener) handleTCPConnections(listener net.Listener) {
+	defer sl.readers.Done()
+	recovery.Supervise(fmt.Sprintf("TCP listener on port %d", sl.port), &sl.panics, func() { sl.acceptTCP(listener) })
+}
+
+// acceptTCP accepts connections until the listener stops or the socket fails
+func (sl *SharedListener) acceptTCP(listener net.Listener) {
+	failures := 0
 	for {
 		select {
-		case <-sl.stopChan:
+		case <-sl.ctx.Done():
 			return
 		default:
-			if sl.tcpListener == nil {
-				continue
-			}
-			
-			conn, err := sl.tcpListener.Accept()
+			conn, err := listener.Accept()
 			if err != nil {
-				if strings.Contains(err.Error(), "use of closed network connection") {
+				if sl.readFailed(err, &failures) {
 					return
 				}
 				continue
 			}
+			failures = 0
 			
-			go sl.handleTCPConnection(conn)
+			sl.handlers.Add(1)
+			lifecycle.Go(sl.ctx, func() { sl.handleTCPConnection(conn) })
 		}
 	}
 }
 
-// handleTCPConnection processes a single TCP connection
+// handleTCPConnection processes a single TCP connection until the sender
+// closes it or the listener stops. A panic closes only this connection; the
+// sender reconnects.
 func (sl *SharedListener) handleTCPConnection(conn net.Conn) {
+	defer sl.handlers.Done()
 	defer conn.Close()
+	stopClosing := context.AfterFunc(sl.ctx, func() { conn.Close() })
+	defer stopClosing()
+	defer recovery.Recover(fmt.Sprintf("TCP connection from %s on port %d", conn.RemoteAddr(), sl.port), &sl.panics)
 	
 	remoteAddr := conn.RemoteAddr().(*net.TCPAddr)
 	sourceIP := remoteAddr.IP.String()
 	
+	sl.sourceMutex.RLock()
+	keepAlivePeriod := sl.settings.TCPKeepAlivePeriodSeconds
+	credentials := sl.tls
+	sl.sourceMutex.RUnlock()
+	configureKeepAlive(conn, keepAlivePeriod)
+	
+	// Peers without a certificate the CA signed don't get to send anything
+	if credentials != nil {
+		tlsConn, err := credentials.Server(conn)
+		if err != nil {
+			log.Printf("⚠ TLS handshake from %s on port %d failed: %v", sourceIP, sl.port, err)
+			return
+		}
+		conn = tlsConn
+	}
+	
+	// Track activity so an idle device can be told apart from a dead connection
+	tracked := sl.connections.open(conn, sourceIP)
+	defer sl.connections.close(tracked)
+	
+	if sl.protocol == models.ProtocolRELP {
+		sl.serveRELP(conn, tracked, sourceIP)
+		return
+	}
+	
+	// Track the bytes consumed per token, including the line terminators
+	// the scanner strips, so wire throughput can be reported accurately
+	var wireSize int
 	scanner := bufio.NewScanner(conn)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		wireSize = advance
+		return advance, token, err
+	})
+	// Devices behind a shared NAT address are told apart by the hostname of
+	// the first message, cached for the lifetime of the connection
+	var attributed *SyslogSource
+	resolved := false
 	for scanner.Scan() {
-		sl.routeMessage(scanner.Bytes(), sourceIP)
+		tracked.touch(wireSize)
+		if !resolved || (attributed != nil && !attributed.IsRunning()) {
+			attributed = sl.resolveHostnameSource(sourceIP, scanner.Bytes())
+			resolved = true
+		}
+		sl.routeMessage(scanner.Bytes(), sourceIP, wireSize, attributed)
+	}
+	
+	// Keep-alive failures and resets surface here rather than as a clean EOF
+	if err := scanner.Err(); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+		log.Printf("⚠ TCP connection from %s on port %d closed after %.0fs idle: %v", tracked.remoteAddr, sl.port, tracked.idle().Seconds(), err)
+	}
+}
+
+// checkAccess returns why a sender is blocked, or "" when it may send.
+// Callers hold sourceMutex.
+func (sl *SharedListener) checkAccess(sourceIP string) string {
+	if ipListContains(sl.denyList, sourceIP) {
+		return "deny-list"
+	}
+	if len(sl.allowList) > 0 && !ipListContains(sl.allowList, sourceIP) {
+		return "not-allowed"
+	}
+	return ""
+}
+
+// resolveHostnameSource finds the hostname-attributed source for a sender
+// from one of its messages, or nil when none applies
+func (sl *SharedListener) resolveHostnameSource(sourceIP string, data []byte) *SyslogSource {
+	sl.sourceMutex.RLock()
+	defer sl.sourceMutex.RUnlock()
+	
+	hasHostnameSources := false
+	for key := range sl.sources {
+		if strings.Contains(key, "/") {
+			hasHostnameSources = true
+			break
+		}
+	}
+	if !hasHostnameSources {
+		return nil
+	}
+	
+	hostname := ParseHostname(data)
+	if hostname == "" {
+		return nil
 	}
+	if source, exists := sl.sources[routingKey(sourceIP, hostname)]; exists {
+		return source
+	}
+	return sl.sources[routingKey("0.0.0.0", hostname)]
 }
 
-// routeMessage routes messages to appropriate sources based on IP
-func (sl *SharedListener) routeMessage(data []byte, sourceIP string) {
+// routeMessage routes messages to appropriate sources based on IP, or to the
+// source a TCP connection was attributed to by hostname.
+// wireSize is the number of bytes the message occupied on the wire. Returns
+// whether a source accepted the message; denied, rejected, unclaimed and
+// quota-dropped messages return false.
+func (sl *SharedListener) routeMessage(data []byte, sourceIP string, wireSize int, attributed *SyslogSource) bool {
 	sl.sourceMutex.RLock()
 	defer sl.sourceMutex.RUnlock()
 	
+	// Allow/deny lists are evaluated before routing; deny wins over allow
+	if reason := sl.checkAccess(sourceIP); reason != "" {
+		atomic.AddInt64(&sl.denied, 1)
+		if sl.settings.RejectLog {
+			sl.rejectLog.Log("DENY", sl.protocol, sl.port, sourceIP, wireSize, reason)
+		}
+		return false
+	}
+	
+	if attributed != nil && attributed.IsRunning() {
+		return attributed.ProcessMessage(data, sourceIP, wireSize)
+	}
+	
 	// Try to find exact IP match first
 	if source, exists := sl.sources[sourceIP]; exists && source.IsRunning() {
-		source.ProcessMessage(data, sourceIP)
-		return
+		return source.ProcessMessage(data, sourceIP, wireSize)
 	}
 	
 	// If no exact match, try wildcard (0.0.0.0) sources
 	if source, exists := sl.sources["0.0.0.0"]; exists && source.IsRunning() {
-		source.ProcessMessage(data, sourceIP)
-		return
+		return source.ProcessMessage(data, sourceIP, wireSize)
 	}
-}
\ No newline at end of file
+	
+	// In strict mode only configured senders may send; count and drop the rest
+	if sl.settings.StrictMode {
+		atomic.AddInt64(&sl.rejected, 1)
+		if sl.settings.RejectLog {
+			sl.rejectLog.Log("REJECT", sl.protocol, sl.port, sourceIP, wireSize, "unconfigured-sender")
+		}
+		return false
+	}
+	
+	// No source claims this sender; remember it for auto-discovery
+	sl.unclaimed.Record(sourceIP, data)
+	return false
+}
\ No newline at end of file
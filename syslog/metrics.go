@@ -2,6 +2,7 @@ package syslog
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"syslog-analyzer/models"
@@ -11,6 +12,9 @@ import (
 type MetricsCalculator struct {
 	buffer            *models.CircularBuffer
 	totalLogsIngested int64
+	totalEventBytes   int64
+	totalWireBytes    int64
+	pendingWireBytes  int64 // wire bytes not yet attached to a data point
 	mutex             sync.RWMutex
 }
 
@@ -25,17 +29,33 @@ func NewMetricsCalculator() *MetricsCalculator {
 func (mc *MetricsCalculator) RecordMetrics(logCount, dataSize, processed, sent int64) {
 	mc.mutex.Lock()
 	mc.totalLogsIngested += logCount
+	mc.totalEventBytes += dataSize
 	mc.mutex.Unlock()
 	
 	mc.buffer.Add(models.MetricDataPoint{
 		Timestamp: time.Now(),
 		LogCount:  logCount,
 		DataSize:  dataSize,
+		WireSize:  atomic.SwapInt64(&mc.pendingWireBytes, 0),
 		Processed: processed,
 		Sent:      sent,
 	})
 }
 
+// AddWireBytes records bytes received on the wire, including framing
+func (mc *MetricsCalculator) AddWireBytes(n int64) {
+	atomic.AddInt64(&mc.pendingWireBytes, n)
+	atomic.AddInt64(&mc.totalWireBytes, n)
+}
+
+// GetTotalBytes returns the total parsed event bytes and wire bytes
+func (mc *MetricsCalculator) GetTotalBytes() (int64, int64) {
+	mc.mutex.RLock()
+	eventBytes := mc.totalEventBytes
+	mc.mutex.RUnlock()
+	return eventBytes, atomic.LoadInt64(&mc.totalWireBytes)
+}
+
 // GetTotalLogsIngested returns the total logs ingested
 func (mc *MetricsCalculator) GetTotalLogsIngested() int64 {
 	mc.mutex.RLock()
@@ -53,6 +73,9 @@ func (mc *MetricsCalculator) CalculateMetrics(name, sourceIP string, port int, p
 	recentLogs, recentGB, _, _ := mc.buffer.GetAverage(1 * time.Minute)
 	realTimeEPS := float64(recentLogs) / 60.0 // Per second
 	realTimeGBps := recentGB / 60.0           // Per second
+	realTimeWireGBps := mc.buffer.GetWireAverage(1*time.Minute) / 60.0
+	
+	eventBytes, wireBytes := mc.GetTotalBytes()
 	
 	return models.SourceMetrics{
 		Name:              name,
@@ -62,6 +85,9 @@ func (mc *MetricsCalculator) CalculateMetrics(name, sourceIP string, port int, p
 		SimulationMode:    simulationMode,
 		RealTimeEPS:       realTimeEPS,
 		RealTimeGBps:      realTimeGBps,
+		RealTimeWireGBps:  realTimeWireGBps,
+		TotalEventBytes:   eventBytes,
+		TotalWireBytes:    wireBytes,
 		TotalLogsIngested: mc.GetTotalLogsIngested(),
 		HourlyAvgLogs:     hourlyLogs,
 		HourlyAvgGB:       hourlyGB,
@@ -70,9 +96,11 @@ func (mc *MetricsCalculator) CalculateMetrics(name, sourceIP string, port int, p
 		QueueDepth:        queueStats.Depth,
 		ProcessedCount:    queueStats.Processed,
 		SentCount:         queueStats.Sent,
+		DroppedCount:      queueStats.Dropped,
+		DestinationErrors: queueStats.DestErrors,
 		LastUpdated:       time.Now(),
 		IsActive:          isActive,
 		IsReceiving:       isReceiving,
 		LastMessageAt:     lastMessageAt,
 	}
-}
\ No newline at end of file
+}
\ No newline at end of file
@@ -0,0 +1,88 @@
+package syslog
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"syslog-analyzer/models"
+	"syslog-analyzer/spool"
+)
+
+// Pause buffer defaults
+const (
+	defaultPauseMemoryEvents = 100000
+	defaultPauseDir          = "pause_buffer"
+	pauseReplayChunk         = 1000 // Buffered events delivered per batch on resume
+)
+
+// pauseBufferConfig is the resolved configuration of processors created from now on
+var pauseBufferConfig atomic.Value
+
+func init() {
+	pauseBufferConfig.Store(models.PauseBufferConfig{MemoryEvents: defaultPauseMemoryEvents, Dir: defaultPauseDir})
+}
+
+// SetPauseBuffer sets how much delivery a paused source holds back, for
+// sources started after the call
+func SetPauseBuffer(config models.PauseBufferConfig) error {
+	if config.MemoryEvents < 0 || config.MaxDiskMB < 0 {
+		return fmt.Errorf("pause buffer limits cannot be negative")
+	}
+	if config.MemoryEvents == 0 {
+		config.MemoryEvents = defaultPauseMemoryEvents
+	}
+	if config.Dir == "" {
+		config.Dir = defaultPauseDir
+	}
+	pauseBufferConfig.Store(config)
+	return nil
+}
+
+// GetPauseBuffer returns the pause buffer configuration new sources use
+func GetPauseBuffer() models.PauseBufferConfig {
+	return pauseBufferConfig.Load().(models.PauseBufferConfig)
+}
+
+// newPauseBuffer creates the buffer holding back a source's events while its
+// delivery is paused
+func newPauseBuffer(config models.PauseBufferConfig, source string) *spool.Spool {
+	buffer, err := spool.New(filepath.Join(config.Dir, spool.FileName(source)), config.MemoryEvents, int64(config.MaxDiskMB)*1024*1024)
+	if err != nil {
+		log.Printf("⚠ Pause buffer of source '%s': %v", source, err)
+	}
+	if held := buffer.Stats(); held.OnDisk > 0 {
+		log.Printf("✓ Source '%s' has %d events held back on disk by an earlier run; they are delivered once delivery is not paused", source, held.OnDisk)
+	}
+	return buffer
+}
+
+// pauseBufferPath returns the file a source's held back events spill to
+func pauseBufferPath(source string) string {
+	return filepath.Join(GetPauseBuffer().Dir, spool.FileName(source))
+}
+
+// RenamePauseBuffer moves the events a stopped source held back on disk to
+// the buffer of its new name
+func RenamePauseBuffer(oldName, newName string) error {
+	if oldName == newName {
+		return nil
+	}
+	err := os.Rename(pauseBufferPath(oldName), pauseBufferPath(newName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// RemovePauseBuffer deletes the events a stopped source held back on disk,
+// so a source created later under the same name does not deliver them
+func RemovePauseBuffer(name string) error {
+	err := os.Remove(pauseBufferPath(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
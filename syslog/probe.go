@@ -0,0 +1,312 @@
+package syslog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	
+	"syslog-analyzer/models"
+	"syslog-analyzer/recovery"
+)
+
+// Probe defaults
+const (
+	defaultProbeSilence  = 300 * time.Second
+	defaultProbeInterval = 60 * time.Second
+	defaultProbeTimeout  = 5 * time.Second
+	defaultProbePort     = 22
+)
+
+// icmpSequence numbers the echo requests of every prober in the process
+var icmpSequence uint32
+
+// ValidateProbe checks the probe of a source
+func ValidateProbe(probe models.Probe, source models.SourceConfig) error {
+	if probe.Method != models.ProbeICMP && probe.Method != models.ProbeTCP {
+		return fmt.Errorf("probe method must be %s or %s", models.ProbeICMP, models.ProbeTCP)
+	}
+	if probe.Host == "" && (source.IP == "" || source.IP == "0.0.0.0") {
+		return fmt.Errorf("probe host is required for sources matching any sender")
+	}
+	if strings.ContainsAny(probe.Host, " /:") && net.ParseIP(probe.Host) == nil {
+		return fmt.Errorf("probe host must be an address or hostname without a port")
+	}
+	if probe.Port < 0 || probe.Port > 65535 {
+		return fmt.Errorf("invalid probe port")
+	}
+	if probe.SilentSeconds < 0 || probe.IntervalSeconds < 0 || probe.TimeoutSeconds < 0 {
+		return fmt.Errorf("probe silent_seconds, interval_seconds and timeout_seconds cannot be negative")
+	}
+	return nil
+}
+
+// prober checks a silent source's device and remembers what it found
+type prober struct {
+	method   string
+	host     string
+	target   string // host for ICMP, host:port for TCP
+	silence  time.Duration
+	interval time.Duration
+	timeout  time.Duration
+	mutex    sync.Mutex
+	state    string
+	since    time.Time
+	last     time.Time
+	rtt      time.Duration
+	lastErr  string
+	probes   int64
+	failures int64
+}
+
+// newProber returns the prober of a source, or nil without one
+func newProber(config models.SourceConfig) *prober {
+	if config.Probe == nil {
+		return nil
+	}
+	probe := *config.Probe
+	if err := ValidateProbe(probe, config); err != nil {
+		log.Printf("⚠ Ignoring probe for source '%s': %v", config.Name, err)
+		return nil
+	}
+	
+	p := &prober{
+		method:   probe.Method,
+		host:     probe.Host,
+		silence:  time.Duration(probe.SilentSeconds) * time.Second,
+		interval: time.Duration(probe.IntervalSeconds) * time.Second,
+		timeout:  time.Duration(probe.TimeoutSeconds) * time.Second,
+		state:    models.ProbeReceiving,
+		since:    time.Now(),
+	}
+	if p.host == "" {
+		p.host = config.IP
+	}
+	if p.silence == 0 {
+		p.silence = defaultProbeSilence
+	}
+	if p.interval == 0 {
+		p.interval = defaultProbeInterval
+	}
+	if p.timeout == 0 {
+		p.timeout = defaultProbeTimeout
+	}
+	
+	p.target = p.host
+	if p.method == models.ProbeTCP {
+		port := probe.Port
+		if port == 0 {
+			port = defaultProbePort
+			if strings.Contains(strings.ToUpper(config.Protocol), "TCP") || strings.EqualFold(config.Protocol, models.ProtocolRELP) {
+				port = config.Port
+			}
+		}
+		p.target = net.JoinHostPort(p.host, strconv.Itoa(port))
+	}
+	return p
+}
+
+// setState records a state and reports whether it changed
+func (p *prober) setState(state string, now time.Time) bool {
+	if p.state == state {
+		return false
+	}
+	p.state = state
+	p.since = now
+	return true
+}
+
+// snapshot reports the probing so far. A source receiving again shows as
+// receiving straight away rather than at the next probe.
+func (p *prober) snapshot(silent bool) *models.ProbeMetrics {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	
+	metrics := &models.ProbeMetrics{
+		Method:    p.method,
+		Target:    p.target,
+		State:     p.state,
+		Since:     p.since,
+		LastProbe: p.last,
+		RTTMs:     float64(p.rtt) / float64(time.Millisecond),
+		Error:     p.lastErr,
+		Probes:    p.probes,
+		Failures:  p.failures,
+	}
+	if !silent {
+		metrics.State = models.ProbeReceiving
+	}
+	return metrics
+}
+
+// runProber probes the device while the source is silent, restarting if it panics
+func (lp *LogProcessor) runProber() {
+	defer close(lp.proberDone)
+	recovery.Supervise(fmt.Sprintf("probe of source '%s'", lp.config.Name), &lp.panics, lp.proberLoop)
+}
+
+// proberLoop checks every interval whether the source has gone silent and
+// probes its device if so, until stopped
+func (lp *LogProcessor) proberLoop() {
+	ticker := time.NewTicker(lp.prober.interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-lp.ctx.Done():
+			return
+		case now := <-ticker.C:
+			if lp.silent(now) {
+				lp.probeDevice(now)
+			} else {
+				lp.prober.mutex.Lock()
+				lp.prober.setState(models.ProbeReceiving, now)
+				lp.prober.mutex.Unlock()
+			}
+		}
+	}
+}
+
+// silent reports whether the source has received nothing for the probe's
+// silence period; a source that never received counts from when it started
+func (lp *LogProcessor) silent(now time.Time) bool {
+	lp.msgMutex.RLock()
+	last := lp.lastMessageAt
+	lp.msgMutex.RUnlock()
+	if last.IsZero() {
+		last = lp.startedAt
+	}
+	return now.Sub(last) >= lp.prober.silence
+}
+
+// probeDevice probes the device once and logs when it changes from
+// answering to not answering or back
+func (lp *LogProcessor) probeDevice(now time.Time) {
+	p := lp.prober
+	var rtt time.Duration
+	var err error
+	if p.method == models.ProbeICMP {
+		rtt, err = pingICMP(lp.ctx, p.host, p.timeout)
+	} else {
+		rtt, err = connectTCP(lp.ctx, p.target, p.timeout)
+	}
+	if lp.ctx.Err() != nil {
+		return
+	}
+	
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.probes++
+	p.last = now
+	if err != nil {
+		p.failures++
+		p.lastErr = err.Error()
+		if p.setState(models.ProbeUnreachable, now) {
+			log.Printf("⚠ Source '%s' is silent and its device %s does not answer %s probes: %v", lp.config.Name, p.target, p.method, err)
+		}
+		return
+	}
+	
+	p.rtt = rtt
+	p.lastErr = ""
+	if p.setState(models.ProbeReachable, now) {
+		log.Printf("⚠ Source '%s' is silent but its device %s answers %s probes; its logging may be disabled", lp.config.Name, p.target, p.method)
+	}
+}
+
+// connectTCP opens and closes a TCP connection to target. A refused
+// connection still proves the device is up, so it counts as an answer.
+func connectTCP(ctx context.Context, target string, timeout time.Duration) (time.Duration, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return time.Since(start), nil
+		}
+		return 0, err
+	}
+	rtt := time.Since(start)
+	conn.Close()
+	return rtt, nil
+}
+
+// pingICMP sends one ICMP echo request to host and waits for its reply
+func pingICMP(ctx context.Context, host string, timeout time.Duration) (time.Duration, error) {
+	addresses, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return 0, err
+	}
+	ip := addresses[0]
+	
+	network, request, reply := "ip4:icmp", byte(8), byte(0)
+	if ip.To4() == nil {
+		network, request, reply = "ip6:ipv6-icmp", byte(128), byte(129)
+	}
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, network, ip.String())
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return 0, fmt.Errorf("ICMP probes need root or CAP_NET_RAW; use the tcp method instead")
+		}
+		return 0, err
+	}
+	defer conn.Close()
+	ipConn := conn.(*net.IPConn)
+	
+	id := uint16(os.Getpid())
+	seq := uint16(atomic.AddUint32(&icmpSequence, 1))
+	message := []byte{request, 0, 0, 0, byte(id >> 8), byte(id), byte(seq >> 8), byte(seq)}
+	message = append(message, "syslog-analyzer probe"...)
+	if request == 8 {
+		// The kernel fills in ICMPv6 checksums, which cover the IPv6 header
+		checksum := icmpChecksum(message)
+		message[2], message[3] = byte(checksum>>8), byte(checksum)
+	}
+	
+	start := time.Now()
+	ipConn.SetDeadline(start.Add(timeout))
+	if _, err := ipConn.Write(message); err != nil {
+		return 0, err
+	}
+	
+	// ReadFrom strips the IPv4 header; replies to other pingers are skipped
+	buffer := make([]byte, 1500)
+	for {
+		n, _, err := ipConn.ReadFrom(buffer)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return 0, fmt.Errorf("no echo reply within %s", timeout)
+			}
+			return 0, err
+		}
+		if n >= 8 && buffer[0] == reply && buffer[4] == byte(id>>8) && buffer[5] == byte(id) &&
+			buffer[6] == byte(seq>>8) && buffer[7] == byte(seq) {
+			return time.Since(start), nil
+		}
+	}
+}
+
+// icmpChecksum computes the Internet checksum of an ICMP message
+func icmpChecksum(message []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(message); i += 2 {
+		sum += uint32(message[i])<<8 | uint32(message[i+1])
+	}
+	if len(message)%2 == 1 {
+		sum += uint32(message[len(message)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
@@ -2,15 +2,22 @@
 package syslog
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"syslog-analyzer/destinations"
+	"syslog-analyzer/enrichment"
 	"syslog-analyzer/filtering"
+	"syslog-analyzer/lifecycle"
 	"syslog-analyzer/models"
+	"syslog-analyzer/recovery"
+	"syslog-analyzer/spool"
 )
 
 // LogProcessor handles the processing pipeline for syslog messages
@@ -19,10 +26,41 @@ type LogProcessor struct {
 	queue          *LogQueue
 	filterEngine   *filtering.Engine
 	aggregator     *filtering.Aggregator
+	destinations   *destinations.Handler
+	admit          func(size int) bool // Quota gate; nil admits everything
+	lookup         enrichment.LookupFunc // Lookup table access; nil disables enrichment
+	minSeverity    int                   // Events with a higher severity code are dropped; -1 keeps all
+	severityDrops  [8]int64              // Events dropped by minSeverity per severity code
+	timeFallbacks  int64                 // Events that kept their receive time under a device timestamp source
+	parseFailures  int64                 // Text messages the parser couldn't split
+	facilityCounts [24]int64             // Parsed events per facility code
+	severityCounts [8]int64              // Parsed events per severity code
 	metrics        *MetricsCalculator
-	stopChan       chan bool
+	reconciliation *ReconciliationLedger
+	unfolded       [3]int64 // Received, dropped and discarded messages not yet folded into the ledger
+	stages         stageTimers
+	samples        *eventSampler // Recent events as the filters see them
+	decodeEvents   bool          // Whether events are decoded on receipt; false keeps the raw JSON
+	simulation     int32         // 1 while batches are only counted, not delivered
+	pathMutex      sync.Mutex    // Held while a batch goes down either path, so switching waits for it
+	paused         int32         // 1 while delivery is paused and processed events are held back
+	pauseBuffer    *spool.Spool
+	eventIDs       *eventIDs          // Stamps events for destinations that suppress duplicates; nil leaves them without IDs
+	meterBase      int64              // Event bytes ingested when the destinations were last added, for metered destinations
+	batcher        *batcher           // Adaptive batching; nil queues every event on its own
+	panics         int64              // Panics recovered in the processing thread and batch flusher
+	flusherDone    chan struct{}      // Closed when the batch flusher has exited
+	tracer         *tracer            // End-to-end latency measurement; nil without a tracer
+	tracerDone     chan struct{}      // Closed when the tracer loop has exited
+	capture        *rawCapture        // Forensic copy of received messages; nil without raw capture
+	prober         *prober            // Checks the device while the source is silent; nil without a probe
+	proberDone     chan struct{}      // Closed when the probe loop has exited
+	startedAt      time.Time          // When Start was called; silence counts from here until a message arrives
+	backlogSince   int64              // Unix nanoseconds the oldest batch not yet processed was queued; 0 when none is
+	ctx            context.Context    // Cancelled to stop the processing thread and batch flusher
+	cancel         context.CancelFunc // Set from Start until Stop
+	doneChan       chan struct{}      // Closed when the processing thread has exited
 	batchSize      int
-	isRunning      bool
 	mutex          sync.RWMutex
 	lastMessageAt  time.Time
 	msgMutex       sync.RWMutex
@@ -31,101 +69,313 @@ type LogProcessor struct {
 // NewLogProcessor creates a new log processor
 func NewLogProcessor(config models.SourceConfig, batchSize int) *LogProcessor {
 	processor := &LogProcessor{
-		config:       config,
-		queue:        NewLogQueue(1000), // Queue capacity
-		filterEngine: filtering.NewEngine(config.Filters),
-		aggregator:   filtering.NewAggregator(config.Aggregations),
-		metrics:      NewMetricsCalculator(),
-		stopChan:     make(chan bool),
-		batchSize:    batchSize,
+		config:         config,
+		queue:          NewLogQueue(1000), // Queue capacity
+		filterEngine:   NewFilterEngine(config),
+		aggregator:     filtering.NewAggregator(config.Aggregations),
+		destinations:   destinations.NewHandler(),
+		metrics:        NewMetricsCalculator(),
+		reconciliation: NewReconciliationLedger(),
+		stages:         newStageTimers(),
+		samples:        newEventSampler(),
+		pauseBuffer:    newPauseBuffer(GetPauseBuffer(), config.Name),
+		decodeEvents:   len(config.Filters) > 0 || len(config.Aggregations) > 0 || len(config.Enrichments) > 0,
+		doneChan:       make(chan struct{}),
+		batchSize:      batchSize,
+		minSeverity:    -1,
+		capture:        newRawCapture(config),
+	}
+	if config.SimulationMode {
+		processor.simulation = 1
+	}
+	if needsEventIDs(config) {
+		processor.eventIDs = newEventIDs()
+	}
+	if config := GetBatching(); config.Mode == BatchingAdaptive {
+		processor.batcher = newBatcher(config)
+		processor.flusherDone = make(chan struct{})
+	}
+	if processor.tracer = newTracer(config); processor.tracer != nil {
+		processor.tracerDone = make(chan struct{})
+	}
+	if processor.prober = newProber(config); processor.prober != nil {
+		processor.proberDone = make(chan struct{})
+	}
+	
+	if config.MinSeverity != "" {
+		if level, err := ParseSeverityLevel(config.MinSeverity); err == nil {
+			processor.minSeverity = level
+		} else {
+			log.Printf("⚠ Ignoring min_severity for source '%s': %v", config.Name, err)
+		}
 	}
 	
 	return processor
 }
 
-// Start begins the log processing pipeline
-func (lp *LogProcessor) Start() error {
+// NewFilterEngine builds the filter engine with the source's matcher
+func NewFilterEngine(config models.SourceConfig) *filtering.Engine {
+	if config.FilterMatcher == models.FilterMatcherMulti {
+		return filtering.NewMultiPatternEngine(config.Filters, config.FilterPolicy)
+	}
+	return filtering.NewEngine(config.Filters, config.FilterPolicy)
+}
+
+// SetAdmitFunc installs the quota gate consulted for every received message
+func (lp *LogProcessor) SetAdmitFunc(admit func(size int) bool) {
+	lp.admit = admit
+}
+
+// SetLookupFunc installs the lookup table access used by enrichment rules
+func (lp *LogProcessor) SetLookupFunc(lookup enrichment.LookupFunc) {
+	lp.lookup = lookup
+}
+
+// Start begins the log processing pipeline, which runs until Stop or until
+// ctx is cancelled
+func (lp *LogProcessor) Start(ctx context.Context) error {
 	lp.mutex.Lock()
 	defer lp.mutex.Unlock()
 	
-	if lp.isRunning {
+	if lp.cancel != nil {
 		return fmt.Errorf("processor already running")
 	}
 	
-	lp.isRunning = true
+	lp.ctx, lp.cancel = context.WithCancel(ctx)
+	lp.startedAt = time.Now()
 	
-	// Start processing threads
-	if !lp.config.SimulationMode {
-		// Full processing mode with filtering/aggregation
-		go lp.runFilteringThread()
-		
-		// Start destination threads
-		for _, dest := range lp.config.Destinations {
-			if dest.Enabled {
-				go lp.runDestinationThread(dest)
-			}
+	// Register enabled destinations before processing starts
+	if !lp.simulating() {
+		lp.addDestinations()
+	}
+	
+	lifecycle.Go(lp.ctx, lp.runProcessingThread)
+	if lp.batcher != nil {
+		lifecycle.Go(lp.ctx, lp.runBatchFlusher)
+	}
+	if lp.tracer != nil {
+		lifecycle.Go(lp.ctx, lp.runTracer)
+	}
+	if lp.prober != nil {
+		lifecycle.Go(lp.ctx, lp.runProber)
+	}
+	
+	log.Printf("✓ Log processor started for source '%s' (simulation: %v)", lp.config.Name, lp.simulating())
+	return nil
+}
+
+// addDestinations registers the source's destinations with the handler,
+// leaving out those the classification rules keep from the source's events
+func (lp *LogProcessor) addDestinations() {
+	eventBytes, _ := lp.metrics.GetTotalBytes()
+	atomic.StoreInt64(&lp.meterBase, eventBytes)
+	for _, dest := range lp.config.Destinations {
+		if err := destinations.CheckRouting(lp.config.Classification, dest); err != nil && dest.Enabled {
+			log.Printf("✗ Not delivering source '%s' to destination '%s': %v", lp.config.Name, dest.Name, err)
+			continue
+		}
+		if err := lp.destinations.AddDestination(lp.ctx, dest, lp.config.Name); err != nil {
+			log.Printf("✗ Failed to add destination '%s' for source '%s': %v", dest.Name, lp.config.Name, err)
+		}
+	}
+}
+
+// simulating returns whether batches are only counted rather than delivered
+func (lp *LogProcessor) simulating() bool {
+	return atomic.LoadInt32(&lp.simulation) == 1
+}
+
+// SetSimulationMode switches between counting and delivering batches without
+// stopping the processor, so queued events are kept. The switch happens
+// between batches: leaving live mode flushes and closes the destinations
+// once the batch in flight is delivered, entering it opens them first.
+func (lp *LogProcessor) SetSimulationMode(enabled bool) {
+	lp.mutex.Lock()
+	defer lp.mutex.Unlock()
+	
+	if enabled == lp.simulating() {
+		return
+	}
+	if lp.cancel == nil {
+		lp.setSimulation(enabled)
+		return
+	}
+	
+	lp.pathMutex.Lock()
+	defer lp.pathMutex.Unlock()
+	
+	if enabled {
+		lp.setSimulation(true)
+		log.Printf("✓ Source '%s' switched to simulation mode", lp.config.Name)
+		if err := lp.destinations.Flush(); err != nil {
+			log.Printf("⚠ Error flushing destinations for source '%s': %v", lp.config.Name, err)
+		}
+		if err := lp.destinations.Close(); err != nil {
+			log.Printf("⚠ Error closing destinations for source '%s': %v", lp.config.Name, err)
 		}
 	} else {
-		// Simulation mode - just process for metrics
-		go lp.runSimulationThread()
+		lp.addDestinations()
+		lp.setSimulation(false)
+		log.Printf("✓ Source '%s' switched to live delivery", lp.config.Name)
 	}
+}
+
+// RotateAPIKey switches one of the source's HEC destinations to a new API
+// key without stopping the processor, so queued and held events are kept and
+// delivered with the new key. The key is also kept for when the destinations
+// are opened again, e.g. leaving simulation mode. It reports whether a
+// running destination took the key.
+func (lp *LogProcessor) RotateAPIKey(destID, apiKey string) bool {
+	lp.mutex.Lock()
+	defer lp.mutex.Unlock()
 	
-	log.Printf("✓ Log processor started for source '%s' (simulation: %v)", lp.config.Name, lp.config.SimulationMode)
-	return nil
+	lp.config.Destinations = destinations.ReplaceAPIKey(lp.config.Destinations, destID, apiKey)
+	return lp.destinations.SetAPIKey(lp.config.Name, destID, apiKey)
+}
+
+func (lp *LogProcessor) setSimulation(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&lp.simulation, value)
 }
 
-// Stop gracefully stops the log processor
+// Stop gracefully stops the log processor: it stops taking batches off the
+// queue, drains what is queued or held to the destinations, then flushes and
+// closes them. Stop drains even when the context was cancelled first.
 func (lp *LogProcessor) Stop() {
 	lp.mutex.Lock()
 	defer lp.mutex.Unlock()
 	
-	if !lp.isRunning {
+	if lp.cancel == nil {
 		return
 	}
 	
-	lp.isRunning = false
-	close(lp.stopChan)
+	lp.cancel()
+	lp.cancel = nil
+	
+	// Let the processing thread finish its current batch, then deliver what
+	// is still queued so a restart does not lose the last few seconds of logs
+	<-lp.doneChan
+	if !lp.deliveryPaused() {
+		lp.replayPaused(-1)
+	}
+	if lp.batcher != nil {
+		<-lp.flusherDone
+		if batch := lp.batcher.take(); batch != nil {
+			lp.enqueueBatch(batch)
+		}
+	}
+	if lp.tracer != nil {
+		<-lp.tracerDone
+	}
+	if lp.prober != nil {
+		<-lp.proberDone
+	}
+	if !lp.simulating() {
+		lp.drain()
+	}
+	lp.foldReceived()
+	if lp.tracer != nil {
+		lp.tracer.reset()
+	}
+	// Events held back on disk are delivered when the source starts again;
+	// only those held in memory are lost
+	lost, err := lp.pauseBuffer.Close()
+	if err != nil {
+		log.Printf("⚠ Error keeping held back events of source '%s': %v", lp.config.Name, err)
+	}
+	if lost > 0 {
+		log.Printf("⚠ Losing %d events held in memory while delivery of source '%s' was paused", lost, lp.config.Name)
+		lp.queue.IncrementDropped(lost)
+		lp.reconciliation.record(time.Now(), func(c *models.ReconciliationCounts) { c.Dropped += lost })
+	}
+	
+	if err := lp.destinations.Flush(); err != nil {
+		log.Printf("⚠ Error flushing destinations for source '%s': %v", lp.config.Name, err)
+	}
+	if err := lp.destinations.Close(); err != nil {
+		log.Printf("⚠ Error closing destinations for source '%s': %v", lp.config.Name, err)
+	}
+	if lp.capture != nil {
+		lp.capture.Close()
+	}
 	
 	log.Printf("✓ Log processor stopped for source '%s'", lp.config.Name)
 }
 
-// ProcessRawMessage processes a raw syslog message
-func (lp *LogProcessor) ProcessRawMessage(data []byte, sourceIP string) {
+// ProcessRawMessage processes a raw syslog message. It returns false when
+// the message was dropped on admission by a tenant or group quota.
+func (lp *LogProcessor) ProcessRawMessage(data []byte, sourceIP string, wireSize int) bool {
 	// Update last message time
 	lp.msgMutex.Lock()
 	lp.lastMessageAt = time.Now()
 	lp.msgMutex.Unlock()
 	
+	// Account for wire bytes before parsing so dropped messages are still counted
+	lp.metrics.AddWireBytes(int64(wireSize))
+	if lp.capture != nil {
+		lp.capture.write(data, sourceIP, time.Now())
+	}
+	atomic.AddInt64(&lp.unfolded[unfoldedReceived], 1)
+	
+	// Enforce tenant and group quotas
+	if lp.admit != nil && !lp.admit(len(data)) {
+		lp.queue.IncrementDropped(1)
+		atomic.AddInt64(&lp.unfolded[unfoldedDropped], 1)
+		return false
+	}
+	
 	// Parse the message into a LogEvent
+	parseStart := time.Now()
 	event := lp.parseMessage(data, sourceIP)
+	if event != nil && lp.config.TimestampSource != "" && lp.config.TimestampSource != models.TimestampReceive {
+		lp.applyDeviceTime(event, data)
+	}
+	lp.stages[StageParse].observe(parseStart, 1)
 	if event == nil {
-		return
+		atomic.AddInt64(&lp.unfolded[unfoldedDiscarded], 1)
+		return true
 	}
 	
 	// Add to current batch
 	lp.addToBatch(event)
+	return true
 }
 
 // parseMessage parses raw syslog data into a LogEvent
 func (lp *LogProcessor) parseMessage(data []byte, sourceIP string) *models.LogEvent {
 	event := &models.LogEvent{
-		Time:   time.Now().UTC(),
-		Source: lp.config.Name,
-		Size:   int64(len(data)),
+		Time:     time.Now().UTC(),
+		Source:   lp.config.Name,
+		Size:     int64(len(data)),
+		SenderIP: sourceIP,
+		Severity: ParseSeverity(data),
 	}
 	
-	// Try to parse as JSON first
-	var jsonData interface{}
-	if err := json.Unmarshal(data, &jsonData); err == nil {
-		// Valid JSON
-		event.Event = jsonData
-	} else {
-		// Treat as string
-		message := strings.TrimSpace(string(data))
-		if message == "" {
-			return nil
+	// Try to parse as JSON first. Sources without filters, aggregation or
+	// enrichment keep the raw bytes (the read buffer is reused, so they are
+	// copied) and leave decoding to destinations that need the fields.
+	if lp.decodeEvents {
+		var jsonData interface{}
+		if err := json.Unmarshal(data, &jsonData); err == nil {
+			event.Event = jsonData
+			return event
 		}
-		event.Event = message
+	} else if json.Valid(data) {
+		event.Raw = append([]byte(nil), data...)
+		return event
+	}
+	
+	// Treat as string
+	message := strings.TrimSpace(string(data))
+	if message == "" {
+		return nil
+	}
+	event.Event = message
+	if lp.config.Parser == models.ParserRFC3164 {
+		lp.applyParser(event, data)
 	}
 	
 	return event
@@ -133,8 +383,14 @@ func (lp *LogProcessor) parseMessage(data []byte, sourceIP string) *models.LogEv
 
 // addToBatch adds an event to the current batch
 func (lp *LogProcessor) addToBatch(event *models.LogEvent) {
-	// For simplicity, we'll create batches immediately
-	// In a production system, you'd accumulate events and batch them
+	if lp.batcher != nil {
+		if batch := lp.batcher.add(lp.queue, event, lp.config.IP); batch != nil {
+			lp.enqueueBatch(batch)
+		}
+		return
+	}
+	
+	// Static batching queues every event immediately
 	batch := lp.queue.GetBatch()
 	batch.Events = append(batch.Events, *event)
 	batch.SourceIP = lp.config.IP
@@ -144,6 +400,8 @@ func (lp *LogProcessor) addToBatch(event *models.LogEvent) {
 	if len(batch.Events) >= lp.batchSize {
 		if !lp.queue.Enqueue(batch) {
 			// Queue full, drop batch
+			lp.queue.IncrementDropped(int64(len(batch.Events)))
+			lp.recordQueueDrop(batch)
 			lp.queue.ReturnBatch(batch)
 			log.Printf("⚠ Queue full for source '%s', dropping batch", lp.config.Name)
 		}
@@ -151,144 +409,487 @@ func (lp *LogProcessor) addToBatch(event *models.LogEvent) {
 		// For now, enqueue single events immediately
 		// In production, you'd buffer until batch size reached
 		if !lp.queue.Enqueue(batch) {
+			lp.queue.IncrementDropped(int64(len(batch.Events)))
+			lp.recordQueueDrop(batch)
 			lp.queue.ReturnBatch(batch)
 		}
 	}
 }
 
-// runSimulationThread processes events in simulation mode (metrics only)
-func (lp *LogProcessor) runSimulationThread() {
-	ticker := time.NewTicker(1 * time.Second)
+// enqueueBatch queues a batch built by the adaptive batcher, dropping it when
+// the queue is full
+func (lp *LogProcessor) enqueueBatch(batch *models.LogBatch) {
+	if lp.queue.Enqueue(batch) {
+		return
+	}
+	lp.queue.IncrementDropped(int64(len(batch.Events)))
+	lp.recordQueueDrop(batch)
+	log.Printf("⚠ Queue full for source '%s', dropping batch of %d events", lp.config.Name, len(batch.Events))
+	lp.queue.ReturnBatch(batch)
+}
+
+// runBatchFlusher queues adaptive batches that reached their flush interval
+// and resizes batches to the queue depth, restarting if it panics
+func (lp *LogProcessor) runBatchFlusher() {
+	defer close(lp.flusherDone)
+	recovery.Supervise(fmt.Sprintf("batch flusher of source '%s'", lp.config.Name), &lp.panics, lp.flushLoop)
+}
+
+// flushLoop checks the adaptive batcher every batchFlushTick until stopped
+func (lp *LogProcessor) flushLoop() {
+	ticker := time.NewTicker(batchFlushTick)
 	defer ticker.Stop()
+	lastAdapt := time.Now()
 	
 	for {
 		select {
-		case <-lp.stopChan:
+		case <-lp.ctx.Done():
 			return
-		case <-ticker.C:
-			// Process batches for metrics only
-			var totalLogs, totalSize int64
-			
-			for {
-				batch := lp.queue.Dequeue()
-				if batch == nil {
-					break
-				}
-				
-				batchLogs := int64(len(batch.Events))
-				var batchSize int64
-				for _, event := range batch.Events {
-					batchSize += event.Size
-				}
-				
-				totalLogs += batchLogs
-				totalSize += batchSize
-				
-				lp.queue.IncrementProcessed(batchLogs)
-				lp.queue.ReturnBatch(batch)
+		case now := <-ticker.C:
+			if batch := lp.batcher.due(now); batch != nil {
+				lp.enqueueBatch(batch)
 			}
-			
-			if totalLogs > 0 {
-				lp.metrics.RecordMetrics(totalLogs, totalSize, totalLogs, 0)
+			if now.Sub(lastAdapt) >= batchAdaptEvery {
+				lp.batcher.adapt(float64(lp.queue.GetStats().Depth) / float64(lp.queue.GetCapacity()))
+				lastAdapt = now
 			}
 		}
 	}
 }
 
-// runFilteringThread processes events with filtering and aggregation
-func (lp *LogProcessor) runFilteringThread() {
+// runProcessingThread takes batches off the queue and sends each down the
+// path of the current mode: filtering, aggregation and delivery, or in
+// simulation mode counting only. The thread is restarted if it panics.
+func (lp *LogProcessor) runProcessingThread() {
+	defer close(lp.doneChan)
+	recovery.Supervise(fmt.Sprintf("processor of source '%s'", lp.config.Name), &lp.panics, lp.processLoop)
+}
+
+// processLoop processes batches until stopped
+func (lp *LogProcessor) processLoop() {
 	for {
 		select {
-		case <-lp.stopChan:
+		case <-lp.ctx.Done():
 			return
 		default:
-			batch := lp.queue.Dequeue()
-			if batch == nil {
-				time.Sleep(10 * time.Millisecond)
+			// Deliver what was held back while paused before anything newer
+			if !lp.deliveryPaused() && lp.pauseBuffer.Pending() {
+				lp.onPath(func() { lp.replayPaused(1) })
 				continue
 			}
 			
-			// Apply filtering
-			filteredEvents := lp.filterEngine.ProcessBatch(batch.Events)
-			
-			// Apply aggregation
-			processedEvents := lp.aggregator.ProcessBatch(filteredEvents)
-			
-			// Record metrics
-			batchLogs := int64(len(batch.Events))
-			processedLogs := int64(len(processedEvents))
-			var batchSize int64
-			for _, event := range batch.Events {
-				batchSize += event.Size
+			batch := lp.queue.Dequeue()
+			if batch == nil {
+				// Emit aggregation windows that closed while the source was quiet
+				lp.onPath(func() {
+					if !lp.simulating() {
+						if closed := lp.aggregator.FlushClosedWindows(); len(closed) > 0 {
+							lp.queue.IncrementProcessed(int64(len(closed)))
+							lp.deliver(closed, lp.config.IP, time.Now())
+						}
+					}
+				})
+			} else {
+				// The batch in progress is the oldest; once it is done the
+				// next one dequeued takes over, unless the queue is empty
+				atomic.StoreInt64(&lp.backlogSince, batch.Timestamp.UnixNano())
+				lp.handleBatch(batch)
+				if lp.queue.Depth() == 0 {
+					atomic.StoreInt64(&lp.backlogSince, 0)
+				}
 			}
 			
-			lp.metrics.RecordMetrics(batchLogs, batchSize, processedLogs, 0)
-			lp.queue.IncrementProcessed(processedLogs)
-			
-			// Create processed batch for destinations
-			if len(processedEvents) > 0 {
-				processedBatch := lp.queue.GetBatch()
-				processedBatch.Events = processedEvents
-				processedBatch.SourceIP = batch.SourceIP
-				processedBatch.Timestamp = batch.Timestamp
-				
-				// Send to destination queues (simplified - in production you'd have separate destination queues)
-				// For now, we'll just mark as sent
-				lp.queue.IncrementSent(int64(len(processedEvents)))
-				lp.queue.ReturnBatch(processedBatch)
+			if batch == nil {
+				time.Sleep(10 * time.Millisecond)
 			}
-			
-			lp.queue.ReturnBatch(batch)
 		}
 	}
 }
 
-// runDestinationThread processes events for a specific destination
-func (lp *LogProcessor) runDestinationThread(dest models.Destination) {
-	log.Printf("✓ Started destination thread for '%s' (%s)", dest.Name, dest.Type)
+// onPath runs fn holding pathMutex, releasing it even if fn panics
+func (lp *LogProcessor) onPath(fn func()) {
+	lp.pathMutex.Lock()
+	defer lp.pathMutex.Unlock()
+	fn()
+}
+
+// handleBatch sends a batch down the path of the current mode. A batch whose
+// processing panics is counted as dropped and the next batch is processed.
+func (lp *LogProcessor) handleBatch(batch *models.LogBatch) {
+	lp.foldReceived()
+	count := int64(len(batch.Events))
+	err := recovery.Guard(fmt.Sprintf("processor of source '%s'", lp.config.Name), &lp.panics, func() error {
+		lp.onPath(func() {
+			if batch.Trace != nil {
+				lp.processTracer(batch)
+			} else if lp.simulating() {
+				lp.simulateBatch(batch)
+			} else {
+				lp.processBatch(batch)
+			}
+		})
+		return nil
+	})
+	if err != nil && batch.Trace != nil {
+		lp.tracer.lose(batch.Trace.Injected)
+	} else if err != nil {
+		lp.queue.IncrementDropped(count)
+		lp.reconciliation.record(time.Now(), func(c *models.ReconciliationCounts) { c.Dropped += count })
+		log.Printf("⚠ Dropped batch of %d events of source '%s' after a panic", count, lp.config.Name)
+	}
+}
+
+// simulateBatch counts a batch for the metrics without delivering it
+func (lp *LogProcessor) simulateBatch(batch *models.LogBatch) {
+	batchLogs := int64(len(batch.Events))
+	var batchSize int64
+	for _, event := range batch.Events {
+		batchSize += event.Size
+	}
 	
-	// This is a simplified implementation
-	// In production, you'd have separate queues per destination
+	lp.samples.record(batch.Events)
+	lp.queue.IncrementProcessed(batchLogs)
+	lp.reconciliation.record(batch.Timestamp, func(c *models.ReconciliationCounts) { c.Processed += batchLogs })
+	lp.metrics.RecordMetrics(batchLogs, batchSize, batchLogs, 0)
+	lp.queue.ReturnBatch(batch)
+}
+
+// drain processes the batches left in the queue and delivers the aggregation
+// windows still open; called on stop after the processing thread has exited
+func (lp *LogProcessor) drain() {
+	drained := 0
 	for {
-		select {
-		case <-lp.stopChan:
+		batch := lp.queue.Dequeue()
+		if batch == nil {
+			break
+		}
+		if batch.Trace != nil {
+			lp.processTracer(batch)
+			continue
+		}
+		drained += len(batch.Events)
+		lp.processBatch(batch)
+	}
+	
+	if open := lp.aggregator.Drain(); len(open) > 0 {
+		lp.queue.IncrementProcessed(int64(len(open)))
+		lp.deliver(open, lp.config.IP, time.Now())
+	}
+	
+	if drained > 0 {
+		log.Printf("✓ Drained %d queued events for source '%s'", drained, lp.config.Name)
+	}
+}
+
+// processBatch runs a batch through the pipeline and delivers the result
+func (lp *LogProcessor) processBatch(batch *models.LogBatch) {
+	// Drop events below the source's minimum severity before any further work
+	events := lp.applySeverityPolicy(batch.Events)
+	
+	// Join events against lookup tables so filters and aggregation see the added fields
+	if lp.lookup != nil && len(lp.config.Enrichments) > 0 {
+		start := time.Now()
+		for i := range events {
+			enrichment.Apply(&events[i], lp.config.Enrichments, lp.lookup)
+		}
+		lp.stages[StageEnrich].observe(start, len(events))
+	}
+	
+	// Apply filtering
+	lp.samples.record(events)
+	start := time.Now()
+	filteredEvents := lp.filterEngine.ProcessBatch(events)
+	lp.stages[StageFilter].observe(start, len(events))
+	
+	// Apply aggregation
+	start = time.Now()
+	processedEvents := lp.aggregator.ProcessBatch(filteredEvents)
+	lp.stages[StageAggregate].observe(start, len(filteredEvents))
+	
+	// Record metrics
+	batchLogs := int64(len(batch.Events))
+	processedLogs := int64(len(processedEvents))
+	var batchSize int64
+	for _, event := range batch.Events {
+		batchSize += event.Size
+	}
+	
+	lp.metrics.RecordMetrics(batchLogs, batchSize, processedLogs, 0)
+	lp.queue.IncrementProcessed(processedLogs)
+	
+	// Account the batch against the minute it was received in
+	lp.reconciliation.record(batch.Timestamp, func(c *models.ReconciliationCounts) {
+		c.SeverityDropped += batchLogs - int64(len(events))
+		c.Filtered += int64(len(events) - len(filteredEvents))
+		c.Processed += int64(len(filteredEvents))
+	})
+	
+	if len(processedEvents) > 0 {
+		lp.deliver(processedEvents, batch.SourceIP, batch.Timestamp)
+	}
+	
+	lp.queue.ReturnBatch(batch)
+}
+
+// applySeverityPolicy removes events less severe than the configured minimum.
+// Events without a PRI header are always kept.
+func (lp *LogProcessor) applySeverityPolicy(events []models.LogEvent) []models.LogEvent {
+	if lp.minSeverity < 0 {
+		return events
+	}
+	
+	kept := events[:0:0]
+	for _, event := range events {
+		if event.Severity > lp.minSeverity {
+			atomic.AddInt64(&lp.severityDrops[event.Severity], 1)
+			continue
+		}
+		kept = append(kept, event)
+	}
+	return kept
+}
+
+// getSeverityDrops returns the events dropped by the severity policy, in
+// total and per severity keyword
+func (lp *LogProcessor) getSeverityDrops() (int64, map[string]int64) {
+	if lp.minSeverity < 0 {
+		return 0, nil
+	}
+	
+	var total int64
+	bySeverity := make(map[string]int64)
+	for code := range lp.severityDrops {
+		if count := atomic.LoadInt64(&lp.severityDrops[code]); count > 0 {
+			bySeverity[models.SeverityNames[code]] = count
+			total += count
+		}
+	}
+	return total, bySeverity
+}
+
+// SetDeliveryPaused pauses or resumes delivery. While paused the source keeps
+// receiving, filtering and counting, and processed events are held back up
+// to the pause buffer limits; resuming delivers them in order before any
+// newer events.
+func (lp *LogProcessor) SetDeliveryPaused(paused bool) {
+	var value int32
+	if paused {
+		value = 1
+	}
+	if atomic.SwapInt32(&lp.paused, value) == value {
+		return
+	}
+	
+	if paused {
+		log.Printf("✓ Delivery paused for source '%s'", lp.config.Name)
+	} else {
+		log.Printf("✓ Delivery resumed for source '%s'", lp.config.Name)
+	}
+}
+
+// DeliveryPaused returns whether delivery is paused
+func (lp *LogProcessor) DeliveryPaused() bool {
+	return lp.deliveryPaused()
+}
+
+// deliveryPaused returns whether processed events are held back
+func (lp *LogProcessor) deliveryPaused() bool {
+	return atomic.LoadInt32(&lp.paused) == 1
+}
+
+// replayPaused delivers up to chunks of pauseReplayChunk held back events,
+// or all of them when chunks is negative
+func (lp *LogProcessor) replayPaused(chunks int) {
+	for ; chunks != 0; chunks-- {
+		events, err := lp.pauseBuffer.Next(pauseReplayChunk)
+		if err != nil {
+			log.Printf("⚠ Error reading held back events of source '%s': %v", lp.config.Name, err)
+		}
+		if len(events) == 0 {
 			return
-		default:
-			// Destination-specific processing would go here
-			time.Sleep(100 * time.Millisecond)
 		}
+		lp.sendToDestinations(events, lp.config.IP, time.Now())
+	}
+}
+
+// deliver sends processed events to all destinations configured for this
+// source, or holds them back while delivery is paused. Events are stamped
+// with their IDs and classification here, so events held back keep them
+// when replayed.
+func (lp *LogProcessor) deliver(events []models.LogEvent, sourceIP string, timestamp time.Time) {
+	lp.stampEvents(events)
+	if lp.deliveryPaused() {
+		refused, err := lp.pauseBuffer.Add(events)
+		if err != nil {
+			log.Printf("⚠ Error holding back events of source '%s': %v", lp.config.Name, err)
+		}
+		if refused > 0 {
+			dropped := int64(refused)
+			lp.queue.IncrementDropped(dropped)
+			lp.reconciliation.record(time.Now(), func(c *models.ReconciliationCounts) { c.Dropped += dropped })
+		}
+		return
+	}
+	lp.sendToDestinations(events, sourceIP, timestamp)
+}
+
+// stampEvents gives events the IDs and classification labels destinations
+// route and deduplicate by
+func (lp *LogProcessor) stampEvents(events []models.LogEvent) {
+	if lp.eventIDs != nil {
+		lp.eventIDs.stamp(events)
+	}
+	if labels := lp.config.Classification; len(labels) > 0 {
+		for i := range events {
+			events[i].Classification = labels
+		}
+	}
+}
+
+// sendToDestinations hands events to the destinations and accounts the
+// outcome. The events may share memory with a pooled batch, so they are
+// wrapped in a batch of their own that is never returned to the pool.
+func (lp *LogProcessor) sendToDestinations(events []models.LogEvent, sourceIP string, timestamp time.Time) {
+	processedBatch := &models.LogBatch{
+		Events:    events,
+		SourceIP:  sourceIP,
+		Timestamp: timestamp,
+	}
+	
+	count := int64(len(events))
+	start := time.Now()
+	err := lp.destinations.ProcessBatch(processedBatch, lp.config.Name)
+	lp.stages[StageDeliver].observe(start, len(events))
+	if err != nil {
+		lp.queue.IncrementDestinationErrors(1)
+		lp.reconciliation.record(time.Now(), func(c *models.ReconciliationCounts) { c.Failed += count })
+	} else {
+		lp.queue.IncrementSent(count)
+		lp.reconciliation.record(time.Now(), func(c *models.ReconciliationCounts) { c.Sent += count })
 	}
 }
 
+// Indexes of the per-message counts in unfolded
+const (
+	unfoldedReceived = iota
+	unfoldedDropped
+	unfoldedDiscarded
+)
+
+// foldReceived moves the messages counted on receipt into the reconciliation
+// ledger. Receipt only counts atomically so the ledger is locked once per
+// batch rather than once per message.
+func (lp *LogProcessor) foldReceived() {
+	received := atomic.SwapInt64(&lp.unfolded[unfoldedReceived], 0)
+	dropped := atomic.SwapInt64(&lp.unfolded[unfoldedDropped], 0)
+	discarded := atomic.SwapInt64(&lp.unfolded[unfoldedDiscarded], 0)
+	if received == 0 && dropped == 0 && discarded == 0 {
+		return
+	}
+	lp.reconciliation.record(time.Now(), func(c *models.ReconciliationCounts) {
+		c.Received += received
+		c.Dropped += dropped
+		c.Discarded += discarded
+	})
+}
+
+// recordQueueDrop accounts a batch dropped because the queue was full
+func (lp *LogProcessor) recordQueueDrop(batch *models.LogBatch) {
+	dropped := int64(len(batch.Events))
+	lp.reconciliation.record(batch.Timestamp, func(c *models.ReconciliationCounts) { c.Dropped += dropped })
+}
+
+// GetReconciliation returns the delivery accounting between from and to
+func (lp *LogProcessor) GetReconciliation(from, to time.Time) models.ReconciliationCounts {
+	lp.foldReceived()
+	return lp.reconciliation.Sum(from, to)
+}
+
+// GetHistory returns the delivery accounting between from and to in intervals of step
+func (lp *LogProcessor) GetHistory(from, to time.Time, step time.Duration) []models.HistoryPoint {
+	lp.foldReceived()
+	return lp.reconciliation.Series(from, to, step)
+}
+
+// GetSamples returns recent events as the source's filters saw them
+func (lp *LogProcessor) GetSamples() []models.LogEvent {
+	return lp.samples.snapshot()
+}
+
 // GetMetrics returns current metrics for this processor
 func (lp *LogProcessor) GetMetrics() models.SourceMetrics {
 	lp.msgMutex.RLock()
 	lastMsgTime := lp.lastMessageAt
 	lp.msgMutex.RUnlock()
 	
-	lp.mutex.RLock()
-	isActive := lp.isRunning
-	lp.mutex.RUnlock()
+	isActive := lp.IsRunning()
 	
 	isReceiving := !lastMsgTime.IsZero() && time.Since(lastMsgTime) < 10*time.Second
 	queueStats := lp.queue.GetStats()
 	
-	return lp.metrics.CalculateMetrics(
+	metrics := lp.metrics.CalculateMetrics(
 		lp.config.Name,
 		lp.config.IP,
 		lp.config.Port,
 		lp.config.Protocol,
-		lp.config.SimulationMode,
+		lp.simulating(),
 		queueStats,
 		isActive,
 		isReceiving,
 		lastMsgTime,
 	)
+	metrics.Tenant = lp.config.Tenant
+	metrics.Group = lp.config.Group
+	metrics.Classification = lp.config.Classification
+	metrics.Tags = lp.config.Tags
+	metrics.AggregationOverflows = lp.aggregator.GetOverflows()
+	metrics.Panics = atomic.LoadInt64(&lp.panics) + lp.destinations.GetPanics()
+	metrics.FilterErrors = lp.filterEngine.Errors()
+	metrics.LogMetrics = lp.aggregator.GetMetricSamples()
+	metrics.SeverityDropped, metrics.DroppedBySeverity = lp.getSeverityDrops()
+	metrics.TimestampFallbacks = atomic.LoadInt64(&lp.timeFallbacks)
+	metrics.ParseFailures = atomic.LoadInt64(&lp.parseFailures)
+	metrics.EventsByFacility, metrics.EventsBySeverity = lp.getParsedCounts()
+	if lp.capture != nil {
+		metrics.RawCapture = lp.capture.snapshot()
+	}
+	metrics.Destinations = lp.destinations.GetStats()
+	for i := range metrics.Destinations {
+		if !metrics.Destinations[i].MeteredSince.IsZero() {
+			metrics.Destinations[i].IngestedBytes = metrics.TotalEventBytes - atomic.LoadInt64(&lp.meterBase)
+		}
+	}
+	metrics.Stages = lp.stages.snapshot()
+	if lp.tracer != nil {
+		metrics.Latency = lp.tracer.snapshot(time.Now())
+	}
+	if lp.prober != nil {
+		metrics.Probe = lp.prober.snapshot(lp.silent(time.Now()))
+	}
+	held := lp.pauseBuffer.Stats()
+	metrics.DeliveryPaused = lp.deliveryPaused()
+	metrics.HeldEvents = held.InMemory + held.OnDisk
+	metrics.HeldBytesOnDisk = held.DiskBytes
+	metrics.HeldRefused = held.Refused
+	if !held.Oldest.IsZero() {
+		metrics.HeldAgeSeconds = time.Since(held.Oldest).Seconds()
+	}
+	if since := atomic.LoadInt64(&lp.backlogSince); since != 0 {
+		metrics.QueueAgeSeconds = time.Since(time.Unix(0, since)).Seconds()
+	}
+	metrics.BatchingMode = BatchingStatic
+	metrics.EffectiveBatchSize = 1
+	if lp.batcher != nil {
+		metrics.BatchingMode = BatchingAdaptive
+		metrics.EffectiveBatchSize, metrics.EffectiveFlushMs = lp.batcher.effective()
+	}
+	
+	return metrics
 }
 
 // IsRunning returns whether the processor is currently running
 func (lp *LogProcessor) IsRunning() bool {
 	lp.mutex.RLock()
 	defer lp.mutex.RUnlock()
-	return lp.isRunning
-}
\ No newline at end of file
+	return lp.cancel != nil && lp.ctx.Err() == nil
+}
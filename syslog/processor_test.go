@@ -0,0 +1,132 @@
+package syslog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	
+	"syslog-analyzer/models"
+)
+
+// benchMessages builds JSON messages of roughly size bytes
+func benchMessages(count, size int) [][]byte {
+	messages := make([][]byte, count)
+	for i := range messages {
+		message := fmt.Sprintf(`{"host":"host-%02d","action":"%s","user":"user-%d","bytes":%d,"message":"request handled","padding":""}`,
+			i%50, []string{"login", "logout", "read", "write"}[i%4], i%200, 100+i*7)
+		if pad := size - len(message); pad > 0 {
+			message = strings.Replace(message, `"padding":""`, `"padding":"`+strings.Repeat("x", pad)+`"`, 1)
+		}
+		messages[i] = []byte(message)
+	}
+	return messages
+}
+
+// TestReconciliationCountsReceipt checks that messages counted on receipt
+// reach the ledger, whether they were delivered or dropped by a quota
+func TestReconciliationCountsReceipt(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	
+	processor := NewLogProcessor(models.SourceConfig{
+		Name:     "reconcile",
+		IP:       "127.0.0.1",
+		Protocol: "UDP",
+		Destinations: []models.Destination{
+			{ID: "null", Name: "null", Type: "null", Enabled: true},
+		},
+	}, 10)
+	admitted := 0
+	processor.admit = func(size int) bool {
+		admitted++
+		return admitted%10 != 0
+	}
+	processor.Start(context.Background())
+	for _, message := range benchMessages(100, 128) {
+		processor.ProcessRawMessage(message, "127.0.0.1", len(message))
+	}
+	processor.Stop()
+	
+	counts := processor.GetReconciliation(time.Now().Add(-time.Minute), time.Now().Add(time.Minute))
+	if counts.Received != 100 || counts.Dropped != 10 || counts.Processed != 90 || counts.Sent != 90 {
+		t.Fatalf("got %+v, want 100 received, 10 dropped, 90 processed and sent", counts)
+	}
+}
+
+// TestPauseBufferSurvivesRestart checks that events held back on disk by a
+// paused processor are delivered by the next processor of the source, and
+// that only those held in memory are lost
+func TestPauseBufferSurvivesRestart(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	previous := GetPauseBuffer()
+	if err := SetPauseBuffer(models.PauseBufferConfig{MemoryEvents: 5, MaxDiskMB: 1, Dir: t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetPauseBuffer(previous)
+	
+	config := models.SourceConfig{
+		Name:     "paused",
+		IP:       "127.0.0.1",
+		Protocol: "UDP",
+		Destinations: []models.Destination{
+			{ID: "null", Name: "null", Type: "null", Enabled: true},
+		},
+	}
+	first := NewLogProcessor(config, 10)
+	first.SetDeliveryPaused(true)
+	first.Start(context.Background())
+	for _, message := range benchMessages(50, 128) {
+		first.ProcessRawMessage(message, "127.0.0.1", len(message))
+	}
+	first.Stop()
+	if counts := first.GetReconciliation(time.Now().Add(-time.Minute), time.Now().Add(time.Minute)); counts.Sent != 0 || counts.Dropped != 5 {
+		t.Fatalf("paused processor: got %+v, want nothing sent and the 5 events in memory dropped", counts)
+	}
+	
+	second := NewLogProcessor(config, 10)
+	if held := second.pauseBuffer.Stats(); held.OnDisk != 45 {
+		t.Fatalf("restarted processor holds %d events on disk, want 45", held.OnDisk)
+	}
+	second.Start(context.Background())
+	deadline := time.Now().Add(5 * time.Second)
+	for second.pauseBuffer.Pending() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	second.Stop()
+	if counts := second.GetReconciliation(time.Now().Add(-time.Minute), time.Now().Add(time.Minute)); counts.Sent != 45 {
+		t.Fatalf("restarted processor: got %+v, want the 45 events held on disk sent", counts)
+	}
+}
+
+// BenchmarkProcessRawMessage takes messages through a processor delivering
+// to a null destination
+func BenchmarkProcessRawMessage(b *testing.B) {
+	// Keep the processor's log lines out of the benchmark output
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	
+	processor := NewLogProcessor(models.SourceConfig{
+		Name:     "bench",
+		IP:       "127.0.0.1",
+		Protocol: "UDP",
+		Destinations: []models.Destination{
+			{ID: "null", Name: "null", Type: "null", Enabled: true},
+		},
+	}, 1000)
+	processor.Start(context.Background())
+	defer processor.Stop()
+	messages := benchMessages(100, 256)
+	b.ReportAllocs()
+	b.ResetTimer()
+	
+	for i := 0; i < b.N; i++ {
+		message := messages[i%len(messages)]
+		processor.ProcessRawMessage(message, "127.0.0.1", len(message))
+	}
+}
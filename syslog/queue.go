@@ -1,6 +1,7 @@
 package syslog
 
 import (
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -8,44 +9,102 @@ import (
 	"syslog-analyzer/models"
 )
 
-// LogQueue implements a thread-safe queue for log batches
+// Queue implementations selectable through GlobalSettings.QueueType
+const (
+	QueueChannel = "channel" // Buffered channel with pooled batches
+	QueueRing    = "ring"    // Lock-free ring buffer with a recycled batch free list
+)
+
+// queueType is the implementation used by queues created from now on
+var queueType atomic.Value
+
+func init() {
+	queueType.Store(QueueChannel)
+}
+
+// SetQueueType selects the queue implementation of sources started after the
+// call; an empty type selects the channel queue
+func SetQueueType(kind string) error {
+	if kind == "" {
+		kind = QueueChannel
+	}
+	if kind != QueueChannel && kind != QueueRing {
+		return fmt.Errorf("unknown queue type %q (expected %s or %s)", kind, QueueChannel, QueueRing)
+	}
+	queueType.Store(kind)
+	return nil
+}
+
+// GetQueueType returns the queue implementation new sources use
+func GetQueueType() string {
+	return queueType.Load().(string)
+}
+
+// batchEventCapacity is the event slice preallocated for every pooled batch
+const batchEventCapacity = 1000
+
+// maxPooledEventCapacity keeps batches whose event slice grew past it out of
+// the pool, so one oversized batch does not pin its memory
+const maxPooledEventCapacity = 16 * batchEventCapacity
+
+// LogQueue implements a thread-safe queue for log batches. Batches travel
+// through a buffered channel or, with the ring queue type, a lock-free ring
+// buffer; both keep the same counters.
+//
+// Only whole batches are pooled: a batch owns its event slice, and a batch
+// handed to ReturnBatch, including the events in its slice, must no longer
+// be used by the caller.
 type LogQueue struct {
 	batches     chan *models.LogBatch
+	ring        *ringBuffer // Replaces batches when set
+	freeBatches *ringBuffer // Recycled batches of the ring queue; replaces the pools
 	processed   int64
 	sent        int64
+	dropped     int64
+	destErrors  int64
 	depth       int64
-	batchPool   sync.Pool
-	eventPool   sync.Pool
+	batchPool   sync.Pool // Recycled batches of the channel queue
 	maxCapacity int
 }
 
-// NewLogQueue creates a new log queue
+// NewLogQueue creates a new log queue of the selected queue type
 func NewLogQueue(capacity int) *LogQueue {
 	queue := &LogQueue{
-		batches:     make(chan *models.LogBatch, capacity),
 		maxCapacity: capacity,
 	}
-	
-	// Initialize object pools for memory efficiency
-	queue.batchPool = sync.Pool{
-		New: func() interface{} {
-			return &models.LogBatch{
-				Events: make([]models.LogEvent, 0, 1000),
-			}
-		},
-	}
-	
-	queue.eventPool = sync.Pool{
-		New: func() interface{} {
-			return &models.LogEvent{}
-		},
+	if GetQueueType() == QueueRing {
+		queue.ring = newRingBuffer(capacity)
+		// Batches in flight: queued, being filled and being processed
+		queue.freeBatches = newRingBuffer(capacity + 16)
+	} else {
+		queue.batches = make(chan *models.LogBatch, capacity)
+		queue.batchPool = sync.Pool{
+			New: func() interface{} {
+				return newBatch()
+			},
+		}
 	}
 	
 	return queue
 }
 
+// newBatch creates an empty batch with a preallocated event slice
+func newBatch() *models.LogBatch {
+	return &models.LogBatch{
+		Events: make([]models.LogEvent, 0, batchEventCapacity),
+	}
+}
+
 // Enqueue adds a batch to the queue
 func (q *LogQueue) Enqueue(batch *models.LogBatch) bool {
+	if q.ring != nil {
+		if !q.ring.push(batch) {
+			return false
+		}
+		atomic.AddInt64(&q.depth, 1)
+		return true
+	}
+	
 	select {
 	case q.batches <- batch:
 		atomic.AddInt64(&q.depth, 1)
@@ -58,6 +117,14 @@ func (q *LogQueue) Enqueue(batch *models.LogBatch) bool {
 
 // Dequeue removes and returns a batch from the queue
 func (q *LogQueue) Dequeue() *models.LogBatch {
+	if q.ring != nil {
+		batch := q.ring.pop()
+		if batch != nil {
+			atomic.AddInt64(&q.depth, -1)
+		}
+		return batch
+	}
+	
 	select {
 	case batch := <-q.batches:
 		atomic.AddInt64(&q.depth, -1)
@@ -67,33 +134,38 @@ func (q *LogQueue) Dequeue() *models.LogBatch {
 	}
 }
 
-// GetBatch gets a batch from the pool
+// GetBatch gets an empty batch from the pool
 func (q *LogQueue) GetBatch() *models.LogBatch {
-	batch := q.batchPool.Get().(*models.LogBatch)
-	batch.Events = batch.Events[:0] // Reset slice
-	return batch
+	if q.freeBatches != nil {
+		if batch := q.freeBatches.pop(); batch != nil {
+			return batch
+		}
+		return newBatch()
+	}
+	return q.batchPool.Get().(*models.LogBatch)
 }
 
-// ReturnBatch returns a batch to the pool
+// ReturnBatch resets a batch and returns it to the pool. The batch must have
+// been taken from GetBatch and must not be used afterwards.
 func (q *LogQueue) ReturnBatch(batch *models.LogBatch) {
-	// Return events to pool
+	if cap(batch.Events) > maxPooledEventCapacity {
+		return
+	}
+	
+	// Clear the events so pooled batches do not keep parsed messages alive
 	for i := range batch.Events {
-		q.eventPool.Put(&batch.Events[i])
+		batch.Events[i] = models.LogEvent{}
+	}
+	*batch = models.LogBatch{Events: batch.Events[:0]}
+	
+	if q.freeBatches != nil {
+		// A batch that does not fit the free list is left to the GC
+		q.freeBatches.push(batch)
+		return
 	}
 	q.batchPool.Put(batch)
 }
 
-// GetEvent gets an event from the pool
-func (q *LogQueue) GetEvent() *models.LogEvent {
-	return q.eventPool.Get().(*models.LogEvent)
-}
-
-// ReturnEvent returns an event to the pool
-func (q *LogQueue) ReturnEvent(event *models.LogEvent) {
-	*event = models.LogEvent{} // Reset
-	q.eventPool.Put(event)
-}
-
 // IncrementProcessed increments the processed counter
 func (q *LogQueue) IncrementProcessed(count int64) {
 	atomic.AddInt64(&q.processed, count)
@@ -104,16 +176,33 @@ func (q *LogQueue) IncrementSent(count int64) {
 	atomic.AddInt64(&q.sent, count)
 }
 
+// IncrementDropped increments the dropped counter
+func (q *LogQueue) IncrementDropped(count int64) {
+	atomic.AddInt64(&q.dropped, count)
+}
+
+// IncrementDestinationErrors increments the destination delivery error counter
+func (q *LogQueue) IncrementDestinationErrors(count int64) {
+	atomic.AddInt64(&q.destErrors, count)
+}
+
 // GetStats returns current queue statistics
 func (q *LogQueue) GetStats() models.QueueStats {
 	return models.QueueStats{
 		Depth:      atomic.LoadInt64(&q.depth),
 		Processed:  atomic.LoadInt64(&q.processed),
 		Sent:       atomic.LoadInt64(&q.sent),
+		Dropped:    atomic.LoadInt64(&q.dropped),
+		DestErrors: atomic.LoadInt64(&q.destErrors),
 		LastUpdate: time.Now(),
 	}
 }
 
+// Depth returns the number of queued batches
+func (q *LogQueue) Depth() int64 {
+	return atomic.LoadInt64(&q.depth)
+}
+
 // GetCapacity returns the queue capacity
 func (q *LogQueue) GetCapacity() int {
 	return q.maxCapacity
@@ -0,0 +1,185 @@
+package syslog
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+	
+	"syslog-analyzer/models"
+)
+
+var queueTypes = []string{QueueChannel, QueueRing}
+
+// newTestQueue creates a queue of the given type, restoring the selected
+// type when the test ends
+func newTestQueue(t *testing.T, kind string, capacity int) *LogQueue {
+	t.Helper()
+	previous := GetQueueType()
+	if err := SetQueueType(kind); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetQueueType(previous) })
+	return NewLogQueue(capacity)
+}
+
+// fillBatch adds n events referencing memory a pooled batch must not keep alive
+func fillBatch(batch *models.LogBatch, n int) {
+	for i := 0; i < n; i++ {
+		batch.Events = append(batch.Events, models.LogEvent{
+			Time:     time.Now(),
+			Event:    map[string]interface{}{"message": "test"},
+			Source:   "test",
+			ID:       "id",
+			SenderIP: "10.0.0.1",
+			Severity: 6,
+			Raw:      []byte(`{"message":"test"}`),
+		})
+	}
+	batch.SourceIP = "10.0.0.1"
+	batch.Timestamp = time.Now()
+	batch.Trace = &models.Trace{Injected: time.Now()}
+}
+
+// getReturned returns batch from the queue's pool. sync.Pool may drop items,
+// and does so at random under the race detector, so the channel queue is
+// asked a few times.
+func getReturned(q *LogQueue, batch *models.LogBatch) bool {
+	for i := 0; i < 100; i++ {
+		got := q.GetBatch()
+		if got == batch {
+			return true
+		}
+		q.ReturnBatch(got)
+		q.ReturnBatch(batch)
+	}
+	return false
+}
+
+func TestReturnBatchReuse(t *testing.T) {
+	for _, kind := range queueTypes {
+		t.Run(kind, func(t *testing.T) {
+			q := newTestQueue(t, kind, 8)
+			batch := q.GetBatch()
+			fillBatch(batch, 10)
+			q.ReturnBatch(batch)
+	
+			if !getReturned(q, batch) {
+				t.Fatal("returned batch was never handed out again")
+			}
+			if len(batch.Events) != 0 || cap(batch.Events) < batchEventCapacity {
+				t.Fatalf("reused batch has %d events and capacity %d", len(batch.Events), cap(batch.Events))
+			}
+			if batch.SourceIP != "" || !batch.Timestamp.IsZero() || batch.Trace != nil {
+				t.Fatalf("reused batch kept its fields: %+v", batch)
+			}
+		})
+	}
+}
+
+func TestReturnBatchClearsEvents(t *testing.T) {
+	for _, kind := range queueTypes {
+		t.Run(kind, func(t *testing.T) {
+			q := newTestQueue(t, kind, 8)
+			batch := q.GetBatch()
+			fillBatch(batch, 10)
+			events := batch.Events
+			q.ReturnBatch(batch)
+	
+			for i, event := range events {
+				if !reflect.DeepEqual(event, models.LogEvent{}) {
+					t.Fatalf("event %d was not cleared: %+v", i, event)
+				}
+			}
+		})
+	}
+}
+
+func TestReturnBatchRejectsOversizedSlices(t *testing.T) {
+	for _, kind := range queueTypes {
+		t.Run(kind, func(t *testing.T) {
+			q := newTestQueue(t, kind, 8)
+			oversized := &models.LogBatch{Events: make([]models.LogEvent, 0, maxPooledEventCapacity+1)}
+			q.ReturnBatch(oversized)
+	
+			for i := 0; i < 100; i++ {
+				batch := q.GetBatch()
+				if batch == oversized {
+					t.Fatal("oversized batch was pooled")
+				}
+				if cap(batch.Events) > maxPooledEventCapacity {
+					t.Fatalf("pooled batch has capacity %d", cap(batch.Events))
+				}
+				q.ReturnBatch(batch)
+			}
+		})
+	}
+}
+
+// TestQueueConcurrentReuse recycles batches between producers and a consumer
+// the way listeners and the processing thread do; run it with -race
+func TestQueueConcurrentReuse(t *testing.T) {
+	for _, kind := range queueTypes {
+		t.Run(kind, func(t *testing.T) {
+			q := newTestQueue(t, kind, 64)
+			const producers, perProducer = 4, 500
+	
+			var wg sync.WaitGroup
+			for p := 0; p < producers; p++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < perProducer; i++ {
+						batch := q.GetBatch()
+						if len(batch.Events) != 0 {
+							t.Errorf("batch from the pool holds %d events", len(batch.Events))
+						}
+						fillBatch(batch, 3)
+						for !q.Enqueue(batch) {
+							time.Sleep(time.Microsecond)
+						}
+					}
+				}()
+			}
+	
+			received := 0
+			for received < producers*perProducer {
+				batch := q.Dequeue()
+				if batch == nil {
+					time.Sleep(time.Microsecond)
+					continue
+				}
+				if len(batch.Events) != 3 {
+					t.Fatalf("dequeued batch holds %d events", len(batch.Events))
+				}
+				received++
+				q.ReturnBatch(batch)
+			}
+			wg.Wait()
+	
+			if depth := q.Depth(); depth != 0 {
+				t.Fatalf("depth %d after draining", depth)
+			}
+		})
+	}
+}
+
+// BenchmarkQueueRoundTrip takes a batch from the pool, queues it, dequeues it
+// and returns it
+func BenchmarkQueueRoundTrip(b *testing.B) {
+	for _, kind := range queueTypes {
+		b.Run(kind, func(b *testing.B) {
+			previous := GetQueueType()
+			SetQueueType(kind)
+			defer SetQueueType(previous)
+			queue := NewLogQueue(1000)
+			b.ReportAllocs()
+			b.ResetTimer()
+			
+			for i := 0; i < b.N; i++ {
+				queue.Enqueue(queue.GetBatch())
+				queue.ReturnBatch(queue.Dequeue())
+			}
+		})
+	}
+}
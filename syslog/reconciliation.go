@@ -0,0 +1,101 @@
+package syslog
+
+import (
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// reconciliationMinutes is the history kept per source, in one-minute buckets
+const reconciliationMinutes = 24 * 60
+
+// reconciliationBucket holds the counts of one minute
+type reconciliationBucket struct {
+	minute int64 // Unix minute the counts belong to
+	counts models.ReconciliationCounts
+}
+
+// ReconciliationLedger keeps per-minute delivery accounting for a source so
+// received and delivered events can be compared over any range of the last day
+type ReconciliationLedger struct {
+	buckets [reconciliationMinutes]reconciliationBucket
+	mutex   sync.Mutex
+}
+
+// NewReconciliationLedger creates an empty ledger
+func NewReconciliationLedger() *ReconciliationLedger {
+	return &ReconciliationLedger{}
+}
+
+// record applies update to the bucket of the minute at falls into
+func (l *ReconciliationLedger) record(at time.Time, update func(*models.ReconciliationCounts)) {
+	minute := at.Unix() / 60
+	
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	bucket := &l.buckets[minute%reconciliationMinutes]
+	if bucket.minute != minute {
+		bucket.minute = minute
+		bucket.counts = models.ReconciliationCounts{}
+	}
+	update(&bucket.counts)
+}
+
+// Sum adds up the buckets between from and to. Ranges older than the kept
+// history are silently truncated.
+func (l *ReconciliationLedger) Sum(from, to time.Time) models.ReconciliationCounts {
+	first := from.Unix() / 60
+	last := to.Unix() / 60
+	
+	var total models.ReconciliationCounts
+	
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	for _, bucket := range l.buckets {
+		if bucket.minute >= first && bucket.minute <= last {
+			total.Add(bucket.counts)
+		}
+	}
+	return total
+}
+
+// Series returns the counts between from and to in intervals of step,
+// rounded to whole minutes. Intervals without events are included as zero
+// so callers can chart the series directly; the range is truncated to the
+// kept history.
+func (l *ReconciliationLedger) Series(from, to time.Time, step time.Duration) []models.HistoryPoint {
+	stepMinutes := int64(step / time.Minute)
+	if stepMinutes < 1 {
+		stepMinutes = 1
+	}
+	first := from.Unix() / 60
+	last := to.Unix() / 60
+	now := time.Now().Unix() / 60
+	if oldest := now - reconciliationMinutes + 1; first < oldest {
+		first = oldest
+	}
+	if last > now {
+		last = now
+	}
+	if first > last {
+		return []models.HistoryPoint{}
+	}
+	
+	points := make([]models.HistoryPoint, (last-first)/stepMinutes+1)
+	for i := range points {
+		points[i].Time = time.Unix((first+int64(i)*stepMinutes)*60, 0).UTC()
+	}
+	
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	for _, bucket := range l.buckets {
+		if bucket.minute >= first && bucket.minute <= last {
+			points[(bucket.minute-first)/stepMinutes].Add(bucket.counts)
+		}
+	}
+	return points
+}
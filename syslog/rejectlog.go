@@ -0,0 +1,54 @@
+package syslog
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// rejectLogInterval limits reject log lines to one per sender per interval
+const rejectLogInterval = time.Minute
+
+// rejectLogger writes firewall-style lines for rejected traffic, collapsing
+// repeats from the same sender so a noisy device cannot flood the log
+type rejectLogger struct {
+	lastLogged map[string]time.Time
+	suppressed map[string]int64
+	mutex      sync.Mutex
+}
+
+func newRejectLogger() *rejectLogger {
+	return &rejectLogger{
+		lastLogged: make(map[string]time.Time),
+		suppressed: make(map[string]int64),
+	}
+}
+
+// Log records a rejected message from sourceIP on the given listener
+func (rl *rejectLogger) Log(action, protocol string, port int, sourceIP string, size int, reason string) {
+	now := time.Now()
+	
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	
+	if last, exists := rl.lastLogged[sourceIP]; exists && now.Sub(last) < rejectLogInterval {
+		rl.suppressed[sourceIP]++
+		return
+	}
+	
+	// Forget stale senders so the map stays bounded by active senders
+	if len(rl.lastLogged) >= maxUnclaimedSenders {
+		for ip, last := range rl.lastLogged {
+			if now.Sub(last) >= rejectLogInterval {
+				delete(rl.lastLogged, ip)
+				delete(rl.suppressed, ip)
+			}
+		}
+	}
+	
+	suppressed := rl.suppressed[sourceIP]
+	rl.lastLogged[sourceIP] = now
+	delete(rl.suppressed, sourceIP)
+	
+	log.Printf("✗ %s PROTO=%s DPT=%d SRC=%s LEN=%d REASON=%s REPEATED=%d", action, protocol, port, sourceIP, size, reason, suppressed)
+}
@@ -0,0 +1,203 @@
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// RELP, the Reliable Event Logging Protocol rsyslog's omrelp speaks, frames
+// every command as "TXNR COMMAND DATALEN[ DATA]\n". The receiver answers each
+// one with an "rsp" frame carrying the same transaction number; the sender
+// keeps the frames it has no answer for and resends them after reconnecting,
+// so nothing is lost with a dropped connection. A frame is answered once its
+// message is queued in memory, so messages still queued when the analyzer
+// stops abruptly are lost like those of any other protocol.
+const (
+	maxRELPData    = 256 * 1024 // Largest DATA accepted, twice omrelp's default message size
+	maxRELPCommand = 32
+	maxRELPNumber  = 9 // TXNR and DATALEN have at most nine digits
+)
+
+// relpOffers is the answer to "open": the protocol version and the commands
+// this receiver supports
+const relpOffers = "200 OK\nrelp_version=0\nrelp_software=syslog-analyzer\ncommands=syslog"
+
+var errRELPFrame = errors.New("malformed RELP frame")
+
+// relpFrame is one command a RELP sender sent
+type relpFrame struct {
+	txnr    int
+	command string
+	data    []byte
+	size    int // Bytes the frame occupied on the wire
+}
+
+// readRELPFrame reads the next frame. It returns io.EOF when the sender
+// closed the connection between frames.
+func readRELPFrame(r *bufio.Reader) (relpFrame, error) {
+	var frame relpFrame
+	
+	txnr, delimiter, err := readRELPField(r, maxRELPNumber)
+	if err != nil {
+		if err == io.EOF && txnr == "" {
+			return frame, io.EOF
+		}
+		return frame, err
+	}
+	if frame.txnr, err = parseRELPNumber(txnr); err != nil || delimiter != ' ' {
+		return frame, fmt.Errorf("%w: bad transaction number %q", errRELPFrame, txnr)
+	}
+	
+	command, delimiter, err := readRELPField(r, maxRELPCommand)
+	if err != nil {
+		return frame, err
+	}
+	if command == "" || delimiter != ' ' {
+		return frame, fmt.Errorf("%w: bad command %q", errRELPFrame, command)
+	}
+	frame.command = command
+	
+	length, delimiter, err := readRELPField(r, maxRELPNumber)
+	if err != nil {
+		return frame, err
+	}
+	dataLen, err := parseRELPNumber(length)
+	if err != nil {
+		return frame, fmt.Errorf("%w: bad data length %q", errRELPFrame, length)
+	}
+	if dataLen > maxRELPData {
+		return frame, fmt.Errorf("%w: %d data bytes exceed the limit of %d", errRELPFrame, dataLen, maxRELPData)
+	}
+	frame.size = len(txnr) + len(command) + len(length) + 3
+	
+	// An empty frame may end right after DATALEN
+	if dataLen == 0 && delimiter == '\n' {
+		return frame, nil
+	}
+	if delimiter != ' ' {
+		return frame, fmt.Errorf("%w: missing data after length %d", errRELPFrame, dataLen)
+	}
+	frame.data = make([]byte, dataLen)
+	if _, err := io.ReadFull(r, frame.data); err != nil {
+		return frame, err
+	}
+	trailer, err := r.ReadByte()
+	if err != nil {
+		return frame, err
+	}
+	if trailer != '\n' {
+		return frame, fmt.Errorf("%w: missing trailer after %d data bytes", errRELPFrame, dataLen)
+	}
+	frame.size += dataLen + 1
+	return frame, nil
+}
+
+// parseRELPNumber parses TXNR or DATALEN, which are digits only: Atoi alone
+// would accept a sign and let "-1" through as a data length
+func parseRELPNumber(field string) (int, error) {
+	if field == "" {
+		return 0, errRELPFrame
+	}
+	for i := 0; i < len(field); i++ {
+		if field[i] < '0' || field[i] > '9' {
+			return 0, errRELPFrame
+		}
+	}
+	return strconv.Atoi(field)
+}
+
+// readRELPField reads up to the next space or newline, returning the field
+// and the delimiter that ended it
+func readRELPField(r *bufio.Reader, maxLen int) (string, byte, error) {
+	var field []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(field) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return string(field), 0, err
+		}
+		if c == ' ' || c == '\n' {
+			return string(field), c, nil
+		}
+		if len(field) == maxLen {
+			return string(field), 0, fmt.Errorf("%w: field longer than %d bytes", errRELPFrame, maxLen)
+		}
+		field = append(field, c)
+	}
+}
+
+// writeRELPResponse queues the answer to a frame
+func writeRELPResponse(w *bufio.Writer, txnr int, data string) {
+	if data == "" {
+		fmt.Fprintf(w, "%d rsp 0\n", txnr)
+		return
+	}
+	fmt.Fprintf(w, "%d rsp %d %s\n", txnr, len(data), data)
+}
+
+// serveRELP runs a RELP session on an accepted connection. A syslog frame is
+// acknowledged with "200 OK" once a source accepted its message; denied,
+// unclaimed and quota-dropped messages are answered with "500", so the
+// sender keeps them queued and retries instead of losing them. Answers are
+// flushed once the frames read so far are handled, so a sender with many
+// frames in flight gets them acknowledged in one write.
+func (sl *SharedListener) serveRELP(conn net.Conn, tracked *tcpConnection, sourceIP string) {
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	defer writer.Flush()
+	
+	opened := false
+	var attributed *SyslogSource
+	resolved := false
+	for {
+		frame, err := readRELPFrame(reader)
+		if err != nil {
+			if err != io.EOF && !strings.Contains(err.Error(), "use of closed network connection") {
+				log.Printf("⚠ RELP session from %s on port %d closed: %v", sourceIP, sl.port, err)
+				fmt.Fprintf(writer, "0 serverclose 0\n")
+			}
+			return
+		}
+		tracked.touch(frame.size)
+	
+		switch {
+		case frame.command == "open":
+			opened = true
+			writeRELPResponse(writer, frame.txnr, relpOffers)
+		case !opened:
+			writeRELPResponse(writer, frame.txnr, "500 session not opened")
+			return
+		case frame.command == "syslog":
+			message := bytes.TrimRight(frame.data, "\n")
+			if !resolved || (attributed != nil && !attributed.IsRunning()) {
+				attributed = sl.resolveHostnameSource(sourceIP, message)
+				resolved = true
+			}
+			if sl.routeMessage(message, sourceIP, frame.size, attributed) {
+				writeRELPResponse(writer, frame.txnr, "200 OK")
+			} else {
+				writeRELPResponse(writer, frame.txnr, "500 message not accepted")
+			}
+		case frame.command == "close":
+			writeRELPResponse(writer, frame.txnr, "")
+			return
+		default:
+			writeRELPResponse(writer, frame.txnr, fmt.Sprintf("500 command %s not supported", frame.command))
+		}
+	
+		if reader.Buffered() == 0 {
+			if err := writer.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
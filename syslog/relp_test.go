@@ -0,0 +1,31 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadRELPFrame(t *testing.T) {
+	frame, err := readRELPFrame(bufio.NewReader(strings.NewReader("7 syslog 5 hello\n")))
+	if err != nil {
+		t.Fatalf("readRELPFrame: %v", err)
+	}
+	if frame.txnr != 7 || frame.command != "syslog" || string(frame.data) != "hello" {
+		t.Fatalf("got %+v", frame)
+	}
+}
+
+func TestReadRELPFrameRejectsSignedNumbers(t *testing.T) {
+	for _, input := range []string{
+		"1 syslog -1 x\n",
+		"1 syslog +5 hello\n",
+		"-1 syslog 5 hello\n",
+		"1 syslog 9999999 x\n",
+	} {
+		if _, err := readRELPFrame(bufio.NewReader(strings.NewReader(input))); !errors.Is(err, errRELPFrame) {
+			t.Errorf("%q: got %v, want errRELPFrame", input, err)
+		}
+	}
+}
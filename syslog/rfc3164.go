@@ -0,0 +1,162 @@
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// maxTagLength bounds the TAG of an RFC 3164 message; longer first words are
+// taken as message text
+const maxTagLength = 48
+
+// ValidateParser checks a source's message parser
+func ValidateParser(parser string) error {
+	switch parser {
+	case "", models.ParserRFC3164:
+		return nil
+	}
+	return fmt.Errorf("unknown parser: %s (expected %s)", parser, models.ParserRFC3164)
+}
+
+// BSDMessage is an RFC 3164 (BSD syslog) message split into its parts
+type BSDMessage struct {
+	Priority  int
+	Facility  int
+	Severity  int
+	Timestamp time.Time // Zero when the message has none
+	Hostname  string
+	Tag       string
+	PID       string
+	Message   string
+}
+
+// ParseRFC3164 splits a BSD syslog message: <PRI>, an optional "Mmm dd
+// hh:mm:ss" or ISO 8601 TIMESTAMP, the HOSTNAME that follows a timestamp,
+// and a "tag[pid]:" before the message. Devices omit parts freely, so only
+// the PRI is required; what can't be read as a header is left in Message.
+// Timestamps are read like ParseDeviceTime does.
+func ParseRFC3164(data []byte, received time.Time) (BSDMessage, bool) {
+	data = bytes.TrimSpace(data)
+	if len(data) < 3 || data[0] != '<' {
+		return BSDMessage{}, false
+	}
+	end := bytes.IndexByte(data, '>')
+	if end < 2 || end > 4 || !isDigits(string(data[1:end])) {
+		return BSDMessage{}, false
+	}
+	pri, _ := strconv.Atoi(string(data[1:end]))
+	if pri > 191 {
+		return BSDMessage{}, false
+	}
+	msg := BSDMessage{Priority: pri, Facility: pri / 8, Severity: pri % 8}
+	rest := string(data[end+1:])
+	
+	// The classic timestamp has a fixed width, with the day padded by a space
+	if len(rest) >= 15 && rest[3] == ' ' && rest[6] == ' ' && rest[9] == ':' && rest[12] == ':' {
+		if parsed, ok := parseBSDTime(rest[:15], received); ok {
+			msg.Timestamp = parsed
+			rest = strings.TrimLeft(rest[15:], " ")
+		}
+	} else if word, remainder := nextWord(rest); strings.Contains(word, "T") && strings.Contains(word, "-") {
+		if parsed, ok := parseISOTime(word); ok {
+			msg.Timestamp = parsed
+			rest = remainder
+		}
+	}
+	
+	// A HOSTNAME only follows a timestamp, and is never a tag
+	if !msg.Timestamp.IsZero() {
+		if word, remainder := nextWord(rest); word != "" && remainder != "" && !strings.HasSuffix(word, ":") && !strings.Contains(word, "[") {
+			msg.Hostname = word
+			rest = remainder
+		}
+	}
+	
+	if word, remainder := nextWord(rest); strings.HasSuffix(word, ":") && len(word) <= maxTagLength {
+		tag := strings.TrimSuffix(word, ":")
+		if open := strings.IndexByte(tag, '['); open > 0 && strings.HasSuffix(tag, "]") {
+			msg.PID = tag[open+1 : len(tag)-1]
+			tag = tag[:open]
+		}
+		if tag != "" {
+			msg.Tag = tag
+			rest = remainder
+		}
+	}
+	msg.Message = rest
+	return msg, true
+}
+
+// nextWord splits off the first space-separated word of s
+func nextWord(s string) (string, string) {
+	s = strings.TrimLeft(s, " ")
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		return s[:i], strings.TrimLeft(s[i+1:], " ")
+	}
+	return s, ""
+}
+
+// Fields returns the message as event fields. Facility and severity are
+// keywords, so filters and aggregation group-bys can match them as text.
+func (m BSDMessage) Fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"message":  m.Message,
+		"priority": m.Priority,
+		"facility": models.FacilityNames[m.Facility],
+		"severity": models.SeverityNames[m.Severity],
+	}
+	if !m.Timestamp.IsZero() {
+		fields["timestamp"] = m.Timestamp.Format(time.RFC3339)
+	}
+	if m.Hostname != "" {
+		fields["hostname"] = m.Hostname
+	}
+	if m.Tag != "" {
+		fields["tag"] = m.Tag
+	}
+	if m.PID != "" {
+		fields["pid"] = m.PID
+	}
+	return fields
+}
+
+// applyParser replaces a text event by the fields of the source's parser,
+// counting events per facility and severity, or counting the failure when
+// the message doesn't parse
+func (lp *LogProcessor) applyParser(event *models.LogEvent, data []byte) {
+	msg, ok := ParseRFC3164(data, event.Time)
+	if !ok {
+		atomic.AddInt64(&lp.parseFailures, 1)
+		return
+	}
+	event.Event = msg.Fields()
+	atomic.AddInt64(&lp.facilityCounts[msg.Facility], 1)
+	atomic.AddInt64(&lp.severityCounts[msg.Severity], 1)
+}
+
+// getParsedCounts returns the parsed events per facility and per severity
+// keyword
+func (lp *LogProcessor) getParsedCounts() (map[string]int64, map[string]int64) {
+	if lp.config.Parser == "" {
+		return nil, nil
+	}
+	byFacility := make(map[string]int64)
+	for code := range lp.facilityCounts {
+		if count := atomic.LoadInt64(&lp.facilityCounts[code]); count > 0 {
+			byFacility[models.FacilityNames[code]] = count
+		}
+	}
+	bySeverity := make(map[string]int64)
+	for code := range lp.severityCounts {
+		if count := atomic.LoadInt64(&lp.severityCounts[code]); count > 0 {
+			bySeverity[models.SeverityNames[code]] = count
+		}
+	}
+	return byFacility, bySeverity
+}
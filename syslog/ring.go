@@ -0,0 +1,109 @@
+package syslog
+
+import (
+	"sync/atomic"
+
+	"syslog-analyzer/models"
+)
+
+// cacheLinePad keeps the producer and consumer cursors on separate cache lines
+type cacheLinePad [64]byte
+
+// ringSlot is one cell of a ring buffer. Its sequence number tells producers
+// and consumers whose turn it is: pos when free for the producer of lap pos,
+// pos+1 once filled.
+type ringSlot struct {
+	sequence uint64
+	batch    *models.LogBatch
+}
+
+// ringBuffer is a bounded lock-free queue of batches (Vyukov's design). Any
+// number of producers and consumers may use it; the pipeline has many
+// listener goroutines producing and one processing thread consuming.
+type ringBuffer struct {
+	_        cacheLinePad
+	head     uint64 // Next position to fill
+	_        cacheLinePad
+	tail     uint64 // Next position to drain
+	_        cacheLinePad
+	count    int64 // Batches reserved or queued, to hold the exact capacity
+	capacity int64
+	mask     uint64
+	slots    []ringSlot
+}
+
+// newRingBuffer creates a ring buffer holding up to capacity batches
+func newRingBuffer(capacity int) *ringBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+	size := 1
+	for size < capacity {
+		size <<= 1
+	}
+	
+	ring := &ringBuffer{
+		capacity: int64(capacity),
+		mask:     uint64(size - 1),
+		slots:    make([]ringSlot, size),
+	}
+	for i := range ring.slots {
+		ring.slots[i].sequence = uint64(i)
+	}
+	return ring
+}
+
+// push adds a batch, returning false when the ring is full
+func (r *ringBuffer) push(batch *models.LogBatch) bool {
+	// Reserve room first so the ring never holds more than its capacity,
+	// which is not necessarily a power of two
+	if atomic.AddInt64(&r.count, 1) > r.capacity {
+		atomic.AddInt64(&r.count, -1)
+		return false
+	}
+	
+	pos := atomic.LoadUint64(&r.head)
+	for {
+		slot := &r.slots[pos&r.mask]
+		sequence := atomic.LoadUint64(&slot.sequence)
+		switch diff := int64(sequence) - int64(pos); {
+		case diff == 0:
+			if atomic.CompareAndSwapUint64(&r.head, pos, pos+1) {
+				slot.batch = batch
+				atomic.StoreUint64(&slot.sequence, pos+1)
+				return true
+			}
+			pos = atomic.LoadUint64(&r.head)
+		case diff < 0:
+			// Slot still held by a consumer of the previous lap
+			atomic.AddInt64(&r.count, -1)
+			return false
+		default:
+			pos = atomic.LoadUint64(&r.head)
+		}
+	}
+}
+
+// pop removes the oldest batch, returning nil when the ring is empty
+func (r *ringBuffer) pop() *models.LogBatch {
+	pos := atomic.LoadUint64(&r.tail)
+	for {
+		slot := &r.slots[pos&r.mask]
+		sequence := atomic.LoadUint64(&slot.sequence)
+		switch diff := int64(sequence) - int64(pos+1); {
+		case diff == 0:
+			if atomic.CompareAndSwapUint64(&r.tail, pos, pos+1) {
+				batch := slot.batch
+				slot.batch = nil
+				atomic.StoreUint64(&slot.sequence, pos+r.mask+1)
+				atomic.AddInt64(&r.count, -1)
+				return batch
+			}
+			pos = atomic.LoadUint64(&r.tail)
+		case diff < 0:
+			return nil
+		default:
+			pos = atomic.LoadUint64(&r.tail)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package syslog
+
+import (
+	"sync"
+
+	"syslog-analyzer/models"
+)
+
+const (
+	eventSampleCapacity  = 1000 // Recent events kept per source for filter impact estimates
+	eventSamplesPerBatch = 10   // Events taken from each batch, so one burst doesn't fill the ring
+)
+
+// eventSampler keeps a ring of recent events as the filters see them, so a
+// proposed filter rule set can be evaluated against real traffic
+type eventSampler struct {
+	events []models.LogEvent
+	next   int
+	mutex  sync.Mutex
+}
+
+func newEventSampler() *eventSampler {
+	return &eventSampler{events: make([]models.LogEvent, 0, eventSampleCapacity)}
+}
+
+// record keeps up to eventSamplesPerBatch events spread evenly over a batch
+func (s *eventSampler) record(events []models.LogEvent) {
+	if len(events) == 0 {
+		return
+	}
+	stride := (len(events) + eventSamplesPerBatch - 1) / eventSamplesPerBatch
+	
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := 0; i < len(events); i += stride {
+		if len(s.events) < eventSampleCapacity {
+			s.events = append(s.events, events[i])
+		} else {
+			s.events[s.next] = events[i]
+		}
+		s.next = (s.next + 1) % eventSampleCapacity
+	}
+}
+
+// snapshot returns copies of the sampled events with their payloads decoded
+func (s *eventSampler) snapshot() []models.LogEvent {
+	s.mutex.Lock()
+	events := append([]models.LogEvent(nil), s.events...)
+	s.mutex.Unlock()
+	
+	for i := range events {
+		events[i].Materialize()
+	}
+	return events
+}
@@ -0,0 +1,58 @@
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"syslog-analyzer/models"
+)
+
+// severityAliases maps common alternative spellings to severity codes
+var severityAliases = map[string]int{
+	"emergency":     0,
+	"panic":         0,
+	"critical":      2,
+	"error":         3,
+	"warn":          4,
+	"informational": 6,
+}
+
+// ParseSeverityLevel parses a severity keyword or code (0-7)
+func ParseSeverityLevel(level string) (int, error) {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if code, err := strconv.Atoi(level); err == nil {
+		if code < 0 || code > 7 {
+			return 0, fmt.Errorf("severity code must be between 0 and 7")
+		}
+		return code, nil
+	}
+	for code, name := range models.SeverityNames {
+		if name == level {
+			return code, nil
+		}
+	}
+	if code, exists := severityAliases[level]; exists {
+		return code, nil
+	}
+	return 0, fmt.Errorf("unknown severity: %s", level)
+}
+
+// ParseSeverity extracts the severity from a message's PRI header. It returns
+// -1 when the message does not start with a valid PRI.
+func ParseSeverity(data []byte) int {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) < 3 || data[0] != '<' {
+		return -1
+	}
+	end := bytes.IndexByte(data, '>')
+	if end < 2 || end > 4 {
+		return -1
+	}
+	pri, err := strconv.Atoi(string(data[1:end]))
+	if err != nil || pri < 0 || pri > 191 {
+		return -1
+	}
+	return pri % 8
+}
@@ -0,0 +1,14 @@
+package syslog
+
+import "testing"
+
+// BenchmarkParseSeverity reads the PRI header of a message
+func BenchmarkParseSeverity(b *testing.B) {
+	messages := benchMessages(100, 256)
+	b.ReportAllocs()
+	b.ResetTimer()
+	
+	for i := 0; i < b.N; i++ {
+		ParseSeverity(messages[i%len(messages)])
+	}
+}
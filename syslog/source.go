@@ -2,10 +2,15 @@
 package syslog
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
+	"time"
 
+	"syslog-analyzer/destinations"
+	"syslog-analyzer/lifecycle"
 	"syslog-analyzer/models"
 )
 
@@ -13,13 +18,21 @@ import (
 type SyslogSource struct {
 	config    models.SourceConfig
 	processor *LogProcessor
+	listeners []*SharedListener
+	owner     string // Goroutines are accounted to this instance
 	mutex     sync.RWMutex
 }
 
 // ApplicationInterface defines the interface for application methods needed by SyslogSource
 type ApplicationInterface interface {
-	GetSharedListener(protocol string, port int) (*SharedListener, error)
-	RemoveSharedListener(protocol string, port int)
+	// AcquireSharedListener registers the source with the listener for the
+	// protocol and port, starting the listener if needed
+	AcquireSharedListener(protocol string, port int, source *SyslogSource) (*SharedListener, error)
+	// ReleaseSharedListener unregisters the source and stops the listener
+	// once no source uses it
+	ReleaseSharedListener(listener *SharedListener, source *SyslogSource)
+	AdmitMessage(config models.SourceConfig, size int) bool
+	LookupValue(table, key string) (map[string]string, bool)
 }
 
 // NewSyslogSource creates a new syslog source processor
@@ -27,25 +40,36 @@ func NewSyslogSource(config models.SourceConfig, batchSize int) *SyslogSource {
 	return &SyslogSource{
 		config:    config,
 		processor: NewLogProcessor(config, batchSize),
+		owner:     lifecycle.NewOwner("source", config.Name),
 	}
 }
 
-// Start begins processing syslog messages for this source
-func (s *SyslogSource) Start(app ApplicationInterface) error {
+// Start begins processing syslog messages for this source until Stop or
+// until ctx is cancelled
+func (s *SyslogSource) Start(ctx context.Context, app ApplicationInterface) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	
-	// Get or create shared listener
-	sharedListener, err := app.GetSharedListener(s.config.Protocol, s.config.Port)
-	if err != nil {
-		return fmt.Errorf("failed to get shared listener on %s port %d: %v", s.config.Protocol, s.config.Port, err)
-	}
+	// Route messages through the application's quota enforcement
+	config := s.config
+	s.processor.SetAdmitFunc(func(size int) bool {
+		return app.AdmitMessage(config, size)
+	})
+	s.processor.SetLookupFunc(app.LookupValue)
 	
-	// Register this source with the shared listener
-	sharedListener.AddSource(s)
+	// Register with a shared listener per transport
+	for _, transport := range Transports(s.config.Protocol) {
+		sharedListener, err := app.AcquireSharedListener(transport, s.config.Port, s)
+		if err != nil {
+			s.releaseListeners(app)
+			return fmt.Errorf("failed to get shared listener on %s port %d: %v", transport, s.config.Port, err)
+		}
+		s.listeners = append(s.listeners, sharedListener)
+	}
 	
-	// Start the log processor
-	if err := s.processor.Start(); err != nil {
+	// Start the log processor; its goroutines are accounted to this source
+	if err := s.processor.Start(lifecycle.WithOwner(ctx, s.owner)); err != nil {
+		s.releaseListeners(app)
 		return fmt.Errorf("failed to start log processor: %v", err)
 	}
 	
@@ -53,32 +77,94 @@ func (s *SyslogSource) Start(app ApplicationInterface) error {
 	return nil
 }
 
-// Stop gracefully stops the syslog source
+// Stop gracefully stops the syslog source in order: it stops intake by
+// leaving its listeners, then drains its queue and closes its destinations
 func (s *SyslogSource) Stop(app ApplicationInterface) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	
-	// Stop the log processor
+	// Nothing reaches the processor once the listeners no longer route to it
+	s.releaseListeners(app)
+	
 	s.processor.Stop()
 	
-	// Remove from shared listener
-	if sharedListener, err := app.GetSharedListener(s.config.Protocol, s.config.Port); err == nil {
-		sharedListener.RemoveSource(s)
-		
-		// Check if this was the last source for this listener
-		if sharedListener.GetSourceCount() == 0 {
-			// No more sources, can stop the shared listener
-			app.RemoveSharedListener(s.config.Protocol, s.config.Port)
-			log.Printf("✓ Stopped %s listener on port %d", s.config.Protocol, s.config.Port)
-		}
+	log.Printf("✓ Source '%s' stopped", s.config.Name)
+}
+
+// releaseListeners unregisters from every shared listener; the application
+// stops those no other source uses. Callers hold the mutex.
+func (s *SyslogSource) releaseListeners(app ApplicationInterface) {
+	for _, sharedListener := range s.listeners {
+		app.ReleaseSharedListener(sharedListener, s)
 	}
+	s.listeners = nil
+}
+
+// Owner returns the name the source's goroutines are accounted to
+func (s *SyslogSource) Owner() string {
+	return s.owner
+}
+
+// GetListenerCount returns the number of shared listeners the source is
+// registered with
+func (s *SyslogSource) GetListenerCount() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.listeners)
+}
+
+// Transports returns the listener protocols a source protocol needs;
+// "UDP+TCP" opens both on the same port
+func Transports(protocol string) []string {
+	if strings.EqualFold(protocol, models.ProtocolUDPTCP) {
+		return []string{"UDP", "TCP"}
+	}
+	return []string{strings.ToUpper(protocol)}
+}
+
+// SocketTransport returns the socket a listener protocol binds; RELP runs
+// over TCP
+func SocketTransport(transport string) string {
+	if strings.EqualFold(transport, models.ProtocolRELP) {
+		return "TCP"
+	}
+	return strings.ToUpper(transport)
+}
+
+// SetSimulationMode switches the source between simulation and live delivery
+// while it keeps receiving
+func (s *SyslogSource) SetSimulationMode(enabled bool) {
+	s.mutex.Lock()
+	s.config.SimulationMode = enabled
+	s.mutex.Unlock()
 	
-	log.Printf("✓ Source '%s' stopped", s.config.Name)
+	s.processor.SetSimulationMode(enabled)
+}
+
+// RotateAPIKey switches one of the source's HEC destinations to a new API
+// key in place, reporting whether a running destination took it
+func (s *SyslogSource) RotateAPIKey(destID, apiKey string) bool {
+	s.mutex.Lock()
+	s.config.Destinations = destinations.ReplaceAPIKey(s.config.Destinations, destID, apiKey)
+	s.mutex.Unlock()
+	
+	return s.processor.RotateAPIKey(destID, apiKey)
 }
 
-// ProcessMessage processes a single syslog message
-func (s *SyslogSource) ProcessMessage(data []byte, sourceIP string) {
-	s.processor.ProcessRawMessage(data, sourceIP)
+// SetDeliveryPaused pauses or resumes delivery to the source's destinations
+func (s *SyslogSource) SetDeliveryPaused(paused bool) {
+	s.processor.SetDeliveryPaused(paused)
+}
+
+// DeliveryPaused returns whether delivery to the source's destinations is paused
+func (s *SyslogSource) DeliveryPaused() bool {
+	return s.processor.DeliveryPaused()
+}
+
+// ProcessMessage processes a single syslog message, returning false when it
+// was dropped on admission
+func (s *SyslogSource) ProcessMessage(data []byte, sourceIP string, wireSize int) bool {
+	return s.processor.ProcessRawMessage(data, sourceIP, wireSize)
 }
 
 // GetMetrics returns current metrics for this source
@@ -86,13 +172,43 @@ func (s *SyslogSource) GetMetrics() models.SourceMetrics {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	
-	return s.processor.GetMetrics()
+	metrics := s.processor.GetMetrics()
+	for _, sharedListener := range s.listeners {
+		if sharedListener.protocol != "UDP" {
+			metrics.OpenConnections += sharedListener.connections.count(s.config.IP)
+		}
+		if drops, ok := sharedListener.GetKernelDrops(); ok {
+			metrics.KernelDrops += drops
+			metrics.KernelDropsAvailable = true
+		}
+		if down, _, lastError, _ := sharedListener.health(); down {
+			metrics.ListenerDown = true
+			metrics.ListenerError = fmt.Sprintf("%s port %d: %s", sharedListener.protocol, sharedListener.port, lastError)
+		}
+	}
+	
+	return metrics
 }
 
-// IsRunning returns whether the source is currently running
+// GetReconciliation returns the delivery accounting of this source between from and to
+func (s *SyslogSource) GetReconciliation(from, to time.Time) models.ReconciliationCounts {
+	return s.processor.GetReconciliation(from, to)
+}
+
+// GetHistory returns the delivery accounting of this source between from and
+// to in intervals of step
+func (s *SyslogSource) GetHistory(from, to time.Time, step time.Duration) []models.HistoryPoint {
+	return s.processor.GetHistory(from, to, step)
+}
+
+// GetSamples returns recent events of this source as its filters saw them
+func (s *SyslogSource) GetSamples() []models.LogEvent {
+	return s.processor.GetSamples()
+}
+
+// IsRunning returns whether the source is currently running. It does not take
+// the mutex: listener connections ask while Stop waits for them to exit.
 func (s *SyslogSource) IsRunning() bool {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
 	return s.processor.IsRunning()
 }
 
@@ -101,4 +217,4 @@ func (s *SyslogSource) GetConfig() models.SourceConfig {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	return s.config
-}
\ No newline at end of file
+}
\ No newline at end of file
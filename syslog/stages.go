@@ -0,0 +1,105 @@
+package syslog
+
+import (
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// Pipeline stages timed per source
+const (
+	StageParse     = "parse"
+	StageEnrich    = "enrich"
+	StageFilter    = "filter"
+	StageAggregate = "aggregate"
+	StageDeliver   = "deliver"
+)
+
+// stageOrder is the order stages are reported in
+var stageOrder = []string{StageParse, StageEnrich, StageFilter, StageAggregate, StageDeliver}
+
+// stageBuckets are the upper bounds, in seconds, of the stage duration
+// histograms; they span a single parse to a slow HEC round trip
+var stageBuckets = []float64{
+	0.000001, 0.000005, 0.00001, 0.00005, 0.0001, 0.0005,
+	0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5,
+}
+
+// stageTimer counts the calls, events and time spent in one pipeline stage
+type stageTimer struct {
+	calls   int64
+	events  int64
+	nanos   int64
+	buckets []int64 // Calls per duration bucket, not cumulative; the last one is +Inf
+}
+
+func newStageTimer() *stageTimer {
+	return &stageTimer{buckets: make([]int64, len(stageBuckets)+1)}
+}
+
+// observe records a call of the stage that started at start and handled events
+func (t *stageTimer) observe(start time.Time, events int) {
+	elapsed := time.Since(start)
+	seconds := elapsed.Seconds()
+	
+	bucket := len(stageBuckets)
+	for i, bound := range stageBuckets {
+		if seconds <= bound {
+			bucket = i
+			break
+		}
+	}
+	
+	atomic.AddInt64(&t.calls, 1)
+	atomic.AddInt64(&t.events, int64(events))
+	atomic.AddInt64(&t.nanos, int64(elapsed))
+	atomic.AddInt64(&t.buckets[bucket], 1)
+}
+
+// snapshot reports the stage with cumulative buckets
+func (t *stageTimer) snapshot(stage string) models.StageMetrics {
+	metrics := models.StageMetrics{
+		Stage:        stage,
+		Calls:        atomic.LoadInt64(&t.calls),
+		Events:       atomic.LoadInt64(&t.events),
+		TotalSeconds: time.Duration(atomic.LoadInt64(&t.nanos)).Seconds(),
+		Buckets:      make([]models.HistogramBucket, len(stageBuckets)),
+	}
+	
+	var cumulative int64
+	for i, bound := range stageBuckets {
+		cumulative += atomic.LoadInt64(&t.buckets[i])
+		metrics.Buckets[i] = models.HistogramBucket{UpperBound: bound, Count: cumulative}
+	}
+	// Calls racing the snapshot may be in the buckets but not yet in calls
+	if metrics.Calls < cumulative {
+		metrics.Calls = cumulative
+	}
+	if metrics.Events > 0 {
+		metrics.AvgMicrosPerEvent = metrics.TotalSeconds * 1e6 / float64(metrics.Events)
+	}
+	return metrics
+}
+
+// stageTimers times every pipeline stage of a source
+type stageTimers map[string]*stageTimer
+
+func newStageTimers() stageTimers {
+	timers := make(stageTimers, len(stageOrder))
+	for _, stage := range stageOrder {
+		timers[stage] = newStageTimer()
+	}
+	return timers
+}
+
+// snapshot reports the stages that have run, in pipeline order
+func (timers stageTimers) snapshot() []models.StageMetrics {
+	var stages []models.StageMetrics
+	for _, stage := range stageOrder {
+		if metrics := timers[stage].snapshot(stage); metrics.Calls > 0 {
+			stages = append(stages, metrics)
+		}
+	}
+	return stages
+}
@@ -0,0 +1,124 @@
+package syslog
+
+import (
+	"errors"
+	"log"
+	"net"
+	"time"
+)
+
+// Listener supervision tuning
+const (
+	maxConsecutiveReadErrors = 100                   // Failed reads in a row treated as a dead socket
+	readErrorPause           = 10 * time.Millisecond // Pause after a failed read so the loop doesn't spin
+	rebindMinBackoff         = time.Second           // First re-bind retry delay
+	rebindMaxBackoff         = time.Minute           // Re-bind retry delay cap
+)
+
+// readFailed handles a failed read or accept and reports whether the reader
+// should exit: when the listener stops or, once the socket looks dead, after
+// handing it to the supervisor to re-bind
+func (sl *SharedListener) readFailed(err error, failures *int) bool {
+	if sl.ctx.Err() != nil {
+		return true
+	}
+	
+	*failures++
+	if errors.Is(err, net.ErrClosed) || *failures >= maxConsecutiveReadErrors {
+		select {
+		case sl.failed <- err:
+		default: // Another reader already reported the socket
+		}
+		return true
+	}
+	
+	time.Sleep(readErrorPause)
+	return false
+}
+
+// supervise re-binds the socket whenever its readers report it dead, backing
+// off while the port cannot be bound, until the listener stops
+func (sl *SharedListener) supervise() {
+	defer sl.handlers.Done()
+	
+	for {
+		select {
+		case <-sl.ctx.Done():
+			return
+		case err := <-sl.failed:
+			sl.setDown(err)
+			log.Printf("✗ %s listener on port %d failed: %v; re-binding", sl.protocol, sl.port, err)
+			
+			// Let every reader of the dead socket exit before binding a new one,
+			// then drop the failures they reported on the way out
+			sl.closeSocket()
+			sl.readers.Wait()
+			select {
+			case <-sl.failed:
+			default:
+			}
+			
+			backoff := rebindMinBackoff
+			for {
+				err := sl.bind()
+				if err == nil {
+					break
+				}
+				if errors.Is(err, errListenerStopped) {
+					return
+				}
+				sl.setDown(err)
+				log.Printf("✗ Could not re-bind %s port %d: %v; retrying in %v", sl.protocol, sl.port, err, backoff)
+				
+				select {
+				case <-sl.ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				backoff *= 2
+				if backoff > rebindMaxBackoff {
+					backoff = rebindMaxBackoff
+				}
+			}
+			
+			sl.setUp()
+			log.Printf("✓ Re-bound %s listener on port %d", sl.protocol, sl.port)
+		}
+	}
+}
+
+// setDown records that the listener is not receiving because of err
+func (sl *SharedListener) setDown(err error) {
+	sl.healthMutex.Lock()
+	defer sl.healthMutex.Unlock()
+	
+	if !sl.down {
+		sl.down = true
+		sl.downSince = time.Now()
+	}
+	sl.lastError = err.Error()
+}
+
+// setUp records a successful re-bind
+func (sl *SharedListener) setUp() {
+	sl.healthMutex.Lock()
+	defer sl.healthMutex.Unlock()
+	
+	sl.down = false
+	sl.downSince = time.Time{}
+	sl.restarts++
+}
+
+// health returns whether the listener is down, since when, the error that
+// took it down and how often it has been re-bound
+func (sl *SharedListener) health() (bool, time.Time, string, int64) {
+	sl.healthMutex.Lock()
+	defer sl.healthMutex.Unlock()
+	return sl.down, sl.downSince, sl.lastError, sl.restarts
+}
+
+// IsDown returns whether the listener lost its socket and has not re-bound yet
+func (sl *SharedListener) IsDown() bool {
+	down, _, _, _ := sl.health()
+	return down
+}
@@ -0,0 +1,137 @@
+package syslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// deviceClockTolerance is how far in the future a device timestamp may lie
+// before device_or_receive falls back to the receive time. Delayed batches
+// only ever carry past timestamps, so a future one means a wrong clock or
+// time zone.
+const deviceClockTolerance = 5 * time.Minute
+
+// ValidateTimestampSource checks a source's timestamp source
+func ValidateTimestampSource(source string) error {
+	switch source {
+	case "", models.TimestampReceive, models.TimestampDevice, models.TimestampDeviceFallback:
+		return nil
+	}
+	return fmt.Errorf("unknown timestamp source: %s (expected %s, %s or %s)", source, models.TimestampReceive, models.TimestampDevice, models.TimestampDeviceFallback)
+}
+
+// ParseDeviceTime extracts the timestamp a device put in a message: the
+// TIMESTAMP of an RFC 5424 or RFC 3164 message, or the "@timestamp",
+// "timestamp" or "time" field of a JSON event, either RFC 3339 text or Unix
+// seconds or milliseconds. RFC 3164 timestamps carry neither year nor zone;
+// they are read in local time, in the year that keeps them from lying far
+// after received.
+func ParseDeviceTime(data []byte, received time.Time) (time.Time, bool) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return time.Time{}, false
+	}
+	if data[0] == '{' {
+		return parseJSONTime(data)
+	}
+	if data[0] != '<' {
+		return time.Time{}, false
+	}
+	end := bytes.IndexByte(data, '>')
+	if end < 2 || end > 4 {
+		return time.Time{}, false
+	}
+	
+	// The timestamp is within the first few fields; don't split the message
+	header := data[end+1:]
+	if len(header) > 64 {
+		header = header[:64]
+	}
+	fields := strings.Fields(string(header))
+	
+	switch {
+	case len(fields) >= 2 && isDigits(fields[0]):
+		// RFC 5424: VERSION TIMESTAMP ..., with "-" for no timestamp
+		return parseISOTime(fields[1])
+	case len(fields) >= 1 && strings.Contains(fields[0], "T") && strings.Contains(fields[0], "-"):
+		// RFC 3164 with an ISO 8601 timestamp
+		return parseISOTime(fields[0])
+	case len(fields) >= 3 && len(fields[0]) == 3 && strings.Contains(fields[2], ":"):
+		// RFC 3164: Mmm dd hh:mm:ss
+		return parseBSDTime(fields[0]+" "+fields[1]+" "+fields[2], received)
+	}
+	return time.Time{}, false
+}
+
+// parseISOTime parses an RFC 3339 timestamp, with or without fractional seconds
+func parseISOTime(text string) (time.Time, bool) {
+	parsed, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsed.UTC(), true
+}
+
+// parseBSDTime parses an RFC 3164 timestamp in local time, choosing the year
+// so a message from late December received in January lands in December
+func parseBSDTime(text string, received time.Time) (time.Time, bool) {
+	parsed, err := time.ParseInLocation(time.Stamp, text, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	local := received.In(time.Local)
+	parsed = time.Date(local.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), parsed.Nanosecond(), time.Local)
+	if parsed.After(local.AddDate(0, 1, 0)) {
+		parsed = parsed.AddDate(-1, 0, 0)
+	}
+	return parsed.UTC(), true
+}
+
+// parseJSONTime reads the timestamp field of a JSON event
+func parseJSONTime(data []byte) (time.Time, bool) {
+	var fields struct {
+		At        interface{} `json:"@timestamp"`
+		Timestamp interface{} `json:"timestamp"`
+		Time      interface{} `json:"time"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return time.Time{}, false
+	}
+	for _, value := range []interface{}{fields.At, fields.Timestamp, fields.Time} {
+		switch v := value.(type) {
+		case string:
+			if parsed, ok := parseISOTime(v); ok {
+				return parsed, true
+			}
+		case float64:
+			// Values this large are milliseconds; seconds would be far in the future
+			if v > 1e12 {
+				return time.UnixMilli(int64(v)).UTC(), true
+			}
+			if v > 0 {
+				return time.Unix(0, int64(v*float64(time.Second))).UTC(), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// applyDeviceTime sets an event's time from the message under the source's
+// timestamp source, counting the events that keep their receive time
+func (lp *LogProcessor) applyDeviceTime(event *models.LogEvent, data []byte) {
+	deviceTime, ok := ParseDeviceTime(data, event.Time)
+	if ok && lp.config.TimestampSource == models.TimestampDeviceFallback && deviceTime.Sub(event.Time) > deviceClockTolerance {
+		ok = false
+	}
+	if !ok {
+		atomic.AddInt64(&lp.timeFallbacks, 1)
+		return
+	}
+	event.Time = deviceTime
+}
@@ -0,0 +1,202 @@
+package syslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+	"syslog-analyzer/recovery"
+)
+
+// defaultTracerInterval is the tracer interval when none is configured
+const defaultTracerInterval = 60 * time.Second
+
+// ValidateTracer checks a source's tracer
+func ValidateTracer(tracer models.Tracer) error {
+	if tracer.IntervalSeconds < 0 {
+		return fmt.Errorf("tracer interval_seconds cannot be negative")
+	}
+	return nil
+}
+
+// tracer measures a source's end-to-end latency with tracer events. The
+// queue is FIFO, so tracers complete in the order they were injected and
+// the first one in flight is the oldest.
+type tracer struct {
+	interval  time.Duration
+	mutex     sync.Mutex
+	inFlight  []time.Time // Injection times of the queued tracers, oldest first
+	injected  int64
+	lost      int64
+	completed int64
+	last      time.Duration
+	queued    time.Duration
+	delivered time.Duration
+	max       time.Duration
+	measured  time.Time
+}
+
+// newTracer returns the tracer of a source, or nil without one
+func newTracer(config models.SourceConfig) *tracer {
+	if config.Tracer == nil {
+		return nil
+	}
+	if err := ValidateTracer(*config.Tracer); err != nil {
+		log.Printf("⚠ Ignoring tracer for source '%s': %v", config.Name, err)
+		return nil
+	}
+	interval := time.Duration(config.Tracer.IntervalSeconds) * time.Second
+	if interval == 0 {
+		interval = defaultTracerInterval
+	}
+	return &tracer{interval: interval}
+}
+
+// inject records a tracer about to be queued
+func (t *tracer) inject(at time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.inFlight = append(t.inFlight, at)
+	t.injected++
+}
+
+// lose forgets the tracer injected at a given time, which was never queued
+// or never made it to the destinations
+func (t *tracer) lose(at time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for i, injected := range t.inFlight {
+		if injected.Equal(at) {
+			t.inFlight = append(t.inFlight[:i], t.inFlight[i+1:]...)
+			break
+		}
+	}
+	t.lost++
+}
+
+// complete records a tracer the destinations accepted at done
+func (t *tracer) complete(trace *models.Trace, done time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.inFlight) > 0 {
+		t.inFlight = t.inFlight[1:]
+	}
+	t.completed++
+	t.last = done.Sub(trace.Injected)
+	t.queued = trace.Dequeued.Sub(trace.Injected)
+	t.delivered = done.Sub(trace.Dequeued)
+	if t.last > t.max {
+		t.max = t.last
+	}
+	t.measured = done
+}
+
+// reset forgets the tracers in flight, which a stopped processor never delivers
+func (t *tracer) reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.inFlight = nil
+}
+
+// snapshot reports the latency measured so far
+func (t *tracer) snapshot(now time.Time) *models.LatencyMetrics {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	
+	metrics := &models.LatencyMetrics{
+		IntervalSeconds: int(t.interval / time.Second),
+		Injected:        t.injected,
+		Lost:            t.lost,
+		Completed:       t.completed,
+		LastSeconds:     t.last.Seconds(),
+		QueueSeconds:    t.queued.Seconds(),
+		DeliverSeconds:  t.delivered.Seconds(),
+		MaxSeconds:      t.max.Seconds(),
+		LastMeasured:    t.measured,
+	}
+	if len(t.inFlight) > 0 {
+		metrics.PendingSeconds = now.Sub(t.inFlight[0]).Seconds()
+	}
+	return metrics
+}
+
+// runTracer injects tracer events, restarting if it panics
+func (lp *LogProcessor) runTracer() {
+	defer close(lp.tracerDone)
+	recovery.Supervise(fmt.Sprintf("tracer of source '%s'", lp.config.Name), &lp.panics, lp.tracerLoop)
+}
+
+// tracerLoop injects a tracer every interval until stopped
+func (lp *LogProcessor) tracerLoop() {
+	ticker := time.NewTicker(lp.tracer.interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-lp.ctx.Done():
+			return
+		case now := <-ticker.C:
+			lp.injectTracer(now)
+		}
+	}
+}
+
+// injectTracer queues a tracer event behind the source's events. A full
+// queue drops the tracer like any other batch, but it is not counted as a
+// dropped event.
+func (lp *LogProcessor) injectTracer(now time.Time) {
+	payload := map[string]interface{}{
+		"message":     "Syslog Analyzer tracer",
+		"synthetic":   true,
+		"event_type":  "tracer",
+		"source":      lp.config.Name,
+		"injected_at": now.UTC().Format(time.RFC3339Nano),
+	}
+	encoded, _ := json.Marshal(payload)
+	
+	batch := lp.queue.GetBatch()
+	batch.Events = append(batch.Events, models.LogEvent{
+		Time:     now.UTC(),
+		Event:    payload,
+		Source:   lp.config.Name,
+		Size:     int64(len(encoded)),
+		SenderIP: lp.config.IP,
+		Severity: 6,
+	})
+	batch.SourceIP = lp.config.IP
+	batch.Timestamp = now
+	batch.Trace = &models.Trace{Injected: now}
+	
+	lp.tracer.inject(now)
+	if !lp.queue.Enqueue(batch) {
+		lp.tracer.lose(now)
+		lp.queue.ReturnBatch(batch)
+		log.Printf("⚠ Queue full for source '%s', dropping tracer", lp.config.Name)
+	}
+}
+
+// processTracer delivers a tracer event to every destination and records its
+// latency. Tracers skip the filters, aggregation and the source's counters.
+// In simulation mode or while delivery is paused nothing is delivered, and
+// the latency is the time the tracer spent queued.
+func (lp *LogProcessor) processTracer(batch *models.LogBatch) {
+	trace := batch.Trace
+	trace.Dequeued = time.Now()
+	
+	if !lp.simulating() && !lp.deliveryPaused() {
+		events := batch.Events
+		lp.stampEvents(events)
+		lp.destinations.ProcessBatch(&models.LogBatch{
+			Events:    events,
+			SourceIP:  batch.SourceIP,
+			Timestamp: batch.Timestamp,
+			Trace:     trace,
+		}, lp.config.Name)
+	}
+	
+	lp.tracer.complete(trace, time.Now())
+	lp.queue.ReturnBatch(batch)
+}
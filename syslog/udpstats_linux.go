@@ -0,0 +1,79 @@
+//go:build linux
+
+package syslog
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readUDPKernelDrops returns the kernel drop counter of the UDP socket with
+// the given inode by matching it against /proc/net/udp and /proc/net/udp6
+func readUDPKernelDrops(inode string) (int64, error) {
+	for _, table := range []string{"/proc/net/udp", "/proc/net/udp6"} {
+		drops, found, err := scanUDPTable(table, inode)
+		if err != nil {
+			continue
+		}
+		if found {
+			return drops, nil
+		}
+	}
+	
+	return 0, fmt.Errorf("socket inode %s not found in /proc/net/udp", inode)
+}
+
+// socketInode resolves the inode of a socket via its /proc/self/fd link
+func socketInode(conn *net.UDPConn) (string, error) {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return "", err
+	}
+	
+	var link string
+	var linkErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		link, linkErr = os.Readlink(fmt.Sprintf("/proc/self/fd/%d", fd))
+	}); err != nil {
+		return "", err
+	}
+	if linkErr != nil {
+		return "", linkErr
+	}
+	
+	// Link format is "socket:[12345]"
+	if !strings.HasPrefix(link, "socket:[") || !strings.HasSuffix(link, "]") {
+		return "", fmt.Errorf("unexpected socket link %q", link)
+	}
+	return link[len("socket:[") : len(link)-1], nil
+}
+
+// scanUDPTable looks up the drops column for the given inode in a proc table
+func scanUDPTable(path, inode string) (int64, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+	
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // Skip header line
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// sl local rem st tx:rx tr:when retrnsmt uid timeout inode ref pointer drops
+		if len(fields) < 13 || fields[9] != inode {
+			continue
+		}
+		drops, err := strconv.ParseInt(fields[12], 10, 64)
+		if err != nil {
+			return 0, false, err
+		}
+		return drops, true, nil
+	}
+	
+	return 0, false, scanner.Err()
+}
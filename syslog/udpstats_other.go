@@ -0,0 +1,21 @@
+//go:build !linux
+
+package syslog
+
+import (
+	"fmt"
+	"net"
+)
+
+// errNoKernelDrops reports that the platform has no kernel UDP drop statistics
+var errNoKernelDrops = fmt.Errorf("kernel UDP drop statistics are not available on this platform")
+
+// socketInode is not supported on this platform
+func socketInode(conn *net.UDPConn) (string, error) {
+	return "", errNoKernelDrops
+}
+
+// readUDPKernelDrops is not supported on this platform
+func readUDPKernelDrops(inode string) (int64, error) {
+	return 0, errNoKernelDrops
+}
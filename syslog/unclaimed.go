@@ -0,0 +1,150 @@
+package syslog
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"syslog-analyzer/models"
+)
+
+const (
+	maxUnclaimedSenders = 1000              // Senders tracked per listener
+	maxSampleLength     = 512               // Bytes of the sample message kept
+	unclaimedRateWindow = 10 * time.Second // Interval the EPS estimate covers
+)
+
+// unclaimedSender tracks traffic from an IP no source is configured for
+type unclaimedSender struct {
+	count       int64
+	sample      string
+	firstSeen   time.Time
+	lastSeen    time.Time
+	windowStart time.Time
+	windowCount int64
+	eps         float64
+}
+
+// UnclaimedTracker records senders that reach a listener without a matching source
+type UnclaimedTracker struct {
+	senders map[string]*unclaimedSender
+	mutex   sync.Mutex
+}
+
+// NewUnclaimedTracker creates an empty tracker
+func NewUnclaimedTracker() *UnclaimedTracker {
+	return &UnclaimedTracker{
+		senders: make(map[string]*unclaimedSender),
+	}
+}
+
+// Record accounts a message from an unmatched sender
+func (t *UnclaimedTracker) Record(sourceIP string, data []byte) {
+	now := time.Now()
+	
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	
+	sender, exists := t.senders[sourceIP]
+	if !exists {
+		if len(t.senders) >= maxUnclaimedSenders {
+			t.evictOldest()
+		}
+		sender = &unclaimedSender{firstSeen: now, windowStart: now}
+		t.senders[sourceIP] = sender
+	}
+	
+	sender.count++
+	sender.lastSeen = now
+	sender.sample = sampleMessage(data)
+	
+	sender.windowCount++
+	if elapsed := now.Sub(sender.windowStart); elapsed >= unclaimedRateWindow {
+		sender.eps = float64(sender.windowCount) / elapsed.Seconds()
+		sender.windowStart = now
+		sender.windowCount = 0
+	}
+}
+
+// Claim forgets a sender once a source has been configured for it.
+// Claiming the wildcard address forgets every sender.
+func (t *UnclaimedTracker) Claim(sourceIP string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	
+	if sourceIP == "0.0.0.0" {
+		t.senders = make(map[string]*unclaimedSender)
+		return
+	}
+	delete(t.senders, sourceIP)
+}
+
+// Forget removes a sender from the list
+func (t *UnclaimedTracker) Forget(sourceIP string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	
+	_, exists := t.senders[sourceIP]
+	delete(t.senders, sourceIP)
+	return exists
+}
+
+// Snapshot returns the tracked senders, busiest first
+func (t *UnclaimedTracker) Snapshot(protocol string, port int) []models.UnclaimedSender {
+	now := time.Now()
+	
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	
+	senders := make([]models.UnclaimedSender, 0, len(t.senders))
+	for ip, sender := range t.senders {
+		// A sender that went quiet no longer has a meaningful rate
+		eps := sender.eps
+		if now.Sub(sender.lastSeen) > 2*unclaimedRateWindow {
+			eps = 0
+		}
+		senders = append(senders, models.UnclaimedSender{
+			IP:            ip,
+			Port:          port,
+			Protocol:      protocol,
+			EPS:           eps,
+			MessageCount:  sender.count,
+			SampleMessage: sender.sample,
+			FirstSeen:     sender.firstSeen,
+			LastSeen:      sender.lastSeen,
+		})
+	}
+	
+	sort.Slice(senders, func(i, j int) bool {
+		if senders[i].EPS != senders[j].EPS {
+			return senders[i].EPS > senders[j].EPS
+		}
+		return senders[i].MessageCount > senders[j].MessageCount
+	})
+	return senders
+}
+
+// evictOldest drops the sender seen least recently; callers hold the mutex
+func (t *UnclaimedTracker) evictOldest() {
+	var oldestIP string
+	var oldest time.Time
+	for ip, sender := range t.senders {
+		if oldestIP == "" || sender.lastSeen.Before(oldest) {
+			oldestIP = ip
+			oldest = sender.lastSeen
+		}
+	}
+	delete(t.senders, oldestIP)
+}
+
+// sampleMessage copies a bounded, valid UTF-8 prefix of a message
+func sampleMessage(data []byte) string {
+	if len(data) > maxSampleLength {
+		data = data[:maxSampleLength]
+		for len(data) > 0 && !utf8.Valid(data) {
+			data = data[:len(data)-1]
+		}
+	}
+	return string(data)
+}
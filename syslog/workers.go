@@ -0,0 +1,238 @@
+package syslog
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// maxUDPWorkers bounds the reader goroutines per UDP listener
+const maxUDPWorkers = 64
+
+// maxWorkerSenders bounds the senders a worker counts between status reads;
+// a sender first seen beyond it is not counted for the top sender
+const maxWorkerSenders = 1024
+
+// udpWorker is one goroutine reading from a UDP listener's socket
+type udpWorker struct {
+	id       int
+	cpus     []int  // CPUs the worker's OS thread is pinned to; empty leaves it to the scheduler
+	pinError string // Why pinning failed, if it did
+	packets  int64
+	bytes    int64
+	dropped  int64
+	
+	// Pin error, packet rate and top sender; the rate and top sender are
+	// sampled when the status is read
+	mutex          sync.Mutex
+	senders        map[string]int64 // Packets per sender since the last sample
+	lastPackets    int64
+	lastSample     time.Time
+	pps            float64
+	topSender      string
+	topSenderShare float64
+}
+
+// newUDPWorkers creates the workers the settings ask for, assigning the
+// configured CPU sets round-robin
+func newUDPWorkers(settings models.ListenerConfig) []*udpWorker {
+	count := settings.UDPWorkers
+	if count < 1 {
+		count = 1
+	}
+	
+	workers := make([]*udpWorker, count)
+	for i := range workers {
+		workers[i] = &udpWorker{id: i, senders: make(map[string]int64), lastSample: time.Now()}
+		if len(settings.CPUAffinity) > 0 {
+			// Validated by ValidateWorkerSettings
+			workers[i].cpus, _ = ParseCPUList(settings.CPUAffinity[i%len(settings.CPUAffinity)])
+		}
+	}
+	return workers
+}
+
+// pin locks the calling goroutine to its OS thread and binds that thread to
+// the worker's CPUs. Called from the worker goroutine; a failed pin leaves
+// the worker running unpinned.
+func (w *udpWorker) pin() error {
+	if len(w.cpus) == 0 {
+		return nil
+	}
+	
+	runtime.LockOSThread()
+	if err := pinThread(w.cpus); err != nil {
+		w.mutex.Lock()
+		w.pinError = err.Error()
+		w.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// record accounts a datagram read by the worker from sourceIP, and whether
+// a source accepted it
+func (w *udpWorker) record(size int, sourceIP string, accepted bool) {
+	atomic.AddInt64(&w.packets, 1)
+	atomic.AddInt64(&w.bytes, int64(size))
+	if !accepted {
+		atomic.AddInt64(&w.dropped, 1)
+	}
+	
+	w.mutex.Lock()
+	if _, exists := w.senders[sourceIP]; exists || len(w.senders) < maxWorkerSenders {
+		w.senders[sourceIP]++
+	}
+	w.mutex.Unlock()
+}
+
+// status reports the worker's counters, refreshing the packet rate and top
+// sender when at least a second has passed since the last sample
+func (w *udpWorker) status() models.ListenerWorkerStatus {
+	packets := atomic.LoadInt64(&w.packets)
+	
+	w.mutex.Lock()
+	if elapsed := time.Since(w.lastSample); elapsed >= time.Second {
+		w.pps = float64(packets-w.lastPackets) / elapsed.Seconds()
+		w.topSender, w.topSenderShare = "", 0
+		var top int64
+		for sender, count := range w.senders {
+			if count > top || (count == top && sender < w.topSender) {
+				w.topSender, top = sender, count
+			}
+		}
+		if window := packets - w.lastPackets; window > 0 {
+			w.topSenderShare = float64(top) / float64(window) * 100
+		}
+		w.senders = make(map[string]int64)
+		w.lastPackets = packets
+		w.lastSample = time.Now()
+	}
+	pps, pinError := w.pps, w.pinError
+	topSender, topSenderShare := w.topSender, w.topSenderShare
+	w.mutex.Unlock()
+	
+	return models.ListenerWorkerStatus{
+		ID:             w.id,
+		CPUs:           FormatCPUList(w.cpus),
+		Pinned:         len(w.cpus) > 0 && pinError == "",
+		PinError:       pinError,
+		Packets:        packets,
+		Bytes:          atomic.LoadInt64(&w.bytes),
+		Dropped:        atomic.LoadInt64(&w.dropped),
+		PPS:            pps,
+		TopSender:      topSender,
+		TopSenderShare: topSenderShare,
+	}
+}
+
+// workerStatuses reports the workers of a listener with each worker's share
+// of the packets, and the busiest worker's rate over the mean. Shares follow
+// the current packet rates, or the totals while the listener is idle.
+func workerStatuses(workers []*udpWorker) ([]models.ListenerWorkerStatus, float64) {
+	if len(workers) == 0 {
+		return nil, 0
+	}
+	
+	statuses := make([]models.ListenerWorkerStatus, len(workers))
+	var totalPPS, busiestPPS float64
+	var totalPackets int64
+	for i, worker := range workers {
+		statuses[i] = worker.status()
+		totalPPS += statuses[i].PPS
+		totalPackets += statuses[i].Packets
+		if statuses[i].PPS > busiestPPS {
+			busiestPPS = statuses[i].PPS
+		}
+	}
+	
+	for i := range statuses {
+		switch {
+		case totalPPS > 0:
+			statuses[i].Share = statuses[i].PPS / totalPPS * 100
+		case totalPackets > 0:
+			statuses[i].Share = float64(statuses[i].Packets) / float64(totalPackets) * 100
+		}
+	}
+	
+	var imbalance float64
+	if len(workers) > 1 && totalPPS > 0 {
+		imbalance = busiestPPS / (totalPPS / float64(len(workers)))
+	}
+	return statuses, imbalance
+}
+
+// ValidateWorkerSettings checks the worker count and CPU sets of a listener
+func ValidateWorkerSettings(settings models.ListenerConfig) error {
+	if settings.UDPWorkers < 0 || settings.UDPWorkers > maxUDPWorkers {
+		return fmt.Errorf("udp_workers must be between 0 and %d", maxUDPWorkers)
+	}
+	if settings.UDPWorkers > 1 && SocketTransport(settings.Protocol) == "TCP" {
+		return fmt.Errorf("udp_workers only applies to UDP listeners")
+	}
+	for _, list := range settings.CPUAffinity {
+		if _, err := ParseCPUList(list); err != nil {
+			return fmt.Errorf("cpu_affinity: %v", err)
+		}
+	}
+	return nil
+}
+
+// ParseCPUList parses a Linux-style CPU list such as "0-3,8,10-11"
+func ParseCPUList(list string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		
+		first, last := part, part
+		if dash := strings.Index(part, "-"); dash >= 0 {
+			first, last = part[:dash], part[dash+1:]
+		}
+		low, errLow := strconv.Atoi(strings.TrimSpace(first))
+		high, errHigh := strconv.Atoi(strings.TrimSpace(last))
+		if errLow != nil || errHigh != nil || low < 0 || high < low || high >= maxCPUs {
+			return nil, fmt.Errorf("invalid CPU list %q (expected e.g. 0-3,8)", list)
+		}
+		for cpu := low; cpu <= high; cpu++ {
+			seen[cpu] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("empty CPU list %q", list)
+	}
+	
+	cpus := make([]int, 0, len(seen))
+	for cpu := range seen {
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
+
+// FormatCPUList formats sorted CPUs as a compact list, e.g. "0-3,8"
+func FormatCPUList(cpus []int) string {
+	var parts []string
+	for i := 0; i < len(cpus); {
+		j := i
+		for j+1 < len(cpus) && cpus[j+1] == cpus[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(cpus[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", cpus[i], cpus[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
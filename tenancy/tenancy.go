@@ -0,0 +1,125 @@
+// Package tenancy scopes sources, metrics and API access to tenants
+// (workspaces) so one analyzer instance can serve several internal customers
+package tenancy
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"syslog-analyzer/models"
+)
+
+// Scope describes what a caller is allowed to see
+type Scope struct {
+	Tenant string `json:"tenant"`
+	Admin  bool   `json:"admin"` // Super-admin: all tenants
+}
+
+// AdminScope is the scope used when multi-tenancy is disabled
+var AdminScope = Scope{Admin: true}
+
+// Allows reports whether the scope may access objects of the given tenant
+func (s Scope) Allows(tenant string) bool {
+	return s.Admin || s.Tenant == tenant
+}
+
+// Key returns a stable identifier for grouping callers by scope
+func (s Scope) Key() string {
+	if s.Admin {
+		return "*"
+	}
+	return "tenant:" + s.Tenant
+}
+
+type contextKey struct{}
+
+// WithScope attaches a scope to a request context
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope)
+}
+
+// FromContext returns the scope attached to a context, defaulting to admin
+// when none is present (multi-tenancy disabled)
+func FromContext(ctx context.Context) Scope {
+	if scope, ok := ctx.Value(contextKey{}).(Scope); ok {
+		return scope
+	}
+	return AdminScope
+}
+
+// ResolveToken maps an API token to a scope using the configuration
+func ResolveToken(config *models.Config, token string) (Scope, bool) {
+	if config == nil || token == "" {
+		return Scope{}, false
+	}
+	
+	for _, adminToken := range config.GlobalSettings.AdminTokens {
+		if tokenEquals(adminToken, token) {
+			return AdminScope, true
+		}
+	}
+	
+	for _, tenant := range config.Tenants {
+		for _, tenantToken := range tenant.Tokens {
+			if tokenEquals(tenantToken, token) {
+				return Scope{Tenant: tenant.ID}, true
+			}
+		}
+	}
+	
+	return Scope{}, false
+}
+
+// tokenEquals compares tokens in constant time
+func tokenEquals(expected, actual string) bool {
+	if expected == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) == 1
+}
+
+// FilterSources returns the source configurations visible to a scope
+func FilterSources(scope Scope, sources []models.SourceConfig) []models.SourceConfig {
+	if scope.Admin {
+		return sources
+	}
+	
+	filtered := []models.SourceConfig{}
+	for _, source := range sources {
+		if scope.Allows(source.Tenant) {
+			filtered = append(filtered, source)
+		}
+	}
+	return filtered
+}
+
+// FilterMetrics returns the source metrics visible to a scope together with
+// global metrics recomputed over just those sources
+func FilterMetrics(scope Scope, sources []models.SourceMetrics, global models.GlobalMetrics) ([]models.SourceMetrics, models.GlobalMetrics) {
+	if scope.Admin {
+		return sources, global
+	}
+	
+	filtered := []models.SourceMetrics{}
+	for _, source := range sources {
+		if scope.Allows(source.Tenant) {
+			filtered = append(filtered, source)
+		}
+	}
+	summary := models.SummarizeMetrics(filtered)
+	for _, quota := range global.Quotas {
+		if quota.Tenant != "" && scope.Allows(quota.Tenant) {
+			summary.Quotas = append(summary.Quotas, quota)
+		}
+	}
+	return filtered, summary
+}
+
+// ForTenant narrows an admin scope to a single tenant, used by the
+// super-admin view to look at one workspace at a time
+func (s Scope) ForTenant(tenant string) Scope {
+	if !s.Admin || tenant == "" {
+		return s
+	}
+	return Scope{Tenant: tenant}
+}
@@ -0,0 +1,116 @@
+package tuning
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// startedAt is when the process started, for the reported uptime
+var startedAt = time.Now()
+
+// sizeUnits are the suffixes accepted by ParseSize, longest first
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+	{"TB", 1000 * 1000 * 1000 * 1000}, {"GB", 1000 * 1000 * 1000}, {"MB", 1000 * 1000}, {"KB", 1000},
+	{"B", 1},
+}
+
+// ParseSize parses a byte size such as "512MiB", "2GB" or "1073741824"
+func ParseSize(size string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid size %q (expected e.g. 512MiB or 2GB)", size)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// Validate checks the runtime settings without applying them
+func Validate(config models.RuntimeConfig) error {
+	if config.MaxProcs < 0 {
+		return fmt.Errorf("invalid max_procs %d (expected 0 for the default or a positive count)", config.MaxProcs)
+	}
+	if config.GCPercent < -1 {
+		return fmt.Errorf("invalid gc_percent %d (expected -1 to disable, 0 for the default or a positive percent)", config.GCPercent)
+	}
+	if config.MemoryLimit != "" {
+		if _, err := ParseSize(config.MemoryLimit); err != nil {
+			return fmt.Errorf("invalid memory_limit: %v", err)
+		}
+	}
+	return nil
+}
+
+// Apply sets GOMAXPROCS, the GC percent and the soft memory limit. Zero
+// values leave the defaults, including those from the GOMAXPROCS, GOGC and
+// GOMEMLIMIT environment variables, in place.
+func Apply(config models.RuntimeConfig) error {
+	if err := Validate(config); err != nil {
+		return err
+	}
+	
+	if config.MaxProcs > 0 {
+		runtime.GOMAXPROCS(config.MaxProcs)
+	}
+	if config.GCPercent != 0 {
+		debug.SetGCPercent(config.GCPercent)
+	}
+	if config.MemoryLimit != "" {
+		limit, _ := ParseSize(config.MemoryLimit)
+		debug.SetMemoryLimit(limit)
+	}
+	return nil
+}
+
+// Status reports the runtime settings in effect and the process' memory use
+func Status(config models.RuntimeConfig) models.RuntimeStatus {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	
+	// The GC percent can only be read by setting it; restore it straight away
+	gcPercent := debug.SetGCPercent(-1)
+	debug.SetGCPercent(gcPercent)
+	
+	status := models.RuntimeStatus{
+		GoVersion:     runtime.Version(),
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		NumCPU:        runtime.NumCPU(),
+		MaxProcs:      runtime.GOMAXPROCS(0),
+		GCPercent:     gcPercent,
+		Configured:    config,
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAlloc:     mem.HeapAlloc,
+		HeapSys:       mem.HeapSys,
+		Sys:           mem.Sys,
+		NumGC:         mem.NumGC,
+		StartedAt:     startedAt,
+		UptimeSeconds: int64(time.Since(startedAt).Seconds()),
+	}
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		status.MemoryLimit = limit
+	}
+	if mem.LastGC > 0 {
+		status.LastGC = time.Unix(0, int64(mem.LastGC))
+	}
+	return status
+}
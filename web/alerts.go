@@ -0,0 +1,193 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"syslog-analyzer/notifications"
+	"syslog-analyzer/tenancy"
+)
+
+// AckAlertRequest is the payload for acknowledging an alert
+type AckAlertRequest struct {
+	Rule   string `json:"rule"`
+	Source string `json:"source"`
+	By     string `json:"by"`
+}
+
+// SilenceRequest is the payload for creating a silence. Either Until or
+// DurationMinutes must be set.
+type SilenceRequest struct {
+	Rule            string    `json:"rule"`
+	Source          string    `json:"source"`
+	Until           time.Time `json:"until"`
+	DurationMinutes int       `json:"duration_minutes"`
+	Comment         string    `json:"comment"`
+	CreatedBy       string    `json:"created_by"`
+}
+
+// handleGetAlerts returns active alerts and silences
+func (s *Server) handleGetAlerts(w http.ResponseWriter, r *http.Request) {
+	if s.getAlertsFunc == nil {
+		http.Error(w, "Alert functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	alerts, silences, err := s.getAlertsFunc()
+	if err != nil {
+		s.sendErrorResponse(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	
+	// Tenants only see alerts and silences for their own sources
+	if !tenancy.FromContext(r.Context()).Admin {
+		visibleAlerts := []notifications.Alert{}
+		for _, alert := range alerts {
+			if s.sourceAllowed(r, alert.Source) {
+				visibleAlerts = append(visibleAlerts, alert)
+			}
+		}
+		visibleSilences := []notifications.Silence{}
+		for _, silence := range silences {
+			if silence.Source != "" && s.sourceAllowed(r, silence.Source) {
+				visibleSilences = append(visibleSilences, silence)
+			}
+		}
+		alerts, silences = visibleAlerts, visibleSilences
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"alerts":   alerts,
+		"silences": silences,
+	})
+}
+
+// handleAckAlert acknowledges a firing alert
+func (s *Server) handleAckAlert(w http.ResponseWriter, r *http.Request) {
+	if s.ackAlertFunc == nil {
+		http.Error(w, "Alert functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var request AckAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	if request.Rule == "" || request.Source == "" {
+		s.sendErrorResponse(w, "Rule and source are required", http.StatusBadRequest)
+		return
+	}
+	if request.By == "" {
+		request.By = "api"
+	}
+	if !s.sourceAllowed(r, request.Source) {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	alert, err := s.ackAlertFunc(request.Rule, request.Source, request.By)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to acknowledge alert: %v", err), http.StatusNotFound)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"alert":   alert,
+	})
+}
+
+// handleAddSilence creates a silence
+func (s *Server) handleAddSilence(w http.ResponseWriter, r *http.Request) {
+	if s.addSilenceFunc == nil {
+		http.Error(w, "Alert functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var request SilenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	until := request.Until
+	if until.IsZero() {
+		if request.DurationMinutes <= 0 {
+			s.sendErrorResponse(w, "Either until or duration_minutes is required", http.StatusBadRequest)
+			return
+		}
+		until = time.Now().Add(time.Duration(request.DurationMinutes) * time.Minute)
+	}
+	if request.CreatedBy == "" {
+		request.CreatedBy = "api"
+	}
+	
+	// Tenants must scope silences to one of their own sources
+	if !tenancy.FromContext(r.Context()).Admin && (request.Source == "" || !s.sourceAllowed(r, request.Source)) {
+		s.sendErrorResponse(w, "A source owned by your tenant is required", http.StatusForbidden)
+		return
+	}
+	
+	silence, err := s.addSilenceFunc(notifications.Silence{
+		Rule:      request.Rule,
+		Source:    request.Source,
+		Until:     until,
+		Comment:   request.Comment,
+		CreatedBy: request.CreatedBy,
+	})
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to add silence: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"silence": silence,
+	})
+}
+
+// handleRemoveSilence deletes a silence
+func (s *Server) handleRemoveSilence(w http.ResponseWriter, r *http.Request) {
+	if s.removeSilenceFunc == nil {
+		http.Error(w, "Alert functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	id := mux.Vars(r)["id"]
+	if !tenancy.FromContext(r.Context()).Admin && !s.silenceAllowed(r, id) {
+		s.sendErrorResponse(w, "Silence not found", http.StatusNotFound)
+		return
+	}
+	if err := s.removeSilenceFunc(id); err != nil {
+		s.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Silence removed successfully")
+}
+
+// silenceAllowed reports whether a tenant caller owns the source a silence applies to
+func (s *Server) silenceAllowed(r *http.Request, id string) bool {
+	if s.getAlertsFunc == nil {
+		return false
+	}
+	_, silences, err := s.getAlertsFunc()
+	if err != nil {
+		return false
+	}
+	for _, silence := range silences {
+		if silence.ID == id {
+			return silence.Source != "" && s.sourceAllowed(r, silence.Source)
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// defaultSlowRequest is the duration requests are logged as slow after when
+// slow_request_ms is not set
+const defaultSlowRequest = 2 * time.Second
+
+// apiLatencySamples is how many recent requests each endpoint's percentiles
+// are computed from
+const apiLatencySamples = 1000
+
+// apiBuckets are the upper bounds, in seconds, of the request duration
+// histograms; they span a cached metrics read to a report of every source
+var apiBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// endpointMetrics counts the requests of one method and route
+type endpointMetrics struct {
+	requests     int64
+	clientErrors int64
+	serverErrors int64
+	slow         int64
+	nanos        int64
+	buckets      []int64         // Requests per duration bucket, not cumulative; the last one is +Inf
+	recent       []time.Duration // Most recent durations as a ring
+	next         int
+	max          time.Duration
+}
+
+// apiMetrics counts requests and their latency per endpoint
+type apiMetrics struct {
+	endpoints map[string]*endpointMetrics // Keyed by method and route
+	slowAfter int64                       // Nanoseconds
+	mutex     sync.Mutex
+}
+
+func newAPIMetrics() *apiMetrics {
+	return &apiMetrics{endpoints: make(map[string]*endpointMetrics), slowAfter: int64(defaultSlowRequest)}
+}
+
+// observe records a request and returns whether it was slow
+func (m *apiMetrics) observe(method, route string, status int, duration time.Duration) bool {
+	slow := int64(duration) > atomic.LoadInt64(&m.slowAfter)
+	
+	bucket := len(apiBuckets)
+	for i, bound := range apiBuckets {
+		if duration.Seconds() <= bound {
+			bucket = i
+			break
+		}
+	}
+	
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	
+	key := method + " " + route
+	endpoint, exists := m.endpoints[key]
+	if !exists {
+		endpoint = &endpointMetrics{buckets: make([]int64, len(apiBuckets)+1)}
+		m.endpoints[key] = endpoint
+	}
+	endpoint.requests++
+	switch {
+	case status >= 500:
+		endpoint.serverErrors++
+	case status >= 400:
+		endpoint.clientErrors++
+	}
+	if slow {
+		endpoint.slow++
+	}
+	endpoint.nanos += int64(duration)
+	endpoint.buckets[bucket]++
+	if len(endpoint.recent) < apiLatencySamples {
+		endpoint.recent = append(endpoint.recent, duration)
+	} else {
+		endpoint.recent[endpoint.next] = duration
+		endpoint.next = (endpoint.next + 1) % apiLatencySamples
+	}
+	if duration > endpoint.max {
+		endpoint.max = duration
+	}
+	return slow
+}
+
+// snapshot reports every endpoint requested so far, by route and method
+func (m *apiMetrics) snapshot() []models.APIEndpointMetrics {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	
+	snapshot := make([]models.APIEndpointMetrics, 0, len(m.endpoints))
+	for key, endpoint := range m.endpoints {
+		method, route, _ := strings.Cut(key, " ")
+		
+		metrics := models.APIEndpointMetrics{
+			Method:       method,
+			Route:        route,
+			Requests:     endpoint.requests,
+			ClientErrors: endpoint.clientErrors,
+			ServerErrors: endpoint.serverErrors,
+			SlowRequests: endpoint.slow,
+			TotalSeconds: time.Duration(endpoint.nanos).Seconds(),
+			MaxMs:        milliseconds(endpoint.max),
+			Buckets:      make([]models.HistogramBucket, len(apiBuckets)),
+		}
+		if metrics.Requests > 0 {
+			metrics.ErrorRate = float64(metrics.ClientErrors+metrics.ServerErrors) / float64(metrics.Requests) * 100
+		}
+		
+		var cumulative int64
+		for i, bound := range apiBuckets {
+			cumulative += endpoint.buckets[i]
+			metrics.Buckets[i] = models.HistogramBucket{UpperBound: bound, Count: cumulative}
+		}
+		
+		recent := append([]time.Duration(nil), endpoint.recent...)
+		sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+		metrics.P50Ms = milliseconds(percentile(recent, 50))
+		metrics.P95Ms = milliseconds(percentile(recent, 95))
+		metrics.P99Ms = milliseconds(percentile(recent, 99))
+		
+		snapshot = append(snapshot, metrics)
+	}
+	
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Route != snapshot[j].Route {
+			return snapshot[i].Route < snapshot[j].Route
+		}
+		return snapshot[i].Method < snapshot[j].Method
+	})
+	return snapshot
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// SetSlowRequestThreshold sets the duration requests are logged as slow
+// after; 0 restores the default
+func (s *Server) SetSlowRequestThreshold(threshold time.Duration) {
+	if threshold <= 0 {
+		threshold = defaultSlowRequest
+	}
+	atomic.StoreInt64(&s.apiMetrics.slowAfter, int64(threshold))
+}
+
+// responseRecorder notes the status and size of a response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// handleGetAPIMetrics reports the request counts, error rates and latency
+// of every web endpoint (super-admin only, as they cover every tenant)
+func (s *Server) handleGetAPIMetrics(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.apiMetrics.snapshot())
+}
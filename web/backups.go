@@ -0,0 +1,65 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// handleGetBackups returns the backup status and the backups kept
+func (s *Server) handleGetBackups(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getBackupStatusFunc == nil {
+		http.Error(w, "Backup functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.getBackupStatusFunc())
+}
+
+// handleBackupNow backs up the configuration immediately
+func (s *Server) handleBackupNow(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.backupNowFunc == nil {
+		http.Error(w, "Backup functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	info, err := s.backupNowFunc()
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Backup failed: %v", err), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// handleRestoreBackup restores the sources of a backup. It is refused while
+// the configuration is read-only or managed by GitOps, which would undo it.
+func (s *Server) handleRestoreBackup(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.configManaged(w) {
+		return
+	}
+	if s.restoreBackupFunc == nil {
+		http.Error(w, "Backup functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	result, err := s.restoreBackupFunc(mux.Vars(r)["name"])
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Restore failed: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"syslog-analyzer/chargeback"
+)
+
+// handleGetChargeback downloads the chargeback CSV of a month (?month=YYYY-MM,
+// default current month to date)
+func (s *Server) handleGetChargeback(w http.ResponseWriter, r *http.Request) {
+	if s.getChargebackFunc == nil {
+		http.Error(w, "Chargeback functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	if _, err := time.Parse("2006-01", month); err != nil {
+		http.Error(w, "Invalid month (expected YYYY-MM)", http.StatusBadRequest)
+		return
+	}
+	
+	usage, err := s.getChargebackFunc(month)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get chargeback: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	// Tenants only see their own row
+	scope := requestScope(r)
+	if !scope.Admin {
+		filtered := []chargeback.Usage{}
+		for _, row := range usage {
+			if row.Kind == chargeback.KindTenant && scope.Allows(row.Name) {
+				filtered = append(filtered, row)
+			}
+		}
+		usage = filtered
+	}
+	
+	csvData, err := chargeback.CSV(month, usage)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to generate chargeback: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", chargeback.Filename(month)))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(csvData)))
+	w.Write(csvData)
+}
+
+// handleGetChargebackMonths lists the months with recorded usage
+func (s *Server) handleGetChargebackMonths(w http.ResponseWriter, r *http.Request) {
+	if s.getChargebackMonthsFunc == nil {
+		http.Error(w, "Chargeback functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.getChargebackMonthsFunc())
+}
+
+// handleGetCosts returns the projected monthly cost of each metered
+// destination and what filtering saves compared with raw ingest
+func (s *Server) handleGetCosts(w http.ResponseWriter, r *http.Request) {
+	if s.getMetricsFunc == nil {
+		http.Error(w, "Metrics function not available", http.StatusInternalServerError)
+		return
+	}
+	
+	sources, _ := s.scopedMetrics(r)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chargeback.Costs(sources, time.Now()))
+}
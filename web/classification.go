@@ -0,0 +1,89 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"syslog-analyzer/models"
+)
+
+// handleGetClassification returns the classification rules and, per label,
+// the sources carrying it and their combined ingest
+func (s *Server) handleGetClassification(w http.ResponseWriter, r *http.Request) {
+	if s.getClassificationRulesFunc == nil || s.getMetricsFunc == nil {
+		http.Error(w, "Classification functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	rules := s.getClassificationRulesFunc()
+	sources, _ := s.scopedMetrics(r)
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rules":  rules,
+		"labels": summarizeClassification(sources, rules),
+	})
+}
+
+// handleUpdateClassificationRules replaces the classification rules
+// (super-admin only)
+func (s *Server) handleUpdateClassificationRules(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configManaged(w) {
+		return
+	}
+	if s.updateClassificationRulesFunc == nil {
+		http.Error(w, "Classification functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var rules []models.ClassificationRule
+	if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	if err := s.updateClassificationRulesFunc(rules); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to update classification rules: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Classification rules updated successfully")
+}
+
+// summarizeClassification groups sources by classification label, sorted by
+// label. Labels are compared regardless of case, as rules match them.
+func summarizeClassification(sources []models.SourceMetrics, rules []models.ClassificationRule) []models.ClassificationSummary {
+	byLabel := make(map[string]*models.ClassificationSummary)
+	for _, source := range sources {
+		for _, label := range source.Classification {
+			key := strings.ToLower(label)
+			summary, exists := byLabel[key]
+			if !exists {
+				summary = &models.ClassificationSummary{Label: key, Sources: []string{}}
+				for _, rule := range rules {
+					if strings.EqualFold(rule.Label, key) {
+						summary.Rules = append(summary.Rules, rule)
+					}
+				}
+				byLabel[key] = summary
+			}
+			summary.Sources = append(summary.Sources, source.Name)
+			summary.RealTimeEPS += source.RealTimeEPS
+			summary.TotalLogs += source.TotalLogsIngested
+			summary.TotalEventBytes += source.TotalEventBytes
+		}
+	}
+	
+	summaries := make([]models.ClassificationSummary, 0, len(byLabel))
+	for _, summary := range byLabel {
+		sort.Strings(summary.Sources)
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Label < summaries[j].Label
+	})
+	return summaries
+}
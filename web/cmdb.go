@@ -0,0 +1,48 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// handleGetCMDB returns the CMDB sync status and the report of the last
+// sync (super-admin only)
+func (s *Server) handleGetCMDB(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getCMDBStatusFunc == nil {
+		http.Error(w, "CMDB functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.getCMDBStatusFunc())
+}
+
+// handleSyncCMDB syncs with the inventory immediately and returns the
+// report; with ?dry_run=true it only reports the changes a sync would make
+// (super-admin only)
+func (s *Server) handleSyncCMDB(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	if !dryRun && s.configManaged(w) {
+		return
+	}
+	if s.syncCMDBFunc == nil {
+		http.Error(w, "CMDB functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	report, err := s.syncCMDBFunc(dryRun)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Sync failed: %v", err), http.StatusConflict)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
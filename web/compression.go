@@ -0,0 +1,120 @@
+package web
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+
+	"syslog-analyzer/models"
+)
+
+// SetCompression enables gzip responses and WebSocket permessage-deflate.
+// Call before Start.
+func (s *Server) SetCompression(config models.CompressionConfig) {
+	level := config.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	
+	s.compression = config
+	s.compression.Level = level
+	s.gzipPool = sync.Pool{New: func() interface{} {
+		writer, _ := gzip.NewWriterLevel(nil, level)
+		return writer
+	}}
+	s.wsManager.setCompression(config.WebSocket, level)
+}
+
+// gzipMiddleware compresses responses for clients that accept gzip. Whether
+// a response is compressed is decided once the handler has set its headers.
+func (s *Server) gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.compression.HTTP || r.Method == http.MethodHead || !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		
+		w.Header().Add("Vary", "Accept-Encoding")
+		gw := &gzipResponseWriter{ResponseWriter: w, pool: &s.gzipPool}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// gzipResponseWriter sends the response body through a gzip writer, unless
+// the response has no body or is compressed already
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	pool        *sync.Pool
+	writer      *gzip.Writer // nil until a compressed body is written, and for uncompressed responses
+	wroteHeader bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	
+	if compressible(status, w.Header()) {
+		// The compressed length differs from anything the handler set
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.writer = w.pool.Get().(*gzip.Writer)
+		w.writer.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			// Sniff the uncompressed body, as the server would
+			w.Header().Set("Content-Type", http.DetectContentType(data))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.writer == nil {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.writer.Write(data)
+}
+
+// close finishes the compressed body and returns the gzip writer to the pool
+func (w *gzipResponseWriter) close() {
+	if w.writer != nil {
+		w.writer.Close()
+		w.pool.Put(w.writer)
+		w.writer = nil
+	}
+}
+
+// compressible reports whether a response with this status and these headers
+// has a body worth compressing
+func compressible(status int, header http.Header) bool {
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.TrimSpace(contentType)
+	switch {
+	case contentType == "image/svg+xml":
+		return true
+	case strings.HasPrefix(contentType, "image/"), strings.HasPrefix(contentType, "video/"), strings.HasPrefix(contentType, "audio/"):
+		return false
+	}
+	switch contentType {
+	case "application/gzip", "application/x-gzip", "application/zip", "application/pdf",
+		"application/zstd", "application/x-xz", "application/x-bzip2", "font/woff2":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"syslog-analyzer/tenancy"
+)
+
+// configReadOnly reports whether the configuration comes from the environment
+// and cannot be changed, answering the request with a conflict if so
+func (s *Server) configReadOnly(w http.ResponseWriter) bool {
+	if s.getConfigModeFunc == nil || !s.getConfigModeFunc().ReadOnly {
+		return false
+	}
+	s.sendErrorResponse(w, "Configuration is read-only in environment-only mode; change it in the deployment", http.StatusConflict)
+	return true
+}
+
+// handleGetConfigMode returns whether the configuration is read-only. Only
+// super-admins see where it was read from and which variables apply.
+func (s *Server) handleGetConfigMode(w http.ResponseWriter, r *http.Request) {
+	if s.getConfigModeFunc == nil {
+		http.Error(w, "Configuration mode not available", http.StatusInternalServerError)
+		return
+	}
+	
+	mode := s.getConfigModeFunc()
+	if !tenancy.FromContext(r.Context()).Admin {
+		mode.Origin = ""
+		mode.Overrides = []string{}
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mode)
+}
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"syslog-analyzer/models"
+	"syslog-analyzer/tenancy"
+)
+
+// handleResetCounters starts a source's cumulative counters from zero. The
+// reset is recorded so graphs can mark it instead of showing a dip.
+func (s *Server) handleResetCounters(w http.ResponseWriter, r *http.Request) {
+	if s.resetCountersFunc == nil {
+		http.Error(w, "Counter functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	name := mux.Vars(r)["name"]
+	if !s.sourceAllowed(r, name) {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	event, err := s.resetCountersFunc(name, tenancy.FromContext(r.Context()).Key())
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to reset counters: %v", err), http.StatusNotFound)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"reset":   event,
+	})
+}
+
+// handleGetCounterResets lists the counter reset events, newest first
+func (s *Server) handleGetCounterResets(w http.ResponseWriter, r *http.Request) {
+	if s.getCounterResetsFunc == nil {
+		http.Error(w, "Counter functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	// Deleted sources have no tenant left to check, so tenants only see
+	// events of sources they still own
+	events := []models.CounterResetEvent{}
+	for _, event := range s.getCounterResetsFunc() {
+		if s.sourceAllowed(r, event.Source) {
+			events = append(events, event)
+		}
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
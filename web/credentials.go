@@ -0,0 +1,83 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"syslog-analyzer/models"
+)
+
+// defaultCredentialAuditLimit is how many audit entries are returned when
+// the request doesn't say
+const defaultCredentialAuditLimit = 100
+
+// handleRotateCredentials switches the HEC destinations with the name in
+// the path to a new API key, in every source the caller may manage or only
+// the listed ones. Running destinations take the key without a restart.
+func (s *Server) handleRotateCredentials(w http.ResponseWriter, r *http.Request) {
+	if s.configManaged(w) {
+		return
+	}
+	if s.rotateCredentialsFunc == nil {
+		http.Error(w, "Credential functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var rotation models.CredentialRotation
+	if err := json.NewDecoder(r.Body).Decode(&rotation); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	scope := requestScope(r)
+	rotation.Actor = "admin"
+	if !scope.Admin {
+		rotation.Actor = "tenant:" + scope.Tenant
+	}
+	rotation.Client, _, _ = net.SplitHostPort(r.RemoteAddr)
+	
+	rotated, err := s.rotateCredentialsFunc(scope, mux.Vars(r)["name"], rotation)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to rotate credentials: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"rotated": rotated,
+	})
+}
+
+// handleGetCredentialAudit returns the newest credential rotations of the
+// caller's sources, newest first
+func (s *Server) handleGetCredentialAudit(w http.ResponseWriter, r *http.Request) {
+	if s.getCredentialAuditFunc == nil {
+		http.Error(w, "Credential functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	limit := defaultCredentialAuditLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			s.sendErrorResponse(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	
+	entries, err := s.getCredentialAuditFunc(requestScope(r), limit)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to read credential audit trail: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
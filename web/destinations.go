@@ -0,0 +1,60 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"syslog-analyzer/destinations"
+)
+
+// handleGetDestinationFiles lists the sidecar indexes of a storage
+// destination's finalized files. Destination IDs are only unique per source;
+// ?source= selects the source when several use the same ID.
+func (s *Server) handleGetDestinationFiles(w http.ResponseWriter, r *http.Request) {
+	if s.getSourcesFunc == nil {
+		http.Error(w, "Sources function not available", http.StatusInternalServerError)
+		return
+	}
+	
+	id := mux.Vars(r)["id"]
+	sourceName := r.URL.Query().Get("source")
+	for _, source := range s.getSourcesFunc() {
+		if sourceName != "" && source.Name != sourceName {
+			continue
+		}
+		if !s.sourceAllowed(r, source.Name) {
+			continue
+		}
+		
+		for _, dest := range source.Destinations {
+			if dest.ID != id {
+				continue
+			}
+			if dest.Type != "storage" {
+				s.sendErrorResponse(w, "Only storage destinations have files", http.StatusBadRequest)
+				return
+			}
+			
+			configMap, _ := dest.Config.(map[string]interface{})
+			path, _ := configMap["path"].(string)
+			files, err := destinations.ListFiles(path, source.Name)
+			if err != nil {
+				s.sendErrorResponse(w, fmt.Sprintf("Failed to list files: %v", err), http.StatusInternalServerError)
+				return
+			}
+			
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"source":      source.Name,
+				"destination": dest.Name,
+				"files":       files,
+			})
+			return
+		}
+	}
+	
+	s.sendErrorResponse(w, "Destination not found", http.StatusNotFound)
+}
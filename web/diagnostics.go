@@ -0,0 +1,21 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleGetDiagnostics reports the Go runtime settings and memory use of the
+// process (super-admin only, as they describe the shared host)
+func (s *Server) handleGetDiagnostics(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getDiagnosticsFunc == nil {
+		http.Error(w, "Diagnostics function not available", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.getDiagnosticsFunc())
+}
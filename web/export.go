@@ -0,0 +1,84 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"syslog-analyzer/config"
+)
+
+// maxConfigUpload caps the size of a configuration file posted for diffing
+const maxConfigUpload = 16 << 20
+
+// handleExportConfig exports the full configuration with secrets replaced by
+// "${NAME}" placeholders. ?part=config or ?part=secrets downloads only the
+// configuration or only the manifest of secrets to supply.
+func (s *Server) handleExportConfig(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.exportConfigFunc == nil {
+		http.Error(w, "Export function not available", http.StatusInternalServerError)
+		return
+	}
+	
+	export, err := s.exportConfigFunc()
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to export configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	var body interface{}
+	switch part := r.URL.Query().Get("part"); part {
+	case "":
+		body = export
+	case "config":
+		body = export.Config
+		w.Header().Set("Content-Disposition", "attachment; filename=syslog_analyzer.json")
+	case "secrets":
+		body = export.Secrets
+		w.Header().Set("Content-Disposition", "attachment; filename=syslog_analyzer_secrets.json")
+	default:
+		s.sendErrorResponse(w, "part must be config or secrets", http.StatusBadRequest)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(body)
+}
+
+// handleDiffConfig diffs the running configuration against a posted
+// configuration file or export, reporting what applying it would change
+func (s *Server) handleDiffConfig(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.diffConfigFunc == nil {
+		http.Error(w, "Diff function not available", http.StatusInternalServerError)
+		return
+	}
+	
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxConfigUpload))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read configuration: %v", err), http.StatusBadRequest)
+		return
+	}
+	proposed, err := config.DecodeConfig(data)
+	if err != nil {
+		s.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	diff, err := s.diffConfigFunc(proposed)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to diff configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
@@ -0,0 +1,77 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"syslog-analyzer/filtering"
+	"syslog-analyzer/models"
+)
+
+// handleValidateFilters checks a list of filter rules without applying them,
+// so the dashboard's rules editor validates patterns the way sources will.
+// The reply holds one error per rule, empty for valid rules.
+func (s *Server) handleValidateFilters(w http.ResponseWriter, r *http.Request) {
+	var rules []models.FilterRule
+	if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	errors := make([]string, len(rules))
+	for i, rule := range rules {
+		if rule.Field == "" {
+			errors[i] = "field is required"
+		} else if err := filtering.ValidateRule(rule); err != nil {
+			errors[i] = err.Error()
+		}
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": errors,
+	})
+}
+
+// handleEstimateFilterImpact estimates what a proposed filter rule set would
+// drop from the recent events of sources, before it is applied
+func (s *Server) handleEstimateFilterImpact(w http.ResponseWriter, r *http.Request) {
+	if s.estimateFilterImpactFunc == nil {
+		http.Error(w, "Filter functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var request models.FilterImpactRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	for _, name := range request.Sources {
+		if !s.sourceAllowed(r, name) {
+			s.sendErrorResponse(w, fmt.Sprintf("Source '%s' not found", name), http.StatusNotFound)
+			return
+		}
+	}
+	
+	impacts, err := s.estimateFilterImpactFunc(request)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to estimate filter impact: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	// Tenants asking for every source only see their own
+	scope := requestScope(r)
+	if !scope.Admin {
+		filtered := []models.FilterImpact{}
+		for _, impact := range impacts {
+			if impact.Tenant != "" && scope.Allows(impact.Tenant) {
+				filtered = append(filtered, impact)
+			}
+		}
+		impacts = filtered
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(impacts)
+}
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"syslog-analyzer/models"
+)
+
+// handleGetFilterSets lists the named filter sets (super-admin only)
+func (s *Server) handleGetFilterSets(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getFilterSetsFunc == nil {
+		http.Error(w, "Filter set functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.getFilterSetsFunc())
+}
+
+// handlePutFilterSet creates or replaces a filter set, restarting the sources
+// it is attached to (super-admin only)
+func (s *Server) handlePutFilterSet(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configManaged(w) {
+		return
+	}
+	if s.putFilterSetFunc == nil {
+		http.Error(w, "Filter set functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var set models.FilterSet
+	if err := json.NewDecoder(r.Body).Decode(&set); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	set.Name = mux.Vars(r)["name"]
+	
+	stored, created, err := s.putFilterSetFunc(set, requestPrecondition(r))
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to store filter set: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	setResourceHeaders(w, "/api/filter-sets/"+stored.Name, stored)
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(stored)
+}
+
+// handleDeleteFilterSet removes a filter set no source is attached to
+// (super-admin only)
+func (s *Server) handleDeleteFilterSet(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configManaged(w) {
+		return
+	}
+	if s.deleteFilterSetFunc == nil {
+		http.Error(w, "Filter set functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	if err := s.deleteFilterSetFunc(mux.Vars(r)["name"], requestPrecondition(r)); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to delete filter set: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Filter set deleted successfully")
+}
@@ -0,0 +1,123 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"syslog-analyzer/forecast"
+	"syslog-analyzer/models"
+)
+
+// handleGetForecast returns the capacity forecast (?model=linear|seasonal,
+// default the configured model)
+func (s *Server) handleGetForecast(w http.ResponseWriter, r *http.Request) {
+	if s.getForecastFunc == nil {
+		http.Error(w, "Forecast functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	model := r.URL.Query().Get("model")
+	switch model {
+	case "", models.ForecastLinear, models.ForecastSeasonal:
+	default:
+		http.Error(w, "Invalid model (expected linear or seasonal)", http.StatusBadRequest)
+		return
+	}
+	
+	report, err := s.getForecastFunc(model)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get forecast: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	// Tenants only see their own sources and quotas
+	scope := requestScope(r)
+	if !scope.Admin {
+		filtered := []models.ForecastSeries{}
+		for _, series := range report.Series {
+			if series.Kind != "global" && series.Tenant != "" && scope.Allows(series.Tenant) {
+				filtered = append(filtered, series)
+			}
+		}
+		report.Series = filtered
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleGetComparison compares each source's ingest between two periods of
+// whole days (?from=&to=&baseline_from=&baseline_to=, YYYY-MM-DD). The
+// current period defaults to the last 7 days including today and the
+// baseline to the period of the same length just before it.
+func (s *Server) handleGetComparison(w http.ResponseWriter, r *http.Request) {
+	if s.getComparisonFunc == nil {
+		http.Error(w, "Comparison functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	query := r.URL.Query()
+	to, err := parseDay(query.Get("to"), time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid to: %v", err), http.StatusBadRequest)
+		return
+	}
+	from, err := parseDay(query.Get("from"), to.AddDate(0, 0, -6))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid from: %v", err), http.StatusBadRequest)
+		return
+	}
+	days := int(to.Sub(from).Hours()/24+0.5) + 1
+	baselineTo, err := parseDay(query.Get("baseline_to"), from.AddDate(0, 0, -1))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid baseline_to: %v", err), http.StatusBadRequest)
+		return
+	}
+	baselineFrom, err := parseDay(query.Get("baseline_from"), baselineTo.AddDate(0, 0, 1-days))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid baseline_from: %v", err), http.StatusBadRequest)
+		return
+	}
+	if from.After(to) || baselineFrom.After(baselineTo) {
+		http.Error(w, "Invalid period (from is after to)", http.StatusBadRequest)
+		return
+	}
+	
+	current := models.IngestPeriod{From: from.Format("2006-01-02"), To: to.Format("2006-01-02")}
+	baseline := models.IngestPeriod{From: baselineFrom.Format("2006-01-02"), To: baselineTo.Format("2006-01-02")}
+	comparison, err := s.getComparisonFunc(current, baseline)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get comparison: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	// Tenants only see their own sources, and totals over those
+	scope := requestScope(r)
+	if !scope.Admin {
+		filtered := []models.SourceComparison{}
+		for _, source := range comparison.Sources {
+			if source.Tenant != "" && scope.Allows(source.Tenant) {
+				filtered = append(filtered, source)
+			}
+		}
+		comparison.Sources = filtered
+		forecast.Summarize(&comparison)
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparison)
+}
+
+// parseDay parses a YYYY-MM-DD local day, or returns the day of fallback when empty
+func parseDay(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Date(fallback.Year(), fallback.Month(), fallback.Day(), 0, 0, 0, 0, time.Local), nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD")
+	}
+	return day, nil
+}
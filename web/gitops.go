@@ -0,0 +1,58 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"syslog-analyzer/tenancy"
+)
+
+// configManaged reports whether GitOps sync owns the source configuration,
+// or the configuration is read-only, answering the request with a conflict
+// if so
+func (s *Server) configManaged(w http.ResponseWriter) bool {
+	if s.configReadOnly(w) {
+		return true
+	}
+	if s.getGitOpsStatusFunc == nil || !s.getGitOpsStatusFunc().Enabled {
+		return false
+	}
+	s.sendErrorResponse(w, "Configuration is managed by GitOps sync; change it in the repository", http.StatusConflict)
+	return true
+}
+
+// handleGetGitOps returns the GitOps sync status. Only super-admins see where
+// the configuration is synced from.
+func (s *Server) handleGetGitOps(w http.ResponseWriter, r *http.Request) {
+	if s.getGitOpsStatusFunc == nil {
+		http.Error(w, "GitOps functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	status := s.getGitOpsStatusFunc()
+	if !tenancy.FromContext(r.Context()).Admin {
+		status.Location = ""
+		status.LastError = ""
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleSyncGitOps syncs the configuration immediately
+func (s *Server) handleSyncGitOps(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.syncGitOpsFunc == nil {
+		http.Error(w, "GitOps functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	if err := s.syncGitOpsFunc(); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Sync failed: %v", err), http.StatusBadGateway)
+		return
+	}
+	s.sendSuccessResponse(w, "Configuration synced")
+}
@@ -0,0 +1,231 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"syslog-analyzer/graphql"
+	"syslog-analyzer/models"
+)
+
+// maxGraphQLRequest caps the size of a posted GraphQL request
+const maxGraphQLRequest = 1 << 20
+
+// handleGraphQL executes a read-only GraphQL query over sources, metrics and
+// their per-minute history. Queries are posted as {"query", "operationName",
+// "variables"} or passed as ?query= (with ?variables= as JSON) on GET.
+//
+// Root fields:
+//
+//	sources(name, tenant, group)            [Source]
+//	source(name)                            Source
+//	global                                  the global metrics
+//	history(source, from, to, step)         [HistoryPoint]
+//	reconciliation(from, to)                the reconciliation report
+//
+// A Source has every field of /api/metrics plus history(from, to, step) and
+// reconciliation(from, to). Times are RFC 3339 (default the last hour) and
+// step is in minutes (default 1).
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	if s.getMetricsFunc == nil {
+		http.Error(w, "Metrics function not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var req graphql.Request
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLRequest)).Decode(&req); err != nil {
+			s.sendErrorResponse(w, "Invalid GraphQL request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				s.sendErrorResponse(w, "Invalid variables: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if req.Query == "" {
+		s.sendErrorResponse(w, "Query is required", http.StatusBadRequest)
+		return
+	}
+	
+	schema := s.graphQLSchema(r)
+	response := schema.Execute(req)
+	
+	w.Header().Set("Content-Type", "application/json")
+	if response.Data == nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// graphQLSchema builds the query schema scoped to the request's tenant
+func (s *Server) graphQLSchema(r *http.Request) *graphql.Schema {
+	sources, global := s.scopedMetrics(r)
+	
+	findSource := func(name string) (models.SourceMetrics, bool) {
+		for _, source := range sources {
+			if source.Name == name {
+				return source, true
+			}
+		}
+		return models.SourceMetrics{}, false
+	}
+	
+	return &graphql.Schema{Query: map[string]graphql.Resolver{
+		"sources": func(args graphql.Args) (interface{}, error) {
+			name, err := args.String("name", "")
+			if err != nil {
+				return nil, err
+			}
+			tenant, err := args.String("tenant", "")
+			if err != nil {
+				return nil, err
+			}
+			group, err := args.String("group", "")
+			if err != nil {
+				return nil, err
+			}
+			
+			objects := []graphql.Object{}
+			for _, source := range sources {
+				if (name != "" && source.Name != name) || (tenant != "" && source.Tenant != tenant) || (group != "" && source.Group != group) {
+					continue
+				}
+				objects = append(objects, s.graphQLSource(source))
+			}
+			return objects, nil
+		},
+		"source": func(args graphql.Args) (interface{}, error) {
+			name, err := args.String("name", "")
+			if err != nil {
+				return nil, err
+			}
+			source, exists := findSource(name)
+			if !exists {
+				return nil, fmt.Errorf("source '%s' not found", name)
+			}
+			return s.graphQLSource(source), nil
+		},
+		"global": func(args graphql.Args) (interface{}, error) {
+			return global, nil
+		},
+		"history": func(args graphql.Args) (interface{}, error) {
+			name, err := args.String("source", "")
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := findSource(name); !exists {
+				return nil, fmt.Errorf("source '%s' not found", name)
+			}
+			return s.graphQLHistory(name, args)
+		},
+		"reconciliation": func(args graphql.Args) (interface{}, error) {
+			if s.getReconciliationFunc == nil {
+				return nil, fmt.Errorf("reconciliation not available")
+			}
+			from, to, err := graphQLRange(args)
+			if err != nil {
+				return nil, err
+			}
+			
+			report := models.ReconciliationReport{
+				From:    from.UTC(),
+				To:      to.UTC(),
+				Sources: []models.SourceReconciliation{},
+			}
+			for _, row := range s.getReconciliationFunc(from, to) {
+				if _, visible := findSource(row.Source); !visible {
+					continue
+				}
+				report.Sources = append(report.Sources, row)
+				report.Total.Add(row.ReconciliationCounts)
+			}
+			return report, nil
+		},
+	}}
+}
+
+// graphQLSource exposes a source's metrics with its history and
+// reconciliation resolved only when selected
+func (s *Server) graphQLSource(source models.SourceMetrics) graphql.Object {
+	name := source.Name
+	return graphql.Object{
+		Value: source,
+		Fields: map[string]graphql.Resolver{
+			"history": func(args graphql.Args) (interface{}, error) {
+				return s.graphQLHistory(name, args)
+			},
+			"reconciliation": func(args graphql.Args) (interface{}, error) {
+				if s.getReconciliationFunc == nil {
+					return nil, fmt.Errorf("reconciliation not available")
+				}
+				from, to, err := graphQLRange(args)
+				if err != nil {
+					return nil, err
+				}
+				for _, row := range s.getReconciliationFunc(from, to) {
+					if row.Source == name {
+						return row.ReconciliationCounts, nil
+					}
+				}
+				return nil, fmt.Errorf("source '%s' not found", name)
+			},
+		},
+	}
+}
+
+// graphQLHistory resolves the per-interval history of a source
+func (s *Server) graphQLHistory(name string, args graphql.Args) (interface{}, error) {
+	if s.getHistoryFunc == nil {
+		return nil, fmt.Errorf("history not available")
+	}
+	from, to, err := graphQLRange(args)
+	if err != nil {
+		return nil, err
+	}
+	step, err := args.Int("step", 1)
+	if err != nil {
+		return nil, err
+	}
+	if step < 1 {
+		return nil, fmt.Errorf("step must be at least 1 minute")
+	}
+	return s.getHistoryFunc(name, from, to, time.Duration(step)*time.Minute)
+}
+
+// graphQLRange reads the from and to arguments, defaulting to the last hour
+func graphQLRange(args graphql.Args) (time.Time, time.Time, error) {
+	to := time.Now()
+	value, err := args.String("to", "")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to %s (expected RFC 3339)", strconv.Quote(value))
+		}
+	}
+	
+	from := to.Add(-defaultReconciliationRange)
+	if value, err = args.String("from", ""); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from %s (expected RFC 3339)", strconv.Quote(value))
+		}
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	return from, to, nil
+}
@@ -4,13 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 
 	"syslog-analyzer/destinations"
+	"syslog-analyzer/exporter"
 	"syslog-analyzer/models"
 	"syslog-analyzer/pdf"
+	"syslog-analyzer/tenancy"
 )
 
 // handleDashboard serves the main dashboard HTML
@@ -19,11 +22,45 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 	w.Header().Set("Pragma", "no-cache")
 	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Security-Policy", contentSecurityPolicy(r))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Referrer-Policy", "no-referrer")
 	
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(HTMLContent))
 }
 
+// contentSecurityPolicy allows only the dashboard's own stylesheet, script and
+// API/WebSocket endpoints; inline scripts, styles and event handlers are blocked
+func contentSecurityPolicy(r *http.Request) string {
+	return strings.Join([]string{
+		"default-src 'none'",
+		"script-src 'self'",
+		"style-src 'self'",
+		"img-src 'self' data: blob:",
+		"connect-src 'self' ws://" + r.Host + " wss://" + r.Host,
+		"base-uri 'none'",
+		"form-action 'self'",
+		"frame-ancestors 'none'",
+	}, "; ")
+}
+
+// handleStatic serves the dashboard stylesheet and script by their content hashed names
+func (s *Server) handleStatic(w http.ResponseWriter, r *http.Request) {
+	asset, ok := staticAssets[mux.Vars(r)["file"]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	
+	w.Header().Set("Content-Type", asset.contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(asset.content)
+}
+
 // NOTE: handleWebSocket method removed from here - it's now in server.go
 
 // handleGetMetrics returns current metrics as JSON
@@ -33,7 +70,7 @@ func (s *Server) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	sources, global := s.getMetricsFunc()
+	sources, global := s.scopedMetrics(r)
 	
 	response := map[string]interface{}{
 		"sources": sources,
@@ -51,7 +88,7 @@ func (s *Server) handleGetSources(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	sources := s.getSourcesFunc()
+	sources := tenancy.FilterSources(requestScope(r), s.getSourcesFunc())
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(sources)
@@ -59,6 +96,9 @@ func (s *Server) handleGetSources(w http.ResponseWriter, r *http.Request) {
 
 // handleAddSource adds a new syslog source
 func (s *Server) handleAddSource(w http.ResponseWriter, r *http.Request) {
+	if s.configManaged(w) {
+		return
+	}
 	if s.addSourceFunc == nil || s.validateSourceFunc == nil {
 		http.Error(w, "Source functions not available", http.StatusInternalServerError)
 		return
@@ -73,6 +113,11 @@ func (s *Server) handleAddSource(w http.ResponseWriter, r *http.Request) {
 	// Set creation time
 	source.CreatedAt = time.Now()
 	
+	// Tenants can only create sources in their own workspace
+	if scope := tenancy.FromContext(r.Context()); !scope.Admin {
+		source.Tenant = scope.Tenant
+	}
+	
 	// Validate the source
 	if err := s.validateSourceFunc(source); err != nil {
 		s.sendErrorResponse(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
@@ -85,66 +130,171 @@ func (s *Server) handleAddSource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if s.getSourceFunc != nil {
+		if added, exists := s.getSourceFunc(source.Name); exists {
+			setResourceHeaders(w, "/api/sources/"+added.ID, added)
+		}
+	}
 	s.sendSuccessResponse(w, "Source added successfully")
 }
 
-// handleUpdateSource updates an existing syslog source
+// handleUpdateSource creates or replaces a syslog source addressed by name or
+// ID. Putting an unchanged source is a no-op; If-Match and If-None-Match
+// guard against overwriting concurrent changes.
 func (s *Server) handleUpdateSource(w http.ResponseWriter, r *http.Request) {
-	if s.updateSourceFunc == nil || s.validateSourceFunc == nil {
+	if s.configManaged(w) {
+		return
+	}
+	if s.putSourceFunc == nil || s.getSourceFunc == nil {
 		http.Error(w, "Source functions not available", http.StatusInternalServerError)
 		return
 	}
 	
 	vars := mux.Vars(r)
-	oldName := vars["name"]
-	if oldName == "" {
+	ref := vars["name"]
+	if ref == "" {
 		s.sendErrorResponse(w, "Source name is required", http.StatusBadRequest)
 		return
 	}
 	
+	existing, exists := s.getSourceFunc(ref)
+	if exists && !s.sourceAllowed(r, existing.Name) {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
 	var source models.SourceConfig
 	if err := json.NewDecoder(r.Body).Decode(&source); err != nil {
 		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
 		return
 	}
 	
-	// Preserve creation time if updating
-	if source.CreatedAt.IsZero() {
-		source.CreatedAt = time.Now()
+	// Tenants cannot move sources out of their workspace
+	if scope := tenancy.FromContext(r.Context()); !scope.Admin {
+		source.Tenant = scope.Tenant
 	}
 	
-	// Validate the updated source (skip duplicate name check if name hasn't changed)
-	if err := s.validateSourceFunc(source); err != nil && source.Name != oldName {
-		s.sendErrorResponse(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+	stored, created, err := s.putSourceFunc(ref, source, requestPrecondition(r))
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to update source: %v", err), resourceErrorStatus(err))
 		return
 	}
 	
-	// Update the source
-	if err := s.updateSourceFunc(oldName, source); err != nil {
-		s.sendErrorResponse(w, fmt.Sprintf("Failed to update source: %v", err), http.StatusInternalServerError)
+	setResourceHeaders(w, "/api/sources/"+stored.ID, stored)
+	if created {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Source created successfully",
+		})
 		return
 	}
-	
 	s.sendSuccessResponse(w, "Source updated successfully")
 }
 
+// handleSetSimulation switches a source between simulation and live delivery
+// without restarting it, so its queued events are kept
+func (s *Server) handleSetSimulation(w http.ResponseWriter, r *http.Request) {
+	if s.configManaged(w) {
+		return
+	}
+	if s.setSimulationFunc == nil {
+		http.Error(w, "Source functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	name := mux.Vars(r)["name"]
+	if !s.sourceAllowed(r, name) {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	var request struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Enabled == nil {
+		s.sendErrorResponse(w, "Request body must be {\"enabled\": true|false}", http.StatusBadRequest)
+		return
+	}
+	
+	if err := s.setSimulationFunc(name, *request.Enabled); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to switch simulation mode: %v", err), http.StatusNotFound)
+		return
+	}
+	
+	if *request.Enabled {
+		s.sendSuccessResponse(w, "Simulation mode enabled")
+	} else {
+		s.sendSuccessResponse(w, "Simulation mode disabled")
+	}
+}
+
+// handleSetPaused pauses or resumes delivery of a source; while paused it
+// keeps receiving and holds processed events back
+func (s *Server) handleSetPaused(w http.ResponseWriter, r *http.Request) {
+	if s.setPausedFunc == nil {
+		http.Error(w, "Source functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	name := mux.Vars(r)["name"]
+	if !s.sourceAllowed(r, name) {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	var request struct {
+		Paused *bool `json:"paused"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Paused == nil {
+		s.sendErrorResponse(w, "Request body must be {\"paused\": true|false}", http.StatusBadRequest)
+		return
+	}
+	
+	if err := s.setPausedFunc(name, *request.Paused); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to change delivery: %v", err), http.StatusNotFound)
+		return
+	}
+	
+	if *request.Paused {
+		s.sendSuccessResponse(w, "Delivery paused")
+	} else {
+		s.sendSuccessResponse(w, "Delivery resumed")
+	}
+}
+
 // handleDeleteSource deletes a syslog source
 func (s *Server) handleDeleteSource(w http.ResponseWriter, r *http.Request) {
+	if s.configManaged(w) {
+		return
+	}
 	if s.deleteSourceFunc == nil {
 		http.Error(w, "Delete function not available", http.StatusInternalServerError)
 		return
 	}
 	
 	vars := mux.Vars(r)
-	name := vars["name"]
-	if name == "" {
+	ref := vars["name"]
+	if ref == "" {
 		s.sendErrorResponse(w, "Source name is required", http.StatusBadRequest)
 		return
 	}
 	
+	name := ref
+	if s.getSourceFunc != nil {
+		if existing, exists := s.getSourceFunc(ref); exists {
+			name = existing.Name
+		}
+	}
+	if !s.sourceAllowed(r, name) {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
 	// Delete the source
-	if err := s.deleteSourceFunc(name); err != nil {
-		s.sendErrorResponse(w, fmt.Sprintf("Failed to delete source: %v", err), http.StatusInternalServerError)
+	if err := s.deleteSourceFunc(ref, requestPrecondition(r)); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to delete source: %v", err), resourceErrorStatus(err))
 		return
 	}
 	
@@ -185,11 +335,24 @@ func (s *Server) handleGenerateReport(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	// Get current metrics
-	sources, global := s.getMetricsFunc()
+	sources, global := s.scopedMetrics(r)
+	
+	// Office hours heatmaps, when the metrics history is running
+	var heatmaps []models.EPSHeatmap
+	if s.getEPSHeatmapsFunc != nil {
+		visible := make(map[string]bool, len(sources))
+		for _, source := range sources {
+			visible[source.Name] = true
+		}
+		now := time.Now()
+		heatmaps, _ = s.getEPSHeatmapsFunc(now.Add(-defaultHeatmapRange), now, time.Local, func(source string) bool {
+			return visible[source]
+		})
+	}
 	
 	// Generate PDF report
 	generator := pdf.NewGenerator()
-	pdfData, err := generator.GenerateReport(sources, global)
+	pdfData, err := generator.GenerateReport(sources, global, heatmaps)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to generate report: %v", err), http.StatusInternalServerError)
 		return
@@ -205,6 +368,44 @@ func (s *Server) handleGenerateReport(w http.ResponseWriter, r *http.Request) {
 	w.Write(pdfData)
 }
 
+// handlePrometheusMetrics serves metrics in the Prometheus text format
+func (s *Server) handlePrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.getMetricsFunc == nil {
+		http.Error(w, "Metrics function not available", http.StatusInternalServerError)
+		return
+	}
+	
+	sources, global := s.scopedMetrics(r)
+	
+	series := exporter.BuildSeries(sources, global)
+	if requestScope(r).Admin {
+		series = append(series, exporter.APISeries(s.apiMetrics.snapshot())...)
+	}
+	
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	exporter.WriteText(w, series)
+}
+
+// handleGrafanaDashboard returns an importable Grafana dashboard definition
+func (s *Server) handleGrafanaDashboard(w http.ResponseWriter, r *http.Request) {
+	dashboard := exporter.GrafanaDashboard(r.URL.Query().Get("datasource"))
+	
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("download") == "true" {
+		w.Header().Set("Content-Disposition", "attachment; filename=\"syslog_analyzer_grafana.json\"")
+	}
+	
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(dashboard)
+}
+
+// scopedMetrics returns the metrics visible to the caller
+func (s *Server) scopedMetrics(r *http.Request) ([]models.SourceMetrics, models.GlobalMetrics) {
+	sources, global := s.getMetricsFunc()
+	return tenancy.FilterMetrics(requestScope(r), sources, global)
+}
+
 // sendSuccessResponse sends a JSON success response
 func (s *Server) sendSuccessResponse(w http.ResponseWriter, message string) {
 	response := map[string]interface{}{
@@ -0,0 +1,254 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"syslog-analyzer/history"
+	"syslog-analyzer/models"
+)
+
+// defaultMetricsHistoryRange is the range returned when ?from= is omitted
+const defaultMetricsHistoryRange = 24 * time.Hour
+
+// defaultHeatmapRange is the history averaged into a heatmap when ?from= is
+// omitted: four of each weekday
+const defaultHeatmapRange = 28 * 24 * time.Hour
+
+// maxHistoryUpload caps the size of a metrics history export posted for import
+const maxHistoryUpload = 256 << 20
+
+// parseHistoryRange parses ?from= and ?to= as RFC 3339 timestamps, with to
+// defaulting to now and from to defaultFrom. It answers the request itself
+// when they are invalid.
+func (s *Server) parseHistoryRange(w http.ResponseWriter, r *http.Request, defaultFrom time.Time) (time.Time, time.Time, bool) {
+	query := r.URL.Query()
+	to := time.Now()
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			s.sendErrorResponse(w, "Invalid to (expected RFC 3339)", http.StatusBadRequest)
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+	from := defaultFrom
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			s.sendErrorResponse(w, "Invalid from (expected RFC 3339)", http.StatusBadRequest)
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		s.sendErrorResponse(w, "from must not be after to", http.StatusBadRequest)
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// historySources returns whether the metrics history of a source is part of
+// the response: the source of ?source=, or else every source the caller may
+// see. It answers the request itself when ?source= is not visible.
+func (s *Server) historySources(w http.ResponseWriter, r *http.Request) (func(source string) bool, bool) {
+	sourceName := r.URL.Query().Get("source")
+	if sourceName != "" && !s.sourceAllowed(r, sourceName) {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return nil, false
+	}
+	
+	allowed := make(map[string]bool)
+	return func(source string) bool {
+		if sourceName != "" {
+			return source == sourceName
+		}
+		if _, checked := allowed[source]; !checked {
+			allowed[source] = s.sourceAllowed(r, source)
+		}
+		return allowed[source]
+	}, true
+}
+
+// handleGetMetricsHistory returns persisted per-source metrics.
+// ?from= and ?to= take RFC 3339 timestamps (default the last day),
+// ?source= limits the points to one source and ?resolution=minute|hour
+// picks a tier (default by the length and age of the range).
+func (s *Server) handleGetMetricsHistory(w http.ResponseWriter, r *http.Request) {
+	if s.queryMetricsHistoryFunc == nil {
+		http.Error(w, "Metrics history functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	query := r.URL.Query()
+	from, to, ok := s.parseHistoryRange(w, r, time.Now().Add(-defaultMetricsHistoryRange))
+	if !ok {
+		return
+	}
+	resolution := query.Get("resolution")
+	switch resolution {
+	case "", models.HistoryMinute, models.HistoryHour:
+	default:
+		s.sendErrorResponse(w, "Invalid resolution (expected minute or hour)", http.StatusBadRequest)
+		return
+	}
+	
+	include, ok := s.historySources(w, r)
+	if !ok {
+		return
+	}
+	
+	points, resolution, err := s.queryMetricsHistoryFunc(from, to, resolution, include)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to query metrics history: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":       from.UTC(),
+		"to":         to.UTC(),
+		"resolution": resolution,
+		"points":     points,
+	})
+}
+
+// handleGetEPSHeatmap returns each source's average EPS by day of week and
+// hour of day. ?from= and ?to= take RFC 3339 timestamps (default the last
+// four weeks), ?source= limits the heatmaps to one source and ?tz= takes an
+// IANA time zone for the hours (default the server's).
+func (s *Server) handleGetEPSHeatmap(w http.ResponseWriter, r *http.Request) {
+	if s.getEPSHeatmapsFunc == nil {
+		http.Error(w, "Metrics history functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	location := time.Local
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		loaded, err := time.LoadLocation(tz)
+		if err != nil {
+			s.sendErrorResponse(w, fmt.Sprintf("Invalid tz: %v", err), http.StatusBadRequest)
+			return
+		}
+		location = loaded
+	}
+	from, to, ok := s.parseHistoryRange(w, r, time.Now().Add(-defaultHeatmapRange))
+	if !ok {
+		return
+	}
+	include, ok := s.historySources(w, r)
+	if !ok {
+		return
+	}
+	
+	heatmaps, err := s.getEPSHeatmapsFunc(from, to, location, include)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to compute heatmaps: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(heatmaps)
+}
+
+// handleGetHistoryStorage reports the retention and disk usage of each
+// metrics history tier
+func (s *Server) handleGetHistoryStorage(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getHistoryStorageFunc == nil {
+		http.Error(w, "Metrics history functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	status, err := s.getHistoryStorageFunc()
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to get metrics history storage: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleExportMetricsHistory downloads the metrics history of both tiers as
+// gzip-compressed JSON or CSV (?format=json|csv, default json). ?from= and
+// ?to= take RFC 3339 timestamps (default everything recorded) and ?source=
+// limits the export to one source.
+func (s *Server) handleExportMetricsHistory(w http.ResponseWriter, r *http.Request) {
+	if s.exportMetricsHistoryFunc == nil {
+		http.Error(w, "Metrics history functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = history.FormatJSON
+	case history.FormatJSON, history.FormatCSV:
+	default:
+		s.sendErrorResponse(w, "Invalid format (expected json or csv)", http.StatusBadRequest)
+		return
+	}
+	from, to, ok := s.parseHistoryRange(w, r, time.Time{})
+	if !ok {
+		return
+	}
+	include, ok := s.historySources(w, r)
+	if !ok {
+		return
+	}
+	
+	export, err := s.exportMetricsHistoryFunc(from, to, include)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to export metrics history: %v", err), http.StatusInternalServerError)
+		return
+	}
+	data, err := history.Encode(export, format)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to export metrics history: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", history.ExportFilename(from, to, format)))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.Write(data)
+}
+
+// handleImportMetricsHistory adds an export from this or another instance to
+// the metrics history. The body may be compressed or not; ?format=json|csv
+// is detected from the content when omitted.
+func (s *Server) handleImportMetricsHistory(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.importMetricsHistoryFunc == nil {
+		http.Error(w, "Metrics history functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxHistoryUpload))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read metrics history: %v", err), http.StatusBadRequest)
+		return
+	}
+	export, err := history.Decode(data, r.URL.Query().Get("format"))
+	if err != nil {
+		s.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	result, err := s.importMetricsHistoryFunc(export)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to import metrics history: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
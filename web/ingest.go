@@ -0,0 +1,139 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultRotationGrace is how long a rotated ingest token keeps working
+// when the request doesn't say
+const defaultRotationGrace = time.Hour
+
+// handleGetIngestTokens lists the ingest tokens of a source with their usage
+func (s *Server) handleGetIngestTokens(w http.ResponseWriter, r *http.Request) {
+	if s.getIngestTokensFunc == nil {
+		http.Error(w, "Ingest token functions not available", http.StatusInternalServerError)
+		return
+	}
+	source, exists := s.allowedSource(r, mux.Vars(r)["name"])
+	if !exists {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	tokens, err := s.getIngestTokensFunc(source.Name)
+	if err != nil {
+		s.sendErrorResponse(w, err.Error(), resourceErrorStatus(err))
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// handleIssueIngestToken issues a token for a new sender to a source. The
+// token is in the response only.
+func (s *Server) handleIssueIngestToken(w http.ResponseWriter, r *http.Request) {
+	if s.configReadOnly(w) {
+		return
+	}
+	if s.issueIngestTokenFunc == nil {
+		http.Error(w, "Ingest token functions not available", http.StatusInternalServerError)
+		return
+	}
+	source, exists := s.allowedSource(r, mux.Vars(r)["name"])
+	if !exists {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	issued, err := s.issueIngestTokenFunc(source.Name, req.Name)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to issue token: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issued)
+}
+
+// handleRotateIngestToken replaces a token by a new one for the same sender.
+// The old token keeps working for grace_seconds, an hour by default.
+func (s *Server) handleRotateIngestToken(w http.ResponseWriter, r *http.Request) {
+	if s.configReadOnly(w) {
+		return
+	}
+	if s.rotateIngestTokenFunc == nil {
+		http.Error(w, "Ingest token functions not available", http.StatusInternalServerError)
+		return
+	}
+	vars := mux.Vars(r)
+	source, exists := s.allowedSource(r, vars["name"])
+	if !exists {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	var req struct {
+		GraceSeconds *int `json:"grace_seconds"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	grace := defaultRotationGrace
+	if req.GraceSeconds != nil {
+		grace = time.Duration(*req.GraceSeconds) * time.Second
+	}
+	
+	issued, err := s.rotateIngestTokenFunc(source.Name, vars["id"], grace)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to rotate token: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issued)
+}
+
+// handleRevokeIngestToken cuts a sender's token off immediately
+func (s *Server) handleRevokeIngestToken(w http.ResponseWriter, r *http.Request) {
+	if s.configReadOnly(w) {
+		return
+	}
+	if s.revokeIngestTokenFunc == nil {
+		http.Error(w, "Ingest token functions not available", http.StatusInternalServerError)
+		return
+	}
+	vars := mux.Vars(r)
+	source, exists := s.allowedSource(r, vars["name"])
+	if !exists {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	if err := s.revokeIngestTokenFunc(source.Name, vars["id"]); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to revoke token: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Token revoked successfully")
+}
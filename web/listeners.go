@@ -0,0 +1,113 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"syslog-analyzer/models"
+)
+
+// handleGetListeners lists the running listeners (super-admin only)
+func (s *Server) handleGetListeners(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getListenersFunc == nil {
+		http.Error(w, "Listener functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.getListenersFunc())
+}
+
+// handleUpdateListener replaces the settings of a listener (super-admin only)
+func (s *Server) handleUpdateListener(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configReadOnly(w) {
+		return
+	}
+	if s.updateListenerFunc == nil {
+		http.Error(w, "Listener functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	vars := mux.Vars(r)
+	port, err := strconv.Atoi(vars["port"])
+	if err != nil {
+		http.Error(w, "Invalid port", http.StatusBadRequest)
+		return
+	}
+	
+	var settings models.ListenerConfig
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	settings.Protocol = vars["protocol"]
+	settings.Port = port
+	
+	if err := s.updateListenerFunc(settings); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to update listener: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Listener updated successfully")
+}
+
+// handleGetConflicts lists sources refused for colliding with another source
+// or a port the analyzer listens on (super-admin only)
+func (s *Server) handleGetConflicts(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getConflictsFunc == nil {
+		http.Error(w, "Listener functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.getConflictsFunc())
+}
+
+// kubernetesServicePort is a port entry of a Kubernetes Service spec
+type kubernetesServicePort struct {
+	Name       string `json:"name"`
+	Port       int    `json:"port"`
+	TargetPort int    `json:"targetPort"`
+	Protocol   string `json:"protocol"`
+}
+
+// handleGetServicePorts lists every port the analyzer binds and the
+// configured ports it does not (super-admin only). With format=kubernetes
+// the bound ports are returned as Service port entries, ready to template.
+func (s *Server) handleGetServicePorts(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getPortsFunc == nil {
+		http.Error(w, "Listener functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	ports := s.getPortsFunc()
+	w.Header().Set("Content-Type", "application/json")
+	
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		json.NewEncoder(w).Encode(ports)
+	case "kubernetes":
+		entries := []kubernetesServicePort{}
+		for _, port := range ports {
+			if port.State == "up" || port.State == "down" {
+				entries = append(entries, kubernetesServicePort{Name: port.Name, Port: port.Port, TargetPort: port.Port, Protocol: port.Protocol})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ports": entries})
+	default:
+		s.sendErrorResponse(w, fmt.Sprintf("Unknown format %q (expected json or kubernetes)", r.URL.Query().Get("format")), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,105 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"syslog-analyzer/models"
+)
+
+// logStreamInterval batches log lines so a burst is sent as one message
+const logStreamInterval = 250 * time.Millisecond
+
+// logUpgrader upgrades log viewer connections; like the metrics WebSocket it
+// accepts any origin and relies on the API token for access control
+var logUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// handleLogStream streams the analyzer's operational log over a WebSocket
+// (super-admin only): the buffered lines first, then new lines as they are
+// written. Each message is a JSON array of lines. Nothing here logs, as the
+// lines would feed back into the stream.
+func (s *Server) handleLogStream(w http.ResponseWriter, r *http.Request) {
+	if s.subscribeLogFunc == nil {
+		http.Error(w, "Log functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	// Browsers cannot set headers on WebSockets, so the token comes as a query
+	// parameter or the session cookie
+	if s.isMultiTenant() {
+		scope, _, ok := s.authenticate(r)
+		if !ok {
+			http.Error(w, "Invalid or missing API token", http.StatusUnauthorized)
+			return
+		}
+		if !scope.Admin {
+			http.Error(w, "Super-admin access required", http.StatusForbidden)
+			return
+		}
+	}
+	
+	conn, err := logUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	
+	recent, lines, cancel := s.subscribeLogFunc()
+	defer cancel()
+	
+	// The viewer sends nothing; reading only notices when it goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	
+	send := func(batch []models.LogLine) error {
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteJSON(batch)
+	}
+	if err := send(recent); err != nil {
+		return
+	}
+	
+	ticker := time.NewTicker(logStreamInterval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+	var batch []models.LogLine
+	for {
+		select {
+		case line := <-lines:
+			batch = append(batch, line)
+			
+		case <-ticker.C:
+			if len(batch) > 0 {
+				if err := send(batch); err != nil {
+					return
+				}
+				batch = batch[:0]
+				lastWrite = time.Now()
+			} else if time.Since(lastWrite) > 30*time.Second {
+				conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+					return
+				}
+				lastWrite = time.Now()
+			}
+			
+		case <-closed:
+			return
+		}
+	}
+}
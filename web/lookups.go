@@ -0,0 +1,103 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"syslog-analyzer/models"
+)
+
+// maxLookupUpload bounds the size of an uploaded lookup table
+const maxLookupUpload = 64 << 20
+
+// handleGetLookups lists the lookup tables and their load state (super-admin only)
+func (s *Server) handleGetLookups(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getLookupsFunc == nil {
+		http.Error(w, "Lookup functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.getLookupsFunc())
+}
+
+// handleUploadLookup creates or replaces a lookup table from the request body
+// (super-admin only). The format, key_field and path query parameters describe
+// the table; format defaults to csv.
+func (s *Server) handleUploadLookup(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configReadOnly(w) {
+		return
+	}
+	if s.uploadLookupFunc == nil {
+		http.Error(w, "Lookup functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	query := r.URL.Query()
+	table := models.LookupTableConfig{
+		Name:     mux.Vars(r)["name"],
+		Format:   query.Get("format"),
+		Path:     query.Get("path"),
+		KeyField: query.Get("key_field"),
+	}
+	
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxLookupUpload))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read lookup table: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	status, err := s.uploadLookupFunc(table, data)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to upload lookup table: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleReloadLookup re-reads a lookup table from its file (super-admin only)
+func (s *Server) handleReloadLookup(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.reloadLookupFunc == nil {
+		http.Error(w, "Lookup functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	status, err := s.reloadLookupFunc(mux.Vars(r)["name"])
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to reload lookup table: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleDeleteLookup removes a lookup table (super-admin only)
+func (s *Server) handleDeleteLookup(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configReadOnly(w) {
+		return
+	}
+	if s.deleteLookupFunc == nil {
+		http.Error(w, "Lookup functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	if err := s.deleteLookupFunc(mux.Vars(r)["name"]); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to delete lookup table: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Lookup table deleted successfully")
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"syslog-analyzer/models"
+)
+
+// handleGetSourceTemplates lists the templates onboarded devices get
+// (super-admin only)
+func (s *Server) handleGetSourceTemplates(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getSourceTemplatesFunc == nil {
+		http.Error(w, "Onboarding functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.getSourceTemplatesFunc())
+}
+
+// handlePutSourceTemplate creates or replaces a source template
+// (super-admin only)
+func (s *Server) handlePutSourceTemplate(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configManaged(w) {
+		return
+	}
+	if s.putSourceTemplateFunc == nil {
+		http.Error(w, "Onboarding functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var template models.SourceTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	template.Name = mux.Vars(r)["name"]
+	
+	stored, created, err := s.putSourceTemplateFunc(template, requestPrecondition(r))
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to store source template: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	setResourceHeaders(w, "/api/source-templates/"+stored.Name, stored)
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(stored)
+}
+
+// handleDeleteSourceTemplate removes a source template (super-admin only)
+func (s *Server) handleDeleteSourceTemplate(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configManaged(w) {
+		return
+	}
+	if s.deleteSourceTemplateFunc == nil {
+		http.Error(w, "Onboarding functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	if err := s.deleteSourceTemplateFunc(mux.Vars(r)["name"], requestPrecondition(r)); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to delete source template: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Source template deleted successfully")
+}
+
+// handleOnboard creates or updates the source of a device being deployed,
+// for provisioning tools to call with an admin token (super-admin only).
+// It answers 201 with the new source, or 200 when the device already had one.
+func (s *Server) handleOnboard(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configManaged(w) {
+		return
+	}
+	if s.onboardFunc == nil {
+		http.Error(w, "Onboarding functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var request models.OnboardRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	result, err := s.onboardFunc(request)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Onboarding failed: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	setResourceHeaders(w, "/api/sources/"+result.Source.Name, result.Source)
+	w.Header().Set("Content-Type", "application/json")
+	if result.Created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,60 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"syslog-analyzer/models"
+)
+
+// handleGetQuotas returns the quota definitions and their current consumption
+func (s *Server) handleGetQuotas(w http.ResponseWriter, r *http.Request) {
+	if s.getQuotasFunc == nil || s.getMetricsFunc == nil {
+		http.Error(w, "Quota functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	scope := requestScope(r)
+	quotas := []models.QuotaConfig{}
+	for _, quota := range s.getQuotasFunc() {
+		if scope.Admin || (quota.Tenant != "" && scope.Allows(quota.Tenant)) {
+			quotas = append(quotas, quota)
+		}
+	}
+	_, global := s.scopedMetrics(r)
+	status := global.Quotas
+	if status == nil {
+		status = []models.QuotaStatus{}
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"quotas": quotas,
+		"status": status,
+	})
+}
+
+// handleUpdateQuotas replaces all quota definitions (super-admin only)
+func (s *Server) handleUpdateQuotas(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configReadOnly(w) {
+		return
+	}
+	if s.updateQuotasFunc == nil {
+		http.Error(w, "Quota functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var quotas []models.QuotaConfig
+	if err := json.NewDecoder(r.Body).Decode(&quotas); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	if err := s.updateQuotasFunc(quotas); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to update quotas: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Quotas updated successfully")
+}
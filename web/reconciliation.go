@@ -0,0 +1,67 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"syslog-analyzer/models"
+)
+
+// defaultReconciliationRange is the range reconciled when ?from= is omitted
+const defaultReconciliationRange = time.Hour
+
+// handleGetReconciliation compares received against processed, sent and
+// dropped events per source. ?from= and ?to= take RFC 3339 timestamps
+// (default the last hour); ?source= limits the report to one source.
+func (s *Server) handleGetReconciliation(w http.ResponseWriter, r *http.Request) {
+	if s.getReconciliationFunc == nil {
+		http.Error(w, "Reconciliation function not available", http.StatusInternalServerError)
+		return
+	}
+	
+	query := r.URL.Query()
+	to := time.Now()
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			s.sendErrorResponse(w, "Invalid to (expected RFC 3339)", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultReconciliationRange)
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			s.sendErrorResponse(w, "Invalid from (expected RFC 3339)", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		s.sendErrorResponse(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+	
+	sourceName := query.Get("source")
+	scope := requestScope(r)
+	report := models.ReconciliationReport{
+		From:    from.UTC(),
+		To:      to.UTC(),
+		Sources: []models.SourceReconciliation{},
+	}
+	for _, row := range s.getReconciliationFunc(from, to) {
+		if sourceName != "" && row.Source != sourceName {
+			continue
+		}
+		if !scope.Allows(row.Tenant) {
+			continue
+		}
+		report.Sources = append(report.Sources, row)
+		report.Total.Add(row.ReconciliationCounts)
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
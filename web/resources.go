@@ -0,0 +1,148 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"syslog-analyzer/config"
+	"syslog-analyzer/models"
+)
+
+// requestPrecondition reads a request's If-Match and If-None-Match headers
+func requestPrecondition(r *http.Request) config.Precondition {
+	return config.Precondition{
+		IfMatch:     r.Header.Get("If-Match"),
+		IfNoneMatch: r.Header.Get("If-None-Match"),
+	}
+}
+
+// resourceErrorStatus maps an error of a resource update to its HTTP status
+func resourceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, config.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, config.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, config.ErrInvalid):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// setResourceHeaders sets the ETag and canonical location of a resource
+func setResourceHeaders(w http.ResponseWriter, location string, resource interface{}) {
+	w.Header().Set("ETag", config.ETag(resource))
+	w.Header().Set("Location", location)
+}
+
+// allowedSource resolves a source reference, a name or ID, the caller may see
+func (s *Server) allowedSource(r *http.Request, ref string) (models.SourceConfig, bool) {
+	if s.getSourceFunc == nil {
+		return models.SourceConfig{}, false
+	}
+	source, exists := s.getSourceFunc(ref)
+	if !exists || !s.sourceAllowed(r, source.Name) {
+		return models.SourceConfig{}, false
+	}
+	return source, true
+}
+
+// handleGetSource returns a single source, addressed by name or ID, with its ETag
+func (s *Server) handleGetSource(w http.ResponseWriter, r *http.Request) {
+	source, exists := s.allowedSource(r, mux.Vars(r)["name"])
+	if !exists {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	setResourceHeaders(w, "/api/sources/"+source.ID, source)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(source)
+}
+
+// handleGetDestination returns a single destination of a source with its ETag
+func (s *Server) handleGetDestination(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	source, exists := s.allowedSource(r, vars["name"])
+	if !exists {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	for _, dest := range source.Destinations {
+		if dest.ID == vars["id"] {
+			setResourceHeaders(w, "/api/sources/"+source.ID+"/destinations/"+dest.ID, dest)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(dest)
+			return
+		}
+	}
+	s.sendErrorResponse(w, "Destination not found", http.StatusNotFound)
+}
+
+// handlePutDestination creates or replaces a destination of a source under a
+// caller-chosen ID, restarting the source only when the destination changed
+func (s *Server) handlePutDestination(w http.ResponseWriter, r *http.Request) {
+	if s.configManaged(w) {
+		return
+	}
+	if s.putDestinationFunc == nil {
+		http.Error(w, "Destination functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	vars := mux.Vars(r)
+	source, exists := s.allowedSource(r, vars["name"])
+	if !exists {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	var dest models.Destination
+	if err := json.NewDecoder(r.Body).Decode(&dest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	stored, created, err := s.putDestinationFunc(source.Name, vars["id"], dest, requestPrecondition(r))
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to update destination: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	setResourceHeaders(w, "/api/sources/"+source.ID+"/destinations/"+stored.ID, stored)
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(stored)
+}
+
+// handleDeleteDestination removes a destination from a source
+func (s *Server) handleDeleteDestination(w http.ResponseWriter, r *http.Request) {
+	if s.configManaged(w) {
+		return
+	}
+	if s.deleteDestinationFunc == nil {
+		http.Error(w, "Destination functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	vars := mux.Vars(r)
+	source, exists := s.allowedSource(r, vars["name"])
+	if !exists {
+		s.sendErrorResponse(w, "Source not found", http.StatusNotFound)
+		return
+	}
+	
+	if err := s.deleteDestinationFunc(source.Name, vars["id"], requestPrecondition(r)); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to delete destination: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Destination deleted successfully")
+}
@@ -5,11 +5,17 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 
+	"syslog-analyzer/chargeback"
+	"syslog-analyzer/config"
 	"syslog-analyzer/models"
+	"syslog-analyzer/notifications"
+	"syslog-analyzer/tenancy"
 )
 
 // Server represents the web server for the dashboard
@@ -22,16 +28,149 @@ type Server struct {
 	getMetricsFunc    func() ([]models.SourceMetrics, models.GlobalMetrics)
 	getSourcesFunc    func() []models.SourceConfig
 	addSourceFunc     func(models.SourceConfig) error
-	updateSourceFunc  func(string, models.SourceConfig) error
-	deleteSourceFunc  func(string) error
+	putSourceFunc     func(string, models.SourceConfig, config.Precondition) (models.SourceConfig, bool, error)
+	deleteSourceFunc  func(string, config.Precondition) error
 	validateSourceFunc func(models.SourceConfig) error
+	setSimulationFunc  func(name string, enabled bool) error
+	setPausedFunc      func(name string, paused bool) error
+	
+	// Resource handler functions for conditional, idempotent updates
+	getSourceFunc         func(ref string) (models.SourceConfig, bool)
+	putDestinationFunc    func(sourceRef, id string, dest models.Destination, precondition config.Precondition) (models.Destination, bool, error)
+	deleteDestinationFunc func(sourceRef, id string, precondition config.Precondition) error
+	
+	// Alert handler functions
+	getAlertsFunc      func() ([]notifications.Alert, []notifications.Silence, error)
+	ackAlertFunc       func(rule, source, by string) (notifications.Alert, error)
+	addSilenceFunc     func(notifications.Silence) (notifications.Silence, error)
+	removeSilenceFunc  func(id string) error
+	
+	// Tenancy handler functions
+	multiTenantFunc    func() bool
+	resolveTokenFunc   func(token string) (tenancy.Scope, bool)
+	getTenantsFunc     func() []models.TenantConfig
+	addTenantFunc      func(models.TenantConfig) error
+	deleteTenantFunc   func(id string) error
+	
+	// Quota handler functions
+	getQuotasFunc      func() []models.QuotaConfig
+	updateQuotasFunc   func([]models.QuotaConfig) error
+	
+	// Chargeback handler functions
+	getChargebackFunc       func(month string) ([]chargeback.Usage, error)
+	getChargebackMonthsFunc func() []string
+	
+	// Capacity forecast handler functions
+	getForecastFunc   func(model string) (models.ForecastReport, error)
+	getComparisonFunc func(current, baseline models.IngestPeriod) (models.IngestComparison, error)
+	
+	// Unclaimed sender handler functions
+	getUnclaimedFunc    func() []models.UnclaimedSender
+	forgetUnclaimedFunc func(protocol string, port int, ip string) error
+	
+	// Listener handler functions
+	getListenersFunc   func() []models.ListenerStatus
+	updateListenerFunc func(models.ListenerConfig) error
+	getConflictsFunc   func() []models.PortConflict
+	getPortsFunc       func() []models.ServicePort
+	
+	// Lookup table handler functions
+	getLookupsFunc   func() []models.LookupTableStatus
+	uploadLookupFunc func(models.LookupTableConfig, []byte) (models.LookupTableStatus, error)
+	reloadLookupFunc func(name string) (models.LookupTableStatus, error)
+	deleteLookupFunc func(name string) error
+	
+	// Operational log handler function
+	subscribeLogFunc func() ([]models.LogLine, <-chan models.LogLine, func())
+	
+	// Global settings handler functions
+	getSettingsFunc    func() models.SettingsStatus
+	updateSettingsFunc func(models.Settings) (models.SettingsStatus, error)
+	
+	// Filter set handler functions
+	getFilterSetsFunc   func() []models.FilterSet
+	putFilterSetFunc    func(models.FilterSet, config.Precondition) (models.FilterSet, bool, error)
+	deleteFilterSetFunc func(name string, precondition config.Precondition) error
+	
+	// Onboarding handler functions
+	getSourceTemplatesFunc   func() []models.SourceTemplate
+	putSourceTemplateFunc    func(models.SourceTemplate, config.Precondition) (models.SourceTemplate, bool, error)
+	deleteSourceTemplateFunc func(name string, precondition config.Precondition) error
+	onboardFunc              func(models.OnboardRequest) (models.OnboardResult, error)
+	
+	// CMDB sync handler functions
+	getCMDBStatusFunc func() models.CMDBSyncStatus
+	syncCMDBFunc      func(dryRun bool) (models.CMDBSyncReport, error)
+	
+	// Filter impact handler function
+	estimateFilterImpactFunc func(models.FilterImpactRequest) ([]models.FilterImpact, error)
+	
+	// Classification handler functions
+	getClassificationRulesFunc    func() []models.ClassificationRule
+	updateClassificationRulesFunc func([]models.ClassificationRule) error
+	
+	// Reconciliation and history handler functions
+	getReconciliationFunc func(from, to time.Time) []models.SourceReconciliation
+	getHistoryFunc        func(name string, from, to time.Time, step time.Duration) ([]models.HistoryPoint, error)
+	
+	// Cumulative counter handler functions
+	resetCountersFunc    func(source, by string) (models.CounterResetEvent, error)
+	getCounterResetsFunc func() []models.CounterResetEvent
+	
+	// Metrics history store handler functions
+	queryMetricsHistoryFunc  func(from, to time.Time, resolution string, include func(source string) bool) ([]models.MetricsPoint, string, error)
+	getHistoryStorageFunc    func() (models.HistoryStorageStatus, error)
+	exportMetricsHistoryFunc func(from, to time.Time, include func(source string) bool) (models.MetricsHistoryExport, error)
+	importMetricsHistoryFunc func(models.MetricsHistoryExport) (models.MetricsHistoryImport, error)
+	getEPSHeatmapsFunc       func(from, to time.Time, location *time.Location, include func(source string) bool) ([]models.EPSHeatmap, error)
+	
+	// GitOps handler functions
+	getGitOpsStatusFunc func() models.GitOpsStatus
+	syncGitOpsFunc      func() error
+	
+	// Backup handler functions
+	getBackupStatusFunc func() models.BackupStatus
+	backupNowFunc       func() (models.BackupInfo, error)
+	restoreBackupFunc   func(name string) (models.BackupRestore, error)
+	
+	// Configuration export and diff handler functions
+	exportConfigFunc func() (models.ConfigExport, error)
+	
+	// Configuration mode handler function
+	getConfigModeFunc func() models.ConfigMode
+	diffConfigFunc   func(proposed *models.Config) (models.ConfigDiff, error)
+	
+	// Ingest token handler functions
+	getIngestTokensFunc   func(sourceRef string) ([]models.IngestTokenStatus, error)
+	issueIngestTokenFunc  func(sourceRef, name string) (models.IssuedIngestToken, error)
+	rotateIngestTokenFunc func(sourceRef, id string, grace time.Duration) (models.IssuedIngestToken, error)
+	revokeIngestTokenFunc func(sourceRef, id string) error
+	
+	// Destination credential handler functions
+	rotateCredentialsFunc  func(scope tenancy.Scope, destination string, rotation models.CredentialRotation) ([]models.CredentialAuditEntry, error)
+	getCredentialAuditFunc func(scope tenancy.Scope, limit int) ([]models.CredentialAuditEntry, error)
+	
+	// Diagnostics handler function
+	getDiagnosticsFunc func() models.RuntimeStatus
+	
+	// Response compression, set before the server starts
+	compression models.CompressionConfig
+	gzipPool    sync.Pool
+	
+	// Per-endpoint request counters and latencies
+	apiMetrics *apiMetrics
+	
+	// Dashboard sessions signed in with an API token
+	sessions *sessionStore
 }
 
 // NewServer creates a new web server instance
 func NewServer() *Server {
 	server := &Server{
-		router:    mux.NewRouter(),
-		wsManager: NewWebSocketManager(),
+		router:     mux.NewRouter(),
+		wsManager:  NewWebSocketManager(),
+		apiMetrics: newAPIMetrics(),
+		sessions:   newSessionStore(),
 	}
 	
 	server.setupRoutes()
@@ -43,23 +182,287 @@ func (s *Server) SetHandlers(
 	getMetrics func() ([]models.SourceMetrics, models.GlobalMetrics),
 	getSources func() []models.SourceConfig,
 	addSource func(models.SourceConfig) error,
-	updateSource func(string, models.SourceConfig) error,
-	deleteSource func(string) error,
+	putSource func(string, models.SourceConfig, config.Precondition) (models.SourceConfig, bool, error),
+	deleteSource func(string, config.Precondition) error,
 	validateSource func(models.SourceConfig) error,
 ) {
 	s.getMetricsFunc = getMetrics
 	s.getSourcesFunc = getSources
 	s.addSourceFunc = addSource
-	s.updateSourceFunc = updateSource
+	s.putSourceFunc = putSource
 	s.deleteSourceFunc = deleteSource
 	s.validateSourceFunc = validateSource
 }
 
+// SetResourceHandlers sets the handler functions for reading single sources
+// and updating their destinations individually
+func (s *Server) SetResourceHandlers(
+	getSource func(ref string) (models.SourceConfig, bool),
+	putDestination func(sourceRef, id string, dest models.Destination, precondition config.Precondition) (models.Destination, bool, error),
+	deleteDestination func(sourceRef, id string, precondition config.Precondition) error,
+) {
+	s.getSourceFunc = getSource
+	s.putDestinationFunc = putDestination
+	s.deleteDestinationFunc = deleteDestination
+}
+
+// SetSimulationHandler sets the handler function for switching a source's
+// simulation mode while it runs
+func (s *Server) SetSimulationHandler(setSimulation func(name string, enabled bool) error) {
+	s.setSimulationFunc = setSimulation
+}
+
+// SetPauseHandler sets the handler function for pausing and resuming a
+// source's delivery
+func (s *Server) SetPauseHandler(setPaused func(name string, paused bool) error) {
+	s.setPausedFunc = setPaused
+}
+
+// SetAlertHandlers sets the handler functions for alert management
+func (s *Server) SetAlertHandlers(
+	getAlerts func() ([]notifications.Alert, []notifications.Silence, error),
+	ackAlert func(rule, source, by string) (notifications.Alert, error),
+	addSilence func(notifications.Silence) (notifications.Silence, error),
+	removeSilence func(id string) error,
+) {
+	s.getAlertsFunc = getAlerts
+	s.ackAlertFunc = ackAlert
+	s.addSilenceFunc = addSilence
+	s.removeSilenceFunc = removeSilence
+}
+
+// SetTenancyHandlers sets the handler functions for multi-tenant access control
+func (s *Server) SetTenancyHandlers(
+	multiTenant func() bool,
+	resolveToken func(token string) (tenancy.Scope, bool),
+	getTenants func() []models.TenantConfig,
+	addTenant func(models.TenantConfig) error,
+	deleteTenant func(id string) error,
+) {
+	s.multiTenantFunc = multiTenant
+	s.resolveTokenFunc = resolveToken
+	s.getTenantsFunc = getTenants
+	s.addTenantFunc = addTenant
+	s.deleteTenantFunc = deleteTenant
+}
+
+// SetQuotaHandlers sets the handler functions for tenant and group quotas
+func (s *Server) SetQuotaHandlers(
+	getQuotas func() []models.QuotaConfig,
+	updateQuotas func([]models.QuotaConfig) error,
+) {
+	s.getQuotasFunc = getQuotas
+	s.updateQuotasFunc = updateQuotas
+}
+
+// SetChargebackHandlers sets the handler functions for chargeback exports
+func (s *Server) SetChargebackHandlers(
+	getChargeback func(month string) ([]chargeback.Usage, error),
+	getChargebackMonths func() []string,
+) {
+	s.getChargebackFunc = getChargeback
+	s.getChargebackMonthsFunc = getChargebackMonths
+}
+
+// SetForecastHandlers sets the handler functions for the capacity forecast
+// and period comparisons of the daily ingest history
+func (s *Server) SetForecastHandlers(
+	getForecast func(model string) (models.ForecastReport, error),
+	getComparison func(current, baseline models.IngestPeriod) (models.IngestComparison, error),
+) {
+	s.getForecastFunc = getForecast
+	s.getComparisonFunc = getComparison
+}
+
+// SetUnclaimedHandlers sets the handler functions for unclaimed sender discovery
+func (s *Server) SetUnclaimedHandlers(
+	getUnclaimed func() []models.UnclaimedSender,
+	forgetUnclaimed func(protocol string, port int, ip string) error,
+) {
+	s.getUnclaimedFunc = getUnclaimed
+	s.forgetUnclaimedFunc = forgetUnclaimed
+}
+
+// SetListenerHandlers sets the handler functions for listener settings
+func (s *Server) SetListenerHandlers(
+	getListeners func() []models.ListenerStatus,
+	updateListener func(models.ListenerConfig) error,
+	getConflicts func() []models.PortConflict,
+	getPorts func() []models.ServicePort,
+) {
+	s.getListenersFunc = getListeners
+	s.updateListenerFunc = updateListener
+	s.getConflictsFunc = getConflicts
+	s.getPortsFunc = getPorts
+}
+
+// SetLookupHandlers sets the handler functions for enrichment lookup tables
+func (s *Server) SetLookupHandlers(
+	getLookups func() []models.LookupTableStatus,
+	uploadLookup func(models.LookupTableConfig, []byte) (models.LookupTableStatus, error),
+	reloadLookup func(name string) (models.LookupTableStatus, error),
+	deleteLookup func(name string) error,
+) {
+	s.getLookupsFunc = getLookups
+	s.uploadLookupFunc = uploadLookup
+	s.reloadLookupFunc = reloadLookup
+	s.deleteLookupFunc = deleteLookup
+}
+
+// SetLogHandler sets the handler function streaming the operational log
+func (s *Server) SetLogHandler(subscribe func() ([]models.LogLine, <-chan models.LogLine, func())) {
+	s.subscribeLogFunc = subscribe
+}
+
+// SetSettingsHandlers sets the handler functions for the global settings
+func (s *Server) SetSettingsHandlers(
+	getSettings func() models.SettingsStatus,
+	updateSettings func(models.Settings) (models.SettingsStatus, error),
+) {
+	s.getSettingsFunc = getSettings
+	s.updateSettingsFunc = updateSettings
+}
+
+// SetFilterSetHandlers sets the handler functions for named filter sets
+func (s *Server) SetFilterSetHandlers(
+	getFilterSets func() []models.FilterSet,
+	putFilterSet func(models.FilterSet, config.Precondition) (models.FilterSet, bool, error),
+	deleteFilterSet func(name string, precondition config.Precondition) error,
+) {
+	s.getFilterSetsFunc = getFilterSets
+	s.putFilterSetFunc = putFilterSet
+	s.deleteFilterSetFunc = deleteFilterSet
+}
+
+// SetOnboardingHandlers sets the handler functions for source templates and
+// device onboarding
+func (s *Server) SetOnboardingHandlers(
+	getTemplates func() []models.SourceTemplate,
+	putTemplate func(models.SourceTemplate, config.Precondition) (models.SourceTemplate, bool, error),
+	deleteTemplate func(name string, precondition config.Precondition) error,
+	onboard func(models.OnboardRequest) (models.OnboardResult, error),
+) {
+	s.getSourceTemplatesFunc = getTemplates
+	s.putSourceTemplateFunc = putTemplate
+	s.deleteSourceTemplateFunc = deleteTemplate
+	s.onboardFunc = onboard
+}
+
+// SetCMDBHandlers sets the handler functions for the CMDB inventory sync
+func (s *Server) SetCMDBHandlers(getStatus func() models.CMDBSyncStatus, sync func(dryRun bool) (models.CMDBSyncReport, error)) {
+	s.getCMDBStatusFunc = getStatus
+	s.syncCMDBFunc = sync
+}
+
+// SetClassificationHandlers sets the handler functions for data classification rules
+func (s *Server) SetClassificationHandlers(
+	getClassificationRules func() []models.ClassificationRule,
+	updateClassificationRules func([]models.ClassificationRule) error,
+) {
+	s.getClassificationRulesFunc = getClassificationRules
+	s.updateClassificationRulesFunc = updateClassificationRules
+}
+
+// SetFilterImpactHandler sets the handler function for what-if filter estimates
+func (s *Server) SetFilterImpactHandler(estimateFilterImpact func(models.FilterImpactRequest) ([]models.FilterImpact, error)) {
+	s.estimateFilterImpactFunc = estimateFilterImpact
+}
+
+// SetReconciliationHandler sets the handler function for delivery reconciliation
+func (s *Server) SetReconciliationHandler(getReconciliation func(from, to time.Time) []models.SourceReconciliation) {
+	s.getReconciliationFunc = getReconciliation
+}
+
+// SetHistoryHandler sets the handler function for per-source history
+func (s *Server) SetHistoryHandler(getHistory func(name string, from, to time.Time, step time.Duration) ([]models.HistoryPoint, error)) {
+	s.getHistoryFunc = getHistory
+}
+
+// SetMetricsHistoryHandlers sets the handler functions for the persisted
+// metrics history
+func (s *Server) SetMetricsHistoryHandlers(
+	queryMetricsHistory func(from, to time.Time, resolution string, include func(source string) bool) ([]models.MetricsPoint, string, error),
+	getHistoryStorage func() (models.HistoryStorageStatus, error),
+	exportMetricsHistory func(from, to time.Time, include func(source string) bool) (models.MetricsHistoryExport, error),
+	importMetricsHistory func(models.MetricsHistoryExport) (models.MetricsHistoryImport, error),
+	getEPSHeatmaps func(from, to time.Time, location *time.Location, include func(source string) bool) ([]models.EPSHeatmap, error),
+) {
+	s.queryMetricsHistoryFunc = queryMetricsHistory
+	s.getHistoryStorageFunc = getHistoryStorage
+	s.exportMetricsHistoryFunc = exportMetricsHistory
+	s.importMetricsHistoryFunc = importMetricsHistory
+	s.getEPSHeatmapsFunc = getEPSHeatmaps
+}
+
+// SetCounterHandlers sets the handler functions for cumulative source counters
+func (s *Server) SetCounterHandlers(
+	resetCounters func(source, by string) (models.CounterResetEvent, error),
+	getCounterResets func() []models.CounterResetEvent,
+) {
+	s.resetCountersFunc = resetCounters
+	s.getCounterResetsFunc = getCounterResets
+}
+
+// SetGitOpsHandlers sets the handler functions for GitOps config sync
+func (s *Server) SetGitOpsHandlers(getStatus func() models.GitOpsStatus, sync func() error) {
+	s.getGitOpsStatusFunc = getStatus
+	s.syncGitOpsFunc = sync
+}
+
+// SetBackupHandlers sets the handler functions for configuration backups
+func (s *Server) SetBackupHandlers(getStatus func() models.BackupStatus, backupNow func() (models.BackupInfo, error), restore func(name string) (models.BackupRestore, error)) {
+	s.getBackupStatusFunc = getStatus
+	s.backupNowFunc = backupNow
+	s.restoreBackupFunc = restore
+}
+
+// SetConfigModeHandler sets the handler function reporting whether the
+// configuration is read-only
+func (s *Server) SetConfigModeHandler(getMode func() models.ConfigMode) {
+	s.getConfigModeFunc = getMode
+}
+
+// SetExportHandlers sets the handler functions for configuration export and diff
+func (s *Server) SetExportHandlers(exportConfig func() (models.ConfigExport, error), diffConfig func(proposed *models.Config) (models.ConfigDiff, error)) {
+	s.exportConfigFunc = exportConfig
+	s.diffConfigFunc = diffConfig
+}
+
+// SetIngestTokenHandlers sets the handler functions for per-source ingest
+// tokens. Until the HTTP ingest receiver exists the tokens only provision senders.
+func (s *Server) SetIngestTokenHandlers(
+	getTokens func(sourceRef string) ([]models.IngestTokenStatus, error),
+	issueToken func(sourceRef, name string) (models.IssuedIngestToken, error),
+	rotateToken func(sourceRef, id string, grace time.Duration) (models.IssuedIngestToken, error),
+	revokeToken func(sourceRef, id string) error,
+) {
+	s.getIngestTokensFunc = getTokens
+	s.issueIngestTokenFunc = issueToken
+	s.rotateIngestTokenFunc = rotateToken
+	s.revokeIngestTokenFunc = revokeToken
+}
+
+// SetCredentialHandlers sets the handler functions for destination
+// credential rotation and its audit trail
+func (s *Server) SetCredentialHandlers(
+	rotateCredentials func(scope tenancy.Scope, destination string, rotation models.CredentialRotation) ([]models.CredentialAuditEntry, error),
+	getCredentialAudit func(scope tenancy.Scope, limit int) ([]models.CredentialAuditEntry, error),
+) {
+	s.rotateCredentialsFunc = rotateCredentials
+	s.getCredentialAuditFunc = getCredentialAudit
+}
+
+// SetDiagnosticsHandler sets the handler function for runtime diagnostics
+func (s *Server) SetDiagnosticsHandler(getDiagnostics func() models.RuntimeStatus) {
+	s.getDiagnosticsFunc = getDiagnostics
+}
+
 // setupRoutes configures all the HTTP routes
 func (s *Server) setupRoutes() {
 	// WebSocket endpoint - COMPLETELY SEPARATE, NO MIDDLEWARE
 	wsRouter := mux.NewRouter()
 	wsRouter.HandleFunc("/ws", s.handleWebSocketRaw).Methods("GET")
+	wsRouter.HandleFunc("/ws/logs", s.handleLogStream).Methods("GET")
 	
 	// All other routes with middleware
 	mainRouter := mux.NewRouter()
@@ -67,20 +470,107 @@ func (s *Server) setupRoutes() {
 	// Static files and dashboard
 	mainRouter.HandleFunc("/", s.handleDashboard).Methods("GET")
 	mainRouter.HandleFunc("/dashboard", s.handleDashboard).Methods("GET")
+	mainRouter.HandleFunc("/static/{file}", s.handleStatic).Methods("GET")
 	
 	// API endpoints
 	api := mainRouter.PathPrefix("/api").Subrouter()
 	api.HandleFunc("/metrics", s.handleGetMetrics).Methods("GET")
 	api.HandleFunc("/sources", s.handleGetSources).Methods("GET")
 	api.HandleFunc("/sources", s.handleAddSource).Methods("POST")
+	api.HandleFunc("/sources/{name}", s.handleGetSource).Methods("GET")
 	api.HandleFunc("/sources/{name}", s.handleUpdateSource).Methods("PUT")
 	api.HandleFunc("/sources/{name}", s.handleDeleteSource).Methods("DELETE")
+	api.HandleFunc("/sources/{name}/simulation", s.handleSetSimulation).Methods("PUT")
+	api.HandleFunc("/sources/{name}/pause", s.handleSetPaused).Methods("PUT")
+	api.HandleFunc("/sources/{name}/destinations/{id}", s.handleGetDestination).Methods("GET")
+	api.HandleFunc("/sources/{name}/destinations/{id}", s.handlePutDestination).Methods("PUT")
+	api.HandleFunc("/sources/{name}/destinations/{id}", s.handleDeleteDestination).Methods("DELETE")
 	api.HandleFunc("/destinations/test", s.handleTestDestination).Methods("POST")
+	api.HandleFunc("/destinations/{id}/files", s.handleGetDestinationFiles).Methods("GET")
+	api.HandleFunc("/destinations/credentials/audit", s.handleGetCredentialAudit).Methods("GET")
+	api.HandleFunc("/destinations/{name}/rotate", s.handleRotateCredentials).Methods("POST")
 	api.HandleFunc("/report", s.handleGenerateReport).Methods("GET")
+	api.HandleFunc("/snapshot", s.handleSnapshot).Methods("GET")
+	api.HandleFunc("/grafana/dashboard", s.handleGrafanaDashboard).Methods("GET")
+	api.HandleFunc("/alerts", s.handleGetAlerts).Methods("GET")
+	api.HandleFunc("/alerts/ack", s.handleAckAlert).Methods("POST")
+	api.HandleFunc("/alerts/silences", s.handleAddSilence).Methods("POST")
+	api.HandleFunc("/alerts/silences/{id}", s.handleRemoveSilence).Methods("DELETE")
+	api.HandleFunc("/tenants", s.handleGetTenants).Methods("GET")
+	api.HandleFunc("/tenants", s.handleAddTenant).Methods("POST")
+	api.HandleFunc("/tenants/{id}", s.handleDeleteTenant).Methods("DELETE")
+	api.HandleFunc("/whoami", s.handleWhoAmI).Methods("GET")
+	api.HandleFunc("/quotas", s.handleGetQuotas).Methods("GET")
+	api.HandleFunc("/quotas", s.handleUpdateQuotas).Methods("PUT")
+	api.HandleFunc("/chargeback", s.handleGetChargeback).Methods("GET")
+	api.HandleFunc("/chargeback/months", s.handleGetChargebackMonths).Methods("GET")
+	api.HandleFunc("/costs", s.handleGetCosts).Methods("GET")
+	api.HandleFunc("/forecast", s.handleGetForecast).Methods("GET")
+	api.HandleFunc("/compare", s.handleGetComparison).Methods("GET")
+	api.HandleFunc("/unclaimed", s.handleGetUnclaimed).Methods("GET")
+	api.HandleFunc("/unclaimed", s.handleForgetUnclaimed).Methods("DELETE")
+	api.HandleFunc("/listeners", s.handleGetListeners).Methods("GET")
+	api.HandleFunc("/listeners/conflicts", s.handleGetConflicts).Methods("GET")
+	api.HandleFunc("/listeners/ports", s.handleGetServicePorts).Methods("GET")
+	api.HandleFunc("/listeners/{protocol}/{port}", s.handleUpdateListener).Methods("PUT")
+	api.HandleFunc("/lookups", s.handleGetLookups).Methods("GET")
+	api.HandleFunc("/lookups/{name}", s.handleUploadLookup).Methods("PUT")
+	api.HandleFunc("/lookups/{name}/reload", s.handleReloadLookup).Methods("POST")
+	api.HandleFunc("/lookups/{name}", s.handleDeleteLookup).Methods("DELETE")
+	api.HandleFunc("/settings", s.handleGetSettings).Methods("GET")
+	api.HandleFunc("/settings", s.handleUpdateSettings).Methods("PUT")
+	api.HandleFunc("/filters/validate", s.handleValidateFilters).Methods("POST")
+	api.HandleFunc("/filters/impact", s.handleEstimateFilterImpact).Methods("POST")
+	api.HandleFunc("/filter-sets", s.handleGetFilterSets).Methods("GET")
+	api.HandleFunc("/filter-sets/{name}", s.handlePutFilterSet).Methods("PUT")
+	api.HandleFunc("/filter-sets/{name}", s.handleDeleteFilterSet).Methods("DELETE")
+	api.HandleFunc("/source-templates", s.handleGetSourceTemplates).Methods("GET")
+	api.HandleFunc("/source-templates/{name}", s.handlePutSourceTemplate).Methods("PUT")
+	api.HandleFunc("/source-templates/{name}", s.handleDeleteSourceTemplate).Methods("DELETE")
+	api.HandleFunc("/onboard", s.handleOnboard).Methods("POST")
+	api.HandleFunc("/cmdb", s.handleGetCMDB).Methods("GET")
+	api.HandleFunc("/cmdb/sync", s.handleSyncCMDB).Methods("POST")
+	api.HandleFunc("/classification", s.handleGetClassification).Methods("GET")
+	api.HandleFunc("/classification/rules", s.handleUpdateClassificationRules).Methods("PUT")
+	api.HandleFunc("/reconciliation", s.handleGetReconciliation).Methods("GET")
+	api.HandleFunc("/history", s.handleGetMetricsHistory).Methods("GET")
+	api.HandleFunc("/history/storage", s.handleGetHistoryStorage).Methods("GET")
+	api.HandleFunc("/history/export", s.handleExportMetricsHistory).Methods("GET")
+	api.HandleFunc("/history/import", s.handleImportMetricsHistory).Methods("POST")
+	api.HandleFunc("/history/heatmap", s.handleGetEPSHeatmap).Methods("GET")
+	api.HandleFunc("/sources/{name}/counters/reset", s.handleResetCounters).Methods("POST")
+	// Groundwork: tokens are issued ahead of the planned HTTP ingest receiver, and nothing checks them yet
+	api.HandleFunc("/sources/{name}/ingest-tokens", s.handleGetIngestTokens).Methods("GET")
+	api.HandleFunc("/sources/{name}/ingest-tokens", s.handleIssueIngestToken).Methods("POST")
+	api.HandleFunc("/sources/{name}/ingest-tokens/{id}/rotate", s.handleRotateIngestToken).Methods("POST")
+	api.HandleFunc("/sources/{name}/ingest-tokens/{id}", s.handleRevokeIngestToken).Methods("DELETE")
+	api.HandleFunc("/counters/resets", s.handleGetCounterResets).Methods("GET")
+	api.HandleFunc("/diagnostics", s.handleGetDiagnostics).Methods("GET")
+	api.HandleFunc("/diagnostics/api", s.handleGetAPIMetrics).Methods("GET")
+	api.HandleFunc("/session", s.handleCreateSession).Methods("POST")
+	api.HandleFunc("/session", s.handleGetSession).Methods("GET")
+	api.HandleFunc("/session", s.handleDeleteSession).Methods("DELETE")
+	api.HandleFunc("/sessions", s.handleDeleteSessions).Methods("DELETE")
+	api.HandleFunc("/gitops", s.handleGetGitOps).Methods("GET")
+	api.HandleFunc("/gitops/sync", s.handleSyncGitOps).Methods("POST")
+	api.HandleFunc("/backups", s.handleGetBackups).Methods("GET")
+	api.HandleFunc("/backups", s.handleBackupNow).Methods("POST")
+	api.HandleFunc("/backups/{name}/restore", s.handleRestoreBackup).Methods("POST")
+	api.HandleFunc("/config/export", s.handleExportConfig).Methods("GET")
+	api.HandleFunc("/config/diff", s.handleDiffConfig).Methods("POST")
+	api.HandleFunc("/config/mode", s.handleGetConfigMode).Methods("GET")
+	
+	// Prometheus scrape endpoint
+	mainRouter.HandleFunc("/metrics", s.handlePrometheusMetrics).Methods("GET")
+	
+	// GraphQL query endpoint
+	mainRouter.HandleFunc("/graphql", s.handleGraphQL).Methods("GET", "POST")
 	
 	// Apply middleware to main router only
 	mainRouter.Use(s.corsMiddleware)
+	mainRouter.Use(s.authMiddleware)
 	mainRouter.Use(s.loggingMiddleware)
+	mainRouter.Use(s.gzipMiddleware)
 	
 	// Combine routers: WebSocket first (no middleware), then main router (with middleware)
 	s.router = mux.NewRouter()
@@ -99,8 +589,20 @@ func (s *Server) handleWebSocketRaw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// Resolve the tenant scope before upgrading; browsers cannot set headers on
+	// WebSockets, so the token comes as a query parameter or the session cookie
+	scope := tenancy.AdminScope
+	if s.isMultiTenant() {
+		var ok bool
+		scope, _, ok = s.authenticate(r)
+		if !ok {
+			http.Error(w, "Invalid or missing API token", http.StatusUnauthorized)
+			return
+		}
+	}
+	
 	// Handle the WebSocket upgrade with completely raw ResponseWriter
-	s.wsManager.HandleWebSocket(w, r)
+	s.wsManager.HandleWebSocket(w, r, scope)
 }
 
 // Start starts the web server on the specified port
@@ -161,15 +663,17 @@ func (s *Server) startMetricsBroadcast() {
 		if s.getMetricsFunc != nil && s.wsManager != nil {
 			sources, global := s.getMetricsFunc()
 			
-			data := map[string]interface{}{
-				"sources": sources,
-				"global":  global,
-			}
-			
 			// Only broadcast if we have connected clients
 			clientCount := s.wsManager.GetClientCount()
 			if clientCount > 0 {
-				s.wsManager.Broadcast(data)
+				// Each client only receives the sources its tenant scope allows
+				s.wsManager.BroadcastScoped(func(scope tenancy.Scope) interface{} {
+					scopedSources, scopedGlobal := tenancy.FilterMetrics(scope, sources, global)
+					return map[string]interface{}{
+						"sources": scopedSources,
+						"global":  scopedGlobal,
+					}
+				})
 				
 				// Log only every 30 seconds instead of every 2 seconds to reduce spam
 				if time.Since(lastLogTime) >= 30*time.Second {
@@ -186,7 +690,8 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Token, X-CSRF-Token, If-Match, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Location")
 		
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -197,16 +702,86 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// loggingMiddleware logs HTTP requests - SIMPLE VERSION WITHOUT RESPONSE WRAPPING
+// authMiddleware resolves the caller's tenant scope from its API token or
+// dashboard session when multi-tenancy is enabled. The dashboard page itself
+// and the session endpoint are served without either; requests authenticated
+// by the session cookie must carry its CSRF token to change anything.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.isMultiTenant() || r.URL.Path == "/" || r.URL.Path == "/dashboard" || r.URL.Path == "/api/session" || strings.HasPrefix(r.URL.Path, "/static/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		
+		scope, sess, ok := s.authenticate(r)
+		if !ok {
+			s.sendErrorResponse(w, "Invalid or missing API token", http.StatusUnauthorized)
+			return
+		}
+		if sess != nil && !csrfValid(r, sess) {
+			s.sendErrorResponse(w, "Missing or invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		
+		next.ServeHTTP(w, r.WithContext(tenancy.WithScope(r.Context(), scope)))
+	})
+}
+
+// isMultiTenant reports whether tenant access control is enabled
+func (s *Server) isMultiTenant() bool {
+	return s.multiTenantFunc != nil && s.multiTenantFunc()
+}
+
+// resolveRequestScope resolves the API token carried by a request
+func (s *Server) resolveRequestScope(r *http.Request) (tenancy.Scope, bool) {
+	if s.resolveTokenFunc == nil {
+		return tenancy.Scope{}, false
+	}
+	return s.resolveTokenFunc(requestToken(r))
+}
+
+// requestToken extracts an API token from the Authorization header,
+// the X-API-Token header or the token query parameter
+func requestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	if token := r.Header.Get("X-API-Token"); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
+
+// requestScope returns the caller's scope, narrowed by the ?tenant= query
+// parameter for super-admins
+func requestScope(r *http.Request) tenancy.Scope {
+	return tenancy.FromContext(r.Context()).ForTenant(r.URL.Query().Get("tenant"))
+}
+
+// loggingMiddleware logs HTTP requests and records them in the per-endpoint
+// metrics. WebSockets are served by their own router, so the response
+// writer may be wrapped to see the status and size.
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		
-		// Call next handler WITHOUT wrapping ResponseWriter
-		next.ServeHTTP(w, r)
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
 		
 		duration := time.Since(start)
 		
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		slow := s.apiMetrics.observe(r.Method, route, recorder.status, duration)
+		if slow {
+			log.Printf("⚠ Slow request: %s %s took %v (status %d, %d bytes in, %d bytes out)", r.Method, r.URL.Path, duration.Round(time.Millisecond), recorder.status, r.ContentLength, recorder.bytes)
+			return
+		}
+		
 		// Only log API calls, not static files
 		if r.URL.Path != "/" && r.URL.Path != "/dashboard" {
 			log.Printf("🌐 %s %s %v", r.Method, r.URL.Path, duration)
@@ -0,0 +1,339 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"syslog-analyzer/models"
+	"syslog-analyzer/tenancy"
+)
+
+// Session cookie and CSRF header names
+const (
+	sessionCookie = "syslog_analyzer_session"
+	csrfHeader    = "X-CSRF-Token"
+)
+
+// Session defaults
+const (
+	defaultSessionIdle     = 30 * time.Minute
+	defaultSessionMaxAge   = 12 * time.Hour
+	defaultSessionRemember = 30 * 24 * time.Hour
+)
+
+// session is a dashboard sign-in. The API token it was created with is kept
+// and resolved on every request, so removing the token from the
+// configuration ends its sessions too.
+type session struct {
+	id       string
+	csrf     string
+	token    string
+	remember bool
+	lastSeen time.Time
+	expires  time.Time // Absolute end of the session
+}
+
+// sessionStore holds the sessions of the running process; a restart signs
+// everyone out
+type sessionStore struct {
+	sessions map[string]*session
+	idle     time.Duration
+	maxAge   time.Duration
+	remember time.Duration // 0 disables remember-me
+	mutex    sync.Mutex
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{
+		sessions: make(map[string]*session),
+		idle:     defaultSessionIdle,
+		maxAge:   defaultSessionMaxAge,
+		remember: defaultSessionRemember,
+	}
+}
+
+// SetSessions sets the session lifetimes; unset limits keep their defaults
+func (s *Server) SetSessions(config models.SessionConfig) {
+	s.sessions.mutex.Lock()
+	defer s.sessions.mutex.Unlock()
+	
+	if config.IdleMinutes > 0 {
+		s.sessions.idle = time.Duration(config.IdleMinutes) * time.Minute
+	}
+	if config.MaxHours > 0 {
+		s.sessions.maxAge = time.Duration(config.MaxHours) * time.Hour
+	}
+	if config.RememberDays > 0 {
+		s.sessions.remember = time.Duration(config.RememberDays) * 24 * time.Hour
+	} else if config.RememberDays < 0 {
+		s.sessions.remember = 0
+	}
+}
+
+// create starts a session for an API token
+func (store *sessionStore) create(token string, remember bool) (*session, error) {
+	id, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	csrf, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	
+	now := time.Now()
+	store.prune(now)
+	
+	sess := &session{id: id, csrf: csrf, token: token, lastSeen: now, expires: now.Add(store.maxAge)}
+	if remember && store.remember > 0 {
+		sess.remember = true
+		sess.expires = now.Add(store.remember)
+	}
+	store.sessions[id] = sess
+	return sess, nil
+}
+
+// lookup returns a live session and marks it used
+func (store *sessionStore) lookup(id string) (*session, bool) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	
+	sess, exists := store.sessions[id]
+	if !exists {
+		return nil, false
+	}
+	now := time.Now()
+	if store.expired(sess, now) {
+		delete(store.sessions, id)
+		return nil, false
+	}
+	sess.lastSeen = now
+	return sess, true
+}
+
+// expired returns whether a session has ended; remembered sessions do not
+// end for being idle
+func (store *sessionStore) expired(sess *session, now time.Time) bool {
+	return now.After(sess.expires) || (!sess.remember && now.Sub(sess.lastSeen) > store.idle)
+}
+
+// prune forgets ended sessions
+func (store *sessionStore) prune(now time.Time) {
+	for id, sess := range store.sessions {
+		if store.expired(sess, now) {
+			delete(store.sessions, id)
+		}
+	}
+}
+
+// revoke ends a session
+func (store *sessionStore) revoke(id string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.sessions, id)
+}
+
+// revokeToken ends every session signed in with a token and returns how many
+func (store *sessionStore) revokeToken(token string) int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	
+	revoked := 0
+	for id, sess := range store.sessions {
+		if subtle.ConstantTimeCompare([]byte(sess.token), []byte(token)) == 1 {
+			delete(store.sessions, id)
+			revoked++
+		}
+	}
+	return revoked
+}
+
+// randomToken returns 32 random bytes in hex
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// requestSession returns the live session of the request's cookie
+func (s *Server) requestSession(r *http.Request) (*session, bool) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || cookie.Value == "" {
+		return nil, false
+	}
+	return s.sessions.lookup(cookie.Value)
+}
+
+// authenticate resolves the caller's scope from an API token or, failing
+// that, a session cookie. The session is returned when the cookie was used.
+func (s *Server) authenticate(r *http.Request) (tenancy.Scope, *session, bool) {
+	if token := requestToken(r); token != "" {
+		scope, ok := s.resolveRequestScope(r)
+		return scope, nil, ok
+	}
+	if s.resolveTokenFunc == nil {
+		return tenancy.Scope{}, nil, false
+	}
+	sess, ok := s.requestSession(r)
+	if !ok {
+		return tenancy.Scope{}, nil, false
+	}
+	scope, ok := s.resolveTokenFunc(sess.token)
+	if !ok {
+		s.sessions.revoke(sess.id)
+		return tenancy.Scope{}, nil, false
+	}
+	return scope, sess, true
+}
+
+// csrfValid returns whether a cookie-authenticated request may change state:
+// safe methods always may, others must carry the session's CSRF token.
+// Requests with an API token are not sent by browsers on their own and need
+// no CSRF token.
+func csrfValid(r *http.Request, sess *session) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(csrfHeader)), []byte(sess.csrf)) == 1
+}
+
+// setSessionCookie sends the session cookie; remembered sessions persist
+// until they expire, others end with the browser
+func (s *Server) setSessionCookie(w http.ResponseWriter, r *http.Request, sess *session) {
+	cookie := &http.Cookie{
+		Name:     sessionCookie,
+		Value:    sess.id,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteStrictMode,
+	}
+	if sess.remember {
+		cookie.Expires = sess.expires
+	}
+	http.SetCookie(w, cookie)
+}
+
+// clearSessionCookie removes the session cookie from the browser
+func clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+}
+
+// sessionInfo describes a session to its owner
+func sessionInfo(scope tenancy.Scope, sess *session) models.SessionInfo {
+	info := models.SessionInfo{AuthRequired: true, Tenant: scope.Tenant, Admin: scope.Admin}
+	if sess != nil {
+		info.CSRFToken = sess.csrf
+		info.Remember = sess.remember
+		expires := sess.expires
+		info.ExpiresAt = &expires
+	}
+	return info
+}
+
+// handleCreateSession signs in with an API token and sets the session cookie
+func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	if !s.isMultiTenant() {
+		s.sendErrorResponse(w, "Sign-in is only needed with multi-tenancy enabled", http.StatusBadRequest)
+		return
+	}
+	
+	// A form on another site cannot send JSON, so it cannot sign a browser in
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		s.sendErrorResponse(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	
+	var request struct {
+		Token    string `json:"token"`
+		Remember bool   `json:"remember"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		s.sendErrorResponse(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	scope, ok := tenancy.Scope{}, false
+	if s.resolveTokenFunc != nil {
+		scope, ok = s.resolveTokenFunc(request.Token)
+	}
+	if !ok {
+		s.sendErrorResponse(w, "Invalid API token", http.StatusUnauthorized)
+		return
+	}
+	
+	sess, err := s.sessions.create(request.Token, request.Remember)
+	if err != nil {
+		s.sendErrorResponse(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	s.setSessionCookie(w, r, sess)
+	log.Printf("✓ Dashboard session started for %s from %s", scope.Key(), r.RemoteAddr)
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessionInfo(scope, sess))
+}
+
+// handleGetSession describes the caller's session, including the CSRF token
+// the dashboard sends back on state-changing requests
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	info := models.SessionInfo{Admin: true}
+	if s.isMultiTenant() {
+		scope, sess, ok := s.authenticate(r)
+		if !ok {
+			s.sendErrorResponse(w, "Not signed in", http.StatusUnauthorized)
+			return
+		}
+		info = sessionInfo(scope, sess)
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// handleDeleteSession signs out of the caller's session
+func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	if sess, ok := s.requestSession(r); ok {
+		if !csrfValid(r, sess) {
+			s.sendErrorResponse(w, "Missing or invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		s.sessions.revoke(sess.id)
+	}
+	clearSessionCookie(w)
+	s.sendSuccessResponse(w, "Signed out")
+}
+
+// handleDeleteSessions signs out every session started with the caller's
+// API token, wherever it was signed in
+func (s *Server) handleDeleteSessions(w http.ResponseWriter, r *http.Request) {
+	token := requestToken(r)
+	if sess, ok := s.requestSession(r); ok && token == "" {
+		token = sess.token
+	}
+	if token == "" {
+		s.sendErrorResponse(w, "Not signed in", http.StatusUnauthorized)
+		return
+	}
+	
+	revoked := s.sessions.revokeToken(token)
+	clearSessionCookie(w)
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"revoked": revoked,
+	})
+}
@@ -0,0 +1,51 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"syslog-analyzer/models"
+)
+
+// handleGetSettings returns the editable global settings and which changes
+// wait for a restart (super-admin only)
+func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getSettingsFunc == nil {
+		http.Error(w, "Settings functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.getSettingsFunc())
+}
+
+// handleUpdateSettings validates and saves the editable global settings
+// (super-admin only)
+func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configManaged(w) {
+		return
+	}
+	if s.updateSettingsFunc == nil {
+		http.Error(w, "Settings functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var settings models.Settings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	status, err := s.updateSettingsFunc(settings)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to update settings: %v", err), resourceErrorStatus(err))
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
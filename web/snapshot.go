@@ -0,0 +1,81 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"syslog-analyzer/models"
+	"syslog-analyzer/snapshot"
+)
+
+// snapshotHistory is the span of delivery history a snapshot charts
+const snapshotHistory = 1 * time.Hour
+
+// handleSnapshot renders the global summary and charts as an image or PDF
+// (?format=png|pdf, default png) for pasting into incident reports
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if s.getMetricsFunc == nil {
+		http.Error(w, "Metrics function not available", http.StatusInternalServerError)
+		return
+	}
+	
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = snapshot.FormatPNG
+	}
+	if format != snapshot.FormatPNG && format != snapshot.FormatPDF {
+		http.Error(w, "Invalid format (expected png or pdf)", http.StatusBadRequest)
+		return
+	}
+	
+	sources, global := s.scopedMetrics(r)
+	now := time.Now()
+	data := snapshot.Data{
+		GeneratedAt: now,
+		Sources:     sources,
+		Global:      global,
+		History:     s.combinedHistory(sources, now.Add(-snapshotHistory), now),
+	}
+	
+	content, err := snapshot.Render(data, format)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to render snapshot: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	contentType := "image/png"
+	if format == snapshot.FormatPDF {
+		contentType = "application/pdf"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", snapshot.Filename(now, format)))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+	w.Write(content)
+}
+
+// combinedHistory sums the per-minute delivery history of sources
+func (s *Server) combinedHistory(sources []models.SourceMetrics, from, to time.Time) []models.HistoryPoint {
+	if s.getHistoryFunc == nil {
+		return nil
+	}
+	
+	var combined []models.HistoryPoint
+	index := make(map[time.Time]int)
+	for _, source := range sources {
+		points, err := s.getHistoryFunc(source.Name, from, to, time.Minute)
+		if err != nil {
+			continue // Deleted since the metrics were taken
+		}
+		for _, point := range points {
+			i, exists := index[point.Time]
+			if !exists {
+				i = len(combined)
+				index[point.Time] = i
+				combined = append(combined, models.HistoryPoint{Time: point.Time})
+			}
+			combined[i].Add(point.ReconciliationCounts)
+		}
+	}
+	return combined
+}
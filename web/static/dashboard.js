@@ -0,0 +1,1511 @@
+class SyslogDashboard {
+    constructor() {
+        this.ws = null;
+        this.reconnectInterval = 5000;
+        this.isConnected = false;
+        this.destinationCounter = 0;
+        this.editingSourceName = null;
+        this.highlightedSource = this.getLinkedSource();
+        this.csrfToken = null;
+        this.init();
+    }
+
+    async init() {
+        this.setupActions();
+        if (!(await this.startSession())) return;
+        this.connectWebSocket();
+        this.setupEventListeners();
+        this.loadInitialData();
+        this.loadUnclaimedSenders();
+        setInterval(() => this.loadUnclaimedSenders(), 10000);
+        this.loadReconciliation();
+        setInterval(() => this.loadReconciliation(), 30000);
+        this.loadGitOpsStatus();
+        setInterval(() => this.loadGitOpsStatus(), 30000);
+    }
+
+    connectWebSocket() {
+        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        const wsUrl = protocol + '//' + window.location.host + '/ws';
+        
+        try {
+            this.ws = new WebSocket(wsUrl);
+            
+            this.ws.onopen = () => {
+                console.log('WebSocket connected');
+                this.isConnected = true;
+                this.updateConnectionStatus(true);
+            };
+            
+            this.ws.onmessage = (event) => {
+                const data = JSON.parse(event.data);
+                this.updateDashboard(data);
+            };
+            
+            this.ws.onclose = () => {
+                console.log('WebSocket disconnected');
+                this.isConnected = false;
+                this.updateConnectionStatus(false);
+                this.scheduleReconnect();
+            };
+            
+            this.ws.onerror = (error) => {
+                console.error('WebSocket error:', error);
+            };
+        } catch (error) {
+            console.error('Failed to create WebSocket:', error);
+            this.scheduleReconnect();
+        }
+    }
+
+    getLinkedSource() {
+        // Deep links from alert notifications use #source=<name>
+        const hash = window.location.hash;
+        if (hash.indexOf('#source=') !== 0) return null;
+        return decodeURIComponent(hash.substring('#source='.length).replace(/\+/g, ' '));
+    }
+
+    async startSession() {
+        // A token passed once as ?token=<value>, or remembered by older
+        // versions, is exchanged for a session cookie and not kept
+        const params = new URLSearchParams(window.location.search);
+        const token = params.get('token') || localStorage.getItem('syslogAnalyzerToken');
+        localStorage.removeItem('syslogAnalyzerToken');
+        if (params.has('token')) {
+            params.delete('token');
+            const query = params.toString();
+            window.history.replaceState(null, '', window.location.pathname + (query ? '?' + query : '') + window.location.hash);
+        }
+        if (token) {
+            await this.login(token, true);
+        }
+        
+        const response = await fetch('/api/session');
+        if (response.status === 401) {
+            document.getElementById('loginForm').addEventListener('submit', async (e) => {
+                e.preventDefault();
+                const status = document.getElementById('loginStatus');
+                status.textContent = 'Signing in...';
+                if (await this.login(document.getElementById('loginToken').value, document.getElementById('loginRemember').checked)) {
+                    window.location.reload();
+                } else {
+                    status.textContent = 'Invalid API token';
+                }
+            });
+            document.getElementById('loginModal').style.display = 'block';
+            return false;
+        }
+        const session = await response.json();
+        this.csrfToken = session.csrf_token || null;
+        if (session.csrf_token) {
+            document.querySelectorAll('.session-button').forEach(function (button) { button.style.display = 'inline-block'; });
+        }
+        return true;
+    }
+
+    async login(token, remember) {
+        const response = await fetch('/api/session', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ token: token, remember: remember })
+        });
+        return response.ok;
+    }
+
+    async logout(everywhere) {
+        await this.apiFetch(everywhere ? '/api/sessions' : '/api/session', { method: 'DELETE' });
+        window.location.reload();
+    }
+
+    apiFetch(url, options) {
+        options = options || {};
+        // Session-authenticated changes must carry the session's CSRF token
+        const method = (options.method || 'GET').toUpperCase();
+        if (this.csrfToken && method !== 'GET' && method !== 'HEAD') {
+            options.headers = Object.assign({}, options.headers, { 'X-CSRF-Token': this.csrfToken });
+        }
+        return fetch(url, options);
+    }
+
+    scheduleReconnect() {
+        setTimeout(() => {
+            if (!this.isConnected) {
+                console.log('Attempting to reconnect...');
+                this.connectWebSocket();
+            }
+        }, this.reconnectInterval);
+    }
+
+    updateConnectionStatus(connected) {
+        const statusDot = document.getElementById('connectionStatus');
+        const statusText = document.getElementById('statusText');
+        
+        if (connected) {
+            statusDot.classList.add('connected');
+            statusText.textContent = 'Connected';
+        } else {
+            statusDot.classList.remove('connected');
+            statusText.textContent = 'Disconnected';
+        }
+    }
+
+    // setupActions dispatches the data-click, data-change and data-input
+    // attributes of the dashboard controls. The Content-Security-Policy blocks
+    // inline event handlers, and row values such as source names travel in
+    // data attributes instead of being spliced into script
+    setupActions() {
+        const source = (el) => el.dataset.source;
+        const sender = (el) => [el.dataset.ip, Number(el.dataset.port), el.dataset.protocol];
+        const destination = (el) => el.closest('.destination-item').dataset.destId;
+        const actions = {
+            generateReport: () => this.generateReport(),
+            openSnapshot: (el) => this.openSnapshot(el.dataset.format),
+            downloadChargeback: () => this.downloadChargeback(),
+            showSettingsModal: () => this.showSettingsModal(),
+            hideSettingsModal: () => this.hideSettingsModal(),
+            showLogViewer: () => this.showLogViewer(),
+            hideLogViewer: () => this.hideLogViewer(),
+            renderLogLines: () => this.renderLogLines(),
+            showForecast: () => this.showForecast(),
+            hideForecast: () => this.hideForecast(),
+            loadForecast: () => this.loadForecast(),
+            renderForecastChart: () => this.renderForecastChart(),
+            showComparison: () => this.showComparison(),
+            hideComparison: () => this.hideComparison(),
+            showHeatmap: () => this.showHeatmap(),
+            hideHeatmap: () => this.hideHeatmap(),
+            renderHeatmap: () => this.renderHeatmap(),
+            logout: (el) => this.logout(el.dataset.everywhere === 'true'),
+            loadReconciliation: () => this.loadReconciliation(),
+            exportHistory: (el) => this.exportHistory(el.dataset.format),
+            importHistory: (el) => this.importHistory(el),
+            showAddSourceModal: () => this.showAddSourceModal(),
+            hideAddSourceModal: () => this.hideAddSourceModal(),
+            addDestination: () => this.addDestination(),
+            removeDestination: (el) => this.removeDestination(destination(el)),
+            updateDestinationConfig: (el) => this.updateDestinationConfig(destination(el)),
+            testDestination: (el) => this.testDestination(destination(el)),
+            addFilterRule: () => this.addFilterRule(),
+            addAggregationRule: () => this.addAggregationRule(),
+            estimateFilterImpact: () => this.estimateFilterImpact(),
+            moveRule: (el) => this.moveRule(el, Number(el.dataset.direction)),
+            removeRule: (el) => this.removeRule(el),
+            setSimulation: (el) => this.setSimulation(source(el), el.dataset.enabled === 'true'),
+            setPaused: (el) => this.setPaused(source(el), el.dataset.paused === 'true'),
+            resetCounters: (el) => this.resetCounters(source(el)),
+            editSource: (el) => this.editSource(source(el)),
+            deleteSource: (el) => this.deleteSource(source(el)),
+            createSourceFromSender: (el) => this.createSourceFromSender(...sender(el)),
+            dismissSender: (el) => this.dismissSender(...sender(el))
+        };
+        ['click', 'change', 'input'].forEach((type) => {
+            document.addEventListener(type, (e) => {
+                const el = e.target.closest && e.target.closest('[data-' + type + ']');
+                if (el && actions[el.dataset[type]]) {
+                    actions[el.dataset[type]](el);
+                }
+            });
+        });
+    }
+
+    setupEventListeners() {
+        document.getElementById('addSourceForm').addEventListener('submit', (e) => {
+            e.preventDefault();
+            this.addSource();
+        });
+
+        document.getElementById('settingsForm').addEventListener('submit', (e) => {
+            e.preventDefault();
+            this.saveSettings();
+        });
+
+        document.getElementById('comparisonForm').addEventListener('submit', (e) => {
+            e.preventDefault();
+            this.loadComparison(true);
+        });
+
+        window.addEventListener('click', (e) => {
+            const modal = document.getElementById('addSourceModal');
+            if (e.target === modal) {
+                this.hideAddSourceModal();
+            }
+            if (e.target === document.getElementById('settingsModal')) {
+                this.hideSettingsModal();
+            }
+            if (e.target === document.getElementById('logViewerModal')) {
+                this.hideLogViewer();
+            }
+            if (e.target === document.getElementById('forecastModal')) {
+                this.hideForecast();
+            }
+            if (e.target === document.getElementById('comparisonModal')) {
+                this.hideComparison();
+            }
+            if (e.target === document.getElementById('heatmapModal')) {
+                this.hideHeatmap();
+            }
+        });
+    }
+
+    async loadInitialData() {
+        try {
+            const response = await this.apiFetch('/api/metrics');
+            const data = await response.json();
+            this.updateDashboard(data);
+        } catch (error) {
+            console.error('Failed to load initial data:', error);
+        }
+    }
+
+    async loadUnclaimedSenders() {
+        try {
+            const response = await this.apiFetch('/api/unclaimed');
+            const section = document.getElementById('unclaimedSection');
+            if (!response.ok) {
+                // Only super-admins can see traffic that belongs to no tenant
+                section.style.display = 'none';
+                return;
+            }
+            const senders = await response.json();
+            section.style.display = senders.length > 0 ? 'block' : 'none';
+            this.updateUnclaimedTable(senders);
+        } catch (error) {
+            console.error('Failed to load unclaimed senders:', error);
+        }
+    }
+
+    updateUnclaimedTable(senders) {
+        const tbody = document.getElementById('unclaimedTableBody');
+        if (!tbody) return;
+        
+        tbody.innerHTML = '';
+        senders.forEach(sender => {
+            const row = document.createElement('tr');
+            const data = { ip: sender.ip, port: sender.port, protocol: sender.protocol };
+            const sample = element('div', 'sample-message', sender.sample_message);
+            sample.title = sender.sample_message || '';
+            const buttons = element('div', 'button-group');
+            buttons.append(
+                actionButton('Create Source', 'btn btn-primary btn-action', 'createSourceFromSender', data),
+                actionButton('Dismiss', 'btn btn-secondary btn-action', 'dismissSender', data)
+            );
+            row.append(
+                tableCell(element('div', 'source-name', sender.ip), element('div', 'source-address', 'Last seen ' + new Date(sender.last_seen).toLocaleTimeString())),
+                tableCell(sender.protocol + ' ' + sender.port),
+                tableCell((sender.eps || 0).toFixed(2)),
+                tableCell((sender.message_count || 0).toLocaleString()),
+                tableCell(sample),
+                tableCell(buttons)
+            );
+            tbody.appendChild(row);
+        });
+    }
+
+    async createSourceFromSender(ip, port, protocol) {
+        const name = prompt('Name for the new source:', 'sender-' + ip.replace(/[^A-Za-z0-9._-]/g, '-'));
+        if (!name) return;
+        
+        try {
+            const response = await this.apiFetch('/api/sources', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ name: name, ip: ip, port: port, protocol: protocol, simulation_mode: true, destinations: [] })
+            });
+            const result = await response.json();
+            if (!response.ok || !result.success) {
+                alert('Failed to create source: ' + (result.message || response.statusText));
+                return;
+            }
+            this.loadUnclaimedSenders();
+            this.loadInitialData();
+        } catch (error) {
+            alert('Failed to create source: ' + error);
+        }
+    }
+
+    async dismissSender(ip, port, protocol) {
+        const query = '?ip=' + encodeURIComponent(ip) + '&port=' + port + '&protocol=' + encodeURIComponent(protocol);
+        try {
+            await this.apiFetch('/api/unclaimed' + query, { method: 'DELETE' });
+            this.loadUnclaimedSenders();
+        } catch (error) {
+            console.error('Failed to dismiss sender:', error);
+        }
+    }
+
+    async setSimulation(name, enabled) {
+        if (!confirm((enabled ? 'Switch source "' + name + '" to simulation mode? Events will be counted but no longer delivered.' : 'Switch source "' + name + '" to live delivery?'))) return;
+        
+        try {
+            const response = await this.apiFetch('/api/sources/' + encodeURIComponent(name) + '/simulation', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ enabled: enabled })
+            });
+            const result = await response.json();
+            if (!response.ok || !result.success) {
+                alert('Failed to switch simulation mode: ' + (result.message || response.statusText));
+                return;
+            }
+            this.loadInitialData();
+        } catch (error) {
+            alert('Failed to switch simulation mode: ' + error);
+        }
+    }
+
+    async setPaused(name, paused) {
+        if (paused && !confirm('Pause delivery of source "' + name + '"? Logs keep being received and are held back until delivery resumes.')) return;
+        
+        try {
+            const response = await this.apiFetch('/api/sources/' + encodeURIComponent(name) + '/pause', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ paused: paused })
+            });
+            const result = await response.json();
+            if (!response.ok || !result.success) {
+                alert('Failed to change delivery: ' + (result.message || response.statusText));
+                return;
+            }
+            this.loadInitialData();
+        } catch (error) {
+            alert('Failed to change delivery: ' + error);
+        }
+    }
+
+    async resetCounters(name) {
+        if (!confirm('Reset the cumulative counters of source "' + name + '"? The reset is recorded and graphs start from zero.')) return;
+        
+        try {
+            const response = await this.apiFetch('/api/sources/' + encodeURIComponent(name) + '/counters/reset', { method: 'POST' });
+            const result = await response.json();
+            if (!response.ok || !result.success) {
+                alert('Failed to reset counters: ' + (result.message || response.statusText));
+                return;
+            }
+            this.loadInitialData();
+        } catch (error) {
+            alert('Failed to reset counters: ' + error);
+        }
+    }
+
+    async loadReconciliation() {
+        const minutes = parseInt(document.getElementById('reconciliationRange').value, 10);
+        const to = new Date();
+        const from = new Date(to.getTime() - minutes * 60000);
+        const query = '?from=' + encodeURIComponent(from.toISOString().split('.')[0] + 'Z') + '&to=' + encodeURIComponent(to.toISOString().split('.')[0] + 'Z');
+        try {
+            const response = await this.apiFetch('/api/reconciliation' + query);
+            if (!response.ok) return;
+            const report = await response.json();
+            this.updateReconciliationTable(report);
+        } catch (error) {
+            console.error('Failed to load reconciliation:', error);
+        }
+    }
+
+    async loadGitOpsStatus() {
+        try {
+            if (!this.configMode) {
+                const modeResponse = await this.apiFetch('/api/config/mode');
+                if (modeResponse.ok) this.configMode = await modeResponse.json();
+            }
+            const response = await this.apiFetch('/api/gitops');
+            if (!response.ok) return;
+            this.updateGitOpsBanner(await response.json());
+        } catch (error) {
+            console.error('Failed to load GitOps status:', error);
+        }
+    }
+
+    updateGitOpsBanner(status) {
+        const banner = document.getElementById('gitopsBanner');
+        const addButton = document.getElementById('addSourceButton');
+        if (!banner) return;
+        const readOnly = this.configMode && this.configMode.read_only;
+        if (addButton) addButton.style.display = status.enabled || readOnly ? 'none' : '';
+        if (!status.enabled) {
+            banner.textContent = readOnly ? '🔒 Configuration is provided by the environment and is read-only here' + (this.configMode.origin ? ' (' + this.configMode.origin + ')' : '') + '.' : '';
+            return;
+        }
+        let text = '🔒 Configuration is managed by GitOps sync and is read-only here';
+        if (status.location) text += ' (' + status.location + ')';
+        text += status.revision ? '. Revision ' + status.revision + (status.verified ? ' (signature verified)' : ' (unsigned)') : '. No revision applied yet';
+        if (status.last_success && !status.last_success.startsWith('0001')) text += ', last synced ' + new Date(status.last_success).toLocaleString();
+        if (status.last_error) text += '. Last sync failed: ' + status.last_error;
+        banner.textContent = text + '.';
+        banner.classList.toggle('out-of-sync', !status.in_sync);
+    }
+
+    updateReconciliationTable(report) {
+        const tbody = document.getElementById('reconciliationTableBody');
+        if (!tbody) return;
+        
+        tbody.innerHTML = '';
+        const rows = (report.sources || []).map(row => ({ label: row.source, counts: row, total: false }));
+        if (rows.length > 1) {
+            rows.push({ label: 'Total', counts: report.total, total: true });
+        }
+        rows.forEach(item => {
+            const counts = item.counts;
+            const row = document.createElement('tr');
+            if (item.total) row.className = 'reconciliation-total';
+            const unaccounted = counts.unaccounted || 0;
+            const unaccountedCell = tableCell(unaccounted.toLocaleString());
+            if (unaccounted !== 0) unaccountedCell.className = 'unaccounted';
+            row.append(
+                tableCell(element('div', 'source-name', item.label)),
+                tableCell((counts.received || 0).toLocaleString()),
+                tableCell(((counts.dropped || 0) + (counts.discarded || 0)).toLocaleString()),
+                tableCell((counts.severity_dropped || 0).toLocaleString()),
+                tableCell((counts.filtered || 0).toLocaleString()),
+                tableCell((counts.processed || 0).toLocaleString()),
+                tableCell((counts.sent || 0).toLocaleString() + ' / ' + (counts.failed || 0).toLocaleString()),
+                unaccountedCell
+            );
+            tbody.appendChild(row);
+        });
+    }
+
+    escapeHtml(text) {
+        const div = document.createElement('div');
+        div.textContent = text || '';
+        return div.innerHTML.replace(/"/g, '&quot;');
+    }
+
+    updateDashboard(data) {
+        this.updateGlobalMetrics(data.global);
+        this.updateSourcesTable(data.sources);
+    }
+
+    updateGlobalMetrics(global) {
+        if (!global) return;
+        
+        try {
+            document.getElementById('globalEPS').textContent = (global.total_realtime_eps || 0).toFixed(2);
+            document.getElementById('globalGBps').textContent = (global.total_realtime_gbps || 0).toFixed(6);
+            document.getElementById('totalLogsIngested').textContent = (global.total_logs_ingested || 0).toLocaleString();
+            document.getElementById('totalHourlyAvgLogs').textContent = (global.total_hourly_avg_logs || 0).toLocaleString();
+            document.getElementById('totalDailyAvgLogs').textContent = (global.total_daily_avg_logs || 0).toLocaleString();
+            document.getElementById('activeSources').textContent = global.active_sources || 0;
+            document.getElementById('totalSources').textContent = global.total_sources || 0;
+            this.updateQuotaGauges(global.quotas || []);
+        } catch (e) {
+            console.error('Error updating global metrics:', e);
+        }
+    }
+
+    updateQuotaGauges(quotas) {
+        const container = document.getElementById('quotaGauges');
+        if (!container) return;
+        
+        container.replaceChildren(...quotas.map(quota => {
+            const target = quota.tenant ? 'Tenant: ' + quota.tenant : 'Group: ' + quota.group;
+            const gauge = element('div', 'quota-gauge');
+            gauge.append(element('h3', '', quota.name + (quota.exceeded ? ' ⚠' : '')), element('div', 'quota-target', target + ' (' + quota.action + ')'));
+            if (quota.max_eps > 0) {
+                gauge.append(...this.renderGauge('EPS', quota.eps_usage, (quota.current_eps || 0).toFixed(0) + ' / ' + quota.max_eps));
+            }
+            if (quota.max_gb_per_day > 0) {
+                gauge.append(...this.renderGauge('GB today', quota.daily_usage, (quota.gb_today || 0).toFixed(3) + ' / ' + quota.max_gb_per_day));
+            }
+            return gauge;
+        }));
+    }
+
+    renderGauge(label, usage, detail) {
+        const percent = Math.min(usage || 0, 100);
+        let fillClass = 'gauge-fill';
+        if (usage >= 100) {
+            fillClass += ' exceeded';
+        } else if (usage >= 80) {
+            fillClass += ' warning';
+        }
+        const heading = element('div', 'gauge-label');
+        heading.append(element('span', '', label), element('span', '', detail + ' (' + (usage || 0).toFixed(1) + '%)'));
+        const fill = element('div', fillClass);
+        fill.style.width = percent + '%';
+        const bar = element('div', 'gauge-bar');
+        bar.append(fill);
+        return [heading, bar];
+    }
+
+    updateSourcesTable(sources) {
+        const tbody = document.getElementById('sourcesTableBody');
+        if (!tbody) return;
+        
+        tbody.innerHTML = '';
+
+        if (!sources || !Array.isArray(sources)) return;
+
+        sources.sort((a, b) => {
+            const nameA = (a && a.name) ? a.name.toLowerCase() : '';
+            const nameB = (b && b.name) ? b.name.toLowerCase() : '';
+            return nameA.localeCompare(nameB);
+        });
+
+        sources.forEach(source => {
+            if (!source) return;
+            
+            const row = document.createElement('tr');
+            
+            let statusClass, statusText;
+            if (source.listener_down) {
+                statusClass = 'status-inactive';
+                statusText = 'Listener Down: Re-binding';
+            } else if (source.is_active && source.is_receiving) {
+                statusClass = 'status-active';
+                statusText = 'Active & Receiving';
+            } else if (source.is_active && source.probe && source.probe.state === 'unreachable') {
+                statusClass = 'status-inactive';
+                statusText = 'Silent: Device Down';
+            } else if (source.is_active && source.probe && source.probe.state === 'reachable') {
+                statusClass = 'status-idle';
+                statusText = 'Silent: Device Up, Not Logging';
+            } else if (source.is_active && !source.is_receiving) {
+                statusClass = 'status-idle';
+                statusText = 'Idle: Waiting for Logs';
+            } else {
+                statusClass = 'status-inactive';
+                statusText = 'Inactive';
+            }
+            
+            const simulationClass = source.simulation_mode ? 'on' : 'off';
+            const simulationText = source.simulation_mode ? 'ON' : 'OFF';
+            const badge = element('span', 'status-badge ' + statusClass, statusText);
+            if (source.listener_error) badge.title = source.listener_error;
+            const simulation = actionButton('Simulation: ' + simulationText, 'simulation-mode ' + simulationClass, 'setSimulation', { source: source.name || '', enabled: !source.simulation_mode }, 'span');
+            simulation.title = 'Click to switch without restarting the source';
+            const info = element('div', 'source-info');
+            info.append(element('div', 'source-name', source.name || 'Unknown'), element('div', 'source-address', (source.source_ip || 'N/A') + ':' + (source.port || 'N/A') + ' (' + (source.protocol || 'N/A') + ')'), badge, simulation);
+            (source.classification || []).forEach((label) => {
+                const classification = element('span', 'classification-badge', label.toUpperCase());
+                classification.title = 'Data classification';
+                info.append(classification);
+            });
+            (source.tags || []).forEach((tag) => {
+                const badge = element('span', 'tag-badge', tag);
+                badge.title = 'Tag';
+                info.append(badge);
+            });
+            
+            const destinations = source.destinations || [];
+            const pipeline = [
+                ['Queue:', (source.queue_depth || 0).toLocaleString() + (source.queue_age_seconds >= 1 ? ' (' + formatAge(source.queue_age_seconds) + ' old)' : ''), 'Age of the oldest unprocessed batch: ' + formatAge(source.queue_age_seconds || 0)],
+                ['Processed:', (source.processed_count || 0).toLocaleString()],
+                ['Sent:', (source.sent_count || 0).toLocaleString()],
+                ['Dropped:', (source.dropped_count || 0).toLocaleString()]
+            ];
+            destinations.filter((dest) => dest.window).forEach((dest) => {
+                pipeline.push([dest.name + ' (' + (dest.window_open ? 'open' : 'closed') + '):', (dest.buffered || 0).toLocaleString() + ' buffered, ' + ((dest.buffered_bytes || 0) / 1048576).toFixed(1) + ' MB', 'Delivery window ' + dest.window + (dest.buffered_age_seconds ? ', oldest spooled ' + formatAge(dest.buffered_age_seconds) + ' ago' : '') + (dest.buffer_refused ? ', ' + dest.buffer_refused.toLocaleString() + ' dropped while full' : '')]);
+            });
+            destinations.filter((dest) => dest.throttle).forEach((dest) => {
+                pipeline.push([dest.name + ' throttle wait:', (dest.throttle_wait_seconds || 0).toFixed(1) + 's', 'Throttled to ' + dest.throttle + ', ' + (dest.throttled_batches || 0).toLocaleString() + ' batches held back']);
+            });
+            destinations.filter((dest) => dest.duplicates_suppressed).forEach((dest) => {
+                pipeline.push([dest.name + ' duplicates:', dest.duplicates_suppressed.toLocaleString(), 'Replayed events skipped because ' + dest.name + ' already received them']);
+            });
+            destinations.filter((dest) => dest.heartbeats_sent || dest.heartbeat_failures).forEach((dest) => {
+                pipeline.push([dest.name + ' heartbeats:', (dest.heartbeats_sent || 0).toLocaleString() + (dest.heartbeat_failures ? ', ' + dest.heartbeat_failures.toLocaleString() + ' failed' : ''), dest.last_heartbeat ? 'Last accepted ' + new Date(dest.last_heartbeat).toLocaleString() : 'No heartbeat accepted yet']);
+            });
+            if (source.latency) {
+                const latency = Math.max(source.latency.last_seconds || 0, source.latency.pending_seconds || 0);
+                const perDestination = destinations.filter((dest) => dest.latency_seconds).map((dest) => dest.name + ' ' + dest.latency_seconds.toFixed(2) + 's').join(', ');
+                pipeline.push(['Latency:', latency.toFixed(2) + 's', 'Tracer every ' + source.latency.interval_seconds + 's: queued ' + (source.latency.queue_seconds || 0).toFixed(2) + 's, max ' + (source.latency.max_seconds || 0).toFixed(2) + 's' + (source.latency.pending_seconds ? ', oldest in flight ' + source.latency.pending_seconds.toFixed(1) + 's' : '') + (perDestination ? ' (' + perDestination + ')' : '')]);
+            }
+            if (source.events_by_severity || source.parse_failures) {
+                const bySeverity = Object.entries(source.events_by_severity || {}).sort((a, b) => b[1] - a[1]);
+                const byFacility = Object.entries(source.events_by_facility || {}).sort((a, b) => b[1] - a[1]);
+                pipeline.push(['Severities:', bySeverity.slice(0, 3).map(([name, count]) => name + ' ' + count.toLocaleString()).join(', ') || 'none', 'Facilities: ' + (byFacility.map(([name, count]) => name + ' ' + count.toLocaleString()).join(', ') || 'none') + (source.parse_failures ? '; ' + source.parse_failures.toLocaleString() + ' messages not parsed' : '')]);
+            }
+            if (source.probe && source.probe.probes) {
+                const probe = source.probe;
+                pipeline.push(['Probe:', probe.state === 'receiving' ? 'not needed' : probe.state === 'reachable' ? 'answering (' + (probe.rtt_ms || 0).toFixed(1) + ' ms)' : 'no answer', probe.method.toUpperCase() + ' ' + probe.target + ', ' + probe.probes.toLocaleString() + ' probes, ' + probe.failures.toLocaleString() + ' unanswered' + (probe.error ? '; last error: ' + probe.error : '') + ', last ' + new Date(probe.last_probe).toLocaleString()]);
+            }
+            if (source.raw_capture) {
+                pipeline.push(['Raw capture:', source.raw_capture.messages.toLocaleString() + (source.raw_capture.errors ? ', ' + source.raw_capture.errors.toLocaleString() + ' errors' : ''), source.raw_capture.file ? 'Writing ' + source.raw_capture.file : 'No file opened yet']);
+            }
+            if (source.delivery_paused || source.held_events) {
+                pipeline.push(['Held' + (source.delivery_paused ? ' (paused)' : '') + ':', (source.held_events || 0).toLocaleString(), source.held_age_seconds ? 'Oldest held ' + formatAge(source.held_age_seconds) + ' ago' : '']);
+            }
+            pipeline.push(['Kernel Drops:', source.kernel_drops_available ? (source.kernel_drops || 0).toLocaleString() : 'N/A']);
+            if ((source.protocol || '').indexOf('TCP') !== -1 || source.protocol === 'RELP') {
+                pipeline.push(['TCP Conns:', String(source.open_connections || 0)]);
+            }
+            
+            const target = { source: source.name || '' };
+            const buttons = element('div', 'button-group');
+            buttons.append(
+                actionButton('Edit', 'btn btn-secondary btn-action', 'editSource', target),
+                actionButton(source.delivery_paused ? 'Resume Delivery' : 'Pause Delivery', 'btn btn-secondary btn-action', 'setPaused', { source: target.source, paused: !source.delivery_paused }),
+                actionButton('Reset Counters', 'btn btn-secondary btn-action', 'resetCounters', target),
+                actionButton('Delete', 'btn btn-danger btn-action', 'deleteSource', target)
+            );
+            
+            row.append(
+                tableCell(info),
+                tableCell(metricsColumn([
+                    ['EPS:', (source.realtime_eps || 0).toFixed(2)],
+                    ['GB/s:', (source.realtime_gbps || 0).toFixed(6)],
+                    ['Wire GB/s:', (source.realtime_wire_gbps || 0).toFixed(6)],
+                    ['Total:', (source.total_logs_ingested || 0).toLocaleString()]
+                ])),
+                tableCell(metricsColumn([
+                    ['Logs:', (source.hourly_avg_logs || 0).toLocaleString()],
+                    ['GB:', (source.hourly_avg_gb || 0).toFixed(4)]
+                ])),
+                tableCell(metricsColumn([
+                    ['Logs:', (source.daily_avg_logs || 0).toLocaleString()],
+                    ['GB:', (source.daily_avg_gb || 0).toFixed(4)]
+                ])),
+                tableCell(metricsColumn(pipeline)),
+                tableCell(buttons)
+            );
+            
+            if (this.highlightedSource && source.name === this.highlightedSource) {
+                row.classList.add('highlighted-row');
+            }
+            
+            tbody.appendChild(row);
+        });
+    }
+
+    showAddSourceModal() {
+        document.getElementById('addSourceForm').reset();
+        document.getElementById('sourceProtocol').value = 'UDP';
+        document.getElementById('simulationMode').checked = true;
+        
+        document.getElementById('destinationsContainer').innerHTML = '';
+        this.destinationCounter = 0;
+        this.resetRules();
+        
+        document.querySelector('#addSourceModal .modal-header h3').textContent = 'Add New Syslog Source';
+        document.querySelector('#addSourceForm button[type="submit"]').textContent = 'Add Source';
+        this.editingSourceName = null;
+        this.editingSource = null;
+        this.editingETag = null;
+        document.getElementById('filterImpactButton').style.display = 'none';
+        
+        document.getElementById('addSourceModal').style.display = 'block';
+    }
+
+    hideAddSourceModal() {
+        document.getElementById('addSourceModal').style.display = 'none';
+        document.getElementById('addSourceForm').reset();
+        document.getElementById('destinationsContainer').innerHTML = '';
+        this.destinationCounter = 0;
+        this.resetRules();
+    }
+
+    resetRules() {
+        document.getElementById('filterPolicy').value = '';
+        document.getElementById('filterImpact').textContent = '';
+        document.getElementById('filtersContainer').innerHTML = '';
+        document.getElementById('aggregationsContainer').innerHTML = '';
+    }
+
+    addFilterRule(rule) {
+        rule = rule || { field: 'message', operator: 'contains', value: '', action: 'exclude' };
+        const item = document.createElement('div');
+        item.className = 'rule-item filter-rule';
+        item.innerHTML = '<div class="rule-fields"><input type="text" class="rule-field" placeholder="Field (message, source or a JSON key)"><select class="rule-operator"><option value="contains">contains</option><option value="equals">equals</option><option value="regex">matches regex</option></select><input type="text" class="rule-value" placeholder="Value"><select class="rule-action"><option value="exclude">Exclude</option><option value="include">Include</option></select><label title="Stop evaluating later rules when this one matches"><input type="checkbox" class="rule-stop"> Stop</label><button type="button" class="rule-move" title="Move up" data-click="moveRule" data-direction="-1">↑</button><button type="button" class="rule-move" title="Move down" data-click="moveRule" data-direction="1">↓</button><button type="button" class="destination-remove" title="Remove" data-click="removeRule">&times;</button></div><span class="rule-error"></span>';
+        
+        // Settings the form doesn't edit, like time conditions, are kept as loaded
+        item.dataset.rule = JSON.stringify(rule);
+        item.querySelector('.rule-field').value = rule.field || '';
+        item.querySelector('.rule-operator').value = rule.operator || 'contains';
+        item.querySelector('.rule-value').value = rule.value || '';
+        item.querySelector('.rule-action').value = rule.action || 'exclude';
+        item.querySelector('.rule-stop').checked = !!rule.stop;
+        item.addEventListener('input', () => this.scheduleFilterValidation());
+        item.addEventListener('change', () => this.scheduleFilterValidation());
+        
+        document.getElementById('filtersContainer').appendChild(item);
+        this.scheduleFilterValidation();
+    }
+
+    addAggregationRule(rule) {
+        rule = rule || { name: '', group_by: [], time_window: 60000000000, metrics: [] };
+        const item = document.createElement('div');
+        item.className = 'rule-item aggregation-rule';
+        item.innerHTML = '<div class="rule-fields"><input type="text" class="agg-name" placeholder="Rule name"><input type="text" class="agg-group-by" placeholder="Group by fields, comma separated"><input type="number" class="agg-window" min="0" placeholder="Window (seconds)"><select class="agg-mode"><option value="events">Summary events</option><option value="metrics">Metrics events</option></select><button type="button" class="rule-move" title="Move up" data-click="moveRule" data-direction="-1">↑</button><button type="button" class="rule-move" title="Move down" data-click="moveRule" data-direction="1">↓</button><button type="button" class="destination-remove" title="Remove" data-click="removeRule">&times;</button></div><div class="rule-fields"><input type="text" class="agg-metrics" placeholder="Metrics, e.g. bytes:sum,max"><input type="number" class="agg-max-groups" min="0" placeholder="Max groups (default)"><select class="agg-overflow"><option value="other">Overflow: other bucket</option><option value="disable">Overflow: stop aggregating</option><option value="alert">Overflow: alert</option></select></div><span class="rule-error"></span>';
+        
+        item.dataset.rule = JSON.stringify(rule);
+        item.querySelector('.agg-name').value = rule.name || '';
+        item.querySelector('.agg-group-by').value = (rule.group_by || []).join(', ');
+        item.querySelector('.agg-window').value = rule.time_window ? rule.time_window / 1e9 : '';
+        item.querySelector('.agg-mode').value = rule.mode || 'events';
+        item.querySelector('.agg-metrics').value = (rule.metrics || []).map(function (metric) { return metric.field + ':' + (metric.ops || []).join(','); }).join('; ');
+        item.querySelector('.agg-max-groups').value = rule.max_groups || '';
+        item.querySelector('.agg-overflow').value = rule.overflow || 'other';
+        item.addEventListener('input', () => this.validateAggregationRule(item));
+        item.addEventListener('change', () => this.validateAggregationRule(item));
+        
+        document.getElementById('aggregationsContainer').appendChild(item);
+        this.validateAggregationRule(item);
+    }
+
+    moveRule(button, direction) {
+        const item = button.closest('.rule-item');
+        const sibling = direction < 0 ? item.previousElementSibling : item.nextElementSibling;
+        if (!sibling) return;
+        item.parentNode.insertBefore(item, direction < 0 ? sibling : sibling.nextElementSibling);
+    }
+
+    removeRule(button) {
+        button.closest('.rule-item').remove();
+    }
+
+    setRuleError(item, message) {
+        item.querySelector('.rule-error').textContent = message || '';
+        item.classList.toggle('invalid', !!message);
+    }
+
+    collectFilterRules() {
+        const rules = Array.from(document.querySelectorAll('#filtersContainer .filter-rule')).map(function (item) {
+            const rule = JSON.parse(item.dataset.rule || '{}');
+            rule.field = item.querySelector('.rule-field').value.trim();
+            rule.operator = item.querySelector('.rule-operator').value;
+            rule.value = item.querySelector('.rule-value').value;
+            rule.action = item.querySelector('.rule-action').value;
+            rule.stop = item.querySelector('.rule-stop').checked;
+            return rule;
+        });
+        
+        // Priorities override the list order; drop them once the rules are reordered against them
+        const ordered = rules.every(function (rule, i) { return i === 0 || (rule.priority || 0) >= (rules[i - 1].priority || 0); });
+        if (!ordered) {
+            rules.forEach(function (rule) { delete rule.priority; });
+        }
+        return rules;
+    }
+
+    scheduleFilterValidation() {
+        clearTimeout(this.filterValidationTimer);
+        this.filterValidationTimer = setTimeout(() => this.validateFilterRules(), 300);
+    }
+
+    async validateFilterRules() {
+        const items = Array.from(document.querySelectorAll('#filtersContainer .filter-rule'));
+        if (items.length === 0) return true;
+        
+        try {
+            const response = await this.apiFetch('/api/filters/validate', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(this.collectFilterRules())
+            });
+            if (!response.ok) return true; // The server validates again on save
+            const result = await response.json();
+            items.forEach((item, i) => this.setRuleError(item, (result.errors || [])[i]));
+            return (result.errors || []).every(function (error) { return !error; });
+        } catch (error) {
+            console.error('Failed to validate filter rules:', error);
+            return true;
+        }
+    }
+
+    async estimateFilterImpact() {
+        const output = document.getElementById('filterImpact');
+        if (!await this.validateFilterRules()) {
+            output.textContent = 'Fix the highlighted rules to estimate their impact.';
+            return;
+        }
+        
+        const source = this.editingSource || {};
+        output.textContent = 'Estimating...';
+        try {
+            const response = await this.apiFetch('/api/filters/impact', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({
+                    sources: [this.editingSourceName],
+                    filters: this.collectFilterRules(),
+                    filter_sets: source.filter_sets || [],
+                    filter_matcher: source.filter_matcher || '',
+                    filter_policy: document.getElementById('filterPolicy').value
+                })
+            });
+            const result = await response.json();
+            if (!response.ok) {
+                throw new Error(result.error || response.statusText);
+            }
+            
+            const impact = result[0];
+            if (!impact || impact.samples === 0) {
+                output.textContent = 'No recent events to estimate from yet.';
+                return;
+            }
+            output.textContent = 'Of ' + impact.samples + ' recent events, these rules would drop ' + impact.proposed_dropped_events.toFixed(1) + '% of events and ' + impact.proposed_dropped_bytes.toFixed(1) + '% of bytes, about ' + impact.proposed_dropped_gb_per_day.toFixed(3) + ' GB of ' + impact.daily_gb.toFixed(3) + ' GB per day (current rules: ' + impact.current_dropped_bytes.toFixed(1) + '%; change ' + (impact.savings_gb_per_day >= 0 ? '-' : '+') + Math.abs(impact.savings_gb_per_day).toFixed(3) + ' GB/day ingested).';
+        } catch (error) {
+            output.textContent = 'Failed to estimate impact: ' + error.message;
+        }
+    }
+
+    parseAggregationRule(item) {
+        const rule = JSON.parse(item.dataset.rule || '{}');
+        rule.name = item.querySelector('.agg-name').value.trim();
+        rule.group_by = item.querySelector('.agg-group-by').value.split(',').map(function (field) { return field.trim(); }).filter(function (field) { return field; });
+        rule.time_window = Math.round((parseFloat(item.querySelector('.agg-window').value) || 0) * 1e9);
+        rule.mode = item.querySelector('.agg-mode').value;
+        rule.max_groups = parseInt(item.querySelector('.agg-max-groups').value, 10) || 0;
+        rule.overflow = item.querySelector('.agg-overflow').value;
+        rule.metrics = item.querySelector('.agg-metrics').value.split(';').map(function (part) { return part.trim(); }).filter(function (part) { return part; }).map(function (part) {
+            const separator = part.indexOf(':');
+            return {
+                field: (separator === -1 ? part : part.slice(0, separator)).trim(),
+                ops: separator === -1 ? [] : part.slice(separator + 1).split(',').map(function (op) { return op.trim(); }).filter(function (op) { return op; })
+            };
+        });
+        return rule;
+    }
+
+    validateAggregationRule(item) {
+        const rule = this.parseAggregationRule(item);
+        const ops = ['sum', 'avg', 'min', 'max', 'rate'];
+        let error = '';
+        if (!rule.name) {
+            error = 'Rule name is required';
+        } else if (rule.group_by.length === 0) {
+            error = 'At least one group by field is required';
+        } else if (rule.mode === 'metrics' && rule.time_window <= 0) {
+            error = 'Metrics mode needs a time window';
+        } else {
+            rule.metrics.forEach(function (metric) {
+                if (error) return;
+                if (!metric.field || metric.ops.length === 0) {
+                    error = 'Write metrics as field:op,op';
+                    return;
+                }
+                const unknown = metric.ops.filter(function (op) { return ops.indexOf(op) === -1; });
+                if (unknown.length > 0) {
+                    error = 'Unknown aggregate ' + unknown[0] + ' for ' + metric.field + '; use ' + ops.join(', ');
+                }
+            });
+        }
+        this.setRuleError(item, error);
+        return !error;
+    }
+
+    collectDestinations() {
+        return Array.from(document.querySelectorAll('#destinationsContainer .destination-item')).map(function (item) {
+            const type = item.querySelector('.dest-type').value;
+            const config = type === 'hec'
+                ? { url: item.querySelector('.dest-config-url').value.trim(), api_key: item.querySelector('.dest-config-apikey').value.trim(), verify_ssl: true }
+                : { path: item.querySelector('.dest-config-path').value.trim() };
+            return {
+                name: item.querySelector('.destination-title').textContent,
+                type: type,
+                config: config,
+                enabled: item.querySelector('.dest-enabled').checked
+            };
+        });
+    }
+
+    addDestination() {
+        const container = document.getElementById('destinationsContainer');
+        const destId = 'dest_' + (++this.destinationCounter);
+        
+        const destDiv = document.createElement('div');
+        destDiv.className = 'destination-item';
+        destDiv.setAttribute('data-dest-id', destId);
+        
+        destDiv.innerHTML = '<div class="destination-header"><div class="destination-title">Destination ' + this.destinationCounter + '</div><button type="button" class="destination-remove" data-click="removeDestination">&times;</button></div><div class="destination-config"><div class="form-group"><label>Destination Type:</label><select class="dest-type" data-change="updateDestinationConfig"><option value="storage" selected>Storage</option><option value="hec">HEC (HTTP Event Collector)</option></select></div></div><div class="dest-config-fields"><div class="form-group"><label>Storage Path:</label><input type="text" class="dest-config-path" placeholder="C:\\\\logs\\\\test or //share/logs/test"></div></div><div class="destination-actions"><button type="button" class="btn btn-secondary test-button" data-click="testDestination">Test Connection</button><div class="test-status idle" id="test-status-' + destId + '">Not tested</div><div class="destination-enable"><input type="checkbox" class="dest-enabled" disabled><label>Enable</label></div></div>';
+        
+        container.appendChild(destDiv);
+    }
+
+    updateDestinationConfig(destId) {
+        const destDiv = document.querySelector('[data-dest-id="' + destId + '"]');
+        const typeSelect = destDiv.querySelector('.dest-type');
+        const configFields = destDiv.querySelector('.dest-config-fields');
+        
+        if (typeSelect.value === 'storage') {
+            configFields.innerHTML = '<div class="form-group"><label>Storage Path:</label><input type="text" class="dest-config-path" placeholder="C:\\\\logs\\\\test or //share/logs/test"></div>';
+        } else if (typeSelect.value === 'hec') {
+            configFields.innerHTML = '<div class="form-group"><label>HEC URL:</label><input type="text" class="dest-config-url" placeholder="https://splunk.example.com:8088/services/collector"></div><div class="form-group"><label>API Key:</label><input type="text" class="dest-config-apikey" placeholder="xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"></div>';
+        }
+        
+        const testStatus = destDiv.querySelector('#test-status-' + destId);
+        testStatus.className = 'test-status idle';
+        testStatus.textContent = 'Not tested';
+        
+        const enableCheckbox = destDiv.querySelector('.dest-enabled');
+        enableCheckbox.checked = false;
+        enableCheckbox.disabled = true;
+    }
+
+    removeDestination(destId) {
+        const destDiv = document.querySelector('[data-dest-id="' + destId + '"]');
+        if (destDiv) {
+            destDiv.remove();
+        }
+    }
+
+    async testDestination(destId) {
+        console.log('Testing destination:', destId);
+    }
+
+    async addSource() {
+        const aggregationsValid = Array.from(document.querySelectorAll('#aggregationsContainer .aggregation-rule')).map((item) => this.validateAggregationRule(item)).every(function (valid) { return valid; });
+        const filtersValid = await this.validateFilterRules();
+        if (!filtersValid || !aggregationsValid) {
+            alert('Please fix the highlighted rules first.');
+            return;
+        }
+        
+        // Editing keeps the settings this form doesn't show
+        const source = Object.assign({}, this.editingSource || {});
+        source.name = document.getElementById('sourceName').value.trim();
+        source.ip = document.getElementById('sourceIP').value.trim();
+        source.port = parseInt(document.getElementById('sourcePort').value, 10);
+        source.protocol = document.getElementById('sourceProtocol').value;
+        source.classification = document.getElementById('sourceClassification').value.split(',').map(function (label) { return label.trim(); }).filter(function (label) { return label; });
+        source.simulation_mode = document.getElementById('simulationMode').checked;
+        source.destinations = (source.destinations || []).concat(this.collectDestinations());
+        source.filter_policy = document.getElementById('filterPolicy').value;
+        source.filters = this.collectFilterRules();
+        source.aggregations = Array.from(document.querySelectorAll('#aggregationsContainer .aggregation-rule')).map((item) => this.parseAggregationRule(item));
+        
+        const editing = this.editingSourceName;
+        const headers = { 'Content-Type': 'application/json' };
+        if (editing && this.editingETag) {
+            headers['If-Match'] = this.editingETag;
+        }
+        try {
+            const response = await this.apiFetch(editing ? '/api/sources/' + encodeURIComponent(editing) : '/api/sources', {
+                method: editing ? 'PUT' : 'POST',
+                headers: headers,
+                body: JSON.stringify(source)
+            });
+            const result = await response.json();
+            if (!response.ok || !result.success) {
+                alert('Failed to save source: ' + (result.error || result.message || response.statusText));
+                return;
+            }
+            this.hideAddSourceModal();
+            this.loadInitialData();
+        } catch (error) {
+            alert('Failed to save source: ' + error);
+        }
+    }
+
+    async editSource(name) {
+        let source;
+        let etag;
+        try {
+            const response = await this.apiFetch('/api/sources/' + encodeURIComponent(name));
+            if (!response.ok) {
+                alert('Failed to load source "' + name + '": ' + response.statusText);
+                return;
+            }
+            etag = response.headers.get('ETag');
+            source = await response.json();
+        } catch (error) {
+            alert('Failed to load source "' + name + '": ' + error);
+            return;
+        }
+        
+        this.showAddSourceModal();
+        this.editingSourceName = name;
+        this.editingSource = source;
+        this.editingETag = etag;
+        document.getElementById('filterImpactButton').style.display = '';
+        document.querySelector('#addSourceModal .modal-header h3').textContent = 'Edit Source: ' + name;
+        document.querySelector('#addSourceForm button[type="submit"]').textContent = 'Save Source';
+        
+        document.getElementById('sourceName').value = source.name || '';
+        document.getElementById('sourceIP').value = source.ip || '';
+        document.getElementById('sourcePort').value = source.port || 514;
+        document.getElementById('sourceProtocol').value = source.protocol || 'UDP';
+        document.getElementById('sourceClassification').value = (source.classification || []).join(', ');
+        document.getElementById('simulationMode').checked = !!source.simulation_mode;
+        document.getElementById('destinationsContainer').innerHTML = (source.destinations || []).length > 0 ? '<small class="help-text">' + source.destinations.length + ' existing destination(s) are kept; destinations added here are appended.</small>' : '';
+        
+        document.getElementById('filterPolicy').value = source.filter_policy || '';
+        (source.filters || []).map(function (rule, i) { return { rule: rule, i: i }; }).sort(function (a, b) {
+            return ((a.rule.priority || 0) - (b.rule.priority || 0)) || (a.i - b.i);
+        }).forEach((entry) => this.addFilterRule(entry.rule));
+        (source.aggregations || []).forEach((rule) => this.addAggregationRule(rule));
+    }
+
+    async deleteSource(name) {
+        if (!confirm('Are you sure you want to delete source "' + name + '"?')) {
+            return;
+        }
+        console.log('Deleting source:', name);
+    }
+
+    async showSettingsModal() {
+        try {
+            const response = await this.apiFetch('/api/settings');
+            if (!response.ok) {
+                alert('Failed to load settings: ' + (response.status === 403 ? 'super-admin access required' : response.statusText));
+                return;
+            }
+            const status = await response.json();
+            document.getElementById('settingWebPort').value = status.web_port;
+            document.getElementById('settingBatchSize').value = status.batch_size;
+            document.getElementById('settingRetention').value = status.metrics_retention_hours;
+            document.getElementById('settingMaxMemory').value = status.max_memory_per_source || '';
+            document.getElementById('settingMaxEPS').value = status.max_eps_per_source;
+            this.showSettingsStatus(status);
+            this.loadHistoryStorage();
+            document.getElementById('settingsModal').style.display = 'block';
+        } catch (error) {
+            alert('Failed to load settings: ' + error);
+        }
+    }
+
+    async loadHistoryStorage() {
+        const output = document.getElementById('historyStorage');
+        output.textContent = '';
+        try {
+            const response = await this.apiFetch('/api/history/storage');
+            if (!response.ok) {
+                return;
+            }
+            const storage = await response.json();
+            const mb = function (bytes) { return ((bytes || 0) / 1048576).toFixed(1) + ' MB'; };
+            const tiers = (storage.tiers || []).map(function (tier) {
+                return tier.resolution + ' points for ' + tier.retention + ': ' + tier.files + ' files, ' + mb(tier.bytes) + (tier.oldest ? ' (' + tier.oldest + ' to ' + tier.newest + ')' : '');
+            });
+            output.textContent = 'Metrics history in ' + storage.dir + ' uses ' + mb(storage.total_bytes) + ' - ' + tiers.join('; ') + '.';
+        } catch (error) {
+            console.error('Failed to load history storage:', error);
+        }
+    }
+
+    exportHistory(format) {
+        window.open('/api/history/export?format=' + format, '_blank');
+    }
+
+    async importHistory(input) {
+        const file = input.files[0];
+        if (!file) {
+            return;
+        }
+        try {
+            const response = await this.apiFetch('/api/history/import', { method: 'POST', body: file });
+            const result = await response.json();
+            if (!response.ok) {
+                alert('Failed to import metrics history: ' + (result.error || response.statusText));
+                return;
+            }
+            alert('Imported ' + result.minute + ' per-minute and ' + result.hourly + ' hourly points (' + result.existing + ' already recorded, ' + result.expired + ' past retention).');
+            this.loadHistoryStorage();
+        } catch (error) {
+            alert('Failed to import metrics history: ' + error);
+        } finally {
+            input.value = '';
+        }
+    }
+
+    hideSettingsModal() {
+        document.getElementById('settingsModal').style.display = 'none';
+    }
+
+    showSettingsStatus(status) {
+        const restart = status.restart_required || [];
+        document.querySelectorAll('#settingsForm .restart-badge').forEach(function (badge) {
+            badge.classList.toggle('shown', restart.indexOf(badge.dataset.setting) !== -1);
+        });
+        const pending = status.pending_restart || [];
+        document.getElementById('settingsPending').textContent = pending.length > 0 ? 'Saved but not in effect until the analyzer restarts: ' + pending.join(', ') : 'Settings marked "restart required" take effect after the analyzer restarts.';
+    }
+
+    async saveSettings() {
+        const settings = {
+            web_port: parseInt(document.getElementById('settingWebPort').value, 10),
+            batch_size: parseInt(document.getElementById('settingBatchSize').value, 10),
+            metrics_retention_hours: parseInt(document.getElementById('settingRetention').value, 10),
+            max_memory_per_source: document.getElementById('settingMaxMemory').value.trim(),
+            max_eps_per_source: parseInt(document.getElementById('settingMaxEPS').value, 10)
+        };
+        try {
+            const response = await this.apiFetch('/api/settings', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(settings)
+            });
+            const result = await response.json();
+            if (!response.ok) {
+                alert('Failed to save settings: ' + (result.error || result.message || response.statusText));
+                return;
+            }
+            this.showSettingsStatus(result);
+            if ((result.pending_restart || []).length === 0) {
+                this.hideSettingsModal();
+            }
+        } catch (error) {
+            alert('Failed to save settings: ' + error);
+        }
+    }
+
+    showLogViewer() {
+        this.logLines = [];
+        this.renderLogLines();
+        document.getElementById('logViewerModal').style.display = 'block';
+        this.connectLogStream();
+    }
+
+    hideLogViewer() {
+        document.getElementById('logViewerModal').style.display = 'none';
+        if (this.logSocket) {
+            this.logSocket.onclose = null;
+            this.logSocket.close();
+            this.logSocket = null;
+        }
+    }
+
+    connectLogStream() {
+        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        const status = document.getElementById('logViewerStatus');
+        const socket = new WebSocket(protocol + '//' + window.location.host + '/ws/logs');
+        this.logSocket = socket;
+        status.textContent = 'Connecting...';
+        
+        socket.onopen = () => { status.textContent = 'Live'; };
+        socket.onmessage = (event) => {
+            const lines = JSON.parse(event.data) || [];
+            this.logLines = this.logLines.concat(lines).slice(-2000);
+            this.renderLogLines();
+        };
+        socket.onclose = () => {
+            status.textContent = 'Disconnected (super-admin access required); retrying...';
+            setTimeout(() => {
+                if (this.logSocket === socket && document.getElementById('logViewerModal').style.display === 'block') {
+                    this.connectLogStream();
+                }
+            }, 5000);
+        };
+    }
+
+    renderLogLines() {
+        const levels = { info: 0, warning: 1, error: 2 };
+        const minimum = levels[document.getElementById('logLevel').value] || 0;
+        const search = document.getElementById('logSearch').value.toLowerCase();
+        const container = document.getElementById('logLines');
+        
+        const fragment = document.createDocumentFragment();
+        (this.logLines || []).forEach(function (line) {
+            if ((levels[line.level] || 0) < minimum) return;
+            if (search && line.message.toLowerCase().indexOf(search) === -1) return;
+            const div = document.createElement('div');
+            div.className = 'log-line ' + line.level;
+            div.textContent = new Date(line.time).toLocaleTimeString() + '  ' + line.message;
+            fragment.appendChild(div);
+        });
+        container.innerHTML = '';
+        container.appendChild(fragment);
+        
+        if (document.getElementById('logFollow').checked) {
+            container.scrollTop = container.scrollHeight;
+        }
+    }
+
+    showForecast() {
+        document.getElementById('forecastModal').style.display = 'block';
+        this.loadForecast();
+    }
+
+    hideForecast() {
+        document.getElementById('forecastModal').style.display = 'none';
+    }
+
+    async loadForecast() {
+        const status = document.getElementById('forecastStatus');
+        const model = document.getElementById('forecastModel').value;
+        status.textContent = 'Loading...';
+        try {
+            const response = await this.apiFetch('/api/forecast' + (model ? '?model=' + model : ''));
+            if (!response.ok) {
+                throw new Error(await response.text());
+            }
+            this.forecast = await response.json();
+        } catch (error) {
+            status.textContent = 'Failed to load forecast: ' + error.message;
+            return;
+        }
+        
+        const series = this.forecast.series || [];
+        status.textContent = series.length > 0 ? this.forecast.model + ' model, next ' + this.forecast.horizon_days + ' days' : 'No complete days of ingest recorded yet';
+        
+        const select = document.getElementById('forecastSeries');
+        const selected = select.value;
+        select.innerHTML = '';
+        series.forEach(function (item, index) {
+            const option = document.createElement('option');
+            option.value = index;
+            option.textContent = item.kind + ': ' + item.name;
+            select.appendChild(option);
+        });
+        const keep = series.findIndex(function (item, index) { return String(index) === selected; });
+        select.value = keep !== -1 ? selected : String(Math.max(0, series.length - 1));
+        
+        this.renderForecastRows();
+        this.renderForecastChart();
+    }
+
+    renderForecastRows() {
+        const rows = document.getElementById('forecastRows');
+        rows.innerHTML = '';
+        (this.forecast.series || []).forEach(function (item) {
+            const history = item.history || [];
+            const last = history.length > 0 ? history[history.length - 1].gb : 0;
+            const limits = item.limits.length > 0 ? item.limits : [null];
+            limits.forEach(function (limit) {
+                const row = document.createElement('tr');
+                let outlook = 'No limit';
+                if (limit) {
+                    outlook = limit.exceeded ? 'Already reached' : limit.reached_on ? limit.reached_on + ' (' + limit.days_left + ' days)' : 'Not within horizon';
+                    row.className = limit.exceeded ? 'exceeded' : '';
+                }
+                [item.kind + ': ' + item.name, last.toFixed(3), (item.slope_gb_per_day >= 0 ? '+' : '') + item.slope_gb_per_day.toFixed(4), limit ? limit.name + ' ' + limit.limit_gb + (limit.kind === 'stored' ? ' GB stored' : ' GB/day') : '-', outlook].forEach(function (text) {
+                    const cell = document.createElement('td');
+                    cell.textContent = text;
+                    row.appendChild(cell);
+                });
+                rows.appendChild(row);
+            });
+        });
+    }
+
+    renderForecastChart() {
+        const chart = document.getElementById('forecastChart');
+        const item = ((this.forecast && this.forecast.series) || [])[document.getElementById('forecastSeries').value];
+        chart.innerHTML = '';
+        if (!item) return;
+        
+        const points = item.history.concat(item.projection);
+        const dailyLimits = item.limits.filter(function (limit) { return limit.kind === 'daily'; });
+        const max = Math.max.apply(null, points.map(function (point) { return point.gb; }).concat(dailyLimits.map(function (limit) { return limit.limit_gb; }), [0.001]));
+        const width = 900, height = 260, pad = 30;
+        const x = function (index) { return pad + index * (width - 2 * pad) / Math.max(1, points.length - 1); };
+        const y = function (gb) { return height - pad - gb / max * (height - 2 * pad); };
+        const path = function (list, offset) {
+            return list.map(function (point, index) { return (index === 0 ? 'M' : 'L') + x(index + offset).toFixed(1) + ',' + y(point.gb).toFixed(1); }).join(' ');
+        };
+        
+        let svg = '<svg viewBox="0 0 ' + width + ' ' + height + '" preserveAspectRatio="none">';
+        svg += '<text x="' + pad + '" y="16" font-size="12" fill="#666">' + max.toFixed(3) + ' GB/day</text>';
+        dailyLimits.forEach((limit) => {
+            svg += '<line x1="' + pad + '" x2="' + (width - pad) + '" y1="' + y(limit.limit_gb) + '" y2="' + y(limit.limit_gb) + '" stroke="#e74c3c" stroke-dasharray="2,4"/>';
+            svg += '<text x="' + (width - pad) + '" y="' + (y(limit.limit_gb) - 4) + '" font-size="12" fill="#e74c3c" text-anchor="end">' + this.escapeHtml(limit.name) + '</text>';
+        });
+        svg += '<path d="' + path(item.history, 0) + '" fill="none" stroke="#3498db" stroke-width="2"/>';
+        svg += '<path d="' + path(item.projection, item.history.length) + '" fill="none" stroke="#9b59b6" stroke-width="2" stroke-dasharray="6,4"/>';
+        svg += '<text x="' + pad + '" y="' + (height - 8) + '" font-size="12" fill="#666">' + (points[0] ? points[0].date : '') + '</text>';
+        svg += '<text x="' + (width - pad) + '" y="' + (height - 8) + '" font-size="12" fill="#666" text-anchor="end">' + (points.length ? points[points.length - 1].date : '') + '</text>';
+        svg += '</svg>';
+        chart.innerHTML = svg;
+    }
+
+    showComparison() {
+        document.getElementById('comparisonModal').style.display = 'block';
+        this.loadComparison(false);
+    }
+
+    hideComparison() {
+        document.getElementById('comparisonModal').style.display = 'none';
+    }
+
+    async loadComparison(useForm) {
+        const fields = { from: 'compareFrom', to: 'compareTo', baseline_from: 'compareBaselineFrom', baseline_to: 'compareBaselineTo' };
+        const params = new URLSearchParams();
+        if (useForm) {
+            Object.keys(fields).forEach(function (key) {
+                const value = document.getElementById(fields[key]).value;
+                if (value) params.set(key, value);
+            });
+        }
+        
+        const status = document.getElementById('comparisonStatus');
+        status.textContent = 'Loading...';
+        let comparison;
+        try {
+            const response = await this.apiFetch('/api/compare?' + params.toString());
+            if (!response.ok) {
+                throw new Error(await response.text());
+            }
+            comparison = await response.json();
+        } catch (error) {
+            status.textContent = 'Failed to compare: ' + error.message;
+            return;
+        }
+        
+        // Show the periods the server chose for any left empty
+        document.getElementById('compareFrom').value = comparison.current.from;
+        document.getElementById('compareTo').value = comparison.current.to;
+        document.getElementById('compareBaselineFrom').value = comparison.baseline.from;
+        document.getElementById('compareBaselineTo').value = comparison.baseline.to;
+        status.textContent = !comparison.recorded_from ? 'No ingest recorded yet' : comparison.recorded_from > comparison.baseline.from ? 'History starts on ' + comparison.recorded_from + '; the baseline is incomplete' : '';
+        
+        const percent = function (item) {
+            if (item.new) return 'new';
+            return (item.change_percent >= 0 ? '+' : '') + item.change_percent.toFixed(1) + '%';
+        };
+        const rows = document.getElementById('comparisonRows');
+        rows.innerHTML = '';
+        const addRow = function (name, item, className) {
+            const row = document.createElement('tr');
+            row.className = className;
+            [name, item.baseline_gb.toFixed(3), item.current_gb.toFixed(3), (item.change_gb >= 0 ? '+' : '') + item.change_gb.toFixed(3), percent(item)].forEach(function (text) {
+                const cell = document.createElement('td');
+                cell.textContent = text;
+                row.appendChild(cell);
+            });
+            rows.appendChild(row);
+        };
+        // Sources come largest growth first; the top growers explain most of an increase
+        comparison.sources.forEach(function (source, index) {
+            addRow(source.name, source, index < 5 && source.change_gb > 0 ? 'top-growth' : '');
+        });
+        addRow('Total', comparison, 'total');
+    }
+
+    showHeatmap() {
+        document.getElementById('heatmapModal').style.display = 'block';
+        this.loadHeatmap();
+    }
+
+    hideHeatmap() {
+        document.getElementById('heatmapModal').style.display = 'none';
+    }
+
+    async loadHeatmap() {
+        const status = document.getElementById('heatmapStatus');
+        status.textContent = 'Loading...';
+        try {
+            // Hours in the browser's time zone, where office hours are
+            const tz = Intl.DateTimeFormat().resolvedOptions().timeZone || '';
+            const response = await this.apiFetch('/api/history/heatmap?tz=' + encodeURIComponent(tz));
+            if (!response.ok) {
+                throw new Error(await response.text());
+            }
+            this.heatmaps = await response.json();
+        } catch (error) {
+            status.textContent = 'Failed to load heatmaps: ' + error.message;
+            return;
+        }
+        
+        const select = document.getElementById('heatmapSource');
+        const selected = select.value;
+        select.innerHTML = '';
+        this.heatmaps.forEach(function (heatmap) {
+            const option = document.createElement('option');
+            option.value = heatmap.source;
+            option.textContent = heatmap.source;
+            select.appendChild(option);
+        });
+        if (this.heatmaps.some(function (heatmap) { return heatmap.source === selected; })) {
+            select.value = selected;
+        }
+        this.renderHeatmap();
+    }
+
+    renderHeatmap() {
+        const status = document.getElementById('heatmapStatus');
+        const head = document.getElementById('heatmapHead');
+        const rows = document.getElementById('heatmapRows');
+        head.innerHTML = '';
+        rows.innerHTML = '';
+        const source = document.getElementById('heatmapSource').value;
+        const heatmap = (this.heatmaps || []).find(function (item) { return item.source === source; });
+        if (!heatmap) {
+            status.textContent = 'No complete hours recorded yet';
+            return;
+        }
+        status.textContent = heatmap.time_zone + ' | ' + heatmap.hours + ' hours averaged | mean ' + heatmap.mean_eps.toFixed(2) + ' EPS | peak hour ' + heatmap.peak_eps.toFixed(2) + ' EPS';
+        
+        const header = document.createElement('tr');
+        header.appendChild(document.createElement('th'));
+        for (let hour = 0; hour < 24; hour++) {
+            const cell = document.createElement('th');
+            cell.textContent = String(hour).padStart(2, '0');
+            header.appendChild(cell);
+        }
+        head.appendChild(header);
+        
+        const days = ['Mon', 'Tue', 'Wed', 'Thu', 'Fri', 'Sat', 'Sun'];
+        heatmap.cells.forEach(function (cells, day) {
+            const row = document.createElement('tr');
+            const label = document.createElement('th');
+            label.textContent = days[day];
+            row.appendChild(label);
+            cells.forEach(function (eps, hour) {
+                // Shade relative to the busiest hour, so scheduled jobs stand out
+                const intensity = heatmap.peak_eps > 0 ? eps / heatmap.peak_eps : 0;
+                const cell = document.createElement('td');
+                cell.style.background = 'rgba(41, 98, 185, ' + intensity.toFixed(2) + ')';
+                cell.style.color = intensity > 0.6 ? '#fff' : '#333';
+                cell.textContent = eps === 0 ? '' : eps >= 1000 ? (eps / 1000).toFixed(1) + 'k' : eps >= 10 ? eps.toFixed(0) : eps.toFixed(1);
+                cell.title = days[day] + ' ' + String(hour).padStart(2, '0') + ':00 - ' + eps.toFixed(2) + ' EPS';
+                row.appendChild(cell);
+            });
+            rows.appendChild(row);
+        });
+    }
+
+    generateReport() {
+        window.open('/api/report', '_blank');
+    }
+
+    openSnapshot(format) {
+        window.open('/api/snapshot?format=' + format, '_blank');
+    }
+
+    downloadChargeback() {
+        window.open('/api/chargeback', '_blank');
+    }
+}
+
+// element creates a node whose text is set through textContent, so values
+// reported by senders are never parsed as markup
+function element(tag, className, text) {
+    const node = document.createElement(tag);
+    if (className) node.className = className;
+    if (text !== undefined && text !== null) node.textContent = text;
+    return node;
+}
+
+function tableCell(...children) {
+    const cell = document.createElement('td');
+    cell.append(...children);
+    return cell;
+}
+
+// actionButton creates a control handled by setupActions, with its arguments
+// in data attributes
+function actionButton(label, className, action, data, tag) {
+    const button = element(tag || 'button', className, label);
+    if (button.tagName === 'BUTTON') button.type = 'button';
+    button.dataset.click = action;
+    Object.entries(data || {}).forEach(([key, value]) => { button.dataset[key] = String(value); });
+    return button;
+}
+
+// formatAge renders a number of seconds as e.g. "45s", "12m 5s" or "3h 20m"
+function formatAge(seconds) {
+    const total = Math.floor(seconds);
+    if (total < 60) return total + 's';
+    if (total < 3600) return Math.floor(total / 60) + 'm ' + (total % 60) + 's';
+    return Math.floor(total / 3600) + 'h ' + Math.floor((total % 3600) / 60) + 'm';
+}
+
+// metricsColumn renders [label, value, title] rows of the sources table
+function metricsColumn(rows) {
+    const column = element('div', 'metrics-column');
+    rows.forEach(([label, value, title]) => {
+        const row = element('div', 'metric-row');
+        if (title) row.title = title;
+        row.append(element('span', 'metric-label', label), element('span', 'metric-number', value));
+        column.append(row);
+    });
+    return column;
+}
+
+let dashboard;
+document.addEventListener('DOMContentLoaded', () => {
+    dashboard = new SyslogDashboard();
+});
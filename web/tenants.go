@@ -0,0 +1,120 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"syslog-analyzer/models"
+	"syslog-analyzer/tenancy"
+)
+
+// handleWhoAmI returns the caller's tenant scope
+func (s *Server) handleWhoAmI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"multi_tenant": s.isMultiTenant(),
+		"scope":        tenancy.FromContext(r.Context()),
+	})
+}
+
+// handleGetTenants lists tenants (super-admin only). Tokens are not returned.
+func (s *Server) handleGetTenants(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getTenantsFunc == nil {
+		http.Error(w, "Tenant functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	tenants := s.getTenantsFunc()
+	response := make([]map[string]interface{}, 0, len(tenants))
+	for _, tenant := range tenants {
+		response = append(response, map[string]interface{}{
+			"id":          tenant.ID,
+			"name":        tenant.Name,
+			"token_count": len(tenant.Tokens),
+		})
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleAddTenant creates a tenant (super-admin only)
+func (s *Server) handleAddTenant(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configReadOnly(w) {
+		return
+	}
+	if s.addTenantFunc == nil {
+		http.Error(w, "Tenant functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	var tenant models.TenantConfig
+	if err := json.NewDecoder(r.Body).Decode(&tenant); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	if err := s.addTenantFunc(tenant); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to add tenant: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Tenant added successfully")
+}
+
+// handleDeleteTenant deletes a tenant (super-admin only)
+func (s *Server) handleDeleteTenant(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) || s.configReadOnly(w) {
+		return
+	}
+	if s.deleteTenantFunc == nil {
+		http.Error(w, "Tenant functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	if err := s.deleteTenantFunc(mux.Vars(r)["id"]); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to delete tenant: %v", err), http.StatusBadRequest)
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Tenant deleted successfully")
+}
+
+// requireAdmin rejects callers that are not super-admins
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !tenancy.FromContext(r.Context()).Admin {
+		s.sendErrorResponse(w, "Super-admin access required", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// sourceTenant returns the tenant owning a configured source
+func (s *Server) sourceTenant(name string) (string, bool) {
+	if s.getSourcesFunc == nil {
+		return "", false
+	}
+	for _, source := range s.getSourcesFunc() {
+		if source.Name == name {
+			return source.Tenant, true
+		}
+	}
+	return "", false
+}
+
+// sourceAllowed reports whether the caller may access the named source.
+// Unknown sources are reported as not allowed so their existence is not leaked.
+func (s *Server) sourceAllowed(r *http.Request, name string) bool {
+	scope := tenancy.FromContext(r.Context())
+	if scope.Admin {
+		return true
+	}
+	tenant, exists := s.sourceTenant(name)
+	return exists && scope.Allows(tenant)
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// handleGetUnclaimed lists senders that reach a listener without a matching
+// source (super-admin only, as the traffic belongs to no tenant yet)
+func (s *Server) handleGetUnclaimed(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.getUnclaimedFunc == nil {
+		http.Error(w, "Unclaimed sender functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.getUnclaimedFunc())
+}
+
+// handleForgetUnclaimed dismisses a sender (?ip=&port=&protocol=)
+func (s *Server) handleForgetUnclaimed(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.forgetUnclaimedFunc == nil {
+		http.Error(w, "Unclaimed sender functions not available", http.StatusInternalServerError)
+		return
+	}
+	
+	query := r.URL.Query()
+	port, err := strconv.Atoi(query.Get("port"))
+	if err != nil || query.Get("ip") == "" || query.Get("protocol") == "" {
+		http.Error(w, "ip, port and protocol are required", http.StatusBadRequest)
+		return
+	}
+	
+	if err := s.forgetUnclaimedFunc(query.Get("protocol"), port, query.Get("ip")); err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to dismiss sender: %v", err), http.StatusNotFound)
+		return
+	}
+	
+	s.sendSuccessResponse(w, "Sender dismissed")
+}
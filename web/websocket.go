@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"syslog-analyzer/tenancy"
 )
 
 // WebSocketManager manages WebSocket connections
@@ -15,11 +17,14 @@ type WebSocketManager struct {
 	clients    map[*Client]bool
 	clientsMux sync.RWMutex
 	broadcast  chan []byte
+	scoped     chan map[*Client][]byte
 	register   chan *Client
 	unregister chan *Client
 	upgrader   websocket.Upgrader
 	running    bool
 	stopChan   chan bool
+	
+	compressionLevel int // permessage-deflate level when the upgrader enables compression
 }
 
 // Client represents a WebSocket client connection
@@ -29,6 +34,7 @@ type Client struct {
 	manager  *WebSocketManager
 	lastPing time.Time
 	id       string
+	scope    tenancy.Scope
 }
 
 // NewWebSocketManager creates a new WebSocket manager
@@ -36,6 +42,7 @@ func NewWebSocketManager() *WebSocketManager {
 	return &WebSocketManager{
 		clients:    make(map[*Client]bool),
 		broadcast:  make(chan []byte, 256),
+		scoped:     make(chan map[*Client][]byte, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		stopChan:   make(chan bool),
@@ -51,6 +58,12 @@ func NewWebSocketManager() *WebSocketManager {
 	}
 }
 
+// setCompression enables permessage-deflate at the given flate level
+func (wsm *WebSocketManager) setCompression(enabled bool, level int) {
+	wsm.upgrader.EnableCompression = enabled
+	wsm.compressionLevel = level
+}
+
 // Start starts the WebSocket manager
 func (wsm *WebSocketManager) Start() {
 	wsm.running = true
@@ -71,6 +84,9 @@ func (wsm *WebSocketManager) Start() {
 		case message := <-wsm.broadcast:
 			wsm.broadcastMessage(message)
 			
+		case messages := <-wsm.scoped:
+			wsm.sendScopedMessages(messages)
+			
 		case <-pingTicker.C:
 			wsm.pingClients()
 			
@@ -127,8 +143,65 @@ func (wsm *WebSocketManager) Broadcast(data interface{}) {
 	}
 }
 
+// BroadcastScoped sends each client the data built for its tenant scope.
+// build is called once per distinct scope among connected clients.
+func (wsm *WebSocketManager) BroadcastScoped(build func(scope tenancy.Scope) interface{}) {
+	if !wsm.running {
+		return
+	}
+	
+	wsm.clientsMux.RLock()
+	clients := make([]*Client, 0, len(wsm.clients))
+	for client := range wsm.clients {
+		clients = append(clients, client)
+	}
+	wsm.clientsMux.RUnlock()
+	
+	payloads := make(map[string][]byte)
+	messages := make(map[*Client][]byte, len(clients))
+	for _, client := range clients {
+		key := client.scope.Key()
+		payload, exists := payloads[key]
+		if !exists {
+			jsonData, err := json.Marshal(build(client.scope))
+			if err != nil {
+				log.Printf("⚠ Error marshaling WebSocket data: %v", err)
+				return
+			}
+			payload = jsonData
+			payloads[key] = payload
+		}
+		messages[client] = payload
+	}
+	
+	select {
+	case wsm.scoped <- messages:
+	default:
+		log.Printf("⚠ WebSocket broadcast channel full, skipping message")
+	}
+}
+
+// sendScopedMessages delivers per-client messages to clients still registered
+func (wsm *WebSocketManager) sendScopedMessages(messages map[*Client][]byte) {
+	wsm.clientsMux.Lock()
+	defer wsm.clientsMux.Unlock()
+	
+	for client, message := range messages {
+		if _, ok := wsm.clients[client]; !ok {
+			continue
+		}
+		select {
+		case client.send <- message:
+		default:
+			delete(wsm.clients, client)
+			close(client.send)
+			log.Printf("⚠ Removed unresponsive WebSocket client %s", client.id)
+		}
+	}
+}
+
 // HandleWebSocket handles WebSocket upgrade requests - FIXED METHOD NAME
-func (wsm *WebSocketManager) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+func (wsm *WebSocketManager) HandleWebSocket(w http.ResponseWriter, r *http.Request, scope tenancy.Scope) {
 	log.Printf("🔌 WebSocket upgrade request from %s", r.RemoteAddr)
 	
 	// Perform WebSocket upgrade
@@ -140,6 +213,12 @@ func (wsm *WebSocketManager) HandleWebSocket(w http.ResponseWriter, r *http.Requ
 	
 	log.Printf("✅ WebSocket connection established with %s", r.RemoteAddr)
 	
+	// Compression applies only if the browser negotiated permessage-deflate
+	if wsm.upgrader.EnableCompression {
+		conn.EnableWriteCompression(true)
+		conn.SetCompressionLevel(wsm.compressionLevel)
+	}
+	
 	// Create new client
 	client := &Client{
 		conn:     conn,
@@ -147,6 +226,7 @@ func (wsm *WebSocketManager) HandleWebSocket(w http.ResponseWriter, r *http.Requ
 		manager:  wsm,
 		lastPing: time.Now(),
 		id:       r.RemoteAddr,
+		scope:    scope,
 	}
 	
 	// Register the client